@@ -41,8 +41,9 @@ var helpText = func(colors logger.Colors) string {
                         bundling, otherwise default is iife when platform
                         is browser and cjs when platform is node)
   --loader:X=L          Use loader L to load file extension X, where L is
-                        one of: js | jsx | ts | tsx | css | json | text |
-                        base64 | file | dataurl | binary
+                        one of: js | jsx | ts | tsx | flow | css |
+                        local-css | html | json | text | base64 | file |
+                        dataurl | binary
   --minify              Minify the output (sets all --minify-* flags)
   --outdir=...          The output directory (for multiple entry points)
   --outfile=...         The output file (for one entry point)
@@ -61,12 +62,19 @@ var helpText = func(colors logger.Colors) string {
                             (use "--analyze=verbose" for a detailed report)
   --asset-names=...         Path template to use for "file" loader files
                             (default "[name]-[hash]")
+  --assume-iterables-are-arrays
+                            Index into arrays directly when lowering array
+                            destructuring instead of using iterators
   --banner:T=...            Text to be prepended to each output file of type T
                             where T is one of: css | js
   --charset=utf8            Do not escape UTF-8 code points
   --chunk-names=...         Path template to use for code splitting chunks
                             (default "[name]-[hash]")
   --color=...               Force use of color terminal escapes (true | false)
+  --css-direction=...       Writing direction used when lowering CSS logical
+                            properties (ltr | rtl, default ltr)
+  --css-rebase-urls         Rewrite relative CSS "url()" references to be
+                            relative to the output file when not bundling
   --entry-names=...         Path template to use for entry point output paths
                             (default "[dir]/[name]", can also use "[hash]")
   --footer:T=...            Text to be appended to each output file of type T
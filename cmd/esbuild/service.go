@@ -679,6 +679,16 @@ func (service *serviceType) convertPlugins(key int, jsPlugins interface{}) ([]ap
 					kind = "import-rule"
 				case api.ResolveCSSURLToken:
 					kind = "url-token"
+				case api.ResolveCSSComposesFrom:
+					kind = "composes-from"
+
+				// HTML
+				case api.ResolveHTMLScript:
+					kind = "html-script"
+				case api.ResolveHTMLStylesheet:
+					kind = "html-stylesheet"
+				case api.ResolveHTMLURL:
+					kind = "html-url"
 
 				default:
 					panic("Internal error")
@@ -694,6 +704,7 @@ func (service *serviceType) convertPlugins(key int, jsPlugins interface{}) ([]ap
 					"resolveDir": args.ResolveDir,
 					"kind":       kind,
 					"pluginData": args.PluginData,
+					"with":       encodeStringMap(args.With),
 				}).(map[string]interface{})
 
 				if value, ok := response["id"]; ok {
@@ -767,6 +778,7 @@ func (service *serviceType) convertPlugins(key int, jsPlugins interface{}) ([]ap
 					"path":       args.Path,
 					"namespace":  args.Namespace,
 					"pluginData": args.PluginData,
+					"with":       encodeStringMap(args.With),
 				}).(map[string]interface{})
 
 				if value, ok := response["id"]; ok {
@@ -940,6 +952,14 @@ func encodeStringArray(strings []string) []interface{} {
 	return values
 }
 
+func encodeStringMap(strings map[string]string) map[string]interface{} {
+	values := make(map[string]interface{}, len(strings))
+	for key, value := range strings {
+		values[key] = value
+	}
+	return values
+}
+
 func decodeStringArray(values []interface{}) []string {
 	strings := make([]string, len(values))
 	for i, value := range values {
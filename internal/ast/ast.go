@@ -32,6 +32,18 @@ const (
 
 	// A CSS "url(...)" token
 	ImportURL
+
+	// A CSS "composes" declaration with a "from" clause
+	ImportComposesFrom
+
+	// An HTML "<script type="module" src="...">" element
+	ImportHTMLScript
+
+	// An HTML "<link rel="stylesheet" href="...">" element
+	ImportHTMLStylesheet
+
+	// An HTML attribute that references an asset, such as "<img src="...">"
+	ImportHTMLURL
 )
 
 func (kind ImportKind) StringForMetafile() string {
@@ -48,6 +60,14 @@ func (kind ImportKind) StringForMetafile() string {
 		return "import-rule"
 	case ImportURL:
 		return "url-token"
+	case ImportComposesFrom:
+		return "composes-from"
+	case ImportHTMLScript:
+		return "html-script"
+	case ImportHTMLStylesheet:
+		return "html-stylesheet"
+	case ImportHTMLURL:
+		return "html-url"
 	case ImportEntryPoint:
 		return "entry-point"
 	default:
@@ -56,13 +76,17 @@ func (kind ImportKind) StringForMetafile() string {
 }
 
 func (kind ImportKind) IsFromCSS() bool {
-	return kind == ImportAt || kind == ImportURL
+	return kind == ImportAt || kind == ImportURL || kind == ImportComposesFrom
+}
+
+func (kind ImportKind) IsFromHTML() bool {
+	return kind == ImportHTMLScript || kind == ImportHTMLStylesheet || kind == ImportHTMLURL
 }
 
 type ImportRecord struct {
-	Range      logger.Range
-	Path       logger.Path
-	Assertions *[]AssertEntry
+	Range        logger.Range
+	Path         logger.Path
+	AssertOrWith *ImportAssertOrWith
 
 	// The resolved source index for an internal import (within the bundle) or
 	// nil for an external import (not included in the bundle)
@@ -110,7 +134,30 @@ type ImportRecord struct {
 	Kind ImportKind
 }
 
-type AssertEntry struct {
+// This is either an import assertion clause ("assert { type: 'json' }") or an
+// import attribute clause ("with { type: 'json' }"). Both forms are treated the
+// same way by the bundler but are printed using the keyword from the source.
+type ImportAssertOrWith struct {
+	Entries    []AssertOrWithEntry
+	KeywordLoc logger.Loc
+	Keyword    AssertOrWithKeyword
+}
+
+type AssertOrWithKeyword uint8
+
+const (
+	AssertKeyword AssertOrWithKeyword = iota
+	WithKeyword
+)
+
+func (kw AssertOrWithKeyword) String() string {
+	if kw == AssertKeyword {
+		return "assert"
+	}
+	return "with"
+}
+
+type AssertOrWithEntry struct {
 	Key             []uint16 // An identifier or a string
 	Value           []uint16 // Always a string
 	KeyLoc          logger.Loc
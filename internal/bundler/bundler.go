@@ -8,6 +8,7 @@ import (
 	"math/rand"
 	"net/http"
 	"sort"
+	"strconv"
 	"strings"
 	"sync"
 	"syscall"
@@ -23,6 +24,7 @@ import (
 	"github.com/evanw/esbuild/internal/fs"
 	"github.com/evanw/esbuild/internal/graph"
 	"github.com/evanw/esbuild/internal/helpers"
+	"github.com/evanw/esbuild/internal/html_parser"
 	"github.com/evanw/esbuild/internal/js_ast"
 	"github.com/evanw/esbuild/internal/js_lexer"
 	"github.com/evanw/esbuild/internal/js_parser"
@@ -98,6 +100,11 @@ type parseResult struct {
 	ok             bool
 }
 
+type resolverCacheKey struct {
+	path             string
+	importAttributes logger.ImportAttributes
+}
+
 type tlaCheck struct {
 	parent            ast.Index32
 	depth             uint32
@@ -160,12 +167,28 @@ func parseFile(args parseArgs) {
 		loader = loaderFromFileExtension(args.options.ExtensionToLoader, base+ext)
 	}
 
+	// Plugins are allowed to give import attributes whatever meaning they want,
+	// so only check import attributes for files that weren't loaded by a plugin
+	if attrs := source.KeyPath.ImportAttributes.DecodeIntoArray(); len(attrs) > 0 && pluginName == "" {
+		tracker := logger.MakeLineColumnTracker(args.importSource)
+		pathWithoutAttrs := source.KeyPath
+		pathWithoutAttrs.ImportAttributes = logger.ImportAttributes{}
+		prettyPath := args.res.PrettyPath(pathWithoutAttrs)
+		isExplicitLoader := loader != loaderFromFileExtension(DefaultExtensionToLoaderMap(), base+ext)
+		var ok bool
+		if loader, ok = applyImportAttributesToLoader(args.log, &tracker, args.importPathRange, prettyPath, loader, isExplicitLoader, attrs); !ok {
+			args.results <- parseResult{}
+			return
+		}
+	}
+
 	result := parseResult{
 		file: scannerFile{
 			inputFile: graph.InputFile{
-				Source:      source,
-				Loader:      loader,
-				SideEffects: args.sideEffects,
+				Source:          source,
+				Loader:          loader,
+				SideEffects:     args.sideEffects,
+				IsolatedModules: args.options.IsolatedModules,
 			},
 			pluginData: pluginData,
 		},
@@ -219,15 +242,34 @@ func parseFile(args parseArgs) {
 		result.file.inputFile.Repr = &graph.JSRepr{AST: ast}
 		result.ok = ok
 
-	case config.LoaderCSS:
+	case config.LoaderFlow:
+		args.options.TS.Parse = true
+		args.options.TS.Flow = true
+		args.options.JSX.Parse = true
+		ast, ok := args.caches.JSCache.Parse(args.log, source, js_parser.OptionsFromConfig(&args.options))
+		if len(ast.Parts) <= 1 { // Ignore the implicitly-generated namespace export part
+			result.file.inputFile.SideEffects.Kind = graph.NoSideEffects_EmptyAST
+		}
+		result.file.inputFile.Repr = &graph.JSRepr{AST: ast}
+		result.ok = ok
+
+	case config.LoaderCSS, config.LoaderLocalCSS:
 		ast := args.caches.CSSCache.Parse(args.log, source, css_parser.Options{
 			MangleSyntax:           args.options.MangleSyntax,
 			RemoveWhitespace:       args.options.RemoveWhitespace,
 			UnsupportedCSSFeatures: args.options.UnsupportedCSSFeatures,
+			MakeLocalSymbols:       loader == config.LoaderLocalCSS,
+			CSSPrefixData:          args.options.CSSPrefixData,
+			RightToLeft:            args.options.CSSRightToLeft,
 		})
 		result.file.inputFile.Repr = &graph.CSSRepr{AST: ast}
 		result.ok = true
 
+	case config.LoaderHTML:
+		ast := html_parser.Parse(args.log, source)
+		result.file.inputFile.Repr = &graph.HTMLRepr{AST: ast}
+		result.ok = true
+
 	case config.LoaderJSON:
 		expr, ok := args.caches.JSONCache.Parse(args.log, source, js_parser.JSONOptions{})
 		ast := js_parser.LazyExportAST(args.log, source, js_parser.OptionsFromConfig(&args.options), expr, "")
@@ -365,7 +407,7 @@ func parseFile(args parseArgs) {
 		result.resolveResults = make([]*resolver.ResolveResult, len(records))
 
 		if len(records) > 0 {
-			resolverCache := make(map[ast.ImportKind]map[string]*resolver.ResolveResult)
+			resolverCache := make(map[ast.ImportKind]map[resolverCacheKey]*resolver.ResolveResult)
 			tracker := logger.MakeLineColumnTracker(&source)
 
 			for importRecordIndex := range records {
@@ -382,12 +424,14 @@ func parseFile(args parseArgs) {
 				}
 
 				// Cache the path in case it's imported multiple times in this file
+				importAttributes := importAttributesFromRecord(record)
+				cacheKey := resolverCacheKey{path: record.Path.Text, importAttributes: importAttributes}
 				cache, ok := resolverCache[record.Kind]
 				if !ok {
-					cache = make(map[string]*resolver.ResolveResult)
+					cache = make(map[resolverCacheKey]*resolver.ResolveResult)
 					resolverCache[record.Kind] = cache
 				}
-				if resolveResult, ok := cache[record.Path.Text]; ok {
+				if resolveResult, ok := cache[cacheKey]; ok {
 					result.resolveResults[importRecordIndex] = resolveResult
 					continue
 				}
@@ -406,8 +450,17 @@ func parseFile(args parseArgs) {
 					record.Kind,
 					absResolveDir,
 					pluginData,
+					importAttributes,
 				)
-				cache[record.Path.Text] = resolveResult
+
+				// Import attributes are part of the path of a file in the bundle since
+				// the same file may be loaded differently depending on its attributes
+				if resolveResult != nil && !resolveResult.IsExternal && importAttributes != (logger.ImportAttributes{}) {
+					clone := *resolveResult
+					clone.PathPair.Primary.ImportAttributes = importAttributes
+					resolveResult = &clone
+				}
+				cache[cacheKey] = resolveResult
 
 				// All "require.resolve()" imports should be external because we don't
 				// want to waste effort traversing into them
@@ -680,12 +733,14 @@ func runOnResolvePlugins(
 	kind ast.ImportKind,
 	absResolveDir string,
 	pluginData interface{},
+	importAttributes logger.ImportAttributes,
 ) (*resolver.ResolveResult, bool, resolver.DebugMeta) {
 	resolverArgs := config.OnResolveArgs{
 		Path:       path,
 		ResolveDir: absResolveDir,
 		Kind:       kind,
 		PluginData: pluginData,
+		With:       importAttributes,
 	}
 	applyPath := logger.Path{
 		Text:      path,
@@ -929,6 +984,64 @@ func runOnLoadPlugins(
 	return loaderPluginResult{loader: config.LoaderNone}, true
 }
 
+func importAttributesFromRecord(record *ast.ImportRecord) logger.ImportAttributes {
+	if record.AssertOrWith == nil {
+		return logger.ImportAttributes{}
+	}
+	attrs := make(map[string]string, len(record.AssertOrWith.Entries))
+	for _, entry := range record.AssertOrWith.Entries {
+		attrs[js_lexer.UTF16ToString(entry.Key)] = js_lexer.UTF16ToString(entry.Value)
+	}
+	return logger.EncodeImportAttributes(attrs)
+}
+
+// Only the "type" attribute is supported. It picks the loader for files that
+// don't otherwise have one, and must agree with the loader for files that do.
+// A "type" attribute overrides the loader that the file extension would
+// normally select (e.g. a ".txt" file imported with "{ type: 'json' }" is
+// parsed as JSON). It's only an error if the loader was explicitly configured
+// to be something else.
+func applyImportAttributesToLoader(
+	log logger.Log,
+	tracker *logger.LineColumnTracker,
+	importPathRange logger.Range,
+	prettyPath string,
+	loader config.Loader,
+	isExplicitLoader bool,
+	attrs []logger.ImportAttribute,
+) (config.Loader, bool) {
+	for _, attr := range attrs {
+		if attr.Key != "type" {
+			log.Add(logger.Error, tracker, importPathRange,
+				fmt.Sprintf("Importing with the %q attribute is not supported", attr.Key))
+			return loader, false
+		}
+
+		var expected config.Loader
+		switch attr.Value {
+		case "json":
+			expected = config.LoaderJSON
+		case "css":
+			expected = config.LoaderCSS
+		default:
+			log.Add(logger.Error, tracker, importPathRange,
+				fmt.Sprintf("Importing with a type attribute of %q is not supported", attr.Value))
+			return loader, false
+		}
+
+		if loader == expected || (expected == config.LoaderCSS && loader.IsCSS()) {
+			continue
+		}
+		if isExplicitLoader {
+			log.Add(logger.Error, tracker, importPathRange,
+				fmt.Sprintf("The file %q was imported with a type attribute of %q but was configured to use a different loader", prettyPath, attr.Value))
+			return loader, false
+		}
+		loader = expected
+	}
+	return loader, true
+}
+
 func loaderFromFileExtension(extensionToLoader map[string]config.Loader, base string) config.Loader {
 	// Pick the loader with the longest matching extension. So if there's an
 	// extension for ".css" and for ".module.css", we want to match the one for
@@ -1118,9 +1231,18 @@ func (s *scanner) maybeParseFile(
 	if resolveResult.UseDefineForClassFieldsTS != config.Unspecified {
 		optionsClone.UseDefineForClassFields = resolveResult.UseDefineForClassFieldsTS
 	}
+	if resolveResult.ExperimentalDecoratorsTS != config.Unspecified {
+		optionsClone.ExperimentalDecorators = resolveResult.ExperimentalDecoratorsTS
+	}
+	if resolveResult.EmitDecoratorMetadataTS != config.Unspecified {
+		optionsClone.EmitDecoratorMetadata = resolveResult.EmitDecoratorMetadataTS
+	}
 	if resolveResult.UnusedImportsTS != config.UnusedImportsRemoveStmt {
 		optionsClone.UnusedImportsTS = resolveResult.UnusedImportsTS
 	}
+	if resolveResult.IsolatedModulesTS {
+		optionsClone.IsolatedModules = true
+	}
 	optionsClone.TSTarget = resolveResult.TSTarget
 
 	// Set the module type preference using node's module type rules
@@ -1154,6 +1276,29 @@ func (s *scanner) maybeParseFile(
 		}
 	}
 
+	// Files with import attributes need a different pretty path than the same
+	// file without them, since they are different modules
+	if attrs := path.ImportAttributes.DecodeIntoArray(); len(attrs) > 0 {
+		var sb strings.Builder
+		sb.WriteString(prettyPath)
+		sb.WriteString(" with {")
+		for i, attr := range attrs {
+			if i > 0 {
+				sb.WriteByte(',')
+			}
+			sb.WriteByte(' ')
+			if js_lexer.IsIdentifier(attr.Key) {
+				sb.WriteString(attr.Key)
+			} else {
+				sb.Write(js_printer.QuoteForJSON(attr.Key, false))
+			}
+			sb.WriteString(": ")
+			sb.Write(js_printer.QuoteForJSON(attr.Value, false))
+		}
+		sb.WriteString(" }")
+		prettyPath = sb.String()
+	}
+
 	var sideEffects graph.SideEffects
 	if resolveResult.PrimarySideEffectsData != nil {
 		sideEffects.Kind = graph.NoSideEffects_PackageJSON
@@ -1381,6 +1526,7 @@ func (s *scanner) addEntryPoints(entryPoints []EntryPoint) []graph.EntryPoint {
 				ast.ImportEntryPoint,
 				entryPointAbsResolveDir,
 				nil,
+				logger.ImportAttributes{},
 			)
 			if resolveResult != nil {
 				if resolveResult.IsExternal {
@@ -1608,10 +1754,132 @@ func (s *scanner) scanAllDependencies() {
 	}
 }
 
+// Each local symbol in a "local-css" file is given a name that is unique
+// across all files in the build. Names are assigned in order of each file's
+// path so that the output is deterministic regardless of the scan order.
+func (s *scanner) assignLocalCSSNames() {
+	var sourceIndices []uint32
+	for sourceIndex, result := range s.results {
+		if result.ok && result.file.inputFile.Loader == config.LoaderLocalCSS {
+			sourceIndices = append(sourceIndices, uint32(sourceIndex))
+		}
+	}
+	sort.Slice(sourceIndices, func(i int, j int) bool {
+		a := s.results[sourceIndices[i]].file.inputFile.Source.KeyPath
+		b := s.results[sourceIndices[j]].file.inputFile.Source.KeyPath
+		return a.Text < b.Text || (a.Text == b.Text && a.Namespace < b.Namespace)
+	})
+
+	usedNames := make(map[string]bool)
+	for _, sourceIndex := range sourceIndices {
+		file := &s.results[sourceIndex].file
+		repr := file.inputFile.Repr.(*graph.CSSRepr)
+		_, base, _ := logger.PlatformIndependentPathDirBaseExt(file.inputFile.Source.KeyPath.Text)
+		prefix := js_lexer.ForceValidIdentifier(base) + "_"
+		repr.LocalNames = make(map[string]string, len(repr.AST.LocalSymbols))
+		for _, symbol := range repr.AST.LocalSymbols {
+			name := prefix + symbol.Name
+			for i := 2; usedNames[name]; i++ {
+				name = prefix + symbol.Name + strconv.Itoa(i)
+			}
+			usedNames[name] = true
+			repr.LocalNames[symbol.Name] = name
+		}
+	}
+
+	// Now that every file has names, check names composed from other files
+	for _, sourceIndex := range sourceIndices {
+		file := &s.results[sourceIndex].file
+		repr := file.inputFile.Repr.(*graph.CSSRepr)
+		tracker := logger.MakeLineColumnTracker(&file.inputFile.Source)
+		for _, symbol := range repr.AST.LocalSymbols {
+			for _, name := range symbol.Composes {
+				if !name.ImportRecordIndex.IsValid() {
+					continue
+				}
+				record := &repr.AST.ImportRecords[name.ImportRecordIndex.GetIndex()]
+				if !record.SourceIndex.IsValid() {
+					continue
+				}
+				otherFile := &s.results[record.SourceIndex.GetIndex()].file
+				if otherRepr, ok := otherFile.inputFile.Repr.(*graph.CSSRepr); ok && otherFile.inputFile.Loader == config.LoaderLocalCSS {
+					if _, ok := otherRepr.LocalNames[name.Name]; !ok {
+						s.log.Add(logger.Error, &tracker, logger.Range{Loc: name.Loc, Len: int32(len(name.Name))},
+							fmt.Sprintf("The name %q never appears in %q", name.Name, otherFile.inputFile.Source.PrettyPath))
+					}
+				}
+			}
+		}
+	}
+}
+
+type localCSSClassKey struct {
+	sourceIndex ast.Index32 // This is invalid for global names
+	name        string
+}
+
+// This returns the class names that the JavaScript stub exports for a local
+// symbol. That's the symbol's own name followed by all names it composes.
+func (s *scanner) localCSSClassNames(sourceIndex uint32, name string, classes []string, visited map[localCSSClassKey]bool) []string {
+	key := localCSSClassKey{sourceIndex: ast.MakeIndex32(sourceIndex), name: name}
+	if visited[key] {
+		return classes
+	}
+	visited[key] = true
+
+	repr, ok := s.results[sourceIndex].file.inputFile.Repr.(*graph.CSSRepr)
+	if !ok {
+		return classes
+	}
+	localName, ok := repr.LocalNames[name]
+	if !ok {
+		return classes
+	}
+	classes = append(classes, localName)
+
+	for _, symbol := range repr.AST.LocalSymbols {
+		if symbol.Name != name {
+			continue
+		}
+		for _, composes := range symbol.Composes {
+			if composes.IsGlobal {
+				if key := (localCSSClassKey{name: composes.Name}); !visited[key] {
+					visited[key] = true
+					classes = append(classes, composes.Name)
+				}
+			} else if !composes.ImportRecordIndex.IsValid() {
+				classes = s.localCSSClassNames(sourceIndex, composes.Name, classes, visited)
+			} else if record := &repr.AST.ImportRecords[composes.ImportRecordIndex.GetIndex()]; record.SourceIndex.IsValid() {
+				classes = s.localCSSClassNames(record.SourceIndex.GetIndex(), composes.Name, classes, visited)
+			}
+		}
+		break
+	}
+	return classes
+}
+
+// The JavaScript stub for a "local-css" file exports an object that maps each
+// original local name to the space-separated list of its final class names.
+func (s *scanner) localCSSExports(sourceIndex uint32) js_ast.Expr {
+	repr := s.results[sourceIndex].file.inputFile.Repr.(*graph.CSSRepr)
+	properties := make([]js_ast.Property, 0, len(repr.AST.LocalSymbols))
+	for _, symbol := range repr.AST.LocalSymbols {
+		classes := s.localCSSClassNames(sourceIndex, symbol.Name, nil, make(map[localCSSClassKey]bool))
+		properties = append(properties, js_ast.Property{
+			Key:        js_ast.Expr{Loc: symbol.Loc, Data: &js_ast.EString{Value: js_lexer.StringToUTF16(symbol.Name)}},
+			ValueOrNil: js_ast.Expr{Loc: symbol.Loc, Data: &js_ast.EString{Value: js_lexer.StringToUTF16(strings.Join(classes, " "))}},
+		})
+	}
+	return js_ast.Expr{Data: &js_ast.EObject{Properties: properties}}
+}
+
 func (s *scanner) processScannedFiles() []scannerFile {
 	s.timer.Begin("Process scanned files")
 	defer s.timer.End("Process scanned files")
 
+	// Local names must be known before generating JavaScript stubs for CSS files
+	s.assignLocalCSSNames()
+
 	// Now that all files have been scanned, process the final file import records
 	for i, result := range s.results {
 		if !result.ok {
@@ -1673,21 +1941,31 @@ func (s *scanner) processScannedFiles() []scannerFile {
 						js_printer.QuoteForJSON(record.Kind.StringForMetafile(), s.options.ASCIIOnly)))
 				}
 
+				// HTML files can only be used as entry points
+				otherFile := &s.results[record.SourceIndex.GetIndex()].file
+				if _, ok := otherFile.inputFile.Repr.(*graph.HTMLRepr); ok {
+					s.log.Add(logger.Error, &tracker, record.Range,
+						fmt.Sprintf("Cannot import %q because HTML files can only be used as entry points", otherFile.inputFile.Source.PrettyPath))
+					continue
+				}
+
 				switch record.Kind {
 				case ast.ImportAt, ast.ImportAtConditional:
 					// Using a JavaScript file with CSS "@import" is not allowed
-					otherFile := &s.results[record.SourceIndex.GetIndex()].file
 					if _, ok := otherFile.inputFile.Repr.(*graph.JSRepr); ok {
 						s.log.Add(logger.Error, &tracker, record.Range,
 							fmt.Sprintf("Cannot import %q into a CSS file", otherFile.inputFile.Source.PrettyPath))
-					} else if record.Kind == ast.ImportAtConditional {
+					}
+
+				case ast.ImportComposesFrom:
+					// Names can only be composed from other "local-css" files
+					if otherFile.inputFile.Loader != config.LoaderLocalCSS {
 						s.log.Add(logger.Error, &tracker, record.Range,
-							"Bundling with conditional \"@import\" rules is not currently supported")
+							fmt.Sprintf("Cannot use \"composes\" with %q because it's not a \"local-css\" file", otherFile.inputFile.Source.PrettyPath))
 					}
 
 				case ast.ImportURL:
 					// Using a JavaScript or CSS file with CSS "url()" is not allowed
-					otherFile := &s.results[record.SourceIndex.GetIndex()].file
 					switch otherRepr := otherFile.inputFile.Repr.(type) {
 					case *graph.CSSRepr:
 						s.log.Add(logger.Error, &tracker, record.Range,
@@ -1699,13 +1977,37 @@ func (s *scanner) processScannedFiles() []scannerFile {
 								fmt.Sprintf("Cannot use %q as a URL", otherFile.inputFile.Source.PrettyPath))
 						}
 					}
+
+				case ast.ImportHTMLScript, ast.ImportHTMLStylesheet:
+					// Scripts must be JavaScript files and stylesheets must be CSS files
+					if record.Kind == ast.ImportHTMLScript {
+						if _, ok := otherFile.inputFile.Repr.(*graph.JSRepr); !ok {
+							s.log.Add(logger.Error, &tracker, record.Range,
+								fmt.Sprintf("Cannot use %q as a script", otherFile.inputFile.Source.PrettyPath))
+						}
+					} else if _, ok := otherFile.inputFile.Repr.(*graph.CSSRepr); !ok {
+						s.log.Add(logger.Error, &tracker, record.Range,
+							fmt.Sprintf("Cannot use %q as a stylesheet", otherFile.inputFile.Source.PrettyPath))
+					}
+
+					// These become separate output files, so they need somewhere to go
+					if s.options.WriteToStdout {
+						s.log.Add(logger.Error, &tracker, record.Range,
+							fmt.Sprintf("Cannot reference %q from an HTML file without an output path configured", otherFile.inputFile.Source.PrettyPath))
+					}
+
+				case ast.ImportHTMLURL:
+					// Assets referenced from HTML follow the same rules as CSS "url()"
+					if otherRepr, ok := otherFile.inputFile.Repr.(*graph.JSRepr); !ok || otherRepr.AST.URLForCSS == "" {
+						s.log.Add(logger.Error, &tracker, record.Range,
+							fmt.Sprintf("Cannot use %q as a URL", otherFile.inputFile.Source.PrettyPath))
+					}
 				}
 
 				// If an import from a JavaScript file targets a CSS file, generate a
 				// JavaScript stub to ensure that JavaScript files only ever import
 				// other JavaScript files.
 				if _, ok := result.file.inputFile.Repr.(*graph.JSRepr); ok {
-					otherFile := &s.results[record.SourceIndex.GetIndex()].file
 					if css, ok := otherFile.inputFile.Repr.(*graph.CSSRepr); ok {
 						if s.options.WriteToStdout {
 							s.log.Add(logger.Error, &tracker, record.Range,
@@ -1720,13 +2022,17 @@ func (s *scanner) processScannedFiles() []scannerFile {
 								Index:      sourceIndex,
 								PrettyPath: otherFile.inputFile.Source.PrettyPath,
 							}
+							exports := js_ast.Expr{Data: &js_ast.EObject{}}
+							if otherFile.inputFile.Loader == config.LoaderLocalCSS {
+								exports = s.localCSSExports(record.SourceIndex.GetIndex())
+							}
 							s.results[sourceIndex] = parseResult{
 								file: scannerFile{
 									inputFile: graph.InputFile{
 										Source: source,
 										Repr: &graph.JSRepr{
 											AST: js_parser.LazyExportAST(s.log, source,
-												js_parser.OptionsFromConfig(&s.options), js_ast.Expr{Data: &js_ast.EObject{}}, ""),
+												js_parser.OptionsFromConfig(&s.options), exports, ""),
 											CSSSourceIndex: ast.MakeIndex32(record.SourceIndex.GetIndex()),
 										},
 									},
@@ -1965,8 +2271,12 @@ func DefaultExtensionToLoaderMap() map[string]config.Loader {
 		".mts":  config.LoaderTSNoAmbiguousLessThan,
 		".tsx":  config.LoaderTSX,
 		".css":  config.LoaderCSS,
+		".html": config.LoaderHTML,
 		".json": config.LoaderJSON,
 		".txt":  config.LoaderText,
+
+		// CSS modules: https://github.com/css-modules/css-modules
+		".module.css": config.LoaderLocalCSS,
 	}
 }
 
@@ -592,11 +592,169 @@ func TestCSSAtImportConditionsBundle(t *testing.T) {
 			Mode:          config.ModeBundle,
 			AbsOutputFile: "/out.css",
 		},
-		expectedScanLog: `entry.css: ERROR: Bundling with conditional "@import" rules is not currently supported
+	})
+}
+
+func TestCSSAtImportConditionsBundleLayerSupportsMedia(t *testing.T) {
+	css_suite.expectBundled(t, bundled{
+		files: map[string]string{
+			"/entry.css": `
+				@import "./a.css" layer;
+				@import "./b.css" layer(base) supports(display: grid) screen and (min-width: 100px);
+				@import "./c.css" supports(not (display: grid));
+				body { color: black }
+			`,
+			"/a.css": `a { color: red }`,
+			"/b.css": `b { color: green }`,
+			"/c.css": `c { color: blue }`,
+		},
+		entryPaths: []string{"/entry.css"},
+		options: config.Options{
+			Mode:          config.ModeBundle,
+			AbsOutputFile: "/out.css",
+		},
+	})
+}
+
+func TestCSSAtImportConditionsBundleInvalidLayer(t *testing.T) {
+	css_suite.expectBundled(t, bundled{
+		files: map[string]string{
+			"/entry.css": `
+				@import "./a.css" layer(a b);
+				@import "./b.css" layer(a . b) print;
+			`,
+			"/a.css": `a { color: red }`,
+			"/b.css": `b { color: green }`,
+		},
+		entryPaths: []string{"/entry.css"},
+		options: config.Options{
+			Mode:          config.ModeBundle,
+			AbsOutputFile: "/out.css",
+		},
+		expectedScanLog: `entry.css: WARNING: Expected a layer name such as "a.b" inside "layer()"
+entry.css: WARNING: Expected a layer name such as "a.b" inside "layer()"
+`,
+	})
+}
+
+func TestCSSAtImportConditionsBundleNested(t *testing.T) {
+	css_suite.expectBundled(t, bundled{
+		files: map[string]string{
+			"/entry.css": `@import "./outer.css" print;`,
+			"/outer.css": `
+				@import "./inner.css" (min-width: 100px);
+				.outer { color: red }
+			`,
+			"/inner.css": `.inner { color: green }`,
+		},
+		entryPaths: []string{"/entry.css"},
+		options: config.Options{
+			Mode:          config.ModeBundle,
+			AbsOutputFile: "/out.css",
+		},
+	})
+}
+
+func TestCSSAtImportConditionsBundleDuplicates(t *testing.T) {
+	css_suite.expectBundled(t, bundled{
+		files: map[string]string{
+			"/entry.css": `
+				@import "./shared.css" print;
+				@import "./shared.css" screen;
+				@import "./shared.css" print;
+				@import "./shared.css";
+			`,
+			"/shared.css": `.shared { color: red }`,
+		},
+		entryPaths: []string{"/entry.css"},
+		options: config.Options{
+			Mode:          config.ModeBundle,
+			AbsOutputFile: "/out.css",
+		},
+	})
+}
+
+func TestCSSAtImportConditionsBundleCycle(t *testing.T) {
+	css_suite.expectBundled(t, bundled{
+		files: map[string]string{
+			"/entry.css": `@import "./a.css" print;`,
+			"/a.css": `
+				@import "./b.css" screen;
+				.a { color: red }
+			`,
+			"/b.css": `
+				@import "./a.css" print;
+				.b { color: green }
+			`,
+		},
+		entryPaths: []string{"/entry.css"},
+		options: config.Options{
+			Mode:          config.ModeBundle,
+			AbsOutputFile: "/out.css",
+		},
+	})
+}
+
+func TestCSSAtImportConditionsBundleExternalNested(t *testing.T) {
+	css_suite.expectBundled(t, bundled{
+		files: map[string]string{
+			"/entry.css": `
+				@import "./print.css" print;
+				@import "https://example.com/screen.css" screen;
+			`,
+			"/print.css": `
+				@import "https://example.com/print.css";
+				.print { color: red }
+			`,
+		},
+		entryPaths: []string{"/entry.css"},
+		options: config.Options{
+			Mode:          config.ModeBundle,
+			AbsOutputFile: "/out.css",
+		},
+	})
+}
+
+func TestCSSAtImportConditionsBundleExternalNestedConditional(t *testing.T) {
+	css_suite.expectBundled(t, bundled{
+		files: map[string]string{
+			"/entry.css": `@import "./both.css" print;`,
+			"/both.css":  `@import "https://example.com/both.css" screen;`,
+		},
+		entryPaths: []string{"/entry.css"},
+		options: config.Options{
+			Mode:          config.ModeBundle,
+			AbsOutputFile: "/out.css",
+		},
+		expectedCompileLog: `both.css: ERROR: Bundling an external "@import" rule with multiple levels of conditions is not currently supported
 `,
 	})
 }
 
+func TestCSSAtLayerBeforeImportBundle(t *testing.T) {
+	css_suite.expectBundled(t, bundled{
+		files: map[string]string{
+			"/entry.css": `
+				@layer base, theme;
+				@import "./theme.css" layer(theme);
+				@import "./base.css" layer(base);
+				@layer utilities;
+				body { color: black }
+			`,
+			"/theme.css": `
+				@layer components;
+				a { color: red }
+			`,
+			"/base.css": `a { color: blue }`,
+		},
+		entryPaths: []string{"/entry.css"},
+		options: config.Options{
+			Mode:          config.ModeBundle,
+			AbsOutputFile: "/out.css",
+		},
+	})
+}
+
 // This test mainly just makes sure that this scenario doesn't crash
 func TestCSSAndJavaScriptCodeSplittingIssue1064(t *testing.T) {
 	css_suite.expectBundled(t, bundled{
@@ -686,3 +844,152 @@ func TestCSSExternalQueryAndHashMatchIssue1822(t *testing.T) {
 		},
 	})
 }
+
+func TestCSSLocalModules(t *testing.T) {
+	css_suite.expectBundled(t, bundled{
+		files: map[string]string{
+			"/entry.js": `
+				import styles from './button.module.css'
+				import { primary } from './button.module.css'
+				console.log(styles, primary)
+			`,
+			"/button.module.css": `
+				.button { composes: base from './base.module.css'; color: black }
+				.primary { composes: button; composes: shared from global; animation: pulse 1s infinite }
+				.primary:not(.disabled):hover { color: blue }
+				:global(.dark) .primary { color: white }
+				:global .legacy .old, #main { color: gray }
+				@keyframes pulse { from { opacity: 1 } to { opacity: 0.5 } }
+			`,
+			"/base.module.css": `
+				.base { padding: 0 }
+			`,
+		},
+		entryPaths: []string{"/entry.js"},
+		options: config.Options{
+			Mode:         config.ModeBundle,
+			AbsOutputDir: "/out",
+		},
+	})
+}
+
+func TestCSSLocalModulesNameCollisions(t *testing.T) {
+	css_suite.expectBundled(t, bundled{
+		files: map[string]string{
+			"/entry.js": `
+				import a from './a/styles.module.css'
+				import b from './b/styles.module.css'
+				console.log(a.title, b.title)
+			`,
+			"/a/styles.module.css": `.title { color: red }`,
+			"/b/styles.module.css": `.title { color: blue }`,
+		},
+		entryPaths: []string{"/entry.js"},
+		options: config.Options{
+			Mode:         config.ModeBundle,
+			AbsOutputDir: "/out",
+		},
+	})
+}
+
+func TestCSSLocalModulesEntryPoint(t *testing.T) {
+	css_suite.expectBundled(t, bundled{
+		files: map[string]string{
+			"/entry.css": `
+				.a { color: red }
+				:global(.b) { color: blue }
+			`,
+		},
+		entryPaths: []string{"/entry.css"},
+		options: config.Options{
+			Mode:          config.ModeBundle,
+			AbsOutputFile: "/out.css",
+			ExtensionToLoader: map[string]config.Loader{
+				".css": config.LoaderLocalCSS,
+			},
+		},
+	})
+}
+
+func TestCSSLocalModulesComposesErrors(t *testing.T) {
+	css_suite.expectBundled(t, bundled{
+		files: map[string]string{
+			"/entry.js": `
+				import styles from './entry.module.css'
+				console.log(styles)
+			`,
+			"/entry.module.css": `
+				.a { composes: b from './global.css' }
+				.c { composes: d from './other.js' }
+				.e { composes: missing from './other.module.css' }
+			`,
+			"/global.css":       `.b { color: red }`,
+			"/other.js":         `export let d = 'd'`,
+			"/other.module.css": `.f { color: blue }`,
+		},
+		entryPaths: []string{"/entry.js"},
+		options: config.Options{
+			Mode:         config.ModeBundle,
+			AbsOutputDir: "/out",
+		},
+		expectedScanLog: `entry.module.css: ERROR: Cannot use "composes" with "global.css" because it's not a "local-css" file
+entry.module.css: ERROR: Cannot use "composes" with "other.js" because it's not a "local-css" file
+entry.module.css: ERROR: The name "missing" never appears in "other.module.css"
+`,
+	})
+}
+
+func TestCSSRebaseURLsOutdir(t *testing.T) {
+	css_suite.expectBundled(t, bundled{
+		files: map[string]string{
+			"/src/css/entry.css": `
+				a { background: url(../img/a.png) }
+				b { background: url("./b.png?v=1#x") }
+				c { background: url(https://example.com/c.png) }
+				d { background: url(/d.png) }
+				e { background: url(data:image/png;base64,AAAA) }
+				f { filter: url(#f) }
+			`,
+		},
+		entryPaths: []string{"/src/css/entry.css"},
+		options: config.Options{
+			Mode:          config.ModePassThrough,
+			AbsOutputDir:  "/out",
+			AbsOutputBase: "/src",
+			CSSRebaseURLs: true,
+		},
+	})
+}
+
+func TestCSSRebaseURLsOutfile(t *testing.T) {
+	css_suite.expectBundled(t, bundled{
+		files: map[string]string{
+			"/src/css/entry.css": `
+				a { background: url(../img/a.png) }
+				b { background: url(b.png) }
+			`,
+		},
+		entryPaths: []string{"/src/css/entry.css"},
+		options: config.Options{
+			Mode:          config.ModePassThrough,
+			AbsOutputFile: "/out/styles/out.css",
+			CSSRebaseURLs: true,
+		},
+	})
+}
+
+func TestCSSRebaseURLsDisabled(t *testing.T) {
+	css_suite.expectBundled(t, bundled{
+		files: map[string]string{
+			"/src/css/entry.css": `
+				a { background: url(../img/a.png) }
+			`,
+		},
+		entryPaths: []string{"/src/css/entry.css"},
+		options: config.Options{
+			Mode:          config.ModePassThrough,
+			AbsOutputDir:  "/out",
+			AbsOutputBase: "/src",
+		},
+	})
+}
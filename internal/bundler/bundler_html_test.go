@@ -0,0 +1,229 @@
+package bundler
+
+import (
+	"testing"
+
+	"github.com/evanw/esbuild/internal/config"
+)
+
+var html_suite = suite{
+	name: "html",
+}
+
+func TestHTMLEntryPoint(t *testing.T) {
+	html_suite.expectBundled(t, bundled{
+		files: map[string]string{
+			"/src/index.html": `<!DOCTYPE html>
+<html>
+  <head>
+    <link rel="stylesheet" href="./style.css">
+    <link rel="icon" href="favicon.png">
+    <script type="module" src="./entry.js"></script>
+    <script src="./classic.js"></script>
+    <script type="module" src="https://example.com/external.js"></script>
+  </head>
+  <body>
+    <!-- <img src="./commented-out.png"> -->
+    <img src='./logo.png' alt="logo">
+    <img src="/root-relative.png">
+    <script>document.write('<img src="./inline.png">')</script>
+  </body>
+</html>
+`,
+			"/src/style.css": `
+				@import "./reset.css";
+				body { background: url(./logo.png) }
+			`,
+			"/src/reset.css": `
+				* { margin: 0 }
+			`,
+			"/src/entry.js": `
+				import './entry.css'
+				console.log('entry')
+			`,
+			"/src/entry.css": `
+				.entry { color: red }
+			`,
+			"/src/favicon.png": "favicon",
+			"/src/logo.png":    "logo",
+		},
+		entryPaths: []string{"/src/index.html"},
+		options: config.Options{
+			Mode:          config.ModeBundle,
+			AbsOutputDir:  "/out",
+			AbsOutputBase: "/src",
+			ExtensionToLoader: map[string]config.Loader{
+				".html": config.LoaderHTML,
+				".js":   config.LoaderJS,
+				".css":  config.LoaderCSS,
+				".png":  config.LoaderFile,
+			},
+		},
+	})
+}
+
+func TestHTMLEntryPointWithHashes(t *testing.T) {
+	html_suite.expectBundled(t, bundled{
+		files: map[string]string{
+			"/src/index.html": `
+				<link rel=stylesheet href=style.css>
+				<script type=module src=entry.js></script>
+			`,
+			"/src/style.css": `
+				body { color: black }
+			`,
+			"/src/entry.js": `
+				console.log('entry')
+			`,
+		},
+		entryPaths: []string{"/src/index.html"},
+		options: config.Options{
+			Mode:         config.ModeBundle,
+			AbsOutputDir: "/out",
+			EntryPathTemplate: []config.PathTemplate{
+				{Data: "./", Placeholder: config.NamePlaceholder},
+				{Data: "-", Placeholder: config.HashPlaceholder},
+			},
+		},
+	})
+}
+
+func TestHTMLEntryPointOutfile(t *testing.T) {
+	html_suite.expectBundled(t, bundled{
+		files: map[string]string{
+			"/src/index.html": `<script type="module" src="./entry.js"></script>`,
+			"/src/entry.js":   `console.log('entry')`,
+		},
+		entryPaths: []string{"/src/index.html"},
+		options: config.Options{
+			Mode:          config.ModeBundle,
+			AbsOutputFile: "/out/page.html",
+			AbsOutputBase: "/src",
+		},
+	})
+}
+
+func TestHTMLSharedCodeESM(t *testing.T) {
+	html_suite.expectBundled(t, bundled{
+		files: map[string]string{
+			"/index.html": `
+				<script type="module" src="./a.js"></script>
+				<script type="module" src="./b.js"></script>
+			`,
+			"/a.js":      `import {shared} from './shared.js'; console.log('a', shared)`,
+			"/b.js":      `import {shared} from './shared.js'; console.log('b', shared)`,
+			"/shared.js": `export let shared = 123`,
+		},
+		entryPaths: []string{"/index.html"},
+		options: config.Options{
+			Mode:          config.ModeBundle,
+			OutputFormat:  config.FormatESModule,
+			AbsOutputDir:  "/out",
+			CodeSplitting: true,
+		},
+	})
+}
+
+// Scripts in HTML files share code through a separate chunk even when code
+// splitting is disabled, which is allowed with the "esm" format
+func TestHTMLSharedCodeESMWithoutCodeSplitting(t *testing.T) {
+	html_suite.expectBundled(t, bundled{
+		files: map[string]string{
+			"/index.html": `
+				<script type="module" src="./a.js"></script>
+				<script type="module" src="./b.js"></script>
+			`,
+			"/a.js":      `import {shared} from './shared.js'; console.log('a', shared)`,
+			"/b.js":      `import {shared} from './shared.js'; console.log('b', shared)`,
+			"/shared.js": `export let shared = 123`,
+		},
+		entryPaths: []string{"/index.html"},
+		options: config.Options{
+			Mode:         config.ModeBundle,
+			OutputFormat: config.FormatESModule,
+			AbsOutputDir: "/out",
+		},
+	})
+}
+
+func TestHTMLSharedCodeIIFE(t *testing.T) {
+	html_suite.expectBundled(t, bundled{
+		files: map[string]string{
+			"/index.html": `
+				<script type="module" src="./a.js"></script>
+				<script type="module" src="./b.js"></script>
+			`,
+			"/a.js":      `import {shared} from './shared.js'; console.log('a', shared)`,
+			"/b.js":      `import {shared} from './shared.js'; console.log('b', shared)`,
+			"/shared.js": `export let shared = 123`,
+		},
+		entryPaths: []string{"/index.html"},
+		options: config.Options{
+			Mode:         config.ModeBundle,
+			OutputFormat: config.FormatIIFE,
+			AbsOutputDir: "/out",
+		},
+		expectedCompileLog: `ERROR: Sharing code between multiple scripts in an HTML file requires the "esm" output format
+`,
+	})
+}
+
+func TestHTMLWrongReferenceTypes(t *testing.T) {
+	html_suite.expectBundled(t, bundled{
+		files: map[string]string{
+			"/index.html": `
+				<script type="module" src="./style.css"></script>
+				<link rel="stylesheet" href="./entry.js">
+				<img src="./entry.js">
+			`,
+			"/style.css": `body { color: red }`,
+			"/entry.js":  `console.log('entry')`,
+		},
+		entryPaths: []string{"/index.html"},
+		options: config.Options{
+			Mode:         config.ModeBundle,
+			AbsOutputDir: "/out",
+		},
+		expectedScanLog: `index.html: ERROR: Cannot use "style.css" as a script
+index.html: ERROR: Cannot use "entry.js" as a stylesheet
+index.html: ERROR: Cannot use "entry.js" as a URL
+`,
+	})
+}
+
+func TestHTMLImportFromJSAndCSS(t *testing.T) {
+	html_suite.expectBundled(t, bundled{
+		files: map[string]string{
+			"/entry.js":   `import './page.html'`,
+			"/entry.css":  `@import "./page.html"; a { background: url(./page.html) }`,
+			"/page.html":  `<p>page</p>`,
+			"/index.html": `<img src="./page.html">`,
+		},
+		entryPaths: []string{"/entry.js", "/entry.css", "/index.html"},
+		options: config.Options{
+			Mode:         config.ModeBundle,
+			AbsOutputDir: "/out",
+		},
+		expectedScanLog: `entry.css: ERROR: Cannot import "page.html" because HTML files can only be used as entry points
+entry.css: ERROR: Cannot import "page.html" because HTML files can only be used as entry points
+entry.js: ERROR: Cannot import "page.html" because HTML files can only be used as entry points
+index.html: ERROR: Cannot import "page.html" because HTML files can only be used as entry points
+`,
+	})
+}
+
+func TestHTMLWriteToStdout(t *testing.T) {
+	html_suite.expectBundled(t, bundled{
+		files: map[string]string{
+			"/index.html": `<script type="module" src="./entry.js"></script>`,
+			"/entry.js":   `console.log('entry')`,
+		},
+		entryPaths: []string{"/index.html"},
+		options: config.Options{
+			Mode:          config.ModeBundle,
+			WriteToStdout: true,
+		},
+		expectedScanLog: `index.html: ERROR: Cannot reference "entry.js" from an HTML file without an output path configured
+`,
+	})
+}
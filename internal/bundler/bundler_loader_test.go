@@ -75,6 +75,64 @@ func TestJSXSyntaxInJSWithJSXLoader(t *testing.T) {
 	})
 }
 
+func TestLoaderFlow(t *testing.T) {
+	loader_suite.expectBundled(t, bundled{
+		files: map[string]string{
+			"/entry.js": `
+				import type { Props } from './types'
+				import { type Node, render } from './render'
+				export opaque type ID: string = string
+				function App(props: Props): Node {
+					return <div id={(props.id: any)}>{props.name}</div>
+				}
+				render(<App name="test" />)
+			`,
+			"/types.js": `
+				export type Props = {| +id: ?ID, name: string |}
+			`,
+			"/render.js": `
+				export type Node = mixed
+				export function render<T: Node>(node: T): void {
+					console.log(node)
+				}
+			`,
+		},
+		entryPaths: []string{"/entry.js"},
+		options: config.Options{
+			Mode:          config.ModeBundle,
+			AbsOutputFile: "/out.js",
+			ExtensionToLoader: map[string]config.Loader{
+				".js": config.LoaderFlow,
+			},
+		},
+	})
+}
+
+func TestLoaderFlowPragma(t *testing.T) {
+	loader_suite.expectBundled(t, bundled{
+		files: map[string]string{
+			"/entry.js": `
+				// @flow
+				import { type Foo, foo } from './foo'
+				let x: Foo = foo((1: any))
+				console.log(x)
+			`,
+			"/foo.js": `
+				/* @flow strict */
+				export type Foo = number
+				export function foo(x: number): Foo {
+					return x
+				}
+			`,
+		},
+		entryPaths: []string{"/entry.js"},
+		options: config.Options{
+			Mode:          config.ModeBundle,
+			AbsOutputFile: "/out.js",
+		},
+	})
+}
+
 func TestJSXPreserveCapitalLetter(t *testing.T) {
 	loader_suite.expectBundled(t, bundled{
 		files: map[string]string{
@@ -959,3 +1017,126 @@ func TestLoaderDataURLUnknownMIME(t *testing.T) {
 		},
 	})
 }
+
+func TestLoaderImportAttributesJSON(t *testing.T) {
+	loader_suite.expectBundled(t, bundled{
+		files: map[string]string{
+			"/entry.js": `
+				import a from './data.json' with { type: 'json' }
+				import b from './data.dat' with { type: 'json' }
+				import c from './data.json' assert { type: 'json' }
+				console.log(a, b, c, import('./data.dat', { with: { type: 'json' } }))
+			`,
+			"/data.json": `{ "a": 1 }`,
+			"/data.dat":  `{ "b": 2 }`,
+		},
+		entryPaths: []string{"/entry.js"},
+		options: config.Options{
+			Mode:          config.ModeBundle,
+			AbsOutputFile: "/out.js",
+		},
+	})
+}
+
+func TestLoaderImportAttributesCSS(t *testing.T) {
+	loader_suite.expectBundled(t, bundled{
+		files: map[string]string{
+			"/entry.js": `
+				import './a.css' with { type: 'css' }
+				import './b.module.css' with { type: 'css' }
+			`,
+			"/a.css":        `a { color: red }`,
+			"/b.module.css": `.b { color: blue }`,
+		},
+		entryPaths: []string{"/entry.js"},
+		options: config.Options{
+			Mode:         config.ModeBundle,
+			AbsOutputDir: "/out",
+			ExtensionToLoader: map[string]config.Loader{
+				".js":         config.LoaderJS,
+				".css":        config.LoaderCSS,
+				".module.css": config.LoaderLocalCSS,
+			},
+		},
+	})
+}
+
+func TestLoaderImportAttributesOverrideExtension(t *testing.T) {
+	loader_suite.expectBundled(t, bundled{
+		files: map[string]string{
+			"/entry.js": `
+				import a from './data.txt' with { type: 'json' }
+				import b from './data.js' with { type: 'json' }
+				import './style.txt' with { type: 'css' }
+				console.log(a, b)
+			`,
+			"/data.txt":  `{ "a": 1 }`,
+			"/data.js":   `{ "b": 2 }`,
+			"/style.txt": `a { color: red }`,
+		},
+		entryPaths: []string{"/entry.js"},
+		options: config.Options{
+			Mode:         config.ModeBundle,
+			AbsOutputDir: "/out",
+		},
+	})
+}
+
+func TestLoaderImportAttributesErrors(t *testing.T) {
+	loader_suite.expectBundled(t, bundled{
+		files: map[string]string{
+			"/entry.js": `
+				import a from './a.dat' with { type: 'json' }
+				import b from './b.cfg' with { type: 'css' }
+				import c from './c.json' with { type: 'xml' }
+				import d from './d.json' with { type: 'json', lazy: 'true' }
+				console.log(a, b, c, d)
+			`,
+			"/a.dat":  `{}`,
+			"/b.cfg":  `{}`,
+			"/c.json": `{}`,
+			"/d.json": `{}`,
+		},
+		entryPaths: []string{"/entry.js"},
+		options: config.Options{
+			Mode:          config.ModeBundle,
+			AbsOutputFile: "/out.js",
+			ExtensionToLoader: map[string]config.Loader{
+				".js":   config.LoaderJS,
+				".json": config.LoaderJSON,
+				".dat":  config.LoaderFile,
+				".cfg":  config.LoaderText,
+			},
+		},
+		expectedScanLog: `entry.js: ERROR: The file "a.dat" was imported with a type attribute of "json" but was configured to use a different loader
+entry.js: ERROR: The file "b.cfg" was imported with a type attribute of "css" but was configured to use a different loader
+entry.js: ERROR: Importing with a type attribute of "xml" is not supported
+entry.js: ERROR: Importing with the "lazy" attribute is not supported
+`,
+	})
+}
+
+func TestLoaderImportAttributesExternal(t *testing.T) {
+	loader_suite.expectBundled(t, bundled{
+		files: map[string]string{
+			"/entry.js": `
+				import a from 'ext' with { type: 'json' }
+				export { default as b } from 'ext2' assert { type: 'json' }
+				console.log(a, import('ext3', { with: { type: 'json' } }))
+			`,
+		},
+		entryPaths: []string{"/entry.js"},
+		options: config.Options{
+			Mode:          config.ModeBundle,
+			OutputFormat:  config.FormatESModule,
+			AbsOutputFile: "/out.js",
+			ExternalModules: config.ExternalModules{
+				NodeModules: map[string]bool{
+					"ext":  true,
+					"ext2": true,
+					"ext3": true,
+				},
+			},
+		},
+	})
+}
@@ -843,6 +843,40 @@ func TestLowerAsync2017NoBundle(t *testing.T) {
 	})
 }
 
+func TestLowerAsyncGenerator2017NoBundle(t *testing.T) {
+	lower_suite.expectBundled(t, bundled{
+		files: map[string]string{
+			"/entry.js": `
+				async function* foo(bar) {
+					await bar
+					yield* bar
+					return arguments
+				}
+				class Derived extends Base {
+					async *foo() {
+						yield super.foo()
+					}
+				}
+				export default [
+					foo,
+					Derived,
+					async function*() {},
+					{async *foo() {}},
+					async function() {
+						for await (const x of foo) console.log(x)
+						outer: for await (y.z of bar) continue outer
+					},
+				]
+			`,
+		},
+		entryPaths: []string{"/entry.js"},
+		options: config.Options{
+			UnsupportedJSFeatures: es(2017),
+			AbsOutputFile:         "/out.js",
+		},
+	})
+}
+
 func TestLowerAsyncThis2016CommonJS(t *testing.T) {
 	lower_suite.expectBundled(t, bundled{
 		files: map[string]string{
@@ -908,14 +942,7 @@ func TestLowerAsyncES5(t *testing.T) {
 			UnsupportedJSFeatures: es(5),
 			AbsOutputFile:         "/out.js",
 		},
-		expectedScanLog: `arrow-1.js: ERROR: Transforming async functions to the configured target environment is not supported yet
-arrow-2.js: ERROR: Transforming async functions to the configured target environment is not supported yet
-export-def-1.js: ERROR: Transforming async functions to the configured target environment is not supported yet
-export-def-2.js: ERROR: Transforming async functions to the configured target environment is not supported yet
-fn-expr.js: ERROR: Transforming async functions to the configured target environment is not supported yet
-fn-stmt.js: ERROR: Transforming async functions to the configured target environment is not supported yet
-obj-method.js: ERROR: Transforming async functions to the configured target environment is not supported yet
-obj-method.js: ERROR: Transforming object literal extensions to the configured target environment is not supported yet
+		expectedScanLog: `obj-method.js: ERROR: Transforming object literal extensions to the configured target environment is not supported yet
 `,
 	})
 }
@@ -2120,3 +2147,186 @@ func TestStaticClassBlockES2021(t *testing.T) {
 		},
 	})
 }
+
+func TestLowerLetConstES5(t *testing.T) {
+	lower_suite.expectBundled(t, bundled{
+		files: map[string]string{
+			"/entry.js": `
+				let x = 1
+				{
+					let x = 2
+					const y = x
+					console.log(x, y)
+				}
+				function foo() {
+					let x = 3
+					for (let x = 0; x < 3; x++) fns.push(() => x)
+					for (let y = 0; y < 3; y++) console.log(y)
+					{ const y = 4; console.log(y) }
+					return x
+				}
+				console.log(x, foo())
+			`,
+		},
+		entryPaths: []string{"/entry.js"},
+		options: config.Options{
+			Mode:                  config.ModeBundle,
+			UnsupportedJSFeatures: es(5),
+			AbsOutputFile:         "/out.js",
+		},
+	})
+}
+
+func TestLowerDestructuringES5(t *testing.T) {
+	lower_suite.expectBundled(t, bundled{
+		files: map[string]string{
+			"/entry.js": `
+				const {a, b: [c, ...d], ...e} = foo
+				export function bar([x, y], {z}) {
+					return [x, y, z]
+				}
+				for (const [first, second] in foo) console.log(first, second)
+				console.log(a, c, d, e)
+			`,
+		},
+		entryPaths: []string{"/entry.js"},
+		options: config.Options{
+			Mode:                  config.ModeBundle,
+			UnsupportedJSFeatures: es(5),
+			AbsOutputFile:         "/out.js",
+		},
+	})
+}
+
+func TestLowerClassES5(t *testing.T) {
+	lower_suite.expectBundled(t, bundled{
+		files: map[string]string{
+			"/entry.js": `
+				import Base from './base'
+				export class Derived extends Base {
+					x = 1
+					constructor(y) {
+						super(y)
+						this.y = y
+					}
+					get sum() { return this.x + this.y }
+					static create() { return new this(super.defaultValue) }
+				}
+			`,
+			"/base.js": `
+				export default class {
+					static defaultValue = 2
+					toString() { return 'Base' }
+				}
+			`,
+		},
+		entryPaths: []string{"/entry.js"},
+		options: config.Options{
+			Mode:                  config.ModeBundle,
+			OutputFormat:          config.FormatESModule,
+			UnsupportedJSFeatures: es(5),
+			AbsOutputFile:         "/out.js",
+		},
+	})
+}
+
+func TestLowerForAwaitTwoLoops(t *testing.T) {
+	lower_suite.expectBundled(t, bundled{
+		files: map[string]string{
+			"/entry.js": `
+				export async function f() {
+					for await (const x of foo) console.log(x)
+					for await (const x of bar) console.log(x)
+				}
+			`,
+		},
+		entryPaths: []string{"/entry.js"},
+		options: config.Options{
+			Mode:                  config.ModeBundle,
+			OutputFormat:          config.FormatESModule,
+			UnsupportedJSFeatures: es(2017),
+			AbsOutputFile:         "/out.js",
+		},
+	})
+}
+
+func TestLowerForOfES5(t *testing.T) {
+	lower_suite.expectBundled(t, bundled{
+		files: map[string]string{
+			"/entry.js": `
+				for (const x of foo) console.log(x)
+				for (const x of bar) console.log(x)
+				export function* gen(items) {
+					for (const item of items) yield item
+				}
+			`,
+		},
+		entryPaths: []string{"/entry.js"},
+		options: config.Options{
+			Mode:                  config.ModeBundle,
+			OutputFormat:          config.FormatESModule,
+			UnsupportedJSFeatures: es(5),
+			AbsOutputFile:         "/out.js",
+		},
+	})
+}
+
+func TestLowerForOfES5AssumeArrays(t *testing.T) {
+	lower_suite.expectBundled(t, bundled{
+		files: map[string]string{
+			"/entry.js": `
+				for (const x of foo) console.log(x)
+				for (const x of bar) console.log(x)
+			`,
+		},
+		entryPaths: []string{"/entry.js"},
+		options: config.Options{
+			Mode:                     config.ModeBundle,
+			UnsupportedJSFeatures:    es(5),
+			AssumeIterablesAreArrays: true,
+			AbsOutputFile:            "/out.js",
+		},
+	})
+}
+
+func TestLowerDecorators(t *testing.T) {
+	lower_suite.expectBundled(t, bundled{
+		files: map[string]string{
+			"/entry.js": `
+				@dec('cls') export class Foo {
+					@dec('method') method() {}
+					@dec('field') field = 1
+					@dec('accessor') accessor x = 2
+					@dec('private') static #y = 3
+					static accessor z
+				}
+			`,
+		},
+		entryPaths: []string{"/entry.js"},
+		options: config.Options{
+			Mode:                  config.ModeBundle,
+			OutputFormat:          config.FormatESModule,
+			UnsupportedJSFeatures: es(2021),
+			AbsOutputFile:         "/out.js",
+		},
+	})
+}
+
+func TestLowerDecoratorsPassthrough(t *testing.T) {
+	lower_suite.expectBundled(t, bundled{
+		files: map[string]string{
+			"/entry.js": `
+				export default @dec class {
+					@dec.foo() method() {}
+					@(foo[bar]) accessor x = 2
+				}
+			`,
+		},
+		entryPaths: []string{"/entry.js"},
+		options: config.Options{
+			Mode:          config.ModeBundle,
+			OutputFormat:  config.FormatESModule,
+			AbsOutputFile: "/out.js",
+		},
+	})
+}
@@ -1571,3 +1571,133 @@ func TestTSEnumDefine(t *testing.T) {
 		},
 	})
 }
+
+func TestTSEnumCrossModuleInliningAccess(t *testing.T) {
+	ts_suite.expectBundled(t, bundled{
+		files: map[string]string{
+			"/entry.ts": `
+				import { a, b, c, d } from './enums'
+				console.log([
+					a.x,
+					b['x'],
+					c?.x,
+					d['x'],
+					d,
+				])
+			`,
+			"/enums.ts": `
+				export enum a { x = 123 }
+				export enum b { x = 'abc' }
+				export enum c { x = 'a-b' }
+				export enum d { x }
+			`,
+		},
+		entryPaths: []string{"/entry.ts"},
+		options: config.Options{
+			Mode:          config.ModeBundle,
+			AbsOutputFile: "/out.js",
+		},
+	})
+}
+
+func TestTSEnumCrossModuleInliningReExport(t *testing.T) {
+	ts_suite.expectBundled(t, bundled{
+		files: map[string]string{
+			"/entry.js": `
+				import { a } from './re-export'
+				import { b } from './re-export-star'
+				import * as ns from './enums'
+				console.log([
+					a.x,
+					b.x,
+					ns.c.x,
+				])
+			`,
+			"/re-export.js": `
+				export { a } from './enums'
+			`,
+			"/re-export-star.js": `
+				export * from './enums'
+			`,
+			"/enums.ts": `
+				export enum a { x = 'a' }
+				export enum b { x = 'b' }
+				export enum c { x = 'c' }
+			`,
+		},
+		entryPaths: []string{"/entry.js"},
+		options: config.Options{
+			Mode:          config.ModeBundle,
+			AbsOutputFile: "/out.js",
+		},
+	})
+}
+
+func TestTSEnumCrossModuleTreeShaking(t *testing.T) {
+	ts_suite.expectBundled(t, bundled{
+		files: map[string]string{
+			"/entry.ts": `
+				import {
+					a_DROP,
+					b_DROP,
+					c_DROP,
+				} from './enums'
+
+				console.log([
+					a_DROP.x,
+					b_DROP['x'],
+					c_DROP.x,
+				])
+
+				import {
+					a_keep,
+					b_keep,
+					c_keep,
+					d_keep,
+				} from './enums'
+
+				console.log([
+					a_keep.x,
+					b_keep.x,
+					c_keep,
+					d_keep.y,
+				])
+			`,
+			"/enums.ts": `
+				export enum a_DROP { x }
+				export enum b_DROP { x }
+				export const enum c_DROP { x }
+
+				export enum a_keep { x = foo }
+				export enum b_keep { x = foo }
+				export enum c_keep { x }
+				export enum d_keep { x }
+			`,
+		},
+		entryPaths: []string{"/entry.ts"},
+		options: config.Options{
+			Mode:          config.ModeBundle,
+			AbsOutputFile: "/out.js",
+		},
+	})
+}
+
+func TestTSEnumCrossModuleInliningMinify(t *testing.T) {
+	ts_suite.expectBundled(t, bundled{
+		files: map[string]string{
+			"/entry.ts": `
+				import { Foo } from './enums'
+				console.log(Foo.A, Foo.B)
+			`,
+			"/enums.ts": `
+				export const enum Foo { A = -1, B = 'b' }
+			`,
+		},
+		entryPaths: []string{"/entry.ts"},
+		options: config.Options{
+			Mode:          config.ModeBundle,
+			MangleSyntax:  true,
+			AbsOutputFile: "/out.js",
+		},
+	})
+}
@@ -1149,6 +1149,171 @@ func TestTsconfigPreserveValueImports(t *testing.T) {
 	})
 }
 
+func TestTsconfigPreserveValueImportsTypeOnly(t *testing.T) {
+	tsconfig_suite.expectBundled(t, bundled{
+		files: map[string]string{
+			"/Users/user/project/src/entry.ts": `
+				import type {a} from "./a"
+				import {type b} from "./b"
+				import {c, type d} from "./c"
+				import e, {type f} from "./e"
+				import * as ns from "./ns"
+				console.log(1 as a)
+			`,
+			"/Users/user/project/src/tsconfig.json": `{
+				"compilerOptions": {
+					"preserveValueImports": true
+				}
+			}`,
+		},
+		entryPaths: []string{"/Users/user/project/src/entry.ts"},
+		options: config.Options{
+			Mode:          config.ModeConvertFormat,
+			OutputFormat:  config.FormatESModule,
+			AbsOutputFile: "/Users/user/project/out.js",
+			ExternalModules: config.ExternalModules{
+				AbsPaths: map[string]bool{
+					"/Users/user/project/src/a":  true,
+					"/Users/user/project/src/b":  true,
+					"/Users/user/project/src/c":  true,
+					"/Users/user/project/src/e":  true,
+					"/Users/user/project/src/ns": true,
+				},
+			},
+		},
+	})
+}
+
+func TestTsconfigVerbatimModuleSyntax(t *testing.T) {
+	tsconfig_suite.expectBundled(t, bundled{
+		files: map[string]string{
+			"/Users/user/project/src/entry.ts": `
+				import type {a} from "./a"
+				import {type b} from "./b"
+				import {c, type d} from "./c"
+				import e, {type f} from "./e"
+				import * as ns from "./ns"
+				console.log(1 as a)
+			`,
+			"/Users/user/project/src/tsconfig.json": `{
+				"compilerOptions": {
+					"verbatimModuleSyntax": true
+				}
+			}`,
+		},
+		entryPaths: []string{"/Users/user/project/src/entry.ts"},
+		options: config.Options{
+			Mode:          config.ModeConvertFormat,
+			OutputFormat:  config.FormatESModule,
+			AbsOutputFile: "/Users/user/project/out.js",
+			ExternalModules: config.ExternalModules{
+				AbsPaths: map[string]bool{
+					"/Users/user/project/src/a":  true,
+					"/Users/user/project/src/b":  true,
+					"/Users/user/project/src/c":  true,
+					"/Users/user/project/src/e":  true,
+					"/Users/user/project/src/ns": true,
+				},
+			},
+		},
+	})
+}
+
+func TestTsconfigVerbatimModuleSyntaxBundle(t *testing.T) {
+	tsconfig_suite.expectBundled(t, bundled{
+		files: map[string]string{
+			"/Users/user/project/src/entry.ts": `
+				import {type a} from "./types"
+				import {b} from "./values"
+				console.log(1 as a)
+			`,
+			"/Users/user/project/src/types.ts": `
+				export type a = number
+				console.log('types')
+			`,
+			"/Users/user/project/src/values.ts": `
+				export let b = 2
+				console.log('values')
+			`,
+			"/Users/user/project/src/tsconfig.json": `{
+				"compilerOptions": {
+					"verbatimModuleSyntax": true
+				}
+			}`,
+		},
+		entryPaths: []string{"/Users/user/project/src/entry.ts"},
+		options: config.Options{
+			Mode:          config.ModeBundle,
+			AbsOutputFile: "/Users/user/project/out.js",
+		},
+	})
+}
+
+func TestTsconfigIsolatedModules(t *testing.T) {
+	tsconfig_suite.expectBundled(t, bundled{
+		files: map[string]string{
+			"/Users/user/project/src/entry.ts": `
+				import "./isolated"
+				import "./verbatim"
+				import "./neither"
+			`,
+			"/Users/user/project/src/types.ts": `
+				export interface Bar {}
+				export type Baz = number
+			`,
+			"/Users/user/project/src/isolated/index.ts": `
+				import { Bar } from "../types"
+				declare const enum Foo { A }
+				interface Local {}
+				console.log(Foo.A)
+				export { Bar, Local }
+				export { Baz } from "../types"
+			`,
+			"/Users/user/project/src/isolated/tsconfig.json": `{
+				"compilerOptions": {
+					"isolatedModules": true
+				}
+			}`,
+			"/Users/user/project/src/verbatim/index.ts": `
+				import { Bar } from "../types"
+				declare const enum Foo { A }
+				interface Local {}
+				console.log(Foo.A)
+				export { Bar, Local }
+				export { Baz } from "../types"
+			`,
+			"/Users/user/project/src/verbatim/tsconfig.json": `{
+				"compilerOptions": {
+					"verbatimModuleSyntax": true
+				}
+			}`,
+			"/Users/user/project/src/neither/index.ts": `
+				import { Bar } from "../types"
+				declare const enum Foo { A }
+				interface Local {}
+				console.log(Foo.A)
+				export { Bar, Local }
+				export { Baz } from "../types"
+			`,
+		},
+		entryPaths: []string{"/Users/user/project/src/entry.ts"},
+		options: config.Options{
+			Mode:          config.ModeBundle,
+			AbsOutputFile: "/Users/user/project/out.js",
+		},
+		expectedScanLog: `Users/user/project/src/isolated/index.ts: WARNING: Cannot access the ambient const enum "Foo" when "isolatedModules" is enabled
+NOTE: The enum "Foo" was declared using "declare", so no code is generated for it and this reference will fail at run time. Remove "declare" to generate the enum instead.
+Users/user/project/src/verbatim/index.ts: WARNING: Cannot access the ambient const enum "Foo" when "isolatedModules" is enabled
+NOTE: The enum "Foo" was declared using "declare", so no code is generated for it and this reference will fail at run time. Remove "declare" to generate the enum instead.
+`,
+		expectedCompileLog: `Users/user/project/src/isolated/index.ts: WARNING: Re-exporting the type "Bar" requires using "export type" when "isolatedModules" is enabled
+Users/user/project/src/isolated/index.ts: WARNING: Re-exporting the type "Baz" requires using "export type" when "isolatedModules" is enabled
+Users/user/project/src/verbatim/index.ts: WARNING: Re-exporting the type "Bar" requires using "export type" when "isolatedModules" is enabled
+Users/user/project/src/verbatim/index.ts: WARNING: Re-exporting the type "Baz" requires using "export type" when "isolatedModules" is enabled
+`,
+	})
+}
+
 func TestTsconfigTarget(t *testing.T) {
 	tsconfig_suite.expectBundled(t, bundled{
 		files: map[string]string{
@@ -1296,6 +1461,59 @@ func TestTsconfigUseDefineForClassFieldsESNext(t *testing.T) {
 	})
 }
 
+func TestTsconfigExperimentalDecoratorsFalse(t *testing.T) {
+	tsconfig_suite.expectBundled(t, bundled{
+		files: map[string]string{
+			"/Users/user/project/src/entry.ts": `
+				@dec class Foo {
+					@dec method(): void {}
+				}
+				console.log(Foo)
+			`,
+			"/Users/user/project/src/tsconfig.json": `{
+				"compilerOptions": {
+					"experimentalDecorators": false
+				}
+			}`,
+		},
+		entryPaths: []string{"/Users/user/project/src/entry.ts"},
+		options: config.Options{
+			Mode:                  config.ModeBundle,
+			UnsupportedJSFeatures: es(2021),
+			AbsOutputFile:         "/Users/user/project/out.js",
+		},
+	})
+}
+
+func TestTsconfigEmitDecoratorMetadata(t *testing.T) {
+	tsconfig_suite.expectBundled(t, bundled{
+		files: map[string]string{
+			"/Users/user/project/src/entry.ts": `
+				import { Service } from './service'
+				@dec class Foo {
+					constructor(service: Service, name: string) {}
+					@dec method(x: number): boolean { return true }
+				}
+				console.log(Foo)
+			`,
+			"/Users/user/project/src/service.ts": `
+				export class Service {}
+			`,
+			"/Users/user/project/src/tsconfig.json": `{
+				"compilerOptions": {
+					"experimentalDecorators": true,
+					"emitDecoratorMetadata": true
+				}
+			}`,
+		},
+		entryPaths: []string{"/Users/user/project/src/entry.ts"},
+		options: config.Options{
+			Mode:          config.ModeBundle,
+			AbsOutputFile: "/Users/user/project/out.js",
+		},
+	})
+}
+
 func TestTsconfigUnrecognizedTargetWarning(t *testing.T) {
 	tsconfig_suite.expectBundled(t, bundled{
 		files: map[string]string{
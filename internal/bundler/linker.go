@@ -15,10 +15,12 @@ import (
 	"github.com/evanw/esbuild/internal/compat"
 	"github.com/evanw/esbuild/internal/config"
 	"github.com/evanw/esbuild/internal/css_ast"
+	"github.com/evanw/esbuild/internal/css_lexer"
 	"github.com/evanw/esbuild/internal/css_printer"
 	"github.com/evanw/esbuild/internal/fs"
 	"github.com/evanw/esbuild/internal/graph"
 	"github.com/evanw/esbuild/internal/helpers"
+	"github.com/evanw/esbuild/internal/html_printer"
 	"github.com/evanw/esbuild/internal/js_ast"
 	"github.com/evanw/esbuild/internal/js_lexer"
 	"github.com/evanw/esbuild/internal/js_printer"
@@ -154,8 +156,9 @@ type intermediateOutput struct {
 
 type chunkRepr interface{ isChunk() }
 
-func (*chunkReprJS) isChunk()  {}
-func (*chunkReprCSS) isChunk() {}
+func (*chunkReprJS) isChunk()   {}
+func (*chunkReprCSS) isChunk()  {}
+func (*chunkReprHTML) isChunk() {}
 
 type chunkReprJS struct {
 	filesInChunkInOrder []uint32
@@ -170,7 +173,17 @@ type chunkReprJS struct {
 
 type chunkReprCSS struct {
 	externalImportsInOrder []externalImportCSS
-	filesInChunkInOrder    []uint32
+	importsInChunkInOrder  []cssImportOrder
+}
+
+type chunkReprHTML struct {
+	// This maps each import record for a script or stylesheet in the HTML file
+	// to the index of the entry point chunk for that file
+	chunkIndexForImportRecord map[uint32]uint32
+
+	// JavaScript entry points that import CSS generate an additional CSS chunk.
+	// This maps the import record for the script to the index of that chunk.
+	cssChunkIndexForImportRecord map[uint32]uint32
 }
 
 type externalImportCSS struct {
@@ -179,6 +192,21 @@ type externalImportCSS struct {
 	conditionImportRecords []ast.ImportRecord
 }
 
+// A file may be imported more than once with different conditions, in which
+// case it's included once for each set of conditions. Each condition comes
+// from one "@import" rule along the import chain, outermost first. Any import
+// records referenced by the condition tokens are in "conditionImportRecords".
+type cssImportOrder struct {
+	sourceIndex            uint32
+	conditions             [][]css_ast.Token
+	conditionImportRecords []ast.ImportRecord
+
+	// If true, this only includes the "@layer" statements that come before the
+	// "@import" rules in this file. They are split off so that they come before
+	// the imported files, which is necessary to preserve the order of layers.
+	leadingLayers bool
+}
+
 // Returns a log where "log.HasErrors()" only returns true if any errors have
 // been logged since this call. This is useful when there have already been
 // errors logged by other linkers that share the same log.
@@ -296,6 +324,12 @@ func link(
 	}
 
 	chunks := c.computeChunks()
+
+	// Stop now if there were errors
+	if c.log.HasErrors() {
+		return []graph.OutputFile{}
+	}
+
 	c.computeCrossChunkDependencies(chunks)
 
 	// Make sure calls to "js_ast.FollowSymbols()" in parallel goroutines after this
@@ -355,6 +389,8 @@ func (c *linkerContext) generateChunksInParallel(chunks []chunkInfo) []graph.Out
 			go c.generateChunkJS(chunks, chunkIndex, &generateWaitGroup)
 		case *chunkReprCSS:
 			go c.generateChunkCSS(chunks, chunkIndex, &generateWaitGroup)
+		case *chunkReprHTML:
+			go c.generateChunkHTML(chunks, chunkIndex, &generateWaitGroup)
 		}
 	}
 	c.enforceNoCyclicChunkImports(chunks)
@@ -406,11 +442,18 @@ func (c *linkerContext) generateChunksInParallel(chunks []chunkInfo) []graph.Out
 				commentPrefix = "//"
 
 			case *chunkReprCSS:
-				for _, sourceIndex := range chunkRepr.filesInChunkInOrder {
-					outputFiles = append(outputFiles, c.graph.Files[sourceIndex].InputFile.AdditionalFiles...)
+				seen := make(map[uint32]bool)
+				for _, entry := range chunkRepr.importsInChunkInOrder {
+					if !seen[entry.sourceIndex] {
+						seen[entry.sourceIndex] = true
+						outputFiles = append(outputFiles, c.graph.Files[entry.sourceIndex].InputFile.AdditionalFiles...)
+					}
 				}
 				commentPrefix = "/*"
 				commentSuffix = " */"
+
+			case *chunkReprHTML:
+				outputFiles = append(outputFiles, c.graph.Files[chunk.sourceIndex].InputFile.AdditionalFiles...)
 			}
 
 			// Path substitution for the chunk itself
@@ -603,6 +646,59 @@ func (c *linkerContext) pathBetweenChunks(fromRelDir string, toRelPath string) s
 	return relPath
 }
 
+func (c *linkerContext) rebaseCSSURLs(sourcePath logger.Path, outputAbsDir string, records []ast.ImportRecord) []ast.ImportRecord {
+	records = append([]ast.ImportRecord{}, records...) // Clone before mutating
+	for i := range records {
+		if record := &records[i]; record.Kind == ast.ImportURL {
+			record.Path.Text = c.rebaseCSSURL(sourcePath, outputAbsDir, record.Path.Text)
+		}
+	}
+	return records
+}
+
+// This rewrites a relative URL in a CSS file so that it still points to the
+// same place when it's printed in an output file in a different directory.
+// Absolute URLs, URLs with a scheme, and fragment-only URLs are left alone.
+// If the input file has no absolute path (e.g. when using the transform API),
+// its path is assumed to be relative to the output file's directory instead.
+func (c *linkerContext) rebaseCSSURL(sourcePath logger.Path, outputAbsDir string, url string) string {
+	if url == "" || url[0] == '/' || url[0] == '#' {
+		return url
+	}
+
+	// Split off the query string and the fragment, which must not be rebased
+	suffix := ""
+	if i := strings.IndexAny(url, "?#"); i != -1 {
+		url, suffix = url[:i], url[i:]
+	}
+
+	// A ":" before the first "/" means this URL has a scheme (e.g. "https:")
+	if i := strings.IndexByte(url, ':'); i != -1 && !strings.ContainsRune(url[:i], '/') {
+		return url + suffix
+	}
+
+	// Keep a trailing slash since "path.Join" removes it
+	trailingSlash := ""
+	if strings.HasSuffix(url, "/") {
+		trailingSlash = "/"
+	}
+
+	if sourcePath.Namespace != "file" {
+		dir, _, _ := logger.PlatformIndependentPathDirBaseExt(sourcePath.Text)
+		dir = strings.ReplaceAll(dir, "\\", "/")
+		return path.Join(dir, url) + trailingSlash + suffix
+	}
+
+	absPath := c.fs.Join(c.fs.Dir(sourcePath.Text), url)
+	relPath, ok := c.fs.Rel(outputAbsDir, absPath)
+	if !ok {
+		return url + suffix
+	}
+
+	// Make sure to always use forward slashes, even on Windows
+	return strings.ReplaceAll(relPath, "\\", "/") + trailingSlash + suffix
+}
+
 // Returns the path of this file relative to "outbase", which is then ready to
 // be joined with the absolute output directory path. The directory and name
 // components are returned separately for convenience.
@@ -1108,6 +1204,25 @@ func (c *linkerContext) scanImportsAndExports() {
 			}
 			file.InputFile.AdditionalFiles = additionalFiles
 
+		case *graph.HTMLRepr:
+			// Inline URLs for assets into the HTML file. Scripts and stylesheets are
+			// left alone since they are separate entry points with their own chunks.
+			var additionalFiles []graph.OutputFile
+			for importRecordIndex := range repr.AST.ImportRecords {
+				if record := &repr.AST.ImportRecords[importRecordIndex]; record.SourceIndex.IsValid() && record.Kind == ast.ImportHTMLURL {
+					otherFile := &c.graph.Files[record.SourceIndex.GetIndex()]
+					if otherRepr, ok := otherFile.InputFile.Repr.(*graph.JSRepr); ok {
+						record.Path.Text = otherRepr.AST.URLForCSS
+						record.Path.Namespace = ""
+						record.SourceIndex = ast.Index32{}
+
+						// Copy the additional files to the output directory
+						additionalFiles = append(additionalFiles, otherFile.InputFile.AdditionalFiles...)
+					}
+				}
+			}
+			file.InputFile.AdditionalFiles = additionalFiles
+
 		case *graph.JSRepr:
 			for importRecordIndex := range repr.AST.ImportRecords {
 				record := &repr.AST.ImportRecords[importRecordIndex]
@@ -1396,6 +1511,37 @@ func (c *linkerContext) scanImportsAndExports() {
 			}
 		}
 
+		// Now that imports have been matched with exports, determine which property
+		// accesses off of imported symbols are references to TypeScript enum values
+		// that will be inlined. All other property accesses count as normal uses.
+		for partIndex := range repr.AST.Parts {
+			part := &repr.AST.Parts[partIndex]
+			for ref, properties := range part.ImportSymbolPropertyUses {
+				use := part.SymbolUses[ref]
+
+				// Rare path: this import is a TypeScript enum
+				if importData, ok := repr.Meta.ImportsToBind[ref]; ok {
+					if enum, ok := c.graph.TSEnums[js_ast.FollowSymbols(c.graph.Symbols, importData.Ref)]; ok {
+						for name, propertyUse := range properties {
+							if _, ok := enum[name]; !ok {
+								use.CountEstimate += propertyUse.CountEstimate
+							}
+						}
+						if use.CountEstimate > 0 {
+							part.SymbolUses[ref] = use
+						}
+						continue
+					}
+				}
+
+				// Common path: this import isn't a TypeScript enum
+				for _, propertyUse := range properties {
+					use.CountEstimate += propertyUse.CountEstimate
+				}
+				part.SymbolUses[ref] = use
+			}
+		}
+
 		for importRef, importData := range repr.Meta.ImportsToBind {
 			resolvedRepr := c.graph.Files[importData.SourceIndex].InputFile.Repr.(*graph.JSRepr)
 			partsDeclaringSymbol := resolvedRepr.TopLevelSymbolToParts(importData.Ref)
@@ -1403,6 +1549,11 @@ func (c *linkerContext) scanImportsAndExports() {
 			for _, partIndex := range repr.AST.NamedImports[importRef].LocalPartsWithUses {
 				part := &repr.AST.Parts[partIndex]
 
+				// Skip parts that only used this import to access inlined enum values
+				if _, ok := part.SymbolUses[importRef]; !ok {
+					continue
+				}
+
 				// Depend on the file containing the imported symbol
 				for _, resolvedPartIndex := range partsDeclaringSymbol {
 					part.Dependencies = append(part.Dependencies, js_ast.Dependency{
@@ -1981,6 +2132,12 @@ func (c *linkerContext) matchImportsWithExportsForFile(sourceIndex uint32) {
 		case matchImportProbablyTypeScriptType:
 			repr.Meta.IsProbablyTypeScriptType[importRef] = true
 
+			// TypeScript's "isolatedModules" setting requires "export type" for
+			// re-exported types so that each file can be compiled independently
+			if file.InputFile.IsolatedModules && repr.AST.NamedImports[importRef].IsExported {
+				c.warnAboutReExportedType(sourceIndex, importRef)
+			}
+
 		case matchImportAmbiguous:
 			namedImport := repr.AST.NamedImports[importRef]
 			r := js_lexer.RangeOfIdentifier(file.InputFile.Source, namedImport.AliasLoc)
@@ -2018,6 +2175,28 @@ func (c *linkerContext) matchImportsWithExportsForFile(sourceIndex uint32) {
 	}
 }
 
+func (c *linkerContext) warnAboutReExportedType(sourceIndex uint32, importRef js_ast.Ref) {
+	file := &c.graph.Files[sourceIndex]
+	repr := file.InputFile.Repr.(*graph.JSRepr)
+	namedImport := repr.AST.NamedImports[importRef]
+	name := namedImport.Alias
+	loc := namedImport.AliasLoc
+
+	// Point at the export clause instead of the import if possible. Use the
+	// first location to be deterministic since map iteration order is random.
+	found := false
+	for alias, export := range repr.AST.NamedExports {
+		if export.Ref == importRef && (!found || export.AliasLoc.Start < loc.Start) {
+			name = alias
+			loc = export.AliasLoc
+			found = true
+		}
+	}
+
+	c.log.Add(logger.Warning, file.LineColumnTracker(), js_lexer.RangeOfIdentifier(file.InputFile.Source, loc),
+		fmt.Sprintf("Re-exporting the type %q requires using \"export type\" when \"isolatedModules\" is enabled", name))
+}
+
 type matchImportKind uint8
 
 const (
@@ -2558,6 +2737,11 @@ func (c *linkerContext) markFileReachableForCodeSplitting(sourceIndex uint32, en
 				c.markFileReachableForCodeSplitting(record.SourceIndex.GetIndex(), entryPointBit, distanceFromEntryPoint)
 			}
 		}
+
+	case *graph.HTMLRepr:
+		// Don't traverse into scripts and stylesheets. Each one is a separate
+		// entry point, so their contents shouldn't be considered reachable from
+		// the HTML file itself.
 	}
 }
 
@@ -2770,77 +2954,140 @@ func (c *linkerContext) findImportedCSSFilesInJSOrder(entryPoint uint32) (order
 //
 // If A imports B and then C, B imports D, and C imports D, then the CSS
 // traversal order is B D C A.
-func (c *linkerContext) findImportedFilesInCSSOrder(entryPoints []uint32) (externalOrder []externalImportCSS, internalOrder []uint32) {
+func (c *linkerContext) findImportedFilesInCSSOrder(entryPoints []uint32) (externalOrder []externalImportCSS, internalOrder []cssImportOrder) {
 	type externalImportsCSS struct {
 		unconditional bool
 		conditions    [][]css_ast.Token
 	}
 
-	visited := make(map[uint32]bool)
+	visited := make(map[uint32][][][]css_ast.Token)
+	visiting := make(map[uint32]bool)
 	externals := make(map[logger.Path]externalImportsCSS)
-	var visit func(uint32, ast.Index32)
+	var visit func(uint32, ast.Index32, [][]css_ast.Token, []ast.ImportRecord)
 
 	// Include this file and all files it imports
-	visit = func(sourceIndex uint32, importerIndex ast.Index32) {
-		if !visited[sourceIndex] {
-			visited[sourceIndex] = true
-			repr := c.graph.Files[sourceIndex].InputFile.Repr.(*graph.CSSRepr)
-			topLevelRules := repr.AST.Rules
-
-			// Iterate in reverse preorder (will be reversed again later)
-			internalOrder = append(internalOrder, sourceIndex)
-
-			// Iterate in the inverse order of top-level "@import" rules
-		outer:
-			for i := len(topLevelRules) - 1; i >= 0; i-- {
-				if atImport, ok := topLevelRules[i].Data.(*css_ast.RAtImport); ok {
-					if record := &repr.AST.ImportRecords[atImport.ImportRecordIndex]; record.SourceIndex.IsValid() {
-						// Follow internal dependencies
-						visit(record.SourceIndex.GetIndex(), ast.MakeIndex32(sourceIndex))
-					} else {
-						// Record external dependencies
-						external := externals[record.Path]
+	visit = func(sourceIndex uint32, importerIndex ast.Index32, conditions [][]css_ast.Token, conditionImportRecords []ast.ImportRecord) {
+		// Avoid infinite recursion when there's an import cycle. Conditional
+		// imports in a cycle would otherwise keep adding more conditions.
+		if visiting[sourceIndex] {
+			return
+		}
 
-						// Check for an unconditional import. An unconditional import
-						// should always mask all conditional imports that are overridden
-						// by the unconditional import.
-						if external.unconditional {
+		// A file that was already included with the same conditions doesn't need
+		// to be included again. Only the last occurrence is kept in CSS order.
+		for _, other := range visited[sourceIndex] {
+			if conditionsEqual(other, conditions) {
+				return
+			}
+		}
+		visited[sourceIndex] = append(visited[sourceIndex], conditions)
+		visiting[sourceIndex] = true
+		defer delete(visiting, sourceIndex)
+
+		file := &c.graph.Files[sourceIndex]
+		repr := file.InputFile.Repr.(*graph.CSSRepr)
+		topLevelRules := repr.AST.Rules
+
+		// Iterate in reverse preorder (will be reversed again later)
+		internalOrder = append(internalOrder, cssImportOrder{
+			sourceIndex:            sourceIndex,
+			conditions:             conditions,
+			conditionImportRecords: conditionImportRecords,
+		})
+
+		// Files that names are composed from come before this file so that
+		// this file's rules take precedence over theirs
+		for i := len(repr.AST.ImportRecords) - 1; i >= 0; i-- {
+			if record := &repr.AST.ImportRecords[i]; record.Kind == ast.ImportComposesFrom && record.SourceIndex.IsValid() {
+				visit(record.SourceIndex.GetIndex(), ast.MakeIndex32(sourceIndex), nil, nil)
+			}
+		}
+
+		// Iterate in the inverse order of top-level "@import" rules
+	outer:
+		for i := len(topLevelRules) - 1; i >= 0; i-- {
+			if atImport, ok := topLevelRules[i].Data.(*css_ast.RAtImport); ok {
+				if record := &repr.AST.ImportRecords[atImport.ImportRecordIndex]; record.SourceIndex.IsValid() {
+					// Follow internal dependencies. The imported file inherits the
+					// conditions of this file in addition to the ones on this import.
+					nestedConditions := conditions
+					nestedImportRecords := conditionImportRecords
+					if len(atImport.ImportConditions) > 0 {
+						var tokens []css_ast.Token
+						nestedImportRecords = append([]ast.ImportRecord{}, conditionImportRecords...)
+						tokens, nestedImportRecords = css_ast.CloneTokensWithImportRecords(
+							atImport.ImportConditions, repr.AST.ImportRecords, nil, nestedImportRecords)
+						nestedConditions = append(append([][]css_ast.Token{}, conditions...), tokens)
+					}
+					visit(record.SourceIndex.GetIndex(), ast.MakeIndex32(sourceIndex), nestedConditions, nestedImportRecords)
+				} else {
+					// External imports can't be nested inside of other rules, so they
+					// can only be conditional if at most one import along the chain
+					// has conditions
+					importConditions := atImport.ImportConditions
+					importRecords := repr.AST.ImportRecords
+					if len(conditions) > 0 {
+						if len(importConditions) > 0 || len(conditions) > 1 {
+							c.log.Add(logger.Error, file.LineColumnTracker(), record.Range,
+								"Bundling an external \"@import\" rule with multiple levels of conditions is not currently supported")
 							continue
 						}
+						importConditions = conditions[0]
+						importRecords = conditionImportRecords
+					}
 
-						if len(atImport.ImportConditions) == 0 {
-							external.unconditional = true
-						} else {
-							// Check for a conditional import. A conditional import does not
-							// mask an earlier unconditional import because re-evaluating a
-							// CSS file can have observable results.
-							for _, tokens := range external.conditions {
-								if css_ast.TokensEqualIgnoringWhitespace(tokens, atImport.ImportConditions) {
-									continue outer
-								}
+					// Record external dependencies
+					external := externals[record.Path]
+
+					// Check for an unconditional import. An unconditional import
+					// should always mask all conditional imports that are overridden
+					// by the unconditional import.
+					if external.unconditional {
+						continue
+					}
+
+					if len(importConditions) == 0 {
+						external.unconditional = true
+					} else {
+						// Check for a conditional import. A conditional import does not
+						// mask an earlier unconditional import because re-evaluating a
+						// CSS file can have observable results.
+						for _, tokens := range external.conditions {
+							if css_ast.TokensEqualIgnoringWhitespace(tokens, importConditions) {
+								continue outer
 							}
-							external.conditions = append(external.conditions, atImport.ImportConditions)
 						}
+						external.conditions = append(external.conditions, importConditions)
+					}
 
-						// Clone any import records associated with the condition tokens
-						conditions, conditionImportRecords := css_ast.CloneTokensWithImportRecords(
-							atImport.ImportConditions, repr.AST.ImportRecords, nil, nil)
+					// Clone any import records associated with the condition tokens
+					clonedConditions, clonedImportRecords := css_ast.CloneTokensWithImportRecords(
+						importConditions, importRecords, nil, nil)
 
-						externals[record.Path] = external
-						externalOrder = append(externalOrder, externalImportCSS{
-							path:                   record.Path,
-							conditions:             conditions,
-							conditionImportRecords: conditionImportRecords,
-						})
-					}
+					externals[record.Path] = external
+					externalOrder = append(externalOrder, externalImportCSS{
+						path:                   record.Path,
+						conditions:             clonedConditions,
+						conditionImportRecords: clonedImportRecords,
+					})
 				}
 			}
 		}
+
+		// Layer statements before the "@import" rules go before the imported files
+		if len(leadingLayerStatements(topLevelRules, repr.AST.ImportRecords)) > 0 {
+			internalOrder = append(internalOrder, cssImportOrder{
+				sourceIndex:            sourceIndex,
+				conditions:             conditions,
+				conditionImportRecords: conditionImportRecords,
+				leadingLayers:          true,
+			})
+		}
 	}
 
 	// Include all files reachable from any entry point
 	for i := len(entryPoints) - 1; i >= 0; i-- {
-		visit(entryPoints[i], ast.Index32{})
+		visit(entryPoints[i], ast.Index32{}, nil, nil)
 	}
 
 	// Reverse the order afterward when traversing in CSS order
@@ -2854,12 +3101,110 @@ func (c *linkerContext) findImportedFilesInCSSOrder(entryPoints []uint32) (exter
 	return
 }
 
+// This returns the indices of the "@layer" statements that come before the
+// last "@import" rule for an internal file. The order of layers is determined
+// by the first time each layer name appears, so these must stay before any
+// rules from the imported files.
+func leadingLayerStatements(rules []css_ast.Rule, importRecords []ast.ImportRecord) (indices []int) {
+	var layers []int
+	for i, rule := range rules {
+		switch r := rule.Data.(type) {
+		case *css_ast.RAtImport:
+			if importRecords[r.ImportRecordIndex].SourceIndex.IsValid() {
+				indices = layers
+			}
+
+		case *css_ast.RAtLayer:
+			if r.HasBlock {
+				return
+			}
+			layers = append(layers, i)
+
+		case *css_ast.RComment, *css_ast.RAtCharset:
+
+		default:
+			return
+		}
+	}
+	return
+}
+
+func conditionsEqual(a [][]css_ast.Token, b [][]css_ast.Token) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if !css_ast.TokensEqualIgnoringWhitespace(a[i], b[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// This wraps the rules from a conditionally-imported file in the rules that
+// are equivalent to the conditions. For example, the contents of a file that
+// was imported with "@import 'foo.css' layer(x) supports(display: grid) print"
+// become "@layer x { @supports (display: grid) { @media print { ... } } }".
+func wrapRulesWithConditions(rules []css_ast.Rule, conditions [][]css_ast.Token) []css_ast.Rule {
+	for i := len(conditions) - 1; i >= 0; i-- {
+		tokens := conditions[i]
+		var layer *css_ast.RAtLayer
+		var supports *css_ast.RAtSupports
+
+		if len(tokens) > 0 && strings.EqualFold(tokens[0].Text, "layer") {
+			if tokens[0].Kind == css_lexer.TIdent {
+				layer = &css_ast.RAtLayer{HasBlock: true}
+				tokens = tokens[1:]
+			} else if tokens[0].Kind == css_lexer.TFunction && tokens[0].Children != nil {
+				// The parser has already warned about invalid layer names
+				if name, ok := css_ast.LayerNameFromTokens(*tokens[0].Children); ok {
+					layer = &css_ast.RAtLayer{Names: [][]string{name}, HasBlock: true}
+				}
+				tokens = tokens[1:]
+			}
+		}
+
+		if len(tokens) > 0 && tokens[0].Kind == css_lexer.TFunction && strings.EqualFold(tokens[0].Text, "supports") && tokens[0].Children != nil {
+			supports = &css_ast.RAtSupports{Condition: []css_ast.Token{{
+				Kind:     css_lexer.TOpenParen,
+				Text:     "(",
+				Children: tokens[0].Children,
+			}}}
+			tokens = tokens[1:]
+		}
+
+		if len(tokens) > 0 {
+			rules = []css_ast.Rule{{Data: &css_ast.RKnownAt{AtToken: "media", Prelude: trimPreludeWhitespace(tokens), Rules: rules}}}
+		}
+		if supports != nil {
+			supports.Rules = rules
+			rules = []css_ast.Rule{{Data: supports}}
+		}
+		if layer != nil {
+			layer.Rules = rules
+			rules = []css_ast.Rule{{Data: layer}}
+		}
+	}
+	return rules
+}
+
+func trimPreludeWhitespace(tokens []css_ast.Token) []css_ast.Token {
+	if len(tokens) == 0 {
+		return tokens
+	}
+	tokens = append([]css_ast.Token{}, tokens...)
+	tokens[0].Whitespace &= ^css_ast.WhitespaceBefore
+	tokens[len(tokens)-1].Whitespace &= ^css_ast.WhitespaceAfter
+	return tokens
+}
+
 func (c *linkerContext) computeChunks() []chunkInfo {
 	c.timer.Begin("Compute chunks")
 	defer c.timer.End("Compute chunks")
 
 	jsChunks := make(map[string]chunkInfo)
 	cssChunks := make(map[string]chunkInfo)
+	htmlChunks := make(map[string]chunkInfo)
 
 	// Create chunks for entry points
 	for i, entryPoint := range c.graph.EntryPoints() {
@@ -2892,8 +3237,8 @@ func (c *linkerContext) computeChunks() []chunkInfo {
 			if cssSourceIndices := c.findImportedCSSFilesInJSOrder(entryPoint.SourceIndex); len(cssSourceIndices) > 0 {
 				externalOrder, internalOrder := c.findImportedFilesInCSSOrder(cssSourceIndices)
 				cssFilesWithPartsInChunk := make(map[uint32]bool)
-				for _, sourceIndex := range internalOrder {
-					cssFilesWithPartsInChunk[uint32(sourceIndex)] = true
+				for _, entry := range internalOrder {
+					cssFilesWithPartsInChunk[entry.sourceIndex] = true
 				}
 				cssChunks[key] = chunkInfo{
 					entryBits:             entryBits,
@@ -2903,21 +3248,26 @@ func (c *linkerContext) computeChunks() []chunkInfo {
 					filesWithPartsInChunk: cssFilesWithPartsInChunk,
 					chunkRepr: &chunkReprCSS{
 						externalImportsInOrder: externalOrder,
-						filesInChunkInOrder:    internalOrder,
+						importsInChunkInOrder:  internalOrder,
 					},
 				}
 			}
 
 		case *graph.CSSRepr:
 			externalOrder, internalOrder := c.findImportedFilesInCSSOrder([]uint32{entryPoint.SourceIndex})
-			for _, sourceIndex := range internalOrder {
-				chunk.filesWithPartsInChunk[uint32(sourceIndex)] = true
+			for _, entry := range internalOrder {
+				chunk.filesWithPartsInChunk[entry.sourceIndex] = true
 			}
 			chunk.chunkRepr = &chunkReprCSS{
 				externalImportsInOrder: externalOrder,
-				filesInChunkInOrder:    internalOrder,
+				importsInChunkInOrder:  internalOrder,
 			}
 			cssChunks[key] = chunk
+
+		case *graph.HTMLRepr:
+			chunk.filesWithPartsInChunk[entryPoint.SourceIndex] = true
+			chunk.chunkRepr = &chunkReprHTML{}
+			htmlChunks[key] = chunk
 		}
 	}
 
@@ -2940,8 +3290,8 @@ func (c *linkerContext) computeChunks() []chunkInfo {
 
 	// Sort the chunks for determinism. This matters because we use chunk indices
 	// as sorting keys in a few places.
-	sortedChunks := make([]chunkInfo, 0, len(jsChunks)+len(cssChunks))
-	sortedKeys := make([]string, 0, len(jsChunks)+len(cssChunks))
+	sortedChunks := make([]chunkInfo, 0, len(jsChunks)+len(cssChunks)+len(htmlChunks))
+	sortedKeys := make([]string, 0, len(jsChunks)+len(cssChunks)+len(htmlChunks))
 	for key := range jsChunks {
 		sortedKeys = append(sortedKeys, key)
 	}
@@ -2957,6 +3307,14 @@ func (c *linkerContext) computeChunks() []chunkInfo {
 	for _, key := range sortedKeys {
 		sortedChunks = append(sortedChunks, cssChunks[key])
 	}
+	sortedKeys = sortedKeys[:0]
+	for key := range htmlChunks {
+		sortedKeys = append(sortedKeys, key)
+	}
+	sort.Strings(sortedKeys)
+	for _, key := range sortedKeys {
+		sortedChunks = append(sortedChunks, htmlChunks[key])
+	}
 
 	// Map from the entry point file to this chunk. We will need this later if
 	// a file contains a dynamic import to this entry point, since we'll need
@@ -2978,6 +3336,59 @@ func (c *linkerContext) computeChunks() []chunkInfo {
 		}
 	}
 
+	// Now that the entry point chunks are known, link each HTML chunk to the
+	// chunks for the scripts and stylesheets that it references
+	for chunkIndex := range sortedChunks {
+		chunk := &sortedChunks[chunkIndex]
+		chunkRepr, ok := chunk.chunkRepr.(*chunkReprHTML)
+		if !ok {
+			continue
+		}
+		chunkRepr.chunkIndexForImportRecord = make(map[uint32]uint32)
+		chunkRepr.cssChunkIndexForImportRecord = make(map[uint32]uint32)
+		repr := c.graph.Files[chunk.sourceIndex].InputFile.Repr.(*graph.HTMLRepr)
+
+		for importRecordIndex, record := range repr.AST.ImportRecords {
+			if !record.SourceIndex.IsValid() || (record.Kind != ast.ImportHTMLScript && record.Kind != ast.ImportHTMLStylesheet) {
+				continue
+			}
+			otherFile := &c.graph.Files[record.SourceIndex.GetIndex()]
+			otherChunkIndex := otherFile.EntryPointChunkIndex
+			chunkRepr.chunkIndexForImportRecord[uint32(importRecordIndex)] = otherChunkIndex
+			chunk.crossChunkImports = append(chunk.crossChunkImports, chunkImport{
+				chunkIndex: otherChunkIndex,
+				importKind: record.Kind,
+			})
+
+			// Also reference the CSS chunk for this script, if there is one
+			if _, ok := otherFile.InputFile.Repr.(*graph.JSRepr); ok {
+				for cssChunkIndex, cssChunk := range sortedChunks {
+					if _, ok := cssChunk.chunkRepr.(*chunkReprCSS); ok && cssChunk.isEntryPoint && cssChunk.sourceIndex == record.SourceIndex.GetIndex() {
+						chunkRepr.cssChunkIndexForImportRecord[uint32(importRecordIndex)] = uint32(cssChunkIndex)
+						chunk.crossChunkImports = append(chunk.crossChunkImports, chunkImport{
+							chunkIndex: uint32(cssChunkIndex),
+							importKind: ast.ImportHTMLStylesheet,
+						})
+						break
+					}
+				}
+			}
+		}
+	}
+
+	// Scripts referenced by HTML files are always split into separate chunks,
+	// even when code splitting is disabled. Any code they share must then be
+	// imported from another chunk, which only works with ECMAScript modules.
+	if len(htmlChunks) > 0 && c.options.OutputFormat != config.FormatESModule {
+		for _, chunk := range sortedChunks {
+			if _, ok := chunk.chunkRepr.(*chunkReprJS); ok && !chunk.isEntryPoint {
+				c.log.Add(logger.Error, nil, logger.Range{},
+					"Sharing code between multiple scripts in an HTML file requires the \"esm\" output format")
+				break
+			}
+		}
+	}
+
 	// Determine the order of JS files (and parts) within the chunk ahead of time
 	for _, chunk := range sortedChunks {
 		if chunkRepr, ok := chunk.chunkRepr.(*chunkReprJS); ok {
@@ -3001,21 +3412,28 @@ func (c *linkerContext) computeChunks() []chunkInfo {
 			stdExt = c.options.OutputExtensionJS
 		case *chunkReprCSS:
 			stdExt = c.options.OutputExtensionCSS
+		case *chunkReprHTML:
+			stdExt = c.fs.Ext(c.graph.Files[chunk.sourceIndex].InputFile.Source.KeyPath.Text)
+			if stdExt == "" {
+				stdExt = ".html"
+			}
 		}
 
 		// Compute the template substitutions
 		var dir, base, ext string
 		var template []config.PathTemplate
 		if chunk.isEntryPoint {
-			// Only use the entry path template for user-specified entry points
+			// Only use the entry path template for user-specified entry points and
+			// for the scripts and stylesheets referenced by HTML entry points
 			file := &c.graph.Files[chunk.sourceIndex]
-			if file.IsUserSpecifiedEntryPoint() {
+			isEntryPathTemplate := file.IsUserSpecifiedEntryPoint() || file.IsHTMLReferencedEntryPoint()
+			if isEntryPathTemplate {
 				template = c.options.EntryPathTemplate
 			} else {
 				template = c.options.ChunkPathTemplate
 			}
 
-			if c.options.AbsOutputFile != "" {
+			if c.options.AbsOutputFile != "" && !file.IsHTMLReferencedEntryPoint() {
 				// If the output path was configured explicitly, use it verbatim
 				dir = "/"
 				base = c.fs.Base(c.options.AbsOutputFile)
@@ -3036,7 +3454,7 @@ func (c *linkerContext) computeChunks() []chunkInfo {
 					&c.graph.Files[chunk.sourceIndex].InputFile,
 					c.options,
 					c.fs,
-					!file.IsUserSpecifiedEntryPoint(),
+					!isEntryPathTemplate,
 					c.graph.EntryPoints()[chunk.entryPointBit].OutputPath,
 				)
 				ext = stdExt
@@ -3796,6 +4214,7 @@ func (c *linkerContext) generateCodeForFileInChunkJS(
 		InputSourceMap:               inputSourceMap,
 		LineOffsetTables:             lineOffsetTables,
 		RequireOrImportMetaForSource: c.requireOrImportMetaForSource,
+		TSEnums:                      c.graph.TSEnums,
 	}
 	tree := repr.AST
 	tree.Directive = "" // This is handled elsewhere
@@ -4788,7 +5207,7 @@ func (c *linkerContext) generateChunkCSS(chunks []chunkInfo, chunkIndex int, chu
 	}
 
 	chunkRepr := chunk.chunkRepr.(*chunkReprCSS)
-	compileResults := make([]compileResultCSS, len(chunkRepr.filesInChunkInOrder))
+	compileResults := make([]compileResultCSS, len(chunkRepr.importsInChunkInOrder))
 	dataForSourceMaps := c.dataForSourceMaps()
 
 	// Note: This contains placeholders instead of what the placeholders are
@@ -4803,30 +5222,64 @@ func (c *linkerContext) generateChunkCSS(chunks []chunkInfo, chunkIndex int, chu
 	// Generate CSS for each file in parallel
 	timer.Begin("Print CSS files")
 	waitGroup := sync.WaitGroup{}
-	for i, sourceIndex := range chunkRepr.filesInChunkInOrder {
+	for i, entry := range chunkRepr.importsInChunkInOrder {
 		// Create a goroutine for this file
 		waitGroup.Add(1)
-		go func(sourceIndex uint32, compileResult *compileResultCSS) {
+		go func(entry cssImportOrder, compileResult *compileResultCSS) {
+			sourceIndex := entry.sourceIndex
 			defer c.recoverInternalError(&waitGroup, sourceIndex)
 
 			file := &c.graph.Files[sourceIndex]
-			ast := file.InputFile.Repr.(*graph.CSSRepr).AST
+			repr := file.InputFile.Repr.(*graph.CSSRepr)
+			ast := repr.AST
 
-			// Filter out "@charset" and "@import" rules
+			// Filter out "@charset" and "@import" rules. Layer statements that
+			// come before "@import" rules are printed separately, before the
+			// imported files.
 			rules := make([]css_ast.Rule, 0, len(ast.Rules))
 			hasCharset := false
-			for _, rule := range ast.Rules {
-				switch rule.Data.(type) {
-				case *css_ast.RAtCharset:
-					hasCharset = true
-					continue
-				case *css_ast.RAtImport:
-					continue
+			leadingLayers := leadingLayerStatements(ast.Rules, ast.ImportRecords)
+			if entry.leadingLayers {
+				for _, index := range leadingLayers {
+					rules = append(rules, ast.Rules[index])
+				}
+			} else {
+				for i, rule := range ast.Rules {
+					switch rule.Data.(type) {
+					case *css_ast.RAtCharset:
+						hasCharset = true
+						continue
+					case *css_ast.RAtImport:
+						continue
+					case *css_ast.RAtLayer:
+						if len(leadingLayers) > 0 && i <= leadingLayers[len(leadingLayers)-1] {
+							continue
+						}
+					}
+					rules = append(rules, rule)
+				}
+			}
+
+			// Wrap the rules in the conditions from the "@import" rules, if any
+			if len(entry.conditions) > 0 {
+				var conditions [][]css_ast.Token
+				importRecords := ast.ImportRecords[:len(ast.ImportRecords):len(ast.ImportRecords)] // Copy on append
+				for _, tokens := range entry.conditions {
+					var cloned []css_ast.Token
+					cloned, importRecords = css_ast.CloneTokensWithImportRecords(tokens, entry.conditionImportRecords, nil, importRecords)
+					conditions = append(conditions, cloned)
 				}
-				rules = append(rules, rule)
+				rules = wrapRulesWithConditions(rules, conditions)
+				ast.ImportRecords = importRecords
 			}
 			ast.Rules = rules
 
+			// Rebase relative "url()" tokens from the input file to the output file
+			// when not bundling, since they aren't resolved and rewritten otherwise
+			if c.options.CSSRebaseURLs && c.options.Mode != config.ModeBundle {
+				ast.ImportRecords = c.rebaseCSSURLs(file.InputFile.Source.KeyPath, chunkAbsDir, ast.ImportRecords)
+			}
+
 			// Only generate a source map if needed
 			var addSourceMappings bool
 			var inputSourceMap *sourcemap.SourceMap
@@ -4844,6 +5297,7 @@ func (c *linkerContext) generateChunkCSS(chunks []chunkInfo, chunkIndex int, chu
 				AddSourceMappings: addSourceMappings,
 				InputSourceMap:    inputSourceMap,
 				LineOffsetTables:  lineOffsetTables,
+				LocalNames:        repr.LocalNames,
 			}
 			*compileResult = compileResultCSS{
 				PrintResult: css_printer.Print(ast, cssOptions),
@@ -4851,7 +5305,7 @@ func (c *linkerContext) generateChunkCSS(chunks []chunkInfo, chunkIndex int, chu
 				hasCharset:  hasCharset,
 			}
 			waitGroup.Done()
-		}(sourceIndex, &compileResults[i])
+		}(entry, &compileResults[i])
 	}
 
 	waitGroup.Wait()
@@ -4949,6 +5403,8 @@ func (c *linkerContext) generateChunkCSS(chunks []chunkInfo, chunkIndex int, chu
 	var compileResultsForSourceMap []compileResultForSourceMap
 	var legalCommentList []string
 	legalCommentSet := make(map[string]bool)
+	bytesInOutputForFile := make(map[uint32]int)
+	var metaOrder []uint32
 	for _, compileResult := range compileResults {
 		for text := range compileResult.ExtractedLegalComments {
 			if !legalCommentSet[text] {
@@ -4990,18 +5446,25 @@ func (c *linkerContext) generateChunkCSS(chunks []chunkInfo, chunkIndex int, chu
 			}
 		}
 
-		// Include this file in the metadata
+		// Include this file in the metadata. A file that was imported with
+		// several different conditions is only listed once.
 		if c.options.NeedsMetafile {
-			if isFirstMeta {
-				isFirstMeta = false
-			} else {
-				jMeta.AddString(",")
+			if _, ok := bytesInOutputForFile[compileResult.sourceIndex]; !ok {
+				metaOrder = append(metaOrder, compileResult.sourceIndex)
 			}
-			jMeta.AddString(fmt.Sprintf("\n        %s: {\n          \"bytesInOutput\": %d\n        }",
-				js_printer.QuoteForJSON(c.graph.Files[compileResult.sourceIndex].InputFile.Source.PrettyPath, c.options.ASCIIOnly),
-				len(compileResult.CSS)))
+			bytesInOutputForFile[compileResult.sourceIndex] += len(compileResult.CSS)
 		}
 	}
+	for _, sourceIndex := range metaOrder {
+		if isFirstMeta {
+			isFirstMeta = false
+		} else {
+			jMeta.AddString(",")
+		}
+		jMeta.AddString(fmt.Sprintf("\n        %s: {\n          \"bytesInOutput\": %d\n        }",
+			js_printer.QuoteForJSON(c.graph.Files[sourceIndex].InputFile.Source.PrettyPath, c.options.ASCIIOnly),
+			bytesInOutputForFile[sourceIndex]))
+	}
 
 	// Make sure the file ends with a newline
 	j.EnsureNewlineAtEnd()
@@ -5039,6 +5502,71 @@ func (c *linkerContext) generateChunkCSS(chunks []chunkInfo, chunkIndex int, chu
 	chunkWaitGroup.Done()
 }
 
+func (c *linkerContext) generateChunkHTML(chunks []chunkInfo, chunkIndex int, chunkWaitGroup *sync.WaitGroup) {
+	chunk := &chunks[chunkIndex]
+	defer c.recoverInternalError(chunkWaitGroup, chunk.sourceIndex)
+
+	timer := c.timer.Fork()
+	if timer != nil {
+		timeName := fmt.Sprintf("Generate chunk %q", path.Clean(config.TemplateToString(chunk.finalTemplate)))
+		timer.Begin(timeName)
+		defer c.timer.Join(timer)
+		defer timer.End(timeName)
+	}
+
+	chunkRepr := chunk.chunkRepr.(*chunkReprHTML)
+	file := &c.graph.Files[chunk.sourceIndex]
+	tree := file.InputFile.Repr.(*graph.HTMLRepr).AST
+
+	// Point each script and stylesheet at the unique key for its chunk. These
+	// keys are substituted with the final relative paths later on.
+	tree.ImportRecords = append([]ast.ImportRecord{}, tree.ImportRecords...)
+	for importRecordIndex, otherChunkIndex := range chunkRepr.chunkIndexForImportRecord {
+		tree.ImportRecords[importRecordIndex].Path.Text = chunks[otherChunkIndex].uniqueKey
+	}
+	stylesheets := make(map[uint32][]string)
+	for importRecordIndex, cssChunkIndex := range chunkRepr.cssChunkIndexForImportRecord {
+		stylesheets[importRecordIndex] = []string{chunks[cssChunkIndex].uniqueKey}
+	}
+
+	timer.Begin("Print HTML file")
+	j := helpers.Joiner{}
+	j.AddBytes(html_printer.Print(file.InputFile.Source.Contents, tree, html_printer.Options{
+		StylesheetsBeforeReference: stylesheets,
+	}))
+	chunk.intermediateOutput = c.breakOutputIntoPieces(j, uint32(len(chunks)))
+	timer.End("Print HTML file")
+
+	// The final output size is not known until the final import paths are
+	// substituted into the output pieces generated above
+	if c.options.NeedsMetafile {
+		chunk.jsonMetadataChunkCallback = func(finalOutputSize int) helpers.Joiner {
+			jMeta := helpers.Joiner{}
+			jMeta.AddString("{\n      \"imports\": [")
+			for i, chunkImport := range chunk.crossChunkImports {
+				if i > 0 {
+					jMeta.AddString(",")
+				}
+				jMeta.AddString(fmt.Sprintf("\n        {\n          \"path\": %s,\n          \"kind\": %s\n        }",
+					js_printer.QuoteForJSON(c.res.PrettyPath(logger.Path{Text: chunks[chunkImport.chunkIndex].uniqueKey, Namespace: "file"}), c.options.ASCIIOnly),
+					js_printer.QuoteForJSON(chunkImport.importKind.StringForMetafile(), c.options.ASCIIOnly)))
+			}
+			if len(chunk.crossChunkImports) > 0 {
+				jMeta.AddString("\n      ")
+			}
+			jMeta.AddString(fmt.Sprintf("],\n      \"entryPoint\": %s,\n      \"inputs\": {\n        %s: {\n          \"bytesInOutput\": %d\n        }\n      },\n      \"bytes\": %d\n    }",
+				js_printer.QuoteForJSON(file.InputFile.Source.PrettyPath, c.options.ASCIIOnly),
+				js_printer.QuoteForJSON(file.InputFile.Source.PrettyPath, c.options.ASCIIOnly),
+				finalOutputSize,
+				finalOutputSize))
+			return jMeta
+		}
+	}
+
+	c.generateIsolatedHashInParallel(chunk)
+	chunkWaitGroup.Done()
+}
+
 // Add all unique legal comments to the end of the file. These are
 // deduplicated because some projects have thousands of files with the same
 // comment. The comment must be preserved in the output for legal reasons but
@@ -31,8 +31,14 @@ func ParseLoader(text string) (api.Loader, *ErrorWithNote) {
 		return api.LoaderTS, nil
 	case "tsx":
 		return api.LoaderTSX, nil
+	case "flow":
+		return api.LoaderFlow, nil
 	case "css":
 		return api.LoaderCSS, nil
+	case "local-css":
+		return api.LoaderLocalCSS, nil
+	case "html":
+		return api.LoaderHTML, nil
 	case "json":
 		return api.LoaderJSON, nil
 	case "text":
@@ -50,7 +56,7 @@ func ParseLoader(text string) (api.Loader, *ErrorWithNote) {
 	default:
 		return api.LoaderNone, MakeErrorWithNote(
 			fmt.Sprintf("Invalid loader value: %q", text),
-			"Valid values are \"js\", \"jsx\", \"ts\", \"tsx\", \"css\", \"json\", \"text\", \"base64\", \"dataurl\", \"file\", or \"binary\".",
+			"Valid values are \"js\", \"jsx\", \"ts\", \"tsx\", \"flow\", \"css\", \"local-css\", \"html\", \"json\", \"text\", \"base64\", \"dataurl\", \"file\", or \"binary\".",
 		)
 	}
 }
@@ -1,5 +1,9 @@
 package compat
 
+import (
+	"github.com/evanw/esbuild/internal/css_ast"
+)
+
 type CSSFeature uint32
 
 const (
@@ -15,6 +19,25 @@ const (
 	Modern_RGB_HSL
 
 	InsetProperty
+
+	// This feature includes the "block" and "inline" flow-relative versions of
+	// the "margin", "padding", and "inset" properties (e.g. "margin-inline" and
+	// "inset-block-start")
+	LogicalProperties
+
+	// This feature includes the "lab()", "lch()", "oklab()", and "oklch()"
+	// color functions
+	ColorFunctions
+
+	ColorMix
+
+	ClampFunction
+
+	// This includes the ":is()" and ":where()" pseudo-classes. The ":is()"
+	// pseudo-class is also used when lowering nesting.
+	IsPseudoClass
+
+	Nesting
 )
 
 func (features CSSFeature) Has(feature CSSFeature) bool {
@@ -53,6 +76,60 @@ var cssTable = map[CSSFeature]map[Engine][]versionRange{
 		IOS:     {{start: v{14, 5, 0}}},
 		Safari:  {{start: v{14, 1, 0}}},
 	},
+
+	// Data from: https://developer.mozilla.org/en-US/docs/Web/CSS/margin-inline
+	LogicalProperties: {
+		Chrome:  {{start: v{87, 0, 0}}},
+		Edge:    {{start: v{87, 0, 0}}},
+		Firefox: {{start: v{66, 0, 0}}},
+		IOS:     {{start: v{14, 5, 0}}},
+		Safari:  {{start: v{14, 1, 0}}},
+	},
+
+	// Data from: https://developer.mozilla.org/en-US/docs/Web/CSS/color_value/oklch
+	ColorFunctions: {
+		Chrome:  {{start: v{111, 0, 0}}},
+		Edge:    {{start: v{111, 0, 0}}},
+		Firefox: {{start: v{113, 0, 0}}},
+		IOS:     {{start: v{15, 4, 0}}},
+		Safari:  {{start: v{15, 4, 0}}},
+	},
+
+	// Data from: https://developer.mozilla.org/en-US/docs/Web/CSS/color_value/color-mix
+	ColorMix: {
+		Chrome:  {{start: v{111, 0, 0}}},
+		Edge:    {{start: v{111, 0, 0}}},
+		Firefox: {{start: v{113, 0, 0}}},
+		IOS:     {{start: v{16, 2, 0}}},
+		Safari:  {{start: v{16, 2, 0}}},
+	},
+
+	// Data from: https://developer.mozilla.org/en-US/docs/Web/CSS/clamp
+	ClampFunction: {
+		Chrome:  {{start: v{79, 0, 0}}},
+		Edge:    {{start: v{79, 0, 0}}},
+		Firefox: {{start: v{75, 0, 0}}},
+		IOS:     {{start: v{13, 4, 0}}},
+		Safari:  {{start: v{13, 1, 0}}},
+	},
+
+	// Data from: https://developer.mozilla.org/en-US/docs/Web/CSS/:is
+	IsPseudoClass: {
+		Chrome:  {{start: v{88, 0, 0}}},
+		Edge:    {{start: v{88, 0, 0}}},
+		Firefox: {{start: v{78, 0, 0}}},
+		IOS:     {{start: v{14, 0, 0}}},
+		Safari:  {{start: v{14, 0, 0}}},
+	},
+
+	// Data from: https://developer.mozilla.org/en-US/docs/Web/CSS/Nesting_selector
+	Nesting: {
+		Chrome:  {{start: v{112, 0, 0}}},
+		Edge:    {{start: v{112, 0, 0}}},
+		Firefox: {{start: v{117, 0, 0}}},
+		IOS:     {{start: v{16, 5, 0}}},
+		Safari:  {{start: v{16, 5, 0}}},
+	},
 }
 
 // Return all features that are not available in at least one environment
@@ -70,3 +147,184 @@ func UnsupportedCSSFeatures(constraints map[Engine][]int) (unsupported CSSFeatur
 	}
 	return
 }
+
+type CSSPrefix uint8
+
+const (
+	WebkitPrefix CSSPrefix = 1 << iota
+	MozPrefix
+	MsPrefix
+
+	NoPrefix CSSPrefix = 0
+)
+
+func (prefixes CSSPrefix) Has(prefix CSSPrefix) bool {
+	return (prefixes & prefix) != 0
+}
+
+// This is the text that comes before the unprefixed name (e.g. "-webkit-")
+func (prefix CSSPrefix) String() string {
+	switch prefix {
+	case WebkitPrefix:
+		return "-webkit-"
+	case MozPrefix:
+		return "-moz-"
+	case MsPrefix:
+		return "-ms-"
+	}
+	return ""
+}
+
+// The prefix is needed for all versions of the engine before "withoutPrefix".
+// Use 0.0.0 for "withoutPrefix" if the prefix is still needed in all versions.
+type prefixData struct {
+	engine        Engine
+	prefix        CSSPrefix
+	withoutPrefix v
+}
+
+// A property value that needs a prefix, such as "position: -webkit-sticky"
+type CSSPrefixedValue struct {
+	Key   css_ast.D
+	Value string
+}
+
+var cssPropertyPrefixTable = map[css_ast.D][]prefixData{
+	// Data from: https://caniuse.com/css-appearance
+	css_ast.DAppearance: {
+		{engine: Chrome, prefix: WebkitPrefix, withoutPrefix: v{84, 0, 0}},
+		{engine: Edge, prefix: WebkitPrefix, withoutPrefix: v{84, 0, 0}},
+		{engine: Firefox, prefix: MozPrefix, withoutPrefix: v{80, 0, 0}},
+		{engine: IOS, prefix: WebkitPrefix, withoutPrefix: v{15, 4, 0}},
+		{engine: Safari, prefix: WebkitPrefix, withoutPrefix: v{15, 4, 0}},
+	},
+
+	// Data from: https://caniuse.com/css-backdrop-filter
+	css_ast.DBackdropFilter: {
+		{engine: IOS, prefix: WebkitPrefix, withoutPrefix: v{18, 0, 0}},
+		{engine: Safari, prefix: WebkitPrefix, withoutPrefix: v{18, 0, 0}},
+	},
+
+	// Data from: https://caniuse.com/user-select-none
+	css_ast.DUserSelect: {
+		{engine: Chrome, prefix: WebkitPrefix, withoutPrefix: v{54, 0, 0}},
+		{engine: Edge, prefix: MsPrefix, withoutPrefix: v{79, 0, 0}},
+		{engine: Firefox, prefix: MozPrefix, withoutPrefix: v{69, 0, 0}},
+		{engine: IOS, prefix: WebkitPrefix},
+		{engine: Safari, prefix: WebkitPrefix},
+	},
+}
+
+var cssValuePrefixTable = map[CSSPrefixedValue][]prefixData{
+	// Data from: https://caniuse.com/css-sticky
+	{Key: css_ast.DPosition, Value: "sticky"}: {
+		{engine: IOS, prefix: WebkitPrefix, withoutPrefix: v{13, 0, 0}},
+		{engine: Safari, prefix: WebkitPrefix, withoutPrefix: v{13, 0, 0}},
+	},
+}
+
+var cssPseudoElementPrefixTable = map[string][]prefixData{
+	// Data from: https://caniuse.com/css-placeholder
+	"placeholder": {
+		{engine: Chrome, prefix: WebkitPrefix, withoutPrefix: v{57, 0, 0}},
+		{engine: Edge, prefix: MsPrefix, withoutPrefix: v{79, 0, 0}},
+		{engine: Firefox, prefix: MozPrefix, withoutPrefix: v{51, 0, 0}},
+		{engine: IOS, prefix: WebkitPrefix, withoutPrefix: v{10, 3, 0}},
+		{engine: Safari, prefix: WebkitPrefix, withoutPrefix: v{10, 1, 0}},
+	},
+}
+
+// This describes which vendor prefixes are needed for the configured target
+// environment. Properties, values, and pseudo-elements that don't need any
+// prefixes are omitted. Anything in the tables above that isn't in here is
+// known to not need a prefix, so existing prefixed copies can be removed.
+type CSSPrefixData struct {
+	Properties     map[css_ast.D]CSSPrefix
+	Values         map[CSSPrefixedValue]CSSPrefix
+	PseudoElements map[string]CSSPrefix
+}
+
+func (a *CSSPrefixData) Equal(b *CSSPrefixData) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	if len(a.Properties) != len(b.Properties) || len(a.Values) != len(b.Values) || len(a.PseudoElements) != len(b.PseudoElements) {
+		return false
+	}
+	for key, prefixes := range a.Properties {
+		if b.Properties[key] != prefixes {
+			return false
+		}
+	}
+	for value, prefixes := range a.Values {
+		if b.Values[value] != prefixes {
+			return false
+		}
+	}
+	for name, prefixes := range a.PseudoElements {
+		if b.PseudoElements[name] != prefixes {
+			return false
+		}
+	}
+	return true
+}
+
+// Returns whether the prefix tables know about this property. Prefixed copies
+// of properties that aren't in the tables are always kept.
+func IsKnownPrefixedProperty(key css_ast.D) bool {
+	_, ok := cssPropertyPrefixTable[key]
+	return ok
+}
+
+func IsKnownPrefixedValue(value CSSPrefixedValue) bool {
+	_, ok := cssValuePrefixTable[value]
+	return ok
+}
+
+func prefixesForConstraints(table []prefixData, constraints map[Engine][]int) (prefixes CSSPrefix) {
+	for _, entry := range table {
+		if version, ok := constraints[entry.engine]; ok {
+			if entry.withoutPrefix == (v{}) || compareVersions(entry.withoutPrefix, version) > 0 {
+				prefixes |= entry.prefix
+			}
+		}
+	}
+	return
+}
+
+// Returns nil if none of the constraints are for a browser, in which case no
+// prefixes should be added or removed
+func CSSPrefixDataForConstraints(constraints map[Engine][]int) *CSSPrefixData {
+	hasBrowser := false
+	for engine := range constraints {
+		if engine != ES && engine != Node {
+			hasBrowser = true
+			break
+		}
+	}
+	if !hasBrowser {
+		return nil
+	}
+
+	data := &CSSPrefixData{
+		Properties:     make(map[css_ast.D]CSSPrefix),
+		Values:         make(map[CSSPrefixedValue]CSSPrefix),
+		PseudoElements: make(map[string]CSSPrefix),
+	}
+	for key, table := range cssPropertyPrefixTable {
+		if prefixes := prefixesForConstraints(table, constraints); prefixes != NoPrefix {
+			data.Properties[key] = prefixes
+		}
+	}
+	for value, table := range cssValuePrefixTable {
+		if prefixes := prefixesForConstraints(table, constraints); prefixes != NoPrefix {
+			data.Values[value] = prefixes
+		}
+	}
+	for name, table := range cssPseudoElementPrefixTable {
+		if prefixes := prefixesForConstraints(table, constraints); prefixes != NoPrefix {
+			data.PseudoElements[name] = prefixes
+		}
+	}
+	return data
+}
@@ -55,6 +55,7 @@ const (
 	ClassStaticBlocks
 	ClassStaticField
 	Const
+	Decorators
 	DefaultArgument
 	Destructuring
 	DynamicImport
@@ -65,6 +66,7 @@ const (
 	Generator
 	Hashbang
 	ImportAssertions
+	ImportAttributes
 	ImportMeta
 	Let
 	LogicalAssignment
@@ -78,10 +80,18 @@ const (
 	ObjectRestSpread
 	OptionalCatchBinding
 	OptionalChain
+	RegexpDotAllFlag
+	RegexpLookbehindAssertions
+	RegexpMatchIndices
+	RegexpNamedCaptureGroups
+	RegexpSetNotation
+	RegexpStickyAndUnicodeFlags
+	RegexpUnicodePropertyEscapes
 	RestArgument
 	TemplateLiteral
 	TopLevelAwait
 	UnicodeEscapes
+	Using
 )
 
 func (features JSFeature) Has(feature JSFeature) bool {
@@ -233,6 +243,7 @@ var jsTable = map[JSFeature]map[Engine][]versionRange{
 		Node:    {{start: v{6, 0, 0}}},
 		Safari:  {{start: v{11, 0, 0}}},
 	},
+	Decorators: {},
 	DefaultArgument: {
 		Chrome:  {{start: v{49, 0, 0}}},
 		Edge:    {{start: v{14, 0, 0}}},
@@ -314,6 +325,15 @@ var jsTable = map[JSFeature]map[Engine][]versionRange{
 	ImportAssertions: {
 		Chrome: {{start: v{91, 0, 0}}},
 	},
+	ImportAttributes: {
+		Chrome:  {{start: v{123, 0, 0}}},
+		Edge:    {{start: v{123, 0, 0}}},
+		ES:      {{start: v{2025, 0, 0}}},
+		Firefox: {{start: v{138, 0, 0}}},
+		IOS:     {{start: v{17, 2, 0}}},
+		Node:    {{start: v{18, 20, 0}, end: v{19, 0, 0}}, {start: v{20, 10, 0}}},
+		Safari:  {{start: v{17, 2, 0}}},
+	},
 	ImportMeta: {
 		Chrome:  {{start: v{64, 0, 0}}},
 		Edge:    {{start: v{79, 0, 0}}},
@@ -416,6 +436,69 @@ var jsTable = map[JSFeature]map[Engine][]versionRange{
 		Node:    {{start: v{16, 9, 0}}},
 		Safari:  {{start: v{13, 1, 0}}},
 	},
+	RegexpDotAllFlag: {
+		Chrome:  {{start: v{62, 0, 0}}},
+		Edge:    {{start: v{79, 0, 0}}},
+		ES:      {{start: v{2018, 0, 0}}},
+		Firefox: {{start: v{78, 0, 0}}},
+		IOS:     {{start: v{11, 3, 0}}},
+		Node:    {{start: v{8, 10, 0}}},
+		Safari:  {{start: v{11, 1, 0}}},
+	},
+	RegexpLookbehindAssertions: {
+		Chrome:  {{start: v{62, 0, 0}}},
+		Edge:    {{start: v{79, 0, 0}}},
+		ES:      {{start: v{2018, 0, 0}}},
+		Firefox: {{start: v{78, 0, 0}}},
+		IOS:     {{start: v{16, 4, 0}}},
+		Node:    {{start: v{8, 10, 0}}},
+		Safari:  {{start: v{16, 4, 0}}},
+	},
+	RegexpMatchIndices: {
+		Chrome:  {{start: v{90, 0, 0}}},
+		Edge:    {{start: v{90, 0, 0}}},
+		ES:      {{start: v{2022, 0, 0}}},
+		Firefox: {{start: v{88, 0, 0}}},
+		IOS:     {{start: v{15, 0, 0}}},
+		Node:    {{start: v{16, 0, 0}}},
+		Safari:  {{start: v{15, 0, 0}}},
+	},
+	RegexpNamedCaptureGroups: {
+		Chrome:  {{start: v{64, 0, 0}}},
+		Edge:    {{start: v{79, 0, 0}}},
+		ES:      {{start: v{2018, 0, 0}}},
+		Firefox: {{start: v{78, 0, 0}}},
+		IOS:     {{start: v{11, 3, 0}}},
+		Node:    {{start: v{10, 0, 0}}},
+		Safari:  {{start: v{11, 1, 0}}},
+	},
+	RegexpSetNotation: {
+		Chrome:  {{start: v{112, 0, 0}}},
+		Edge:    {{start: v{112, 0, 0}}},
+		ES:      {{start: v{2024, 0, 0}}},
+		Firefox: {{start: v{116, 0, 0}}},
+		IOS:     {{start: v{17, 0, 0}}},
+		Node:    {{start: v{20, 0, 0}}},
+		Safari:  {{start: v{17, 0, 0}}},
+	},
+	RegexpStickyAndUnicodeFlags: {
+		Chrome:  {{start: v{50, 0, 0}}},
+		Edge:    {{start: v{13, 0, 0}}},
+		ES:      {{start: v{2015, 0, 0}}},
+		Firefox: {{start: v{46, 0, 0}}},
+		IOS:     {{start: v{12, 0, 0}}},
+		Node:    {{start: v{6, 0, 0}}},
+		Safari:  {{start: v{12, 0, 0}}},
+	},
+	RegexpUnicodePropertyEscapes: {
+		Chrome:  {{start: v{64, 0, 0}}},
+		Edge:    {{start: v{79, 0, 0}}},
+		ES:      {{start: v{2018, 0, 0}}},
+		Firefox: {{start: v{78, 0, 0}}},
+		IOS:     {{start: v{11, 3, 0}}},
+		Node:    {{start: v{10, 0, 0}}},
+		Safari:  {{start: v{11, 1, 0}}},
+	},
 	RestArgument: {
 		Chrome:  {{start: v{47, 0, 0}}},
 		Edge:    {{start: v{12, 0, 0}}},
@@ -450,6 +533,12 @@ var jsTable = map[JSFeature]map[Engine][]versionRange{
 		Node:    {{start: v{4, 0, 0}}},
 		Safari:  {{start: v{9, 0, 0}}},
 	},
+	Using: {
+		Chrome:  {{start: v{134, 0, 0}}},
+		Edge:    {{start: v{134, 0, 0}}},
+		Firefox: {{start: v{141, 0, 0}}},
+		Node:    {{start: v{24, 0, 0}}},
+	},
 }
 
 // Return all features that are not available in at least one environment
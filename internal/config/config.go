@@ -27,6 +27,7 @@ type JSXExpr struct {
 type TSOptions struct {
 	Parse               bool
 	NoAmbiguousLessThan bool
+	Flow                bool // Parse Flow type syntax instead of TypeScript type syntax
 }
 
 type Platform uint8
@@ -81,6 +82,7 @@ const (
 	LoaderTS
 	LoaderTSNoAmbiguousLessThan // Used with ".mts" and ".cts"
 	LoaderTSX
+	LoaderFlow
 	LoaderJSON
 	LoaderText
 	LoaderBase64
@@ -88,6 +90,8 @@ const (
 	LoaderFile
 	LoaderBinary
 	LoaderCSS
+	LoaderLocalCSS
+	LoaderHTML
 	LoaderDefault
 )
 
@@ -100,9 +104,13 @@ func (loader Loader) IsTypeScript() bool {
 	}
 }
 
+func (loader Loader) IsCSS() bool {
+	return loader == LoaderCSS || loader == LoaderLocalCSS
+}
+
 func (loader Loader) CanHaveSourceMap() bool {
 	switch loader {
-	case LoaderJS, LoaderJSX, LoaderTS, LoaderTSNoAmbiguousLessThan, LoaderTSX, LoaderCSS:
+	case LoaderJS, LoaderJSX, LoaderTS, LoaderTSNoAmbiguousLessThan, LoaderTSX, LoaderFlow, LoaderCSS, LoaderLocalCSS:
 		return true
 	default:
 		return false
@@ -224,7 +232,10 @@ type Options struct {
 
 	OmitRuntimeForTests     bool
 	UnusedImportsTS         UnusedImportsTS
+	IsolatedModules         bool
 	UseDefineForClassFields MaybeBool
+	ExperimentalDecorators  MaybeBool
+	EmitDecoratorMetadata   MaybeBool
 	ASCIIOnly               bool
 	KeepNames               bool
 	IgnoreDCEAnnotations    bool
@@ -238,8 +249,16 @@ type Options struct {
 	TargetFromAPI          TargetFromAPI
 	UnsupportedJSFeatures  compat.JSFeature
 	UnsupportedCSSFeatures compat.CSSFeature
+	CSSPrefixData          *compat.CSSPrefixData
+	CSSRightToLeft         bool
+	CSSRebaseURLs          bool
 	TSTarget               *TSTarget
 
+	// When lowering array destructuring, assume that every value being
+	// destructured is an array and index into it directly instead of using
+	// the iterator protocol
+	AssumeIterablesAreArrays bool
+
 	// This is the original information that was used to generate the
 	// unsupported feature sets above. It's used for error messages.
 	OriginalTargetEnv string
@@ -308,9 +327,16 @@ const (
 
 	// "import { unused } from 'foo'" => "import { unused } from 'foo'" ("preserveValueImports" == true)
 	UnusedImportsKeepValues
+
+	// "import { unused } from 'foo'" => "import { unused } from 'foo'" ("verbatimModuleSyntax" == true)
+	// "import { type unused } from 'foo'" => "import {} from 'foo'"
+	UnusedImportsKeepStmtKeepValues
 )
 
-func UnusedImportsFromTsconfigValues(preserveImportsNotUsedAsValues bool, preserveValueImports bool) UnusedImportsTS {
+func UnusedImportsFromTsconfigValues(preserveImportsNotUsedAsValues bool, preserveValueImports bool, verbatimModuleSyntax bool) UnusedImportsTS {
+	if verbatimModuleSyntax || (preserveValueImports && preserveImportsNotUsedAsValues) {
+		return UnusedImportsKeepStmtKeepValues
+	}
 	if preserveValueImports {
 		return UnusedImportsKeepValues
 	}
@@ -546,6 +572,7 @@ type OnResolveArgs struct {
 	ResolveDir string
 	Kind       ast.ImportKind
 	PluginData interface{}
+	With       logger.ImportAttributes
 }
 
 type OnResolveResult struct {
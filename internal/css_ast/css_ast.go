@@ -28,6 +28,30 @@ type AST struct {
 	Rules                []Rule
 	SourceMapComment     logger.Span
 	ApproximateLineCount int32
+
+	// This is only used for files with the "local-css" loader. It contains the
+	// locally-scoped class, id, and keyframe names in the order they first
+	// appear. The bundler assigns each of them a unique name before printing.
+	LocalSymbols []LocalSymbol
+}
+
+type LocalSymbol struct {
+	Name     string
+	Loc      logger.Loc
+	Composes []ComposesName
+}
+
+// A name from a "composes" declaration. The class name for the local symbol
+// containing this declaration is also given the class name referenced here.
+type ComposesName struct {
+	Name string
+	Loc  logger.Loc
+
+	// If valid, this is a local name in the file from the "from" clause
+	ImportRecordIndex ast.Index32
+
+	// If true, this is a global name from a "from global" clause
+	IsGlobal bool
 }
 
 // We create a lot of tokens, so make sure this layout is memory-efficient.
@@ -157,6 +181,28 @@ func TokensAreCommaSeparated(tokens []Token) bool {
 	return false
 }
 
+// This converts the contents of "layer(a.b)" into ["a", "b"]. Layer names must
+// be identifiers separated by "." without any whitespace in between.
+func LayerNameFromTokens(tokens []Token) (name []string, ok bool) {
+	if n := len(tokens); (n & 1) != 0 {
+		for i, t := range tokens {
+			if i > 0 && (t.Whitespace&WhitespaceBefore) != 0 || i+1 < n && (t.Whitespace&WhitespaceAfter) != 0 {
+				return nil, false
+			}
+			if (i & 1) == 0 {
+				if t.Kind != css_lexer.TIdent {
+					return nil, false
+				}
+				name = append(name, t.Text)
+			} else if t.Kind != css_lexer.TDelimDot {
+				return nil, false
+			}
+		}
+		return name, true
+	}
+	return nil, false
+}
+
 func (t Token) FractionForPercentage() (float64, bool) {
 	if t.Kind == css_lexer.TPercentage {
 		if f, err := strconv.ParseFloat(t.PercentageValue(), 64); err == nil {
@@ -322,6 +368,7 @@ type RAtKeyframes struct {
 	AtToken string
 	Name    string
 	Blocks  []KeyframeBlock
+	IsLocal bool // If true, "Name" is a local symbol
 }
 
 type KeyframeBlock struct {
@@ -331,7 +378,7 @@ type KeyframeBlock struct {
 
 func (a *RAtKeyframes) Equal(rule R) bool {
 	b, ok := rule.(*RAtKeyframes)
-	if ok && a.AtToken == b.AtToken && a.Name == b.Name && len(a.Blocks) == len(b.Blocks) {
+	if ok && a.AtToken == b.AtToken && a.Name == b.Name && a.IsLocal == b.IsLocal && len(a.Blocks) == len(b.Blocks) {
 		for i, ai := range a.Blocks {
 			bi := b.Blocks[i]
 			if len(ai.Selectors) != len(bi.Selectors) {
@@ -374,7 +421,7 @@ type RKnownAt struct {
 
 func (a *RKnownAt) Equal(rule R) bool {
 	b, ok := rule.(*RKnownAt)
-	return ok && a.AtToken == b.AtToken && TokensEqual(a.Prelude, b.Prelude) && RulesEqual(a.Rules, a.Rules)
+	return ok && a.AtToken == b.AtToken && TokensEqual(a.Prelude, b.Prelude) && RulesEqual(a.Rules, b.Rules)
 }
 
 func (r *RKnownAt) Hash() (uint32, bool) {
@@ -393,7 +440,7 @@ type RUnknownAt struct {
 
 func (a *RUnknownAt) Equal(rule R) bool {
 	b, ok := rule.(*RUnknownAt)
-	return ok && a.AtToken == b.AtToken && TokensEqual(a.Prelude, b.Prelude) && TokensEqual(a.Block, a.Block)
+	return ok && a.AtToken == b.AtToken && TokensEqual(a.Prelude, b.Prelude) && TokensEqual(a.Block, b.Block)
 }
 
 func (r *RUnknownAt) Hash() (uint32, bool) {
@@ -404,6 +451,83 @@ func (r *RUnknownAt) Hash() (uint32, bool) {
 	return hash, true
 }
 
+// This is either the statement form "@layer a, b;" or the block form
+// "@layer a { ... }". The block form has at most one name and the name is
+// omitted for anonymous layers. Each name is a list of dot-separated parts.
+type RAtLayer struct {
+	Names    [][]string
+	Rules    []Rule
+	HasBlock bool
+}
+
+func (a *RAtLayer) Equal(rule R) bool {
+	b, ok := rule.(*RAtLayer)
+	if ok && a.HasBlock == b.HasBlock && len(a.Names) == len(b.Names) {
+		for i, ai := range a.Names {
+			if !LayerNamesEqual(ai, b.Names[i]) {
+				return false
+			}
+		}
+		return RulesEqual(a.Rules, b.Rules)
+	}
+	return false
+}
+
+// Layer rules can't be deduplicated because the order of layers is determined
+// by where each layer name first appears
+func (r *RAtLayer) Hash() (uint32, bool) {
+	return 0, false
+}
+
+func LayerNamesEqual(a []string, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i, ai := range a {
+		if ai != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+type RAtSupports struct {
+	Condition []Token
+	Rules     []Rule
+}
+
+func (a *RAtSupports) Equal(rule R) bool {
+	b, ok := rule.(*RAtSupports)
+	return ok && TokensEqual(a.Condition, b.Condition) && RulesEqual(a.Rules, b.Rules)
+}
+
+func (r *RAtSupports) Hash() (uint32, bool) {
+	hash := uint32(10)
+	hash = HashTokens(hash, r.Condition)
+	hash = HashRules(hash, r.Rules)
+	return hash, true
+}
+
+// The name and the condition are both optional, but at least one is present
+type RAtContainer struct {
+	Name      string
+	Condition []Token
+	Rules     []Rule
+}
+
+func (a *RAtContainer) Equal(rule R) bool {
+	b, ok := rule.(*RAtContainer)
+	return ok && a.Name == b.Name && TokensEqual(a.Condition, b.Condition) && RulesEqual(a.Rules, b.Rules)
+}
+
+func (r *RAtContainer) Hash() (uint32, bool) {
+	hash := uint32(11)
+	hash = helpers.HashCombineString(hash, r.Name)
+	hash = HashTokens(hash, r.Condition)
+	hash = HashRules(hash, r.Rules)
+	return hash, true
+}
+
 type RSelector struct {
 	Selectors []ComplexSelector
 	Rules     []Rule
@@ -425,22 +549,7 @@ func (a *RSelector) Equal(rule R) bool {
 
 func (r *RSelector) Hash() (uint32, bool) {
 	hash := uint32(5)
-	hash = helpers.HashCombine(hash, uint32(len(r.Selectors)))
-	for _, complex := range r.Selectors {
-		hash = helpers.HashCombine(hash, uint32(len(complex.Selectors)))
-		for _, sel := range complex.Selectors {
-			if sel.TypeSelector != nil {
-				hash = helpers.HashCombineString(hash, sel.TypeSelector.Name.Text)
-			} else {
-				hash = helpers.HashCombine(hash, 0)
-			}
-			hash = helpers.HashCombine(hash, uint32(len(sel.SubclassSelectors)))
-			for _, sub := range sel.SubclassSelectors {
-				hash = helpers.HashCombine(hash, sub.Hash())
-			}
-			hash = helpers.HashCombineString(hash, sel.Combinator)
-		}
-	}
+	hash = HashComplexSelectors(hash, r.Selectors)
 	hash = HashRules(hash, r.Rules)
 	return hash, true
 }
@@ -516,6 +625,38 @@ type ComplexSelector struct {
 	Selectors []CompoundSelector
 }
 
+func HashComplexSelectors(hash uint32, selectors []ComplexSelector) uint32 {
+	hash = helpers.HashCombine(hash, uint32(len(selectors)))
+	for _, complex := range selectors {
+		hash = helpers.HashCombine(hash, uint32(len(complex.Selectors)))
+		for _, sel := range complex.Selectors {
+			if sel.TypeSelector != nil {
+				hash = helpers.HashCombineString(hash, sel.TypeSelector.Name.Text)
+			} else {
+				hash = helpers.HashCombine(hash, 0)
+			}
+			hash = helpers.HashCombine(hash, uint32(len(sel.SubclassSelectors)))
+			for _, sub := range sel.SubclassSelectors {
+				hash = helpers.HashCombine(hash, sub.Hash())
+			}
+			hash = helpers.HashCombineString(hash, sel.Combinator)
+		}
+	}
+	return hash
+}
+
+func ComplexSelectorsEqual(a []ComplexSelector, b []ComplexSelector) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i, ai := range a {
+		if !ai.Equal(b[i]) {
+			return false
+		}
+	}
+	return true
+}
+
 func (a ComplexSelector) Equal(b ComplexSelector) bool {
 	if len(a.Selectors) != len(b.Selectors) {
 		return false
@@ -551,6 +692,11 @@ type CompoundSelector struct {
 	Combinator        string // Optional, may be ""
 	TypeSelector      *NamespacedName
 	SubclassSelectors []SS
+
+	// The "&" can appear anywhere in the compound selector, so this is the
+	// number of type and subclass selectors that came before it. For example,
+	// this is 0 for "&div", 1 for "div&", and 2 for "div.a&.b".
+	NestPrefixIndex uint32
 }
 
 type NameToken struct {
@@ -577,12 +723,13 @@ type SS interface {
 }
 
 type SSHash struct {
-	Name string
+	Name    string
+	IsLocal bool // If true, "Name" is a local symbol
 }
 
 func (a *SSHash) Equal(ss SS) bool {
 	b, ok := ss.(*SSHash)
-	return ok && a.Name == b.Name
+	return ok && a.Name == b.Name && a.IsLocal == b.IsLocal
 }
 
 func (ss *SSHash) Hash() uint32 {
@@ -592,12 +739,13 @@ func (ss *SSHash) Hash() uint32 {
 }
 
 type SSClass struct {
-	Name string
+	Name    string
+	IsLocal bool // If true, "Name" is a local symbol
 }
 
 func (a *SSClass) Equal(ss SS) bool {
 	b, ok := ss.(*SSClass)
-	return ok && a.Name == b.Name
+	return ok && a.Name == b.Name && a.IsLocal == b.IsLocal
 }
 
 func (ss *SSClass) Hash() uint32 {
@@ -644,3 +792,23 @@ func (ss *SSPseudoClass) Hash() uint32 {
 	hash = HashTokens(hash, ss.Args)
 	return hash
 }
+
+// This is used for pseudo-classes that take a selector list such as ":is()".
+// The parser generates these for ":is()" and ":where()" when the arguments are
+// a valid selector list. Lowering nested rules also generates these.
+type SSPseudoClassWithSelectorList struct {
+	Name      string
+	Selectors []ComplexSelector
+}
+
+func (a *SSPseudoClassWithSelectorList) Equal(ss SS) bool {
+	b, ok := ss.(*SSPseudoClassWithSelectorList)
+	return ok && a.Name == b.Name && ComplexSelectorsEqual(a.Selectors, b.Selectors)
+}
+
+func (ss *SSPseudoClassWithSelectorList) Hash() uint32 {
+	hash := uint32(5)
+	hash = helpers.HashCombineString(hash, ss.Name)
+	hash = HashComplexSelectors(hash, ss.Selectors)
+	return hash
+}
@@ -18,6 +18,8 @@ const (
 	DAnimationName
 	DAnimationPlayState
 	DAnimationTimingFunction
+	DAppearance
+	DBackdropFilter
 	DBackfaceVisibility
 	DBackground
 	DBackgroundAttachment
@@ -106,6 +108,7 @@ const (
 	DColumnSpan
 	DColumnWidth
 	DColumns
+	DComposes
 	DContent
 	DCounterIncrement
 	DCounterReset
@@ -172,6 +175,12 @@ const (
 	DImageRendering
 	DInlineSize
 	DInset
+	DInsetBlock
+	DInsetBlockEnd
+	DInsetBlockStart
+	DInsetInline
+	DInsetInlineEnd
+	DInsetInlineStart
 	DJustifyContent
 	DJustifyItems
 	DJustifySelf
@@ -185,9 +194,11 @@ const (
 	DListStylePosition
 	DListStyleType
 	DMargin
+	DMarginBlock
 	DMarginBlockEnd
 	DMarginBlockStart
 	DMarginBottom
+	DMarginInline
 	DMarginInlineEnd
 	DMarginInlineStart
 	DMarginLeft
@@ -233,9 +244,11 @@ const (
 	DOverscrollBehaviorX
 	DOverscrollBehaviorY
 	DPadding
+	DPaddingBlock
 	DPaddingBlockEnd
 	DPaddingBlockStart
 	DPaddingBottom
+	DPaddingInline
 	DPaddingInlineEnd
 	DPaddingInlineStart
 	DPaddingLeft
@@ -337,6 +350,8 @@ var KnownDeclarations = map[string]D{
 	"animation-name":              DAnimationName,
 	"animation-play-state":        DAnimationPlayState,
 	"animation-timing-function":   DAnimationTimingFunction,
+	"appearance":                  DAppearance,
+	"backdrop-filter":             DBackdropFilter,
 	"backface-visibility":         DBackfaceVisibility,
 	"background":                  DBackground,
 	"background-attachment":       DBackgroundAttachment,
@@ -425,6 +440,7 @@ var KnownDeclarations = map[string]D{
 	"column-span":                 DColumnSpan,
 	"column-width":                DColumnWidth,
 	"columns":                     DColumns,
+	"composes":                    DComposes,
 	"content":                     DContent,
 	"counter-increment":           DCounterIncrement,
 	"counter-reset":               DCounterReset,
@@ -491,6 +507,12 @@ var KnownDeclarations = map[string]D{
 	"image-rendering":             DImageRendering,
 	"inline-size":                 DInlineSize,
 	"inset":                       DInset,
+	"inset-block":                 DInsetBlock,
+	"inset-block-end":             DInsetBlockEnd,
+	"inset-block-start":           DInsetBlockStart,
+	"inset-inline":                DInsetInline,
+	"inset-inline-end":            DInsetInlineEnd,
+	"inset-inline-start":          DInsetInlineStart,
 	"justify-content":             DJustifyContent,
 	"justify-items":               DJustifyItems,
 	"justify-self":                DJustifySelf,
@@ -504,9 +526,11 @@ var KnownDeclarations = map[string]D{
 	"list-style-position":         DListStylePosition,
 	"list-style-type":             DListStyleType,
 	"margin":                      DMargin,
+	"margin-block":                DMarginBlock,
 	"margin-block-end":            DMarginBlockEnd,
 	"margin-block-start":          DMarginBlockStart,
 	"margin-bottom":               DMarginBottom,
+	"margin-inline":               DMarginInline,
 	"margin-inline-end":           DMarginInlineEnd,
 	"margin-inline-start":         DMarginInlineStart,
 	"margin-left":                 DMarginLeft,
@@ -552,9 +576,11 @@ var KnownDeclarations = map[string]D{
 	"overscroll-behavior-x":       DOverscrollBehaviorX,
 	"overscroll-behavior-y":       DOverscrollBehaviorY,
 	"padding":                     DPadding,
+	"padding-block":               DPaddingBlock,
 	"padding-block-end":           DPaddingBlockEnd,
 	"padding-block-start":         DPaddingBlockStart,
 	"padding-bottom":              DPaddingBottom,
+	"padding-inline":              DPaddingInline,
 	"padding-inline-end":          DPaddingInlineEnd,
 	"padding-inline-start":        DPaddingInlineStart,
 	"padding-left":                DPaddingLeft,
@@ -56,6 +56,10 @@ const (
 	TString
 	TURL
 	TWhitespace
+
+	// This is never generated by the lexer. It's used by the parser for an
+	// identifier that is a locally-scoped name in a "local-css" file.
+	TSymbol
 )
 
 var tokenToString = []string{
@@ -97,6 +101,7 @@ var tokenToString = []string{
 	"string token",
 	"URL token",
 	"whitespace",
+	"symbol",
 }
 
 func (t T) String() string {
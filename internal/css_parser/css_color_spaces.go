@@ -0,0 +1,312 @@
+package css_parser
+
+import "math"
+
+// This file implements the color space conversions from the CSS Color Module
+// Level 4 specification: https://drafts.csswg.org/css-color-4/#color-conversion-code.
+// Colors are converted through CIE XYZ with a D65 white point, which is the
+// common connection space for all supported color spaces.
+
+type colorSpace uint8
+
+const (
+	colorSpace_srgb colorSpace = iota
+	colorSpace_srgb_linear
+	colorSpace_xyz_d50
+	colorSpace_xyz_d65
+	colorSpace_lab
+	colorSpace_lch
+	colorSpace_oklab
+	colorSpace_oklch
+)
+
+var colorSpaceNames = map[string]colorSpace{
+	"lab":         colorSpace_lab,
+	"lch":         colorSpace_lch,
+	"oklab":       colorSpace_oklab,
+	"oklch":       colorSpace_oklch,
+	"srgb":        colorSpace_srgb,
+	"srgb-linear": colorSpace_srgb_linear,
+	"xyz":         colorSpace_xyz_d65,
+	"xyz-d50":     colorSpace_xyz_d50,
+	"xyz-d65":     colorSpace_xyz_d65,
+}
+
+// Polar color spaces have a hue as their third component
+func (space colorSpace) isPolar() bool {
+	return space == colorSpace_lch || space == colorSpace_oklch
+}
+
+type matrix3 [3][3]float64
+
+func (m *matrix3) multiply(x float64, y float64, z float64) (float64, float64, float64) {
+	return m[0][0]*x + m[0][1]*y + m[0][2]*z,
+		m[1][0]*x + m[1][1]*y + m[1][2]*z,
+		m[2][0]*x + m[2][1]*y + m[2][2]*z
+}
+
+var linearSRGBToXYZ = matrix3{
+	{0.41239079926595934, 0.357584339383878, 0.1804807884018343},
+	{0.21263900587151027, 0.715168678767756, 0.07219231536073371},
+	{0.01933081871559182, 0.11919477979462598, 0.9505321522496607},
+}
+
+var xyzToLinearSRGB = matrix3{
+	{3.2409699419045226, -1.537383177570094, -0.4986107602930034},
+	{-0.9692436362808796, 1.8759675015077202, 0.04155505740717559},
+	{0.05563007969699366, -0.20397695888897652, 1.0569715142428786},
+}
+
+// Bradford chromatic adaptation
+var d50ToD65 = matrix3{
+	{0.9554734527042182, -0.023098536874261423, 0.0632593086610217},
+	{-0.028369706963208136, 1.0099954580058226, 0.021041398966943008},
+	{0.012314001688319899, -0.020507696433477912, 1.3303659366080753},
+}
+
+var d65ToD50 = matrix3{
+	{1.0479298208405488, 0.022946793341019088, -0.05019222954313557},
+	{0.029627815688159344, 0.990434484573249, -0.01707382502938514},
+	{-0.009243058152591178, 0.015055144896577895, 0.7518742899580008},
+}
+
+var xyzToLMS = matrix3{
+	{0.8190224379967030, 0.3619062600528904, -0.1288737815209879},
+	{0.0329836539323885, 0.9292868615863434, 0.0361446663506424},
+	{0.0481771893596242, 0.2642395317527308, 0.6335478284694309},
+}
+
+var lmsToXYZ = matrix3{
+	{1.2268798758459243, -0.5578149944602171, 0.2813910456659647},
+	{-0.0405757452148008, 1.1122868032803170, -0.0717110580655164},
+	{-0.0763729366746601, -0.4214933324022432, 1.5869240198367816},
+}
+
+var lmsToOKLab = matrix3{
+	{0.2104542683093140, 0.7936177747023054, -0.0040720430116193},
+	{1.9779985324311684, -2.4285922420485799, 0.4505937096174110},
+	{0.0259040424655478, 0.7827717124575296, -0.8086757549230774},
+}
+
+var okLabToLMS = matrix3{
+	{1.0000000000000000, 0.3963377773761749, 0.2158037573099136},
+	{1.0000000000000000, -0.1055613458156586, -0.0638541728258133},
+	{1.0000000000000000, -0.0894841775298119, -1.2914855480194092},
+}
+
+// The D50 white point used by CIE Lab
+var d50White = [3]float64{0.3457 / 0.3585, 1, (1 - 0.3457 - 0.3585) / 0.3585}
+
+const labEpsilon = 216.0 / 24389.0
+const labKappa = 24389.0 / 27.0
+
+func srgbToLinear(c float64) float64 {
+	if abs := math.Abs(c); abs > 0.04045 {
+		return math.Copysign(math.Pow((abs+0.055)/1.055, 2.4), c)
+	}
+	return c / 12.92
+}
+
+func linearToSRGB(c float64) float64 {
+	if abs := math.Abs(c); abs > 0.0031308 {
+		return math.Copysign(1.055*math.Pow(abs, 1/2.4)-0.055, c)
+	}
+	return c * 12.92
+}
+
+func labToXYZ50(l float64, a float64, b float64) (float64, float64, float64) {
+	f1 := (l + 16) / 116
+	f0 := a/500 + f1
+	f2 := f1 - b/200
+	var x, y, z float64
+	if f0*f0*f0 > labEpsilon {
+		x = f0 * f0 * f0
+	} else {
+		x = (116*f0 - 16) / labKappa
+	}
+	if l > labKappa*labEpsilon {
+		y = f1 * f1 * f1
+	} else {
+		y = l / labKappa
+	}
+	if f2*f2*f2 > labEpsilon {
+		z = f2 * f2 * f2
+	} else {
+		z = (116*f2 - 16) / labKappa
+	}
+	return x * d50White[0], y * d50White[1], z * d50White[2]
+}
+
+func xyz50ToLab(x float64, y float64, z float64) (float64, float64, float64) {
+	f := func(v float64) float64 {
+		if v > labEpsilon {
+			return math.Cbrt(v)
+		}
+		return (labKappa*v + 16) / 116
+	}
+	f0 := f(x / d50White[0])
+	f1 := f(y / d50White[1])
+	f2 := f(z / d50White[2])
+	return 116*f1 - 16, 500 * (f0 - f1), 200 * (f1 - f2)
+}
+
+func okLabToXYZ65(l float64, a float64, b float64) (float64, float64, float64) {
+	l, m, s := okLabToLMS.multiply(l, a, b)
+	return lmsToXYZ.multiply(l*l*l, m*m*m, s*s*s)
+}
+
+func xyz65ToOKLab(x float64, y float64, z float64) (float64, float64, float64) {
+	l, m, s := xyzToLMS.multiply(x, y, z)
+	return lmsToOKLab.multiply(math.Cbrt(l), math.Cbrt(m), math.Cbrt(s))
+}
+
+func polarToRectangular(l float64, c float64, h float64) (float64, float64, float64) {
+	radians := h * (math.Pi / 180)
+	return l, c * math.Cos(radians), c * math.Sin(radians)
+}
+
+func rectangularToPolar(l float64, a float64, b float64) (float64, float64, float64) {
+	h := math.Atan2(b, a) * (180 / math.Pi)
+	if h < 0 {
+		h += 360
+	}
+	return l, math.Sqrt(a*a + b*b), h
+}
+
+// Converts from the given color space to XYZ with a D65 white point
+func colorSpaceToXYZ65(space colorSpace, c0 float64, c1 float64, c2 float64) (float64, float64, float64) {
+	switch space {
+	case colorSpace_srgb:
+		c0, c1, c2 = srgbToLinear(c0), srgbToLinear(c1), srgbToLinear(c2)
+		return linearSRGBToXYZ.multiply(c0, c1, c2)
+
+	case colorSpace_srgb_linear:
+		return linearSRGBToXYZ.multiply(c0, c1, c2)
+
+	case colorSpace_xyz_d50:
+		return d50ToD65.multiply(c0, c1, c2)
+
+	case colorSpace_lab:
+		return d50ToD65.multiply(labToXYZ50(c0, c1, c2))
+
+	case colorSpace_lch:
+		return d50ToD65.multiply(labToXYZ50(polarToRectangular(c0, c1, c2)))
+
+	case colorSpace_oklab:
+		return okLabToXYZ65(c0, c1, c2)
+
+	case colorSpace_oklch:
+		return okLabToXYZ65(polarToRectangular(c0, c1, c2))
+	}
+	return c0, c1, c2
+}
+
+// Converts from XYZ with a D65 white point to the given color space
+func xyz65ToColorSpace(space colorSpace, x float64, y float64, z float64) (float64, float64, float64) {
+	switch space {
+	case colorSpace_srgb:
+		r, g, b := xyzToLinearSRGB.multiply(x, y, z)
+		return linearToSRGB(r), linearToSRGB(g), linearToSRGB(b)
+
+	case colorSpace_srgb_linear:
+		return xyzToLinearSRGB.multiply(x, y, z)
+
+	case colorSpace_xyz_d50:
+		return d65ToD50.multiply(x, y, z)
+
+	case colorSpace_lab:
+		return xyz50ToLab(d65ToD50.multiply(x, y, z))
+
+	case colorSpace_lch:
+		return rectangularToPolar(xyz50ToLab(d65ToD50.multiply(x, y, z)))
+
+	case colorSpace_oklab:
+		return xyz65ToOKLab(x, y, z)
+
+	case colorSpace_oklch:
+		return rectangularToPolar(xyz65ToOKLab(x, y, z))
+	}
+	return x, y, z
+}
+
+// This allows for colors that are on the edge of the sRGB gamut, such as
+// "lab(100 0 0)" for white, to be slightly outside of it due to floating-point
+// error or rounding in the input. The allowed error is less than what is lost
+// when converting to 8-bit color channels anyway.
+const srgbGamutEpsilon = 0.5 / 255
+
+func isInSRGBGamut(r float64, g float64, b float64) bool {
+	return r >= -srgbGamutEpsilon && r <= 1+srgbGamutEpsilon &&
+		g >= -srgbGamutEpsilon && g <= 1+srgbGamutEpsilon &&
+		b >= -srgbGamutEpsilon && b <= 1+srgbGamutEpsilon
+}
+
+func clampToUnit(v float64) float64 {
+	return math.Max(0, math.Min(1, v))
+}
+
+func deltaEOK(l1 float64, a1 float64, b1 float64, l2 float64, a2 float64, b2 float64) float64 {
+	dl, da, db := l1-l2, a1-a2, b1-b2
+	return math.Sqrt(dl*dl + da*da + db*db)
+}
+
+// This maps a color that may be outside of the sRGB gamut into the sRGB gamut
+// by reducing its chroma in OKLCh until clipping it doesn't change it by more
+// than a just-noticeable difference. The algorithm is from the section "CSS
+// Gamut Mapping to an RGB Destination" in the specification. The result is
+// gamma-encoded sRGB with each component between 0 and 1.
+func gamutMapXYZ65ToSRGB(x float64, y float64, z float64) (float64, float64, float64) {
+	const jnd = 0.02
+	const epsilon = 0.0001
+
+	l, c, h := xyz65ToColorSpace(colorSpace_oklch, x, y, z)
+	if l >= 1 {
+		return 1, 1, 1
+	}
+	if l <= 0 {
+		return 0, 0, 0
+	}
+	if r, g, b := xyz65ToColorSpace(colorSpace_srgb, x, y, z); isInSRGBGamut(r, g, b) {
+		return clampToUnit(r), clampToUnit(g), clampToUnit(b)
+	}
+
+	// Returns the clipped color and how different it is from the original
+	clip := func(chroma float64) (float64, float64, float64, float64, bool) {
+		l0, a0, b0 := polarToRectangular(l, chroma, h)
+		x, y, z := okLabToXYZ65(l0, a0, b0)
+		r, g, b := xyz65ToColorSpace(colorSpace_srgb, x, y, z)
+		inGamut := isInSRGBGamut(r, g, b)
+		r, g, b = clampToUnit(r), clampToUnit(g), clampToUnit(b)
+		x, y, z = colorSpaceToXYZ65(colorSpace_srgb, r, g, b)
+		l1, a1, b1 := xyz65ToOKLab(x, y, z)
+		return r, g, b, deltaEOK(l0, a0, b0, l1, a1, b1), inGamut
+	}
+
+	r, g, b, e, _ := clip(c)
+	if e < jnd {
+		return r, g, b
+	}
+
+	min := 0.0
+	max := c
+	minInGamut := true
+	for max-min > epsilon {
+		chroma := (min + max) / 2
+		cr, cg, cb, e, inGamut := clip(chroma)
+		if minInGamut && inGamut {
+			min = chroma
+			continue
+		}
+		r, g, b = cr, cg, cb
+		if e < jnd {
+			if jnd-e < epsilon {
+				break
+			}
+			minInGamut = false
+			min = chroma
+		} else {
+			max = chroma
+		}
+	}
+	return r, g, b
+}
@@ -1,6 +1,8 @@
 package css_parser
 
 import (
+	"strings"
+
 	"github.com/evanw/esbuild/internal/compat"
 	"github.com/evanw/esbuild/internal/css_ast"
 	"github.com/evanw/esbuild/internal/css_lexer"
@@ -78,6 +80,10 @@ func compactTokenQuad(a css_ast.Token, b css_ast.Token, c css_ast.Token, d css_a
 }
 
 func (p *parser) processDeclarations(rules []css_ast.Rule) []css_ast.Rule {
+	if p.options.UnsupportedCSSFeatures.Has(compat.LogicalProperties | compat.InsetProperty) {
+		rules = p.lowerLogicalProperties(rules)
+	}
+
 	margin := boxTracker{key: css_ast.DMargin, keyText: "margin", allowAuto: true}
 	padding := boxTracker{key: css_ast.DPadding, keyText: "padding", allowAuto: false}
 	inset := boxTracker{key: css_ast.DInset, keyText: "inset", allowAuto: true}
@@ -150,6 +156,11 @@ func (p *parser) processDeclarations(rules []css_ast.Rule) []css_ast.Rule {
 				decl.Value = p.mangleBoxShadows(decl.Value)
 			}
 
+		case css_ast.DAnimation, css_ast.DAnimationName:
+			if p.options.MakeLocalSymbols {
+				p.processAnimationNames(decl.Value, decl.Key == css_ast.DAnimation, decl.KeyRange.Loc)
+			}
+
 		// Margin
 		case css_ast.DMargin:
 			if p.options.MangleSyntax {
@@ -252,5 +263,137 @@ func (p *parser) processDeclarations(rules []css_ast.Rule) []css_ast.Rule {
 		rules = rules[:end]
 	}
 
+	// Add the vendor-prefixed copies that the target environment needs and
+	// remove the ones that it doesn't
+	if p.options.CSSPrefixData != nil {
+		rules = p.processPrefixedDeclarations(rules)
+	}
+
 	return rules
 }
+
+var vendorPrefixes = []compat.CSSPrefix{
+	compat.WebkitPrefix,
+	compat.MozPrefix,
+	compat.MsPrefix,
+}
+
+func splitVendorPrefix(text string) (compat.CSSPrefix, string) {
+	for _, prefix := range vendorPrefixes {
+		if s := prefix.String(); strings.HasPrefix(text, s) {
+			return prefix, text[len(s):]
+		}
+	}
+	return compat.NoPrefix, text
+}
+
+func singleIdent(tokens []css_ast.Token) (string, bool) {
+	if len(tokens) == 1 && tokens[0].Kind == css_lexer.TIdent {
+		return tokens[0].Text, true
+	}
+	return "", false
+}
+
+func (p *parser) processPrefixedDeclarations(rules []css_ast.Rule) []css_ast.Rule {
+	data := p.options.CSSPrefixData
+	result := make([]css_ast.Rule, 0, len(rules))
+
+	for _, rule := range rules {
+		decl, ok := rule.Data.(*css_ast.RDeclaration)
+		if !ok {
+			result = append(result, rule)
+			continue
+		}
+
+		// "-webkit-user-select: none; user-select: none" => "user-select: none"
+		if isRedundantPrefixedDeclaration(data, decl, rules) {
+			continue
+		}
+
+		// "user-select: none" => "-webkit-user-select: none; user-select: none"
+		if prefixes, ok := data.Properties[decl.Key]; ok {
+			for _, prefix := range vendorPrefixes {
+				if keyText := prefix.String() + decl.KeyText; prefixes.Has(prefix) && !hasDeclaration(rules, keyText, nil) {
+					result = append(result, css_ast.Rule{Loc: rule.Loc, Data: &css_ast.RDeclaration{
+						KeyText:   keyText,
+						Value:     append([]css_ast.Token{}, decl.Value...),
+						KeyRange:  decl.KeyRange,
+						Important: decl.Important,
+					}})
+				}
+			}
+		}
+
+		// "position: sticky" => "position: -webkit-sticky; position: sticky"
+		if ident, ok := singleIdent(decl.Value); ok {
+			if prefixes, ok := data.Values[compat.CSSPrefixedValue{Key: decl.Key, Value: strings.ToLower(ident)}]; ok {
+				for _, prefix := range vendorPrefixes {
+					value := []css_ast.Token{{Kind: css_lexer.TIdent, Text: prefix.String() + ident, Whitespace: decl.Value[0].Whitespace}}
+					if prefixes.Has(prefix) && !hasDeclaration(rules, decl.KeyText, value) {
+						result = append(result, css_ast.Rule{Loc: rule.Loc, Data: &css_ast.RDeclaration{
+							KeyText:   decl.KeyText,
+							Value:     value,
+							KeyRange:  decl.KeyRange,
+							Key:       decl.Key,
+							Important: decl.Important,
+						}})
+					}
+				}
+			}
+		}
+
+		result = append(result, rule)
+	}
+
+	return result
+}
+
+// Returns true if there's a declaration with this key (and value, if present)
+func hasDeclaration(rules []css_ast.Rule, keyText string, value []css_ast.Token) bool {
+	for _, rule := range rules {
+		if decl, ok := rule.Data.(*css_ast.RDeclaration); ok && decl.KeyText == keyText &&
+			(value == nil || css_ast.TokensEqualIgnoringWhitespace(decl.Value, value)) {
+			return true
+		}
+	}
+	return false
+}
+
+// A prefixed declaration is redundant if the target environment doesn't need
+// that prefix and the same declaration is also present without the prefix,
+// either before or after it. Prefixed declarations that aren't in the
+// compatibility table are always kept.
+func isRedundantPrefixedDeclaration(data *compat.CSSPrefixData, decl *css_ast.RDeclaration, rules []css_ast.Rule) bool {
+	// Check for a prefixed property such as "-webkit-user-select: none"
+	if prefix, name := splitVendorPrefix(decl.KeyText); prefix != compat.NoPrefix {
+		key := css_ast.KnownDeclarations[name]
+		if !compat.IsKnownPrefixedProperty(key) || data.Properties[key].Has(prefix) {
+			return false
+		}
+		for _, rule := range rules {
+			if next, ok := rule.Data.(*css_ast.RDeclaration); ok && next.Key == key && next.Important == decl.Important &&
+				css_ast.TokensEqualIgnoringWhitespace(next.Value, decl.Value) {
+				return true
+			}
+		}
+		return false
+	}
+
+	// Check for a prefixed value such as "position: -webkit-sticky"
+	if ident, ok := singleIdent(decl.Value); ok {
+		if prefix, name := splitVendorPrefix(strings.ToLower(ident)); prefix != compat.NoPrefix {
+			value := compat.CSSPrefixedValue{Key: decl.Key, Value: name}
+			if !compat.IsKnownPrefixedValue(value) || data.Values[value].Has(prefix) {
+				return false
+			}
+			for _, rule := range rules {
+				if next, ok := rule.Data.(*css_ast.RDeclaration); ok && next.Key == decl.Key && next.Important == decl.Important {
+					if nextIdent, ok := singleIdent(next.Value); ok && strings.EqualFold(nextIdent, name) {
+						return true
+					}
+				}
+			}
+		}
+	}
+	return false
+}
@@ -407,12 +407,336 @@ func (p *parser) lowerColor(token css_ast.Token) css_ast.Token {
 					}
 				}
 			}
+
+		case "lab", "lch", "oklab", "oklch":
+			// "oklch(62.8% 0.258 29.2)" => "#ff0000"
+			if p.options.UnsupportedCSSFeatures.Has(compat.ColorFunctions) {
+				if c, ok := parseModernColor(token); ok {
+					token = p.lowerModernColor(token, c)
+				}
+			}
+
+		case "color-mix":
+			// "color-mix(in srgb, red, blue)" => "#800080"
+			if p.options.UnsupportedCSSFeatures.Has(compat.ColorMix) {
+				if c, ok := parseModernColor(token); ok {
+					token = p.lowerModernColor(token, c)
+				}
+			}
+		}
+	}
+
+	return token
+}
+
+// Colors that can't be represented in sRGB are gamut-mapped, so the lowered
+// color may look less saturated than the original on wide-gamut displays
+func (p *parser) lowerModernColor(token css_ast.Token, c xyzColor) css_ast.Token {
+	r, g, b := gamutMapXYZ65ToSRGB(c.x, c.y, c.z)
+	hex := (floatToColorByte(r) << 24) | (floatToColorByte(g) << 16) | (floatToColorByte(b) << 8) | floatToColorByte(c.alpha)
+	token.Children = nil
+
+	if hexA(hex) == 255 {
+		token.Kind = css_lexer.THash
+		token.Text = fmt.Sprintf("%06x", hex>>8)
+	} else if !p.options.UnsupportedCSSFeatures.Has(compat.HexRGBA) {
+		token.Kind = css_lexer.THash
+		token.Text = fmt.Sprintf("%08x", hex)
+	} else {
+		token.Kind = css_lexer.TFunction
+		token.Text = "rgba"
+		commaToken := p.commaToken()
+		token.Children = &[]css_ast.Token{
+			{Kind: css_lexer.TNumber, Text: strconv.Itoa(hexR(hex))}, commaToken,
+			{Kind: css_lexer.TNumber, Text: strconv.Itoa(hexG(hex))}, commaToken,
+			{Kind: css_lexer.TNumber, Text: strconv.Itoa(hexB(hex))}, commaToken,
+			{Kind: css_lexer.TNumber, Text: floatToStringForColor(c.alpha)},
 		}
 	}
 
 	return token
 }
 
+func floatToColorByte(f float64) uint32 {
+	return uint32(math.Round(clampToUnit(f) * 255))
+}
+
+// A color in CIE XYZ with a D65 white point
+type xyzColor struct {
+	x     float64
+	y     float64
+	z     float64
+	alpha float64
+}
+
+var modernColorFunctions = map[string]bool{
+	"color-mix": true,
+	"lab":       true,
+	"lch":       true,
+	"oklab":     true,
+	"oklch":     true,
+}
+
+// This handles the color functions that can describe colors outside of the
+// sRGB gamut. Other colors are handled by "parseColor" instead.
+func parseModernColor(token css_ast.Token) (xyzColor, bool) {
+	if token.Kind == css_lexer.TFunction {
+		args := *token.Children
+
+		switch token.Text {
+		case "lab":
+			// "lab(50% 40 59.5)"
+			if l, a, b, alpha, ok := parseColorComponents(args, 100, 125, 125, false); ok {
+				x, y, z := colorSpaceToXYZ65(colorSpace_lab, math.Max(0, math.Min(100, l)), a, b)
+				return xyzColor{x: x, y: y, z: z, alpha: alpha}, true
+			}
+
+		case "lch":
+			// "lch(52.2% 72.2 50)"
+			if l, c, h, alpha, ok := parseColorComponents(args, 100, 150, 0, true); ok {
+				x, y, z := colorSpaceToXYZ65(colorSpace_lch, math.Max(0, math.Min(100, l)), math.Max(0, c), h)
+				return xyzColor{x: x, y: y, z: z, alpha: alpha}, true
+			}
+
+		case "oklab":
+			// "oklab(59% 0.1 0.1)"
+			if l, a, b, alpha, ok := parseColorComponents(args, 1, 0.4, 0.4, false); ok {
+				x, y, z := colorSpaceToXYZ65(colorSpace_oklab, math.Max(0, math.Min(1, l)), a, b)
+				return xyzColor{x: x, y: y, z: z, alpha: alpha}, true
+			}
+
+		case "oklch":
+			// "oklch(60% 0.15 50)"
+			if l, c, h, alpha, ok := parseColorComponents(args, 1, 0.4, 0, true); ok {
+				x, y, z := colorSpaceToXYZ65(colorSpace_oklch, math.Max(0, math.Min(1, l)), math.Max(0, c), h)
+				return xyzColor{x: x, y: y, z: z, alpha: alpha}, true
+			}
+
+		case "color-mix":
+			return parseColorMix(args)
+		}
+
+		// Don't fall back to "parseColor" for the functions above. They are only
+		// converted to sRGB by "lowerColor" when the target doesn't support them.
+		if modernColorFunctions[token.Text] {
+			return xyzColor{}, false
+		}
+	}
+
+	// Fall back to the colors that are always inside the sRGB gamut
+	if hex, ok := parseColor(token); ok {
+		x, y, z := colorSpaceToXYZ65(colorSpace_srgb, float64(hexR(hex))/255, float64(hexG(hex))/255, float64(hexB(hex))/255)
+		return xyzColor{x: x, y: y, z: z, alpha: float64(hexA(hex)) / 255}, true
+	}
+	return xyzColor{}, false
+}
+
+// This parses "c0 c1 c2" or "c0 c1 c2 / alpha". Percentages are scaled so
+// that "100%" is the given value. If "isPolar" is true, the third component
+// is a hue instead.
+func parseColorComponents(args []css_ast.Token, scale0 float64, scale1 float64, scale2 float64, isPolar bool) (c0 float64, c1 float64, c2 float64, alpha float64, ok bool) {
+	alpha = 1
+	switch len(args) {
+	case 3:
+	case 5:
+		if args[3].Kind != css_lexer.TDelimSlash {
+			return
+		}
+		if alpha, ok = parseColorComponent(args[4], 1); !ok {
+			return
+		}
+		alpha = clampToUnit(alpha)
+	default:
+		return
+	}
+
+	if c0, ok = parseColorComponent(args[0], scale0); !ok {
+		return
+	}
+	if c1, ok = parseColorComponent(args[1], scale1); !ok {
+		return
+	}
+	if isPolar {
+		if args[2].Kind == css_lexer.TIdent && strings.EqualFold(args[2].Text, "none") {
+			c2, ok = 0, true
+		} else {
+			c2, ok = degreesForAngle(args[2])
+		}
+	} else {
+		c2, ok = parseColorComponent(args[2], scale2)
+	}
+	return
+}
+
+// Parses "none", a number, or a percentage where "100%" is the given value
+func parseColorComponent(token css_ast.Token, scale float64) (float64, bool) {
+	switch token.Kind {
+	case css_lexer.TIdent:
+		if strings.EqualFold(token.Text, "none") {
+			return 0, true
+		}
+
+	case css_lexer.TNumber:
+		if f, err := strconv.ParseFloat(token.Text, 64); err == nil {
+			return f, true
+		}
+
+	case css_lexer.TPercentage:
+		if f, err := strconv.ParseFloat(token.PercentageValue(), 64); err == nil {
+			return f * scale / 100, true
+		}
+	}
+	return 0, false
+}
+
+// The hue of a color without chroma doesn't mean anything, so it's replaced
+// by the hue of the other color when mixing
+func isPowerlessHue(space colorSpace, chroma float64) bool {
+	if space == colorSpace_lch {
+		return chroma < 0.0015
+	}
+	return chroma < 0.000004
+}
+
+// This implements "color-mix()" from the CSS Color Module Level 5
+// specification: https://drafts.csswg.org/css-color-5/#color-mix. For example,
+// "color-mix(in oklch longer hue, red 25%, blue)".
+func parseColorMix(args []css_ast.Token) (xyzColor, bool) {
+	var groups [][]css_ast.Token
+	start := 0
+	for i, t := range args {
+		if t.Kind == css_lexer.TComma {
+			groups = append(groups, args[start:i])
+			start = i + 1
+		}
+	}
+	groups = append(groups, args[start:])
+	if len(groups) != 3 {
+		return xyzColor{}, false
+	}
+
+	// Parse the color interpolation method
+	method := groups[0]
+	if len(method) < 2 || method[0].Kind != css_lexer.TIdent || method[0].Text != "in" || method[1].Kind != css_lexer.TIdent {
+		return xyzColor{}, false
+	}
+	space, ok := colorSpaceNames[strings.ToLower(method[1].Text)]
+	if !ok {
+		return xyzColor{}, false
+	}
+	hueMethod := "shorter"
+	if len(method) == 4 && space.isPolar() && method[2].Kind == css_lexer.TIdent &&
+		method[3].Kind == css_lexer.TIdent && strings.EqualFold(method[3].Text, "hue") {
+		hueMethod = strings.ToLower(method[2].Text)
+		switch hueMethod {
+		case "shorter", "longer", "increasing", "decreasing":
+		default:
+			return xyzColor{}, false
+		}
+	} else if len(method) != 2 {
+		return xyzColor{}, false
+	}
+
+	// Parse the two colors and their optional percentages
+	var colors [2]xyzColor
+	var percentages [2]float64
+	var hasPercentage [2]bool
+	for i, group := range groups[1:] {
+		var colorToken *css_ast.Token
+		for j := range group {
+			if t := group[j]; t.Kind == css_lexer.TPercentage && !hasPercentage[i] {
+				if f, err := strconv.ParseFloat(t.PercentageValue(), 64); err == nil && f >= 0 && f <= 100 {
+					percentages[i] = f
+					hasPercentage[i] = true
+					continue
+				}
+				return xyzColor{}, false
+			} else if colorToken == nil {
+				colorToken = &group[j]
+			} else {
+				return xyzColor{}, false
+			}
+		}
+		if colorToken == nil {
+			return xyzColor{}, false
+		}
+		if colors[i], ok = parseModernColor(*colorToken); !ok {
+			return xyzColor{}, false
+		}
+	}
+
+	// Normalize the percentages
+	if !hasPercentage[0] && !hasPercentage[1] {
+		percentages = [2]float64{50, 50}
+	} else if !hasPercentage[1] {
+		percentages[1] = 100 - percentages[0]
+	} else if !hasPercentage[0] {
+		percentages[0] = 100 - percentages[1]
+	}
+	sum := percentages[0] + percentages[1]
+	if sum == 0 {
+		return xyzColor{}, false
+	}
+	alphaMultiplier := math.Min(1, sum/100)
+	p0 := percentages[0] / sum
+	p1 := percentages[1] / sum
+
+	// Convert both colors into the interpolation color space
+	a0, a1 := colors[0].alpha, colors[1].alpha
+	var c0, c1 [3]float64
+	c0[0], c0[1], c0[2] = xyz65ToColorSpace(space, colors[0].x, colors[0].y, colors[0].z)
+	c1[0], c1[1], c1[2] = xyz65ToColorSpace(space, colors[1].x, colors[1].y, colors[1].z)
+
+	// Interpolate the hue separately for polar color spaces
+	var hue float64
+	if space.isPolar() {
+		if isPowerlessHue(space, c0[1]) {
+			c0[2] = c1[2]
+		} else if isPowerlessHue(space, c1[1]) {
+			c1[2] = c0[2]
+		}
+		h0, h1 := c0[2], c1[2]
+		switch hueMethod {
+		case "shorter":
+			if h1-h0 > 180 {
+				h0 += 360
+			} else if h1-h0 < -180 {
+				h1 += 360
+			}
+		case "longer":
+			if 0 < h1-h0 && h1-h0 < 180 {
+				h0 += 360
+			} else if -180 < h1-h0 && h1-h0 <= 0 {
+				h1 += 360
+			}
+		case "increasing":
+			if h1 < h0 {
+				h1 += 360
+			}
+		case "decreasing":
+			if h0 < h1 {
+				h0 += 360
+			}
+		}
+		hue = math.Mod(h0*p0+h1*p1, 360)
+	}
+
+	// Interpolate using premultiplied alpha
+	alpha := a0*p0 + a1*p1
+	var result [3]float64
+	for i := range result {
+		if alpha > 0 {
+			result[i] = (c0[i]*a0*p0 + c1[i]*a1*p1) / alpha
+		}
+	}
+	if space.isPolar() {
+		result[2] = hue
+	}
+
+	x, y, z := colorSpaceToXYZ65(space, result[0], result[1], result[2])
+	return xyzColor{x: x, y: y, z: z, alpha: alpha * alphaMultiplier}, true
+}
+
 func parseColor(token css_ast.Token) (uint32, bool) {
 	text := token.Text
 
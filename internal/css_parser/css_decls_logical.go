@@ -0,0 +1,209 @@
+package css_parser
+
+import (
+	"github.com/evanw/esbuild/internal/compat"
+	"github.com/evanw/esbuild/internal/css_ast"
+	"github.com/evanw/esbuild/internal/css_lexer"
+	"github.com/evanw/esbuild/internal/logger"
+)
+
+// This lowers the flow-relative "margin", "padding", "inset", and "border"
+// properties to the equivalent physical properties for browsers that don't
+// support them. This assumes a horizontal writing mode, so "block" always means
+// top and bottom. Whether "inline-start" means left or right depends on the writing
+// direction, which must be configured since it isn't known at compile time.
+
+type logicalSides uint8
+
+const (
+	logicalBoth logicalSides = iota
+	logicalStart
+	logicalEnd
+)
+
+type logicalProperty struct {
+	prefix   string // This is empty for "inset" since "top" has no prefix
+	suffix   string // This is only used for "border" properties such as "-width"
+	isInline bool
+	sides    logicalSides
+}
+
+var logicalProperties = map[css_ast.D]logicalProperty{
+	css_ast.DBorderBlockEnd:         {prefix: "border-", isInline: false, sides: logicalEnd},
+	css_ast.DBorderBlockEndColor:    {prefix: "border-", suffix: "-color", isInline: false, sides: logicalEnd},
+	css_ast.DBorderBlockEndStyle:    {prefix: "border-", suffix: "-style", isInline: false, sides: logicalEnd},
+	css_ast.DBorderBlockEndWidth:    {prefix: "border-", suffix: "-width", isInline: false, sides: logicalEnd},
+	css_ast.DBorderBlockStart:       {prefix: "border-", isInline: false, sides: logicalStart},
+	css_ast.DBorderBlockStartColor:  {prefix: "border-", suffix: "-color", isInline: false, sides: logicalStart},
+	css_ast.DBorderBlockStartStyle:  {prefix: "border-", suffix: "-style", isInline: false, sides: logicalStart},
+	css_ast.DBorderBlockStartWidth:  {prefix: "border-", suffix: "-width", isInline: false, sides: logicalStart},
+	css_ast.DBorderInlineEnd:        {prefix: "border-", isInline: true, sides: logicalEnd},
+	css_ast.DBorderInlineEndColor:   {prefix: "border-", suffix: "-color", isInline: true, sides: logicalEnd},
+	css_ast.DBorderInlineEndStyle:   {prefix: "border-", suffix: "-style", isInline: true, sides: logicalEnd},
+	css_ast.DBorderInlineEndWidth:   {prefix: "border-", suffix: "-width", isInline: true, sides: logicalEnd},
+	css_ast.DBorderInlineStart:      {prefix: "border-", isInline: true, sides: logicalStart},
+	css_ast.DBorderInlineStartColor: {prefix: "border-", suffix: "-color", isInline: true, sides: logicalStart},
+	css_ast.DBorderInlineStartStyle: {prefix: "border-", suffix: "-style", isInline: true, sides: logicalStart},
+	css_ast.DBorderInlineStartWidth: {prefix: "border-", suffix: "-width", isInline: true, sides: logicalStart},
+
+	css_ast.DInsetBlock:       {prefix: "", isInline: false, sides: logicalBoth},
+	css_ast.DInsetBlockEnd:    {prefix: "", isInline: false, sides: logicalEnd},
+	css_ast.DInsetBlockStart:  {prefix: "", isInline: false, sides: logicalStart},
+	css_ast.DInsetInline:      {prefix: "", isInline: true, sides: logicalBoth},
+	css_ast.DInsetInlineEnd:   {prefix: "", isInline: true, sides: logicalEnd},
+	css_ast.DInsetInlineStart: {prefix: "", isInline: true, sides: logicalStart},
+
+	css_ast.DMarginBlock:       {prefix: "margin-", isInline: false, sides: logicalBoth},
+	css_ast.DMarginBlockEnd:    {prefix: "margin-", isInline: false, sides: logicalEnd},
+	css_ast.DMarginBlockStart:  {prefix: "margin-", isInline: false, sides: logicalStart},
+	css_ast.DMarginInline:      {prefix: "margin-", isInline: true, sides: logicalBoth},
+	css_ast.DMarginInlineEnd:   {prefix: "margin-", isInline: true, sides: logicalEnd},
+	css_ast.DMarginInlineStart: {prefix: "margin-", isInline: true, sides: logicalStart},
+
+	css_ast.DPaddingBlock:       {prefix: "padding-", isInline: false, sides: logicalBoth},
+	css_ast.DPaddingBlockEnd:    {prefix: "padding-", isInline: false, sides: logicalEnd},
+	css_ast.DPaddingBlockStart:  {prefix: "padding-", isInline: false, sides: logicalStart},
+	css_ast.DPaddingInline:      {prefix: "padding-", isInline: true, sides: logicalBoth},
+	css_ast.DPaddingInlineEnd:   {prefix: "padding-", isInline: true, sides: logicalEnd},
+	css_ast.DPaddingInlineStart: {prefix: "padding-", isInline: true, sides: logicalStart},
+}
+
+func (p *parser) lowerLogicalProperties(rules []css_ast.Rule) []css_ast.Rule {
+	var results []css_ast.Rule
+
+	for i, rule := range rules {
+		var expanded []css_ast.Rule
+		if decl, ok := rule.Data.(*css_ast.RDeclaration); ok {
+			expanded = p.lowerLogicalProperty(rule.Loc, decl)
+		}
+
+		// Avoid allocating a new array if there's nothing to do
+		if expanded == nil {
+			if results != nil {
+				results = append(results, rule)
+			}
+			continue
+		}
+		if results == nil {
+			results = make([]css_ast.Rule, 0, len(rules)+len(expanded))
+			results = append(results, rules[:i]...)
+		}
+		results = append(results, expanded...)
+	}
+
+	if results == nil {
+		return rules
+	}
+	return results
+}
+
+func (p *parser) lowerLogicalProperty(loc logger.Loc, decl *css_ast.RDeclaration) []css_ast.Rule {
+	// "inset: 1px 2px" => "top: 1px; right: 2px; bottom: 1px; left: 2px"
+	if decl.Key == css_ast.DInset {
+		if !p.options.UnsupportedCSSFeatures.Has(compat.InsetProperty) {
+			return nil
+		}
+		values, ok := splitPhysicalValues(decl.Value, 4, p.options.RemoveWhitespace)
+		if !ok {
+			return nil
+		}
+		quad := [4]css_ast.Token{values[0], values[0], values[0], values[0]}
+		if len(values) > 1 {
+			quad[1], quad[3] = values[1], values[1]
+		}
+		if len(values) > 2 {
+			quad[2] = values[2]
+		}
+		if len(values) > 3 {
+			quad[3] = values[3]
+		}
+		return []css_ast.Rule{
+			physicalDeclaration(loc, decl, "top", quad[0]),
+			physicalDeclaration(loc, decl, "right", quad[1]),
+			physicalDeclaration(loc, decl, "bottom", quad[2]),
+			physicalDeclaration(loc, decl, "left", quad[3]),
+		}
+	}
+
+	logical, ok := logicalProperties[decl.Key]
+	if !ok || !p.options.UnsupportedCSSFeatures.Has(compat.LogicalProperties) {
+		return nil
+	}
+	start, end := "top", "bottom"
+	if logical.isInline {
+		start, end = "left", "right"
+		if p.options.RightToLeft {
+			start, end = end, start
+		}
+	}
+
+	switch logical.sides {
+	case logicalStart:
+		// "margin-inline-start: 1px" => "margin-left: 1px"
+		return []css_ast.Rule{{Loc: loc, Data: &css_ast.RDeclaration{
+			Key:       css_ast.KnownDeclarations[logical.prefix+start+logical.suffix],
+			KeyText:   logical.prefix + start + logical.suffix,
+			Value:     decl.Value,
+			KeyRange:  decl.KeyRange,
+			Important: decl.Important,
+		}}}
+
+	case logicalEnd:
+		// "margin-inline-end: 1px" => "margin-right: 1px"
+		return []css_ast.Rule{{Loc: loc, Data: &css_ast.RDeclaration{
+			Key:       css_ast.KnownDeclarations[logical.prefix+end+logical.suffix],
+			KeyText:   logical.prefix + end + logical.suffix,
+			Value:     decl.Value,
+			KeyRange:  decl.KeyRange,
+			Important: decl.Important,
+		}}}
+	}
+
+	// "margin-inline: 1px 2px" => "margin-left: 1px; margin-right: 2px"
+	values, ok := splitPhysicalValues(decl.Value, 2, p.options.RemoveWhitespace)
+	if !ok {
+		return nil
+	}
+	last := values[len(values)-1]
+	return []css_ast.Rule{
+		physicalDeclaration(loc, decl, logical.prefix+start, values[0]),
+		physicalDeclaration(loc, decl, logical.prefix+end, last),
+	}
+}
+
+func physicalDeclaration(loc logger.Loc, decl *css_ast.RDeclaration, keyText string, value css_ast.Token) css_ast.Rule {
+	return css_ast.Rule{Loc: loc, Data: &css_ast.RDeclaration{
+		Key:       css_ast.KnownDeclarations[keyText],
+		KeyText:   keyText,
+		Value:     []css_ast.Token{value},
+		KeyRange:  decl.KeyRange,
+		Important: decl.Important,
+	}}
+}
+
+// Each value in a shorthand is a single token since functions such as "calc()"
+// are stored as a single token with children. Splitting a value isn't possible
+// if it contains "var()" because that could substitute multiple values.
+func splitPhysicalValues(tokens []css_ast.Token, max int, removeWhitespace bool) ([]css_ast.Token, bool) {
+	if len(tokens) < 1 || len(tokens) > max {
+		return nil, false
+	}
+	values := make([]css_ast.Token, 0, len(tokens))
+	for _, t := range tokens {
+		switch t.Kind {
+		case css_lexer.TFunction:
+			if t.Text == "var" || t.Text == "env" {
+				return nil, false
+			}
+
+		case css_lexer.TComma, css_lexer.TDelimSlash, css_lexer.TSemicolon:
+			return nil, false
+		}
+		t.Whitespace = 0
+		if !removeWhitespace {
+			t.Whitespace = css_ast.WhitespaceBefore
+		}
+		values = append(values, t)
+	}
+	return values, true
+}
@@ -0,0 +1,281 @@
+package css_parser
+
+import (
+	"github.com/evanw/esbuild/internal/compat"
+	"github.com/evanw/esbuild/internal/css_ast"
+	"github.com/evanw/esbuild/internal/logger"
+)
+
+// This flattens nested rules for browsers that don't support CSS nesting:
+// https://drafts.csswg.org/css-nesting-1/. Each nested rule becomes a separate
+// top-level rule with the "&" selectors replaced by the parent selectors. This
+// tries hard to keep the specificity of each rule the same as it would be with
+// native nesting, which behaves as if the parent selectors were wrapped in an
+// ":is()" pseudo-class.
+
+func (p *parser) lowerNestingInRules(rules []css_ast.Rule) []css_ast.Rule {
+	var results []css_ast.Rule
+	for _, rule := range rules {
+		switch r := rule.Data.(type) {
+		case *css_ast.RSelector:
+			results = p.lowerNestingInRule(rule.Loc, r.Selectors, r.Rules, results)
+			continue
+
+		case *css_ast.RKnownAt:
+			r.Rules = p.lowerNestingInRules(r.Rules)
+
+		case *css_ast.RAtLayer:
+			r.Rules = p.lowerNestingInRules(r.Rules)
+
+		case *css_ast.RAtSupports:
+			r.Rules = p.lowerNestingInRules(r.Rules)
+
+		case *css_ast.RAtContainer:
+			r.Rules = p.lowerNestingInRules(r.Rules)
+		}
+		results = append(results, rule)
+	}
+	if p.options.MangleSyntax {
+		results = mangleRules(results)
+	}
+	return results
+}
+
+func (p *parser) lowerNestingInRule(loc logger.Loc, selectors []css_ast.ComplexSelector, body []css_ast.Rule, results []css_ast.Rule) []css_ast.Rule {
+	// Separate the declarations from the nested rules. Declarations that come
+	// after a nested rule still apply to the parent rule.
+	var declarations []css_ast.Rule
+	var nested []css_ast.Rule
+	for _, child := range body {
+		if _, ok := child.Data.(*css_ast.RSelector); ok {
+			nested = append(nested, child)
+			continue
+		}
+		if _, ok := conditionalGroupRules(child.Data); ok {
+			nested = append(nested, child)
+			continue
+		}
+		declarations = append(declarations, child)
+	}
+
+	// Avoid generating new rules if there's nothing to do
+	if len(nested) == 0 {
+		return append(results, css_ast.Rule{Loc: loc, Data: &css_ast.RSelector{Selectors: selectors, Rules: body}})
+	}
+	if len(declarations) > 0 {
+		results = append(results, css_ast.Rule{Loc: loc, Data: &css_ast.RSelector{Selectors: selectors, Rules: declarations}})
+	}
+
+	for _, child := range nested {
+		if c, ok := child.Data.(*css_ast.RSelector); ok {
+			substituted := p.substituteNestingSelectors(child.Loc, c.Selectors, selectors)
+			results = p.lowerNestingInRule(child.Loc, substituted, c.Rules, results)
+		} else {
+			// "a { @media screen { color: red } }" => "@media screen { a { color: red } }"
+			childRules, _ := conditionalGroupRules(child.Data)
+			rules := p.lowerNestingInRule(child.Loc, selectors, childRules, nil)
+			results = append(results, css_ast.Rule{Loc: child.Loc, Data: cloneConditionalGroupRule(child.Data, rules)})
+		}
+	}
+	return results
+}
+
+func (p *parser) substituteNestingSelectors(loc logger.Loc, children []css_ast.ComplexSelector, parents []css_ast.ComplexSelector) (results []css_ast.ComplexSelector) {
+	for _, child := range children {
+		// Nested selectors without a "&" are relative to the parent, which is
+		// the same as if they started with "& " (e.g. "> a" means "& > a")
+		nestCount := 0
+		for _, sel := range child.Selectors {
+			if sel.HasNestPrefix {
+				nestCount++
+			}
+		}
+		if nestCount == 0 || child.Selectors[0].Combinator != "" {
+			selectors := make([]css_ast.CompoundSelector, 0, len(child.Selectors)+1)
+			selectors = append(selectors, css_ast.CompoundSelector{HasNestPrefix: true})
+			child = css_ast.ComplexSelector{Selectors: append(selectors, child.Selectors...)}
+			nestCount++
+		}
+
+		// A single parent can always be substituted directly
+		if len(parents) == 1 {
+			results = append(results, p.substituteNestingSelector(loc, child, parents, nestCount))
+			continue
+		}
+
+		// Multiple parents act like ":is(a, b)", which has the specificity of the
+		// most specific parent. Expanding "a, b { & c {} }" into "a c, b c" is
+		// only equivalent if all parents have the same specificity.
+		sameSpecificity := true
+		for _, parent := range parents[1:] {
+			if specificityOfComplexSelector(parent) != specificityOfComplexSelector(parents[0]) {
+				sameSpecificity = false
+				break
+			}
+		}
+		if sameSpecificity && nestCount == 1 {
+			for _, parent := range parents {
+				results = append(results, p.substituteNestingSelector(loc, child, []css_ast.ComplexSelector{parent}, 1))
+			}
+			continue
+		}
+		if !p.options.UnsupportedCSSFeatures.Has(compat.IsPseudoClass) {
+			is := css_ast.ComplexSelector{Selectors: []css_ast.CompoundSelector{{
+				SubclassSelectors: []css_ast.SS{&css_ast.SSPseudoClassWithSelectorList{Name: "is", Selectors: parents}},
+			}}}
+			results = append(results, p.substituteNestingSelector(loc, child, []css_ast.ComplexSelector{is}, nestCount))
+			continue
+		}
+
+		// Otherwise, generate every combination of parents. This may change the
+		// specificity of the rule, so warn about it.
+		if !sameSpecificity {
+			p.log.Add(logger.Warning, &p.tracker, logger.Range{Loc: loc},
+				"Lowering this nested CSS rule for the configured target environment may change its specificity")
+		}
+		results = p.expandNestingSelectors(loc, child, parents, nestCount, nil, results)
+	}
+	return
+}
+
+// This generates one selector for each way of picking a parent for each "&"
+func (p *parser) expandNestingSelectors(
+	loc logger.Loc, child css_ast.ComplexSelector, parents []css_ast.ComplexSelector,
+	nestCount int, picked []css_ast.ComplexSelector, results []css_ast.ComplexSelector,
+) []css_ast.ComplexSelector {
+	if len(picked) == nestCount {
+		return append(results, p.substituteNestingSelector(loc, child, picked, nestCount))
+	}
+	for _, parent := range parents {
+		next := append(append([]css_ast.ComplexSelector{}, picked...), parent)
+		results = p.expandNestingSelectors(loc, child, parents, nestCount, next, results)
+	}
+	return results
+}
+
+// This replaces each "&" in the child with the corresponding parent. If there's
+// only one parent, it's used for every "&" in the child.
+func (p *parser) substituteNestingSelector(
+	loc logger.Loc, child css_ast.ComplexSelector, parents []css_ast.ComplexSelector, nestCount int,
+) css_ast.ComplexSelector {
+	var result []css_ast.CompoundSelector
+	nestIndex := 0
+
+	for i, sel := range child.Selectors {
+		if !sel.HasNestPrefix {
+			result = append(result, sel)
+			continue
+		}
+		parent := parents[0]
+		if len(parents) == nestCount {
+			parent = parents[nestIndex]
+		}
+		nestIndex++
+		sel.HasNestPrefix = false
+
+		// "a b { &.c {} }" => "a b.c"
+		// "a { .b & {} }" => ".b a"
+		// "a b { .c & {} }" => ".c :is(a b)"
+		// "a { div& {} }" => "div:is(a)"
+		last := parent.Selectors[len(parent.Selectors)-1]
+		if (i == 0 || len(parent.Selectors) == 1) && (sel.TypeSelector == nil || last.TypeSelector == nil) {
+			if i == 0 {
+				result = append(result, parent.Selectors[:len(parent.Selectors)-1]...)
+			} else {
+				last.Combinator = sel.Combinator
+			}
+			sel = mergeCompoundSelectors(last, sel)
+		} else {
+			if p.options.UnsupportedCSSFeatures.Has(compat.IsPseudoClass) {
+				p.log.Add(logger.Warning, &p.tracker, logger.Range{Loc: loc},
+					"Lowering this nested CSS rule for the configured target environment requires \":is()\", which isn't supported there")
+			}
+			subclasses := make([]css_ast.SS, 0, len(sel.SubclassSelectors)+1)
+			subclasses = append(subclasses, &css_ast.SSPseudoClassWithSelectorList{Name: "is", Selectors: []css_ast.ComplexSelector{parent}})
+			sel.SubclassSelectors = append(subclasses, sel.SubclassSelectors...)
+		}
+		result = append(result, sel)
+	}
+
+	return css_ast.ComplexSelector{Selectors: result}
+}
+
+// The parent comes first because "&" must be substituted in place. For
+// example, "a::before { &:hover {} }" must become "a::before:hover".
+func mergeCompoundSelectors(parent css_ast.CompoundSelector, child css_ast.CompoundSelector) css_ast.CompoundSelector {
+	result := css_ast.CompoundSelector{
+		HasNestPrefix:   parent.HasNestPrefix,
+		NestPrefixIndex: parent.NestPrefixIndex,
+		Combinator:      parent.Combinator,
+		TypeSelector:    parent.TypeSelector,
+	}
+	if child.TypeSelector != nil {
+		if result.TypeSelector == nil && result.NestPrefixIndex != 0 {
+			result.NestPrefixIndex++
+		}
+		result.TypeSelector = child.TypeSelector
+	}
+	result.SubclassSelectors = make([]css_ast.SS, 0, len(parent.SubclassSelectors)+len(child.SubclassSelectors))
+	result.SubclassSelectors = append(result.SubclassSelectors, parent.SubclassSelectors...)
+	result.SubclassSelectors = append(result.SubclassSelectors, child.SubclassSelectors...)
+	return result
+}
+
+// Specificity is compared component-wise: ids, then classes, attributes, and
+// pseudo-classes, then type selectors and pseudo-elements. Reference:
+// https://www.w3.org/TR/selectors-4/#specificity-rules
+type specificity [3]uint32
+
+func (a specificity) add(b specificity) specificity {
+	return specificity{a[0] + b[0], a[1] + b[1], a[2] + b[2]}
+}
+
+func (a specificity) lessThan(b specificity) bool {
+	for i := range a {
+		if a[i] != b[i] {
+			return a[i] < b[i]
+		}
+	}
+	return false
+}
+
+func specificityOfComplexSelector(complex css_ast.ComplexSelector) (result specificity) {
+	for _, sel := range complex.Selectors {
+		if sel.TypeSelector != nil && sel.TypeSelector.Name.Text != "*" && sel.TypeSelector.Name.Text != "" {
+			result[2]++
+		}
+		for _, ss := range sel.SubclassSelectors {
+			switch s := ss.(type) {
+			case *css_ast.SSHash:
+				result[0]++
+
+			case *css_ast.SSPseudoClass:
+				switch {
+				case s.IsElement:
+					result[2]++
+				case s.Name == "where":
+				case s.Name == "before" || s.Name == "after" || s.Name == "first-line" || s.Name == "first-letter":
+					// These legacy pseudo-elements can be written with a single ":"
+					result[2]++
+				default:
+					result[1]++
+				}
+
+			case *css_ast.SSPseudoClassWithSelectorList:
+				if s.Name != "where" {
+					var max specificity
+					for _, inner := range s.Selectors {
+						if it := specificityOfComplexSelector(inner); max.lessThan(it) {
+							max = it
+						}
+					}
+					result = result.add(max)
+				}
+
+			default:
+				result[1]++
+			}
+		}
+	}
+	return
+}
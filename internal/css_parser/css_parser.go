@@ -27,12 +27,36 @@ type parser struct {
 	legalCommentIndex int
 	prevError         logger.Loc
 	importRecords     []ast.ImportRecord
+	localSymbols      []css_ast.LocalSymbol
+	localScope        map[string]uint32
+	makeLocalSymbols  bool
+	composesTarget    ast.Index32
 }
 
 type Options struct {
 	UnsupportedCSSFeatures compat.CSSFeature
 	MangleSyntax           bool
 	RemoveWhitespace       bool
+
+	// If true, class names, ids, and keyframe names are locally-scoped unless
+	// they are inside ":global". This is used for the "local-css" loader.
+	MakeLocalSymbols bool
+
+	// This is nil if no browser targets were configured, in which case vendor
+	// prefixes are neither added nor removed
+	CSSPrefixData *compat.CSSPrefixData
+
+	// Logical properties such as "margin-inline-start" are lowered to physical
+	// properties using this writing direction when they aren't supported
+	RightToLeft bool
+}
+
+func (a *Options) Equal(b *Options) bool {
+	// Compare everything except "CSSPrefixData" using structural equality
+	aa, bb := *a, *b
+	aa.CSSPrefixData = nil
+	bb.CSSPrefixData = nil
+	return aa == bb && a.CSSPrefixData.Equal(b.CSSPrefixData)
 }
 
 func Parse(log logger.Log, source logger.Source, options Options) css_ast.AST {
@@ -45,18 +69,27 @@ func Parse(log logger.Log, source logger.Source, options Options) css_ast.AST {
 		tokens:        result.Tokens,
 		legalComments: result.LegalComments,
 		prevError:     logger.Loc{Start: -1},
+		localScope:    make(map[string]uint32),
 	}
 	p.end = len(p.tokens)
+	p.makeLocalSymbols = options.MakeLocalSymbols
 	rules := p.parseListOfRules(ruleContext{
 		isTopLevel:     true,
 		parseSelectors: true,
 	})
 	p.expect(css_lexer.TEndOfFile)
+	if p.options.UnsupportedCSSFeatures.Has(compat.Nesting) {
+		rules = p.lowerNestingInRules(rules)
+	}
+	if p.options.CSSPrefixData != nil {
+		rules = p.prefixPseudoElementsInRules(rules)
+	}
 	return css_ast.AST{
 		Rules:                rules,
 		ImportRecords:        p.importRecords,
 		ApproximateLineCount: result.ApproximateLineCount,
 		SourceMapComment:     result.SourceMapComment,
+		LocalSymbols:         p.localSymbols,
 	}
 }
 
@@ -227,9 +260,13 @@ loop:
 					if !didWarnAboutImport {
 					importLoop:
 						for i, before := range rules {
-							switch before.Data.(type) {
+							switch b := before.Data.(type) {
 							case *css_ast.RComment, *css_ast.RAtCharset, *css_ast.RAtImport:
 							default:
+								// Layer statements are allowed to come before "@import" rules
+								if layer, ok := b.(*css_ast.RAtLayer); ok && !layer.HasBlock {
+									continue
+								}
 								p.log.AddWithNotes(logger.Warning, &p.tracker, first, "All \"@import\" rules must come first",
 									[]logger.MsgData{p.tracker.MsgData(logger.Range{Loc: locs[i]},
 										"This rule cannot come before an \"@import\" rule")})
@@ -258,7 +295,7 @@ loop:
 			locs = append(locs, p.current().Range.Loc)
 		}
 		if context.parseSelectors {
-			rules = append(rules, p.parseSelectorRule())
+			rules = append(rules, p.parseSelectorRule(false /* isNested */))
 		} else {
 			rules = append(rules, p.parseQualifiedRuleFrom(p.index, false /* isAlreadyInvalid */))
 		}
@@ -290,10 +327,15 @@ func (p *parser) parseListOfDeclarations() (list []css_ast.Rule) {
 
 		case css_lexer.TDelimAmpersand:
 			// Reference: https://drafts.csswg.org/css-nesting-1/
-			list = append(list, p.parseSelectorRule())
+			list = append(list, p.parseSelectorRule(true /* isNested */))
 
 		default:
-			list = append(list, p.parseDeclaration())
+			// Nested rules don't have to start with "&"
+			if p.peekNestedRule() {
+				list = append(list, p.parseSelectorRule(true /* isNested */))
+			} else if rule := p.parseDeclaration(); rule.Data != nil {
+				list = append(list, rule)
+			}
 		}
 	}
 }
@@ -317,6 +359,26 @@ func mangleRules(rules []css_ast.Rule) []css_ast.Rule {
 				continue
 			}
 
+		case *css_ast.RAtLayer:
+			if r.HasBlock && len(r.Rules) == 0 {
+				// Anonymous layers can't be referenced elsewhere, so empty ones do
+				// nothing. But an empty named layer still determines layer order.
+				if len(r.Names) == 0 {
+					continue
+				}
+				rule.Data = &css_ast.RAtLayer{Names: r.Names}
+			}
+
+		case *css_ast.RAtSupports:
+			if len(r.Rules) == 0 {
+				continue
+			}
+
+		case *css_ast.RAtContainer:
+			if len(r.Rules) == 0 {
+				continue
+			}
+
 		case *css_ast.RSelector:
 			if len(r.Rules) == 0 {
 				continue
@@ -354,6 +416,12 @@ skipRule:
 					continue skipRule
 				}
 			}
+
+			// Merge adjacent at-rules with the same prelude
+			// "@supports (a: b) { c {} } @supports (a: b) { d {} }" => "@supports (a: b) { c {} d {} }"
+			if mergeAdjacentAtRules(rules[i-1].Data, rule.Data) {
+				continue skipRule
+			}
 		}
 
 		// For duplicate rules, omit all but the last copy
@@ -375,6 +443,101 @@ skipRule:
 	return rules[start:]
 }
 
+// This merges the second rule into the first rule if they are both at-rules
+// with the same prelude and returns true if it did
+func mergeAdjacentAtRules(first css_ast.R, second css_ast.R) bool {
+	switch a := first.(type) {
+	case *css_ast.RAtLayer:
+		b, ok := second.(*css_ast.RAtLayer)
+		if !ok || a.HasBlock != b.HasBlock {
+			return false
+		}
+
+		// "@layer a; @layer b, a;" => "@layer a, b;"
+		if !a.HasBlock {
+		nextName:
+			for _, name := range b.Names {
+				for _, existing := range a.Names {
+					if css_ast.LayerNamesEqual(name, existing) {
+						continue nextName
+					}
+				}
+				a.Names = append(a.Names, name)
+			}
+			return true
+		}
+
+		// Anonymous layers are always different layers
+		if len(a.Names) != 1 || len(b.Names) != 1 || !css_ast.LayerNamesEqual(a.Names[0], b.Names[0]) {
+			return false
+		}
+		a.Rules = mangleRules(append(a.Rules, b.Rules...))
+		return true
+
+	case *css_ast.RAtSupports:
+		if b, ok := second.(*css_ast.RAtSupports); ok && css_ast.TokensEqualIgnoringWhitespace(a.Condition, b.Condition) {
+			a.Rules = mangleRules(append(a.Rules, b.Rules...))
+			return true
+		}
+
+	case *css_ast.RAtContainer:
+		if b, ok := second.(*css_ast.RAtContainer); ok && a.Name == b.Name && css_ast.TokensEqualIgnoringWhitespace(a.Condition, b.Condition) {
+			a.Rules = mangleRules(append(a.Rules, b.Rules...))
+			return true
+		}
+	}
+	return false
+}
+
+// Conditional group rules such as "@media" contain rules in the same context
+// as the rule itself. This returns the rules inside of them.
+func conditionalGroupRules(rule css_ast.R) ([]css_ast.Rule, bool) {
+	switch r := rule.(type) {
+	case *css_ast.RKnownAt:
+		if specialAtRules[r.AtToken] == atRuleInheritContext {
+			return r.Rules, true
+		}
+
+	case *css_ast.RAtLayer:
+		if r.HasBlock {
+			return r.Rules, true
+		}
+
+	case *css_ast.RAtSupports:
+		return r.Rules, true
+
+	case *css_ast.RAtContainer:
+		return r.Rules, true
+	}
+	return nil, false
+}
+
+// This returns a shallow copy of a conditional group rule with other rules
+func cloneConditionalGroupRule(rule css_ast.R, rules []css_ast.Rule) css_ast.R {
+	switch r := rule.(type) {
+	case *css_ast.RKnownAt:
+		clone := *r
+		clone.Rules = rules
+		return &clone
+
+	case *css_ast.RAtLayer:
+		clone := *r
+		clone.Rules = rules
+		return &clone
+
+	case *css_ast.RAtSupports:
+		clone := *r
+		clone.Rules = rules
+		return &clone
+
+	case *css_ast.RAtContainer:
+		clone := *r
+		clone.Rules = rules
+		return &clone
+	}
+	panic("Internal error")
+}
+
 // Reference: https://developer.mozilla.org/en-US/docs/Web/HTML/Element
 var nonDeprecatedElementsSupportedByIE7 = map[string]bool{
 	"a":          true,
@@ -619,9 +782,10 @@ var specialAtRules = map[string]atRuleKind{
 	"document":      atRuleInheritContext,
 	"-moz-document": atRuleInheritContext,
 
-	"media":    atRuleInheritContext,
-	"scope":    atRuleInheritContext,
-	"supports": atRuleInheritContext,
+	"container": atRuleInheritContext,
+	"media":     atRuleInheritContext,
+	"scope":     atRuleInheritContext,
+	"supports":  atRuleInheritContext,
 }
 
 type atRuleContext struct {
@@ -670,6 +834,20 @@ func (p *parser) parseAtRule(context atRuleContext) css_ast.Rule {
 			importConditions := p.convertTokens(p.tokens[importConditionsStart:p.index])
 			kind := ast.ImportAt
 
+			// Remove an invalid layer name so that the file is imported without a layer
+			if len(importConditions) > 0 {
+				if t := importConditions[0]; t.Kind == css_lexer.TFunction && strings.EqualFold(t.Text, "layer") && t.Children != nil {
+					if name, ok := css_ast.LayerNameFromTokens(*t.Children); !ok || isCSSWideKeywordInLayerName(name) {
+						r := p.at(importConditionsStart).Range
+						if p.at(importConditionsStart).Kind == css_lexer.TWhitespace {
+							r = p.at(importConditionsStart + 1).Range
+						}
+						p.log.Add(logger.Warning, &p.tracker, r, "Expected a layer name such as \"a.b\" inside \"layer()\"")
+						importConditions = importConditions[1:]
+					}
+				}
+			}
+
 			// Insert or remove whitespace before the first token
 			if len(importConditions) > 0 {
 				kind = ast.ImportAtConditional
@@ -693,12 +871,96 @@ func (p *parser) parseAtRule(context atRuleContext) css_ast.Rule {
 			}}
 		}
 
+	case "layer":
+		// Reference: https://drafts.csswg.org/css-cascade-5/#layering
+		p.eat(css_lexer.TWhitespace)
+		var names [][]string
+		if !p.peek(css_lexer.TOpenBrace) {
+			var ok bool
+			if names, ok = p.parseLayerNames(); !ok {
+				break
+			}
+		}
+
+		switch p.current().Kind {
+		case css_lexer.TOpenBrace:
+			if len(names) > 1 {
+				p.log.Add(logger.Warning, &p.tracker, atRange, "A \"@layer\" rule with a block can only have one layer name")
+				break
+			}
+			p.advance()
+			rules := p.parseRulesInheritingContext(context)
+			p.expect(css_lexer.TCloseBrace)
+			return css_ast.Rule{Loc: atRange.Loc, Data: &css_ast.RAtLayer{Names: names, Rules: rules, HasBlock: true}}
+
+		case css_lexer.TSemicolon, css_lexer.TCloseBrace, css_lexer.TEndOfFile:
+			p.expect(css_lexer.TSemicolon)
+			return css_ast.Rule{Loc: atRange.Loc, Data: &css_ast.RAtLayer{Names: names}}
+
+		default:
+			p.expect(css_lexer.TSemicolon)
+		}
+
+	case "supports":
+		// Reference: https://drafts.csswg.org/css-conditional-3/#at-supports
+		p.eat(css_lexer.TWhitespace)
+		conditionStart := p.index
+		if !p.parseCondition() {
+			break
+		}
+		condition := p.convertTokens(p.tokens[conditionStart:p.indexBeforeWhitespace()])
+		p.eat(css_lexer.TWhitespace)
+		if !p.expect(css_lexer.TOpenBrace) {
+			break
+		}
+		rules := p.parseRulesInheritingContext(context)
+		p.expect(css_lexer.TCloseBrace)
+		return css_ast.Rule{Loc: atRange.Loc, Data: &css_ast.RAtSupports{Condition: condition, Rules: rules}}
+
+	case "container":
+		// Reference: https://drafts.csswg.org/css-contain-3/#container-rule
+		p.eat(css_lexer.TWhitespace)
+		var name string
+		if p.peek(css_lexer.TIdent) && !strings.EqualFold(p.decoded(), "not") {
+			name = p.decoded()
+			if lower := strings.ToLower(name); lower == "none" || lower == "and" || lower == "or" || cssWideKeywords[lower] {
+				p.log.Add(logger.Warning, &p.tracker, p.current().Range, fmt.Sprintf("Cannot use %q as a container name", name))
+				break
+			}
+			p.advance()
+			p.eat(css_lexer.TWhitespace)
+		}
+		var condition []css_ast.Token
+		if name == "" || !p.peek(css_lexer.TOpenBrace) {
+			conditionStart := p.index
+			if !p.parseCondition() {
+				break
+			}
+			condition = p.convertTokens(p.tokens[conditionStart:p.indexBeforeWhitespace()])
+			p.eat(css_lexer.TWhitespace)
+		}
+		if p.peek(css_lexer.TComma) {
+			// Lists of container conditions are kept as-is
+			break
+		}
+		if !p.expect(css_lexer.TOpenBrace) {
+			break
+		}
+		rules := p.parseRulesInheritingContext(context)
+		p.expect(css_lexer.TCloseBrace)
+		return css_ast.Rule{Loc: atRange.Loc, Data: &css_ast.RAtContainer{Name: name, Condition: condition, Rules: rules}}
+
 	case "keyframes", "-webkit-keyframes", "-moz-keyframes", "-ms-keyframes", "-o-keyframes":
 		p.eat(css_lexer.TWhitespace)
 		var name string
+		isLocal := false
 
 		if p.peek(css_lexer.TIdent) {
 			name = p.decoded()
+			if p.options.MakeLocalSymbols {
+				p.recordLocalSymbol(name, p.current().Range.Loc)
+				isLocal = true
+			}
 			p.advance()
 		} else if !p.expect(css_lexer.TIdent) && !p.eat(css_lexer.TString) && !p.peek(css_lexer.TOpenBrace) {
 			// Consider string names a syntax error even though they are allowed by
@@ -786,6 +1048,7 @@ func (p *parser) parseAtRule(context atRuleContext) css_ast.Rule {
 				AtToken: atToken,
 				Name:    name,
 				Blocks:  blocks,
+				IsLocal: isLocal,
 			}}
 		}
 
@@ -857,14 +1120,7 @@ prelude:
 	case atRuleInheritContext:
 		// Parse known rules whose blocks consist of whatever the current context is
 		p.advance()
-		var rules []css_ast.Rule
-		if context.isDeclarationList {
-			rules = p.parseListOfDeclarations()
-		} else {
-			rules = p.parseListOfRules(ruleContext{
-				parseSelectors: true,
-			})
-		}
+		rules := p.parseRulesInheritingContext(context)
 		p.expect(css_lexer.TCloseBrace)
 		return css_ast.Rule{Loc: atRange.Loc, Data: &css_ast.RKnownAt{AtToken: atToken, Prelude: prelude, Rules: rules}}
 
@@ -876,6 +1132,122 @@ prelude:
 	}
 }
 
+// These are reserved and can't be used as names for layers or containers
+var cssWideKeywords = map[string]bool{
+	"default":      true,
+	"inherit":      true,
+	"initial":      true,
+	"revert":       true,
+	"revert-layer": true,
+	"unset":        true,
+}
+
+func (p *parser) parseRulesInheritingContext(context atRuleContext) []css_ast.Rule {
+	if context.isDeclarationList {
+		return p.parseListOfDeclarations()
+	}
+	return p.parseListOfRules(ruleContext{
+		parseSelectors: true,
+	})
+}
+
+// Layer names are identifiers separated by "." without any whitespace:
+// https://drafts.csswg.org/css-cascade-5/#typedef-layer-name
+func isCSSWideKeywordInLayerName(name []string) bool {
+	for _, part := range name {
+		if cssWideKeywords[strings.ToLower(part)] {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *parser) parseLayerNames() (names [][]string, ok bool) {
+	for {
+		var name []string
+		for {
+			if !p.peek(css_lexer.TIdent) {
+				p.expect(css_lexer.TIdent)
+				return nil, false
+			}
+			part := p.decoded()
+			if cssWideKeywords[strings.ToLower(part)] {
+				p.log.Add(logger.Warning, &p.tracker, p.current().Range, fmt.Sprintf("Cannot use %q as a layer name", part))
+				return nil, false
+			}
+			name = append(name, part)
+			p.advance()
+			if !p.eat(css_lexer.TDelimDot) {
+				break
+			}
+		}
+		names = append(names, name)
+		p.eat(css_lexer.TWhitespace)
+		if !p.eat(css_lexer.TComma) {
+			return names, true
+		}
+		p.eat(css_lexer.TWhitespace)
+	}
+}
+
+// This validates the conditions used by "@supports" and "@container", which
+// share the same structure. Each condition in parentheses is kept as-is. Note
+// that "and" and "or" can't be mixed without additional parentheses:
+// https://drafts.csswg.org/css-conditional-3/#typedef-supports-condition
+func (p *parser) parseCondition() bool {
+	if p.peek(css_lexer.TIdent) && strings.EqualFold(p.decoded(), "not") {
+		p.advance()
+		if !p.expect(css_lexer.TWhitespace) {
+			return false
+		}
+		return p.parseConditionInParens()
+	}
+
+	if !p.parseConditionInParens() {
+		return false
+	}
+	operator := ""
+	for {
+		p.eat(css_lexer.TWhitespace)
+		if !p.peek(css_lexer.TIdent) {
+			return true
+		}
+		text := strings.ToLower(p.decoded())
+		if text != "and" && text != "or" {
+			return true
+		}
+		if operator != "" && text != operator {
+			p.log.Add(logger.Warning, &p.tracker, p.current().Range,
+				"Cannot mix \"and\" and \"or\" in the same condition without parentheses")
+			return false
+		}
+		operator = text
+		p.advance()
+		if !p.expect(css_lexer.TWhitespace) || !p.parseConditionInParens() {
+			return false
+		}
+	}
+}
+
+func (p *parser) parseConditionInParens() bool {
+	switch p.current().Kind {
+	case css_lexer.TOpenParen, css_lexer.TFunction:
+		p.parseComponentValue()
+		return true
+	}
+	p.expect(css_lexer.TOpenParen)
+	return false
+}
+
+// Returns the current index, excluding any whitespace tokens right before it
+func (p *parser) indexBeforeWhitespace() int {
+	index := p.index
+	for index > 0 && p.tokens[index-1].Kind == css_lexer.TWhitespace {
+		index--
+	}
+	return index
+}
+
 func (p *parser) convertTokens(tokens []css_lexer.Token) []css_ast.Token {
 	result, _ := p.convertTokensHelper(tokens, css_lexer.TEndOfFile, convertTokensOpts{})
 	return result
@@ -982,17 +1354,23 @@ loop:
 				// CSS variables require verbatim whitespace for correctness
 				nestedOpts.verbatimWhitespace = true
 			}
-			if token.Text == "calc" {
+			isMathFunction := token.Text == "calc" || token.Text == "min" || token.Text == "max" || token.Text == "clamp"
+			if isMathFunction {
 				nestedOpts.isInsideCalcFunction = true
 			}
 			nested, tokens = p.convertTokensHelper(tokens, css_lexer.TCloseParen, nestedOpts)
 			token.Children = &nested
 
 			// Apply "calc" simplification rules when minifying
-			if p.options.MangleSyntax && token.Text == "calc" {
+			if p.options.MangleSyntax && isMathFunction {
 				token = p.tryToReduceCalcExpression(token)
 			}
 
+			// "clamp(a, b, c)" => "max(a, min(b, c))"
+			if token.Kind == css_lexer.TFunction && token.Text == "clamp" && p.options.UnsupportedCSSFeatures.Has(compat.ClampFunction) {
+				token = lowerClamp(token)
+			}
+
 			// Treat a URL function call with a string just like a URL token
 			if token.Text == "url" && len(nested) == 1 && nested[0].Kind == css_lexer.TString {
 				token.Kind = css_lexer.TURL
@@ -1183,14 +1561,47 @@ func mangleNumber(t string) (string, bool) {
 	return t, t != original
 }
 
-func (p *parser) parseSelectorRule() css_ast.Rule {
+// Nested rules such as "a { b {} }" look like declarations at first. They can
+// be told apart by whether a "{" block comes before the end of the declaration,
+// which never happens for valid declarations other than custom properties.
+func (p *parser) peekNestedRule() bool {
+	if p.peek(css_lexer.TIdent) && strings.HasPrefix(p.decoded(), "--") {
+		return false
+	}
+	depth := 0
+	for i := p.index; i < p.end; i++ {
+		switch p.tokens[i].Kind {
+		case css_lexer.TOpenParen, css_lexer.TFunction, css_lexer.TOpenBracket:
+			depth++
+		case css_lexer.TCloseParen, css_lexer.TCloseBracket:
+			if depth > 0 {
+				depth--
+			}
+		case css_lexer.TOpenBrace:
+			return depth == 0
+		case css_lexer.TSemicolon, css_lexer.TCloseBrace:
+			if depth == 0 {
+				return false
+			}
+		}
+	}
+	return false
+}
+
+func (p *parser) parseSelectorRule(isNested bool) css_ast.Rule {
 	preludeStart := p.index
 
 	// Try parsing the prelude as a selector list
-	if list, ok := p.parseSelectorList(); ok {
+	if list, ok := p.parseSelectorList(isNested); ok {
+		if p.options.UnsupportedCSSFeatures.Has(compat.IsPseudoClass) {
+			list = p.lowerIsAndWhereInSelectors(p.tokens[preludeStart].Range.Loc, list, isNested)
+		}
 		selector := css_ast.RSelector{Selectors: list}
 		if p.expect(css_lexer.TOpenBrace) {
+			oldComposesTarget := p.composesTarget
+			p.composesTarget = p.singleLocalClass(list)
 			selector.Rules = p.parseListOfDeclarations()
+			p.composesTarget = oldComposesTarget
 			p.expect(css_lexer.TCloseBrace)
 			return css_ast.Rule{Loc: p.tokens[preludeStart].Range.Loc, Data: &selector}
 		}
@@ -1283,6 +1694,13 @@ stop:
 	value := p.tokens[valueStart:p.index]
 	verbatimWhitespace := strings.HasPrefix(keyText, "--")
 
+	// "composes" declarations are removed from the output and are instead
+	// turned into additional class names for the JavaScript export object
+	if p.options.MakeLocalSymbols && keyText == "composes" {
+		p.parseComposes(keyToken.Range, value)
+		return css_ast.Rule{}
+	}
+
 	// Remove trailing "!important"
 	important := false
 	i := len(value) - 1
@@ -0,0 +1,236 @@
+package css_parser
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/evanw/esbuild/internal/ast"
+	"github.com/evanw/esbuild/internal/css_ast"
+	"github.com/evanw/esbuild/internal/css_lexer"
+	"github.com/evanw/esbuild/internal/logger"
+)
+
+// This implements the "local-css" loader, which is esbuild's take on CSS
+// modules: https://github.com/css-modules/css-modules. Class names, ids, and
+// keyframe names are local to the file unless they are wrapped in ":global".
+// The bundler later gives each local name a unique name across the bundle.
+
+func (p *parser) recordLocalSymbol(name string, loc logger.Loc) uint32 {
+	if index, ok := p.localScope[name]; ok {
+		return index
+	}
+	index := uint32(len(p.localSymbols))
+	p.localSymbols = append(p.localSymbols, css_ast.LocalSymbol{Name: name, Loc: loc})
+	p.localScope[name] = index
+	return index
+}
+
+// Returns true if the current token is the start of ":local" or ":global"
+// (with or without parentheses). Also returns whether it's ":local" or not.
+func (p *parser) peekLocalOrGlobal() (isLocal bool, ok bool) {
+	if !p.options.MakeLocalSymbols || !p.peek(css_lexer.TColon) {
+		return
+	}
+	if next := p.next(); next.Kind == css_lexer.TIdent || next.Kind == css_lexer.TFunction {
+		switch next.DecodedText(p.source.Contents) {
+		case "local":
+			return true, true
+		case "global":
+			return false, true
+		}
+	}
+	return
+}
+
+// This parses "(...)" after ":local" or ":global" and merges the contents
+// into the given compound selector. The parentheses must contain a single
+// compound selector.
+func (p *parser) parseLocalOrGlobalArgs(sel *css_ast.CompoundSelector, isLocal bool) bool {
+	oldMakeLocalSymbols := p.makeLocalSymbols
+	p.makeLocalSymbols = isLocal
+	p.eat(css_lexer.TWhitespace)
+	inner, ok := p.parseCompoundSelector()
+	p.makeLocalSymbols = oldMakeLocalSymbols
+	if !ok {
+		return false
+	}
+	p.eat(css_lexer.TWhitespace)
+	if !p.expect(css_lexer.TCloseParen) {
+		return false
+	}
+
+	if inner.TypeSelector != nil {
+		if sel.TypeSelector != nil || len(sel.SubclassSelectors) > 0 {
+			p.log.Add(logger.Warning, &p.tracker, p.at(p.index-1).Range,
+				"Type selectors inside \":local(...)\" or \":global(...)\" must come first in the selector")
+		} else {
+			sel.TypeSelector = inner.TypeSelector
+		}
+	}
+	sel.SubclassSelectors = append(sel.SubclassSelectors, inner.SubclassSelectors...)
+	return true
+}
+
+// Class names inside pseudo-class arguments such as ":not(.foo)" are stored
+// as tokens instead of as selectors. Turn the local ones into symbols.
+func (p *parser) makeLocalSymbolsInTokens(tokens []css_ast.Token, loc logger.Loc) {
+	for i := range tokens {
+		t := &tokens[i]
+		if t.Kind == css_lexer.TIdent && i > 0 {
+			if prev := tokens[i-1]; prev.Kind == css_lexer.TDelimDot &&
+				(prev.Whitespace&css_ast.WhitespaceAfter) == 0 && (t.Whitespace&css_ast.WhitespaceBefore) == 0 {
+				t.Kind = css_lexer.TSymbol
+				p.recordLocalSymbol(t.Text, loc)
+			}
+		}
+		if t.Children != nil {
+			p.makeLocalSymbolsInTokens(*t.Children, loc)
+		}
+	}
+}
+
+// Returns the local symbol for the rule's selector if it's a single local
+// class name such as ".foo". This is the only place where "composes" is valid.
+func (p *parser) singleLocalClass(list []css_ast.ComplexSelector) ast.Index32 {
+	if len(list) == 1 && len(list[0].Selectors) == 1 {
+		if sel := list[0].Selectors[0]; !sel.HasNestPrefix && sel.TypeSelector == nil && len(sel.SubclassSelectors) == 1 {
+			if class, ok := sel.SubclassSelectors[0].(*css_ast.SSClass); ok && class.IsLocal {
+				return ast.MakeIndex32(p.localScope[class.Name])
+			}
+		}
+	}
+	return ast.Index32{}
+}
+
+// Parses the value of a "composes" declaration. This is a list of class names
+// optionally followed by either "from global" or a "from" clause with a path
+// such as "composes: a b from './file.css'".
+func (p *parser) parseComposes(keyRange logger.Range, tokens []css_lexer.Token) {
+	if !p.composesTarget.IsValid() {
+		p.log.Add(logger.Warning, &p.tracker, keyRange, "\"composes\" only works inside single class selectors")
+		return
+	}
+
+	var names []css_ast.ComposesName
+	importRecordIndex := ast.Index32{}
+	isGlobal := false
+
+	for i := 0; i < len(tokens); i++ {
+		t := tokens[i]
+		switch t.Kind {
+		case css_lexer.TWhitespace:
+			continue
+
+		case css_lexer.TIdent:
+			text := t.DecodedText(p.source.Contents)
+			if text != "from" || len(names) == 0 {
+				names = append(names, css_ast.ComposesName{Name: text, Loc: t.Range.Loc})
+				continue
+			}
+
+			// Parse the "from" clause
+			i++
+			for i < len(tokens) && tokens[i].Kind == css_lexer.TWhitespace {
+				i++
+			}
+			if i < len(tokens) && tokens[i].Kind == css_lexer.TString {
+				importRecordIndex = ast.MakeIndex32(uint32(len(p.importRecords)))
+				p.importRecords = append(p.importRecords, ast.ImportRecord{
+					Kind:  ast.ImportComposesFrom,
+					Path:  logger.Path{Text: tokens[i].DecodedText(p.source.Contents)},
+					Range: tokens[i].Range,
+				})
+			} else if i < len(tokens) && tokens[i].Kind == css_lexer.TIdent && tokens[i].DecodedText(p.source.Contents) == "global" {
+				isGlobal = true
+			} else {
+				r := keyRange
+				if i < len(tokens) {
+					r = tokens[i].Range
+				}
+				p.log.Add(logger.Warning, &p.tracker, r, "Expected a string or \"global\" after \"from\"")
+				return
+			}
+
+			// Nothing is allowed after the "from" clause
+			for i++; i < len(tokens); i++ {
+				if tokens[i].Kind != css_lexer.TWhitespace {
+					p.log.Add(logger.Warning, &p.tracker, tokens[i].Range,
+						fmt.Sprintf("Unexpected %q", p.source.TextForRange(tokens[i].Range)))
+					return
+				}
+			}
+
+		default:
+			p.log.Add(logger.Warning, &p.tracker, t.Range, fmt.Sprintf("Unexpected %q", p.source.TextForRange(t.Range)))
+			return
+		}
+	}
+
+	if len(names) == 0 {
+		p.log.Add(logger.Warning, &p.tracker, keyRange, "Expected at least one class name after \"composes\"")
+		return
+	}
+
+	for i := range names {
+		names[i].ImportRecordIndex = importRecordIndex
+		names[i].IsGlobal = isGlobal
+
+		// Names without a "from" clause are local names in this file
+		if !importRecordIndex.IsValid() && !isGlobal {
+			p.recordLocalSymbol(names[i].Name, names[i].Loc)
+		}
+	}
+
+	target := &p.localSymbols[p.composesTarget.GetIndex()]
+	target.Composes = append(target.Composes, names...)
+}
+
+// These are the keywords that can appear in the "animation" shorthand. Any
+// other identifier is the name of a keyframes rule.
+var animationKeywords = map[string]bool{
+	// <single-animation-timing-function>
+	"ease":        true,
+	"ease-in":     true,
+	"ease-in-out": true,
+	"ease-out":    true,
+	"linear":      true,
+	"step-end":    true,
+	"step-start":  true,
+
+	// <single-animation-iteration-count>
+	"infinite": true,
+
+	// <single-animation-direction>
+	"alternate":         true,
+	"alternate-reverse": true,
+	"normal":            true,
+	"reverse":           true,
+
+	// <single-animation-fill-mode>
+	"backwards": true,
+	"both":      true,
+	"forwards":  true,
+
+	// <single-animation-play-state>
+	"paused":  true,
+	"running": true,
+}
+
+func (p *parser) processAnimationNames(tokens []css_ast.Token, isShorthand bool, loc logger.Loc) {
+	for i := range tokens {
+		t := &tokens[i]
+		if t.Kind != css_lexer.TIdent {
+			continue
+		}
+		switch lower := strings.ToLower(t.Text); lower {
+		case "none", "initial", "inherit", "unset", "revert":
+			continue
+		default:
+			if isShorthand && animationKeywords[lower] {
+				continue
+			}
+		}
+		t.Kind = css_lexer.TSymbol
+		p.recordLocalSymbol(t.Text, loc)
+	}
+}
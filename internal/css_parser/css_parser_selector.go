@@ -1,14 +1,24 @@
 package css_parser
 
 import (
+	"fmt"
+	"strings"
+
+	"github.com/evanw/esbuild/internal/compat"
 	"github.com/evanw/esbuild/internal/css_ast"
 	"github.com/evanw/esbuild/internal/css_lexer"
+	"github.com/evanw/esbuild/internal/logger"
 )
 
-func (p *parser) parseSelectorList() (list []css_ast.ComplexSelector, ok bool) {
+func (p *parser) parseSelectorList(isNested bool) (list []css_ast.ComplexSelector, ok bool) {
+	// A bare ":local" or ":global" only lasts until the end of the selector
+	defer func() {
+		p.makeLocalSymbols = p.options.MakeLocalSymbols
+	}()
+
 	// Parse the first selector
 	p.eat(css_lexer.TWhitespace)
-	sel, good := p.parseComplexSelector()
+	sel, good := p.parseComplexSelector(isNested)
 	if !good {
 		return
 	}
@@ -21,7 +31,8 @@ func (p *parser) parseSelectorList() (list []css_ast.ComplexSelector, ok bool) {
 			break
 		}
 		p.eat(css_lexer.TWhitespace)
-		sel, good := p.parseComplexSelector()
+		p.makeLocalSymbols = p.options.MakeLocalSymbols
+		sel, good := p.parseComplexSelector(isNested)
 		if !good {
 			return
 		}
@@ -32,12 +43,21 @@ func (p *parser) parseSelectorList() (list []css_ast.ComplexSelector, ok bool) {
 	return
 }
 
-func (p *parser) parseComplexSelector() (result css_ast.ComplexSelector, ok bool) {
+func (p *parser) parseComplexSelector(isNested bool) (result css_ast.ComplexSelector, ok bool) {
+	// Nested selectors can be relative such as "> a", which means "& > a"
+	var combinator string
+	if isNested {
+		if combinator = p.parseCombinator(); combinator != "" {
+			p.eat(css_lexer.TWhitespace)
+		}
+	}
+
 	// Parent
 	sel, good := p.parseCompoundSelector()
 	if !good {
 		return
 	}
+	sel.Combinator = combinator
 	result.Selectors = append(result.Selectors, sel)
 
 	for {
@@ -78,6 +98,19 @@ func (p *parser) parseCompoundSelector() (sel css_ast.CompoundSelector, ok bool)
 		sel.HasNestPrefix = true
 	}
 
+	// A bare ":local" or ":global" changes the mode for the rest of the
+	// selector. It doesn't produce a selector itself, so skip over it.
+	for !sel.HasNestPrefix {
+		isLocal, isLocalOrGlobal := p.peekLocalOrGlobal()
+		if !isLocalOrGlobal || p.next().Kind != css_lexer.TIdent {
+			break
+		}
+		p.advance()
+		p.advance()
+		p.makeLocalSymbols = isLocal
+		p.eat(css_lexer.TWhitespace)
+	}
+
 	// Parse the type selector
 	switch p.current().Kind {
 	case css_lexer.TDelimBar, css_lexer.TIdent, css_lexer.TDelimAsterisk:
@@ -111,15 +144,33 @@ subclassSelectors:
 				break subclassSelectors
 			}
 			name := p.decoded()
-			sel.SubclassSelectors = append(sel.SubclassSelectors, &css_ast.SSHash{Name: name})
+			if p.makeLocalSymbols {
+				p.recordLocalSymbol(name, p.current().Range.Loc)
+			}
+			sel.SubclassSelectors = append(sel.SubclassSelectors, &css_ast.SSHash{Name: name, IsLocal: p.makeLocalSymbols})
 			p.advance()
 
 		case css_lexer.TDelimDot:
 			p.advance()
 			name := p.decoded()
-			sel.SubclassSelectors = append(sel.SubclassSelectors, &css_ast.SSClass{Name: name})
+			isLocal := p.makeLocalSymbols && p.peek(css_lexer.TIdent)
+			if isLocal {
+				p.recordLocalSymbol(name, p.current().Range.Loc)
+			}
+			sel.SubclassSelectors = append(sel.SubclassSelectors, &css_ast.SSClass{Name: name, IsLocal: isLocal})
 			p.expect(css_lexer.TIdent)
 
+		case css_lexer.TDelimAmpersand:
+			// The nesting selector can appear anywhere in the compound selector
+			p.advance()
+			if !sel.HasNestPrefix {
+				sel.HasNestPrefix = true
+				sel.NestPrefixIndex = uint32(len(sel.SubclassSelectors))
+				if sel.TypeSelector != nil {
+					sel.NestPrefixIndex++
+				}
+			}
+
 		case css_lexer.TOpenBracket:
 			p.advance()
 			attr, good := p.parseAttributeSelector()
@@ -129,6 +180,17 @@ subclassSelectors:
 			sel.SubclassSelectors = append(sel.SubclassSelectors, &attr)
 
 		case css_lexer.TColon:
+			if isLocal, isLocalOrGlobal := p.peekLocalOrGlobal(); isLocalOrGlobal {
+				isFunction := p.next().Kind == css_lexer.TFunction
+				p.advance()
+				p.advance()
+				if !isFunction {
+					p.makeLocalSymbols = isLocal
+				} else if !p.parseLocalOrGlobalArgs(&sel, isLocal) {
+					return
+				}
+				continue
+			}
 			if p.next().Kind == css_lexer.TColon {
 				// Special-case the start of the pseudo-element selector section
 				for p.current().Kind == css_lexer.TColon {
@@ -155,6 +217,10 @@ subclassSelectors:
 				}
 				break subclassSelectors
 			}
+			if list, ok := p.parsePseudoClassWithSelectorList(); ok {
+				sel.SubclassSelectors = append(sel.SubclassSelectors, list)
+				continue
+			}
 			pseudo := p.parsePseudoClassSelector()
 			sel.SubclassSelectors = append(sel.SubclassSelectors, &pseudo)
 
@@ -268,7 +334,11 @@ func (p *parser) parsePseudoClassSelector() css_ast.SSPseudoClass {
 	if p.peek(css_lexer.TFunction) {
 		text := p.decoded()
 		p.advance()
+		argsLoc := p.current().Range.Loc
 		args := p.convertTokens(p.parseAnyValue())
+		if p.makeLocalSymbols {
+			p.makeLocalSymbolsInTokens(args, argsLoc)
+		}
 		p.expect(css_lexer.TCloseParen)
 		return css_ast.SSPseudoClass{Name: text, Args: args}
 	}
@@ -281,6 +351,103 @@ func (p *parser) parsePseudoClassSelector() css_ast.SSPseudoClass {
 	return sel
 }
 
+// This parses the arguments to ":is()" and ":where()" as a selector list so
+// that they can be lowered for older browsers. These pseudo-classes have a
+// forgiving grammar, so anything that isn't a valid selector list is left as
+// the original tokens instead of generating a warning. The arguments to
+// ":not()" are only parsed if they may need to be lowered.
+func (p *parser) parsePseudoClassWithSelectorList() (*css_ast.SSPseudoClassWithSelectorList, bool) {
+	if p.next().Kind != css_lexer.TFunction {
+		return nil, false
+	}
+	name := p.next().DecodedText(p.source.Contents)
+	if name != "is" && name != "where" && name != "not" {
+		return nil, false
+	}
+
+	// Find the matching ")"
+	closeIndex := -1
+	depth := 0
+	for i := p.index + 2; i < p.end && closeIndex == -1; i++ {
+		switch p.tokens[i].Kind {
+		case css_lexer.TOpenParen, css_lexer.TFunction:
+			depth++
+		case css_lexer.TCloseParen:
+			if depth == 0 {
+				closeIndex = i
+			}
+			depth--
+		}
+	}
+	if closeIndex == -1 {
+		return nil, false
+	}
+	if name == "not" && (!p.options.UnsupportedCSSFeatures.Has(compat.IsPseudoClass) || !p.hasIsOrWhereBefore(closeIndex)) {
+		return nil, false
+	}
+
+	// Parse the arguments as if the ")" were the end of the file, and discard
+	// any warnings since they are handled by the fallback path
+	oldIndex := p.index
+	oldEnd := p.end
+	oldLog := p.log
+	oldPrevError := p.prevError
+	oldMakeLocalSymbols := p.makeLocalSymbols
+	p.index += 2
+	p.end = closeIndex
+	p.log = logger.NewDeferLog(logger.DeferLogAll)
+	var list []css_ast.ComplexSelector
+	ok := true
+	for {
+		p.makeLocalSymbols = oldMakeLocalSymbols
+		p.eat(css_lexer.TWhitespace)
+		sel, good := p.parseComplexSelector(false /* isNested */)
+		if !good {
+			ok = false
+			break
+		}
+		list = append(list, sel)
+		p.eat(css_lexer.TWhitespace)
+		if !p.eat(css_lexer.TComma) {
+			break
+		}
+	}
+	if ok && (p.index != closeIndex || len(p.log.Done()) > 0) {
+		ok = false
+	}
+
+	// The nesting selector isn't substituted inside these pseudo-classes
+	for _, complex := range list {
+		for _, compound := range complex.Selectors {
+			if compound.HasNestPrefix {
+				ok = false
+			}
+		}
+	}
+
+	p.end = oldEnd
+	p.log = oldLog
+	p.prevError = oldPrevError
+	p.makeLocalSymbols = oldMakeLocalSymbols
+	if !ok {
+		p.index = oldIndex
+		return nil, false
+	}
+	p.index = closeIndex + 1
+	return &css_ast.SSPseudoClassWithSelectorList{Name: name, Selectors: list}, true
+}
+
+func (p *parser) hasIsOrWhereBefore(end int) bool {
+	for i := p.index + 2; i+1 < end; i++ {
+		if p.tokens[i].Kind == css_lexer.TColon && p.tokens[i+1].Kind == css_lexer.TFunction {
+			if name := p.tokens[i+1].DecodedText(p.source.Contents); name == "is" || name == "where" {
+				return true
+			}
+		}
+	}
+	return false
+}
+
 func (p *parser) parseAnyValue() []css_lexer.Token {
 	// Reference: https://drafts.csswg.org/css-syntax-3/#typedef-declaration-value
 
@@ -290,6 +457,9 @@ func (p *parser) parseAnyValue() []css_lexer.Token {
 loop:
 	for {
 		switch p.current().Kind {
+		case css_lexer.TEndOfFile:
+			break loop
+
 		case css_lexer.TCloseParen, css_lexer.TCloseBracket, css_lexer.TCloseBrace:
 			last := len(p.stack) - 1
 			if last < 0 || !p.peek(p.stack[last]) {
@@ -340,3 +510,320 @@ func (p *parser) parseCombinator() string {
 		return ""
 	}
 }
+
+// Prefixed pseudo-elements don't always have the same name as the unprefixed
+// pseudo-element, so each one is listed explicitly
+var prefixedPseudoElements = map[string]map[compat.CSSPrefix]string{
+	"placeholder": {
+		compat.WebkitPrefix: "-webkit-input-placeholder",
+		compat.MozPrefix:    "-moz-placeholder",
+		compat.MsPrefix:     "-ms-input-placeholder",
+	},
+}
+
+// Browsers drop the whole rule if any selector in it is invalid, so prefixed
+// pseudo-elements must go in separate rules. For example, "input::placeholder"
+// becomes "input::-webkit-input-placeholder" followed by the original rule.
+func (p *parser) prefixPseudoElementsInRules(rules []css_ast.Rule) []css_ast.Rule {
+	var results []css_ast.Rule
+
+	for _, rule := range rules {
+		switch r := rule.Data.(type) {
+		case *css_ast.RKnownAt:
+			r.Rules = p.prefixPseudoElementsInRules(r.Rules)
+
+		case *css_ast.RAtLayer:
+			r.Rules = p.prefixPseudoElementsInRules(r.Rules)
+
+		case *css_ast.RAtSupports:
+			r.Rules = p.prefixPseudoElementsInRules(r.Rules)
+
+		case *css_ast.RAtContainer:
+			r.Rules = p.prefixPseudoElementsInRules(r.Rules)
+
+		case *css_ast.RSelector:
+			r.Rules = p.prefixPseudoElementsInRules(r.Rules)
+			for _, prefix := range vendorPrefixes {
+				var selectors []css_ast.ComplexSelector
+				for _, complex := range r.Selectors {
+					if prefixed, ok := p.prefixPseudoElements(complex, prefix); ok {
+						selectors = append(selectors, prefixed)
+					}
+				}
+				if selectors != nil {
+					// Give the prefixed rule its own copy so that later passes don't affect both
+					clone := &css_ast.RSelector{Selectors: selectors, Rules: append([]css_ast.Rule{}, r.Rules...)}
+					results = append(results, css_ast.Rule{Loc: rule.Loc, Data: clone})
+				}
+			}
+		}
+
+		results = append(results, rule)
+	}
+
+	return results
+}
+
+func (p *parser) prefixPseudoElements(complex css_ast.ComplexSelector, prefix compat.CSSPrefix) (result css_ast.ComplexSelector, ok bool) {
+	result.Selectors = make([]css_ast.CompoundSelector, len(complex.Selectors))
+	for i, sel := range complex.Selectors {
+		result.Selectors[i] = sel
+		isCloned := false
+		for j, ss := range sel.SubclassSelectors {
+			if pseudo, isPseudo := ss.(*css_ast.SSPseudoClass); isPseudo && pseudo.IsElement && pseudo.Args == nil &&
+				p.options.CSSPrefixData.PseudoElements[pseudo.Name].Has(prefix) {
+				if !isCloned {
+					// Avoid mutating the original selector
+					result.Selectors[i].SubclassSelectors = append([]css_ast.SS{}, sel.SubclassSelectors...)
+					isCloned = true
+				}
+				ok = true
+				result.Selectors[i].SubclassSelectors[j] = &css_ast.SSPseudoClass{
+					Name:      prefixedPseudoElements[pseudo.Name][prefix],
+					IsElement: true,
+				}
+			}
+		}
+	}
+	return
+}
+
+// Browsers without ":is()" and ":where()" drop the whole rule, so these are
+// expanded into separate selectors instead. For example, ":is(a, b) c" becomes
+// "a c, b c". Complex selectors such as ":is(a b)" can only be expanded at the
+// start of a selector because "c :is(a b)" also matches "a c b".
+func (p *parser) lowerIsAndWhereInSelectors(loc logger.Loc, list []css_ast.ComplexSelector, isNested bool) []css_ast.ComplexSelector {
+	var results []css_ast.ComplexSelector
+	expansion := selectorExpansion{isNested: isNested}
+
+	for _, complex := range list {
+		if expanded, ok := expansion.expand(complex, nil); ok {
+			results = appendUniqueSelectors(results, expanded)
+		} else {
+			results = appendUniqueSelectors(results, []css_ast.ComplexSelector{complex})
+		}
+	}
+
+	if expansion.failedName != "" {
+		p.log.Add(logger.Warning, &p.tracker, logger.Range{Loc: loc}, fmt.Sprintf(
+			"Transforming %q to the configured target environment is not supported here", ":"+expansion.failedName+"()"))
+		return list
+	}
+	if expansion.changedSpecificityName != "" {
+		p.log.Add(logger.Warning, &p.tracker, logger.Range{Loc: loc}, fmt.Sprintf(
+			"Lowering %q for the configured target environment may change the specificity of this rule", ":"+expansion.changedSpecificityName+"()"))
+	}
+
+	// Every expanded selector may have been dropped if it can never match
+	if len(results) == 0 {
+		return list
+	}
+	return results
+}
+
+type selectorExpansion struct {
+	failedName             string
+	changedSpecificityName string
+	isNested               bool
+}
+
+func (e *selectorExpansion) expand(complex css_ast.ComplexSelector, results []css_ast.ComplexSelector) ([]css_ast.ComplexSelector, bool) {
+	for i, compound := range complex.Selectors {
+		for j, ss := range compound.SubclassSelectors {
+			pseudo, ok := ss.(*css_ast.SSPseudoClassWithSelectorList)
+			if !ok {
+				continue
+			}
+			if pseudo.Name == "not" {
+				if name := nameOfIsOrWhere(pseudo.Selectors); name != "" {
+					return e.expandNot(complex, i, j, name, results)
+				}
+				continue
+			}
+			if pseudo.Name != "is" && pseudo.Name != "where" {
+				continue
+			}
+
+			// ":is()" has the specificity of its most specific argument and
+			// ":where()" has no specificity, but the expanded selectors each have
+			// the specificity of their own argument
+			if e.changedSpecificityName == "" {
+				for _, arg := range pseudo.Selectors {
+					it := specificityOfComplexSelector(arg)
+					if (pseudo.Name == "where" && it != specificity{}) ||
+						(pseudo.Name == "is" && it != specificityOfComplexSelector(pseudo.Selectors[0])) {
+						e.changedSpecificityName = pseudo.Name
+						break
+					}
+				}
+			}
+
+			for _, arg := range pseudo.Selectors {
+				last := len(arg.Selectors) - 1
+				if last > 0 && (i > 0 || compound.Combinator != "" || e.isNested) {
+					e.failedName = pseudo.Name
+					return nil, false
+				}
+				merged, ok := substituteSelectorListArg(compound, j, arg.Selectors[last])
+				if !ok {
+					e.failedName = pseudo.Name
+					return nil, false
+				}
+				if merged == nil {
+					// This combination can never match anything, so omit it
+					continue
+				}
+
+				// "a :is(b, c.d) e" => "a b e, a c.d e"
+				selectors := make([]css_ast.CompoundSelector, 0, len(complex.Selectors)+last)
+				selectors = append(selectors, complex.Selectors[:i]...)
+				selectors = append(selectors, arg.Selectors[:last]...)
+				selectors = append(selectors, *merged)
+				selectors = append(selectors, complex.Selectors[i+1:]...)
+
+				// Expand any remaining pseudo-classes recursively
+				if results, ok = e.expand(css_ast.ComplexSelector{Selectors: selectors}, results); !ok {
+					return nil, false
+				}
+			}
+			return results, true
+		}
+	}
+
+	return append(results, complex), true
+}
+
+// Old browsers only allow a single simple selector inside ":not()", so each
+// expanded argument becomes a separate ":not()". For example, ":not(:is(a, b))"
+// becomes ":not(a):not(b)".
+func (e *selectorExpansion) expandNot(complex css_ast.ComplexSelector, i int, j int, name string, results []css_ast.ComplexSelector) ([]css_ast.ComplexSelector, bool) {
+	compound := complex.Selectors[i]
+	pseudo := compound.SubclassSelectors[j].(*css_ast.SSPseudoClassWithSelectorList)
+	var args []css_ast.ComplexSelector
+	for _, arg := range pseudo.Selectors {
+		inner := selectorExpansion{isNested: true}
+		expanded, ok := inner.expand(arg, nil)
+		if !ok {
+			e.failedName = inner.failedName
+			return nil, false
+		}
+		args = appendUniqueSelectors(args, expanded)
+	}
+
+	nots := make([]css_ast.SS, 0, len(args))
+	for _, arg := range args {
+		if len(arg.Selectors) != 1 || !isSimpleSelector(arg.Selectors[0]) {
+			e.failedName = name
+			return nil, false
+		}
+		nots = append(nots, &css_ast.SSPseudoClassWithSelectorList{Name: "not", Selectors: []css_ast.ComplexSelector{arg}})
+	}
+
+	// "a:not(:is(b, c)) d" => "a:not(b):not(c) d"
+	result := compound
+	result.SubclassSelectors = make([]css_ast.SS, 0, len(compound.SubclassSelectors)+len(nots)-1)
+	result.SubclassSelectors = append(result.SubclassSelectors, compound.SubclassSelectors[:j]...)
+	result.SubclassSelectors = append(result.SubclassSelectors, nots...)
+	result.SubclassSelectors = append(result.SubclassSelectors, compound.SubclassSelectors[j+1:]...)
+	selectors := append([]css_ast.CompoundSelector{}, complex.Selectors...)
+	selectors[i] = result
+	lowered := css_ast.ComplexSelector{Selectors: selectors}
+
+	// ":not()" has the specificity of its most specific argument
+	if e.changedSpecificityName == "" && specificityOfComplexSelector(complex) != specificityOfComplexSelector(lowered) {
+		e.changedSpecificityName = name
+	}
+
+	// Expand any remaining pseudo-classes recursively
+	return e.expand(lowered, results)
+}
+
+// This returns true for a compound selector with a single type or subclass
+// selector, which is all that ":not()" accepts in older browsers
+func isSimpleSelector(compound css_ast.CompoundSelector) bool {
+	if compound.HasNestPrefix || compound.Combinator != "" {
+		return false
+	}
+	if compound.TypeSelector != nil {
+		return len(compound.SubclassSelectors) == 0
+	}
+	if len(compound.SubclassSelectors) != 1 {
+		return false
+	}
+	_, isList := compound.SubclassSelectors[0].(*css_ast.SSPseudoClassWithSelectorList)
+	return !isList
+}
+
+func nameOfIsOrWhere(list []css_ast.ComplexSelector) string {
+	for _, complex := range list {
+		for _, compound := range complex.Selectors {
+			for _, ss := range compound.SubclassSelectors {
+				if pseudo, ok := ss.(*css_ast.SSPseudoClassWithSelectorList); ok {
+					if pseudo.Name == "is" || pseudo.Name == "where" {
+						return pseudo.Name
+					}
+					if name := nameOfIsOrWhere(pseudo.Selectors); name != "" {
+						return name
+					}
+				}
+			}
+		}
+	}
+	return ""
+}
+
+// This replaces the subclass selector at the given index with the argument.
+// It returns nil if the result can never match because it has two different
+// type selectors, and false if it can't be represented without ":is()".
+func substituteSelectorListArg(compound css_ast.CompoundSelector, index int, arg css_ast.CompoundSelector) (*css_ast.CompoundSelector, bool) {
+	// Pseudo-elements aren't valid inside ":is()" and ":where()"
+	for _, ss := range arg.SubclassSelectors {
+		if pseudo, ok := ss.(*css_ast.SSPseudoClass); ok && pseudo.IsElement {
+			return nil, false
+		}
+	}
+
+	result := compound
+	if arg.TypeSelector != nil {
+		switch {
+		case compound.TypeSelector == nil || isUniversalTypeSelector(compound.TypeSelector):
+			// "*:is(a)" => "a"
+			result.TypeSelector = arg.TypeSelector
+
+		case isUniversalTypeSelector(arg.TypeSelector) || compound.TypeSelector.Equal(*arg.TypeSelector):
+			// "a:is(*)" => "a"
+
+		case compound.TypeSelector.NamespacePrefix != nil || arg.TypeSelector.NamespacePrefix != nil ||
+			strings.EqualFold(compound.TypeSelector.Name.Text, arg.TypeSelector.Name.Text):
+			// Whether these match the same elements depends on the document
+			return nil, false
+
+		default:
+			// "a:is(b)" never matches anything
+			return nil, true
+		}
+	}
+
+	result.SubclassSelectors = make([]css_ast.SS, 0, len(compound.SubclassSelectors)+len(arg.SubclassSelectors)-1)
+	result.SubclassSelectors = append(result.SubclassSelectors, compound.SubclassSelectors[:index]...)
+	result.SubclassSelectors = append(result.SubclassSelectors, arg.SubclassSelectors...)
+	result.SubclassSelectors = append(result.SubclassSelectors, compound.SubclassSelectors[index+1:]...)
+	return &result, true
+}
+
+func isUniversalTypeSelector(name *css_ast.NamespacedName) bool {
+	return name.NamespacePrefix == nil && name.Name.Kind == css_lexer.TDelimAsterisk
+}
+
+func appendUniqueSelectors(results []css_ast.ComplexSelector, selectors []css_ast.ComplexSelector) []css_ast.ComplexSelector {
+nextSelector:
+	for _, sel := range selectors {
+		for _, prev := range results {
+			if sel.Equal(prev) {
+				continue nextSelector
+			}
+		}
+		results = append(results, sel)
+	}
+	return results
+}
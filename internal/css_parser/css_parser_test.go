@@ -507,6 +507,68 @@ func TestColorHSLA(t *testing.T) {
 	expectPrintedLowerMangle(t, "a { color: hsl(var(--x) var(--y) var(--z)) }", "a {\n  color: hsl(var(--x) var(--y) var(--z));\n}\n")
 }
 
+func TestColorModern(t *testing.T) {
+	// Colors are converted when minifying for targets that don't support them
+	expectPrintedLowerMangle(t, "a { color: oklch(62.8% 0.2577 29.23) }", "a {\n  color: red;\n}\n")
+	expectPrintedLowerMangle(t, "a { color: oklch(0.628 0.2577 29.23deg) }", "a {\n  color: red;\n}\n")
+	expectPrintedLowerMangle(t, "a { color: oklab(100% 0 0) }", "a {\n  color: #fff;\n}\n")
+	expectPrintedLowerMangle(t, "a { color: oklab(0 0 0 / 50%) }", "a {\n  color: rgba(0, 0, 0, .5);\n}\n")
+	expectPrintedLowerMangle(t, "a { color: lab(100 0 0) }", "a {\n  color: #fff;\n}\n")
+	expectPrintedLowerMangle(t, "a { color: lab(50% 0 0) }", "a {\n  color: #777;\n}\n")
+	expectPrintedLowerMangle(t, "a { color: lch(50 0 none) }", "a {\n  color: #777;\n}\n")
+	expectPrintedLowerMangle(t, "a { color: color-mix(in srgb, red, blue) }", "a {\n  color: purple;\n}\n")
+	expectPrintedLowerMangle(t, "a { color: color-mix(in srgb, red 25%, blue) }", "a {\n  color: #4000bf;\n}\n")
+	expectPrintedLowerMangle(t, "a { color: color-mix(in srgb, red, blue 75%) }", "a {\n  color: #4000bf;\n}\n")
+	expectPrintedLowerMangle(t, "a { color: color-mix(in srgb, 25% red, blue) }", "a {\n  color: #4000bf;\n}\n")
+	expectPrintedLowerMangle(t, "a { color: color-mix(in srgb, red 20%, blue 20%) }", "a {\n  color: rgba(128, 0, 128, .4);\n}\n")
+	expectPrintedLowerMangle(t, "a { color: color-mix(in srgb, red, #0000) }", "a {\n  color: rgba(255, 0, 0, .5);\n}\n")
+	expectPrintedLowerMangle(t, "a { color: color-mix(in oklab, white, black) }", "a {\n  color: #636363;\n}\n")
+	expectPrintedLowerMangle(t, "a { color: color-mix(in srgb-linear, white, black) }", "a {\n  color: #bcbcbc;\n}\n")
+	expectPrintedLowerMangle(t, "a { color: color-mix(in lch, white, black) }", "a {\n  color: #777;\n}\n")
+
+	// Colors are kept when minifying for targets that support them, even if
+	// they are inside the sRGB gamut
+	expectPrintedMangle(t, "a { color: oklch(62.8% 0.2577 29.23) }", "a {\n  color: oklch(62.8% .2577 29.23);\n}\n")
+	expectPrintedMangle(t, "a { color: lch(50 0 none) }", "a {\n  color: lch(50 0 none);\n}\n")
+	expectPrintedMangle(t, "a { color: color-mix(in srgb, red, blue) }", "a {\n  color: color-mix(in srgb, red, blue);\n}\n")
+	expectPrintedMangle(t, "a { box-shadow: 0 0 1px lab(50% 0 0) }", "a {\n  box-shadow: 0 0 1px lab(50% 0 0);\n}\n")
+	modern := Options{UnsupportedCSSFeatures: compat.UnsupportedCSSFeatures(map[compat.Engine][]int{compat.Chrome: {120}}), MangleSyntax: true}
+	expectPrintedWithOptions(t, modern, "a { color: oklch(62.8% 0.2577 29.23) }", "a {\n  color: oklch(62.8% .2577 29.23);\n}\n", "")
+	expectPrintedWithOptions(t, modern, "a { border-color: lch(54.29% 106.84 40.85) }", "a {\n  border-color: lch(54.29% 106.84 40.85);\n}\n", "")
+	old := Options{UnsupportedCSSFeatures: compat.UnsupportedCSSFeatures(map[compat.Engine][]int{compat.Chrome: {100}}), MangleSyntax: true}
+	expectPrintedWithOptions(t, old, "a { color: oklch(62.8% 0.2577 29.23) }", "a {\n  color: red;\n}\n", "")
+
+	// Colors outside the sRGB gamut are kept when minifying
+	expectPrintedMangle(t, "a { color: oklch(70% 0.4 150) }", "a {\n  color: oklch(70% .4 150);\n}\n")
+	expectPrintedMangle(t, "a { color: color-mix(in oklch, oklch(70% 0.4 150), green) }",
+		"a {\n  color: color-mix(in oklch, oklch(70% .4 150), green);\n}\n")
+
+	// Invalid colors are left alone
+	expectPrintedMangle(t, "a { color: oklch(70% 0.1) }", "a {\n  color: oklch(70% .1);\n}\n")
+	expectPrintedMangle(t, "a { color: oklch(var(--l) 0.1 150) }", "a {\n  color: oklch(var(--l) .1 150);\n}\n")
+	expectPrintedMangle(t, "a { color: color-mix(in hsl, red, blue) }", "a {\n  color: color-mix(in hsl, red, blue);\n}\n")
+	expectPrintedMangle(t, "a { color: color-mix(in srgb, red 0%, blue 0%) }", "a {\n  color: color-mix(in srgb, red 0%, blue 0%);\n}\n")
+	expectPrintedMangle(t, "a { color: color-mix(in srgb, currentColor, blue) }", "a {\n  color: color-mix(in srgb, currentColor, blue);\n}\n")
+	expectPrintedMangle(t, "a { color: color-mix(in srgb longer hue, red, blue) }", "a {\n  color: color-mix(in srgb longer hue, red, blue);\n}\n")
+}
+
+func TestLowerColorModern(t *testing.T) {
+	expectPrintedLower(t, "a { color: oklch(62.8% 0.2577 29.23) }", "a {\n  color: #ff0000;\n}\n")
+	expectPrintedLower(t, "a { color: lab(54.29 80.8 69.89) }", "a {\n  color: #ff0000;\n}\n")
+	expectPrintedLower(t, "a { color: lch(54.29% 106.84 40.85) }", "a {\n  color: #ff0000;\n}\n")
+	expectPrintedLower(t, "a { color: oklab(0.628 0.2249 0.1258 / 0.5) }", "a {\n  color: rgba(255, 0, 0, 0.5);\n}\n")
+	expectPrintedLower(t, "a { color: oklch(70% 0.4 150) }", "a {\n  color: #00c248;\n}\n")
+	expectPrintedLower(t, "a { color: oklch(100% 0.4 150) }", "a {\n  color: #ffffff;\n}\n")
+	expectPrintedLower(t, "a { color: oklch(0% 0.4 150) }", "a {\n  color: #000000;\n}\n")
+	expectPrintedLower(t, "a { color: color-mix(in srgb, red, blue) }", "a {\n  color: #800080;\n}\n")
+	expectPrintedLower(t, "a { color: color-mix(in oklch, red, blue) }", "a {\n  color: #b700be;\n}\n")
+	expectPrintedLower(t, "a { color: color-mix(in oklch longer hue, red, blue) }", "a {\n  color: #008a0e;\n}\n")
+	expectPrintedLower(t, "a { color: color-mix(in oklch, oklch(70% 0.4 150), white) }", "a {\n  color: #52f184;\n}\n")
+	expectPrintedLower(t, "a { color: oklch(var(--l) 0.1 150) }", "a {\n  color: oklch(var(--l) 0.1 150);\n}\n")
+	expectPrintedLowerMangle(t, "a { color: oklch(62.8% 0.2577 29.23 / 50%) }", "a {\n  color: rgba(255, 0, 0, .5);\n}\n")
+	expectPrintedLowerMangle(t, "a { color: color-mix(in srgb, red, blue) }", "a {\n  color: purple;\n}\n")
+}
+
 func TestLowerColor(t *testing.T) {
 	expectPrintedLower(t, "a { color: rebeccapurple }", "a {\n  color: #663399;\n}\n")
 
@@ -548,7 +610,7 @@ func TestDeclaration(t *testing.T) {
 	expectPrinted(t, ".decl { a: b; }", ".decl {\n  a: b;\n}\n")
 	expectPrinted(t, ".decl { a: b; c: d }", ".decl {\n  a: b;\n  c: d;\n}\n")
 	expectPrinted(t, ".decl { a: b; c: d; }", ".decl {\n  a: b;\n  c: d;\n}\n")
-	expectParseError(t, ".decl { a { b: c; } }", "<stdin>: WARNING: Expected \":\"\n")
+	expectPrinted(t, ".decl { a { b: c; } }", ".decl {\n  a {\n    b: c;\n  }\n}\n")
 	expectPrinted(t, ".decl { & a { b: c; } }", ".decl {\n  & a {\n    b: c;\n  }\n}\n")
 
 	// See http://browserhacks.com/
@@ -670,6 +732,17 @@ func TestSelector(t *testing.T) {
 	expectParseError(t, "_:\\ms-lang(x) {}", "")
 }
 
+func TestIsAndWhereSelector(t *testing.T) {
+	expectPrinted(t, ":is(a, b) c {}", ":is(a, b) c {\n}\n")
+	expectPrinted(t, ":is( a , b ) c {}", ":is(a, b) c {\n}\n")
+	expectPrinted(t, "a:where(.b > .c, #d) {}", "a:where(.b > .c, #d) {\n}\n")
+	expectPrinted(t, ":is(:where(a), b) {}", ":is(:where(a), b) {\n}\n")
+	expectPrinted(t, ":is(1, a) {}", ":is(1, a) {\n}\n")
+	expectPrinted(t, ":is(a, ) {}", ":is(a, ) {\n}\n")
+	expectPrinted(t, ":not(a, b) {}", ":not(a, b) {\n}\n")
+	expectParseError(t, ":is(a {}", "<stdin>: WARNING: Expected \")\" but found end of file\n")
+}
+
 func TestNestedSelector(t *testing.T) {
 	expectPrinted(t, "& {}", "& {\n}\n")
 	expectPrinted(t, "& b {}", "& b {\n}\n")
@@ -690,11 +763,175 @@ func TestNestedSelector(t *testing.T) {
 	expectPrinted(t, "a { &[b] {} }", "a {\n  &[b] {\n  }\n}\n")
 }
 
+func TestNestedSelectorRelaxed(t *testing.T) {
+	expectPrinted(t, "a { b {} }", "a {\n  b {\n  }\n}\n")
+	expectPrinted(t, "a { .b {} }", "a {\n  .b {\n  }\n}\n")
+	expectPrinted(t, "a { b:hover {} }", "a {\n  b:hover {\n  }\n}\n")
+	expectPrinted(t, "a { > b {} }", "a {\n  > b {\n  }\n}\n")
+	expectPrinted(t, "a { + b {} }", "a {\n  + b {\n  }\n}\n")
+	expectPrinted(t, "a { ~ b {} }", "a {\n  ~ b {\n  }\n}\n")
+	expectPrinted(t, "a { .b & {} }", "a {\n  .b & {\n  }\n}\n")
+	expectPrinted(t, "a { .b& {} }", "a {\n  .b& {\n  }\n}\n")
+	expectPrinted(t, "a { div& {} }", "a {\n  div& {\n  }\n}\n")
+	expectPrinted(t, "a { div.b&.c {} }", "a {\n  div.b&.c {\n  }\n}\n")
+	expectPrinted(t, "a { .b > & {} }", "a {\n  .b > & {\n  }\n}\n")
+	expectPrinted(t, ".x, .y { & + & {} }", ".x,\n.y {\n  & + & {\n  }\n}\n")
+	expectPrinted(t, "a { color: red; b { color: blue } }", "a {\n  color: red;\n  b {\n    color: blue;\n  }\n}\n")
+	expectPrinted(t, "a { --x: { b: c }; }", "a {\n  --x: { b: c };\n}\n")
+	expectPrinted(t, "a { @media screen { color: red } }", "a {\n  @media screen {\n    color: red;\n  }\n}\n")
+}
+
+func expectPrintedLowerNesting(t *testing.T, unsupported compat.CSSFeature, contents string, expected string, expectedLog string) {
+	t.Helper()
+	t.Run(contents+" [lower]", func(t *testing.T) {
+		t.Helper()
+		log := logger.NewDeferLog(logger.DeferLogNoVerboseOrDebug)
+		tree := Parse(log, test.SourceForTest(contents), Options{UnsupportedCSSFeatures: compat.Nesting | unsupported})
+		msgs := log.Done()
+		text := ""
+		for _, msg := range msgs {
+			text += msg.String(logger.OutputOptions{}, logger.TerminalInfo{})
+		}
+		test.AssertEqualWithDiff(t, text, expectedLog)
+		result := css_printer.Print(tree, css_printer.Options{})
+		test.AssertEqualWithDiff(t, string(result.CSS), expected)
+	})
+}
+
+func TestLowerNesting(t *testing.T) {
+	expectPrintedLowerNesting(t, 0, "a { color: red }", "a {\n  color: red;\n}\n", "")
+	expectPrintedLowerNesting(t, 0, "a { & {} }", "a {\n}\n", "")
+	expectPrintedLowerNesting(t, 0, "a { b {} }", "a b {\n}\n", "")
+	expectPrintedLowerNesting(t, 0, "a { & b {} }", "a b {\n}\n", "")
+	expectPrintedLowerNesting(t, 0, "a { > b {} }", "a > b {\n}\n", "")
+	expectPrintedLowerNesting(t, 0, "a { & > b {} }", "a > b {\n}\n", "")
+	expectPrintedLowerNesting(t, 0, "a { ~ b {} }", "a ~ b {\n}\n", "")
+	expectPrintedLowerNesting(t, 0, "a { &:hover {} }", "a:hover {\n}\n", "")
+	expectPrintedLowerNesting(t, 0, "a { &.b {} }", "a.b {\n}\n", "")
+	expectPrintedLowerNesting(t, 0, "a { .b& {} }", "a.b {\n}\n", "")
+	expectPrintedLowerNesting(t, 0, "a { .b & {} }", ".b a {\n}\n", "")
+	expectPrintedLowerNesting(t, 0, "a { & + & {} }", "a + a {\n}\n", "")
+	expectPrintedLowerNesting(t, 0, ".a { div& {} }", "div.a {\n}\n", "")
+	expectPrintedLowerNesting(t, 0, "a { div& {} }", "div:is(a) {\n}\n", "")
+	expectPrintedLowerNesting(t, 0, "a b { &.c {} }", "a b.c {\n}\n", "")
+	expectPrintedLowerNesting(t, 0, "a b { .c & {} }", ".c :is(a b) {\n}\n", "")
+	expectPrintedLowerNesting(t, 0, "a::before { &:hover {} }", "a::before:hover {\n}\n", "")
+	expectPrintedLowerNesting(t, 0, "a { b { c {} } }", "a b c {\n}\n", "")
+	expectPrintedLowerNesting(t, 0, "a { b, c { d {} } }", "a b d,\na c d {\n}\n", "")
+
+	// Declarations stay with the parent rule and nested rules come afterward
+	expectPrintedLowerNesting(t, 0, "a { color: red; &:hover { color: blue } background: green }",
+		"a {\n  color: red;\n  background: green;\n}\na:hover {\n  color: blue;\n}\n", "")
+	expectPrintedLowerNesting(t, 0, "@media screen { a { & b { color: red } } }",
+		"@media screen {\n  a b {\n    color: red;\n  }\n}\n", "")
+
+	// Nested at-rules are moved outside of the parent rule
+	expectPrintedLowerNesting(t, 0, "a { color: red; @media screen { color: blue } }",
+		"a {\n  color: red;\n}\n@media screen {\n  a {\n    color: blue;\n  }\n}\n", "")
+	expectPrintedLowerNesting(t, 0, "a { @media screen { & b { color: blue } } }",
+		"@media screen {\n  a b {\n    color: blue;\n  }\n}\n", "")
+	expectPrintedLowerNesting(t, 0, "a { @supports (display: grid) { @media screen { color: blue } } }",
+		"@supports (display: grid) {\n  @media screen {\n    a {\n      color: blue;\n    }\n  }\n}\n", "")
+
+	// Multiple parents with the same specificity can be expanded
+	expectPrintedLowerNesting(t, 0, "a, b { & c {} }", "a c,\nb c {\n}\n", "")
+	expectPrintedLowerNesting(t, 0, ".a, .b { &:hover {} }", ".a:hover,\n.b:hover {\n}\n", "")
+	expectPrintedLowerNesting(t, compat.IsPseudoClass, "a, b { & + & {} }", "a + a,\na + b,\nb + a,\nb + b {\n}\n", "")
+
+	// Otherwise ":is()" is needed to preserve specificity
+	expectPrintedLowerNesting(t, 0, "a, .b { & c {} }", ":is(a, .b) c {\n}\n", "")
+	expectPrintedLowerNesting(t, 0, "a, b { & + & {} }", ":is(a, b) + :is(a, b) {\n}\n", "")
+	expectPrintedLowerNesting(t, 0, "#a, .b .c { &:hover {} }", ":is(#a, .b .c):hover {\n}\n", "")
+	expectPrintedLowerNesting(t, 0, "a, :where(b) { & c {} }", ":is(a, :where(b)) c {\n}\n", "")
+	expectPrintedLowerNesting(t, 0, "a, :is(.b) { & c {} }", ":is(a, :is(.b)) c {\n}\n", "")
+	expectPrintedLowerNesting(t, 0, ".a, :is(.b) { & c {} }", ".a c,\n:is(.b) c {\n}\n", "")
+
+	// Warn when specificity can't be preserved
+	expectPrintedLowerNesting(t, compat.IsPseudoClass, "a, .b { & c {} }", "a c,\n.b c {\n}\n",
+		"<stdin>: WARNING: Lowering this nested CSS rule for the configured target environment may change its specificity\n")
+	expectPrintedLowerNesting(t, compat.IsPseudoClass, "a b { .c & {} }", ".c :is(a b) {\n}\n",
+		"<stdin>: WARNING: Lowering this nested CSS rule for the configured target environment requires \":is()\", which isn't supported there\n")
+
+	// A top-level "&" has no parent to substitute
+	expectPrintedLowerNesting(t, 0, "& { b {} }", "& b {\n}\n", "")
+}
+
+func expectPrintedWithOptions(t *testing.T, options Options, contents string, expected string, expectedLog string) {
+	t.Helper()
+	t.Run(contents+" [options]", func(t *testing.T) {
+		t.Helper()
+		log := logger.NewDeferLog(logger.DeferLogNoVerboseOrDebug)
+		tree := Parse(log, test.SourceForTest(contents), options)
+		msgs := log.Done()
+		text := ""
+		for _, msg := range msgs {
+			text += msg.String(logger.OutputOptions{}, logger.TerminalInfo{})
+		}
+		test.AssertEqualWithDiff(t, text, expectedLog)
+		result := css_printer.Print(tree, css_printer.Options{RemoveWhitespace: options.RemoveWhitespace})
+		test.AssertEqualWithDiff(t, string(result.CSS), expected)
+	})
+}
+
+func TestLowerIsAndWhere(t *testing.T) {
+	lower := Options{UnsupportedCSSFeatures: compat.IsPseudoClass}
+	specificity := func(name string) string {
+		return "<stdin>: WARNING: Lowering \":" + name + "()\" for the configured target environment may change the specificity of this rule\n"
+	}
+	unsupported := func(name string) string {
+		return "<stdin>: WARNING: Transforming \":" + name + "()\" to the configured target environment is not supported here\n"
+	}
+
+	expectPrintedWithOptions(t, lower, ":is(a, b) c {}", "a c,\nb c {\n}\n", "")
+	expectPrintedWithOptions(t, lower, "a:is(.b, .c) {}", "a.b,\na.c {\n}\n", "")
+	expectPrintedWithOptions(t, lower, ":is(.a, .b) :is(.c, .d) {}", ".a .c,\n.a .d,\n.b .c,\n.b .d {\n}\n", "")
+	expectPrintedWithOptions(t, lower, ":is(a b, c d) > e {}", "a b > e,\nc d > e {\n}\n", "")
+	expectPrintedWithOptions(t, lower, "*:is(a) {}", "a {\n}\n", "")
+	expectPrintedWithOptions(t, lower, "a:is(*) {}", "a {\n}\n", "")
+	expectPrintedWithOptions(t, lower, ":is(:is(.a, .b), .c) {}", ".a,\n.b,\n.c {\n}\n", "")
+	expectPrintedWithOptions(t, lower, ":is(.a, .a) {}", ".a {\n}\n", "")
+	expectPrintedWithOptions(t, lower, ":where(*) .a {}", "* .a {\n}\n", "")
+	expectPrintedWithOptions(t, lower, "a, :is(a) {}", "a {\n}\n", "")
+
+	// Combinations with two different type selectors never match
+	expectPrintedWithOptions(t, lower, "a:is(b, a.c) {}", "a.c {\n}\n", specificity("is"))
+	expectPrintedWithOptions(t, lower, "a:is(b) {}", "a:is(b) {\n}\n", "")
+
+	// Warn when specificity can't be preserved
+	expectPrintedWithOptions(t, lower, ":is(.a, #b) {}", ".a,\n#b {\n}\n", specificity("is"))
+	expectPrintedWithOptions(t, lower, ":where(.a) {}", ".a {\n}\n", specificity("where"))
+	expectPrintedWithOptions(t, lower, "a, :where(b c) > d {}", "a,\nb c > d {\n}\n", specificity("where"))
+
+	// Complex selectors can only be expanded at the start
+	expectPrintedWithOptions(t, lower, "a :is(b c) {}", "a :is(b c) {\n}\n", unsupported("is"))
+	expectPrintedWithOptions(t, lower, "a, .b :where(c d) {}", "a,\n.b :where(c d) {\n}\n", unsupported("where"))
+	expectPrintedWithOptions(t, lower, ":is(::before) {}", ":is(::before) {\n}\n", unsupported("is"))
+
+	// Arguments that aren't a valid selector list are left alone
+	expectPrintedWithOptions(t, lower, ":is(1, a) {}", ":is(1, a) {\n}\n", "")
+
+	// Old browsers only allow a single simple selector inside ":not()"
+	expectPrintedWithOptions(t, lower, ":not(:is(.a, .b)) {}", ":not(.a):not(.b) {\n}\n", specificity("is"))
+	expectPrintedWithOptions(t, lower, "a:not(:is(b)) c {}", "a:not(b) c {\n}\n", "")
+	expectPrintedWithOptions(t, lower, ".a:not(:where(.b)) {}", ".a:not(.b) {\n}\n", specificity("where"))
+	expectPrintedWithOptions(t, lower, ":not(:is(.a, .b)) :is(.c, .d) {}", ":not(.a):not(.b) .c,\n:not(.a):not(.b) .d {\n}\n", specificity("is"))
+	expectPrintedWithOptions(t, lower, ":not(:is(.a.b, .c)) {}", ":not(:is(.a.b, .c)) {\n}\n", unsupported("is"))
+	expectPrintedWithOptions(t, lower, ":not(:is(.a .b)) {}", ":not(:is(.a .b)) {\n}\n", unsupported("is"))
+	expectPrintedWithOptions(t, lower, ":not(.a, .b) {}", ":not(.a, .b) {\n}\n", "")
+	expectPrintedWithOptions(t, Options{}, ":not(:is(.a, .b)) {}", ":not(:is(.a, .b)) {\n}\n", "")
+
+	// This happens before nesting is lowered
+	expectPrintedWithOptions(t, Options{UnsupportedCSSFeatures: compat.IsPseudoClass | compat.Nesting},
+		".a { &:is(.b, .c) { color: red } }", ".a.b,\n.a.c {\n  color: red;\n}\n", "")
+	expectPrintedWithOptions(t, Options{UnsupportedCSSFeatures: compat.IsPseudoClass | compat.Nesting},
+		".a { :is(.b .c) {} }", ".a :is(.b .c) {\n}\n", unsupported("is"))
+}
+
 func TestBadQualifiedRules(t *testing.T) {
 	expectParseError(t, "$bad: rule;", "<stdin>: WARNING: Unexpected \"$\"\n")
 	expectParseError(t, "$bad { color: red }", "<stdin>: WARNING: Unexpected \"$\"\n")
-	expectParseError(t, "a { div.major { color: blue } color: red }", "<stdin>: WARNING: Expected \":\" but found \".\"\n")
-	expectParseError(t, "a { div:hover { color: blue } color: red }", "<stdin>: WARNING: Expected \";\"\n")
+	expectParseError(t, "a { div.major { color: blue } color: red }", "")
+	expectParseError(t, "a { div:hover { color: blue } color: red }", "")
 	expectParseError(t, "a { div:hover { color: blue }; color: red }", "")
 	expectParseError(t, "a { div:hover { color: blue } ; color: red }", "")
 }
@@ -796,6 +1033,80 @@ func TestAtRule(t *testing.T) {
 `)
 }
 
+func TestAtLayer(t *testing.T) {
+	expectPrinted(t, "@layer a;", "@layer a;\n")
+	expectPrinted(t, "@layer a , b.c;", "@layer a, b.c;\n")
+	expectPrinted(t, "@layer a { b { color: red } }", "@layer a {\n  b {\n    color: red;\n  }\n}\n")
+	expectPrinted(t, "@layer a.b{b{color:red}}", "@layer a.b {\n  b {\n    color: red;\n  }\n}\n")
+	expectPrinted(t, "@layer { b { color: red } }", "@layer {\n  b {\n    color: red;\n  }\n}\n")
+	expectPrinted(t, "a { @layer b { color: red } }", "a {\n  @layer b {\n    color: red;\n  }\n}\n")
+
+	expectParseError(t, "@layer a, b { c {} }", "<stdin>: WARNING: A \"@layer\" rule with a block can only have one layer name\n")
+	expectParseError(t, "@layer a b;", "<stdin>: WARNING: Expected \";\"\n")
+	expectParseError(t, "@layer a,b.c d;", "<stdin>: WARNING: Expected \";\"\n")
+	expectParseError(t, "@layer a, ;", "<stdin>: WARNING: Expected identifier but found \";\"\n")
+	expectParseError(t, "@layer a.;", "<stdin>: WARNING: Expected identifier but found \";\"\n")
+	expectParseError(t, "@layer initial;", "<stdin>: WARNING: Cannot use \"initial\" as a layer name\n")
+	expectParseError(t, "@layer a", "<stdin>: WARNING: Expected \";\" but found end of file\n")
+	expectPrinted(t, "@layer a, b { c {} }", "@layer a, b { c {} }\n")
+
+	expectParseError(t, "@layer a; @import \"foo.css\";", "")
+	expectParseError(t, "@layer a {} @import \"foo.css\";",
+		"<stdin>: WARNING: All \"@import\" rules must come first\n"+
+			"<stdin>: NOTE: This rule cannot come before an \"@import\" rule\n")
+
+	expectPrintedMangle(t, "@layer a; @layer b, a; @layer c;", "@layer a, b, c;\n")
+	expectPrintedMangle(t, "@layer a {} @layer {} b { color: red }", "@layer a;\nb {\n  color: red;\n}\n")
+	expectPrintedMangle(t, "@layer a { a { color: red } } @layer a { b { color: red } }",
+		"@layer a {\n  a,\n  b {\n    color: red;\n  }\n}\n")
+	expectPrintedMangle(t, "@layer { b { color: red } } @layer { c { color: red } }",
+		"@layer {\n  b {\n    color: red;\n  }\n}\n@layer {\n  c {\n    color: red;\n  }\n}\n")
+	expectPrintedMangle(t, "@layer a { b { color: red } } @layer b { b { color: red } } @layer a { b { color: red } }",
+		"@layer a {\n  b {\n    color: red;\n  }\n}\n@layer b {\n  b {\n    color: red;\n  }\n}\n@layer a {\n  b {\n    color: red;\n  }\n}\n")
+}
+
+func TestAtSupports(t *testing.T) {
+	expectPrinted(t, "@supports (display: grid) { a { color: red } }", "@supports (display: grid) {\n  a {\n    color: red;\n  }\n}\n")
+	expectPrinted(t, "@supports not (display: grid) { a {} }", "@supports not (display: grid) {\n  a {\n  }\n}\n")
+	expectPrinted(t, "@supports (a: b) and (c: d) and selector(e) { a {} }", "@supports (a: b) and (c: d) and selector(e) {\n  a {\n  }\n}\n")
+	expectPrinted(t, "@supports ((a: b) or (c: d)) and (e: f) { a {} }", "@supports ((a: b) or (c: d)) and (e: f) {\n  a {\n  }\n}\n")
+
+	expectParseError(t, "@supports not (a: b) {}", "")
+	expectParseError(t, "@supports (a: b) and (c: d) or (e: f) {}",
+		"<stdin>: WARNING: Cannot mix \"and\" and \"or\" in the same condition without parentheses\n")
+	expectParseError(t, "@supports display: grid {}", "<stdin>: WARNING: Expected \"(\" but found \"display\"\n")
+	expectParseError(t, "@supports (a: b) and(c: d) {}", "<stdin>: WARNING: Expected \"{\" but found \"and(\"\n")
+	expectParseError(t, "@supports not (a: b) or (c: d) {}", "<stdin>: WARNING: Expected \"{\" but found \"or\"\n")
+	expectPrinted(t, "@supports display: grid { a {} }", "@supports display: grid {\n  a {\n  }\n}\n")
+
+	expectPrintedMangle(t, "@supports (a: b) { a { color: red } } @supports (a: b) { b { color: red } }",
+		"@supports (a: b) {\n  a,\n  b {\n    color: red;\n  }\n}\n")
+	expectPrintedMangle(t, "@supports (a: b) { a { color: red } } @supports (c: d) { b { color: red } }",
+		"@supports (a: b) {\n  a {\n    color: red;\n  }\n}\n@supports (c: d) {\n  b {\n    color: red;\n  }\n}\n")
+	expectPrintedMangle(t, "@supports (a: b) {}", "")
+}
+
+func TestAtContainer(t *testing.T) {
+	expectPrinted(t, "@container (min-width: 100px) { a {} }", "@container (min-width: 100px) {\n  a {\n  }\n}\n")
+	expectPrinted(t, "@container card (min-width: 100px) { a {} }", "@container card (min-width: 100px) {\n  a {\n  }\n}\n")
+	expectPrinted(t, "@container card not (width > 10px) { a {} }", "@container card not (width > 10px) {\n  a {\n  }\n}\n")
+	expectPrinted(t, "@container style(--x: y) and (width > 0) { a {} }", "@container style(--x: y) and (width > 0) {\n  a {\n  }\n}\n")
+	expectPrinted(t, "@container card { a {} }", "@container card {\n  a {\n  }\n}\n")
+	expectPrinted(t, "a { @container (width > 0) { color: red } }", "a {\n  @container (width > 0) {\n    color: red;\n  }\n}\n")
+
+	expectParseError(t, "@container not (width > 0) {}", "")
+	expectParseError(t, "@container none (width > 0) {}", "<stdin>: WARNING: Cannot use \"none\" as a container name\n")
+	expectParseError(t, "@container {}", "<stdin>: WARNING: Expected \"(\" but found \"{\"\n")
+	expectParseError(t, "@container a b {}", "<stdin>: WARNING: Expected \"(\" but found \"b\"\n")
+	expectParseError(t, "@container a (width > 0), b (width > 0) {}", "")
+
+	expectPrintedMangle(t, "@container a (width > 0) { a { color: red } } @container a (width > 0) { b { color: red } }",
+		"@container a (width > 0) {\n  a,\n  b {\n    color: red;\n  }\n}\n")
+	expectPrintedMangle(t, "@container a (width > 0) { a { color: red } } @container b (width > 0) { b { color: red } }",
+		"@container a (width > 0) {\n  a {\n    color: red;\n  }\n}\n@container b (width > 0) {\n  b {\n    color: red;\n  }\n}\n")
+	expectPrintedMangle(t, "@container a (width > 0) {}", "")
+}
+
 func TestAtCharset(t *testing.T) {
 	expectPrinted(t, "@charset \"UTF-8\";", "@charset \"UTF-8\";\n")
 	expectPrinted(t, "@charset 'UTF-8';", "@charset \"UTF-8\";\n")
@@ -837,6 +1148,17 @@ func TestAtImport(t *testing.T) {
 `)
 
 	expectParseError(t, "@import \"foo.css\" {}", "<stdin>: WARNING: Expected \";\"\n")
+
+	// Invalid layer names are removed so that the file is imported without a layer
+	expectPrinted(t, "@import \"foo.css\" layer(a.b) print;", "@import \"foo.css\" layer(a.b) print;\n")
+	expectPrinted(t, "@import \"foo.css\" layer( a.b );", "@import \"foo.css\" layer(a.b);\n")
+	expectPrinted(t, "@import \"foo.css\" layer(a b);", "@import \"foo.css\";\n")
+	expectPrinted(t, "@import \"foo.css\" layer(a . b) print;", "@import \"foo.css\" print;\n")
+	expectParseError(t, "@import \"foo.css\" layer(a.b);", "")
+	expectParseError(t, "@import \"foo.css\" layer(a b);", "<stdin>: WARNING: Expected a layer name such as \"a.b\" inside \"layer()\"\n")
+	expectParseError(t, "@import \"foo.css\" layer(a,b);", "<stdin>: WARNING: Expected a layer name such as \"a.b\" inside \"layer()\"\n")
+	expectParseError(t, "@import \"foo.css\" layer();", "<stdin>: WARNING: Expected a layer name such as \"a.b\" inside \"layer()\"\n")
+	expectParseError(t, "@import \"foo.css\" layer(a.initial);", "<stdin>: WARNING: Expected a layer name such as \"a.b\" inside \"layer()\"\n")
 	expectPrinted(t, "@import \"foo\"\na { color: red }\nb { color: blue }", "@import \"foo\" a { color: red }\nb {\n  color: blue;\n}\n")
 }
 
@@ -1056,6 +1378,48 @@ func TestMarginAndPaddingAndInset(t *testing.T) {
 	expectPrintedLowerMangle(t, "a { top: 0; right: 0; bottom: 0; left: 0; }", "a {\n  top: 0;\n  right: 0;\n  bottom: 0;\n  left: 0;\n}\n")
 }
 
+func TestLowerLogicalProperties(t *testing.T) {
+	lower := Options{UnsupportedCSSFeatures: compat.LogicalProperties | compat.InsetProperty}
+	rtl := Options{UnsupportedCSSFeatures: compat.LogicalProperties | compat.InsetProperty, RightToLeft: true}
+
+	expectPrintedWithOptions(t, lower, "a { margin-inline: 1px 2px }", "a {\n  margin-left: 1px;\n  margin-right: 2px;\n}\n", "")
+	expectPrintedWithOptions(t, lower, "a { margin-block: 1px }", "a {\n  margin-top: 1px;\n  margin-bottom: 1px;\n}\n", "")
+	expectPrintedWithOptions(t, lower, "a { padding-inline-start: 1px }", "a {\n  padding-left: 1px;\n}\n", "")
+	expectPrintedWithOptions(t, lower, "a { padding-block-end: 1px }", "a {\n  padding-bottom: 1px;\n}\n", "")
+	expectPrintedWithOptions(t, lower, "a { inset-inline-start: 0 }", "a {\n  left: 0;\n}\n", "")
+	expectPrintedWithOptions(t, lower, "a { inset-block: auto 2px }", "a {\n  top: auto;\n  bottom: 2px;\n}\n", "")
+	expectPrintedWithOptions(t, lower, "a { inset: 1px 2px 3px }", "a {\n  top: 1px;\n  right: 2px;\n  bottom: 3px;\n  left: 2px;\n}\n", "")
+	expectPrintedWithOptions(t, lower, "a { margin-inline: calc(1px + 2%) auto !important }",
+		"a {\n  margin-left: calc(1px + 2%) !important;\n  margin-right: auto !important;\n}\n", "")
+
+	expectPrintedWithOptions(t, lower, "a { border-inline-start: 1px solid red }", "a {\n  border-left: 1px solid red;\n}\n", "")
+	expectPrintedWithOptions(t, lower, "a { border-inline-end: 1px solid red }", "a {\n  border-right: 1px solid red;\n}\n", "")
+	expectPrintedWithOptions(t, lower, "a { border-inline-start-width: 1px }", "a {\n  border-left-width: 1px;\n}\n", "")
+	expectPrintedWithOptions(t, lower, "a { border-inline-end-color: red }", "a {\n  border-right-color: red;\n}\n", "")
+	expectPrintedWithOptions(t, lower, "a { border-inline-start-style: dashed }", "a {\n  border-left-style: dashed;\n}\n", "")
+	expectPrintedWithOptions(t, lower, "a { border-block-end-width: 2px }", "a {\n  border-bottom-width: 2px;\n}\n", "")
+
+	// The inline direction is reversed for right-to-left text
+	expectPrintedWithOptions(t, rtl, "a { margin-inline: 1px 2px }", "a {\n  margin-right: 1px;\n  margin-left: 2px;\n}\n", "")
+	expectPrintedWithOptions(t, rtl, "a { inset-inline-start: 0 }", "a {\n  right: 0;\n}\n", "")
+	expectPrintedWithOptions(t, rtl, "a { padding-block-start: 0 }", "a {\n  padding-top: 0;\n}\n", "")
+	expectPrintedWithOptions(t, rtl, "a { border-inline-start-color: red }", "a {\n  border-right-color: red;\n}\n", "")
+
+	// Shorthands can't be split when "var()" could expand to multiple values
+	expectPrintedWithOptions(t, lower, "a { margin-inline: var(--x) }", "a {\n  margin-inline: var(--x);\n}\n", "")
+	expectPrintedWithOptions(t, lower, "a { inset: var(--x) 0 }", "a {\n  inset: var(--x) 0;\n}\n", "")
+	expectPrintedWithOptions(t, lower, "a { margin-inline-end: var(--x) }", "a {\n  margin-right: var(--x);\n}\n", "")
+	expectPrintedWithOptions(t, lower, "a { margin-inline: 1px 2px 3px }", "a {\n  margin-inline: 1px 2px 3px;\n}\n", "")
+
+	// These are only lowered when they are unsupported
+	expectPrintedWithOptions(t, Options{}, "a { margin-inline: 1px 2px }", "a {\n  margin-inline: 1px 2px;\n}\n", "")
+	expectPrintedWithOptions(t, Options{UnsupportedCSSFeatures: compat.InsetProperty}, "a { inset-inline: 0 }", "a {\n  inset-inline: 0;\n}\n", "")
+
+	// The physical properties can be merged when minifying
+	expectPrintedWithOptions(t, Options{UnsupportedCSSFeatures: compat.LogicalProperties, MangleSyntax: true, RemoveWhitespace: true},
+		"a { margin: 0; margin-inline: 1px 2px }", "a{margin:0 2px 0 1px}", "")
+}
+
 func TestBorderRadius(t *testing.T) {
 	expectPrinted(t, "a { border-top-left-radius: 0 0 }", "a {\n  border-top-left-radius: 0 0;\n}\n")
 	expectPrintedMangle(t, "a { border-top-left-radius: 0 0 }", "a {\n  border-top-left-radius: 0;\n}\n")
@@ -1241,6 +1605,10 @@ func TestCalc(t *testing.T) {
 	expectParseError(t, "a { b: calc(1 -(2)) }", "<stdin>: WARNING: The \"-\" operator only works if there is whitespace on both sides\n")
 	expectParseError(t, "a { b: calc(1 *(2)) }", "")
 	expectParseError(t, "a { b: calc(1 /(2)) }", "")
+
+	expectParseError(t, "a { b: min(1px+ 2px) }", "<stdin>: WARNING: The \"+\" operator only works if there is whitespace on both sides\n")
+	expectParseError(t, "a { b: max(1px, 1px -2px) }", "<stdin>: WARNING: The \"-\" operator only works if there is whitespace on both sides\n")
+	expectParseError(t, "a { b: clamp(1px, 1px +(2px), 3px) }", "<stdin>: WARNING: The \"+\" operator only works if there is whitespace on both sides\n")
 }
 
 func TestMinifyCalc(t *testing.T) {
@@ -1322,6 +1690,55 @@ func TestMangleCalc(t *testing.T) {
 	expectPrintedMangle(t, "a { b: calc(100% / -100000) }", "a {\n  b: -.001%;\n}\n")
 	expectPrintedMangle(t, "a { b: calc(3 * (2px + 1em / 7)) }", "a {\n  b: calc(3 * (2px + 1em / 7));\n}\n")
 	expectPrintedMangle(t, "a { b: calc(3 * (2px + 1em / 8)) }", "a {\n  b: calc(3 * (2px + .125em));\n}\n")
+
+	// Test units that can be converted to each other
+	expectPrintedMangle(t, "a { b: calc(1in + 4px) }", "a {\n  b: 100px;\n}\n")
+	expectPrintedMangle(t, "a { b: calc(4px + 1in) }", "a {\n  b: 100px;\n}\n")
+	expectPrintedMangle(t, "a { b: calc(1in + 48px) }", "a {\n  b: 1.5in;\n}\n")
+	expectPrintedMangle(t, "a { b: calc(1s + 500ms) }", "a {\n  b: 1.5s;\n}\n")
+	expectPrintedMangle(t, "a { b: calc(1px + 2PX) }", "a {\n  b: 3px;\n}\n")
+	expectPrintedMangle(t, "a { b: calc(1turn - 90deg) }", "a {\n  b: .75turn;\n}\n")
+	expectPrintedMangle(t, "a { b: calc(1px + 1em) }", "a {\n  b: calc(1px + 1em);\n}\n")
+	expectPrintedMangle(t, "a { b: calc(1px + 1s) }", "a {\n  b: calc(1px + 1s);\n}\n")
+	expectPrintedMangle(t, "a { b: calc(1px + 1%) }", "a {\n  b: calc(1px + 1%);\n}\n")
+
+	// Test "min()" and "max()"
+	expectPrintedMangle(t, "a { b: min(1px, 2px) }", "a {\n  b: 1px;\n}\n")
+	expectPrintedMangle(t, "a { b: max(1px, 2px) }", "a {\n  b: 2px;\n}\n")
+	expectPrintedMangle(t, "a { b: min(1px) }", "a {\n  b: 1px;\n}\n")
+	expectPrintedMangle(t, "a { b: min(x) }", "a {\n  b: min(x);\n}\n")
+	expectPrintedMangle(t, "a { b: min(1in, 100px) }", "a {\n  b: 1in;\n}\n")
+	expectPrintedMangle(t, "a { b: max(1in, 100px) }", "a {\n  b: 100px;\n}\n")
+	expectPrintedMangle(t, "a { b: min(1px, 2em, 3px) }", "a {\n  b: min(1px, 2em);\n}\n")
+	expectPrintedMangle(t, "a { b: max(1px, 2em, 3px) }", "a {\n  b: max(3px, 2em);\n}\n")
+	expectPrintedMangle(t, "a { b: min(1px + 2px, 2em * 2) }", "a {\n  b: min(3px, 4em);\n}\n")
+	expectPrintedMangle(t, "a { b: min(1px + 2em, 3px) }", "a {\n  b: min(1px + 2em, 3px);\n}\n")
+	expectPrintedMangle(t, "a { b: calc(min(1px, 2px) + 3px) }", "a {\n  b: 4px;\n}\n")
+	expectPrintedMangle(t, "a { b: calc(100% - max(1px, 2em)) }", "a {\n  b: calc(100% - max(1px, 2em));\n}\n")
+	expectPrintedMangle(t, "a { b: min(var(--x), 1px, 2px) }", "a {\n  b: min(var(--x), 1px, 2px);\n}\n")
+	expectPrintedMangle(t, "a { b: min(NaN, 1px) }", "a {\n  b: min(NaN, 1px);\n}\n")
+	expectPrintedMangleMinify(t, "a { b: min(1px + 2em, 3px) }", "a{b:min(1px + 2em,3px)}")
+
+	// Test "clamp()"
+	expectPrintedMangle(t, "a { b: clamp(1px, 2px, 3px) }", "a {\n  b: 2px;\n}\n")
+	expectPrintedMangle(t, "a { b: clamp(1px, 0px, 3px) }", "a {\n  b: 1px;\n}\n")
+	expectPrintedMangle(t, "a { b: clamp(1px, 4px, 3px) }", "a {\n  b: 3px;\n}\n")
+	expectPrintedMangle(t, "a { b: clamp(5px, 4px, 3px) }", "a {\n  b: 5px;\n}\n")
+	expectPrintedMangle(t, "a { b: clamp(1px, 2em, 3px) }", "a {\n  b: clamp(1px, 2em, 3px);\n}\n")
+	expectPrintedMangle(t, "a { b: clamp(1px + 1px, 2em, 3px) }", "a {\n  b: clamp(2px, 2em, 3px);\n}\n")
+	expectPrintedMangle(t, "a { b: clamp(1px, 2px) }", "a {\n  b: clamp(1px, 2px);\n}\n")
+}
+
+func TestLowerClamp(t *testing.T) {
+	expectPrinted(t, "a { b: clamp(1px, 50%, 3em) }", "a {\n  b: clamp(1px, 50%, 3em);\n}\n")
+	expectPrintedLower(t, "a { b: clamp(1px, 50%, 3em) }", "a {\n  b: max(1px, min(50%, 3em));\n}\n")
+	expectPrintedLower(t, "a { b: clamp(1px, 50% + 1px, 3em) }", "a {\n  b: max(1px, min(50% + 1px, 3em));\n}\n")
+	expectPrintedLower(t, "a { b: calc(clamp(1px, 50%, 3em) * 2) }", "a {\n  b: calc(max(1px, min(50%, 3em)) * 2);\n}\n")
+	expectPrintedLower(t, "a { b: clamp(var(--x), 3em) }", "a {\n  b: clamp(var(--x), 3em);\n}\n")
+	expectPrintedLower(t, "a { b: clamp(1px, var(--x), 3em) }", "a {\n  b: clamp(1px, var(--x), 3em);\n}\n")
+	expectPrintedLower(t, "a { b: clamp(1px, 2px) }", "a {\n  b: clamp(1px, 2px);\n}\n")
+	expectPrintedLowerMangle(t, "a { b: clamp(1px, 2px, 3px) }", "a {\n  b: 2px;\n}\n")
+	expectPrintedLowerMangle(t, "a { b: clamp(1px + 1px, 50%, 3em) }", "a {\n  b: max(2px, min(50%, 3em));\n}\n")
 }
 
 func TestTransform(t *testing.T) {
@@ -1601,3 +2018,190 @@ func TestFont(t *testing.T) {
 	expectPrintedMangleMinify(t, "a { font: italic small-caps bold ultra-condensed 1rem/1.2 'aaa bbb' }", "a{font:italic small-caps 700 ultra-condensed 1rem/1.2 aaa bbb}")
 	expectPrintedMangleMinify(t, "a { font: italic small-caps bold ultra-condensed 1rem / 1.2 'aaa bbb' }", "a{font:italic small-caps 700 ultra-condensed 1rem/1.2 aaa bbb}")
 }
+
+func expectPrintedLocal(t *testing.T, contents string, expected string, expectedLog string) {
+	t.Helper()
+	t.Run(contents+" [local]", func(t *testing.T) {
+		t.Helper()
+		log := logger.NewDeferLog(logger.DeferLogNoVerboseOrDebug)
+		tree := Parse(log, test.SourceForTest(contents), Options{MakeLocalSymbols: true})
+		msgs := log.Done()
+		text := ""
+		for _, msg := range msgs {
+			text += msg.String(logger.OutputOptions{}, logger.TerminalInfo{})
+		}
+		test.AssertEqualWithDiff(t, text, expectedLog)
+
+		// Rename every local symbol so it's obvious which names are local
+		localNames := make(map[string]string)
+		composes := ""
+		for _, symbol := range tree.LocalSymbols {
+			localNames[symbol.Name] = "L_" + symbol.Name
+			for _, name := range symbol.Composes {
+				composes += fmt.Sprintf("/* %s composes %s", symbol.Name, name.Name)
+				if name.ImportRecordIndex.IsValid() {
+					composes += fmt.Sprintf(" from %q", tree.ImportRecords[name.ImportRecordIndex.GetIndex()].Path.Text)
+				} else if name.IsGlobal {
+					composes += " from global"
+				}
+				composes += " */\n"
+			}
+		}
+		result := css_printer.Print(tree, css_printer.Options{LocalNames: localNames})
+		test.AssertEqualWithDiff(t, composes+string(result.CSS), expected)
+	})
+}
+
+func TestLocalNames(t *testing.T) {
+	expectPrintedLocal(t, ".a {}", ".L_a {\n}\n", "")
+	expectPrintedLocal(t, "#a {}", "#L_a {\n}\n", "")
+	expectPrintedLocal(t, "div.a#b.a {}", "div.L_a#L_b.L_a {\n}\n", "")
+	expectPrintedLocal(t, ".a > .b, .c {}", ".L_a > .L_b,\n.L_c {\n}\n", "")
+	expectPrintedLocal(t, ".a:hover::before {}", ".L_a:hover::before {\n}\n", "")
+	expectPrintedLocal(t, ".a:not(.b, div.c) {}", ".L_a:not(.L_b, div.L_c) {\n}\n", "")
+	expectPrintedLocal(t, "[class=a] {}", "[class=a] {\n}\n", "")
+	expectPrintedLocal(t, ".a { & .b {} }", ".L_a {\n  & .L_b {\n  }\n}\n", "")
+}
+
+func TestLocalGlobalPseudoClass(t *testing.T) {
+	expectPrintedLocal(t, ":global(.a) {}", ".a {\n}\n", "")
+	expectPrintedLocal(t, ":global(.a) .b {}", ".a .L_b {\n}\n", "")
+	expectPrintedLocal(t, ".a:global(.b.c) .d {}", ".L_a.b.c .L_d {\n}\n", "")
+	expectPrintedLocal(t, ":global(div.a) {}", "div.a {\n}\n", "")
+	expectPrintedLocal(t, ":local(.a) {}", ".L_a {\n}\n", "")
+	expectPrintedLocal(t, ":global .a .b, .c {}", ".a .b,\n.L_c {\n}\n", "")
+	expectPrintedLocal(t, ".a :global .b :local .c {}", ".L_a .b .L_c {\n}\n", "")
+	expectPrintedLocal(t, ".a:global .b {}", ".L_a .b {\n}\n", "")
+	expectPrintedLocal(t, ":global(.a) { & .b {} }", ".a {\n  & .L_b {\n  }\n}\n", "")
+	expectPrintedLocal(t, ".a:global(div) {}", ".L_a {\n}\n",
+		"<stdin>: WARNING: Type selectors inside \":local(...)\" or \":global(...)\" must come first in the selector\n")
+
+	// These are only special in "local-css" files
+	expectPrinted(t, ":global(.a) {}", ":global(.a) {\n}\n")
+	expectPrinted(t, ":global .a {}", ":global .a {\n}\n")
+}
+
+func TestLocalKeyframes(t *testing.T) {
+	expectPrintedLocal(t, "@keyframes a { to { color: red } }", "@keyframes L_a {\n  to {\n    color: red;\n  }\n}\n", "")
+	expectPrintedLocal(t, ".a { animation-name: a, none }", ".L_a {\n  animation-name: L_a, none;\n}\n", "")
+	expectPrintedLocal(t, ".a { animation: b 1s ease-in infinite alternate }",
+		".L_a {\n  animation: L_b 1s ease-in infinite alternate;\n}\n", "")
+	expectPrintedLocal(t, ".a { animation: inherit }", ".L_a {\n  animation: inherit;\n}\n", "")
+	expectPrintedLocal(t, ".a { transition: b 1s }", ".L_a {\n  transition: b 1s;\n}\n", "")
+}
+
+func TestLocalComposes(t *testing.T) {
+	expectPrintedLocal(t, ".a { composes: b c; color: red } .b {}",
+		"/* a composes b */\n/* a composes c */\n.L_a {\n  color: red;\n}\n.L_b {\n}\n", "")
+	expectPrintedLocal(t, ".a { composes: b from './b.css' }",
+		"/* a composes b from \"./b.css\" */\n.L_a {\n}\n", "")
+	expectPrintedLocal(t, ".a { composes: b c from global }",
+		"/* a composes b from global */\n/* a composes c from global */\n.L_a {\n}\n", "")
+	expectPrintedLocal(t, ".a { composes: from }", "/* a composes from */\n.L_a {\n}\n", "")
+
+	expectPrintedLocal(t, ".a .b { composes: c }", ".L_a .L_b {\n}\n",
+		"<stdin>: WARNING: \"composes\" only works inside single class selectors\n")
+	expectPrintedLocal(t, "div { composes: c }", "div {\n}\n",
+		"<stdin>: WARNING: \"composes\" only works inside single class selectors\n")
+	expectPrintedLocal(t, ":global(.a) { composes: c }", ".a {\n}\n",
+		"<stdin>: WARNING: \"composes\" only works inside single class selectors\n")
+	expectPrintedLocal(t, ".a { composes: }", ".L_a {\n}\n",
+		"<stdin>: WARNING: Expected at least one class name after \"composes\"\n")
+	expectPrintedLocal(t, ".a { composes: b from c }", ".L_a {\n}\n",
+		"<stdin>: WARNING: Expected a string or \"global\" after \"from\"\n")
+	expectPrintedLocal(t, ".a { composes: b from global c }", ".L_a {\n}\n",
+		"<stdin>: WARNING: Unexpected \"c\"\n")
+	expectPrintedLocal(t, ".a { composes: b, c }", ".L_a {\n}\n",
+		"<stdin>: WARNING: Unexpected \",\"\n")
+
+	// This is only special in "local-css" files
+	expectPrinted(t, ".a { composes: b }", ".a {\n  composes: b;\n}\n")
+}
+
+func expectPrintedWithTargets(t *testing.T, constraints map[compat.Engine][]int, contents string, expected string) {
+	t.Helper()
+	t.Run(contents+" [targets]", func(t *testing.T) {
+		t.Helper()
+		log := logger.NewDeferLog(logger.DeferLogNoVerboseOrDebug)
+		tree := Parse(log, test.SourceForTest(contents), Options{
+			UnsupportedCSSFeatures: compat.UnsupportedCSSFeatures(constraints),
+			CSSPrefixData:          compat.CSSPrefixDataForConstraints(constraints),
+		})
+		msgs := log.Done()
+		text := ""
+		for _, msg := range msgs {
+			text += msg.String(logger.OutputOptions{}, logger.TerminalInfo{})
+		}
+		test.AssertEqualWithDiff(t, text, "")
+		result := css_printer.Print(tree, css_printer.Options{})
+		test.AssertEqualWithDiff(t, string(result.CSS), expected)
+	})
+}
+
+func TestVendorPrefixes(t *testing.T) {
+	chrome50 := map[compat.Engine][]int{compat.Chrome: {50}}
+	chrome100 := map[compat.Engine][]int{compat.Chrome: {100}}
+	firefox50 := map[compat.Engine][]int{compat.Firefox: {50}}
+	safari12 := map[compat.Engine][]int{compat.Safari: {12}}
+	safari16 := map[compat.Engine][]int{compat.Safari: {16}}
+	edge18 := map[compat.Engine][]int{compat.Edge: {18}}
+	es5 := map[compat.Engine][]int{compat.ES: {5}}
+
+	// Properties
+	expectPrintedWithTargets(t, chrome50, "a { user-select: none }", "a {\n  -webkit-user-select: none;\n  user-select: none;\n}\n")
+	expectPrintedWithTargets(t, chrome100, "a { user-select: none }", "a {\n  user-select: none;\n}\n")
+	expectPrintedWithTargets(t, firefox50, "a { user-select: none }", "a {\n  -moz-user-select: none;\n  user-select: none;\n}\n")
+	expectPrintedWithTargets(t, edge18, "a { user-select: none }", "a {\n  -ms-user-select: none;\n  user-select: none;\n}\n")
+	expectPrintedWithTargets(t, safari16, "a { user-select: none !important }",
+		"a {\n  -webkit-user-select: none !important;\n  user-select: none !important;\n}\n")
+	expectPrintedWithTargets(t, safari16, "a { backdrop-filter: blur(4px) }",
+		"a {\n  -webkit-backdrop-filter: blur(4px);\n  backdrop-filter: blur(4px);\n}\n")
+	expectPrintedWithTargets(t, chrome100, "a { backdrop-filter: blur(4px) }", "a {\n  backdrop-filter: blur(4px);\n}\n")
+	expectPrintedWithTargets(t, safari12, "a { appearance: none }", "a {\n  -webkit-appearance: none;\n  appearance: none;\n}\n")
+
+	// Values
+	expectPrintedWithTargets(t, safari12, "a { position: sticky }", "a {\n  position: -webkit-sticky;\n  position: sticky;\n}\n")
+	expectPrintedWithTargets(t, safari16, "a { position: sticky }", "a {\n  position: sticky;\n}\n")
+	expectPrintedWithTargets(t, safari12, "a { position: relative }", "a {\n  position: relative;\n}\n")
+
+	// Don't add prefixed copies that are already there
+	expectPrintedWithTargets(t, safari16, "a { -webkit-user-select: text; user-select: none }",
+		"a {\n  -webkit-user-select: text;\n  user-select: none;\n}\n")
+	expectPrintedWithTargets(t, safari12, "a { position: -webkit-sticky; position: sticky }",
+		"a {\n  position: -webkit-sticky;\n  position: sticky;\n}\n")
+
+	// Remove prefixed copies that aren't needed
+	expectPrintedWithTargets(t, chrome100, "a { -webkit-user-select: none; -moz-user-select: none; user-select: none }",
+		"a {\n  user-select: none;\n}\n")
+	expectPrintedWithTargets(t, firefox50, "a { -webkit-user-select: none; -moz-user-select: none; user-select: none }",
+		"a {\n  -moz-user-select: none;\n  user-select: none;\n}\n")
+	expectPrintedWithTargets(t, safari16, "a { position: -webkit-sticky; position: sticky }", "a {\n  position: sticky;\n}\n")
+
+	// The unprefixed declaration can also come first
+	expectPrintedWithTargets(t, chrome100, "a { user-select: none; -webkit-user-select: none }", "a {\n  user-select: none;\n}\n")
+	expectPrintedWithTargets(t, safari16, "a { position: sticky; position: -webkit-sticky }", "a {\n  position: sticky;\n}\n")
+	expectPrintedWithTargets(t, chrome100, "a { user-select: none; -webkit-user-select: text }",
+		"a {\n  user-select: none;\n  -webkit-user-select: text;\n}\n")
+
+	// Only remove prefixed copies that are identical to an unprefixed declaration
+	expectPrintedWithTargets(t, chrome100, "a { -webkit-user-select: text; user-select: none }",
+		"a {\n  -webkit-user-select: text;\n  user-select: none;\n}\n")
+	expectPrintedWithTargets(t, chrome100, "a { -webkit-user-select: none }", "a {\n  -webkit-user-select: none;\n}\n")
+	expectPrintedWithTargets(t, chrome100, "a { -webkit-box-sizing: border-box; box-sizing: border-box }",
+		"a {\n  -webkit-box-sizing: border-box;\n  box-sizing: border-box;\n}\n")
+
+	// Targets without any browsers don't change vendor prefixes
+	expectPrintedWithTargets(t, es5, "a { -webkit-user-select: none; user-select: none }",
+		"a {\n  -webkit-user-select: none;\n  user-select: none;\n}\n")
+	expectPrinted(t, "a { user-select: none }", "a {\n  user-select: none;\n}\n")
+
+	// Pseudo-elements
+	expectPrintedWithTargets(t, chrome50, "input::placeholder { color: gray }",
+		"input::-webkit-input-placeholder {\n  color: gray;\n}\ninput::placeholder {\n  color: gray;\n}\n")
+	expectPrintedWithTargets(t, firefox50, "a, input::placeholder { color: gray }",
+		"input::-moz-placeholder {\n  color: gray;\n}\na,\ninput::placeholder {\n  color: gray;\n}\n")
+	expectPrintedWithTargets(t, chrome100, "input::placeholder { color: gray }", "input::placeholder {\n  color: gray;\n}\n")
+	expectPrintedWithTargets(t, map[compat.Engine][]int{compat.Chrome: {50}, compat.Edge: {18}}, "@media screen { ::placeholder { color: gray } }",
+		"@media screen {\n  ::-webkit-input-placeholder {\n    color: gray;\n  }\n  ::-ms-input-placeholder {\n    color: gray;\n  }\n"+
+			"  ::placeholder {\n    color: gray;\n  }\n}\n")
+}
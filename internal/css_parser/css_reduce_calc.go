@@ -11,7 +11,13 @@ import (
 )
 
 func (p *parser) tryToReduceCalcExpression(token css_ast.Token) css_ast.Token {
-	if term := tryToParseCalcTerm(*token.Children); term != nil {
+	var term calcTerm
+	if token.Text == "calc" {
+		term = tryToParseCalcTerm(*token.Children)
+	} else {
+		term = tryToParseCalcMathFunction(token)
+	}
+	if term != nil {
 		whitespace := css_ast.WhitespaceBefore | css_ast.WhitespaceAfter
 		if p.options.RemoveWhitespace {
 			whitespace = 0
@@ -50,6 +56,17 @@ type calcInvert struct {
 	term calcTerm
 }
 
+type calcMinMax struct {
+	terms []calcTerm
+	isMax bool
+}
+
+type calcClamp struct {
+	min    calcTerm
+	center calcTerm
+	max    calcTerm
+}
+
 type calcNumeric struct {
 	number float64
 	unit   string
@@ -228,7 +245,7 @@ func (c *calcNegate) convertToToken(whitespace css_ast.WhitespaceFlags) (css_ast
 		Text: "(",
 		Children: &[]css_ast.Token{
 			{Kind: css_lexer.TNumber, Text: "-1"},
-			{Kind: css_lexer.TDelimSlash, Text: "*", Whitespace: css_ast.WhitespaceBefore | css_ast.WhitespaceAfter},
+			{Kind: css_lexer.TDelimAsterisk, Text: "*", Whitespace: css_ast.WhitespaceBefore | css_ast.WhitespaceAfter},
 			token,
 		},
 	}, true
@@ -251,6 +268,51 @@ func (c *calcInvert) convertToToken(whitespace css_ast.WhitespaceFlags) (css_ast
 	}, true
 }
 
+func (c *calcMinMax) convertToToken(whitespace css_ast.WhitespaceFlags) (css_ast.Token, bool) {
+	text := "min"
+	if c.isMax {
+		text = "max"
+	}
+	return convertCalcMathFunctionToToken(text, c.terms, whitespace)
+}
+
+func (c *calcClamp) convertToToken(whitespace css_ast.WhitespaceFlags) (css_ast.Token, bool) {
+	return convertCalcMathFunctionToToken("clamp", []calcTerm{c.min, c.center, c.max}, whitespace)
+}
+
+func convertCalcMathFunctionToToken(text string, args []calcTerm, whitespace css_ast.WhitespaceFlags) (css_ast.Token, bool) {
+	tokens := make([]css_ast.Token, 0, len(args)*2)
+
+	for i, arg := range args {
+		if i > 0 {
+			tokens = append(tokens, css_ast.Token{
+				Kind:       css_lexer.TComma,
+				Text:       ",",
+				Whitespace: whitespace & css_ast.WhitespaceAfter,
+			})
+		}
+		token, ok := arg.convertToToken(whitespace)
+		if !ok {
+			return css_ast.Token{}, false
+		}
+
+		// Each argument is already a calculation, so sums and products don't
+		// need to be wrapped in parentheses
+		switch arg.(type) {
+		case *calcSum, *calcProduct:
+			tokens = append(tokens, *token.Children...)
+		default:
+			tokens = append(tokens, token)
+		}
+	}
+
+	return css_ast.Token{
+		Kind:     css_lexer.TFunction,
+		Text:     text,
+		Children: &tokens,
+	}, true
+}
+
 func (c *calcNumeric) convertToToken(whitespace css_ast.WhitespaceFlags) (css_ast.Token, bool) {
 	text, ok := floatToStringForCalc(c.number)
 	if !ok {
@@ -307,6 +369,8 @@ func (c *calcSum) partiallySimplify() calcTerm {
 				term2 := terms[j]
 				if numeric2, ok := term2.(*calcNumeric); ok && numeric2.unit == numeric.unit {
 					numeric.number += numeric2.number
+				} else if ok && addCalcNumericsWithDifferentUnits(numeric, numeric2) {
+					// "calc(1in + 4px)" => "100px"
 				} else {
 					terms[end] = term2
 					end++
@@ -442,6 +506,73 @@ func (c *calcInvert) partiallySimplify() calcTerm {
 	return c
 }
 
+func (c *calcMinMax) partiallySimplify() calcTerm {
+	// Specification: https://www.w3.org/TR/css-values-4/#calc-simplification
+
+	// Combine the numeric children that can be compared with each other
+	terms := make([]calcTerm, 0, len(c.terms))
+nextTerm:
+	for _, term := range c.terms {
+		term = term.partiallySimplify()
+		if numeric, ok := term.(*calcNumeric); ok {
+			for i, prev := range terms {
+				if prevNumeric, ok := prev.(*calcNumeric); ok {
+					if order, ok := compareCalcNumerics(prevNumeric, numeric); ok {
+						if (c.isMax && order < 0) || (!c.isMax && order > 0) {
+							terms[i] = numeric
+						}
+						continue nextTerm
+					}
+				}
+			}
+		}
+		terms = append(terms, term)
+	}
+
+	// If root has only a single child at this point, return the child. Don't
+	// do this for other values such as "min(auto)" since that's invalid while
+	// "auto" may not be.
+	if len(terms) == 1 {
+		if _, ok := terms[0].(*calcValue); !ok {
+			return terms[0]
+		}
+	}
+
+	// Otherwise, return root.
+	c.terms = terms
+	return c
+}
+
+func (c *calcClamp) partiallySimplify() calcTerm {
+	// Specification: https://www.w3.org/TR/css-values-4/#calc-simplification
+
+	c.min = c.min.partiallySimplify()
+	c.center = c.center.partiallySimplify()
+	c.max = c.max.partiallySimplify()
+
+	// "clamp(MIN, VAL, MAX)" is the same as "max(MIN, min(VAL, MAX))"
+	if min, ok := c.min.(*calcNumeric); ok {
+		if center, ok := c.center.(*calcNumeric); ok {
+			if max, ok := c.max.(*calcNumeric); ok {
+				if order, ok := compareCalcNumerics(center, max); ok {
+					result := center
+					if order > 0 {
+						result = max
+					}
+					if order, ok := compareCalcNumerics(min, result); ok {
+						if order > 0 {
+							result = min
+						}
+						return result
+					}
+				}
+			}
+		}
+	}
+
+	return c
+}
+
 func (c *calcNumeric) partiallySimplify() calcTerm {
 	return c
 }
@@ -459,6 +590,11 @@ func tryToParseCalcTerm(tokens []css_ast.Token) calcTerm {
 		if token.Kind == css_lexer.TFunction && token.Text == "var" {
 			// Using "var()" should bail because it can expand to any number of tokens
 			return nil
+		} else if token.Kind == css_lexer.TFunction && (token.Text == "min" || token.Text == "max" || token.Text == "clamp") {
+			term = tryToParseCalcMathFunction(token)
+			if term == nil {
+				return nil
+			}
 		} else if token.Kind == css_lexer.TOpenParen || (token.Kind == css_lexer.TFunction && token.Text == "calc") {
 			term = tryToParseCalcTerm(*token.Children)
 			if term == nil {
@@ -579,3 +715,177 @@ func tryToParseCalcTerm(tokens []css_ast.Token) calcTerm {
 	}
 	return nil
 }
+
+func tryToParseCalcMathFunction(token css_ast.Token) calcTerm {
+	// Each comma-separated argument is a separate calculation
+	children := *token.Children
+	var args []calcTerm
+	start := 0
+	for i := 0; i <= len(children); i++ {
+		if i == len(children) || children[i].Kind == css_lexer.TComma {
+			arg := tryToParseCalcTerm(children[start:i])
+			if arg == nil {
+				return nil
+			}
+			args = append(args, arg)
+			start = i + 1
+		}
+	}
+
+	switch token.Text {
+	case "min", "max":
+		return &calcMinMax{terms: args, isMax: token.Text == "max"}
+
+	case "clamp":
+		if len(args) == 3 {
+			return &calcClamp{min: args[0], center: args[1], max: args[2]}
+		}
+	}
+	return nil
+}
+
+type calcUnitKind uint8
+
+const (
+	calcUnitLength calcUnitKind = iota
+	calcUnitAngle
+	calcUnitTime
+	calcUnitFrequency
+	calcUnitResolution
+)
+
+type calcUnit struct {
+	kind   calcUnitKind
+	factor float64 // This converts to the canonical unit for this kind
+}
+
+// Only units with a fixed ratio between them can be converted. Relative units
+// such as "em" and "%" depend on information that isn't available here.
+// Reference: https://www.w3.org/TR/css-values-4/#absolute-lengths
+var calcUnits = map[string]calcUnit{
+	"px": {kind: calcUnitLength, factor: 1},
+	"cm": {kind: calcUnitLength, factor: 96 / 2.54},
+	"mm": {kind: calcUnitLength, factor: 96 / 25.4},
+	"q":  {kind: calcUnitLength, factor: 96 / 101.6},
+	"in": {kind: calcUnitLength, factor: 96},
+	"pc": {kind: calcUnitLength, factor: 16},
+	"pt": {kind: calcUnitLength, factor: 4.0 / 3},
+
+	"deg":  {kind: calcUnitAngle, factor: 1},
+	"grad": {kind: calcUnitAngle, factor: 0.9},
+	"rad":  {kind: calcUnitAngle, factor: 180 / math.Pi},
+	"turn": {kind: calcUnitAngle, factor: 360},
+
+	"s":  {kind: calcUnitTime, factor: 1},
+	"ms": {kind: calcUnitTime, factor: 0.001},
+
+	"hz":  {kind: calcUnitFrequency, factor: 1},
+	"khz": {kind: calcUnitFrequency, factor: 1000},
+
+	"dppx": {kind: calcUnitResolution, factor: 1},
+	"x":    {kind: calcUnitResolution, factor: 1},
+	"dpi":  {kind: calcUnitResolution, factor: 1.0 / 96},
+	"dpcm": {kind: calcUnitResolution, factor: 2.54 / 96},
+}
+
+// This returns the ratio to convert a value from unit "b" to unit "a"
+func calcUnitRatio(a string, b string) (float64, bool) {
+	if a == b {
+		return 1, true
+	}
+	if strings.EqualFold(a, b) {
+		return 1, true
+	}
+	if unitA, ok := calcUnits[strings.ToLower(a)]; ok {
+		if unitB, ok := calcUnits[strings.ToLower(b)]; ok && unitA.kind == unitB.kind {
+			return unitB.factor / unitA.factor, true
+		}
+	}
+	return 0, false
+}
+
+// Returns -1, 0, or 1 if the values can be compared
+func compareCalcNumerics(a *calcNumeric, b *calcNumeric) (int, bool) {
+	ratio, ok := calcUnitRatio(a.unit, b.unit)
+	if !ok || math.IsNaN(a.number) || math.IsNaN(b.number) {
+		return 0, false
+	}
+	bNumber := b.number * ratio
+	if a.number < bNumber {
+		return -1, true
+	}
+	if a.number > bNumber {
+		return 1, true
+	}
+	return 0, true
+}
+
+// This adds "b" to "a" using whichever of the two units can represent the sum
+// exactly. Folding the values together isn't done otherwise since it would lose
+// precision.
+func addCalcNumericsWithDifferentUnits(a *calcNumeric, b *calcNumeric) bool {
+	ratio, ok := calcUnitRatio(a.unit, b.unit)
+	if !ok {
+		return false
+	}
+	if sum := a.number + b.number*ratio; isExactForCalc(sum) {
+		a.number = sum
+		return true
+	}
+	if sum := a.number/ratio + b.number; isExactForCalc(sum) {
+		a.number = sum
+		a.unit = b.unit
+		return true
+	}
+	return false
+}
+
+func isExactForCalc(a float64) bool {
+	_, ok := floatToStringForCalc(a)
+	return ok
+}
+
+// Browsers that support "min()" and "max()" but not "clamp()" can still use
+// the equivalent "max(MIN, min(VAL, MAX))" form
+func lowerClamp(token css_ast.Token) css_ast.Token {
+	var args [][]css_ast.Token
+	var commas []css_ast.Token
+	children := *token.Children
+	start := 0
+	for i, t := range children {
+		// Using "var()" should bail because it can expand to any number of arguments
+		if t.Kind == css_lexer.TFunction && t.Text == "var" {
+			return token
+		}
+		if t.Kind == css_lexer.TComma {
+			args = append(args, children[start:i])
+			commas = append(commas, t)
+			start = i + 1
+		}
+	}
+	args = append(args, children[start:])
+	if len(args) != 3 || len(args[0]) == 0 || len(args[1]) == 0 || len(args[2]) == 0 {
+		return token
+	}
+
+	// Move the whitespace before the second argument to before "min("
+	center := append([]css_ast.Token{}, args[1]...)
+	minToken := css_ast.Token{
+		Kind:       css_lexer.TFunction,
+		Text:       "min",
+		Whitespace: center[0].Whitespace & css_ast.WhitespaceBefore,
+	}
+	center[0].Whitespace &= ^css_ast.WhitespaceBefore
+	minChildren := make([]css_ast.Token, 0, len(center)+len(args[2])+1)
+	minChildren = append(minChildren, center...)
+	minChildren = append(minChildren, commas[1])
+	minChildren = append(minChildren, args[2]...)
+	minToken.Children = &minChildren
+
+	maxChildren := make([]css_ast.Token, 0, len(args[0])+2)
+	maxChildren = append(maxChildren, args[0]...)
+	maxChildren = append(maxChildren, commas[0], minToken)
+	token.Text = "max"
+	token.Children = &maxChildren
+	return token
+}
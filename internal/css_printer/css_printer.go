@@ -36,6 +36,10 @@ type Options struct {
 	// This will be present if the input file had a source map. In that case we
 	// want to map all the way back to the original input file(s).
 	InputSourceMap *sourcemap.SourceMap
+
+	// This maps the local symbols in a "local-css" file to their final names.
+	// Local symbols without an entry here are printed with their original name.
+	LocalNames map[string]string
 }
 
 type PrintResult struct {
@@ -111,7 +115,7 @@ func (p *printer) printRule(rule css_ast.Rule, indent int32, omitTrailingSemicol
 		if r.Name == "" {
 			p.print("\"\"")
 		} else {
-			p.printIdent(r.Name, identNormal, canDiscardWhitespaceAfter)
+			p.printIdent(p.nameForSymbol(r.Name, r.IsLocal), identNormal, canDiscardWhitespaceAfter)
 		}
 		if !p.options.RemoveWhitespace {
 			p.print(" ")
@@ -151,17 +155,50 @@ func (p *printer) printRule(rule css_ast.Rule, indent int32, omitTrailingSemicol
 		p.print("}")
 
 	case *css_ast.RKnownAt:
-		p.print("@")
-		whitespace := mayNeedWhitespaceAfter
-		if len(r.Prelude) == 0 {
-			whitespace = canDiscardWhitespaceAfter
+		p.printAtRuleWithPrelude(r.AtToken, r.Prelude, r.Rules, indent)
+
+	case *css_ast.RAtLayer:
+		p.print("@layer")
+		for i, name := range r.Names {
+			if i > 0 {
+				if p.options.RemoveWhitespace {
+					p.print(",")
+				} else {
+					p.print(", ")
+				}
+			} else {
+				p.print(" ")
+			}
+			for j, part := range name {
+				if j > 0 {
+					p.print(".")
+				}
+				p.printIdent(part, identNormal, canDiscardWhitespaceAfter)
+			}
 		}
-		p.printIdent(r.AtToken, identNormal, whitespace)
-		if !p.options.RemoveWhitespace || len(r.Prelude) > 0 {
+		if !r.HasBlock {
+			p.print(";")
+		} else {
+			if !p.options.RemoveWhitespace {
+				p.print(" ")
+			}
+			p.printRuleBlock(r.Rules, indent)
+		}
+
+	case *css_ast.RAtSupports:
+		p.printAtRuleWithPrelude("supports", r.Condition, r.Rules, indent)
+
+	case *css_ast.RAtContainer:
+		p.print("@container")
+		if r.Name != "" {
 			p.print(" ")
+			p.printIdent(r.Name, identNormal, mayNeedWhitespaceAfter)
 		}
-		p.printTokens(r.Prelude, printTokensOpts{})
-		if !p.options.RemoveWhitespace && len(r.Prelude) > 0 {
+		if len(r.Condition) > 0 {
+			p.print(" ")
+			p.printTokens(r.Condition, printTokensOpts{})
+		}
+		if !p.options.RemoveWhitespace {
 			p.print(" ")
 		}
 		p.printRuleBlock(r.Rules, indent)
@@ -254,6 +291,23 @@ func (p *printer) printIndentedComment(indent int32, text string) {
 	p.print(text)
 }
 
+func (p *printer) printAtRuleWithPrelude(atToken string, prelude []css_ast.Token, rules []css_ast.Rule, indent int32) {
+	p.print("@")
+	whitespace := mayNeedWhitespaceAfter
+	if len(prelude) == 0 {
+		whitespace = canDiscardWhitespaceAfter
+	}
+	p.printIdent(atToken, identNormal, whitespace)
+	if !p.options.RemoveWhitespace || len(prelude) > 0 {
+		p.print(" ")
+	}
+	p.printTokens(prelude, printTokensOpts{})
+	if !p.options.RemoveWhitespace && len(prelude) > 0 {
+		p.print(" ")
+	}
+	p.printRuleBlock(rules, indent)
+}
+
 func (p *printer) printRuleBlock(rules []css_ast.Rule, indent int32) {
 	if p.options.RemoveWhitespace {
 		p.print("{")
@@ -289,6 +343,24 @@ func (p *printer) printComplexSelectors(selectors []css_ast.ComplexSelector, ind
 	}
 }
 
+// This is used for selector lists inside pseudo-classes such as ":is()", which
+// are always printed on a single line
+func (p *printer) printComplexSelectorsInline(selectors []css_ast.ComplexSelector) {
+	for i, complex := range selectors {
+		if i > 0 {
+			if p.options.RemoveWhitespace {
+				p.print(",")
+			} else {
+				p.print(", ")
+			}
+		}
+
+		for j, compound := range complex.Selectors {
+			p.printCompoundSelector(compound, j == 0, j+1 == len(complex.Selectors))
+		}
+	}
+}
+
 func (p *printer) printCompoundSelector(sel css_ast.CompoundSelector, isFirst bool, isLast bool) {
 	if !isFirst && sel.Combinator == "" {
 		// A space is required in between compound selectors if there is no
@@ -297,12 +369,9 @@ func (p *printer) printCompoundSelector(sel css_ast.CompoundSelector, isFirst bo
 		p.print(" ")
 	}
 
-	if sel.HasNestPrefix {
-		p.print("&")
-	}
-
 	if sel.Combinator != "" {
-		if !p.options.RemoveWhitespace {
+		// Relative selectors in nested rules such as "> a" start with a combinator
+		if !p.options.RemoveWhitespace && !isFirst {
 			p.print(" ")
 		}
 		p.print(sel.Combinator)
@@ -311,21 +380,36 @@ func (p *printer) printCompoundSelector(sel css_ast.CompoundSelector, isFirst bo
 		}
 	}
 
+	// The "&" is printed in the same position that it was in originally, which
+	// is given as the number of type and subclass selectors before it
+	nestIndex := -1
+	if sel.HasNestPrefix {
+		nestIndex = int(sel.NestPrefixIndex)
+	}
+	index := 0
+	if nestIndex == index {
+		p.print("&")
+	}
+
 	if sel.TypeSelector != nil {
 		whitespace := mayNeedWhitespaceAfter
-		if len(sel.SubclassSelectors) > 0 {
+		if len(sel.SubclassSelectors) > 0 || nestIndex == index+1 {
 			// There is no chance of whitespace before a subclass selector or pseudo
 			// class selector
 			whitespace = canDiscardWhitespaceAfter
 		}
 		p.printNamespacedName(*sel.TypeSelector, whitespace)
+		index++
+		if nestIndex == index {
+			p.print("&")
+		}
 	}
 
 	for i, sub := range sel.SubclassSelectors {
 		whitespace := mayNeedWhitespaceAfter
 
 		// There is no chance of whitespace between subclass selectors
-		if i+1 < len(sel.SubclassSelectors) {
+		if i+1 < len(sel.SubclassSelectors) || nestIndex == index+1 {
 			whitespace = canDiscardWhitespaceAfter
 		}
 
@@ -335,11 +419,11 @@ func (p *printer) printCompoundSelector(sel css_ast.CompoundSelector, isFirst bo
 
 			// This deliberately does not use identHash. From the specification:
 			// "In <id-selector>, the <hash-token>'s value must be an identifier."
-			p.printIdent(s.Name, identNormal, whitespace)
+			p.printIdent(p.nameForSymbol(s.Name, s.IsLocal), identNormal, whitespace)
 
 		case *css_ast.SSClass:
 			p.print(".")
-			p.printIdent(s.Name, identNormal, whitespace)
+			p.printIdent(p.nameForSymbol(s.Name, s.IsLocal), identNormal, whitespace)
 
 		case *css_ast.SSAttribute:
 			p.print("[")
@@ -373,6 +457,18 @@ func (p *printer) printCompoundSelector(sel css_ast.CompoundSelector, isFirst bo
 
 		case *css_ast.SSPseudoClass:
 			p.printPseudoClassSelector(*s, whitespace)
+
+		case *css_ast.SSPseudoClassWithSelectorList:
+			p.print(":")
+			p.printIdent(s.Name, identNormal, canDiscardWhitespaceAfter)
+			p.print("(")
+			p.printComplexSelectorsInline(s.Selectors)
+			p.print(")")
+		}
+
+		index++
+		if nestIndex == index {
+			p.print("&")
 		}
 	}
 }
@@ -620,6 +716,15 @@ func (p *printer) printIdent(text string, mode identMode, whitespace trailingWhi
 	}
 }
 
+func (p *printer) nameForSymbol(name string, isLocal bool) string {
+	if isLocal {
+		if localName, ok := p.options.LocalNames[name]; ok {
+			return localName
+		}
+	}
+	return name
+}
+
 func (p *printer) printIndent(indent int32) {
 	for i, n := 0, int(indent); i < n; i++ {
 		p.css = append(p.css, "  "...)
@@ -671,6 +776,9 @@ func (p *printer) printTokens(tokens []css_ast.Token, opts printTokensOpts) bool
 		case css_lexer.TIdent:
 			p.printIdent(t.Text, identNormal, whitespace)
 
+		case css_lexer.TSymbol:
+			p.printIdent(p.nameForSymbol(t.Text, true), identNormal, whitespace)
+
 		case css_lexer.TFunction:
 			p.printIdent(t.Text, identNormal, whitespace)
 			p.print("(")
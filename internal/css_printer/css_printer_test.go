@@ -142,13 +142,21 @@ func TestNestedSelector(t *testing.T) {
 	expectPrintedMinify(t, "a { & b {} }", "a{& b{}}")
 	expectPrintedMinify(t, "a { & :b {} }", "a{& :b{}}")
 	expectPrintedMinify(t, "& a & b & c {}", "& a & b & c{}")
+	expectPrintedMinify(t, ".x, .y { & + & {} }", ".x,.y{&+&{}}")
+	expectPrintedMinify(t, ".a { .b > & {} }", ".a{.b>&{}}")
+	expectPrintedMinify(t, "a { div& {} }", "a{div&{}}")
+	expectPrintedMinify(t, "a { div.b&.c {} }", "a{div.b&.c{}}")
+	expectPrintedMinify(t, "a { .b& {} }", "a{.b&{}}")
+	expectPrinted(t, ".x, .y { & + & {} }", ".x,\n.y {\n  & + & {\n  }\n}\n")
+	expectPrinted(t, ".a { .b > & {} }", ".a {\n  .b > & {\n  }\n}\n")
+	expectPrinted(t, "a { > & {} }", "a {\n  > & {\n  }\n}\n")
 }
 
 func TestBadQualifiedRules(t *testing.T) {
 	expectPrinted(t, "$bad: rule;", "$bad: rule {\n}\n")
-	expectPrinted(t, "a { div.major { color: blue } color: red }", "a {\n  div.major { color: blue };\n  color: red;\n}\n")
-	expectPrinted(t, "a { div:hover { color: blue } color: red }", "a {\n  div: hover { color: blue };\n  color: red;\n}\n")
-	expectPrinted(t, "a { div:hover { color: blue }; color: red }", "a {\n  div: hover { color: blue };\n  color: red;\n}\n")
+	expectPrinted(t, "a { div.major { color: blue } color: red }", "a {\n  div.major {\n    color: blue;\n  }\n  color: red;\n}\n")
+	expectPrinted(t, "a { div:hover { color: blue } color: red }", "a {\n  div:hover {\n    color: blue;\n  }\n  color: red;\n}\n")
+	expectPrinted(t, "a { div:hover { color: blue }; color: red }", "a {\n  div:hover {\n    color: blue;\n  }\n  color: red;\n}\n")
 
 	expectPrinted(t, "$bad{ color: red }", "$bad {\n  color: red;\n}\n")
 	expectPrinted(t, "$bad { color: red }", "$bad {\n  color: red;\n}\n")
@@ -332,6 +340,28 @@ func TestAtMedia(t *testing.T) {
 	expectPrintedMinify(t, "@media screen{div{color:red}}", "@media screen{div{color:red}}")
 }
 
+func TestAtLayer(t *testing.T) {
+	expectPrinted(t, "@layer a, b.c;", "@layer a, b.c;\n")
+	expectPrinted(t, "@layer a { div { color: red } }", "@layer a {\n  div {\n    color: red;\n  }\n}\n")
+	expectPrinted(t, "@layer { div { color: red } }", "@layer {\n  div {\n    color: red;\n  }\n}\n")
+	expectPrintedMinify(t, "@layer a, b.c;", "@layer a,b.c;")
+	expectPrintedMinify(t, "@layer a { div { color: red } }", "@layer a{div{color:red}}")
+	expectPrintedMinify(t, "@layer { div { color: red } }", "@layer{div{color:red}}")
+}
+
+func TestAtSupports(t *testing.T) {
+	expectPrinted(t, "@supports (display: grid) { div { color: red } }", "@supports (display: grid) {\n  div {\n    color: red;\n  }\n}\n")
+	expectPrintedMinify(t, "@supports (display: grid) { div { color: red } }", "@supports (display: grid){div{color:red}}")
+	expectPrintedMinify(t, "@supports not (display: grid) { div { color: red } }", "@supports not (display: grid){div{color:red}}")
+}
+
+func TestAtContainer(t *testing.T) {
+	expectPrinted(t, "@container card (min-width: 10px) { div { color: red } }", "@container card (min-width: 10px) {\n  div {\n    color: red;\n  }\n}\n")
+	expectPrintedMinify(t, "@container card (min-width: 10px) { div { color: red } }", "@container card (min-width: 10px){div{color:red}}")
+	expectPrintedMinify(t, "@container (min-width: 10px) { div { color: red } }", "@container (min-width: 10px){div{color:red}}")
+	expectPrintedMinify(t, "@container card { div { color: red } }", "@container card{div{color:red}}")
+}
+
 func TestAtFontFace(t *testing.T) {
 	expectPrinted(t, "@font-face { font-family: 'Open Sans'; src: url('OpenSans.woff') format('woff') }",
 		"@font-face {\n  font-family: \"Open Sans\";\n  src: url(OpenSans.woff) format(\"woff\");\n}\n")
@@ -29,6 +29,7 @@ type entryPointKind uint8
 const (
 	entryPointNone entryPointKind = iota
 	entryPointUserSpecified
+	entryPointHTMLReference
 	entryPointDynamicImport
 )
 
@@ -55,6 +56,8 @@ type LinkerFile struct {
 	// Note that dynamically-imported files are allowed to also be specified by
 	// the user as top-level entry points, so some dynamically-imported files
 	// may be "entryPointUserSpecified" instead of "entryPointDynamicImport".
+	// Likewise, files referenced by an HTML entry point are only
+	// "entryPointHTMLReference" if they aren't already user-specified.
 	entryPointKind entryPointKind
 
 	// This is true if this file has been marked as live by the tree shaking
@@ -70,6 +73,10 @@ func (f *LinkerFile) IsUserSpecifiedEntryPoint() bool {
 	return f.entryPointKind == entryPointUserSpecified
 }
 
+func (f *LinkerFile) IsHTMLReferencedEntryPoint() bool {
+	return f.entryPointKind == entryPointHTMLReference
+}
+
 // Note: This is not guarded by a mutex. Make sure this isn't called from a
 // parallel part of the code.
 func (f *LinkerFile) LineColumnTracker() *logger.LineColumnTracker {
@@ -115,6 +122,10 @@ type LinkerGraph struct {
 	// is useful as a deterministic key for sorting if you need to sort something
 	// containing a source index (such as "js_ast.Ref" symbol references).
 	StableSourceIndices []uint32
+
+	// This holds the constant values of all top-level TypeScript enums in all
+	// reachable files. It's used to inline enum values across modules.
+	TSEnums map[js_ast.Ref]map[string]js_ast.TSEnumValue
 }
 
 func CloneLinkerGraph(
@@ -135,7 +146,9 @@ func CloneLinkerGraph(
 	// Clone various things since we may mutate them later. Do this in parallel
 	// for a speedup (around ~2x faster for this function in the three.js
 	// benchmark on a 6-core laptop).
+	var htmlReferenceEntryPoints []uint32
 	var dynamicImportEntryPoints []uint32
+	var htmlReferenceEntryPointsMutex sync.Mutex
 	var dynamicImportEntryPointsMutex sync.Mutex
 	waitGroup := sync.WaitGroup{}
 	waitGroup.Add(len(reachableFiles))
@@ -228,6 +241,29 @@ func CloneLinkerGraph(
 
 				// Clone the import records
 				repr.AST.ImportRecords = append([]ast.ImportRecord{}, repr.AST.ImportRecords...)
+
+			case *HTMLRepr:
+				// Clone the representation
+				{
+					clone := *repr
+					repr = &clone
+					file.InputFile.Repr = repr
+				}
+
+				// Clone the import records
+				repr.AST.ImportRecords = append([]ast.ImportRecord{}, repr.AST.ImportRecords...)
+
+				// Scripts and stylesheets referenced by an HTML file are always entry
+				// points, even if code splitting is disabled, since each one must be
+				// loaded by the browser as a separate file
+				for importRecordIndex := range repr.AST.ImportRecords {
+					if record := &repr.AST.ImportRecords[importRecordIndex]; record.SourceIndex.IsValid() &&
+						(record.Kind == ast.ImportHTMLScript || record.Kind == ast.ImportHTMLStylesheet) {
+						htmlReferenceEntryPointsMutex.Lock()
+						htmlReferenceEntryPoints = append(htmlReferenceEntryPoints, record.SourceIndex.GetIndex())
+						htmlReferenceEntryPointsMutex.Unlock()
+					}
+				}
 			}
 
 			// All files start off as far as possible from an entry point
@@ -237,8 +273,39 @@ func CloneLinkerGraph(
 	}
 	waitGroup.Wait()
 
-	// Process dynamic entry points after merging control flow again
-	stableEntryPoints := make([]int, 0, len(dynamicImportEntryPoints))
+	// Merge the enum values from all files together. The symbol references are
+	// already unique across files so there is no need to worry about collisions.
+	var tsEnums map[js_ast.Ref]map[string]js_ast.TSEnumValue
+	for _, sourceIndex := range reachableFiles {
+		if repr, ok := files[sourceIndex].InputFile.Repr.(*JSRepr); ok && repr.AST.TSEnums != nil {
+			if tsEnums == nil {
+				tsEnums = make(map[js_ast.Ref]map[string]js_ast.TSEnumValue)
+			}
+			for ref, values := range repr.AST.TSEnums {
+				tsEnums[ref] = values
+			}
+		}
+	}
+
+	// Process HTML-referenced entry points after merging control flow again.
+	// These are added before dynamic entry points so that a file that is both
+	// referenced from HTML and dynamically-imported is treated as the former.
+	stableEntryPoints := make([]int, 0, len(htmlReferenceEntryPoints))
+	for _, sourceIndex := range htmlReferenceEntryPoints {
+		if otherFile := &files[sourceIndex]; otherFile.entryPointKind == entryPointNone {
+			stableEntryPoints = append(stableEntryPoints, int(stableSourceIndices[sourceIndex]))
+			otherFile.entryPointKind = entryPointHTMLReference
+		}
+	}
+
+	// Make sure to add HTML-referenced entry points in a deterministic order
+	sort.Ints(stableEntryPoints)
+	for _, stableIndex := range stableEntryPoints {
+		entryPoints = append(entryPoints, EntryPoint{SourceIndex: reachableFiles[stableIndex]})
+	}
+
+	// Process dynamic entry points
+	stableEntryPoints = make([]int, 0, len(dynamicImportEntryPoints))
 	for _, sourceIndex := range dynamicImportEntryPoints {
 		if otherFile := &files[sourceIndex]; otherFile.entryPointKind == entryPointNone {
 			stableEntryPoints = append(stableEntryPoints, int(stableSourceIndices[sourceIndex]))
@@ -264,6 +331,7 @@ func CloneLinkerGraph(
 		Files:               files,
 		ReachableFiles:      reachableFiles,
 		StableSourceIndices: stableSourceIndices,
+		TSEnums:             tsEnums,
 	}
 }
 
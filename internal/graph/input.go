@@ -11,6 +11,7 @@ import (
 	"github.com/evanw/esbuild/internal/ast"
 	"github.com/evanw/esbuild/internal/config"
 	"github.com/evanw/esbuild/internal/css_ast"
+	"github.com/evanw/esbuild/internal/html_ast"
 	"github.com/evanw/esbuild/internal/js_ast"
 	"github.com/evanw/esbuild/internal/logger"
 	"github.com/evanw/esbuild/internal/resolver"
@@ -30,6 +31,10 @@ type InputFile struct {
 
 	SideEffects SideEffects
 	Loader      config.Loader
+
+	// This is true if TypeScript's "isolatedModules" setting applies to this
+	// file, in which case re-exporting a type without "export type" is a mistake
+	IsolatedModules bool
 }
 
 type OutputFile struct {
@@ -110,8 +115,20 @@ type CSSRepr struct {
 	// A JavaScript stub is automatically generated for a CSS file when it's
 	// imported from a JavaScript file.
 	JSSourceIndex ast.Index32
+
+	// This maps each local symbol in a "local-css" file to its final name. The
+	// final names are unique across all files in the build.
+	LocalNames map[string]string
 }
 
 func (repr *CSSRepr) ImportRecords() *[]ast.ImportRecord {
 	return &repr.AST.ImportRecords
 }
+
+type HTMLRepr struct {
+	AST html_ast.AST
+}
+
+func (repr *HTMLRepr) ImportRecords() *[]ast.ImportRecord {
+	return &repr.AST.ImportRecords
+}
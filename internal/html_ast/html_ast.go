@@ -0,0 +1,34 @@
+package html_ast
+
+import (
+	"github.com/evanw/esbuild/internal/ast"
+	"github.com/evanw/esbuild/internal/logger"
+)
+
+// Unlike the JavaScript and CSS ASTs, this AST doesn't attempt to represent
+// the whole document. HTML files are only ever used as entry points and the
+// only thing the bundler needs to do with them is to substitute the paths of
+// referenced files with the paths of the corresponding output files. So the
+// original source text is kept around and this AST just stores the locations
+// of the attribute values that reference other files. Everything else is
+// printed back out verbatim, which avoids reformatting the user's markup.
+
+type AST struct {
+	ImportRecords []ast.ImportRecord
+
+	// These are in source order and do not overlap
+	References []Reference
+}
+
+type Reference struct {
+	// The range of the attribute value including the surrounding quotes (if
+	// any). This whole range is replaced with a quoted path when printing.
+	ValueRange logger.Range
+
+	// The location of the "<" character that starts the element containing
+	// this reference. Additional elements (e.g. a "<link>" tag for the CSS
+	// output file of a JavaScript entry point) are inserted here.
+	TagLoc logger.Loc
+
+	ImportRecordIndex uint32
+}
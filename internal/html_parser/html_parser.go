@@ -0,0 +1,357 @@
+package html_parser
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/evanw/esbuild/internal/ast"
+	"github.com/evanw/esbuild/internal/html_ast"
+	"github.com/evanw/esbuild/internal/logger"
+)
+
+// This is not a full HTML parser. It only understands enough of the HTML
+// tokenization rules to find start tags and their attributes while correctly
+// skipping over comments and the contents of raw text elements such as
+// "<script>" and "<style>". That's all that's needed to discover the files
+// that an HTML entry point references.
+
+type parser struct {
+	log           logger.Log
+	source        logger.Source
+	tracker       logger.LineColumnTracker
+	importRecords []ast.ImportRecord
+	references    []html_ast.Reference
+}
+
+type attribute struct {
+	name       string
+	value      string
+	valueRange logger.Range
+	hasValue   bool
+}
+
+func Parse(log logger.Log, source logger.Source) html_ast.AST {
+	p := parser{
+		log:     log,
+		source:  source,
+		tracker: logger.MakeLineColumnTracker(&source),
+	}
+	p.parseDocument()
+	return html_ast.AST{
+		ImportRecords: p.importRecords,
+		References:    p.references,
+	}
+}
+
+func (p *parser) parseDocument() {
+	text := p.source.Contents
+	i := 0
+
+	for i < len(text) {
+		lt := strings.IndexByte(text[i:], '<')
+		if lt == -1 {
+			break
+		}
+		i += lt
+		rest := text[i:]
+
+		switch {
+		case strings.HasPrefix(rest, "<!--"):
+			end := strings.Index(rest[4:], "-->")
+			if end == -1 {
+				p.log.Add(logger.Warning, &p.tracker, logger.Range{Loc: logger.Loc{Start: int32(i)}, Len: 4},
+					"Expected \"-->\" to terminate HTML comment")
+				return
+			}
+			i += 4 + end + 3
+
+		case len(rest) > 1 && (rest[1] == '!' || rest[1] == '?' || rest[1] == '/'):
+			// Doctypes, processing instructions, and end tags can't reference files
+			end := strings.IndexByte(rest, '>')
+			if end == -1 {
+				return
+			}
+			i += end + 1
+
+		case len(rest) > 1 && isASCIILetter(rest[1]):
+			i = p.parseStartTag(i)
+
+		default:
+			i++
+		}
+	}
+}
+
+func (p *parser) parseStartTag(start int) int {
+	text := p.source.Contents
+	i := start + 1
+
+	// Parse the tag name
+	nameStart := i
+	for i < len(text) && !isWhitespace(text[i]) && text[i] != '/' && text[i] != '>' {
+		i++
+	}
+	tagName := strings.ToLower(text[nameStart:i])
+	var attrs []attribute
+
+	// Parse the attributes
+	for {
+		for i < len(text) && (isWhitespace(text[i]) || text[i] == '/') {
+			i++
+		}
+		if i == len(text) {
+			p.log.Add(logger.Warning, &p.tracker, logger.Range{Loc: logger.Loc{Start: int32(start)}, Len: int32(i - start)},
+				fmt.Sprintf("Expected \">\" to terminate the %q tag", tagName))
+			return i
+		}
+		if text[i] == '>' {
+			i++
+			break
+		}
+
+		// The first character is always part of the name, even if it's an "="
+		nameStart := i
+		i++
+		for i < len(text) && !isWhitespace(text[i]) && text[i] != '/' && text[i] != '>' && text[i] != '=' {
+			i++
+		}
+		attr := attribute{name: strings.ToLower(text[nameStart:i])}
+
+		// Parse the optional value
+		j := skipWhitespace(text, i)
+		if j < len(text) && text[j] == '=' {
+			i = skipWhitespace(text, j+1)
+			if i < len(text) {
+				if quote := text[i]; quote == '"' || quote == '\'' {
+					end := strings.IndexByte(text[i+1:], quote)
+					if end == -1 {
+						p.log.Add(logger.Warning, &p.tracker, logger.Range{Loc: logger.Loc{Start: int32(i)}, Len: int32(len(text) - i)},
+							fmt.Sprintf("Expected %q to terminate the value of the %q attribute", string(quote), attr.name))
+						return len(text)
+					}
+					attr.value = decodeCharacterReferences(text[i+1 : i+1+end])
+					attr.valueRange = logger.Range{Loc: logger.Loc{Start: int32(i)}, Len: int32(end + 2)}
+					i += end + 2
+				} else {
+					valueStart := i
+					for i < len(text) && !isWhitespace(text[i]) && text[i] != '>' {
+						i++
+					}
+					attr.value = decodeCharacterReferences(text[valueStart:i])
+					attr.valueRange = logger.Range{Loc: logger.Loc{Start: int32(valueStart)}, Len: int32(i - valueStart)}
+				}
+				attr.hasValue = true
+			}
+		}
+
+		attrs = append(attrs, attr)
+	}
+
+	p.handleStartTag(tagName, logger.Loc{Start: int32(start)}, attrs)
+
+	// The contents of these elements are not parsed as markup
+	switch tagName {
+	case "script", "style", "textarea", "title":
+		i = skipRawText(text, i, tagName)
+	}
+	return i
+}
+
+func (p *parser) handleStartTag(tagName string, tagLoc logger.Loc, attrs []attribute) {
+	switch tagName {
+	case "script":
+		// Only module scripts are bundled. Classic scripts share a single global
+		// scope, so they can't safely be turned into separate entry points.
+		if src := findAttribute(attrs, "src"); src != nil {
+			if scriptType := findAttribute(attrs, "type"); scriptType != nil &&
+				strings.EqualFold(strings.TrimSpace(scriptType.value), "module") {
+				p.addReference(tagLoc, src, ast.ImportHTMLScript)
+			}
+		}
+
+	case "link":
+		if href := findAttribute(attrs, "href"); href != nil {
+			if rel := findAttribute(attrs, "rel"); rel != nil {
+				for _, token := range strings.Fields(strings.ToLower(rel.value)) {
+					switch token {
+					case "stylesheet":
+						p.addReference(tagLoc, href, ast.ImportHTMLStylesheet)
+						return
+
+					case "icon", "apple-touch-icon", "mask-icon":
+						p.addReference(tagLoc, href, ast.ImportHTMLURL)
+						return
+					}
+				}
+			}
+		}
+
+	case "img", "source", "audio", "track", "embed", "input":
+		if src := findAttribute(attrs, "src"); src != nil {
+			p.addReference(tagLoc, src, ast.ImportHTMLURL)
+		}
+
+	case "video":
+		if src := findAttribute(attrs, "src"); src != nil {
+			p.addReference(tagLoc, src, ast.ImportHTMLURL)
+		}
+		if poster := findAttribute(attrs, "poster"); poster != nil {
+			p.addReference(tagLoc, poster, ast.ImportHTMLURL)
+		}
+	}
+}
+
+func (p *parser) addReference(tagLoc logger.Loc, attr *attribute, kind ast.ImportKind) {
+	if !attr.hasValue {
+		return
+	}
+
+	// Only paths that are relative to the HTML file are bundled. Everything
+	// else (e.g. "https://..." or "/favicon.ico") is left alone.
+	path := strings.TrimSpace(attr.value)
+	if !isRelativeURL(path) {
+		return
+	}
+
+	p.references = append(p.references, html_ast.Reference{
+		ValueRange:        attr.valueRange,
+		TagLoc:            tagLoc,
+		ImportRecordIndex: uint32(len(p.importRecords)),
+	})
+	p.importRecords = append(p.importRecords, ast.ImportRecord{
+		Kind:  kind,
+		Path:  logger.Path{Text: path},
+		Range: attr.valueRange,
+	})
+}
+
+func findAttribute(attrs []attribute, name string) *attribute {
+	for i := range attrs {
+		if attrs[i].name == name {
+			return &attrs[i]
+		}
+	}
+	return nil
+}
+
+func isRelativeURL(url string) bool {
+	if url == "" {
+		return false
+	}
+
+	// Root-relative URLs, query strings, and fragments
+	switch url[0] {
+	case '/', '\\', '?', '#':
+		return false
+	}
+
+	// URLs with a scheme (e.g. "data:" or "http:")
+	for i := 0; i < len(url); i++ {
+		c := url[i]
+		if c == ':' {
+			return i == 0
+		}
+		if !isASCIILetter(c) && (i == 0 || !isSchemeChar(c)) {
+			break
+		}
+	}
+
+	return true
+}
+
+func skipRawText(text string, i int, tagName string) int {
+	for {
+		lt := strings.Index(text[i:], "</")
+		if lt == -1 {
+			return len(text)
+		}
+		i += lt
+		end := i + 2 + len(tagName)
+		if end <= len(text) && strings.EqualFold(text[i+2:end], tagName) &&
+			(end == len(text) || isWhitespace(text[end]) || text[end] == '/' || text[end] == '>') {
+			return i
+		}
+		i += 2
+	}
+}
+
+func skipWhitespace(text string, i int) int {
+	for i < len(text) && isWhitespace(text[i]) {
+		i++
+	}
+	return i
+}
+
+func isWhitespace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == '\f'
+}
+
+func isASCIILetter(c byte) bool {
+	return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func isSchemeChar(c byte) bool {
+	return (c >= '0' && c <= '9') || c == '+' || c == '-' || c == '.'
+}
+
+var namedCharacterReferences = map[string]string{
+	"amp":  "&",
+	"apos": "'",
+	"gt":   ">",
+	"lt":   "<",
+	"nbsp": "\u00A0",
+	"quot": "\"",
+}
+
+// Attribute values can contain character references such as "&amp;". Only the
+// numeric forms and a few common named forms are supported since those are the
+// only ones that are likely to appear in a file path.
+func decodeCharacterReferences(text string) string {
+	if strings.IndexByte(text, '&') == -1 {
+		return text
+	}
+
+	sb := strings.Builder{}
+	for {
+		amp := strings.IndexByte(text, '&')
+		if amp == -1 {
+			break
+		}
+		sb.WriteString(text[:amp])
+		text = text[amp:]
+
+		semicolon := strings.IndexByte(text, ';')
+		if semicolon == -1 {
+			break
+		}
+		name := text[1:semicolon]
+
+		if strings.HasPrefix(name, "#") {
+			var codePoint uint64
+			var err error
+			if len(name) > 1 && (name[1] == 'x' || name[1] == 'X') {
+				codePoint, err = strconv.ParseUint(name[2:], 16, 32)
+			} else {
+				codePoint, err = strconv.ParseUint(name[1:], 10, 32)
+			}
+			if err == nil && codePoint <= utf8.MaxRune {
+				sb.WriteRune(rune(codePoint))
+				text = text[semicolon+1:]
+				continue
+			}
+		} else if value, ok := namedCharacterReferences[name]; ok {
+			sb.WriteString(value)
+			text = text[semicolon+1:]
+			continue
+		}
+
+		// Leave anything unrecognized alone
+		sb.WriteByte('&')
+		text = text[1:]
+	}
+
+	sb.WriteString(text)
+	return sb.String()
+}
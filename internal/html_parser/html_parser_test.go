@@ -0,0 +1,117 @@
+package html_parser
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/evanw/esbuild/internal/ast"
+	"github.com/evanw/esbuild/internal/logger"
+	"github.com/evanw/esbuild/internal/test"
+)
+
+func expectParseReferences(t *testing.T, contents string, expected string) {
+	t.Helper()
+	expectParseReferencesAndLog(t, contents, expected, "")
+}
+
+func expectParseReferencesAndLog(t *testing.T, contents string, expected string, expectedLog string) {
+	t.Helper()
+	t.Run(contents, func(t *testing.T) {
+		t.Helper()
+		log := logger.NewDeferLog(logger.DeferLogNoVerboseOrDebug)
+		tree := Parse(log, test.SourceForTest(contents))
+		msgs := log.Done()
+		text := ""
+		for _, msg := range msgs {
+			text += msg.String(logger.OutputOptions{}, logger.TerminalInfo{})
+		}
+		test.AssertEqualWithDiff(t, text, expectedLog)
+
+		sb := strings.Builder{}
+		for _, ref := range tree.References {
+			record := tree.ImportRecords[ref.ImportRecordIndex]
+			sb.WriteString(fmt.Sprintf("%s %q %s\n", record.Kind.StringForMetafile(), record.Path.Text,
+				contents[ref.ValueRange.Loc.Start:ref.ValueRange.End()]))
+		}
+		test.AssertEqualWithDiff(t, sb.String(), expected)
+	})
+}
+
+func TestScripts(t *testing.T) {
+	expectParseReferences(t, `<script type="module" src="./a.js"></script>`, "html-script \"./a.js\" \"./a.js\"\n")
+	expectParseReferences(t, `<SCRIPT TYPE=MODULE SRC=a.js></SCRIPT>`, "html-script \"a.js\" a.js\n")
+	expectParseReferences(t, `<script type=" module " src='a.js'></script>`, "html-script \"a.js\" 'a.js'\n")
+	expectParseReferences(t, `<script src="./a.js"></script>`, "")
+	expectParseReferences(t, `<script type="text/javascript" src="./a.js"></script>`, "")
+	expectParseReferences(t, `<script type="module"></script>`, "")
+	expectParseReferences(t, `<script type="module" src></script>`, "")
+}
+
+func TestLinks(t *testing.T) {
+	expectParseReferences(t, `<link rel="stylesheet" href="a.css">`, "html-stylesheet \"a.css\" \"a.css\"\n")
+	expectParseReferences(t, `<link href="a.css" rel="preload stylesheet">`, "html-stylesheet \"a.css\" \"a.css\"\n")
+	expectParseReferences(t, `<link rel="icon" href="a.png">`, "html-url \"a.png\" \"a.png\"\n")
+	expectParseReferences(t, `<link rel="shortcut icon" href="a.ico">`, "html-url \"a.ico\" \"a.ico\"\n")
+	expectParseReferences(t, `<link rel="apple-touch-icon" href="a.png">`, "html-url \"a.png\" \"a.png\"\n")
+	expectParseReferences(t, `<link rel="canonical" href="a.html">`, "")
+	expectParseReferences(t, `<link href="a.css">`, "")
+}
+
+func TestAssets(t *testing.T) {
+	expectParseReferences(t, `<img src="a.png">`, "html-url \"a.png\" \"a.png\"\n")
+	expectParseReferences(t, `<img alt="x" src = "a.png" />`, "html-url \"a.png\" \"a.png\"\n")
+	expectParseReferences(t, `<video src="a.mp4" poster="a.png"></video>`,
+		"html-url \"a.mp4\" \"a.mp4\"\nhtml-url \"a.png\" \"a.png\"\n")
+	expectParseReferences(t, `<audio src="a.mp3"></audio>`, "html-url \"a.mp3\" \"a.mp3\"\n")
+	expectParseReferences(t, `<source src="a.webm">`, "html-url \"a.webm\" \"a.webm\"\n")
+	expectParseReferences(t, `<div src="a.png"></div>`, "")
+}
+
+func TestNonRelativeURLs(t *testing.T) {
+	expectParseReferences(t, `<img src="/a.png">`, "")
+	expectParseReferences(t, `<img src="//example.com/a.png">`, "")
+	expectParseReferences(t, `<img src="https://example.com/a.png">`, "")
+	expectParseReferences(t, `<img src="data:image/png;base64,">`, "")
+	expectParseReferences(t, `<img src="#a">`, "")
+	expectParseReferences(t, `<img src="?a">`, "")
+	expectParseReferences(t, `<img src="">`, "")
+	expectParseReferences(t, `<img src="a/b:c.png">`, "html-url \"a/b:c.png\" \"a/b:c.png\"\n")
+	expectParseReferences(t, `<img src="../a.png">`, "html-url \"../a.png\" \"../a.png\"\n")
+}
+
+func TestCharacterReferences(t *testing.T) {
+	expectParseReferences(t, `<img src="a&amp;b.png">`, "html-url \"a&b.png\" \"a&amp;b.png\"\n")
+	expectParseReferences(t, `<img src="a&#38;b&#x26;c.png">`, "html-url \"a&b&c.png\" \"a&#38;b&#x26;c.png\"\n")
+	expectParseReferences(t, `<img src="a&unknown;b.png">`, "html-url \"a&unknown;b.png\" \"a&unknown;b.png\"\n")
+	expectParseReferences(t, `<img src="a&b.png">`, "html-url \"a&b.png\" \"a&b.png\"\n")
+}
+
+func TestRawText(t *testing.T) {
+	expectParseReferences(t, `<!-- <img src="a.png"> --><img src="b.png">`, "html-url \"b.png\" \"b.png\"\n")
+	expectParseReferences(t, `<script>"<img src='a.png'>"</script><img src="b.png">`, "html-url \"b.png\" \"b.png\"\n")
+	expectParseReferences(t, `<style>/* <img src="a.png"> */</style><img src="b.png">`, "html-url \"b.png\" \"b.png\"\n")
+	expectParseReferences(t, `<textarea><img src="a.png"></TEXTAREA><img src="b.png">`, "html-url \"b.png\" \"b.png\"\n")
+	expectParseReferences(t, `<script>"</scripty>"</script><img src="b.png">`, "html-url \"b.png\" \"b.png\"\n")
+	expectParseReferences(t, `<!DOCTYPE html><img src="b.png">`, "html-url \"b.png\" \"b.png\"\n")
+}
+
+func TestErrors(t *testing.T) {
+	expectParseReferencesAndLog(t, `<!-- <img src="a.png">`, "", "<stdin>: WARNING: Expected \"-->\" to terminate HTML comment\n")
+	expectParseReferencesAndLog(t, `<img src="a.png"`, "", "<stdin>: WARNING: Expected \">\" to terminate the \"img\" tag\n")
+	expectParseReferencesAndLog(t, `<img src="a.png>`, "", "<stdin>: WARNING: Expected \"\\\"\" to terminate the value of the \"src\" attribute\n")
+}
+
+func TestImportRecordKinds(t *testing.T) {
+	tree := Parse(logger.NewDeferLog(logger.DeferLogNoVerboseOrDebug), test.SourceForTest(
+		`<script type="module" src="a.js"></script><link rel="stylesheet" href="b.css"><img src="c.png">`))
+	kinds := []ast.ImportKind{ast.ImportHTMLScript, ast.ImportHTMLStylesheet, ast.ImportHTMLURL}
+	if len(tree.ImportRecords) != len(kinds) {
+		t.Fatalf("Expected %d import records, got %d", len(kinds), len(tree.ImportRecords))
+	}
+	for i, kind := range kinds {
+		if tree.ImportRecords[i].Kind != kind {
+			t.Fatalf("Expected import record %d to have kind %q", i, kind.StringForMetafile())
+		}
+	}
+}
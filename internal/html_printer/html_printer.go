@@ -0,0 +1,69 @@
+package html_printer
+
+import (
+	"strings"
+
+	"github.com/evanw/esbuild/internal/html_ast"
+)
+
+type Options struct {
+	// The bundler generates a CSS file for each JavaScript entry point that
+	// imports CSS. Since there's nothing in the original HTML file that refers
+	// to these generated files, "<link>" tags for them are inserted before the
+	// element containing the reference with the given import record index.
+	StylesheetsBeforeReference map[uint32][]string
+}
+
+// The HTML printer doesn't reformat anything. It copies the original source
+// text through verbatim and only replaces the attribute values that reference
+// other files with the paths in the corresponding import records.
+func Print(contents string, tree html_ast.AST, options Options) []byte {
+	var html []byte
+	end := 0
+
+	for _, ref := range tree.References {
+		start := int(ref.ValueRange.Loc.Start)
+
+		if stylesheets := options.StylesheetsBeforeReference[ref.ImportRecordIndex]; len(stylesheets) > 0 {
+			if tagStart := int(ref.TagLoc.Start); tagStart >= end {
+				html = append(html, contents[end:tagStart]...)
+				end = tagStart
+				var separator string
+				if indent, ok := indentationBefore(contents, tagStart); ok {
+					separator = "\n" + indent
+				}
+				for _, href := range stylesheets {
+					html = append(html, "<link rel=\"stylesheet\" href="...)
+					html = append(html, quote(href)...)
+					html = append(html, '>')
+					html = append(html, separator...)
+				}
+			}
+		}
+
+		html = append(html, contents[end:start]...)
+		html = append(html, quote(tree.ImportRecords[ref.ImportRecordIndex].Path.Text)...)
+		end = start + int(ref.ValueRange.Len)
+	}
+
+	html = append(html, contents[end:]...)
+	return html
+}
+
+// Returns the whitespace between the start of the line and the given offset if
+// there's only whitespace there. This is used to put inserted elements on their
+// own line that lines up with the element they are inserted before.
+func indentationBefore(contents string, offset int) (string, bool) {
+	lineStart := strings.LastIndexAny(contents[:offset], "\r\n") + 1
+	indent := contents[lineStart:offset]
+	if strings.TrimLeft(indent, " \t") != "" {
+		return "", false
+	}
+	return indent, true
+}
+
+func quote(text string) string {
+	text = strings.ReplaceAll(text, "&", "&amp;")
+	text = strings.ReplaceAll(text, "\"", "&quot;")
+	return "\"" + text + "\""
+}
@@ -0,0 +1,52 @@
+package html_printer
+
+import (
+	"testing"
+
+	"github.com/evanw/esbuild/internal/html_parser"
+	"github.com/evanw/esbuild/internal/logger"
+	"github.com/evanw/esbuild/internal/test"
+)
+
+func expectPrinted(t *testing.T, contents string, paths []string, stylesheets map[uint32][]string, expected string) {
+	t.Helper()
+	t.Run(contents, func(t *testing.T) {
+		t.Helper()
+		log := logger.NewDeferLog(logger.DeferLogNoVerboseOrDebug)
+		tree := html_parser.Parse(log, test.SourceForTest(contents))
+		msgs := log.Done()
+		text := ""
+		for _, msg := range msgs {
+			text += msg.String(logger.OutputOptions{}, logger.TerminalInfo{})
+		}
+		test.AssertEqualWithDiff(t, text, "")
+		for i, path := range paths {
+			tree.ImportRecords[i].Path.Text = path
+		}
+		result := Print(contents, tree, Options{StylesheetsBeforeReference: stylesheets})
+		test.AssertEqualWithDiff(t, string(result), expected)
+	})
+}
+
+func TestReplacePaths(t *testing.T) {
+	expectPrinted(t, `<img src="a.png">`, []string{"./b.png"}, nil, `<img src="./b.png">`)
+	expectPrinted(t, `<img src='a.png'>`, []string{"./b.png"}, nil, `<img src="./b.png">`)
+	expectPrinted(t, `<img src=a.png alt=x>`, []string{"./b.png"}, nil, `<img src="./b.png" alt=x>`)
+	expectPrinted(t, `<img src="a.png">`, []string{`./a&"b.png`}, nil, `<img src="./a&amp;&quot;b.png">`)
+	expectPrinted(t, `<img src="a.png"><img src="https://example.com/x.png"><img src="c.png">`,
+		[]string{"./b.png", "./d.png"}, nil,
+		`<img src="./b.png"><img src="https://example.com/x.png"><img src="./d.png">`)
+}
+
+func TestInsertStylesheets(t *testing.T) {
+	expectPrinted(t, `<script type="module" src="a.js"></script>`, []string{"./b.js"},
+		map[uint32][]string{0: {"./b.css"}},
+		`<link rel="stylesheet" href="./b.css">
+<script type="module" src="./b.js"></script>`)
+	expectPrinted(t, "<head>\n    <script type=\"module\" src=\"a.js\"></script>\n</head>", []string{"./b.js"},
+		map[uint32][]string{0: {"./b.css"}},
+		"<head>\n    <link rel=\"stylesheet\" href=\"./b.css\">\n    <script type=\"module\" src=\"./b.js\"></script>\n</head>")
+	expectPrinted(t, "<head><script type=\"module\" src=\"a.js\"></script></head>", []string{"./b.js"},
+		map[uint32][]string{0: {"./b.css"}},
+		"<head><link rel=\"stylesheet\" href=\"./b.css\"><script type=\"module\" src=\"./b.js\"></script></head>")
+}
@@ -262,15 +262,48 @@ const (
 	PropertySpread
 	PropertyDeclare
 	PropertyClassStaticBlock
+
+	// "class Foo { accessor x = 1 }"
+	PropertyAutoAccessor
+)
+
+// TypeScript's "emitDecoratorMetadata" setting generates code that refers to
+// a runtime value for each type annotation on a decorated class member. This
+// is a summary of a type annotation with just enough information to do that.
+type TSMetadataKind uint8
+
+const (
+	TSMetadataNone TSMetadataKind = iota // There was no type annotation
+	TSMetadataObject
+	TSMetadataAny
+	TSMetadataUnknown
+	TSMetadataNever
+	TSMetadataVoid
+	TSMetadataUndefined
+	TSMetadataNull
+	TSMetadataFunction
+	TSMetadataArray
+	TSMetadataBoolean
+	TSMetadataString
+	TSMetadataNumber
+	TSMetadataBigInt
+	TSMetadataSymbol
+	TSMetadataReference
 )
 
+type TSMetadata struct {
+	// This is a dot-separated name such as "Foo" or "ns.Foo" for references
+	Name string
+	Kind TSMetadataKind
+}
+
 type ClassStaticBlock struct {
 	Loc   logger.Loc
 	Stmts []Stmt
 }
 
 type Property struct {
-	TSDecorators     []Expr
+	Decorators       []Expr
 	ClassStaticBlock *ClassStaticBlock
 
 	Key Expr
@@ -289,6 +322,9 @@ type Property struct {
 	//
 	InitializerOrNil Expr
 
+	// This is the type annotation of a TypeScript class field
+	TSMetadata TSMetadata
+
 	Kind            PropertyKind
 	IsComputed      bool
 	IsMethod        bool
@@ -308,6 +344,7 @@ type PropertyBinding struct {
 
 type Arg struct {
 	TSDecorators []Expr
+	TSMetadata   TSMetadata
 	Binding      Binding
 	DefaultOrNil Expr
 
@@ -322,6 +359,9 @@ type Fn struct {
 	Body         FnBody
 	ArgumentsRef Ref
 
+	// This is the return type annotation in TypeScript
+	ReturnTSMetadata TSMetadata
+
 	IsAsync     bool
 	IsGenerator bool
 	HasRestArg  bool
@@ -338,7 +378,7 @@ type FnBody struct {
 
 type Class struct {
 	ClassKeyword logger.Range
-	TSDecorators []Expr
+	Decorators   []Expr
 	Name         *LocRef
 	ExtendsOrNil Expr
 	BodyLoc      logger.Loc
@@ -531,6 +571,11 @@ type EDot struct {
 	// unwrapped if the resulting value is unused. Unwrapping means discarding
 	// the call target but keeping any arguments with side effects.
 	CallCanBeUnwrappedIfUnused bool
+
+	// If true, the target is an imported symbol and this property access is
+	// not an assignment target. If the linker discovers that the import is a
+	// TypeScript enum, the printer will substitute the member's value instead.
+	CanBeInlinedIfEnum bool
 }
 
 func (a *EDot) HasSameFlagsAs(b *EDot) bool {
@@ -543,6 +588,9 @@ type EIndex struct {
 	Target        Expr
 	Index         Expr
 	OptionalChain OptionalChain
+
+	// This is the same as "CanBeInlinedIfEnum" on "EDot"
+	CanBeInlinedIfEnum bool
 }
 
 func (a *EIndex) HasSameFlagsAs(b *EIndex) bool {
@@ -1093,6 +1141,7 @@ type SEnum struct {
 	Arg      Ref
 	Values   []EnumValue
 	IsExport bool
+	IsConst  bool
 }
 
 type SNamespace struct {
@@ -1229,8 +1278,30 @@ const (
 	LocalVar LocalKind = iota
 	LocalLet
 	LocalConst
+	LocalUsing
+	LocalAwaitUsing
 )
 
+func (kind LocalKind) IsUsing() bool {
+	return kind == LocalUsing || kind == LocalAwaitUsing
+}
+
+func (kind LocalKind) String() string {
+	switch kind {
+	case LocalVar:
+		return "var"
+	case LocalLet:
+		return "let"
+	case LocalConst:
+		return "const"
+	case LocalUsing:
+		return "using"
+	case LocalAwaitUsing:
+		return "await using"
+	}
+	return ""
+}
+
 type SLocal struct {
 	Decls    []Decl
 	Kind     LocalKind
@@ -1938,9 +2009,19 @@ type AST struct {
 	// call "TopLevelSymbolToParts" instead.
 	TopLevelSymbolToPartsFromParser map[Ref][]uint32
 
+	// This contains the constant values of all top-level enums in this file.
+	// It's used by the linker to inline enum values across modules.
+	TSEnums map[Ref]map[string]TSEnumValue
+
 	SourceMapComment logger.Span
 }
 
+// Exactly one of these fields is used
+type TSEnumValue struct {
+	String []uint16 // Use this if it's not nil
+	Number float64  // Use this if "String" is nil
+}
+
 // This is a histogram of character frequencies for minification
 type CharFreq [64]int32
 
@@ -2085,6 +2166,12 @@ type Part struct {
 	// An estimate of the number of uses of all symbols used within this part.
 	SymbolUses map[Ref]SymbolUse
 
+	// Property accesses off of imported symbols are counted here instead of in
+	// "SymbolUses" because they may turn out to be references to constant
+	// TypeScript enum values that will be inlined. The linker moves any uses
+	// that can't be inlined into "SymbolUses" once imports have been bound.
+	ImportSymbolPropertyUses map[Ref]map[string]SymbolUse
+
 	// The indices of the other parts in this file that are needed if this part
 	// is needed.
 	Dependencies []Dependency
@@ -0,0 +1,277 @@
+// This file contains code for parsing Flow syntax. Flow type annotations are
+// close enough to TypeScript type annotations that Flow files are parsed using
+// the TypeScript parser with some additional Flow-specific syntax enabled. Like
+// TypeScript types, Flow types are skipped over as if they are whitespace.
+
+package js_parser
+
+import (
+	"github.com/evanw/esbuild/internal/js_ast"
+	"github.com/evanw/esbuild/internal/js_lexer"
+	"github.com/evanw/esbuild/internal/logger"
+)
+
+// Flow function types can omit parameter names, so "(string, number) => void"
+// is a function type even though it's not a valid arrow function argument list.
+// This is only called after parsing an argument list has already failed.
+func (p *parser) skipFlowParenOrFnType(opts skipTypeOpts) js_ast.TSMetadata {
+	p.lexer.Expect(js_lexer.TOpenParen)
+	var metadata js_ast.TSMetadata
+	isFnType := false
+
+	for {
+		// "(...Array<string>) => void"
+		if p.lexer.Token == js_lexer.TDotDotDot {
+			p.lexer.Next()
+			isFnType = true
+		}
+
+		metadata = p.skipTypeScriptType(js_ast.LLowest)
+		if p.lexer.Token != js_lexer.TComma {
+			break
+		}
+		p.lexer.Next()
+		isFnType = true
+
+		// "(string, number,) => void"
+		if p.lexer.Token == js_lexer.TCloseParen {
+			break
+		}
+	}
+
+	p.lexer.Expect(js_lexer.TCloseParen)
+
+	// "(Array<string>) => void"
+	//
+	// A parenthesized type followed by "=>" isn't a function type in a return
+	// type position because the "=>" belongs to the arrow function instead:
+	//
+	//   let f = (x): (string => void) => x
+	//
+	if isFnType || (p.lexer.Token == js_lexer.TEqualsGreaterThan && !opts.isReturnType) {
+		p.lexer.Expect(js_lexer.TEqualsGreaterThan)
+		p.skipTypeScriptReturnType()
+		return js_ast.TSMetadata{Kind: js_ast.TSMetadataFunction}
+	}
+
+	return metadata
+}
+
+// This skips over Flow's predicate function annotation
+// "function isString(x: mixed): boolean %checks { ... }"
+// "declare function isString(x: mixed): boolean %checks(typeof x === 'string')"
+func (p *parser) trySkipFlowPredicate() bool {
+	if !p.options.ts.Flow || p.lexer.Token != js_lexer.TPercent {
+		return false
+	}
+	p.lexer.Next()
+	p.lexer.ExpectContextualKeyword("checks")
+
+	// The expression after "%checks" is only type information
+	if p.lexer.Token == js_lexer.TOpenParen && !p.lexer.HasNewlineBefore {
+		p.skipFlowParenthesizedTokens()
+	}
+	return true
+}
+
+// This skips over a balanced sequence of parenthesized tokens without parsing
+// them, which is only safe to do for code that's entirely type information
+func (p *parser) skipFlowParenthesizedTokens() {
+	p.lexer.Expect(js_lexer.TOpenParen)
+
+	for depth := 1; depth > 0; {
+		switch p.lexer.Token {
+		case js_lexer.TOpenParen:
+			depth++
+		case js_lexer.TCloseParen:
+			depth--
+		case js_lexer.TEndOfFile:
+			p.lexer.Expect(js_lexer.TCloseParen)
+		}
+		p.lexer.Next()
+	}
+}
+
+// Returns true if the current "|" token ends a Flow exact object type such as
+// "{| x: A | B |}" instead of continuing a union type
+func (p *parser) isFlowExactObjectTypeEnd() bool {
+	if !p.options.ts.Flow {
+		return false
+	}
+
+	oldLexer := p.lexer
+	p.lexer.Next()
+	isEnd := p.lexer.Token == js_lexer.TCloseBrace
+
+	// Restore the lexer
+	p.lexer = oldLexer
+	return isEnd
+}
+
+// Returns true if the current less-than token is considered to be an arrow
+// function under Flow's rules for files containing JSX syntax. Unlike
+// TypeScript, Flow doesn't require "<T,>" to disambiguate a generic arrow
+// function from a JSX element, so this must look all the way ahead to the "=>".
+func (p *parser) isFlowArrowFnJSX() (isArrowFn bool) {
+	if !p.options.ts.Flow {
+		return false
+	}
+
+	oldLexer := p.lexer
+	p.lexer.IsLogDisabled = true
+
+	// Restore the lexer's memory to its original state when done, even if a
+	// syntax error was encountered while looking ahead
+	defer func() {
+		r := recover()
+		if _, isLexerPanic := r.(js_lexer.LexerPanic); !isLexerPanic && r != nil {
+			panic(r)
+		}
+		p.lexer = oldLexer
+	}()
+
+	// "<T>(x) => {}"
+	p.skipTypeScriptTypeParameters(allowConstModifier)
+	p.skipFlowParenthesizedTokens()
+
+	// "<T>(x): T => {}"
+	if p.lexer.Token == js_lexer.TColon {
+		p.lexer.Next()
+		p.skipTypeScriptReturnType()
+	}
+
+	return p.lexer.Token == js_lexer.TEqualsGreaterThan
+}
+
+// "opaque type Foo = string"
+// "opaque type Foo: Super = string"
+// "declare opaque type Foo"
+// "declare opaque type Foo: Super"
+func (p *parser) skipFlowOpaqueTypeStmt(opts parseStmtOpts) {
+	p.lexer.ExpectContextualKeyword("type")
+	name := p.lexer.Identifier
+	p.lexer.Expect(js_lexer.TIdentifier)
+
+	if opts.isModuleScope {
+		p.localTypeNames[name] = true
+	}
+
+	p.skipTypeScriptTypeParameters(0)
+
+	// "opaque type Foo: Super = string"
+	if p.lexer.Token == js_lexer.TColon {
+		p.lexer.Next()
+		p.skipTypeScriptType(js_ast.LLowest)
+	}
+
+	// The underlying type is omitted in declarations
+	if !opts.isTypeScriptDeclare || p.lexer.Token == js_lexer.TEquals {
+		p.lexer.Expect(js_lexer.TEquals)
+		p.skipTypeScriptType(js_ast.LLowest)
+	}
+
+	p.lexer.ExpectOrInsertSemicolon()
+}
+
+// This handles the forms of Flow's "declare" statement that TypeScript doesn't
+// have. The current token is the one after the "declare" keyword.
+func (p *parser) trySkipFlowDeclareStmt(opts parseStmtOpts) bool {
+	switch {
+	case p.lexer.IsContextualKeyword("module"):
+		// "declare module.exports: { foo: number }"
+		oldLexer := p.lexer
+		p.lexer.Next()
+		if p.lexer.Token != js_lexer.TDot {
+			p.lexer = oldLexer
+			return false
+		}
+		p.lexer.Next()
+		p.lexer.ExpectContextualKeyword("exports")
+		p.lexer.Expect(js_lexer.TColon)
+		p.skipTypeScriptType(js_ast.LLowest)
+		p.lexer.ExpectOrInsertSemicolon()
+		return true
+
+	case p.lexer.Token == js_lexer.TExport:
+		// "declare export function foo(): void"
+		// "declare export var foo: number"
+		p.lexer.Next()
+
+		if p.lexer.Token == js_lexer.TDefault {
+			p.lexer.Next()
+
+			// "declare export default string"
+			if p.lexer.Token != js_lexer.TFunction && p.lexer.Token != js_lexer.TClass {
+				p.skipTypeScriptType(js_ast.LLowest)
+				p.lexer.ExpectOrInsertSemicolon()
+				return true
+			}
+
+			// "declare export default class Foo {}"
+			// "declare export default function (): void"
+			opts.isNameOptional = true
+		}
+
+		p.parseStmt(opts)
+		return true
+	}
+
+	return false
+}
+
+// Returns true if the current "{" token starts an import clause with at least
+// one item. Flow removes import clauses where every item is type-only, but an
+// import clause that was empty to begin with is kept.
+func (p *parser) isNonEmptyImportClause() bool {
+	oldLexer := p.lexer
+	p.lexer.Next()
+	isNonEmpty := p.lexer.Token != js_lexer.TCloseBrace
+
+	// Restore the lexer
+	p.lexer = oldLexer
+	return isNonEmpty
+}
+
+// "import typeof foo from 'bar'"
+// "import typeof foo, {bar} from 'bar'"
+// "import typeof {foo} from 'bar'"
+// "import typeof * as foo from 'bar'"
+func (p *parser) skipFlowTypeofImportStmt() {
+	if p.lexer.Token == js_lexer.TIdentifier {
+		p.lexer.Next()
+		if p.lexer.Token == js_lexer.TComma {
+			p.lexer.Next()
+			p.skipFlowImportBindings()
+		}
+	} else {
+		p.skipFlowImportBindings()
+	}
+
+	p.lexer.ExpectContextualKeyword("from")
+	p.parsePath()
+	p.lexer.ExpectOrInsertSemicolon()
+}
+
+// "* as foo"
+// "{foo, bar}"
+func (p *parser) skipFlowImportBindings() {
+	switch p.lexer.Token {
+	case js_lexer.TAsterisk:
+		p.lexer.Next()
+		p.lexer.ExpectContextualKeyword("as")
+		p.lexer.Expect(js_lexer.TIdentifier)
+
+	case js_lexer.TOpenBrace:
+		p.parseImportClause()
+
+	default:
+		p.lexer.Unexpected()
+	}
+}
+
+// Flow enums have different run-time semantics than TypeScript enums and
+// require a run-time library, so they aren't supported
+func (p *parser) logFlowEnumError() {
+	p.log.Add(logger.Error, &p.tracker, p.lexer.Range(), "Flow enums are not supported")
+	panic(js_lexer.LexerPanic{})
+}
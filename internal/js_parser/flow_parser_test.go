@@ -0,0 +1,257 @@
+package js_parser
+
+import (
+	"testing"
+
+	"github.com/evanw/esbuild/internal/config"
+)
+
+func expectParseErrorFlow(t *testing.T, contents string, expected string) {
+	t.Helper()
+	expectParseErrorCommon(t, contents, expected, config.Options{
+		TS: config.TSOptions{
+			Parse: true,
+			Flow:  true,
+		},
+		JSX: config.JSXOptions{
+			Parse: true,
+		},
+	})
+}
+
+func expectPrintedFlow(t *testing.T, contents string, expected string) {
+	t.Helper()
+	expectPrintedCommon(t, contents, expected, config.Options{
+		TS: config.TSOptions{
+			Parse: true,
+			Flow:  true,
+		},
+		JSX: config.JSXOptions{
+			Parse: true,
+		},
+	})
+}
+
+func TestFlowPragma(t *testing.T) {
+	expectPrinted(t, "// @flow\nlet x: number = 1", "let x = 1;\n")
+	expectPrinted(t, "/* @flow strict */\nlet x: number = 1", "let x = 1;\n")
+	expectPrinted(t, "/**\n * @flow\n */\nlet x: number = 1", "let x = 1;\n")
+	expectPrinted(t, "#!/usr/bin/env node\n// @flow\nlet x: number = 1", "let x = 1;\n")
+	expectPrinted(t, "// @flow\nlet x = <div />", "let x = /* @__PURE__ */ React.createElement(\"div\", null);\n")
+	expectPrinted(t, "// @flow\nlet a = b ? (c) : d => e", "let a = b ? c : (d) => e;\n")
+	expectPrinted(t, "// @flow\nlet a = b ? (c): T => e : f", "let a = b ? (c) => e : f;\n")
+
+	// The pragma must come before the first token
+	expectParseError(t, "let x\n// @flow\nlet y: number = 1", "<stdin>: ERROR: Expected \";\" but found \":\"\n")
+	expectParseError(t, "// @flowtype\nlet x: number = 1", "<stdin>: ERROR: Expected \";\" but found \":\"\n")
+	expectParseError(t, "// @noflow\nlet x: number = 1", "<stdin>: ERROR: Expected \";\" but found \":\"\n")
+}
+
+func TestFlowTypes(t *testing.T) {
+	expectPrintedFlow(t, "let x: ?string", "let x;\n")
+	expectPrintedFlow(t, "let x: ?Array<?string>", "let x;\n")
+	expectPrintedFlow(t, "let x: Array<*>", "let x;\n")
+	expectPrintedFlow(t, "let x: mixed | empty", "let x;\n")
+	expectPrintedFlow(t, "let x: $ReadOnly<{ x: number }>", "let x;\n")
+	expectPrintedFlow(t, "let x: typeof y", "let x;\n")
+
+	// Object types
+	expectPrintedFlow(t, "let x: { a: number, b?: string }", "let x;\n")
+	expectPrintedFlow(t, "let x: { a: number; b?: string }", "let x;\n")
+	expectPrintedFlow(t, "let x: { +a: number, -b: string }", "let x;\n")
+	expectPrintedFlow(t, "let x: { [string]: number }", "let x;\n")
+	expectPrintedFlow(t, "let x: { [key: string]: number }", "let x;\n")
+	expectPrintedFlow(t, "let x: { ...A, b: number }", "let x;\n")
+	expectPrintedFlow(t, "let x: { a: number, ... }", "let x;\n")
+	expectPrintedFlow(t, "let x: { a: number, ...; }", "let x;\n")
+	expectPrintedFlow(t, "let x: { (): void, m(x: number): string }", "let x;\n")
+
+	// Exact object types
+	expectPrintedFlow(t, "let x: {||}", "let x;\n")
+	expectPrintedFlow(t, "let x: {| |}", "let x;\n")
+	expectPrintedFlow(t, "let x: {| a: number |}", "let x;\n")
+	expectPrintedFlow(t, "let x: {| a: number, b: string, |}", "let x;\n")
+	expectPrintedFlow(t, "let x: {| a: A | B |}", "let x;\n")
+	expectPrintedFlow(t, "let x: {| a: A | B, b: C | D |}", "let x;\n")
+	expectPrintedFlow(t, "let x: {| ...A, b: number |}", "let x;\n")
+	expectPrintedFlow(t, "let x: {| a: {| b: number |} |}", "let x;\n")
+	expectParseErrorFlow(t, "let x: {| a: number }", "<stdin>: ERROR: Expected \"|\" but found \"}\"\n")
+
+	// Function types
+	expectPrintedFlow(t, "let x: () => void", "let x;\n")
+	expectPrintedFlow(t, "let x: (a: number, b?: string) => void", "let x;\n")
+	expectPrintedFlow(t, "let x: (number, string) => void", "let x;\n")
+	expectPrintedFlow(t, "let x: (number, string,) => void", "let x;\n")
+	expectPrintedFlow(t, "let x: (Array<number>) => void", "let x;\n")
+	expectPrintedFlow(t, "let x: (?number) => void", "let x;\n")
+	expectPrintedFlow(t, "let x: (...Array<number>) => void", "let x;\n")
+	expectPrintedFlow(t, "let x: (number, ...rest: Array<number>) => void", "let x;\n")
+	expectPrintedFlow(t, "let x: <T>(T) => T", "let x;\n")
+	expectPrintedFlow(t, "let x: string => void", "let x;\n")
+	expectPrintedFlow(t, "let x: Array<string => void>", "let x;\n")
+	expectPrintedFlow(t, "let x: (string => void) | null", "let x;\n")
+	expectPrintedFlow(t, "let x: (A | B)", "let x;\n")
+	expectPrintedFlow(t, "let x: (A | B)[]", "let x;\n")
+	expectParseErrorFlow(t, "let x: (A, B)", "<stdin>: ERROR: Expected \"=>\" but found end of file\n")
+
+	// These aren't allowed in TypeScript
+	expectParseErrorTS(t, "let x: ?string", "<stdin>: ERROR: Unexpected \"?\"\n")
+	expectParseErrorTS(t, "let x: Array<*>", "<stdin>: ERROR: Unexpected \"*\"\n")
+	expectParseErrorTS(t, "let x: {| a: number |}", "<stdin>: ERROR: Unexpected \"|\"\n")
+	expectParseErrorTS(t, "let x: (Array<number>, string) => void", "<stdin>: ERROR: Expected \")\" but found \",\"\n")
+	expectParseErrorTS(t, "let x: string => void", "<stdin>: ERROR: Expected \";\" but found \"=>\"\n")
+}
+
+func TestFlowTypeParameters(t *testing.T) {
+	expectPrintedFlow(t, "function f<T>(x: T): T { return x }", "function f(x) {\n  return x;\n}\n")
+	expectPrintedFlow(t, "function f<T: Object>(x: T) {}", "function f(x) {\n}\n")
+	expectPrintedFlow(t, "function f<T: Object = {}>(x: T) {}", "function f(x) {\n}\n")
+	expectPrintedFlow(t, "function f<T = string, U: T = T>() {}", "function f() {\n}\n")
+	expectPrintedFlow(t, "class Foo<+T, -U> {}", "class Foo {\n}\n")
+	expectPrintedFlow(t, "type Foo<+T: string> = T", "")
+	expectPrintedFlow(t, "f<T>(x)", "f(x);\n")
+	expectPrintedFlow(t, "new Foo<T>()", "new Foo();\n")
+
+	// Generic arrow functions don't need a trailing comma in Flow even with JSX
+	expectPrintedFlow(t, "let f = <T>(x: T) => x", "let f = (x) => x;\n")
+	expectPrintedFlow(t, "let f = <T>(x: T): T => x", "let f = (x) => x;\n")
+	expectPrintedFlow(t, "let f = <T: Object>(x: T): T => x", "let f = (x) => x;\n")
+	expectPrintedFlow(t, "let f = <T,>(x: T) => x", "let f = (x) => x;\n")
+	expectPrintedFlow(t, "let f = async <T>(x: T) => x", "let f = async (x) => x;\n")
+	expectPrintedFlow(t, "let f = <T>(x = (1), y: (T) => void) => x", "let f = (x = 1, y) => x;\n")
+	expectPrintedFlow(t, "let x = <T>(y)</T>", "let x = /* @__PURE__ */ React.createElement(T, null, \"(y)\");\n")
+	expectPrintedFlow(t, "let x = <T>(y): z</T>", "let x = /* @__PURE__ */ React.createElement(T, null, \"(y): z\");\n")
+	expectPrintedFlow(t, "let x = <T>(don't)</T>", "let x = /* @__PURE__ */ React.createElement(T, null, \"(don't)\");\n")
+}
+
+func TestFlowCast(t *testing.T) {
+	expectPrintedFlow(t, "let x = (y: any)", "let x = y;\n")
+	expectPrintedFlow(t, "let x = ((y: any): string)", "let x = y;\n")
+	expectPrintedFlow(t, "let x = (a + b: number) * c", "let x = (a + b) * c;\n")
+	expectPrintedFlow(t, "let x = ([]: Array<string>)", "let x = [];\n")
+	expectPrintedFlow(t, "let x = ({}: {| a?: number |})", "let x = {};\n")
+	expectPrintedFlow(t, "f((x: any))", "f(x);\n")
+	expectPrintedFlow(t, "let x = a ? (b: T) : c", "let x = a ? b : c;\n")
+	expectParseErrorFlow(t, "let x = (a: any, b: any)", "<stdin>: ERROR: Unexpected \":\"\n")
+	expectParseErrorFlow(t, "let x = (...a: any)", "<stdin>: ERROR: Unexpected \":\"\n")
+	expectParseErrorTS(t, "let x = (y: any)", "<stdin>: ERROR: Unexpected \":\"\n")
+}
+
+func TestFlowFunction(t *testing.T) {
+	expectPrintedFlow(t, "function f(x: number, y?: string, ...z: Array<number>): void {}", "function f(x, y, ...z) {\n}\n")
+	expectPrintedFlow(t, "function f(this: Foo) {}", "function f() {\n}\n")
+	expectPrintedFlow(t, "function f(x: mixed): boolean %checks { return !!x }", "function f(x) {\n  return !!x;\n}\n")
+	expectPrintedFlow(t, "function f(x: mixed): %checks { return !!x }", "function f(x) {\n  return !!x;\n}\n")
+	expectPrintedFlow(t, "let f = (x: mixed): boolean %checks => !!x", "let f = (x) => !!x;\n")
+	expectPrintedFlow(t, "let f = (x): string => x", "let f = (x) => x;\n")
+	expectPrintedFlow(t, "let f = (x): (string => void) => x", "let f = (x) => x;\n")
+	expectPrintedFlow(t, "let f = (x: string => void) => x", "let f = (x) => x;\n")
+	expectPrintedFlow(t, "let f = async (x: number): Promise<void> => {}", "let f = async (x) => {\n};\n")
+	expectParseErrorFlow(t, "function f(x): boolean %foo {}", "<stdin>: ERROR: Expected \"checks\" but found \"foo\"\n")
+	expectParseErrorTS(t, "function f(x): boolean %checks {}", "<stdin>: ERROR: Expected \";\" but found \"%\"\n")
+}
+
+func TestFlowClass(t *testing.T) {
+	expectPrintedFlow(t, "class Foo extends Bar<T> implements Baz, Qux<T> {}", "class Foo extends Bar {\n}\n")
+	expectPrintedFlow(t, "class Foo { x: number }", "class Foo {\n  x;\n}\n")
+	expectPrintedFlow(t, "class Foo { x: number = 1 }", "class Foo {\n  x = 1;\n}\n")
+	expectPrintedFlow(t, "class Foo { +x: number; -y: string = 'y' }", "class Foo {\n  x;\n  y = \"y\";\n}\n")
+	expectPrintedFlow(t, "class Foo { static +x: number }", "class Foo {\n  static x;\n}\n")
+	expectPrintedFlow(t, "class Foo { +[x]: number }", "class Foo {\n  [x];\n}\n")
+	expectPrintedFlow(t, "class Foo { declare x: number }", "class Foo {\n}\n")
+	expectPrintedFlow(t, "class Foo { static +x = 1; static -y }", "class Foo {\n  static x = 1;\n  static y;\n}\n")
+	expectPrintedFlow(t, "class Foo { m<T>(x: T): T { return x } }", "class Foo {\n  m(x) {\n    return x;\n  }\n}\n")
+	expectPrintedFlow(t, "class Foo { x = a\n+b }", "class Foo {\n  x = a + b;\n}\n")
+	expectParseErrorFlow(t, "({ +x: 1 })", "<stdin>: ERROR: Expected identifier but found \"+\"\n")
+	expectParseErrorTS(t, "class Foo { +x: number }", "<stdin>: ERROR: Expected identifier but found \"+\"\n")
+
+	// Flow class fields use JavaScript semantics instead of TypeScript semantics
+	expectPrintedFlow(t, "class Foo { x: number; y = 1 }", "class Foo {\n  x;\n  y = 1;\n}\n")
+	expectPrintedTS(t, "class Foo { x: number; y = 1 }", "class Foo {\n  constructor() {\n    this.y = 1;\n  }\n}\n")
+}
+
+func TestFlowTypeDeclarations(t *testing.T) {
+	expectPrintedFlow(t, "type Foo = string", "")
+	expectPrintedFlow(t, "export type Foo = string", "")
+	expectPrintedFlow(t, "export type { Foo, Bar }", "")
+	expectPrintedFlow(t, "export type { Foo } from 'foo'", "")
+	expectPrintedFlow(t, "interface Foo { x: number }", "")
+	expectPrintedFlow(t, "export interface Foo<T> extends Bar<T> { x: T }", "")
+	expectPrintedFlow(t, "type Foo = string; export { Foo }", "export {};\n")
+	expectPrintedFlow(t, "type Foo = string; export default Foo", "")
+
+	expectPrintedFlow(t, "opaque type Foo = string", "")
+	expectPrintedFlow(t, "opaque type Foo: string = string", "")
+	expectPrintedFlow(t, "opaque type Foo<T>: Bar<T> = Baz<T>", "")
+	expectPrintedFlow(t, "export opaque type Foo = string", "")
+	expectPrintedFlow(t, "opaque type Foo = string; export { Foo }", "export {};\n")
+	expectPrintedFlow(t, "opaque\ntype\nFoo = string", "opaque;\ntype;\nFoo = string;\n")
+	expectPrintedFlow(t, "opaque(type)", "opaque(type);\n")
+	expectParseErrorFlow(t, "opaque type Foo", "<stdin>: ERROR: Expected \"=\" but found end of file\n")
+	expectParseErrorFlow(t, "opaque type Foo: string", "<stdin>: ERROR: Expected \"=\" but found end of file\n")
+	expectParseErrorTS(t, "opaque type Foo = string", "<stdin>: ERROR: Expected \";\" but found \"type\"\n")
+}
+
+func TestFlowDeclare(t *testing.T) {
+	expectPrintedFlow(t, "declare var foo: number", "")
+	expectPrintedFlow(t, "declare let foo: number", "")
+	expectPrintedFlow(t, "declare const foo: number", "")
+	expectPrintedFlow(t, "declare function foo(x: number): string", "")
+	expectPrintedFlow(t, "declare function foo(x: mixed): boolean %checks(typeof x === 'string')", "")
+	expectPrintedFlow(t, "declare function foo(x: mixed): boolean %checks((x))", "")
+	expectPrintedFlow(t, "declare class Foo<T> extends Bar<T> { x: T; static y(): void }", "")
+	expectPrintedFlow(t, "declare type Foo = number", "")
+	expectPrintedFlow(t, "declare interface Foo { x: number }", "")
+	expectPrintedFlow(t, "declare opaque type Foo", "")
+	expectPrintedFlow(t, "declare opaque type Foo: string", "")
+	expectPrintedFlow(t, "declare opaque type Foo = string", "")
+	expectPrintedFlow(t, "declare module.exports: { foo: number }", "")
+	expectPrintedFlow(t, "declare module 'foo' { declare module.exports: number }", "")
+	expectPrintedFlow(t, "declare module 'foo' { declare export function foo(): void }", "")
+	expectPrintedFlow(t, "declare export function foo(): void", "")
+	expectPrintedFlow(t, "declare export var foo: number", "")
+	expectPrintedFlow(t, "declare export class Foo {}", "")
+	expectPrintedFlow(t, "declare export type Foo = number", "")
+	expectPrintedFlow(t, "declare export default string", "")
+	expectPrintedFlow(t, "declare export default class Foo {}", "")
+	expectPrintedFlow(t, "declare export default function (): void", "")
+	expectPrintedFlow(t, "declare export default function foo(): void", "")
+	expectPrintedFlow(t, "module.exports = 1", "module.exports = 1;\n")
+	expectParseErrorTS(t, "declare module.exports: number", "<stdin>: ERROR: Expected \";\" but found \":\"\n")
+}
+
+func TestFlowImport(t *testing.T) {
+	expectPrintedFlow(t, "import type Foo from 'foo'", "")
+	expectPrintedFlow(t, "import type { Foo } from 'foo'", "")
+	expectPrintedFlow(t, "import type * as Foo from 'foo'", "")
+	expectPrintedFlow(t, "import type Foo, { Bar } from 'foo'", "")
+	expectPrintedFlow(t, "import type Foo, * as Bar from 'foo'", "")
+	expectPrintedFlow(t, "import typeof Foo from 'foo'", "")
+	expectPrintedFlow(t, "import typeof { Foo } from 'foo'", "")
+	expectPrintedFlow(t, "import typeof * as Foo from 'foo'", "")
+	expectPrintedFlow(t, "import typeof Foo, { Bar } from 'foo'", "")
+	expectPrintedFlow(t, "import { type Foo } from 'foo'", "")
+	expectPrintedFlow(t, "import { typeof Foo } from 'foo'", "")
+	expectPrintedFlow(t, "import { type Foo, typeof Bar as Baz } from 'foo'", "")
+	expectPrintedFlow(t, "import { type Foo, bar } from 'foo'", "import { bar } from \"foo\";\n")
+	expectPrintedFlow(t, "import { typeof Foo, bar } from 'foo'", "import { bar } from \"foo\";\n")
+	expectPrintedFlow(t, "import foo, { type Bar } from 'foo'", "import foo from \"foo\";\n")
+	expectPrintedFlow(t, "import { type } from 'foo'; type", "import { type } from \"foo\";\ntype;\n")
+	expectPrintedFlow(t, "import { typeof as foo } from 'foo'; foo", "import { typeof as foo } from \"foo\";\nfoo;\n")
+	expectParseErrorTS(t, "import typeof Foo from 'foo'", "<stdin>: ERROR: Unexpected \"typeof\"\n")
+	expectParseErrorTS(t, "import { typeof Foo } from 'foo'", "<stdin>: ERROR: Expected \"as\" but found \"Foo\"\n")
+
+	// Unlike TypeScript, Flow doesn't remove unused imports
+	expectPrintedFlow(t, "import foo from 'foo'", "import foo from \"foo\";\n")
+	expectPrintedFlow(t, "import { foo } from 'foo'", "import { foo } from \"foo\";\n")
+	expectPrintedFlow(t, "import * as foo from 'foo'", "import * as foo from \"foo\";\n")
+	expectPrintedFlow(t, "import {} from 'foo'", "import {} from \"foo\";\n")
+	expectPrintedFlow(t, "import foo, {} from 'foo'", "import foo, {} from \"foo\";\n")
+	expectPrintedTS(t, "import foo from 'foo'", "")
+}
+
+func TestFlowEnum(t *testing.T) {
+	expectParseErrorFlow(t, "enum Foo { A, B }", "<stdin>: ERROR: Flow enums are not supported\n")
+	expectParseErrorFlow(t, "enum Foo of string { A, B }", "<stdin>: ERROR: Flow enums are not supported\n")
+	expectParseErrorFlow(t, "export enum Foo { A, B }", "<stdin>: ERROR: Flow enums are not supported\n")
+}
@@ -53,6 +53,7 @@ type parser struct {
 	allocatedNames             []string
 	latestArrowArgLoc          logger.Loc
 	forbidSuffixAfterAsLoc     logger.Loc
+	conditionalYesLoc          logger.Loc
 	currentScope               *js_ast.Scope
 	scopesForCurrentPart       []*js_ast.Scope
 	symbols                    []js_ast.Symbol
@@ -67,6 +68,7 @@ type parser struct {
 	injectedDefineSymbols      []js_ast.Ref
 	injectedSymbolSources      map[js_ast.Ref]injectedSymbolSource
 	symbolUses                 map[js_ast.Ref]js_ast.SymbolUse
+	importSymbolPropertyUses   map[js_ast.Ref]map[string]js_ast.SymbolUse
 	declaredSymbols            []js_ast.DeclaredSymbol
 	runtimeImports             map[string]js_ast.Ref
 	duplicateCaseChecker       duplicateCaseChecker
@@ -76,6 +78,16 @@ type parser struct {
 	// For strict mode handling
 	hoistedRefForSloppyModeBlockFn map[js_ast.Ref]js_ast.Ref
 
+	// For lowering "let" and "const" to "var". The other maps track accesses
+	// that may happen before initialization but that can't be checked:
+	// calls to hoisted functions before a binding is initialized, the function
+	// currently being visited if it was called that way, and the case that is
+	// currently being visited in each switch statement.
+	loweredLexicalBindings     map[js_ast.Ref]*loweredLexicalBinding
+	loweredEarlyCalls          map[js_ast.Ref]map[*loweredLexicalBinding]bool
+	loweredEarlyCalledBindings map[*loweredLexicalBinding]bool
+	loweredSwitchCases         map[*js_ast.Scope]int
+
 	// For lowering private methods
 	weakMapRef     js_ast.Ref
 	weakSetRef     js_ast.Ref
@@ -107,6 +119,21 @@ type parser struct {
 	emittedNamespaceVars       map[js_ast.Ref]bool
 	isExportedInsideNamespace  map[js_ast.Ref]js_ast.Ref
 	localTypeNames             map[string]bool
+	localAmbientConstEnumNames map[string]bool
+
+	// The constant values of top-level enums are remembered when bundling so
+	// that the linker can inline them into other files that import the enum
+	tsEnums map[js_ast.Ref]map[string]js_ast.TSEnumValue
+
+	// The "emitDecoratorMetadata" setting needs to know whether an enum type is
+	// numeric or string-valued. This is computed from the enum members when the
+	// enum is parsed, since decorated classes may come before the enum.
+	tsEnumMetadataKinds map[js_ast.Ref]js_ast.TSMetadataKind
+
+	// Const enums are erased unless something uses the enum object itself.
+	// That can't be known until the enclosing scope has been fully visited, so
+	// the generated code for each candidate is remembered here until then.
+	erasableConstEnums []erasableConstEnum
 
 	// This is the reference to the generated function argument for the namespace,
 	// which is different than the reference to the namespace itself:
@@ -168,6 +195,11 @@ type parser struct {
 	moduleScope       *js_ast.Scope
 	isControlFlowDead bool
 
+	// When classes are lowered to ES5 constructor functions, this is set by
+	// "visitClass" right before visiting the function of a class member and is
+	// then consumed by "visitFn" for that function
+	nextLoweredClassMember *loweredClassMember
+
 	// Inside a TypeScript namespace, an "export declare" statement can be used
 	// to cause a namespace to be emitted even though it has no other observable
 	// effect. This flag is used to implement this feature.
@@ -344,21 +376,25 @@ type optionsThatSupportStructuralEquality struct {
 	originalTargetEnv     string
 
 	// Byte-sized values go here (gathered together here to keep this object compact)
-	ts                      config.TSOptions
-	mode                    config.Mode
-	platform                config.Platform
-	outputFormat            config.Format
-	moduleType              config.ModuleType
-	targetFromAPI           config.TargetFromAPI
-	asciiOnly               bool
-	keepNames               bool
-	mangleSyntax            bool
-	minifyIdentifiers       bool
-	omitRuntimeForTests     bool
-	ignoreDCEAnnotations    bool
-	treeShaking             bool
-	unusedImportsTS         config.UnusedImportsTS
-	useDefineForClassFields config.MaybeBool
+	ts                       config.TSOptions
+	mode                     config.Mode
+	platform                 config.Platform
+	outputFormat             config.Format
+	moduleType               config.ModuleType
+	targetFromAPI            config.TargetFromAPI
+	asciiOnly                bool
+	assumeIterablesAreArrays bool
+	keepNames                bool
+	mangleSyntax             bool
+	minifyIdentifiers        bool
+	omitRuntimeForTests      bool
+	ignoreDCEAnnotations     bool
+	treeShaking              bool
+	unusedImportsTS          config.UnusedImportsTS
+	isolatedModules          bool
+	useDefineForClassFields  config.MaybeBool
+	experimentalDecorators   config.MaybeBool
+	emitDecoratorMetadata    config.MaybeBool
 }
 
 func OptionsFromConfig(options *config.Options) Options {
@@ -368,23 +404,27 @@ func OptionsFromConfig(options *config.Options) Options {
 		defines:       options.Defines,
 		tsTarget:      options.TSTarget,
 		optionsThatSupportStructuralEquality: optionsThatSupportStructuralEquality{
-			unsupportedJSFeatures:   options.UnsupportedJSFeatures,
-			originalTargetEnv:       options.OriginalTargetEnv,
-			ts:                      options.TS,
-			mode:                    options.Mode,
-			platform:                options.Platform,
-			outputFormat:            options.OutputFormat,
-			moduleType:              options.ModuleType,
-			targetFromAPI:           options.TargetFromAPI,
-			asciiOnly:               options.ASCIIOnly,
-			keepNames:               options.KeepNames,
-			mangleSyntax:            options.MangleSyntax,
-			minifyIdentifiers:       options.MinifyIdentifiers,
-			omitRuntimeForTests:     options.OmitRuntimeForTests,
-			ignoreDCEAnnotations:    options.IgnoreDCEAnnotations,
-			treeShaking:             options.TreeShaking,
-			unusedImportsTS:         options.UnusedImportsTS,
-			useDefineForClassFields: options.UseDefineForClassFields,
+			unsupportedJSFeatures:    options.UnsupportedJSFeatures,
+			originalTargetEnv:        options.OriginalTargetEnv,
+			ts:                       options.TS,
+			mode:                     options.Mode,
+			platform:                 options.Platform,
+			outputFormat:             options.OutputFormat,
+			moduleType:               options.ModuleType,
+			targetFromAPI:            options.TargetFromAPI,
+			asciiOnly:                options.ASCIIOnly,
+			assumeIterablesAreArrays: options.AssumeIterablesAreArrays,
+			keepNames:                options.KeepNames,
+			mangleSyntax:             options.MangleSyntax,
+			minifyIdentifiers:        options.MinifyIdentifiers,
+			omitRuntimeForTests:      options.OmitRuntimeForTests,
+			ignoreDCEAnnotations:     options.IgnoreDCEAnnotations,
+			treeShaking:              options.TreeShaking,
+			unusedImportsTS:          options.UnusedImportsTS,
+			isolatedModules:          options.IsolatedModules,
+			useDefineForClassFields:  options.UseDefineForClassFields,
+			experimentalDecorators:   options.ExperimentalDecorators,
+			emitDecoratorMetadata:    options.EmitDecoratorMetadata,
 		},
 	}
 }
@@ -523,6 +563,9 @@ type fnOrArrowDataVisit struct {
 	isOutsideFnOrArrow bool
 	shouldLowerSuper   bool
 
+	// This is the innermost loop when lowering "let" and "const" to "var"
+	loweredLoop *loweredLoop
+
 	// This is used to silence unresolvable imports due to "require" calls inside
 	// a try/catch statement. The assumption is that the try/catch statement is
 	// there to handle the case where the reference to "require" crashes.
@@ -534,6 +577,27 @@ type superHelpers struct {
 	setRef js_ast.Ref
 }
 
+// This is information about the class member being visited when classes are
+// lowered to ES5 constructor functions. Class members are no longer inside a
+// class body after lowering, so "super" must be lowered within them.
+type loweredClassMember struct {
+	// This is the class itself, as seen from inside the class body. Lowered
+	// "super" property accesses start the lookup from the prototype of this
+	// (or from the prototype of "classRef.prototype" for instance members).
+	classRef js_ast.Ref
+
+	// This is the base class constructor, which is passed to the function that
+	// wraps the lowered class. It's only valid if the class has "extends".
+	superCtorRef js_ast.Ref
+
+	// Constructors of derived classes (and instance field initializers, which
+	// are moved into the constructor) use the object returned by the lowered
+	// "super()" call as "this". This is only valid in those places.
+	ctorThisRef js_ast.Ref
+
+	isStatic bool
+}
+
 // This is function-specific information used during visiting. It is saved and
 // restored on the call stack around code that parses nested functions (but not
 // nested arrow functions).
@@ -559,12 +623,22 @@ type fnOnlyDataVisit struct {
 	// replaced with the class name.
 	thisClassStaticRef *js_ast.Ref
 
+	// This is non-nil inside class members if classes are being lowered to ES5
+	// constructor functions. It's used to lower "super" and "this".
+	loweredClassMember *loweredClassMember
+
 	// If we're inside an async arrow function and async functions are not
 	// supported, then we will have to convert that arrow function to a generator
 	// function. That means references to "arguments" inside the arrow function
 	// will have to reference a captured variable instead of the real variable.
 	isInsideAsyncArrowFn bool
 
+	// If we're inside a generator function and generator functions are not
+	// supported, then the function body will be moved into a nested function
+	// for the state machine. That means references to "arguments" will have to
+	// reference a captured variable instead of the real variable.
+	isInsideLoweredGenerator bool
+
 	// If false, disallow "new.target" expressions. We disallow all "new.target"
 	// expressions at the top-level of the file (i.e. not inside a function or
 	// a class field). Technically since CommonJS files are wrapped in a function
@@ -583,6 +657,10 @@ type fnOnlyDataVisit struct {
 	// or a class declaration). That means the top-level module scope "this" value
 	// has been shadowed and is now inaccessible.
 	isThisNested bool
+
+	// This is the innermost loop when lowering "let" and "const" to "var",
+	// which may be outside of the current arrow function
+	loweredLoop *loweredLoop
 }
 
 const bloomFilterSize = 251
@@ -1164,11 +1242,23 @@ func hasValueForThisInCall(expr js_ast.Expr) bool {
 }
 
 func (p *parser) selectLocalKind(kind js_ast.LocalKind) js_ast.LocalKind {
+	// Resources in "using" declarations are disposed of at the end of the block
+	if kind.IsUsing() {
+		return kind
+	}
+
 	// Safari workaround: Automatically avoid TDZ issues when bundling
 	if p.options.mode == config.ModeBundle && p.currentScope.Parent == nil {
 		return js_ast.LocalVar
 	}
 
+	// Lower "let" and "const" to "var" for older language environments. Block
+	// scoping is emulated by renaming, so this is safe to do everywhere.
+	if (kind == js_ast.LocalLet && p.options.unsupportedJSFeatures.Has(compat.Let)) ||
+		(kind == js_ast.LocalConst && p.options.unsupportedJSFeatures.Has(compat.Const)) {
+		return js_ast.LocalVar
+	}
+
 	// Optimization: use "let" instead of "const" because it's shorter. This is
 	// only done when bundling because assigning to "const" is only an error when
 	// bundling.
@@ -1514,6 +1604,8 @@ func (p *parser) declareSymbol(kind js_ast.SymbolKind, loc logger.Loc, name stri
 
 func (p *parser) hoistSymbols(scope *js_ast.Scope) {
 	if !scope.Kind.StopsHoisting() {
+		p.hoistLoweredLexicalBindings(scope)
+
 	nextMember:
 		for _, member := range scope.Members {
 			symbol := &p.symbols[member.Ref.InnerIndex]
@@ -1713,6 +1805,44 @@ func (p *parser) ignoreUsage(ref js_ast.Ref) {
 	// the value is ignored because that's what the TypeScript compiler does.
 }
 
+// Property accesses off of imported symbols are counted separately when
+// bundling. The import may turn out to be a TypeScript enum in another file,
+// in which case the linker can inline the property value and the part that
+// contains this property access no longer depends on the enum declaration.
+// This returns true if the property access was counted separately.
+func (p *parser) recordImportSymbolPropertyUse(target js_ast.Expr, name string) bool {
+	id, ok := target.Data.(*js_ast.EImportIdentifier)
+	if !ok || p.options.mode != config.ModeBundle {
+		return false
+	}
+
+	// Move the use from "symbolUses" to "importSymbolPropertyUses". This
+	// intentionally doesn't change the use count estimate on the symbol itself
+	// since the import statement must not be removed.
+	if !p.isControlFlowDead {
+		use := p.symbolUses[id.Ref]
+		use.CountEstimate--
+		if use.CountEstimate == 0 {
+			delete(p.symbolUses, id.Ref)
+		} else {
+			p.symbolUses[id.Ref] = use
+		}
+
+		if p.importSymbolPropertyUses == nil {
+			p.importSymbolPropertyUses = make(map[js_ast.Ref]map[string]js_ast.SymbolUse)
+		}
+		properties := p.importSymbolPropertyUses[id.Ref]
+		if properties == nil {
+			properties = make(map[string]js_ast.SymbolUse)
+			p.importSymbolPropertyUses[id.Ref] = properties
+		}
+		use = properties[name]
+		use.CountEstimate++
+		properties[name] = use
+	}
+	return true
+}
+
 func (p *parser) ignoreUsageOfIdentifierInDotChain(expr js_ast.Expr) {
 	for {
 		switch e := expr.Data.(type) {
@@ -1935,12 +2065,12 @@ type propertyOpts struct {
 	isGenerator    bool
 
 	// Class-related options
-	isStatic          bool
-	isTSAbstract      bool
-	isClass           bool
-	classHasExtends   bool
-	allowTSDecorators bool
-	tsDecorators      []js_ast.Expr
+	isStatic        bool
+	isTSAbstract    bool
+	isClass         bool
+	classHasExtends bool
+	allowDecorators bool
+	decorators      []js_ast.Expr
 }
 
 func (p *parser) parseProperty(kind js_ast.PropertyKind, opts propertyOpts, errors *deferredErrors) (js_ast.Property, bool) {
@@ -1949,6 +2079,12 @@ func (p *parser) parseProperty(kind js_ast.PropertyKind, opts propertyOpts, erro
 	isComputed := false
 	preferQuotedKey := false
 
+	// "class Foo { +x: number; -y: string }" is Flow's variance syntax
+	if p.options.ts.Flow && opts.isClass && (p.lexer.Token == js_lexer.TPlus || p.lexer.Token == js_lexer.TMinus) {
+		p.lexer.Next()
+		keyRange = p.lexer.Range()
+	}
+
 	switch p.lexer.Token {
 	case js_lexer.TNumericLiteral:
 		key = js_ast.Expr{Loc: p.lexer.Loc(), Data: &js_ast.ENumber{Value: p.lexer.Number}}
@@ -1965,7 +2101,7 @@ func (p *parser) parseProperty(kind js_ast.PropertyKind, opts propertyOpts, erro
 		p.lexer.Next()
 
 	case js_lexer.TPrivateIdentifier:
-		if !opts.isClass || len(opts.tsDecorators) > 0 {
+		if !opts.isClass || (len(opts.decorators) > 0 && p.isUsingLegacyDecorators()) {
 			p.lexer.Expected(js_lexer.TIdentifier)
 		}
 		if opts.tsDeclareRange.Len != 0 {
@@ -1976,7 +2112,9 @@ func (p *parser) parseProperty(kind js_ast.PropertyKind, opts propertyOpts, erro
 
 	case js_lexer.TOpenBracket:
 		isComputed = true
-		p.markSyntaxFeature(compat.ObjectExtensions, p.lexer.Range())
+		if !opts.isClass {
+			p.markSyntaxFeature(compat.ObjectExtensions, p.lexer.Range())
+		}
 		p.lexer.Next()
 		wasIdentifier := p.lexer.Token == js_lexer.TIdentifier
 		expr := p.parseExpr(js_ast.LComma)
@@ -2030,6 +2168,10 @@ func (p *parser) parseProperty(kind js_ast.PropertyKind, opts propertyOpts, erro
 				case js_lexer.TOpenBracket, js_lexer.TNumericLiteral, js_lexer.TStringLiteral,
 					js_lexer.TAsterisk, js_lexer.TPrivateIdentifier:
 					couldBeModifierKeyword = true
+
+				case js_lexer.TPlus, js_lexer.TMinus:
+					// "static +x: number" in Flow
+					couldBeModifierKeyword = p.options.ts.Flow && opts.isClass
 				}
 			}
 
@@ -2052,7 +2194,6 @@ func (p *parser) parseProperty(kind js_ast.PropertyKind, opts propertyOpts, erro
 					if !opts.isAsync && raw == name && !p.lexer.HasNewlineBefore {
 						opts.isAsync = true
 						opts.asyncRange = nameRange
-						p.markLoweredSyntaxFeature(compat.AsyncAwait, nameRange, compat.Generator)
 						return p.parseProperty(kind, opts, nil)
 					}
 
@@ -2062,6 +2203,11 @@ func (p *parser) parseProperty(kind js_ast.PropertyKind, opts propertyOpts, erro
 						return p.parseProperty(kind, opts, nil)
 					}
 
+				case "accessor":
+					if !p.lexer.HasNewlineBefore && !opts.isAsync && opts.isClass && raw == name {
+						return p.parseProperty(js_ast.PropertyAutoAccessor, opts, nil)
+					}
+
 				case "declare":
 					if opts.isClass && p.options.ts.Parse && opts.tsDeclareRange.Len == 0 && raw == name {
 						opts.tsDeclareRange = nameRange
@@ -2169,12 +2315,12 @@ func (p *parser) parseProperty(kind js_ast.PropertyKind, opts propertyOpts, erro
 
 		// "class X { foo?<T>(): T }"
 		// "const x = { foo<T>(): T {} }"
-		p.skipTypeScriptTypeParameters()
+		p.skipTypeScriptTypeParameters(allowConstModifier)
 	}
 
 	// Parse a class field with an optional initial value
-	if opts.isClass && kind == js_ast.PropertyNormal && !opts.isAsync &&
-		!opts.isGenerator && p.lexer.Token != js_lexer.TOpenParen {
+	if opts.isClass && ((kind == js_ast.PropertyNormal && !opts.isAsync &&
+		!opts.isGenerator && p.lexer.Token != js_lexer.TOpenParen) || kind == js_ast.PropertyAutoAccessor) {
 		var initializerOrNil js_ast.Expr
 
 		// Forbid the names "constructor" and "prototype" in some cases
@@ -2186,9 +2332,10 @@ func (p *parser) parseProperty(kind js_ast.PropertyKind, opts propertyOpts, erro
 		}
 
 		// Skip over types
+		var tsMetadata js_ast.TSMetadata
 		if p.options.ts.Parse && p.lexer.Token == js_lexer.TColon {
 			p.lexer.Next()
-			p.skipTypeScriptType(js_ast.LLowest)
+			tsMetadata = p.skipTypeScriptType(js_ast.LLowest)
 		}
 
 		if p.lexer.Token == js_lexer.TEquals {
@@ -2216,24 +2363,37 @@ func (p *parser) parseProperty(kind js_ast.PropertyKind, opts propertyOpts, erro
 			if name == "#constructor" {
 				p.log.Add(logger.Error, &p.tracker, keyRange, fmt.Sprintf("Invalid field name %q", name))
 			}
-			var declare js_ast.SymbolKind
-			if opts.isStatic {
-				declare = js_ast.SymbolPrivateStaticField
+			if kind == js_ast.PropertyAutoAccessor {
+				// An auto-accessor behaves like a private getter and setter pair
+				getKind, setKind := js_ast.SymbolPrivateGet, js_ast.SymbolPrivateSet
+				if opts.isStatic {
+					getKind, setKind = js_ast.SymbolPrivateStaticGet, js_ast.SymbolPrivateStaticSet
+				}
+				private.Ref = p.declareSymbol(getKind, key.Loc, name)
+				p.declareSymbol(setKind, key.Loc, name)
+				p.privateGetters[private.Ref] = p.newSymbol(js_ast.SymbolOther, name[1:]+"_get")
+				p.privateSetters[private.Ref] = p.newSymbol(js_ast.SymbolOther, name[1:]+"_set")
 			} else {
-				declare = js_ast.SymbolPrivateField
+				var declare js_ast.SymbolKind
+				if opts.isStatic {
+					declare = js_ast.SymbolPrivateStaticField
+				} else {
+					declare = js_ast.SymbolPrivateField
+				}
+				private.Ref = p.declareSymbol(declare, key.Loc, name)
 			}
-			private.Ref = p.declareSymbol(declare, key.Loc, name)
 		}
 
 		p.lexer.ExpectOrInsertSemicolon()
 		return js_ast.Property{
-			TSDecorators:     opts.tsDecorators,
+			Decorators:       opts.decorators,
 			Kind:             kind,
 			IsComputed:       isComputed,
 			PreferQuotedKey:  preferQuotedKey,
 			IsStatic:         opts.isStatic,
 			Key:              key,
 			InitializerOrNil: initializerOrNil,
+			TSMetadata:       tsMetadata,
 		}, true
 	}
 
@@ -2250,7 +2410,7 @@ func (p *parser) parseProperty(kind js_ast.PropertyKind, opts propertyOpts, erro
 			p.log.Add(logger.Error, &p.tracker, opts.tsDeclareRange, "\"declare\" cannot be used with a "+what)
 		}
 
-		if p.lexer.Token == js_lexer.TOpenParen && kind != js_ast.PropertyGet && kind != js_ast.PropertySet {
+		if p.lexer.Token == js_lexer.TOpenParen && kind != js_ast.PropertyGet && kind != js_ast.PropertySet && !opts.isClass {
 			p.markSyntaxFeature(compat.ObjectExtensions, p.lexer.Range())
 		}
 		loc := p.lexer.Loc()
@@ -2295,7 +2455,7 @@ func (p *parser) parseProperty(kind js_ast.PropertyKind, opts propertyOpts, erro
 			yield:              yield,
 			allowSuperCall:     opts.classHasExtends && isConstructor,
 			allowSuperProperty: true,
-			allowTSDecorators:  opts.allowTSDecorators,
+			allowTSDecorators:  opts.allowDecorators && p.isUsingLegacyDecorators(),
 			isConstructor:      isConstructor,
 
 			// Only allow omitting the body if we're parsing TypeScript class
@@ -2372,7 +2532,7 @@ func (p *parser) parseProperty(kind js_ast.PropertyKind, opts propertyOpts, erro
 		}
 
 		return js_ast.Property{
-			TSDecorators:    opts.tsDecorators,
+			Decorators:      opts.decorators,
 			Kind:            kind,
 			IsComputed:      isComputed,
 			PreferQuotedKey: preferQuotedKey,
@@ -2580,7 +2740,6 @@ func (p *parser) parseAsyncPrefixExpr(asyncRange logger.Range, level js_ast.L, f
 				}
 
 				if isArrowFn {
-					p.markLoweredSyntaxFeature(compat.AsyncAwait, asyncRange, compat.Generator)
 					ref := p.storeNameInRef(p.lexer.Identifier)
 					arg := js_ast.Arg{Binding: js_ast.Binding{Loc: p.lexer.Loc(), Data: &js_ast.BIdentifier{Ref: ref}}}
 					p.lexer.Next()
@@ -2622,10 +2781,7 @@ func (p *parser) parseFnExpr(loc logger.Loc, isAsync bool, asyncRange logger.Ran
 	p.lexer.Next()
 	isGenerator := p.lexer.Token == js_lexer.TAsterisk
 	if isGenerator {
-		p.markSyntaxFeature(compat.Generator, p.lexer.Range())
 		p.lexer.Next()
-	} else if isAsync {
-		p.markLoweredSyntaxFeature(compat.AsyncAwait, asyncRange, compat.Generator)
 	}
 	var name *js_ast.LocRef
 
@@ -2646,7 +2802,7 @@ func (p *parser) parseFnExpr(loc logger.Loc, isAsync bool, asyncRange logger.Ran
 
 	// Even anonymous functions can have TypeScript type parameters
 	if p.options.ts.Parse {
-		p.skipTypeScriptTypeParameters()
+		p.skipTypeScriptTypeParameters(allowConstModifier)
 	}
 
 	await := allowIdent
@@ -2768,10 +2924,6 @@ func (p *parser) parseParenExpr(loc logger.Loc, level js_ast.L, opts parenExprOp
 		var invalidLog invalidLog
 		args := []js_ast.Arg{}
 
-		if opts.isAsync {
-			p.markLoweredSyntaxFeature(compat.AsyncAwait, opts.asyncRange, compat.Generator)
-		}
-
 		// First, try converting the expressions to bindings
 		for _, item := range items {
 			isSpread := false
@@ -2788,8 +2940,11 @@ func (p *parser) parseParenExpr(loc logger.Loc, level js_ast.L, opts parenExprOp
 		// function. The ":" after the ")" may be a return type annotation, so we
 		// attempt to convert the expressions to bindings first before deciding
 		// whether this is an arrow function, and only pick an arrow function if
-		// there were no conversion errors.
+		// there were no conversion errors. Code like "a ? (b) : c => d" is also
+		// ambiguous, so like TypeScript we only pick an arrow function there if
+		// it's followed by another ":".
 		if p.lexer.Token == js_lexer.TEqualsGreaterThan || (len(invalidLog.invalidTokens) == 0 &&
+			(loc != p.conditionalYesLoc || p.isTypeScriptArrowFnFollowedByColonWithBacktracking(opts.isAsync)) &&
 			p.trySkipTypeScriptArrowReturnTypeWithBacktracking()) || opts.forceArrowFn {
 			if commaAfterSpread.Start != 0 {
 				p.log.Add(logger.Error, &p.tracker, logger.Range{Loc: commaAfterSpread, Len: 1}, "Unexpected \",\" after rest pattern")
@@ -2831,8 +2986,9 @@ func (p *parser) parseParenExpr(loc logger.Loc, level js_ast.L, opts parenExprOp
 	// parent scope as if the scope was never pushed in the first place.
 	p.popAndFlattenScope(scopeIndex)
 
-	// If this isn't an arrow function, then types aren't allowed
-	if typeColonRange.Len > 0 {
+	// If this isn't an arrow function, then types aren't allowed (except for
+	// Flow type casts, which look like "(x: any)")
+	if typeColonRange.Len > 0 && (!p.options.ts.Flow || len(items) != 1 || opts.isAsync || spreadRange.Len > 0) {
 		p.log.Add(logger.Error, &p.tracker, typeColonRange, "Unexpected \":\"")
 		panic(js_lexer.LexerPanic{})
 	}
@@ -2916,7 +3072,6 @@ func (p *parser) convertExprToBinding(expr js_ast.Expr, invalidLog invalidLog) (
 		if e.IsParenthesized {
 			invalidLog.invalidTokens = append(invalidLog.invalidTokens, p.source.RangeOfOperatorBefore(expr.Loc, "("))
 		}
-		p.markSyntaxFeature(compat.Destructuring, p.source.RangeOfOperatorAfter(expr.Loc, "["))
 		items := []js_ast.ArrayBinding{}
 		isSpread := false
 		for _, item := range e.Items {
@@ -2944,7 +3099,6 @@ func (p *parser) convertExprToBinding(expr js_ast.Expr, invalidLog invalidLog) (
 		if e.IsParenthesized {
 			invalidLog.invalidTokens = append(invalidLog.invalidTokens, p.source.RangeOfOperatorBefore(expr.Loc, "("))
 		}
-		p.markSyntaxFeature(compat.Destructuring, p.source.RangeOfOperatorAfter(expr.Loc, "{"))
 		properties := []js_ast.PropertyBinding{}
 		for _, item := range e.Properties {
 			if item.IsMethod || item.Kind == js_ast.PropertyGet || item.Kind == js_ast.PropertySet {
@@ -3270,35 +3424,19 @@ func (p *parser) parsePrefix(level js_ast.L, errors *deferredErrors, flags exprF
 	case js_lexer.TFunction:
 		return p.parseFnExpr(loc, false /* isAsync */, logger.Range{})
 
-	case js_lexer.TClass:
-		classKeyword := p.lexer.Range()
-		p.markSyntaxFeature(compat.Class, classKeyword)
-		p.lexer.Next()
-		var name *js_ast.LocRef
-
-		p.pushScopeForParsePass(js_ast.ScopeClassName, loc)
-
-		// Parse an optional class name
-		if p.lexer.Token == js_lexer.TIdentifier {
-			if nameText := p.lexer.Identifier; !p.options.ts.Parse || nameText != "implements" {
-				if p.fnOrArrowDataParse.await != allowIdent && nameText == "await" {
-					p.log.Add(logger.Error, &p.tracker, p.lexer.Range(), "Cannot use \"await\" as an identifier here:")
-				}
-				name = &js_ast.LocRef{Loc: p.lexer.Loc(), Ref: p.newSymbol(js_ast.SymbolOther, nameText)}
-				p.lexer.Next()
-			}
+	case js_lexer.TAt:
+		// Standard decorators are also allowed before class expressions
+		if p.isUsingLegacyDecorators() {
+			p.lexer.Unexpected()
 		}
-
-		// Even anonymous classes can have TypeScript type parameters
-		if p.options.ts.Parse {
-			p.skipTypeScriptTypeParameters()
+		decorators := p.parseDecorators()
+		if p.lexer.Token != js_lexer.TClass {
+			p.lexer.Expected(js_lexer.TClass)
 		}
+		return p.parseClassExpr(loc, decorators)
 
-		class := p.parseClass(classKeyword, name, parseClassOpts{})
-
-		p.popScope()
-		return js_ast.Expr{Loc: loc, Data: &js_ast.EClass{Class: class}}
-
+	case js_lexer.TClass:
+		return p.parseClassExpr(loc, nil)
 	case js_lexer.TNew:
 		p.lexer.Next()
 
@@ -3493,8 +3631,8 @@ func (p *parser) parsePrefix(level js_ast.L, errors *deferredErrors, flags exprF
 		//     <A>(x) => {}
 		//     <A = B>(x) => {}
 
-		if p.options.ts.Parse && p.options.jsx.Parse && p.isTSArrowFnJSX() {
-			p.skipTypeScriptTypeParameters()
+		if p.options.ts.Parse && p.options.jsx.Parse && (p.isTSArrowFnJSX() || p.isFlowArrowFnJSX()) {
+			p.skipTypeScriptTypeParameters(allowConstModifier)
 			p.lexer.Expect(js_lexer.TOpenParen)
 			return p.parseParenExpr(loc, level, parenExprOpts{forceArrowFn: true})
 		}
@@ -3963,6 +4101,9 @@ func (p *parser) parseSuffix(left js_ast.Expr, level js_ast.L, errors *deferredE
 			oldAllowIn := p.allowIn
 			p.allowIn = true
 
+			// Remember where the "yes" branch starts for "a ? (b) : c => d"
+			p.conditionalYesLoc = p.lexer.Loc()
+
 			yes := p.parseExpr(js_ast.LComma)
 
 			p.allowIn = oldAllowIn
@@ -3972,7 +4113,7 @@ func (p *parser) parseSuffix(left js_ast.Expr, level js_ast.L, errors *deferredE
 			left = js_ast.Expr{Loc: left.Loc, Data: &js_ast.EIf{Test: left, Yes: yes, No: no}}
 
 		case js_lexer.TExclamation:
-			// Skip over TypeScript non-null assertions
+			// Skip over TypeScript non-null assertOrWith
 			if p.lexer.HasNewlineBefore {
 				return left
 			}
@@ -4361,8 +4502,9 @@ func (p *parser) parseSuffix(left js_ast.Expr, level js_ast.L, errors *deferredE
 			left = js_ast.Expr{Loc: left.Loc, Data: &js_ast.EBinary{Op: js_ast.BinOpInstanceof, Left: left, Right: p.parseExpr(js_ast.LCompare)}}
 
 		default:
-			// Handle the TypeScript "as" operator
-			if p.options.ts.Parse && level < js_ast.LCompare && !p.lexer.HasNewlineBefore && p.lexer.IsContextualKeyword("as") {
+			// Handle the TypeScript "as" and "satisfies" operators
+			if p.options.ts.Parse && level < js_ast.LCompare && !p.lexer.HasNewlineBefore &&
+				(p.lexer.IsContextualKeyword("as") || p.lexer.IsContextualKeyword("satisfies")) {
 				p.lexer.Next()
 				p.skipTypeScriptType(js_ast.LLowest)
 
@@ -4391,10 +4533,41 @@ func (p *parser) parseSuffix(left js_ast.Expr, level js_ast.L, errors *deferredE
 	}
 }
 
-func (p *parser) parseExprOrLetStmt(opts parseStmtOpts) (js_ast.Expr, js_ast.Stmt, []js_ast.Decl) {
+func (p *parser) parseExprOrLetOrUsingStmt(opts parseStmtOpts) (js_ast.Expr, js_ast.Stmt, []js_ast.Decl) {
 	letRange := p.lexer.Range()
 	raw := p.lexer.Raw()
 
+	// "using x = y"
+	// "await using x = y"
+	if kind, ok := p.isUsingDeclaration(raw, opts); ok {
+		if kind == js_ast.LocalAwaitUsing {
+			if p.fnOrArrowDataParse.isTopLevel {
+				p.topLevelAwaitKeyword = letRange
+				p.markSyntaxFeature(compat.TopLevelAwait, letRange)
+			}
+			p.lexer.Next()
+		}
+		p.lexer.Next()
+		if opts.lexicalDecl != lexicalDeclAllowAll {
+			p.forbidLexicalDecl(letRange.Loc)
+		}
+		decls := p.parseAndDeclareDecls(js_ast.SymbolConst, opts)
+		for _, decl := range decls {
+			if _, ok := decl.Binding.Data.(*js_ast.BIdentifier); !ok {
+				p.log.Add(logger.Error, &p.tracker, logger.Range{Loc: decl.Binding.Loc},
+					fmt.Sprintf("%q declarations cannot use destructuring", kind.String()))
+			}
+		}
+		if !opts.isForLoopInit {
+			p.requireInitializers(kind, decls)
+		}
+		return js_ast.Expr{}, js_ast.Stmt{Loc: letRange.Loc, Data: &js_ast.SLocal{
+			Kind:     kind,
+			Decls:    decls,
+			IsExport: opts.isExport,
+		}}, decls
+	}
+
 	if p.lexer.Token != js_lexer.TIdentifier || raw != "let" {
 		var flags exprFlag
 		if opts.isForLoopInit {
@@ -4414,7 +4587,6 @@ func (p *parser) parseExprOrLetStmt(opts parseStmtOpts) (js_ast.Expr, js_ast.Stm
 			if opts.lexicalDecl != lexicalDeclAllowAll {
 				p.forbidLexicalDecl(letRange.Loc)
 			}
-			p.markSyntaxFeature(compat.Let, letRange)
 			decls := p.parseAndDeclareDecls(js_ast.SymbolOther, opts)
 			return js_ast.Expr{}, js_ast.Stmt{Loc: letRange.Loc, Data: &js_ast.SLocal{
 				Kind:     js_ast.LocalLet,
@@ -4429,6 +4601,54 @@ func (p *parser) parseExprOrLetStmt(opts parseStmtOpts) (js_ast.Expr, js_ast.Stm
 	return p.parseSuffix(expr, js_ast.LLowest, nil, 0), js_ast.Stmt{}, nil
 }
 
+// Returns true if the current token starts a "using" or "await using"
+// declaration. The identifier "using" only starts a declaration when it's
+// followed by a binding identifier on the same line. Otherwise it's an
+// expression such as "using[0] = x" or the loop variable in "for (using of y)".
+func (p *parser) isUsingDeclaration(raw string, opts parseStmtOpts) (kind js_ast.LocalKind, isUsing bool) {
+	if p.lexer.Token != js_lexer.TIdentifier || (raw != "using" && raw != "await") {
+		return
+	}
+	if raw == "await" && p.fnOrArrowDataParse.await != allowExpr {
+		return
+	}
+
+	oldLexer := p.lexer
+	p.lexer.IsLogDisabled = true
+
+	// Restore the lexer's memory to its original state when done, even if a
+	// syntax error was encountered while looking ahead
+	defer func() {
+		r := recover()
+		if _, isLexerPanic := r.(js_lexer.LexerPanic); !isLexerPanic && r != nil {
+			panic(r)
+		}
+		p.lexer = oldLexer
+	}()
+
+	kind = js_ast.LocalUsing
+	if raw == "await" {
+		p.lexer.Next()
+		if p.lexer.HasNewlineBefore || p.lexer.Raw() != "using" {
+			return
+		}
+		kind = js_ast.LocalAwaitUsing
+	}
+
+	p.lexer.Next()
+	if p.lexer.Token != js_lexer.TIdentifier || p.lexer.HasNewlineBefore {
+		return
+	}
+
+	// "for (using of y)" assigns to a variable called "using"
+	if opts.isForLoopInit && p.lexer.Raw() == "of" {
+		return
+	}
+
+	isUsing = true
+	return
+}
+
 func (p *parser) parseCallArgs() []js_ast.Expr {
 	// Allow "in" inside call arguments
 	oldAllowIn := p.allowIn
@@ -4763,6 +4983,9 @@ func (p *parser) parseAndDeclareDecls(kind js_ast.SymbolKind, opts parseStmtOpts
 		var valueOrNil js_ast.Expr
 		local := p.parseBinding()
 		p.declareBinding(kind, local, opts)
+		if !opts.isTypeScriptDeclare {
+			p.recordLoweredLexicalBindings(kind, local)
+		}
 
 		// Skip over types
 		if p.options.ts.Parse {
@@ -4795,15 +5018,19 @@ func (p *parser) parseAndDeclareDecls(kind js_ast.SymbolKind, opts parseStmtOpts
 	return decls
 }
 
-func (p *parser) requireInitializers(decls []js_ast.Decl) {
+func (p *parser) requireInitializers(kind js_ast.LocalKind, decls []js_ast.Decl) {
+	what := "constant"
+	if kind.IsUsing() {
+		what = "declaration"
+	}
 	for _, d := range decls {
 		if d.ValueOrNil.Data == nil {
 			if id, ok := d.Binding.Data.(*js_ast.BIdentifier); ok {
 				r := js_lexer.RangeOfIdentifier(p.source, d.Binding.Loc)
-				p.log.Add(logger.Error, &p.tracker, r, fmt.Sprintf("The constant %q must be initialized",
-					p.symbols[id.Ref.InnerIndex].OriginalName))
+				p.log.Add(logger.Error, &p.tracker, r, fmt.Sprintf("The %s %q must be initialized",
+					what, p.symbols[id.Ref.InnerIndex].OriginalName))
 			} else {
-				p.log.Add(logger.Error, &p.tracker, logger.Range{Loc: d.Binding.Loc}, "This constant must be initialized")
+				p.log.Add(logger.Error, &p.tracker, logger.Range{Loc: d.Binding.Loc}, fmt.Sprintf("This %s must be initialized", what))
 			}
 		}
 	}
@@ -4871,7 +5098,9 @@ func (p *parser) parseImportClause() ([]js_ast.ClauseItem, bool) {
 		// "import { type as } from 'mod'"
 		// "import { type as as } from 'mod'"
 		// "import { type as as as } from 'mod'"
-		if p.options.ts.Parse && alias == "type" && p.lexer.Token != js_lexer.TComma && p.lexer.Token != js_lexer.TCloseBrace {
+		// "import { typeof xx } from 'mod'"
+		if p.options.ts.Parse && (alias == "type" || (p.options.ts.Flow && alias == "typeof")) &&
+			p.lexer.Token != js_lexer.TComma && p.lexer.Token != js_lexer.TCloseBrace {
 			if p.lexer.IsContextualKeyword("as") {
 				p.lexer.Next()
 				if p.lexer.IsContextualKeyword("as") {
@@ -5127,7 +5356,6 @@ func (p *parser) parseBinding() js_ast.Binding {
 		return js_ast.Binding{Loc: loc, Data: &js_ast.BIdentifier{Ref: ref}}
 
 	case js_lexer.TOpenBracket:
-		p.markSyntaxFeature(compat.Destructuring, p.lexer.Range())
 		p.lexer.Next()
 		isSingleLine := !p.lexer.HasNewlineBefore
 		items := []js_ast.ArrayBinding{}
@@ -5194,7 +5422,6 @@ func (p *parser) parseBinding() js_ast.Binding {
 		}}
 
 	case js_lexer.TOpenBrace:
-		p.markSyntaxFeature(compat.Destructuring, p.lexer.Range())
 		p.lexer.Next()
 		isSingleLine := !p.lexer.HasNewlineBefore
 		properties := []js_ast.PropertyBinding{}
@@ -5242,10 +5469,6 @@ func (p *parser) parseBinding() js_ast.Binding {
 }
 
 func (p *parser) parseFn(name *js_ast.LocRef, data fnOrArrowDataParse) (fn js_ast.Fn, hadBody bool) {
-	if data.await == allowExpr && data.yield == allowExpr {
-		p.markSyntaxFeature(compat.AsyncGenerator, data.asyncRange)
-	}
-
 	fn.Name = name
 	fn.HasRestArg = false
 	fn.IsAsync = data.await == allowExpr
@@ -5288,7 +5511,7 @@ func (p *parser) parseFn(name *js_ast.LocRef, data fnOrArrowDataParse) (fn js_as
 
 		var tsDecorators []js_ast.Expr
 		if data.allowTSDecorators {
-			tsDecorators = p.parseTypeScriptDecorators()
+			tsDecorators = p.parseDecorators()
 		}
 
 		if !fn.HasRestArg && p.lexer.Token == js_lexer.TDotDotDot {
@@ -5297,6 +5520,7 @@ func (p *parser) parseFn(name *js_ast.LocRef, data fnOrArrowDataParse) (fn js_as
 			fn.HasRestArg = true
 		}
 
+		var tsMetadata js_ast.TSMetadata
 		isTypeScriptCtorField := false
 		isIdentifier := p.lexer.Token == js_lexer.TIdentifier
 		text := p.lexer.Identifier
@@ -5332,7 +5556,7 @@ func (p *parser) parseFn(name *js_ast.LocRef, data fnOrArrowDataParse) (fn js_as
 			// "function foo(a: any) {}"
 			if p.lexer.Token == js_lexer.TColon {
 				p.lexer.Next()
-				p.skipTypeScriptType(js_ast.LLowest)
+				tsMetadata = p.skipTypeScriptType(js_ast.LLowest)
 			}
 		}
 
@@ -5347,6 +5571,7 @@ func (p *parser) parseFn(name *js_ast.LocRef, data fnOrArrowDataParse) (fn js_as
 
 		fn.Args = append(fn.Args, js_ast.Arg{
 			TSDecorators: tsDecorators,
+			TSMetadata:   tsMetadata,
 			Binding:      arg,
 			DefaultOrNil: defaultValueOrNil,
 
@@ -5385,7 +5610,7 @@ func (p *parser) parseFn(name *js_ast.LocRef, data fnOrArrowDataParse) (fn js_as
 	// "function foo(): any {}"
 	if p.options.ts.Parse && p.lexer.Token == js_lexer.TColon {
 		p.lexer.Next()
-		p.skipTypeScriptReturnType()
+		fn.ReturnTSMetadata = p.skipTypeScriptReturnType()
 	}
 
 	// "function foo(): any;"
@@ -5427,11 +5652,42 @@ func (p *parser) validateDeclaredSymbolName(loc logger.Loc, name string) {
 	}
 }
 
+func (p *parser) parseClassExpr(loc logger.Loc, decorators []js_ast.Expr) js_ast.Expr {
+	classKeyword := p.lexer.Range()
+	p.lexer.Next()
+	var name *js_ast.LocRef
+
+	p.pushScopeForParsePass(js_ast.ScopeClassName, loc)
+
+	// Parse an optional class name
+	if p.lexer.Token == js_lexer.TIdentifier {
+		if nameText := p.lexer.Identifier; !p.options.ts.Parse || nameText != "implements" {
+			if p.fnOrArrowDataParse.await != allowIdent && nameText == "await" {
+				p.log.Add(logger.Error, &p.tracker, p.lexer.Range(), "Cannot use \"await\" as an identifier here:")
+			}
+			name = &js_ast.LocRef{Loc: p.lexer.Loc(), Ref: p.newSymbol(js_ast.SymbolOther, nameText)}
+			p.lexer.Next()
+		}
+	}
+
+	// Even anonymous classes can have TypeScript type parameters
+	if p.options.ts.Parse {
+		p.skipTypeScriptTypeParameters(allowInOutVarianceAnnotations | allowConstModifier)
+	}
+
+	class := p.parseClass(classKeyword, name, parseClassOpts{
+		decorators:      decorators,
+		allowDecorators: !p.isUsingLegacyDecorators(),
+	})
+
+	p.popScope()
+	return js_ast.Expr{Loc: loc, Data: &js_ast.EClass{Class: class}}
+}
+
 func (p *parser) parseClassStmt(loc logger.Loc, opts parseStmtOpts) js_ast.Stmt {
 	var name *js_ast.LocRef
 	classKeyword := p.lexer.Range()
 	if p.lexer.Token == js_lexer.TClass {
-		p.markSyntaxFeature(compat.Class, classKeyword)
 		p.lexer.Next()
 	} else {
 		p.lexer.Expected(js_lexer.TClass)
@@ -5452,15 +5708,15 @@ func (p *parser) parseClassStmt(loc logger.Loc, opts parseStmtOpts) js_ast.Stmt
 
 	// Even anonymous classes can have TypeScript type parameters
 	if p.options.ts.Parse {
-		p.skipTypeScriptTypeParameters()
+		p.skipTypeScriptTypeParameters(allowInOutVarianceAnnotations | allowConstModifier)
 	}
 
 	classOpts := parseClassOpts{
-		allowTSDecorators:   true,
+		allowDecorators:     true,
 		isTypeScriptDeclare: opts.isTypeScriptDeclare,
 	}
-	if opts.tsDecorators != nil {
-		classOpts.tsDecorators = opts.tsDecorators.values
+	if opts.decorators != nil {
+		classOpts.decorators = opts.decorators.values
 	}
 	scopeIndex := p.pushScopeForParsePass(js_ast.ScopeClassName, loc)
 	class := p.parseClass(classKeyword, name, classOpts)
@@ -5479,9 +5735,83 @@ func (p *parser) parseClassStmt(loc logger.Loc, opts parseStmtOpts) js_ast.Stmt
 	return js_ast.Stmt{Loc: loc, Data: &js_ast.SClass{Class: class, IsExport: opts.isExport}}
 }
 
+// TypeScript files use the legacy "experimentalDecorators" semantics unless
+// "tsconfig.json" explicitly turns them off. Everything else uses the standard
+// JavaScript decorator semantics.
+func (p *parser) isUsingLegacyDecorators() bool {
+	return p.options.ts.Parse && p.options.experimentalDecorators != config.False
+}
+
+func (p *parser) parseDecorators() []js_ast.Expr {
+	var decorators []js_ast.Expr
+	for p.lexer.Token == js_lexer.TAt {
+		p.lexer.Next()
+
+		if p.isUsingLegacyDecorators() {
+			// Parse a new/call expression with "exprFlagTSDecorator" so we ignore
+			// EIndex expressions, since they may be part of a computed property:
+			//
+			//   class Foo {
+			//     @foo ['computed']() {}
+			//   }
+			//
+			// This matches the behavior of the TypeScript compiler.
+			decorators = append(decorators, p.parseExprWithFlags(js_ast.LNew, exprFlagTSDecorator))
+		} else {
+			decorators = append(decorators, p.parseDecorator())
+		}
+	}
+	return decorators
+}
+
+// Standard decorators are restricted to a parenthesized expression such as
+// "@(expr)" or to a chain of property accesses such as "@foo.bar.#baz" that
+// may optionally be followed by a single call such as "@foo.bar(args)".
+func (p *parser) parseDecorator() js_ast.Expr {
+	if p.lexer.Token == js_lexer.TOpenParen {
+		p.lexer.Next()
+		value := p.parseExpr(js_ast.LLowest)
+		p.lexer.Expect(js_lexer.TCloseParen)
+		return value
+	}
+
+	name := p.lexer.Identifier
+	nameRange := p.lexer.Range()
+	p.lexer.Expect(js_lexer.TIdentifier)
+	if (p.fnOrArrowDataParse.await != allowIdent && name == "await") || (p.fnOrArrowDataParse.yield != allowIdent && name == "yield") {
+		p.log.Add(logger.Error, &p.tracker, nameRange, fmt.Sprintf("Cannot use %q as an identifier here:", name))
+	}
+	value := js_ast.Expr{Loc: nameRange.Loc, Data: &js_ast.EIdentifier{Ref: p.storeNameInRef(name)}}
+
+	for p.lexer.Token == js_lexer.TDot {
+		p.lexer.Next()
+		nameLoc := p.lexer.Loc()
+		if p.lexer.Token == js_lexer.TPrivateIdentifier && p.allowPrivateIdentifiers {
+			ref := p.storeNameInRef(p.lexer.Identifier)
+			p.lexer.Next()
+			value = js_ast.Expr{Loc: value.Loc, Data: &js_ast.EIndex{
+				Target: value,
+				Index:  js_ast.Expr{Loc: nameLoc, Data: &js_ast.EPrivateIdentifier{Ref: ref}},
+			}}
+		} else {
+			if !p.lexer.IsIdentifierOrKeyword() {
+				p.lexer.Expect(js_lexer.TIdentifier)
+			}
+			name := p.lexer.Identifier
+			p.lexer.Next()
+			value = js_ast.Expr{Loc: value.Loc, Data: &js_ast.EDot{Target: value, Name: name, NameLoc: nameLoc}}
+		}
+	}
+
+	if p.lexer.Token == js_lexer.TOpenParen {
+		value = js_ast.Expr{Loc: value.Loc, Data: &js_ast.ECall{Target: value, Args: p.parseCallArgs()}}
+	}
+	return value
+}
+
 type parseClassOpts struct {
-	tsDecorators        []js_ast.Expr
-	allowTSDecorators   bool
+	decorators          []js_ast.Expr
+	allowDecorators     bool
 	isTypeScriptDeclare bool
 }
 
@@ -5531,9 +5861,9 @@ func (p *parser) parseClass(classKeyword logger.Range, name *js_ast.LocRef, clas
 	scopeIndex := p.pushScopeForParsePass(js_ast.ScopeClassBody, bodyLoc)
 
 	opts := propertyOpts{
-		isClass:           true,
-		allowTSDecorators: classOpts.allowTSDecorators,
-		classHasExtends:   extendsOrNil.Data != nil,
+		isClass:         true,
+		allowDecorators: classOpts.allowDecorators,
+		classHasExtends: extendsOrNil.Data != nil,
 	}
 	hasConstructor := false
 
@@ -5545,21 +5875,32 @@ func (p *parser) parseClass(classKeyword logger.Range, name *js_ast.LocRef, clas
 
 		// Parse decorators for this property
 		firstDecoratorLoc := p.lexer.Loc()
-		if opts.allowTSDecorators {
-			opts.tsDecorators = p.parseTypeScriptDecorators()
+		if opts.allowDecorators {
+			opts.decorators = p.parseDecorators()
 		} else {
-			opts.tsDecorators = nil
+			opts.decorators = nil
 		}
 
 		// This property may turn out to be a type in TypeScript, which should be ignored
 		if property, ok := p.parseProperty(js_ast.PropertyNormal, opts, nil); ok {
 			properties = append(properties, property)
 
+			// Forbid decorators on class static blocks
+			if property.Kind == js_ast.PropertyClassStaticBlock && len(opts.decorators) > 0 {
+				p.log.Add(logger.Error, &p.tracker, logger.Range{Loc: firstDecoratorLoc},
+					"Decorators are not allowed on class static blocks")
+			}
+
 			// Forbid decorators on class constructors
 			if key, ok := property.Key.Data.(*js_ast.EString); ok && js_lexer.UTF16EqualsString(key.Value, "constructor") {
-				if len(opts.tsDecorators) > 0 {
-					p.log.Add(logger.Error, &p.tracker, logger.Range{Loc: firstDecoratorLoc},
-						"TypeScript does not allow decorators on class constructors")
+				if len(opts.decorators) > 0 {
+					if p.isUsingLegacyDecorators() {
+						p.log.Add(logger.Error, &p.tracker, logger.Range{Loc: firstDecoratorLoc},
+							"TypeScript does not allow decorators on class constructors")
+					} else {
+						p.log.Add(logger.Error, &p.tracker, logger.Range{Loc: firstDecoratorLoc},
+							"Decorators are not allowed on class constructors")
+					}
 				}
 				if property.IsMethod && !property.IsStatic && !property.IsComputed {
 					if hasConstructor {
@@ -5585,7 +5926,7 @@ func (p *parser) parseClass(classKeyword logger.Range, name *js_ast.LocRef, clas
 	p.lexer.Expect(js_lexer.TCloseBrace)
 	return js_ast.Class{
 		ClassKeyword: classKeyword,
-		TSDecorators: classOpts.tsDecorators,
+		Decorators:   classOpts.decorators,
 		Name:         name,
 		ExtendsOrNil: extendsOrNil,
 		BodyLoc:      bodyLoc,
@@ -5603,7 +5944,7 @@ func (p *parser) parseLabelName() *js_ast.LocRef {
 	return &name
 }
 
-func (p *parser) parsePath() (logger.Loc, string, *[]ast.AssertEntry) {
+func (p *parser) parsePath() (logger.Loc, string, *ast.ImportAssertOrWith) {
 	pathLoc := p.lexer.Loc()
 	pathText := js_lexer.UTF16ToString(p.lexer.StringLiteral())
 	if p.lexer.Token == js_lexer.TNoSubstitutionTemplateLiteral {
@@ -5612,12 +5953,18 @@ func (p *parser) parsePath() (logger.Loc, string, *[]ast.AssertEntry) {
 		p.lexer.Expect(js_lexer.TStringLiteral)
 	}
 
-	// See https://github.com/tc39/proposal-import-assertions for more info
-	var assertions *[]ast.AssertEntry
-	if !p.lexer.HasNewlineBefore && p.lexer.IsContextualKeyword("assert") {
+	// See https://github.com/tc39/proposal-import-attributes for more info
+	var assertOrWith *ast.ImportAssertOrWith
+	if p.lexer.Token == js_lexer.TWith || (!p.lexer.HasNewlineBefore && p.lexer.IsContextualKeyword("assert")) {
 		// "import './foo.json' assert { type: 'json' }"
-		var entries []ast.AssertEntry
+		// "import './foo.json' with { type: 'json' }"
+		var entries []ast.AssertOrWithEntry
 		duplicates := make(map[string]logger.Range)
+		keyword := ast.WithKeyword
+		if p.lexer.Token != js_lexer.TWith {
+			keyword = ast.AssertKeyword
+		}
+		keywordLoc := p.lexer.Loc()
 		p.lexer.Next()
 		p.lexer.Expect(js_lexer.TOpenBrace)
 
@@ -5638,7 +5985,11 @@ func (p *parser) parsePath() (logger.Loc, string, *[]ast.AssertEntry) {
 				p.lexer.Expect(js_lexer.TIdentifier)
 			}
 			if prevRange, ok := duplicates[keyText]; ok {
-				p.log.AddWithNotes(logger.Error, &p.tracker, p.lexer.Range(), fmt.Sprintf("Duplicate import assertion %q", keyText),
+				what := "attribute"
+				if keyword == ast.AssertKeyword {
+					what = "assertion"
+				}
+				p.log.AddWithNotes(logger.Error, &p.tracker, p.lexer.Range(), fmt.Sprintf("Duplicate import %s %q", what, keyText),
 					[]logger.MsgData{p.tracker.MsgData(prevRange, fmt.Sprintf("The first %q was here:", keyText))})
 			}
 			duplicates[keyText] = p.lexer.Range()
@@ -5650,7 +6001,7 @@ func (p *parser) parsePath() (logger.Loc, string, *[]ast.AssertEntry) {
 			value := p.lexer.StringLiteral()
 			p.lexer.Expect(js_lexer.TStringLiteral)
 
-			entries = append(entries, ast.AssertEntry{
+			entries = append(entries, ast.AssertOrWithEntry{
 				Key:             key,
 				KeyLoc:          keyLoc,
 				Value:           value,
@@ -5665,20 +6016,21 @@ func (p *parser) parsePath() (logger.Loc, string, *[]ast.AssertEntry) {
 		}
 
 		p.lexer.Expect(js_lexer.TCloseBrace)
-		assertions = &entries
+		assertOrWith = &ast.ImportAssertOrWith{
+			Entries:    entries,
+			KeywordLoc: keywordLoc,
+			Keyword:    keyword,
+		}
 	}
 
-	return pathLoc, pathText, assertions
+	return pathLoc, pathText, assertOrWith
 }
 
 // This assumes the "function" token has already been parsed
 func (p *parser) parseFnStmt(loc logger.Loc, opts parseStmtOpts, isAsync bool, asyncRange logger.Range) js_ast.Stmt {
 	isGenerator := p.lexer.Token == js_lexer.TAsterisk
 	if isGenerator {
-		p.markSyntaxFeature(compat.Generator, p.lexer.Range())
 		p.lexer.Next()
-	} else if isAsync {
-		p.markLoweredSyntaxFeature(compat.AsyncAwait, asyncRange, compat.Generator)
 	}
 
 	switch opts.lexicalDecl {
@@ -5708,7 +6060,7 @@ func (p *parser) parseFnStmt(loc logger.Loc, opts parseStmtOpts, isAsync bool, a
 
 	// Even anonymous functions can have TypeScript type parameters
 	if p.options.ts.Parse {
-		p.skipTypeScriptTypeParameters()
+		p.skipTypeScriptTypeParameters(allowConstModifier)
 	}
 
 	// Introduce a fake block scope for function declarations inside if statements
@@ -5782,7 +6134,7 @@ func (p *parser) parseFnStmt(loc logger.Loc, opts parseStmtOpts, isAsync bool, a
 	return js_ast.Stmt{Loc: loc, Data: &js_ast.SFunction{Fn: fn, IsExport: opts.isExport}}
 }
 
-type deferredTSDecorators struct {
+type deferredDecorators struct {
 	values []js_ast.Expr
 
 	// If this turns out to be a "declare class" statement, we need to undo the
@@ -5800,7 +6152,7 @@ const (
 )
 
 type parseStmtOpts struct {
-	tsDecorators           *deferredTSDecorators
+	decorators             *deferredDecorators
 	lexicalDecl            lexicalDecl
 	isModuleScope          bool
 	isNamespaceScope       bool
@@ -5829,20 +6181,20 @@ func (p *parser) parseStmt(opts parseStmtOpts) js_ast.Stmt {
 		}
 		p.lexer.Next()
 
-		// TypeScript decorators only work on class declarations
+		// Decorators only work on class declarations
 		// "@decorator export class Foo {}"
 		// "@decorator export abstract class Foo {}"
 		// "@decorator export default class Foo {}"
 		// "@decorator export default abstract class Foo {}"
 		// "@decorator export declare class Foo {}"
 		// "@decorator export declare abstract class Foo {}"
-		if opts.tsDecorators != nil && p.lexer.Token != js_lexer.TClass && p.lexer.Token != js_lexer.TDefault &&
+		if opts.decorators != nil && p.lexer.Token != js_lexer.TClass && p.lexer.Token != js_lexer.TDefault &&
 			!p.lexer.IsContextualKeyword("abstract") && !p.lexer.IsContextualKeyword("declare") {
 			p.lexer.Expected(js_lexer.TClass)
 		}
 
 		switch p.lexer.Token {
-		case js_lexer.TClass, js_lexer.TConst, js_lexer.TFunction, js_lexer.TVar:
+		case js_lexer.TClass, js_lexer.TConst, js_lexer.TFunction, js_lexer.TVar, js_lexer.TAt:
 			opts.isExport = true
 			return p.parseStmt(opts)
 
@@ -5860,6 +6212,9 @@ func (p *parser) parseStmt(opts parseStmtOpts) js_ast.Stmt {
 			if !p.options.ts.Parse {
 				p.lexer.Unexpected()
 			}
+			if p.options.ts.Flow {
+				p.logFlowEnumError()
+			}
 			opts.isExport = true
 			return p.parseStmt(opts)
 
@@ -5920,6 +6275,13 @@ func (p *parser) parseStmt(opts parseStmtOpts) js_ast.Stmt {
 					opts.lexicalDecl = lexicalDeclAllowAll
 					opts.isTypeScriptDeclare = true
 					return p.parseStmt(opts)
+
+				case "opaque":
+					// "export opaque type Foo = string"
+					if p.options.ts.Flow {
+						opts.isExport = true
+						return p.parseStmt(opts)
+					}
 				}
 			}
 
@@ -5944,7 +6306,7 @@ func (p *parser) parseStmt(opts parseStmtOpts) js_ast.Stmt {
 			// TypeScript decorators only work on class declarations
 			// "@decorator export default class Foo {}"
 			// "@decorator export default abstract class Foo {}"
-			if opts.tsDecorators != nil && p.lexer.Token != js_lexer.TClass && !p.lexer.IsContextualKeyword("abstract") {
+			if opts.decorators != nil && p.lexer.Token != js_lexer.TClass && !p.lexer.IsContextualKeyword("abstract") {
 				p.lexer.Expected(js_lexer.TClass)
 			}
 
@@ -5980,9 +6342,10 @@ func (p *parser) parseStmt(opts parseStmtOpts) js_ast.Stmt {
 					DefaultName: defaultName, Value: js_ast.Stmt{Loc: loc, Data: &js_ast.SExpr{Value: expr}}}}
 			}
 
-			if p.lexer.Token == js_lexer.TFunction || p.lexer.Token == js_lexer.TClass || p.lexer.IsContextualKeyword("interface") {
+			if p.lexer.Token == js_lexer.TFunction || p.lexer.Token == js_lexer.TClass || p.lexer.Token == js_lexer.TAt ||
+				p.lexer.IsContextualKeyword("interface") {
 				stmt := p.parseStmt(parseStmtOpts{
-					tsDecorators:   opts.tsDecorators,
+					decorators:     opts.decorators,
 					isNameOptional: true,
 					lexicalDecl:    lexicalDeclAllowAll,
 				})
@@ -6018,9 +6381,9 @@ func (p *parser) parseStmt(opts parseStmtOpts) js_ast.Stmt {
 
 			// Handle the default export of an abstract class in TypeScript
 			if p.options.ts.Parse && isIdentifier && name == "abstract" {
-				if _, ok := expr.Data.(*js_ast.EIdentifier); ok && (p.lexer.Token == js_lexer.TClass || opts.tsDecorators != nil) {
+				if _, ok := expr.Data.(*js_ast.EIdentifier); ok && (p.lexer.Token == js_lexer.TClass || opts.decorators != nil) {
 					stmt := p.parseClassStmt(loc, parseStmtOpts{
-						tsDecorators:   opts.tsDecorators,
+						decorators:     opts.decorators,
 						isNameOptional: true,
 					})
 
@@ -6051,7 +6414,7 @@ func (p *parser) parseStmt(opts parseStmtOpts) js_ast.Stmt {
 			var alias *js_ast.ExportStarAlias
 			var pathLoc logger.Loc
 			var pathText string
-			var assertions *[]ast.AssertEntry
+			var assertOrWith *ast.ImportAssertOrWith
 
 			if p.lexer.IsContextualKeyword("as") {
 				// "export * as ns from 'path'"
@@ -6061,15 +6424,15 @@ func (p *parser) parseStmt(opts parseStmtOpts) js_ast.Stmt {
 				alias = &js_ast.ExportStarAlias{Loc: p.lexer.Loc(), OriginalName: name}
 				p.lexer.Next()
 				p.lexer.ExpectContextualKeyword("from")
-				pathLoc, pathText, assertions = p.parsePath()
+				pathLoc, pathText, assertOrWith = p.parsePath()
 			} else {
 				// "export * from 'path'"
 				p.lexer.ExpectContextualKeyword("from")
-				pathLoc, pathText, assertions = p.parsePath()
+				pathLoc, pathText, assertOrWith = p.parsePath()
 				name := js_ast.GenerateNonUniqueNameFromPath(pathText) + "_star"
 				namespaceRef = p.storeNameInRef(name)
 			}
-			importRecordIndex := p.addImportRecord(ast.ImportStmt, pathLoc, pathText, assertions)
+			importRecordIndex := p.addImportRecord(ast.ImportStmt, pathLoc, pathText, assertOrWith)
 
 			p.lexer.ExpectOrInsertSemicolon()
 			return js_ast.Stmt{Loc: loc, Data: &js_ast.SExportStar{
@@ -6087,8 +6450,8 @@ func (p *parser) parseStmt(opts parseStmtOpts) js_ast.Stmt {
 			if p.lexer.IsContextualKeyword("from") {
 				// "export {} from 'path'"
 				p.lexer.Next()
-				pathLoc, pathText, assertions := p.parsePath()
-				importRecordIndex := p.addImportRecord(ast.ImportStmt, pathLoc, pathText, assertions)
+				pathLoc, pathText, assertOrWith := p.parsePath()
+				importRecordIndex := p.addImportRecord(ast.ImportStmt, pathLoc, pathText, assertOrWith)
 				name := "import_" + js_ast.GenerateNonUniqueNameFromPath(pathText)
 				namespaceRef := p.storeNameInRef(name)
 				p.lexer.ExpectOrInsertSemicolon()
@@ -6127,13 +6490,16 @@ func (p *parser) parseStmt(opts parseStmtOpts) js_ast.Stmt {
 		if !p.options.ts.Parse {
 			p.lexer.Unexpected()
 		}
-		return p.parseTypeScriptEnumStmt(loc, opts)
+		if p.options.ts.Flow {
+			p.logFlowEnumError()
+		}
+		return p.parseTypeScriptEnumStmt(loc, opts, false /* isConst */)
 
 	case js_lexer.TAt:
 		// Parse decorators before class statements, which are potentially exported
-		if p.options.ts.Parse {
+		if opts.decorators == nil {
 			scopeIndex := len(p.scopesInOrder)
-			tsDecorators := p.parseTypeScriptDecorators()
+			decorators := p.parseDecorators()
 
 			// If this turns out to be a "declare class" statement, we need to undo the
 			// scopes that were potentially pushed while parsing the decorator arguments.
@@ -6144,8 +6510,8 @@ func (p *parser) parseStmt(opts parseStmtOpts) js_ast.Stmt {
 			//   "@decorator export declare class Foo {}"
 			//   "@decorator export declare abstract class Foo {}"
 			//
-			opts.tsDecorators = &deferredTSDecorators{
-				values:     tsDecorators,
+			opts.decorators = &deferredDecorators{
+				values:     decorators,
 				scopeIndex: scopeIndex,
 			}
 
@@ -6159,8 +6525,10 @@ func (p *parser) parseStmt(opts parseStmtOpts) js_ast.Stmt {
 			// "@decorator export declare abstract class Foo {}"
 			// "@decorator export default class Foo {}"
 			// "@decorator export default abstract class Foo {}"
-			if p.lexer.Token != js_lexer.TClass && p.lexer.Token != js_lexer.TExport &&
-				!p.lexer.IsContextualKeyword("abstract") && !p.lexer.IsContextualKeyword("declare") {
+			// "export @decorator class Foo {}"
+			// "export default @decorator class Foo {}"
+			if p.lexer.Token != js_lexer.TClass && (p.lexer.Token != js_lexer.TExport || opts.isExport || opts.isNameOptional) &&
+				(!p.options.ts.Parse || (!p.lexer.IsContextualKeyword("abstract") && !p.lexer.IsContextualKeyword("declare"))) {
 				p.lexer.Expected(js_lexer.TClass)
 			}
 
@@ -6190,17 +6558,16 @@ func (p *parser) parseStmt(opts parseStmtOpts) js_ast.Stmt {
 		if opts.lexicalDecl != lexicalDeclAllowAll {
 			p.forbidLexicalDecl(loc)
 		}
-		p.markSyntaxFeature(compat.Const, p.lexer.Range())
 		p.lexer.Next()
 
 		if p.options.ts.Parse && p.lexer.Token == js_lexer.TEnum {
-			return p.parseTypeScriptEnumStmt(loc, opts)
+			return p.parseTypeScriptEnumStmt(loc, opts, true /* isConst */)
 		}
 
 		decls := p.parseAndDeclareDecls(js_ast.SymbolConst, opts)
 		p.lexer.ExpectOrInsertSemicolon()
 		if !opts.isTypeScriptDeclare {
-			p.requireInitializers(decls)
+			p.requireInitializers(js_ast.LocalConst, decls)
 		}
 		return js_ast.Stmt{Loc: loc, Data: &js_ast.SLocal{
 			Kind:     js_ast.LocalConst,
@@ -6299,7 +6666,12 @@ func (p *parser) parseStmt(opts parseStmtOpts) js_ast.Stmt {
 					break caseBody
 
 				default:
-					body = append(body, p.parseStmt(parseStmtOpts{lexicalDecl: lexicalDeclAllowAll}))
+					stmt := p.parseStmt(parseStmtOpts{lexicalDecl: lexicalDeclAllowAll})
+					if local, ok := stmt.Data.(*js_ast.SLocal); ok && local.Kind.IsUsing() {
+						p.log.Add(logger.Error, &p.tracker, js_lexer.RangeOfIdentifier(p.source, stmt.Loc),
+							fmt.Sprintf("%q declarations are not allowed directly inside a switch case", local.Kind.String()))
+					}
+					body = append(body, stmt)
 				}
 			}
 
@@ -6402,12 +6774,9 @@ func (p *parser) parseStmt(opts parseStmtOpts) js_ast.Stmt {
 			if p.fnOrArrowDataParse.await != allowExpr {
 				p.log.Add(logger.Error, &p.tracker, awaitRange, "Cannot use \"await\" outside an async function")
 				isForAwait = false
-			} else {
-				didGenerateError := p.markSyntaxFeature(compat.ForAwait, awaitRange)
-				if p.fnOrArrowDataParse.isTopLevel && !didGenerateError {
-					p.topLevelAwaitKeyword = awaitRange
-					p.markSyntaxFeature(compat.TopLevelAwait, awaitRange)
-				}
+			} else if p.fnOrArrowDataParse.isTopLevel {
+				p.topLevelAwaitKeyword = awaitRange
+				p.markSyntaxFeature(compat.TopLevelAwait, awaitRange)
 			}
 			p.lexer.Next()
 		}
@@ -6436,7 +6805,6 @@ func (p *parser) parseStmt(opts parseStmtOpts) js_ast.Stmt {
 			initOrNil = js_ast.Stmt{Loc: initLoc, Data: &js_ast.SLocal{Kind: js_ast.LocalVar, Decls: decls}}
 
 		case js_lexer.TConst:
-			p.markSyntaxFeature(compat.Const, p.lexer.Range())
 			p.lexer.Next()
 			decls = p.parseAndDeclareDecls(js_ast.SymbolConst, parseStmtOpts{})
 			initOrNil = js_ast.Stmt{Loc: initLoc, Data: &js_ast.SLocal{Kind: js_ast.LocalConst, Decls: decls}}
@@ -6446,7 +6814,7 @@ func (p *parser) parseStmt(opts parseStmtOpts) js_ast.Stmt {
 		default:
 			var expr js_ast.Expr
 			var stmt js_ast.Stmt
-			expr, stmt, decls = p.parseExprOrLetStmt(parseStmtOpts{
+			expr, stmt, decls = p.parseExprOrLetOrUsingStmt(parseStmtOpts{
 				lexicalDecl:        lexicalDeclAllowAll,
 				isForLoopInit:      true,
 				isForAwaitLoopInit: isForAwait,
@@ -6475,7 +6843,6 @@ func (p *parser) parseStmt(opts parseStmtOpts) js_ast.Stmt {
 				}
 			}
 			p.forbidInitializers(decls, "of", false)
-			p.markSyntaxFeature(compat.ForOf, p.lexer.Range())
 			p.lexer.Next()
 			value := p.parseExpr(js_ast.LComma)
 			p.lexer.Expect(js_lexer.TCloseParen)
@@ -6485,6 +6852,10 @@ func (p *parser) parseStmt(opts parseStmtOpts) js_ast.Stmt {
 
 		// Detect for-in loops
 		if p.lexer.Token == js_lexer.TIn {
+			if local, ok := initOrNil.Data.(*js_ast.SLocal); ok && local.Kind.IsUsing() {
+				p.log.Add(logger.Error, &p.tracker, js_lexer.RangeOfIdentifier(p.source, initLoc),
+					fmt.Sprintf("%q declarations are not allowed in for-in loops", local.Kind.String()))
+			}
 			p.forbidInitializers(decls, "in", isVar)
 			p.lexer.Next()
 			value := p.parseExpr(js_ast.LLowest)
@@ -6493,9 +6864,9 @@ func (p *parser) parseStmt(opts parseStmtOpts) js_ast.Stmt {
 			return js_ast.Stmt{Loc: loc, Data: &js_ast.SForIn{Init: initOrNil, Value: value, Body: body}}
 		}
 
-		// Only require "const" statement initializers when we know we're a normal for loop
-		if local, ok := initOrNil.Data.(*js_ast.SLocal); ok && local.Kind == js_ast.LocalConst {
-			p.requireInitializers(decls)
+		// Only require "const" and "using" statement initializers when we know we're a normal for loop
+		if local, ok := initOrNil.Data.(*js_ast.SLocal); ok && (local.Kind == js_ast.LocalConst || local.Kind.IsUsing()) {
+			p.requireInitializers(local.Kind, decls)
 		}
 
 		p.lexer.Expect(js_lexer.TSemicolon)
@@ -6565,6 +6936,17 @@ func (p *parser) parseStmt(opts parseStmtOpts) js_ast.Stmt {
 			p.lexer.Expect(js_lexer.TIdentifier)
 			p.lexer.ExpectContextualKeyword("from")
 
+		case js_lexer.TTypeof:
+			// "import typeof foo from 'path'"
+			if !p.options.ts.Flow || (!opts.isModuleScope && (!opts.isNamespaceScope || !opts.isTypeScriptDeclare)) {
+				p.lexer.Unexpected()
+				return js_ast.Stmt{}
+			}
+
+			p.lexer.Next()
+			p.skipFlowTypeofImportStmt()
+			return js_ast.Stmt{Loc: loc, Data: &js_ast.STypeScript{}}
+
 		case js_lexer.TOpenBrace:
 			// "import {item1, item2} from 'path'"
 			if !opts.isModuleScope && (!opts.isNamespaceScope || !opts.isTypeScriptDeclare) {
@@ -6572,7 +6954,17 @@ func (p *parser) parseStmt(opts parseStmtOpts) js_ast.Stmt {
 				return js_ast.Stmt{}
 			}
 
+			isFlowClause := p.options.ts.Flow && p.isNonEmptyImportClause()
 			items, isSingleLine := p.parseImportClause()
+
+			// "import { type Foo } from 'bar'" is removed entirely in Flow
+			if isFlowClause && len(items) == 0 {
+				p.lexer.ExpectContextualKeyword("from")
+				p.parsePath()
+				p.lexer.ExpectOrInsertSemicolon()
+				return js_ast.Stmt{Loc: loc, Data: &js_ast.STypeScript{}}
+			}
+
 			stmt.Items = &items
 			stmt.IsSingleLine = isSingleLine
 			p.lexer.ExpectContextualKeyword("from")
@@ -6605,6 +6997,11 @@ func (p *parser) parseStmt(opts parseStmtOpts) js_ast.Stmt {
 								return p.parseTypeScriptImportEqualsStmt(loc, opts, stmt.DefaultName.Loc, defaultName)
 							} else {
 								// "import type foo from 'bar';"
+								// "import type foo, {bar} from 'bar';"
+								if p.options.ts.Flow && p.lexer.Token == js_lexer.TComma {
+									p.lexer.Next()
+									p.skipFlowImportBindings()
+								}
 								p.lexer.ExpectContextualKeyword("from")
 								p.parsePath()
 								p.lexer.ExpectOrInsertSemicolon()
@@ -6653,9 +7050,14 @@ func (p *parser) parseStmt(opts parseStmtOpts) js_ast.Stmt {
 
 				case js_lexer.TOpenBrace:
 					// "import defaultItem, {item1, item2} from 'path'"
+					isFlowClause := p.options.ts.Flow && p.isNonEmptyImportClause()
 					items, isSingleLine := p.parseImportClause()
-					stmt.Items = &items
-					stmt.IsSingleLine = isSingleLine
+
+					// "import foo, { type Bar } from 'baz'" => "import foo from 'baz'" in Flow
+					if !isFlowClause || len(items) > 0 {
+						stmt.Items = &items
+						stmt.IsSingleLine = isSingleLine
+					}
 
 				default:
 					p.lexer.Unexpected()
@@ -6669,8 +7071,8 @@ func (p *parser) parseStmt(opts parseStmtOpts) js_ast.Stmt {
 			return js_ast.Stmt{}
 		}
 
-		pathLoc, pathText, assertions := p.parsePath()
-		stmt.ImportRecordIndex = p.addImportRecord(ast.ImportStmt, pathLoc, pathText, assertions)
+		pathLoc, pathText, assertOrWith := p.parsePath()
+		stmt.ImportRecordIndex = p.addImportRecord(ast.ImportStmt, pathLoc, pathText, assertOrWith)
 		p.importRecords[stmt.ImportRecordIndex].WasOriginallyBareImport = wasOriginallyBareImport
 		p.lexer.ExpectOrInsertSemicolon()
 
@@ -6779,7 +7181,7 @@ func (p *parser) parseStmt(opts parseStmtOpts) js_ast.Stmt {
 			expr = p.parseSuffix(p.parseAsyncPrefixExpr(asyncRange, js_ast.LLowest, 0), js_ast.LLowest, nil, 0)
 		} else {
 			var stmt js_ast.Stmt
-			expr, stmt, _ = p.parseExprOrLetStmt(opts)
+			expr, stmt, _ = p.parseExprOrLetOrUsingStmt(opts)
 			if stmt.Data != nil {
 				p.lexer.ExpectOrInsertSemicolon()
 				return stmt
@@ -6788,7 +7190,7 @@ func (p *parser) parseStmt(opts parseStmtOpts) js_ast.Stmt {
 
 		if isIdentifier {
 			if ident, ok := expr.Data.(*js_ast.EIdentifier); ok {
-				if p.lexer.Token == js_lexer.TColon && opts.tsDecorators == nil {
+				if p.lexer.Token == js_lexer.TColon && opts.decorators == nil {
 					p.pushScopeForParsePass(js_ast.ScopeLabel, loc)
 					defer p.popScope()
 
@@ -6812,6 +7214,16 @@ func (p *parser) parseStmt(opts parseStmtOpts) js_ast.Stmt {
 							return js_ast.Stmt{Loc: loc, Data: &js_ast.STypeScript{}}
 						}
 
+					case "opaque":
+						// "opaque type Foo = string"
+						if p.options.ts.Flow && p.lexer.IsContextualKeyword("type") && !p.lexer.HasNewlineBefore {
+							p.skipFlowOpaqueTypeStmt(parseStmtOpts{
+								isModuleScope:       opts.isModuleScope,
+								isTypeScriptDeclare: opts.isTypeScriptDeclare,
+							})
+							return js_ast.Stmt{Loc: loc, Data: &js_ast.STypeScript{}}
+						}
+
 					case "namespace", "module":
 						// "namespace Foo {}"
 						// "module Foo {}"
@@ -6828,7 +7240,7 @@ func (p *parser) parseStmt(opts parseStmtOpts) js_ast.Stmt {
 						return js_ast.Stmt{Loc: loc, Data: &js_ast.STypeScript{}}
 
 					case "abstract":
-						if p.lexer.Token == js_lexer.TClass || opts.tsDecorators != nil {
+						if p.lexer.Token == js_lexer.TClass || opts.decorators != nil {
 							return p.parseClassStmt(loc, opts)
 						}
 
@@ -6847,7 +7259,7 @@ func (p *parser) parseStmt(opts parseStmtOpts) js_ast.Stmt {
 
 						// "@decorator declare class Foo {}"
 						// "@decorator declare abstract class Foo {}"
-						if opts.tsDecorators != nil && p.lexer.Token != js_lexer.TClass && !p.lexer.IsContextualKeyword("abstract") {
+						if opts.decorators != nil && p.lexer.Token != js_lexer.TClass && !p.lexer.IsContextualKeyword("abstract") {
 							p.lexer.Expected(js_lexer.TClass)
 						}
 
@@ -6860,10 +7272,16 @@ func (p *parser) parseStmt(opts parseStmtOpts) js_ast.Stmt {
 							return js_ast.Stmt{Loc: loc, Data: &js_ast.STypeScript{}}
 						}
 
+						// "declare module.exports: any"
+						// "declare export function foo(): void"
+						if p.options.ts.Flow && p.trySkipFlowDeclareStmt(opts) {
+							return js_ast.Stmt{Loc: loc, Data: &js_ast.STypeScript{}}
+						}
+
 						// "declare const x: any"
 						stmt := p.parseStmt(opts)
-						if opts.tsDecorators != nil {
-							p.discardScopesUpTo(opts.tsDecorators.scopeIndex)
+						if opts.decorators != nil {
+							p.discardScopesUpTo(opts.decorators.scopeIndex)
 						}
 
 						// Unlike almost all uses of "declare", statements that use
@@ -6937,13 +7355,13 @@ func extractDeclsForBinding(binding js_ast.Binding, decls []js_ast.Decl) []js_as
 	return decls
 }
 
-func (p *parser) addImportRecord(kind ast.ImportKind, loc logger.Loc, text string, assertions *[]ast.AssertEntry) uint32 {
+func (p *parser) addImportRecord(kind ast.ImportKind, loc logger.Loc, text string, assertOrWith *ast.ImportAssertOrWith) uint32 {
 	index := uint32(len(p.importRecords))
 	p.importRecords = append(p.importRecords, ast.ImportRecord{
-		Kind:       kind,
-		Range:      p.source.RangeOfString(loc),
-		Path:       logger.Path{Text: text},
-		Assertions: assertions,
+		Kind:         kind,
+		Range:        p.source.RangeOfString(loc),
+		Path:         logger.Path{Text: text},
+		AssertOrWith: assertOrWith,
 	})
 	return index
 }
@@ -7384,10 +7802,84 @@ type stmtsKind uint8
 
 const (
 	stmtsNormal stmtsKind = iota
+	stmtsSwitch
 	stmtsLoopBody
 	stmtsFnBody
 )
 
+type erasableConstEnum struct {
+	scope    *js_ast.Scope
+	stmts    []js_ast.S
+	ref      js_ast.Ref
+	useCount uint32
+}
+
+type erasedConstEnums struct {
+	stmts map[js_ast.S]bool
+	refs  map[js_ast.Ref]bool
+}
+
+// This finds the const enums declared in the current scope that turned out to
+// be unused. Each enum is considered unused if the use count of the enum
+// object hasn't changed since it was declared, which means every reference to
+// it was inlined. It must only be called once every statement in the current
+// scope has been visited.
+func (p *parser) unusedConstEnumsInCurrentScope(exportedRefs map[js_ast.Ref]bool) (result erasedConstEnums) {
+	end := 0
+	for _, e := range p.erasableConstEnums {
+		if e.scope != p.currentScope {
+			p.erasableConstEnums[end] = e
+			end++
+			continue
+		}
+		if p.symbols[e.ref.InnerIndex].UseCountEstimate != e.useCount || exportedRefs[e.ref] {
+			continue
+		}
+		if result.stmts == nil {
+			result.stmts = make(map[js_ast.S]bool)
+			result.refs = make(map[js_ast.Ref]bool)
+		}
+		for _, s := range e.stmts {
+			result.stmts[s] = true
+		}
+		result.refs[e.ref] = true
+	}
+	p.erasableConstEnums = p.erasableConstEnums[:end]
+	return
+}
+
+func (erased erasedConstEnums) eraseFrom(stmts []js_ast.Stmt) []js_ast.Stmt {
+	if erased.stmts == nil {
+		return stmts
+	}
+
+	end := 0
+	for _, stmt := range stmts {
+		// Variable declarations are matched by symbol instead of by statement
+		// since other declarations may have been merged into the same statement
+		if s, ok := stmt.Data.(*js_ast.SLocal); ok {
+			declEnd := 0
+			for _, decl := range s.Decls {
+				if id, ok := decl.Binding.Data.(*js_ast.BIdentifier); ok && erased.refs[id.Ref] {
+					continue
+				}
+				s.Decls[declEnd] = decl
+				declEnd++
+			}
+			if declEnd == 0 {
+				continue
+			}
+			s.Decls = s.Decls[:declEnd]
+		} else if erased.stmts[stmt.Data] {
+			continue
+		}
+
+		stmts[end] = stmt
+		end++
+	}
+	return stmts[:end]
+}
+
 func (p *parser) visitStmts(stmts []js_ast.Stmt, kind stmtsKind) []js_ast.Stmt {
 	// Save the current control-flow liveness. This represents if we are
 	// currently inside an "if (false) { ... }" block.
@@ -7419,6 +7911,14 @@ func (p *parser) visitStmts(stmts []js_ast.Stmt, kind stmtsKind) []js_ast.Stmt {
 		visited = p.visitAndAppendStmt(visited, stmt)
 	}
 
+	// Top-level statements are visited one part at a time, so const enums at
+	// the top level are handled once all parts have been visited instead. All
+	// cases in a switch statement share a scope, so const enums declared there
+	// are conservatively kept.
+	if len(p.erasableConstEnums) > 0 && kind != stmtsSwitch && p.currentScope != p.moduleScope {
+		visited = p.unusedConstEnumsInCurrentScope(nil).eraseFrom(visited)
+	}
+
 	// Transform block-level function declarations into variable declarations
 	if len(before) > 0 {
 		var letDecls []js_ast.Decl
@@ -7477,6 +7977,11 @@ func (p *parser) visitStmts(stmts []js_ast.Stmt, kind stmtsKind) []js_ast.Stmt {
 	// Move TypeScript "export =" statements to the end
 	visited = append(visited, after...)
 
+	// Lower "using" declarations for older language environments
+	if p.shouldLowerUsingDeclarations(visited) {
+		visited = p.lowerUsingDeclarations(visited)
+	}
+
 	// Restore the current control-flow liveness if it was changed inside the
 	// loop above. This is important because the caller will not restore it.
 	p.isControlFlowDead = oldIsControlFlowDead
@@ -7565,8 +8070,9 @@ func (p *parser) mangleStmts(stmts []js_ast.Stmt, kind stmtsKind) []js_ast.Stmt
 				// we may not have visited all of their uses yet by this point. We
 				// should have visited all the uses of "let" and "const" declarations
 				// by now since they are scoped to this block which we just finished
-				// visiting.
-				if prevS, ok := result[len(result)-1].Data.(*js_ast.SLocal); ok && prevS.Kind != js_ast.LocalVar {
+				// visiting. Ignore "using" declarations since substituting them would
+				// change when the value is disposed.
+				if prevS, ok := result[len(result)-1].Data.(*js_ast.SLocal); ok && prevS.Kind != js_ast.LocalVar && !prevS.Kind.IsUsing() {
 					// The variable must be initialized, since we will be substituting
 					// the value into the usage.
 					if last := prevS.Decls[len(prevS.Decls)-1]; last.ValueOrNil.Data != nil {
@@ -7629,7 +8135,7 @@ func (p *parser) mangleStmts(stmts []js_ast.Stmt, kind stmtsKind) []js_ast.Stmt
 			// Merge adjacent expression statements
 			if len(result) > 0 {
 				prevStmt := result[len(result)-1]
-				if prevS, ok := prevStmt.Data.(*js_ast.SExpr); ok && !js_ast.IsSuperCall(prevStmt) && !js_ast.IsSuperCall(stmt) {
+				if prevS, ok := prevStmt.Data.(*js_ast.SExpr); ok && !p.isSuperCallStmt(prevStmt) && !p.isSuperCallStmt(stmt) {
 					prevS.Value = js_ast.JoinWithComma(prevS.Value, s.Value)
 					prevS.DoesNotAffectTreeShaking = prevS.DoesNotAffectTreeShaking && s.DoesNotAffectTreeShaking
 					continue
@@ -7640,7 +8146,7 @@ func (p *parser) mangleStmts(stmts []js_ast.Stmt, kind stmtsKind) []js_ast.Stmt
 			// Absorb a previous expression statement
 			if len(result) > 0 {
 				prevStmt := result[len(result)-1]
-				if prevS, ok := prevStmt.Data.(*js_ast.SExpr); ok && !js_ast.IsSuperCall(prevStmt) {
+				if prevS, ok := prevStmt.Data.(*js_ast.SExpr); ok && !p.isSuperCallStmt(prevStmt) {
 					s.Test = js_ast.JoinWithComma(prevS.Value, s.Test)
 					result = result[:len(result)-1]
 				}
@@ -7650,7 +8156,7 @@ func (p *parser) mangleStmts(stmts []js_ast.Stmt, kind stmtsKind) []js_ast.Stmt
 			// Absorb a previous expression statement
 			if len(result) > 0 {
 				prevStmt := result[len(result)-1]
-				if prevS, ok := prevStmt.Data.(*js_ast.SExpr); ok && !js_ast.IsSuperCall(prevStmt) {
+				if prevS, ok := prevStmt.Data.(*js_ast.SExpr); ok && !p.isSuperCallStmt(prevStmt) {
 					s.Test = js_ast.JoinWithComma(prevS.Value, s.Test)
 					result = result[:len(result)-1]
 				}
@@ -7753,7 +8259,7 @@ func (p *parser) mangleStmts(stmts []js_ast.Stmt, kind stmtsKind) []js_ast.Stmt
 			// Merge return statements with the previous expression statement
 			if len(result) > 0 && s.ValueOrNil.Data != nil {
 				prevStmt := result[len(result)-1]
-				if prevS, ok := prevStmt.Data.(*js_ast.SExpr); ok && !js_ast.IsSuperCall(prevStmt) {
+				if prevS, ok := prevStmt.Data.(*js_ast.SExpr); ok && !p.isSuperCallStmt(prevStmt) {
 					result[len(result)-1] = js_ast.Stmt{Loc: prevStmt.Loc,
 						Data: &js_ast.SReturn{ValueOrNil: js_ast.JoinWithComma(prevS.Value, s.ValueOrNil)}}
 					continue
@@ -7766,7 +8272,7 @@ func (p *parser) mangleStmts(stmts []js_ast.Stmt, kind stmtsKind) []js_ast.Stmt
 			// Merge throw statements with the previous expression statement
 			if len(result) > 0 {
 				prevStmt := result[len(result)-1]
-				if prevS, ok := prevStmt.Data.(*js_ast.SExpr); ok && !js_ast.IsSuperCall(prevStmt) {
+				if prevS, ok := prevStmt.Data.(*js_ast.SExpr); ok && !p.isSuperCallStmt(prevStmt) {
 					result[len(result)-1] = js_ast.Stmt{Loc: prevStmt.Loc, Data: &js_ast.SThrow{Value: js_ast.JoinWithComma(prevS.Value, s.Value)}}
 					continue
 				}
@@ -7780,7 +8286,7 @@ func (p *parser) mangleStmts(stmts []js_ast.Stmt, kind stmtsKind) []js_ast.Stmt
 		case *js_ast.SFor:
 			if len(result) > 0 {
 				prevStmt := result[len(result)-1]
-				if prevS, ok := prevStmt.Data.(*js_ast.SExpr); ok && !js_ast.IsSuperCall(prevStmt) {
+				if prevS, ok := prevStmt.Data.(*js_ast.SExpr); ok && !p.isSuperCallStmt(prevStmt) {
 					// Insert the previous expression into the for loop initializer
 					if s.InitOrNil.Data == nil {
 						result[len(result)-1] = stmt
@@ -7882,7 +8388,7 @@ func (p *parser) mangleStmts(stmts []js_ast.Stmt, kind stmtsKind) []js_ast.Stmt
 					}
 
 					// Do not absorb a "super()" call so that we keep it first
-					if js_ast.IsSuperCall(prevStmt) {
+					if p.isSuperCallStmt(prevStmt) {
 						break returnLoop
 					}
 
@@ -7959,7 +8465,7 @@ func (p *parser) mangleStmts(stmts []js_ast.Stmt, kind stmtsKind) []js_ast.Stmt
 				switch prevS := prevStmt.Data.(type) {
 				case *js_ast.SExpr:
 					// Do not absorb a "super()" call so that we keep it first
-					if js_ast.IsSuperCall(prevStmt) {
+					if p.isSuperCallStmt(prevStmt) {
 						break throwLoop
 					}
 
@@ -8289,6 +8795,9 @@ func (p *parser) substituteSingleUseSymbolInExpr(
 func (p *parser) visitLoopBody(stmt js_ast.Stmt) js_ast.Stmt {
 	oldIsInsideLoop := p.fnOrArrowDataVisit.isInsideLoop
 	p.fnOrArrowDataVisit.isInsideLoop = true
+	if loop := p.fnOrArrowDataVisit.loweredLoop; loop != nil {
+		loop.isInsideBody = true
+	}
 	p.loopBody = stmt.Data
 	stmt = p.visitSingleStmt(stmt, stmtsLoopBody)
 	p.fnOrArrowDataVisit.isInsideLoop = oldIsInsideLoop
@@ -8345,8 +8854,10 @@ func (p *parser) visitForLoopInit(stmt js_ast.Stmt, isInOrOf bool) js_ast.Stmt {
 			d := &s.Decls[i]
 			p.visitBinding(d.Binding, bindingOpts{})
 			if d.ValueOrNil.Data != nil {
+				p.visitLoweredLexicalInitializer(d.Binding)
 				d.ValueOrNil = p.visitExpr(d.ValueOrNil)
 			}
+			p.visitLoweredLexicalDecl(d.Binding, true /* isLoopHead */)
 		}
 		s.Decls = p.lowerObjectRestInDecls(s.Decls)
 		s.Kind = p.selectLocalKind(s.Kind)
@@ -8359,9 +8870,16 @@ func (p *parser) visitForLoopInit(stmt js_ast.Stmt, isInOrOf bool) js_ast.Stmt {
 }
 
 func (p *parser) recordDeclaredSymbol(ref js_ast.Ref) {
+	isTopLevel := p.currentScope == p.moduleScope
+
+	// Lowered "let" and "const" bindings in top-level blocks become top-level
+	if binding, ok := p.loweredLexicalBindings[ref]; ok && binding.isTopLevel {
+		isTopLevel = true
+	}
+
 	p.declaredSymbols = append(p.declaredSymbols, js_ast.DeclaredSymbol{
 		Ref:        ref,
-		IsTopLevel: p.currentScope == p.moduleScope,
+		IsTopLevel: isTopLevel,
 	})
 }
 
@@ -9012,10 +9530,10 @@ func (p *parser) visitAndAppendStmt(stmts []js_ast.Stmt, stmt js_ast.Stmt) []js_
 			return stmts
 
 		case *js_ast.SClass:
-			shadowRef := p.visitClass(s.Value.Loc, &s2.Class)
+			result := p.visitClass(s.Value.Loc, &s2.Class)
 
 			// Lower class field syntax for browsers that don't support it
-			classStmts, _ := p.lowerClass(stmt, js_ast.Expr{}, shadowRef)
+			classStmts, _ := p.lowerClass(stmt, js_ast.Expr{}, result)
 			return append(stmts, classStmts...)
 
 		default:
@@ -9094,15 +9612,32 @@ func (p *parser) visitAndAppendStmt(stmts []js_ast.Stmt, stmt js_ast.Stmt) []js_
 		case *js_ast.SFor, *js_ast.SForIn, *js_ast.SForOf, *js_ast.SWhile, *js_ast.SDoWhile:
 			p.currentScope.LabelStmtIsLoop = true
 		}
+		forOf, ok := s.Stmt.Data.(*js_ast.SForOf)
+		isLoweredForOf := ok && p.isLoweredForOfLoop(forOf)
 		s.Stmt = p.visitSingleStmt(s.Stmt, stmtsNormal)
 		p.popScope()
 
+		// A lowered "for-of" loop is wrapped in a "try" statement. Move the
+		// label onto the inner loop since "continue" must target a loop.
+		if try, ok := s.Stmt.Data.(*js_ast.STry); ok && isLoweredForOf {
+			try.Body[0] = js_ast.Stmt{Loc: stmt.Loc, Data: &js_ast.SLabel{Name: s.Name, Stmt: try.Body[0]}}
+			return append(stmts, s.Stmt)
+		}
+
 	case *js_ast.SLocal:
 		for i := range s.Decls {
 			d := &s.Decls[i]
+
+			// Default values in destructuring patterns are evaluated after the
+			// bindings before them have been initialized
+			if _, ok := d.Binding.Data.(*js_ast.BIdentifier); !ok {
+				p.visitLoweredLexicalDecl(d.Binding, false /* isLoopHead */)
+			}
+
 			p.visitBinding(d.Binding, bindingOpts{})
 			if d.ValueOrNil.Data != nil {
 				wasAnonymousNamedExpr := p.isAnonymousNamedExpr(d.ValueOrNil)
+				p.visitLoweredLexicalInitializer(d.Binding)
 				d.ValueOrNil = p.visitExpr(d.ValueOrNil)
 
 				// Optionally preserve the name
@@ -9132,6 +9667,8 @@ func (p *parser) visitAndAppendStmt(stmts []js_ast.Stmt, stmt js_ast.Stmt) []js_
 					}
 				}
 			}
+
+			p.visitLoweredLexicalDecl(d.Binding, false /* isLoopHead */)
 		}
 
 		// Handle being exported inside a namespace
@@ -9159,10 +9696,26 @@ func (p *parser) visitAndAppendStmt(stmts []js_ast.Stmt, stmt js_ast.Stmt) []js_
 		}
 
 		s.Decls = p.lowerObjectRestInDecls(s.Decls)
+		originalKind := s.Kind
 		s.Kind = p.selectLocalKind(s.Kind)
 
-		// Potentially relocate "var" declarations to the top level
-		if s.Kind == js_ast.LocalVar {
+		// Each loop iteration gets a new "let" binding but a "var" binding is
+		// shared, so it must be explicitly reset if there's no initializer:
+		//
+		//   for (;;) { let x; x ||= 1 }  =>  for (;;) { var x = void 0; x ||= 1 }
+		//
+		if originalKind == js_ast.LocalLet && s.Kind == js_ast.LocalVar && p.fnOrArrowDataVisit.isInsideLoop {
+			for i := range s.Decls {
+				d := &s.Decls[i]
+				if _, ok := d.Binding.Data.(*js_ast.BIdentifier); ok && d.ValueOrNil.Data == nil {
+					d.ValueOrNil = js_ast.Expr{Loc: d.Binding.Loc, Data: js_ast.EUndefinedShared}
+				}
+			}
+		}
+
+		// Potentially relocate "var" declarations to the top level. Lowered "let"
+		// and "const" declarations aren't relocated since they are block-scoped.
+		if s.Kind == js_ast.LocalVar && originalKind == js_ast.LocalVar {
 			if assign, ok := p.maybeRelocateVarsToTopLevel(s.Decls, relocateVarsNormal); ok {
 				if assign.Data != nil {
 					stmts = append(stmts, assign)
@@ -9208,6 +9761,20 @@ func (p *parser) visitAndAppendStmt(stmts []js_ast.Stmt, stmt js_ast.Stmt) []js_
 					s.ValueOrNil = js_ast.Expr{}
 				}
 			}
+
+			// The returned value must be awaited inside the lowered async generator
+			// so that a rejection can still be caught by a surrounding "try"
+			if p.isInsideLoweredAsyncGenerator() {
+				s.ValueOrNil = p.lowerAwaitInAsyncGenerator(s.ValueOrNil.Loc, s.ValueOrNil)
+			}
+		}
+
+		// A plain "return" in the constructor of a derived class that is lowered
+		// to ES5 must return the object that was created by the "super()" call
+		if member := p.fnOnlyDataVisit.loweredClassMember; member != nil && member.ctorThisRef != js_ast.InvalidRef &&
+			!p.fnOrArrowDataVisit.isArrow && s.ValueOrNil.Data == nil {
+			p.recordUsage(member.ctorThisRef)
+			s.ValueOrNil = js_ast.Expr{Loc: stmt.Loc, Data: &js_ast.EIdentifier{Ref: member.ctorThisRef}}
 		}
 
 	case *js_ast.SBlock:
@@ -9242,8 +9809,10 @@ func (p *parser) visitAndAppendStmt(stmts []js_ast.Stmt, stmt js_ast.Stmt) []js_
 		p.popScope()
 
 	case *js_ast.SWhile:
+		loop := p.pushLoweredLoop()
 		s.Test = p.visitExpr(s.Test)
 		s.Body = p.visitLoopBody(s.Body)
+		p.popLoweredLoop(loop)
 
 		if p.options.mangleSyntax {
 			s.Test = p.simplifyBooleanExpr(s.Test)
@@ -9260,14 +9829,24 @@ func (p *parser) visitAndAppendStmt(stmts []js_ast.Stmt, stmt js_ast.Stmt) []js_
 			stmt = js_ast.Stmt{Loc: stmt.Loc, Data: forS}
 		}
 
+		if loop != nil {
+			return p.lowerLoopWithCapturedBindings(stmts, stmt, loop)
+		}
+
 	case *js_ast.SDoWhile:
+		loop := p.pushLoweredLoop()
 		s.Body = p.visitLoopBody(s.Body)
 		s.Test = p.visitExpr(s.Test)
+		p.popLoweredLoop(loop)
 
 		if p.options.mangleSyntax {
 			s.Test = p.simplifyBooleanExpr(s.Test)
 		}
 
+		if loop != nil {
+			return p.lowerLoopWithCapturedBindings(stmts, stmt, loop)
+		}
+
 	case *js_ast.SIf:
 		s.Test = p.visitExpr(s.Test)
 
@@ -9313,9 +9892,19 @@ func (p *parser) visitAndAppendStmt(stmts []js_ast.Stmt, stmt js_ast.Stmt) []js_
 		}
 
 	case *js_ast.SFor:
+		loop := p.pushLoweredLoop()
 		p.pushScopeForVisitPass(js_ast.ScopeBlock, stmt.Loc)
 		if s.InitOrNil.Data != nil {
+			if loop != nil {
+				loop.isInsideInit = true
+			}
 			p.visitForLoopInit(s.InitOrNil, false)
+			if loop != nil {
+				loop.isInsideInit = false
+			}
+		}
+		if loop != nil {
+			loop.isInsideTestOrUpdate = true
 		}
 
 		if s.TestOrNil.Data != nil {
@@ -9334,6 +9923,9 @@ func (p *parser) visitAndAppendStmt(stmts []js_ast.Stmt, stmt js_ast.Stmt) []js_
 		if s.UpdateOrNil.Data != nil {
 			s.UpdateOrNil = p.visitExpr(s.UpdateOrNil)
 		}
+		if loop != nil {
+			loop.isInsideTestOrUpdate = false
+		}
 		s.Body = p.visitLoopBody(s.Body)
 
 		// Potentially relocate "var" declarations to the top level. Note that this
@@ -9351,12 +9943,18 @@ func (p *parser) visitAndAppendStmt(stmts []js_ast.Stmt, stmt js_ast.Stmt) []js_
 		}
 
 		p.popScope()
+		p.popLoweredLoop(loop)
 
 		if p.options.mangleSyntax {
 			mangleFor(s)
 		}
 
+		if loop != nil {
+			return p.lowerLoopWithCapturedBindings(stmts, stmt, loop)
+		}
+
 	case *js_ast.SForIn:
+		loop := p.pushLoweredLoop()
 		p.pushScopeForVisitPass(js_ast.ScopeBlock, stmt.Loc)
 		p.visitForLoopInit(s.Init, true)
 		s.Value = p.visitExpr(s.Value)
@@ -9386,10 +9984,19 @@ func (p *parser) visitAndAppendStmt(stmts []js_ast.Stmt, stmt js_ast.Stmt) []js_
 		}
 
 		p.popScope()
+		p.popLoweredLoop(loop)
 
 		p.lowerObjectRestInForLoopInit(s.Init, &s.Body)
 
+		if loop != nil {
+			return p.lowerLoopWithCapturedBindings(stmts, stmt, loop)
+		}
+
 	case *js_ast.SForOf:
+		if s.IsAwait {
+			p.markLoweredLoopsAsUnsupported("for await", logger.Range{Loc: stmt.Loc, Len: 3}, false /* isInheritedByArrows */)
+		}
+		loop := p.pushLoweredLoop()
 		p.pushScopeForVisitPass(js_ast.ScopeBlock, stmt.Loc)
 		p.visitForLoopInit(s.Init, true)
 		s.Value = p.visitExpr(s.Value)
@@ -9403,10 +10010,45 @@ func (p *parser) visitAndAppendStmt(stmts []js_ast.Stmt, stmt js_ast.Stmt) []js_
 			}
 		}
 
+		// "for (using x of y) z" => "for (const x of y) { __using(_stack, x); z }"
+		if init, ok := s.Init.Data.(*js_ast.SLocal); ok && init.Kind.IsUsing() && p.options.unsupportedJSFeatures.Has(compat.Using) {
+			ctx := p.lowerUsingDeclarationContext()
+			id := init.Decls[0].Binding.Data.(*js_ast.BIdentifier)
+			p.recordUsage(id.Ref)
+			value := js_ast.Expr{Loc: init.Decls[0].Binding.Loc, Data: &js_ast.EIdentifier{Ref: id.Ref}}
+			body := []js_ast.Stmt{{Loc: s.Body.Loc, Data: &js_ast.SExpr{Value: ctx.callUsing(p, value, init.Kind == js_ast.LocalAwaitUsing)}}}
+			if block, ok := s.Body.Data.(*js_ast.SBlock); ok {
+				body = append(body, block.Stmts...)
+			} else if _, ok := s.Body.Data.(*js_ast.SEmpty); !ok {
+				body = append(body, s.Body)
+			}
+			init.Kind = p.selectLocalKind(js_ast.LocalConst)
+			s.Body = js_ast.Stmt{Loc: s.Body.Loc, Data: &js_ast.SBlock{Stmts: ctx.finalize(p, s.Body.Loc, body)}}
+		}
+
 		p.popScope()
+		p.popLoweredLoop(loop)
 
 		p.lowerObjectRestInForLoopInit(s.Init, &s.Body)
 
+		isLowered := p.isLoweredForOfLoop(s)
+		if loop != nil {
+			stmts = p.lowerLoopWithCapturedBindings(stmts, stmt, loop)
+			if !isLowered {
+				return stmts
+			}
+			stmts = stmts[:len(stmts)-1]
+		}
+
+		// Lower "for-of" and "for await" loops into explicit calls to the
+		// iterator methods, or into an indexed loop if iterables are arrays
+		if isLowered {
+			if !s.IsAwait && p.options.assumeIterablesAreArrays {
+				return append(stmts, p.lowerForOfLoopAssumingArrays(stmt.Loc, s))
+			}
+			return append(stmts, p.lowerForOfLoop(stmt.Loc, s))
+		}
+
 	case *js_ast.STry:
 		p.pushScopeForVisitPass(js_ast.ScopeBlock, stmt.Loc)
 		p.fnOrArrowDataVisit.tryBodyCount++
@@ -9439,18 +10081,27 @@ func (p *parser) visitAndAppendStmt(stmts []js_ast.Stmt, stmt js_ast.Stmt) []js_
 		p.pushScopeForVisitPass(js_ast.ScopeBlock, s.BodyLoc)
 		oldIsInsideSwitch := p.fnOrArrowDataVisit.isInsideSwitch
 		p.fnOrArrowDataVisit.isInsideSwitch = true
+		if p.loweredLexicalBindings != nil && p.loweredSwitchCases == nil {
+			p.loweredSwitchCases = make(map[*js_ast.Scope]int)
+		}
 		for i, c := range s.Cases {
+			if p.loweredSwitchCases != nil {
+				p.loweredSwitchCases[p.currentScope] = i
+			}
 			if c.ValueOrNil.Data != nil {
 				c.ValueOrNil = p.visitExpr(c.ValueOrNil)
 				p.warnAboutEqualityCheck("case", c.ValueOrNil, c.ValueOrNil.Loc)
 				p.warnAboutTypeofAndString(s.Test, c.ValueOrNil)
 			}
-			c.Body = p.visitStmts(c.Body, stmtsNormal)
+			c.Body = p.visitStmts(c.Body, stmtsSwitch)
 
 			// Make sure the assignment to the body above is preserved
 			s.Cases[i] = c
 		}
 		p.fnOrArrowDataVisit.isInsideSwitch = oldIsInsideSwitch
+		if p.loweredSwitchCases != nil {
+			delete(p.loweredSwitchCases, p.currentScope)
+		}
 		p.popScope()
 
 		// Check for duplicate case values
@@ -9462,7 +10113,14 @@ func (p *parser) visitAndAppendStmt(stmts []js_ast.Stmt, stmt js_ast.Stmt) []js_
 		}
 
 	case *js_ast.SFunction:
+		oldEarlyCalledBindings := p.loweredEarlyCalledBindings
+		if s.Fn.Name != nil {
+			if bindings, ok := p.loweredEarlyCalls[s.Fn.Name.Ref]; ok {
+				p.loweredEarlyCalledBindings = bindings
+			}
+		}
 		p.visitFn(&s.Fn, s.Fn.OpenParenLoc)
+		p.loweredEarlyCalledBindings = oldEarlyCalledBindings
 
 		// Handle exporting this function from a namespace
 		if s.IsExport && p.enclosingNamespaceArgRef != nil {
@@ -9486,7 +10144,7 @@ func (p *parser) visitAndAppendStmt(stmts []js_ast.Stmt, stmt js_ast.Stmt) []js_
 		return stmts
 
 	case *js_ast.SClass:
-		shadowRef := p.visitClass(stmt.Loc, &s.Class)
+		result := p.visitClass(stmt.Loc, &s.Class)
 
 		// Remove the export flag inside a namespace
 		wasExportInsideNamespace := s.IsExport && p.enclosingNamespaceArgRef != nil
@@ -9495,7 +10153,7 @@ func (p *parser) visitAndAppendStmt(stmts []js_ast.Stmt, stmt js_ast.Stmt) []js_
 		}
 
 		// Lower class field syntax for browsers that don't support it
-		classStmts, _ := p.lowerClass(stmt, js_ast.Expr{}, shadowRef)
+		classStmts, _ := p.lowerClass(stmt, js_ast.Expr{}, result)
 		stmts = append(stmts, classStmts...)
 
 		// Handle exporting this class from a namespace
@@ -9513,6 +10171,12 @@ func (p *parser) visitAndAppendStmt(stmts []js_ast.Stmt, stmt js_ast.Stmt) []js_
 		return stmts
 
 	case *js_ast.SEnum:
+		// Track cross-module enum constants during bundling
+		var tsTopLevelEnumValues map[string]js_ast.TSEnumValue
+		if p.currentScope == p.moduleScope && p.options.mode == config.ModeBundle {
+			tsTopLevelEnumValues = make(map[string]js_ast.TSEnumValue)
+		}
+
 		p.recordDeclaredSymbol(s.Name.Ref)
 		p.pushScopeForVisitPass(js_ast.ScopeEntry, stmt.Loc)
 		p.recordDeclaredSymbol(s.Arg)
@@ -9534,6 +10198,7 @@ func (p *parser) visitAndAppendStmt(stmts []js_ast.Stmt, stmt js_ast.Stmt) []js_
 		hasNumericValue := true
 		valueExprs := []js_ast.Expr{}
 		allValuesArePure := true
+		allValuesAreConstant := true
 
 		// Update the exported members of this enum as we constant fold each one
 		exportedMembers := p.currentScope.TSNamespace.ExportedMembers
@@ -9562,6 +10227,9 @@ func (p *parser) visitAndAppendStmt(stmts []js_ast.Stmt, stmt js_ast.Stmt) []js_
 					p.refToTSNamespaceMemberData[value.Ref] = member.Data
 					hasNumericValue = true
 					nextNumericValue = e.Value + 1
+					if tsTopLevelEnumValues != nil {
+						tsTopLevelEnumValues[name] = js_ast.TSEnumValue{Number: e.Value}
+					}
 
 				case *js_ast.EString:
 					member := exportedMembers[name]
@@ -9569,8 +10237,12 @@ func (p *parser) visitAndAppendStmt(stmts []js_ast.Stmt, stmt js_ast.Stmt) []js_
 					exportedMembers[name] = member
 					p.refToTSNamespaceMemberData[value.Ref] = member.Data
 					hasStringValue = true
+					if tsTopLevelEnumValues != nil {
+						tsTopLevelEnumValues[name] = js_ast.TSEnumValue{String: e.Value}
+					}
 
 				default:
+					allValuesAreConstant = false
 					if !p.exprCanBeRemovedIfUnused(value.ValueOrNil) {
 						allValuesArePure = false
 					}
@@ -9581,9 +10253,13 @@ func (p *parser) visitAndAppendStmt(stmts []js_ast.Stmt, stmt js_ast.Stmt) []js_
 				exportedMembers[name] = member
 				p.refToTSNamespaceMemberData[value.Ref] = member.Data
 				value.ValueOrNil = js_ast.Expr{Loc: value.Loc, Data: &js_ast.ENumber{Value: nextNumericValue}}
+				if tsTopLevelEnumValues != nil {
+					tsTopLevelEnumValues[name] = js_ast.TSEnumValue{Number: nextNumericValue}
+				}
 				nextNumericValue++
 			} else {
 				value.ValueOrNil = js_ast.Expr{Loc: value.Loc, Data: js_ast.EUndefinedShared}
+				allValuesAreConstant = false
 			}
 
 			if p.options.mangleSyntax && js_lexer.IsIdentifier(name) {
@@ -9627,9 +10303,52 @@ func (p *parser) visitAndAppendStmt(stmts []js_ast.Stmt, stmt js_ast.Stmt) []js_
 		p.popScope()
 		p.shouldFoldNumericConstants = oldShouldFoldNumericConstants
 
+		// Follow the link chain in case symbols were merged
+		nameRef := s.Name.Ref
+		for link := p.symbols[nameRef.InnerIndex].Link; link != js_ast.InvalidRef; link = p.symbols[nameRef.InnerIndex].Link {
+			nameRef = link
+		}
+		useCountBefore := p.symbols[nameRef.InnerIndex].UseCountEstimate
+
+		// Track all top-level enums for cross-module inlining. Sibling enum
+		// declarations with the same name all contribute to the same map.
+		if tsTopLevelEnumValues != nil {
+			if p.tsEnums == nil {
+				p.tsEnums = make(map[js_ast.Ref]map[string]js_ast.TSEnumValue)
+			}
+			if values, ok := p.tsEnums[nameRef]; ok {
+				for name, value := range tsTopLevelEnumValues {
+					values[name] = value
+				}
+			} else {
+				p.tsEnums[nameRef] = tsTopLevelEnumValues
+			}
+		}
+
 		// Wrap this enum definition in a closure
+		oldLen := len(stmts)
 		stmts = p.generateClosureForTypeScriptEnum(
 			stmts, stmt.Loc, s.IsExport, s.Name.Loc, s.Name.Ref, s.Arg, valueExprs, allValuesArePure)
+
+		// Const enums are erased like TypeScript does, since all references to
+		// their members are inlined. This is only safe if every member is a
+		// constant and nothing references the enum object itself. Later code in
+		// the same scope may still do that, so the decision is deferred until the
+		// whole scope has been visited. Const enums that are exported are kept so
+		// that other files can still use them, although when bundling those
+		// references will be inlined and the enum object will then be removed by
+		// tree shaking.
+		if s.IsConst && !s.IsExport && allValuesAreConstant && useCountBefore == 0 {
+			erasable := erasableConstEnum{
+				scope:    p.currentScope,
+				ref:      nameRef,
+				useCount: p.symbols[nameRef.InnerIndex].UseCountEstimate,
+			}
+			for _, stmt := range stmts[oldLen:] {
+				erasable.stmts = append(erasable.stmts, stmt.Data)
+			}
+			p.erasableConstEnums = append(p.erasableConstEnums, erasable)
+		}
 		return stmts
 
 	case *js_ast.SNamespace:
@@ -9909,15 +10628,23 @@ func (p *parser) captureValueWithPossibleSideEffects(
 	}, wrapFunc
 }
 
-func (p *parser) visitTSDecorators(tsDecorators []js_ast.Expr) []js_ast.Expr {
-	for i, decorator := range tsDecorators {
-		tsDecorators[i] = p.visitExpr(decorator)
+func (p *parser) visitDecorators(decorators []js_ast.Expr) []js_ast.Expr {
+	for i, decorator := range decorators {
+		decorators[i] = p.visitExpr(decorator)
 	}
-	return tsDecorators
+	return decorators
 }
 
-func (p *parser) visitClass(nameScopeLoc logger.Loc, class *js_ast.Class) js_ast.Ref {
-	class.TSDecorators = p.visitTSDecorators(class.TSDecorators)
+type visitClassResult struct {
+	shadowRef js_ast.Ref
+
+	// These are only used when classes are lowered to ES5 constructor functions
+	loweredClass           *loweredClassMember
+	instanceThisCaptureRef js_ast.Ref
+}
+
+func (p *parser) visitClass(nameScopeLoc logger.Loc, class *js_ast.Class) (result visitClassResult) {
+	class.Decorators = p.visitDecorators(class.Decorators)
 
 	if class.Name != nil {
 		p.recordDeclaredSymbol(class.Name.Ref)
@@ -9981,8 +10708,13 @@ func (p *parser) visitClass(nameScopeLoc logger.Loc, class *js_ast.Class) js_ast
 	}
 
 	classNameRef := js_ast.InvalidRef
+	lowerToES5 := p.options.unsupportedJSFeatures.Has(compat.Class)
 	if class.Name != nil {
 		classNameRef = class.Name.Ref
+	} else if lowerToES5 {
+		// Generate a name if one doesn't already exist. This is necessary for
+		// referencing the class from inside the lowered class members.
+		classNameRef = p.newSymbol(js_ast.SymbolOther, "_class")
 	} else if classLoweringInfo.lowerAllStaticFields {
 		// Generate a name if one doesn't already exist. This is necessary for
 		// handling "this" in static class property initializers.
@@ -10006,6 +10738,23 @@ func (p *parser) visitClass(nameScopeLoc logger.Loc, class *js_ast.Class) js_ast
 		}
 	}
 
+	// Class members that are lowered to ES5 reference the class and the base
+	// class using the wrapper function around the lowered class
+	var loweredClass *loweredClassMember
+	instanceThisCaptureRef := js_ast.InvalidRef
+	if lowerToES5 {
+		loweredClass = &loweredClassMember{
+			classRef:     shadowRef,
+			superCtorRef: js_ast.InvalidRef,
+			ctorThisRef:  js_ast.InvalidRef,
+		}
+		if class.ExtendsOrNil.Data != nil {
+			loweredClass.superCtorRef = p.newSymbol(js_ast.SymbolOther, "_super")
+			loweredClass.ctorThisRef = p.newSymbol(js_ast.SymbolOther, "_this")
+			p.currentScope.Generated = append(p.currentScope.Generated, loweredClass.superCtorRef, loweredClass.ctorThisRef)
+		}
+	}
+
 	if class.ExtendsOrNil.Data != nil {
 		class.ExtendsOrNil = p.visitExpr(class.ExtendsOrNil)
 	}
@@ -10015,6 +10764,8 @@ func (p *parser) visitClass(nameScopeLoc logger.Loc, class *js_ast.Class) js_ast
 	defer p.popScope()
 
 	end := 0
+	var tsConstructorArgs []js_ast.Arg
+	hasTSConstructor := false
 
 	for i := range class.Properties {
 		property := &class.Properties[i]
@@ -10036,6 +10787,9 @@ func (p *parser) visitClass(nameScopeLoc logger.Loc, class *js_ast.Class) js_ast
 				// Need to lower "super" since it won't be valid outside the class body
 				p.fnOrArrowDataVisit.shouldLowerSuper = true
 			}
+			if loweredClass != nil {
+				p.fnOnlyDataVisit.loweredClassMember = loweredClass.forMember(true /* isStatic */, false /* isCtor */)
+			}
 
 			p.pushScopeForVisitPass(js_ast.ScopeClassStaticInit, property.ClassStaticBlock.Loc)
 
@@ -10059,9 +10813,39 @@ func (p *parser) visitClass(nameScopeLoc logger.Loc, class *js_ast.Class) js_ast
 			continue
 		}
 
-		property.TSDecorators = p.visitTSDecorators(property.TSDecorators)
+		property.Decorators = p.visitDecorators(property.Decorators)
 		private, isPrivate := property.Key.Data.(*js_ast.EPrivateIdentifier)
 
+		// TypeScript parameter decorators are merged with the decorators of the
+		// method after it has been visited. Parameter decorators on the constructor
+		// are merged with the decorators of the class instead. Decorator metadata
+		// goes after the parameter decorators, but it must be generated before the
+		// method is visited since lowering may change the function.
+		var tsParamDecoratorArgs []js_ast.Arg
+		var tsDecoratorMetadata []js_ast.Expr
+		isTSConstructor := false
+		if p.options.ts.Parse && p.isUsingLegacyDecorators() {
+			hasParamDecorators := false
+			if fn, ok := property.ValueOrNil.Data.(*js_ast.EFunction); ok && property.IsMethod {
+				if str, ok := property.Key.Data.(*js_ast.EString); ok {
+					isTSConstructor = js_lexer.UTF16EqualsString(str.Value, "constructor")
+				}
+				tsParamDecoratorArgs = fn.Fn.Args
+				for _, arg := range fn.Fn.Args {
+					if len(arg.TSDecorators) > 0 {
+						hasParamDecorators = true
+					}
+				}
+				if isTSConstructor && p.options.emitDecoratorMetadata == config.True {
+					tsConstructorArgs = fn.Fn.Args
+					hasTSConstructor = true
+				}
+			}
+			if p.options.emitDecoratorMetadata == config.True && !isTSConstructor && (len(property.Decorators) > 0 || hasParamDecorators) {
+				tsDecoratorMetadata = p.tsDecoratorMetadataForProperty(property)
+			}
+		}
+
 		// Special-case EPrivateIdentifier to allow it here
 		if isPrivate {
 			p.recordDeclaredSymbol(private.Ref)
@@ -10100,6 +10884,7 @@ func (p *parser) visitClass(nameScopeLoc logger.Loc, class *js_ast.Class) js_ast
 		oldIsThisCaptured := p.fnOnlyDataVisit.isThisNested
 		oldThis := p.fnOnlyDataVisit.thisClassStaticRef
 		oldShouldLowerSuper := p.fnOrArrowDataVisit.shouldLowerSuper
+		oldLoweredClassMember := p.fnOnlyDataVisit.loweredClassMember
 		p.fnOnlyDataVisit.isThisNested = true
 		p.fnOnlyDataVisit.isNewTargetAllowed = true
 		p.fnOnlyDataVisit.thisClassStaticRef = nil
@@ -10119,12 +10904,20 @@ func (p *parser) visitClass(nameScopeLoc logger.Loc, class *js_ast.Class) js_ast
 		}
 
 		if property.ValueOrNil.Data != nil {
+			if loweredClass != nil {
+				isCtor := false
+				if str, ok := property.Key.Data.(*js_ast.EString); ok && !property.IsStatic && !property.IsComputed {
+					isCtor = js_lexer.UTF16EqualsString(str.Value, "constructor")
+				}
+				p.nextLoweredClassMember = loweredClass.forMember(property.IsStatic, isCtor)
+			}
 			if nameToKeep != "" {
 				wasAnonymousNamedExpr := p.isAnonymousNamedExpr(property.ValueOrNil)
 				property.ValueOrNil = p.maybeKeepExprSymbolName(p.visitExpr(property.ValueOrNil), nameToKeep, wasAnonymousNamedExpr)
 			} else {
 				property.ValueOrNil = p.visitExpr(property.ValueOrNil)
 			}
+			p.nextLoweredClassMember = nil
 		}
 
 		if property.InitializerOrNil.Data != nil {
@@ -10135,27 +10928,74 @@ func (p *parser) visitClass(nameScopeLoc logger.Loc, class *js_ast.Class) js_ast
 				// Need to lower "super" since it won't be valid outside the class body
 				p.fnOrArrowDataVisit.shouldLowerSuper = true
 			}
+
+			// Field initializers of classes lowered to ES5 end up either in the
+			// constructor or after the class, so they are visited like they are in
+			// a separate function. Instance field initializers share a single
+			// captured "this" that is declared in the constructor.
+			oldFnOrArrowData := p.fnOrArrowDataVisit
+			oldThisCaptureRef := p.fnOnlyDataVisit.thisCaptureRef
+			if loweredClass != nil {
+				p.fnOrArrowDataVisit = fnOrArrowDataVisit{shouldLowerSuper: true}
+				p.fnOnlyDataVisit.loweredClassMember = loweredClass.forMember(property.IsStatic, !property.IsStatic)
+				p.fnOnlyDataVisit.thisCaptureRef = nil
+				if !property.IsStatic && instanceThisCaptureRef != js_ast.InvalidRef {
+					p.fnOnlyDataVisit.thisCaptureRef = &instanceThisCaptureRef
+				}
+			}
+
 			if nameToKeep != "" {
 				wasAnonymousNamedExpr := p.isAnonymousNamedExpr(property.InitializerOrNil)
 				property.InitializerOrNil = p.maybeKeepExprSymbolName(p.visitExpr(property.InitializerOrNil), nameToKeep, wasAnonymousNamedExpr)
 			} else {
 				property.InitializerOrNil = p.visitExpr(property.InitializerOrNil)
 			}
+
+			if loweredClass != nil {
+				if ref := p.fnOnlyDataVisit.thisCaptureRef; ref != nil && !property.IsStatic {
+					instanceThisCaptureRef = *ref
+				}
+				p.fnOrArrowDataVisit = oldFnOrArrowData
+				p.fnOnlyDataVisit.thisCaptureRef = oldThisCaptureRef
+			}
 		}
 
 		// Restore "this" so it will take the inherited value in property keys
 		p.fnOnlyDataVisit.thisClassStaticRef = oldThis
 		p.fnOnlyDataVisit.isThisNested = oldIsThisCaptured
 		p.fnOrArrowDataVisit.shouldLowerSuper = oldShouldLowerSuper
+		p.fnOnlyDataVisit.loweredClassMember = oldLoweredClassMember
 
 		// Restore the ability to use "arguments" in decorators and computed properties
 		p.currentScope.ForbidArguments = false
 
+		// Generate a call to "__decorateParam()" for each parameter decorator
+		for i, arg := range tsParamDecoratorArgs {
+			for _, decorator := range arg.TSDecorators {
+				decorators := &property.Decorators
+				if isTSConstructor {
+					decorators = &class.Decorators
+				}
+				*decorators = append(*decorators,
+					p.callRuntime(decorator.Loc, "__decorateParam", []js_ast.Expr{
+						{Loc: decorator.Loc, Data: &js_ast.ENumber{Value: float64(i)}},
+						decorator,
+					}),
+				)
+			}
+		}
+		property.Decorators = append(property.Decorators, tsDecoratorMetadata...)
+
 		// Keep this property
 		class.Properties[end] = *property
 		end++
 	}
 
+	// A decorated class gets the parameter types of its constructor
+	if hasTSConstructor && len(class.Decorators) > 0 {
+		class.Decorators = append(class.Decorators, p.tsMetadataParamTypes(class.BodyLoc, tsConstructorArgs))
+	}
+
 	// Finish the filtering operation
 	class.Properties = class.Properties[:end]
 
@@ -10176,7 +11016,19 @@ func (p *parser) visitClass(nameScopeLoc logger.Loc, class *js_ast.Class) js_ast
 		}
 	}
 
-	return shadowRef
+	result.shadowRef = shadowRef
+	result.loweredClass = loweredClass
+	result.instanceThisCaptureRef = instanceThisCaptureRef
+	return
+}
+
+func (class *loweredClassMember) forMember(isStatic bool, isCtor bool) *loweredClassMember {
+	member := *class
+	member.isStatic = isStatic
+	if !isCtor {
+		member.ctorThisRef = js_ast.InvalidRef
+	}
+	return &member
 }
 
 func isSimpleParameterList(args []js_ast.Arg, hasRestArg bool) bool {
@@ -10238,7 +11090,7 @@ func (p *parser) visitArgs(args []js_ast.Arg, opts visitArgsOpts) {
 
 	for i := range args {
 		arg := &args[i]
-		arg.TSDecorators = p.visitTSDecorators(arg.TSDecorators)
+		arg.TSDecorators = p.visitDecorators(arg.TSDecorators)
 		p.visitBinding(arg.Binding, bindingOpts{
 			duplicateArgCheck: duplicateArgCheck,
 		})
@@ -10899,6 +11751,13 @@ func (p *parser) valueForThis(
 		return js_ast.Expr{Loc: loc, Data: &js_ast.EIdentifier{Ref: *p.fnOnlyDataVisit.thisClassStaticRef}}, true
 	}
 
+	// Substitute "this" if we're inside the constructor of a derived class that
+	// is being lowered to ES5, since "this" is the result of the "super()" call
+	if member := p.fnOnlyDataVisit.loweredClassMember; member != nil && member.ctorThisRef != js_ast.InvalidRef {
+		p.recordUsage(member.ctorThisRef)
+		return js_ast.Expr{Loc: loc, Data: &js_ast.EIdentifier{Ref: member.ctorThisRef}}, true
+	}
+
 	// Is this a top-level use of "this"?
 	if !p.fnOnlyDataVisit.isThisNested {
 		// Substitute user-specified defines
@@ -11094,14 +11953,19 @@ func (p *parser) visitExprInOut(expr js_ast.Expr, in exprIn) (js_ast.Expr, exprO
 	}
 
 	switch e := expr.Data.(type) {
-	case *js_ast.ENull, *js_ast.ESuper,
-		*js_ast.EBoolean, *js_ast.EBigInt,
-		*js_ast.ERegExp, *js_ast.EUndefined:
+	case *js_ast.ENull, *js_ast.EBoolean, *js_ast.EBigInt, *js_ast.EUndefined:
+
+	case *js_ast.ERegExp:
+		return p.lowerRegExp(expr.Loc, e), exprOut{}
+
+	case *js_ast.ESuper:
+		p.markLoweredLoopsAsUnsupported("super", logger.Range{Loc: expr.Loc, Len: 5}, true /* isInheritedByArrows */)
 
 	case *js_ast.ENewTarget:
 		if !p.fnOnlyDataVisit.isNewTargetAllowed {
 			p.log.Add(logger.Error, &p.tracker, e.Range, "Cannot use \"new.target\" here:")
 		}
+		p.markLoweredLoopsAsUnsupported("new.target", e.Range, true /* isInheritedByArrows */)
 
 	case *js_ast.EString:
 		if e.LegacyOctalLoc.Start > 0 {
@@ -11134,6 +11998,9 @@ func (p *parser) visitExprInOut(expr js_ast.Expr, in exprIn) (js_ast.Expr, exprO
 			return js_ast.Expr{Loc: expr.Loc, Data: &js_ast.EIdentifier{Ref: p.captureThis()}}, exprOut{}
 		}
 
+		// Loop bodies that are moved into a function must be called with "this"
+		p.markLoweredLoopsAsUsingThis()
+
 	case *js_ast.EImportMeta:
 		isDeleteTarget := e == p.deleteTarget
 		isCallTarget := e == p.callTarget
@@ -11174,6 +12041,13 @@ func (p *parser) visitExprInOut(expr js_ast.Expr, in exprIn) (js_ast.Expr, exprO
 		e.MustKeepDueToWithStmt = result.isInsideWithScope
 		e.Ref = result.ref
 
+		// Reading a lowered "let" or "const" binding before it's initialized throws
+		if binding, ok := p.loweredLexicalBindings[result.ref]; ok {
+			if p.visitLoweredLexicalReference(expr.Loc, result.ref, binding, in.assignTarget) && in.assignTarget == js_ast.AssignTargetNone {
+				return p.lowerLoweredLexicalTDZ(expr.Loc, result.ref), exprOut{}
+			}
+		}
+
 		// Handle assigning to a constant
 		if in.assignTarget != js_ast.AssignTargetNone {
 			switch p.symbols[result.ref.InnerIndex].Kind {
@@ -11445,6 +12319,13 @@ func (p *parser) visitExprInOut(expr js_ast.Expr, in exprIn) (js_ast.Expr, exprO
 			e.Right = p.visitExpr(e.Right)
 		}
 
+		// Assigning to a lowered "const" binding throws
+		if p.loweredLexicalBindings != nil && e.Op.BinaryAssignTarget() != js_ast.AssignTargetNone {
+			if result, ok := p.lowerAssignToLoweredLexical(expr.Loc, e); ok {
+				return result, exprOut{}
+			}
+		}
+
 		// Always put constants on the right for equality comparisons to help
 		// reduce the number of cases we have to check during pattern matching. We
 		// can only reorder expressions that do not have any side effects.
@@ -11946,6 +12827,11 @@ func (p *parser) visitExprInOut(expr js_ast.Expr, in exprIn) (js_ast.Expr, exprO
 			e.Index = p.visitExpr(e.Index)
 		}
 
+		// "declare const enum Foo { A }" followed by "Foo['A']"
+		if p.options.isolatedModules {
+			p.warnAboutAmbientConstEnumAccess(e.Target)
+		}
+
 		// Lower "super[prop]" if necessary
 		if e.OptionalChain == js_ast.OptionalChainNone && in.assignTarget == js_ast.AssignTargetNone &&
 			!isCallTarget && p.shouldLowerSuperPropertyAccess(e.Target) {
@@ -11975,6 +12861,9 @@ func (p *parser) visitExprInOut(expr js_ast.Expr, in exprIn) (js_ast.Expr, exprO
 				e.Target, js_lexer.UTF16ToString(str.Value), e.Index.Loc, isCallTarget, preferQuotedKey); ok {
 				return value, out
 			}
+			if in.assignTarget == js_ast.AssignTargetNone && !isDeleteTarget && !isCallTarget {
+				e.CanBeInlinedIfEnum = p.recordImportSymbolPropertyUse(e.Target, js_lexer.UTF16ToString(str.Value))
+			}
 		}
 
 		// Create an error for assigning to an import namespace when bundling. Even
@@ -12072,6 +12961,14 @@ func (p *parser) visitExprInOut(expr js_ast.Expr, in exprIn) (js_ast.Expr, exprO
 		default:
 			e.Value, _ = p.visitExprInOut(e.Value, exprIn{assignTarget: e.Op.UnaryAssignTarget()})
 
+			// Assigning to a lowered "const" binding throws
+			if p.loweredLexicalBindings != nil && e.Op.UnaryAssignTarget() != js_ast.AssignTargetNone {
+				if throwExpr, _, ok := p.throwForLoweredLexicalAssign(e.Value); ok {
+					// "x++" => "__constAssign('x')"
+					return throwExpr, exprOut{}
+				}
+			}
+
 			// Post-process the unary expression
 			switch e.Op {
 			case js_ast.UnOpNot:
@@ -12179,6 +13076,11 @@ func (p *parser) visitExprInOut(expr js_ast.Expr, in exprIn) (js_ast.Expr, exprO
 		})
 		e.Target = target
 
+		// "declare const enum Foo { A }" followed by "Foo.A"
+		if p.options.isolatedModules {
+			p.warnAboutAmbientConstEnumAccess(e.Target)
+		}
+
 		// Lower "super.prop" if necessary
 		if e.OptionalChain == js_ast.OptionalChainNone && in.assignTarget == js_ast.AssignTargetNone &&
 			!isCallTarget && p.shouldLowerSuperPropertyAccess(e.Target) {
@@ -12208,6 +13110,9 @@ func (p *parser) visitExprInOut(expr js_ast.Expr, in exprIn) (js_ast.Expr, exprO
 				isDeleteTarget, e.Target, e.Name, e.NameLoc, isCallTarget, false); ok {
 				return value, out
 			}
+			if in.assignTarget == js_ast.AssignTargetNone && !isDeleteTarget && !isCallTarget {
+				e.CanBeInlinedIfEnum = p.recordImportSymbolPropertyUse(e.Target, e.Name)
+			}
 		}
 		return js_ast.Expr{Loc: expr.Loc, Data: e}, out
 
@@ -12279,17 +13184,27 @@ func (p *parser) visitExprInOut(expr js_ast.Expr, in exprIn) (js_ast.Expr, exprO
 		}
 
 	case *js_ast.EAwait:
+		p.markLoweredLoopsAsUnsupported("await", logger.Range{Loc: expr.Loc, Len: 5}, false /* isInheritedByArrows */)
 		p.awaitTarget = e.Value.Data
 		e.Value = p.visitExpr(e.Value)
 
 		// "await" expressions turn into "yield" expressions when lowering
+		if p.isInsideLoweredAsyncGenerator() {
+			return p.lowerAwaitInAsyncGenerator(expr.Loc, e.Value), exprOut{}
+		}
 		if p.options.unsupportedJSFeatures.Has(compat.AsyncAwait) {
 			return js_ast.Expr{Loc: expr.Loc, Data: &js_ast.EYield{ValueOrNil: e.Value}}, exprOut{}
 		}
 
 	case *js_ast.EYield:
+		p.markLoweredLoopsAsUnsupported("yield", logger.Range{Loc: expr.Loc, Len: 5}, false /* isInheritedByArrows */)
 		if e.ValueOrNil.Data != nil {
 			e.ValueOrNil = p.visitExpr(e.ValueOrNil)
+
+			// "yield* x" => "yield* __yieldStar(x)" when lowering async generators
+			if e.IsStar && p.isInsideLoweredAsyncGenerator() {
+				e.ValueOrNil = p.callRuntime(e.ValueOrNil.Loc, "__yieldStar", []js_ast.Expr{e.ValueOrNil})
+			}
 		}
 
 	case *js_ast.EArray:
@@ -12297,7 +13212,6 @@ func (p *parser) visitExprInOut(expr js_ast.Expr, in exprIn) (js_ast.Expr, exprO
 			if e.CommaAfterSpread.Start != 0 {
 				p.log.Add(logger.Error, &p.tracker, logger.Range{Loc: e.CommaAfterSpread, Len: 1}, "Unexpected \",\" after rest pattern")
 			}
-			p.markSyntaxFeature(compat.Destructuring, logger.Range{Loc: expr.Loc, Len: 1})
 		}
 		hasSpread := false
 		for i, item := range e.Items {
@@ -12336,7 +13250,6 @@ func (p *parser) visitExprInOut(expr js_ast.Expr, in exprIn) (js_ast.Expr, exprO
 			if e.CommaAfterSpread.Start != 0 {
 				p.log.Add(logger.Error, &p.tracker, logger.Range{Loc: e.CommaAfterSpread, Len: 1}, "Unexpected \",\" after rest pattern")
 			}
-			p.markSyntaxFeature(compat.Destructuring, logger.Range{Loc: expr.Loc, Len: 1})
 		}
 		hasSpread := false
 		protoRange := logger.Range{}
@@ -12493,7 +13406,7 @@ func (p *parser) visitExprInOut(expr js_ast.Expr, in exprIn) (js_ast.Expr, exprO
 		isThenCatchTarget := e == p.thenCatchChain.nextTarget && p.thenCatchChain.hasCatch
 		e.Expr = p.visitExpr(e.Expr)
 
-		var assertions *[]ast.AssertEntry
+		var assertOrWith *ast.ImportAssertOrWith
 		if e.OptionsOrNil.Data != nil {
 			e.OptionsOrNil = p.visitExpr(e.OptionsOrNil)
 
@@ -12506,18 +13419,24 @@ func (p *parser) visitExprInOut(expr js_ast.Expr, in exprIn) (js_ast.Expr, exprO
 			whyLoc := e.OptionsOrNil.Loc
 
 			// However, make a special case for an additional argument that contains
-			// only an "assert" clause. In that case we can split this AST node.
+			// only an "assert" or "with" clause. In that case we can split this AST
+			// node.
 			if object, ok := e.OptionsOrNil.Data.(*js_ast.EObject); ok {
 				if len(object.Properties) == 1 {
 					if prop := object.Properties[0]; prop.Kind == js_ast.PropertyNormal && !prop.IsComputed && !prop.IsMethod {
-						if str, ok := prop.Key.Data.(*js_ast.EString); ok && js_lexer.UTF16EqualsString(str.Value, "assert") {
+						if str, ok := prop.Key.Data.(*js_ast.EString); ok && (js_lexer.UTF16EqualsString(str.Value, "assert") ||
+							js_lexer.UTF16EqualsString(str.Value, "with")) {
+							keyword := ast.WithKeyword
+							if js_lexer.UTF16EqualsString(str.Value, "assert") {
+								keyword = ast.AssertKeyword
+							}
 							if value, ok := prop.ValueOrNil.Data.(*js_ast.EObject); ok {
-								entries := []ast.AssertEntry{}
+								entries := []ast.AssertOrWithEntry{}
 								for _, p := range value.Properties {
 									if p.Kind == js_ast.PropertyNormal && !p.IsComputed && !p.IsMethod {
 										if key, ok := p.Key.Data.(*js_ast.EString); ok {
 											if value, ok := p.ValueOrNil.Data.(*js_ast.EString); ok {
-												entries = append(entries, ast.AssertEntry{
+												entries = append(entries, ast.AssertOrWithEntry{
 													Key:             key.Value,
 													KeyLoc:          p.Key.Loc,
 													Value:           value.Value,
@@ -12542,15 +13461,19 @@ func (p *parser) visitExprInOut(expr js_ast.Expr, in exprIn) (js_ast.Expr, exprO
 									break
 								}
 								if entries != nil {
-									assertions = &entries
+									assertOrWith = &ast.ImportAssertOrWith{
+										Entries:    entries,
+										KeywordLoc: prop.Key.Loc,
+										Keyword:    keyword,
+									}
 									why = ""
 								}
 							} else {
-								why = "the value for \"assert\" was not an object literal"
+								why = fmt.Sprintf("the value for %q was not an object literal", keyword.String())
 								whyLoc = prop.ValueOrNil.Loc
 							}
 						} else {
-							why = "this property was not called \"assert\""
+							why = "this property was not called \"assert\" or \"with\""
 							whyLoc = prop.Key.Loc
 						}
 					} else {
@@ -12558,7 +13481,7 @@ func (p *parser) visitExprInOut(expr js_ast.Expr, in exprIn) (js_ast.Expr, exprO
 						whyLoc = prop.Key.Loc
 					}
 				} else {
-					why = "the second argument was not an object literal with a single property called \"assert\""
+					why = "the second argument was not an object literal with a single property called \"assert\" or \"with\""
 					whyLoc = e.OptionsOrNil.Loc
 				}
 			}
@@ -12575,12 +13498,12 @@ func (p *parser) visitExprInOut(expr js_ast.Expr, in exprIn) (js_ast.Expr, exprO
 					p.log.Add(kind, &p.tracker, logger.Range{Loc: whyLoc}, text)
 				}
 
-				// If import assertions aren't supported in the target platform, keeping
-				// them would be a syntax error so we need to get rid of them. We can't
-				// just not print them because they may have important side effects.
-				// Attempt to discard them without changing side effects and generate an
-				// error if that isn't possible.
-				if p.options.unsupportedJSFeatures.Has(compat.ImportAssertions) {
+				// If import assertions and import attributes aren't supported in the
+				// target platform, keeping them would be a syntax error so we need to
+				// get rid of them. We can't just not print them because they may have
+				// important side effects. Attempt to discard them without changing side
+				// effects and generate an error if that isn't possible.
+				if p.options.unsupportedJSFeatures.Has(compat.ImportAssertions) && p.options.unsupportedJSFeatures.Has(compat.ImportAttributes) {
 					if p.exprCanBeRemovedIfUnused(e.OptionsOrNil) {
 						e.OptionsOrNil = js_ast.Expr{}
 					} else {
@@ -12604,7 +13527,7 @@ func (p *parser) visitExprInOut(expr js_ast.Expr, in exprIn) (js_ast.Expr, exprO
 					return js_ast.Expr{Loc: arg.Loc, Data: js_ast.ENullShared}
 				}
 
-				importRecordIndex := p.addImportRecord(ast.ImportDynamic, arg.Loc, js_lexer.UTF16ToString(str.Value), assertions)
+				importRecordIndex := p.addImportRecord(ast.ImportDynamic, arg.Loc, js_lexer.UTF16ToString(str.Value), assertOrWith)
 				p.importRecords[importRecordIndex].HandlesImportErrors = (isAwaitTarget && p.fnOrArrowDataVisit.tryBodyCount != 0) || isThenCatchTarget
 				p.importRecordsForCurrentPart = append(p.importRecordsForCurrentPart, importRecordIndex)
 				return js_ast.Expr{Loc: expr.Loc, Data: &js_ast.EImportString{
@@ -12706,6 +13629,9 @@ func (p *parser) visitExprInOut(expr js_ast.Expr, in exprIn) (js_ast.Expr, exprO
 		})
 		e.Target = target
 		p.warnAboutImportNamespaceCall(e.Target, exprKindCall)
+		if p.loweredLexicalBindings != nil {
+			p.recordLoweredLexicalEarlyCall(e.Target)
+		}
 
 		hasSpread := false
 		for i, arg := range e.Args {
@@ -12843,6 +13769,19 @@ func (p *parser) visitExprInOut(expr js_ast.Expr, in exprIn) (js_ast.Expr, exprO
 			p.maybeLowerSuperPropertyGetInsideCall(e)
 		}
 
+		// Lower "super()" inside constructors of classes lowered to ES5
+		if _, ok := e.Target.Data.(*js_ast.ESuper); ok {
+			if member := p.fnOnlyDataVisit.loweredClassMember; member != nil && member.ctorThisRef != js_ast.InvalidRef {
+				// Capture "this" inside arrow functions that will be lowered
+				thisExpr := js_ast.Expr{Loc: expr.Loc, Data: js_ast.EThisShared}
+				if p.fnOrArrowDataVisit.isArrow && p.options.unsupportedJSFeatures.Has(compat.Arrow) {
+					thisExpr.Data = &js_ast.EIdentifier{Ref: p.captureThis()}
+				}
+				args := js_ast.Expr{Loc: expr.Loc, Data: &js_ast.EArray{Items: e.Args, IsSingleLine: true}}
+				return p.lowerSuperCall(expr.Loc, member, thisExpr, args), exprOut{}
+			}
+		}
+
 		// Track calls to require() so we can use them while bundling
 		if p.options.mode != config.ModePassThrough && e.OptionalChain == js_ast.OptionalChainNone {
 			if id, ok := e.Target.Data.(*js_ast.EIdentifier); ok && id.Ref == p.requireRef {
@@ -12966,7 +13905,7 @@ func (p *parser) visitExprInOut(expr js_ast.Expr, in exprIn) (js_ast.Expr, exprO
 		p.pushScopeForVisitPass(js_ast.ScopeFunctionBody, e.Body.Loc)
 		e.Body.Stmts = p.visitStmtsAndPrependTempRefs(e.Body.Stmts, prependTempRefsOpts{kind: stmtsFnBody})
 		p.popScope()
-		p.lowerFunction(&e.IsAsync, &e.Args, e.Body.Loc, &e.Body.Stmts, &e.PreferExpr, &e.HasRestArg, true /* isArrow */, superHelpersOrNil)
+		p.lowerFunction(&e.IsAsync, nil, &e.Args, e.Body.Loc, &e.Body.Stmts, &e.PreferExpr, &e.HasRestArg, true /* isArrow */, superHelpersOrNil)
 		p.popScope()
 
 		// If we intercepted "super" accesses above, clear out the helpers so they
@@ -13017,10 +13956,10 @@ func (p *parser) visitExprInOut(expr js_ast.Expr, in exprIn) (js_ast.Expr, exprO
 		}
 
 	case *js_ast.EClass:
-		shadowRef := p.visitClass(expr.Loc, &e.Class)
+		result := p.visitClass(expr.Loc, &e.Class)
 
 		// Lower class field syntax for browsers that don't support it
-		_, expr = p.lowerClass(js_ast.Stmt{}, expr, shadowRef)
+		_, expr = p.lowerClass(js_ast.Stmt{}, expr, result)
 
 	default:
 		panic("Internal error")
@@ -13193,9 +14132,10 @@ func (p *parser) handleIdentifier(loc logger.Loc, e *js_ast.EIdentifier, opts id
 	if p.fnOnlyDataVisit.argumentsRef != nil && ref == *p.fnOnlyDataVisit.argumentsRef {
 		isInsideUnsupportedArrow := p.fnOrArrowDataVisit.isArrow && p.options.unsupportedJSFeatures.Has(compat.Arrow)
 		isInsideUnsupportedAsyncArrow := p.fnOnlyDataVisit.isInsideAsyncArrowFn && p.options.unsupportedJSFeatures.Has(compat.AsyncAwait)
-		if isInsideUnsupportedArrow || isInsideUnsupportedAsyncArrow {
+		if isInsideUnsupportedArrow || isInsideUnsupportedAsyncArrow || p.fnOnlyDataVisit.isInsideLoweredGenerator {
 			return js_ast.Expr{Loc: loc, Data: &js_ast.EIdentifier{Ref: p.captureArguments()}}
 		}
+		p.markLoweredLoopsAsUnsupported("arguments", js_lexer.RangeOfIdentifier(p.source, loc), true /* isInheritedByArrows */)
 	}
 
 	// Create an error for assigning to an import namespace
@@ -13316,22 +14256,31 @@ func extractNumericValues(left js_ast.Expr, right js_ast.Expr) (float64, float64
 func (p *parser) visitFn(fn *js_ast.Fn, scopeLoc logger.Loc) {
 	oldFnOrArrowData := p.fnOrArrowDataVisit
 	oldFnOnlyData := p.fnOnlyDataVisit
+	isLoweredToGenerator := fn.IsAsync && (p.options.unsupportedJSFeatures.Has(compat.AsyncAwait) ||
+		(fn.IsGenerator && p.options.unsupportedJSFeatures.Has(compat.AsyncGenerator)))
+	isLoweredGenerator := (fn.IsGenerator || isLoweredToGenerator) && p.options.unsupportedJSFeatures.Has(compat.Generator)
+	loweredClassMember := p.nextLoweredClassMember
+	p.nextLoweredClassMember = nil
 	p.fnOrArrowDataVisit = fnOrArrowDataVisit{
 		isAsync:          fn.IsAsync,
 		isGenerator:      fn.IsGenerator,
-		shouldLowerSuper: fn.IsAsync && p.options.unsupportedJSFeatures.Has(compat.AsyncAwait),
+		shouldLowerSuper: isLoweredToGenerator || isLoweredGenerator || loweredClassMember != nil,
 	}
 	p.fnOnlyDataVisit = fnOnlyDataVisit{
-		isThisNested:       true,
-		isNewTargetAllowed: true,
-		argumentsRef:       &fn.ArgumentsRef,
+		isThisNested:             true,
+		isNewTargetAllowed:       true,
+		argumentsRef:             &fn.ArgumentsRef,
+		isInsideLoweredGenerator: isLoweredGenerator,
+		loweredClassMember:       loweredClassMember,
 	}
 
-	// If this function is "async" and we need to lower it, also lower any
-	// "super" property accesses within this function. This object will be
+	// If this function is "async" or a generator and we need to lower it, also
+	// lower any "super" property accesses within this function. This object will be
 	// populated if "super" is used, and then any necessary helper functions
-	// will be placed in the function body by "lowerFunction" below.
-	if p.fnOrArrowDataVisit.shouldLowerSuper {
+	// will be placed in the function body by "lowerFunction" below. This isn't
+	// needed for members of classes lowered to ES5, which lower "super" using
+	// runtime helpers instead.
+	if p.fnOrArrowDataVisit.shouldLowerSuper && loweredClassMember == nil {
 		p.fnOnlyDataVisit.superHelpers = &superHelpers{
 			getRef: js_ast.InvalidRef,
 			setRef: js_ast.InvalidRef,
@@ -13354,7 +14303,7 @@ func (p *parser) visitFn(fn *js_ast.Fn, scopeLoc logger.Loc) {
 	}
 	fn.Body.Stmts = p.visitStmtsAndPrependTempRefs(fn.Body.Stmts, prependTempRefsOpts{fnBodyLoc: &fn.Body.Loc, kind: stmtsFnBody})
 	p.popScope()
-	p.lowerFunction(&fn.IsAsync, &fn.Args, fn.Body.Loc, &fn.Body.Stmts, nil, &fn.HasRestArg, false /* isArrow */, p.fnOnlyDataVisit.superHelpers)
+	p.lowerFunction(&fn.IsAsync, &fn.IsGenerator, &fn.Args, fn.Body.Loc, &fn.Body.Stmts, nil, &fn.HasRestArg, false /* isArrow */, p.fnOnlyDataVisit.superHelpers)
 	p.popScope()
 
 	p.fnOrArrowDataVisit = oldFnOrArrowData
@@ -13462,6 +14411,10 @@ func (p *parser) scanForUnusedTSImportEquals(stmts []js_ast.Stmt) (result import
 func (p *parser) scanForImportsAndExports(stmts []js_ast.Stmt) (result importsExportsScanResult) {
 	stmtsEnd := 0
 
+	// Flow type annotations are parsed using the TypeScript parser, but unused
+	// imports in Flow files are handled the same way as in JavaScript files
+	isTypeScript := p.options.ts.Parse && !p.options.ts.Flow
+
 	for _, stmt := range stmts {
 		switch s := stmt.Data.(type) {
 		case *js_ast.SImport:
@@ -13503,13 +14456,29 @@ func (p *parser) scanForImportsAndExports(stmts []js_ast.Stmt) (result importsEx
 			//     user is expecting the output to be as small as possible. So we
 			//     should omit unused imports.
 			//
-			keepUnusedImports := p.options.ts.Parse && p.options.unusedImportsTS == config.UnusedImportsKeepValues &&
+			keepUnusedImports := isTypeScript && (p.options.unusedImportsTS == config.UnusedImportsKeepValues ||
+				p.options.unusedImportsTS == config.UnusedImportsKeepStmtKeepValues) &&
 				p.options.mode != config.ModeBundle && !p.options.minifyIdentifiers
 
+			// Unlike "verbatimModuleSyntax", "preserveValueImports" still removes
+			// import statements that only contain types such as "import { type
+			// T } from 'foo'". Only "importsNotUsedAsValues" keeps those around.
+			if keepUnusedImports && p.options.unusedImportsTS == config.UnusedImportsKeepValues &&
+				s.DefaultName == nil && s.StarNameLoc == nil && s.Items != nil && len(*s.Items) == 0 &&
+				!record.SourceIndex.IsValid() {
+				record.IsUnused = true
+				continue
+			}
+
+			// "import foo, { type bar } from 'mod'" => "import foo from 'mod'"
+			if keepUnusedImports && s.Items != nil && len(*s.Items) == 0 && (s.DefaultName != nil || s.StarNameLoc != nil) {
+				s.Items = nil
+			}
+
 			// TypeScript always trims unused imports. This is important for
 			// correctness since some imports might be fake (only in the type
 			// system and used for type-only imports).
-			if (p.options.mangleSyntax || p.options.ts.Parse) && !keepUnusedImports {
+			if (p.options.mangleSyntax || isTypeScript) && !keepUnusedImports {
 				foundImports := false
 				isUnusedInTypeScript := true
 
@@ -13519,12 +14488,12 @@ func (p *parser) scanForImportsAndExports(stmts []js_ast.Stmt) (result importsEx
 					symbol := p.symbols[s.DefaultName.Ref.InnerIndex]
 
 					// TypeScript has a separate definition of unused
-					if p.options.ts.Parse && p.tsUseCounts[s.DefaultName.Ref.InnerIndex] != 0 {
+					if isTypeScript && p.tsUseCounts[s.DefaultName.Ref.InnerIndex] != 0 {
 						isUnusedInTypeScript = false
 					}
 
 					// Remove the symbol if it's never used outside a dead code region
-					if symbol.UseCountEstimate == 0 && (p.options.ts.Parse || !p.moduleScope.ContainsDirectEval) {
+					if symbol.UseCountEstimate == 0 && (isTypeScript || !p.moduleScope.ContainsDirectEval) {
 						s.DefaultName = nil
 					}
 				}
@@ -13535,12 +14504,12 @@ func (p *parser) scanForImportsAndExports(stmts []js_ast.Stmt) (result importsEx
 					symbol := p.symbols[s.NamespaceRef.InnerIndex]
 
 					// TypeScript has a separate definition of unused
-					if p.options.ts.Parse && p.tsUseCounts[s.NamespaceRef.InnerIndex] != 0 {
+					if isTypeScript && p.tsUseCounts[s.NamespaceRef.InnerIndex] != 0 {
 						isUnusedInTypeScript = false
 					}
 
 					// Remove the symbol if it's never used outside a dead code region
-					if symbol.UseCountEstimate == 0 && (p.options.ts.Parse || !p.moduleScope.ContainsDirectEval) {
+					if symbol.UseCountEstimate == 0 && (isTypeScript || !p.moduleScope.ContainsDirectEval) {
 						// Make sure we don't remove this if it was used for a property
 						// access while bundling
 						if importItems, ok := p.importItemsForNamespace[s.NamespaceRef]; ok && len(importItems) == 0 {
@@ -13558,12 +14527,12 @@ func (p *parser) scanForImportsAndExports(stmts []js_ast.Stmt) (result importsEx
 						symbol := p.symbols[item.Name.Ref.InnerIndex]
 
 						// TypeScript has a separate definition of unused
-						if p.options.ts.Parse && p.tsUseCounts[item.Name.Ref.InnerIndex] != 0 {
+						if isTypeScript && p.tsUseCounts[item.Name.Ref.InnerIndex] != 0 {
 							isUnusedInTypeScript = false
 						}
 
 						// Remove the symbol if it's never used outside a dead code region
-						if symbol.UseCountEstimate != 0 || (!p.options.ts.Parse && p.moduleScope.ContainsDirectEval) {
+						if symbol.UseCountEstimate != 0 || (!isTypeScript && p.moduleScope.ContainsDirectEval) {
 							(*s.Items)[itemsEnd] = item
 							itemsEnd++
 						}
@@ -13590,7 +14559,7 @@ func (p *parser) scanForImportsAndExports(stmts []js_ast.Stmt) (result importsEx
 				//
 				// We do not want to do this culling in JavaScript though because the
 				// module may have side effects even if all imports are unused.
-				if p.options.ts.Parse && foundImports && isUnusedInTypeScript && p.options.unusedImportsTS == config.UnusedImportsRemoveStmt {
+				if isTypeScript && foundImports && isUnusedInTypeScript && p.options.unusedImportsTS == config.UnusedImportsRemoveStmt {
 					// Ignore import records with a pre-filled source index. These are
 					// for injected files and we definitely do not want to trim these.
 					if !record.SourceIndex.IsValid() {
@@ -13765,6 +14734,7 @@ func (p *parser) scanForImportsAndExports(stmts []js_ast.Stmt) (result importsEx
 
 func (p *parser) appendPart(parts []js_ast.Part, stmts []js_ast.Stmt) []js_ast.Part {
 	p.symbolUses = make(map[js_ast.Ref]js_ast.SymbolUse)
+	p.importSymbolPropertyUses = nil
 	p.declaredSymbols = nil
 	p.importRecordsForCurrentPart = nil
 	p.scopesForCurrentPart = nil
@@ -13773,6 +14743,7 @@ func (p *parser) appendPart(parts []js_ast.Part, stmts []js_ast.Stmt) []js_ast.P
 		Stmts:      p.visitStmtsAndPrependTempRefs(stmts, prependTempRefsOpts{}),
 		SymbolUses: p.symbolUses,
 	}
+	part.ImportSymbolPropertyUses = p.importSymbolPropertyUses
 
 	// Insert any relocated variable statements now
 	if len(p.relocatedTopLevelVars) > 0 {
@@ -13839,6 +14810,10 @@ func (p *parser) stmtsCanBeRemovedIfUnused(stmts []js_ast.Stmt) bool {
 			}
 
 		case *js_ast.SLocal:
+			// "using" declarations have side effects when they go out of scope
+			if s.Kind.IsUsing() {
+				return false
+			}
 			for _, decl := range s.Decls {
 				if !p.bindingCanBeRemovedIfUnused(decl.Binding) {
 					return false
@@ -14394,6 +15369,7 @@ func newParser(log logger.Log, source logger.Source, lexer js_lexer.Lexer, optio
 		runtimeImports:    make(map[string]js_ast.Ref),
 		promiseRef:        js_ast.InvalidRef,
 		afterArrowBodyLoc: logger.Loc{Start: -1},
+		conditionalYesLoc: logger.Loc{Start: -1},
 
 		// For lowering private methods
 		weakMapRef:     js_ast.InvalidRef,
@@ -14453,7 +15429,25 @@ func Parse(log logger.Log, source logger.Source, options Options) (result js_ast
 		options.jsx.Fragment = config.JSXExpr{Parts: defaultJSXFragment}
 	}
 
-	if !options.ts.Parse {
+	lexer := js_lexer.NewLexer(log, source)
+
+	// Consume a leading hashbang comment
+	hashbang := ""
+	if lexer.Token == js_lexer.THashbang {
+		hashbang = lexer.Identifier
+		lexer.Next()
+	}
+
+	// JavaScript files that opt into Flow type checking with a "@flow" pragma
+	// comment before the first token are allowed to contain Flow type syntax.
+	// Flow always allows JSX syntax, so enable that too.
+	if !options.ts.Parse && lexer.HasFlowPragma {
+		options.ts.Parse = true
+		options.ts.Flow = true
+		options.jsx.Parse = true
+	}
+
+	if !options.ts.Parse || options.ts.Flow {
 		// Non-TypeScript files always get the real JavaScript class field behavior
 		options.useDefineForClassFields = config.True
 	} else if options.useDefineForClassFields == config.Unspecified {
@@ -14482,14 +15476,7 @@ func Parse(log logger.Log, source logger.Source, options Options) (result js_ast
 		options.unsupportedJSFeatures |= options.tsTarget.UnsupportedJSFeatures
 	}
 
-	p := newParser(log, source, js_lexer.NewLexer(log, source), &options)
-
-	// Consume a leading hashbang comment
-	hashbang := ""
-	if p.lexer.Token == js_lexer.THashbang {
-		hashbang = p.lexer.Identifier
-		p.lexer.Next()
-	}
+	p := newParser(log, source, lexer, &options)
 
 	// Allow top-level await
 	p.fnOrArrowDataParse.await = allowExpr
@@ -14582,8 +15569,10 @@ func Parse(log logger.Log, source logger.Source, options Options) (result js_ast
 	// single pass, but it turns out it's pretty much impossible to do this
 	// correctly while handling arrow functions because of the grammar
 	// ambiguities.
-	if !p.options.treeShaking {
-		// When tree shaking is disabled, everything comes in a single part
+	if !p.options.treeShaking || p.shouldLowerUsingDeclarations(stmts) {
+		// When tree shaking is disabled, everything comes in a single part. This
+		// is also the case when lowering top-level "using" declarations since the
+		// whole module is then wrapped in a "try" statement.
 		parts = p.appendPart(parts, stmts)
 	} else {
 		// When tree shaking is enabled, each top-level statement is potentially a separate part
@@ -14627,6 +15616,30 @@ func Parse(log logger.Log, source logger.Source, options Options) (result js_ast
 		}
 	}
 
+	// Erase unused top-level const enums now that every use has been visited.
+	// Const enums that are exported with an "export {}" clause are kept.
+	if len(p.erasableConstEnums) > 0 {
+		exportedRefs := make(map[js_ast.Ref]bool)
+		for _, part := range parts {
+			for _, stmt := range part.Stmts {
+				if s, ok := stmt.Data.(*js_ast.SExportClause); ok {
+					for _, item := range s.Items {
+						exportedRefs[item.Name.Ref] = true
+					}
+				}
+			}
+		}
+		erased := p.unusedConstEnumsInCurrentScope(exportedRefs)
+		end := 0
+		for _, part := range parts {
+			if part.Stmts = erased.eraseFrom(part.Stmts); len(part.Stmts) > 0 {
+				parts[end] = part
+				end++
+			}
+		}
+		parts = parts[:end]
+	}
+
 	// Pop the module scope to apply the "ContainsDirectEval" rules
 	p.popScope()
 
@@ -15052,6 +16065,17 @@ func (p *parser) toAST(parts []js_ast.Part, hashbang string, directive string) j
 					p.namedImports[ref] = namedImport
 				}
 			}
+
+			// Property accesses off of imports may also end up being uses
+			for ref := range part.ImportSymbolPropertyUses {
+				if _, ok := part.SymbolUses[ref]; ok {
+					continue
+				}
+				if namedImport, ok := p.namedImports[ref]; ok {
+					namedImport.LocalPartsWithUses = append(namedImport.LocalPartsWithUses, uint32(partIndex))
+					p.namedImports[ref] = namedImport
+				}
+			}
 		}
 	}
 
@@ -15112,6 +16136,7 @@ func (p *parser) toAST(parts []js_ast.Part, hashbang string, directive string) j
 		NamedExports:                    p.namedExports,
 		NestedScopeSlotCounts:           nestedScopeSlotCounts,
 		TopLevelSymbolToPartsFromParser: p.topLevelSymbolToParts,
+		TSEnums:                         p.tsEnums,
 		ExportStarImportRecords:         p.exportStarImportRecords,
 		ImportRecords:                   p.importRecords,
 		ApproximateLineCount:            int32(p.lexer.ApproximateNewlineCount) + 1,
@@ -6,6 +6,8 @@ package js_parser
 
 import (
 	"fmt"
+	"sort"
+	"strings"
 
 	"github.com/evanw/esbuild/internal/compat"
 	"github.com/evanw/esbuild/internal/config"
@@ -54,43 +56,21 @@ func (p *parser) markSyntaxFeature(feature compat.JSFeature, r logger.Range) (di
 	case compat.ArraySpread:
 		name = "array spread"
 
-	case compat.ForOf:
-		name = "for-of loops"
-
 	case compat.ObjectAccessors:
 		name = "object accessors"
 
 	case compat.ObjectExtensions:
 		name = "object literal extensions"
 
-	case compat.Destructuring:
-		name = "destructuring"
-
 	case compat.NewTarget:
 		name = "new.target"
 
-	case compat.Const:
-		name = "const"
-
-	case compat.Let:
-		name = "let"
-
-	case compat.Class:
-		name = "class syntax"
-
-	case compat.Generator:
-		name = "generator functions"
-
-	case compat.AsyncAwait:
-		name = "async functions"
-
-	case compat.AsyncGenerator:
-		name = "async generator functions"
-
-	case compat.ForAwait:
-		name = "for-await loops"
-
 	case compat.NestedRestBinding:
+		// These are handled when all destructuring is lowered
+		if p.options.unsupportedJSFeatures.Has(compat.Destructuring) {
+			didGenerateError = false
+			return
+		}
 		name = "non-identifier array rest patterns"
 
 	case compat.ImportAssertions:
@@ -213,12 +193,6 @@ func (p *parser) markStrictModeFeature(feature strictModeFeature, r logger.Range
 
 // Mark the feature if "loweredFeature" is unsupported. This is used when one
 // feature is implemented in terms of another feature.
-func (p *parser) markLoweredSyntaxFeature(feature compat.JSFeature, r logger.Range, loweredFeature compat.JSFeature) {
-	if p.options.unsupportedJSFeatures.Has(loweredFeature) {
-		p.markSyntaxFeature(feature, r)
-	}
-}
-
 func (p *parser) privateSymbolNeedsToBeLowered(private *js_ast.EPrivateIdentifier) bool {
 	symbol := &p.symbols[private.Ref.InnerIndex]
 	return p.options.unsupportedJSFeatures.Has(symbol.Kind.Feature()) || symbol.PrivateSymbolMustBeLowered
@@ -242,6 +216,7 @@ func (p *parser) captureArguments() js_ast.Ref {
 
 func (p *parser) lowerFunction(
 	isAsync *bool,
+	isGenerator *bool,
 	args *[]js_ast.Arg,
 	bodyLoc logger.Loc,
 	bodyStmts *[]js_ast.Stmt,
@@ -250,8 +225,8 @@ func (p *parser) lowerFunction(
 	isArrow bool,
 	superHelpers *superHelpers,
 ) {
-	// Lower object rest binding patterns in function arguments
-	if p.options.unsupportedJSFeatures.Has(compat.ObjectRestSpread) {
+	// Lower binding patterns in function arguments
+	if p.options.unsupportedJSFeatures.Has(compat.ObjectRestSpread | compat.Destructuring) {
 		var prefixStmts []js_ast.Stmt
 
 		// Lower each argument individually instead of lowering all arguments
@@ -285,7 +260,7 @@ func (p *parser) lowerFunction(
 		// thinking that perhaps scope matters more in real-world code than side
 		// effect order.
 		for i, arg := range *args {
-			if bindingHasObjectRest(arg.Binding) {
+			if p.bindingPatternNeedsLowering(arg.Binding) {
 				ref := p.generateTempRef(tempRefNoDeclare, "")
 				target := js_ast.ConvertBindingToExpr(arg.Binding, nil)
 				init := js_ast.Expr{Loc: arg.Binding.Loc, Data: &js_ast.EIdentifier{Ref: ref}}
@@ -307,8 +282,10 @@ func (p *parser) lowerFunction(
 		}
 	}
 
-	// Lower async functions
-	if p.options.unsupportedJSFeatures.Has(compat.AsyncAwait) && *isAsync {
+	// Lower async functions and async generator functions
+	isAsyncGenerator := *isAsync && isGenerator != nil && *isGenerator
+	if *isAsync && (p.options.unsupportedJSFeatures.Has(compat.AsyncAwait) ||
+		(isAsyncGenerator && p.options.unsupportedJSFeatures.Has(compat.AsyncGenerator))) {
 		// Use the shortened form if we're an arrow function
 		if preferExpr != nil {
 			*preferExpr = true
@@ -346,7 +323,7 @@ func (p *parser) lowerFunction(
 
 		// Forward the arguments to the wrapper function
 		usesArgumentsRef := !isArrow && p.fnOnlyDataVisit.argumentsRef != nil &&
-			p.symbolUses[*p.fnOnlyDataVisit.argumentsRef].CountEstimate > 0
+			(p.symbolUses[*p.fnOnlyDataVisit.argumentsRef].CountEstimate > 0 || p.fnOnlyDataVisit.argumentsCaptureRef != nil)
 		var forwardedArgs js_ast.Expr
 		if !couldThrowErrors && !usesArgumentsRef {
 			// Simple case: the arguments can stay on the outer function. It's
@@ -437,9 +414,21 @@ func (p *parser) lowerFunction(
 			}
 		}
 
+		// The generator function must also be lowered if generators aren't supported
+		if p.options.unsupportedJSFeatures.Has(compat.Generator) {
+			fn.Body.Stmts = p.lowerGeneratorBody(bodyLoc, fn.Body.Stmts, nil)
+			fn.IsGenerator = false
+		}
+
 		// "async function foo(a, b) { stmts }" => "function foo(a, b) { return __async(this, null, function* () { stmts }) }"
+		// "async function* foo(a, b) { stmts }" => "function foo(a, b) { return __asyncGen(this, null, function* () { stmts }) }"
+		helper := "__async"
+		if isAsyncGenerator {
+			helper = "__asyncGen"
+			*isGenerator = false
+		}
 		*isAsync = false
-		callAsync := p.callRuntime(bodyLoc, "__async", []js_ast.Expr{
+		callAsync := p.callRuntime(bodyLoc, helper, []js_ast.Expr{
 			thisValue,
 			forwardedArgs,
 			{Loc: bodyLoc, Data: &js_ast.EFunction{Fn: fn}},
@@ -449,69 +438,290 @@ func (p *parser) lowerFunction(
 		// Prepend the "super" index functions if necessary
 		var bodyStmtList []js_ast.Stmt
 		if superHelpers != nil {
-			if superHelpers.getRef != js_ast.InvalidRef {
-				keyRef := p.newSymbol(js_ast.SymbolOther, "key")
-				p.currentScope.Generated = append(p.currentScope.Generated, superHelpers.getRef, keyRef)
-				superGetStmt := js_ast.Stmt{Loc: bodyLoc, Data: &js_ast.SLocal{
-					Decls: []js_ast.Decl{{
-						Binding: js_ast.Binding{Loc: bodyLoc, Data: &js_ast.BIdentifier{Ref: superHelpers.getRef}},
-						ValueOrNil: js_ast.Expr{Loc: bodyLoc, Data: &js_ast.EArrow{
-							Args: []js_ast.Arg{
-								{Binding: js_ast.Binding{Loc: bodyLoc, Data: &js_ast.BIdentifier{Ref: keyRef}}},
-							},
-							Body: js_ast.FnBody{
-								Loc: bodyLoc,
-								Stmts: []js_ast.Stmt{{Loc: bodyLoc, Data: &js_ast.SReturn{
-									ValueOrNil: js_ast.Expr{Loc: bodyLoc, Data: &js_ast.EIndex{
-										Target: js_ast.Expr{Loc: bodyLoc, Data: js_ast.ESuperShared},
-										Index:  js_ast.Expr{Loc: bodyLoc, Data: &js_ast.EIdentifier{Ref: keyRef}},
-									}},
-								}}},
-							},
-							PreferExpr: true,
-						}},
-					}},
-				}}
-				p.recordUsage(keyRef)
-				bodyStmtList = append(bodyStmtList, superGetStmt)
-			}
-			if superHelpers.setRef != js_ast.InvalidRef {
-				keyRef := p.newSymbol(js_ast.SymbolOther, "key")
-				valueRef := p.newSymbol(js_ast.SymbolOther, "value")
-				p.currentScope.Generated = append(p.currentScope.Generated, superHelpers.setRef, keyRef)
-				p.currentScope.Generated = append(p.currentScope.Generated, superHelpers.setRef, valueRef)
-				superSetStmt := js_ast.Stmt{Loc: bodyLoc, Data: &js_ast.SLocal{
-					Decls: []js_ast.Decl{{
-						Binding: js_ast.Binding{Loc: bodyLoc, Data: &js_ast.BIdentifier{Ref: superHelpers.setRef}},
-						ValueOrNil: js_ast.Expr{Loc: bodyLoc, Data: &js_ast.EArrow{
-							Args: []js_ast.Arg{
-								{Binding: js_ast.Binding{Loc: bodyLoc, Data: &js_ast.BIdentifier{Ref: keyRef}}},
-								{Binding: js_ast.Binding{Loc: bodyLoc, Data: &js_ast.BIdentifier{Ref: valueRef}}},
-							},
-							Body: js_ast.FnBody{
-								Loc: bodyLoc,
-								Stmts: []js_ast.Stmt{{Loc: bodyLoc, Data: &js_ast.SReturn{
-									ValueOrNil: js_ast.Expr{Loc: bodyLoc, Data: &js_ast.EBinary{
-										Op: js_ast.BinOpAssign,
-										Left: js_ast.Expr{Loc: bodyLoc, Data: &js_ast.EIndex{
-											Target: js_ast.Expr{Loc: bodyLoc, Data: js_ast.ESuperShared},
-											Index:  js_ast.Expr{Loc: bodyLoc, Data: &js_ast.EIdentifier{Ref: keyRef}},
-										}},
-										Right: js_ast.Expr{Loc: bodyLoc, Data: &js_ast.EIdentifier{Ref: valueRef}},
-									}},
-								}}},
-							},
-							PreferExpr: true,
-						}},
-					}},
-				}}
-				p.recordUsage(keyRef)
-				p.recordUsage(valueRef)
-				bodyStmtList = append(bodyStmtList, superSetStmt)
-			}
+			bodyStmtList = p.superHelperStmts(bodyLoc, superHelpers)
 		}
 		*bodyStmts = append(bodyStmtList, returnStmt)
 	}
+
+	// Lower generator functions
+	if p.options.unsupportedJSFeatures.Has(compat.Generator) && isGenerator != nil && *isGenerator && !*isAsync {
+		*bodyStmts = p.lowerGeneratorBody(bodyLoc, *bodyStmts, superHelpers)
+		*isGenerator = false
+	}
+}
+
+func (p *parser) isInsideLoweredAsyncGenerator() bool {
+	return p.fnOrArrowDataVisit.isAsync && p.fnOrArrowDataVisit.isGenerator &&
+		p.options.unsupportedJSFeatures.Has(compat.AsyncGenerator)
+}
+
+// "await x" => "yield new __genAwait(x)"
+func (p *parser) lowerAwaitInAsyncGenerator(loc logger.Loc, value js_ast.Expr) js_ast.Expr {
+	return js_ast.Expr{Loc: loc, Data: &js_ast.EYield{ValueOrNil: js_ast.Expr{Loc: loc, Data: &js_ast.ENew{
+		Target: p.importFromRuntime(loc, "__genAwait"),
+		Args:   []js_ast.Expr{value},
+	}}}}
+}
+
+// This generates "await x" for the current function, which may itself be
+// in the process of being lowered into a generator function
+func (p *parser) awaitExpr(loc logger.Loc, value js_ast.Expr) js_ast.Expr {
+	if p.isInsideLoweredAsyncGenerator() {
+		return p.lowerAwaitInAsyncGenerator(loc, value)
+	}
+	if p.fnOrArrowDataVisit.isAsync && p.options.unsupportedJSFeatures.Has(compat.AsyncAwait) {
+		return js_ast.Expr{Loc: loc, Data: &js_ast.EYield{ValueOrNil: value}}
+	}
+	return js_ast.Expr{Loc: loc, Data: &js_ast.EAwait{Value: value}}
+}
+
+// Lowered loops declare their variables using "var", so the names must be
+// unique within the enclosing function instead of within the enclosing block.
+// Otherwise a stale value from an earlier loop could be observed.
+func (p *parser) newLoweredLoopVar(name string) js_ast.Ref {
+	ref := p.generateTempRef(tempRefNoDeclare, name)
+	scope := p.currentScope
+	for !scope.Kind.StopsHoisting() {
+		scope = scope.Parent
+	}
+	p.declaredSymbols = append(p.declaredSymbols, js_ast.DeclaredSymbol{
+		Ref:        ref,
+		IsTopLevel: scope == p.moduleScope,
+	})
+	return ref
+}
+
+func (p *parser) isLoweredForOfLoop(loop *js_ast.SForOf) bool {
+	if loop.IsAwait {
+		return p.options.unsupportedJSFeatures.Has(compat.ForAwait)
+	}
+	return p.options.unsupportedJSFeatures.Has(compat.ForOf)
+}
+
+// This lowers a "for-of" or "for await" loop into a regular "for" loop that
+// calls the methods of the iterator explicitly:
+//
+//   // Original code
+//   for await (let x of y) z()
+//
+//   // Lowered code
+//   try {
+//     for (var iter = __forAwait(y), more, temp, error; more = !(temp = await iter.next()).done; more = false) {
+//       let x = temp.value;
+//       z();
+//     }
+//   } catch (temp) {
+//     error = [temp];
+//   } finally {
+//     try {
+//       more && (temp = iter.return) && await temp.call(iter);
+//     } finally {
+//       if (error)
+//         throw error[0];
+//     }
+//   }
+//
+// The "more" flag is only true while the loop body is running, so the
+// iterator is only closed if the loop exits early due to "break", "return",
+// or an exception. A "for-of" loop is lowered the same way except that it
+// uses "__getIterator" and there is no "await".
+func (p *parser) lowerForOfLoop(loc logger.Loc, loop *js_ast.SForOf) js_ast.Stmt {
+	iterRef := p.newLoweredLoopVar("iter")
+	moreRef := p.newLoweredLoopVar("more")
+	tempRef := p.newLoweredLoopVar("temp")
+	errorRef := p.newLoweredLoopVar("error")
+	ref := func(ref js_ast.Ref) js_ast.Expr {
+		p.recordUsage(ref)
+		return js_ast.Expr{Loc: loc, Data: &js_ast.EIdentifier{Ref: ref}}
+	}
+	binding := func(ref js_ast.Ref) js_ast.Binding {
+		return js_ast.Binding{Loc: loc, Data: &js_ast.BIdentifier{Ref: ref}}
+	}
+	method := func(target js_ast.Expr, name string) js_ast.Expr {
+		return js_ast.Expr{Loc: loc, Data: &js_ast.EDot{Target: target, Name: name, NameLoc: loc}}
+	}
+
+	maybeAwait := func(value js_ast.Expr) js_ast.Expr {
+		if loop.IsAwait {
+			return p.awaitExpr(loc, value)
+		}
+		return value
+	}
+	helper := "__getIterator"
+	if loop.IsAwait {
+		helper = "__forAwait"
+	}
+
+	// "for (var iter = __forAwait(y), more, temp, error; more = !(temp = await iter.next()).done; more = false)"
+	forStmt := js_ast.Stmt{Loc: loc, Data: &js_ast.SFor{
+		InitOrNil: js_ast.Stmt{Loc: loc, Data: &js_ast.SLocal{Kind: js_ast.LocalVar, Decls: []js_ast.Decl{
+			{Binding: binding(iterRef), ValueOrNil: p.callRuntime(loc, helper, []js_ast.Expr{loop.Value})},
+			{Binding: binding(moreRef)},
+			{Binding: binding(tempRef)},
+			{Binding: binding(errorRef)},
+		}}},
+		TestOrNil: js_ast.Assign(ref(moreRef), js_ast.Not(method(js_ast.Assign(ref(tempRef),
+			maybeAwait(js_ast.Expr{Loc: loc, Data: &js_ast.ECall{Target: method(ref(iterRef), "next")}})), "done"))),
+		UpdateOrNil: js_ast.Assign(ref(moreRef), js_ast.Expr{Loc: loc, Data: &js_ast.EBoolean{Value: false}}),
+		Body:        forOfLoopBody(loop, method(ref(tempRef), "value")),
+	}}
+
+	// "more && (temp = iter.return) && await temp.call(iter)"
+	closeIter := js_ast.Expr{Loc: loc, Data: &js_ast.EBinary{
+		Op: js_ast.BinOpLogicalAnd,
+		Left: js_ast.Expr{Loc: loc, Data: &js_ast.EBinary{
+			Op:    js_ast.BinOpLogicalAnd,
+			Left:  ref(moreRef),
+			Right: js_ast.Assign(ref(tempRef), method(ref(iterRef), "return")),
+		}},
+		Right: maybeAwait(js_ast.Expr{Loc: loc, Data: &js_ast.ECall{
+			Target: method(ref(tempRef), "call"),
+			Args:   []js_ast.Expr{ref(iterRef)},
+		}}),
+	}}
+
+	// "if (error) throw error[0]"
+	rethrow := js_ast.Stmt{Loc: loc, Data: &js_ast.SIf{
+		Test: ref(errorRef),
+		Yes: js_ast.Stmt{Loc: loc, Data: &js_ast.SThrow{Value: js_ast.Expr{Loc: loc, Data: &js_ast.EIndex{
+			Target: ref(errorRef),
+			Index:  js_ast.Expr{Loc: loc, Data: &js_ast.ENumber{Value: 0}},
+		}}}},
+	}}
+
+	return js_ast.Stmt{Loc: loc, Data: &js_ast.STry{
+		BodyLoc: loc,
+		Body:    []js_ast.Stmt{forStmt},
+		Catch: &js_ast.Catch{
+			Loc:          loc,
+			BindingOrNil: binding(tempRef),
+			BodyLoc:      loc,
+			Body: []js_ast.Stmt{{Loc: loc, Data: &js_ast.SExpr{Value: js_ast.Assign(ref(errorRef),
+				js_ast.Expr{Loc: loc, Data: &js_ast.EArray{Items: []js_ast.Expr{ref(tempRef)}, IsSingleLine: true}})}}},
+		},
+		Finally: &js_ast.Finally{Loc: loc, Stmts: []js_ast.Stmt{{Loc: loc, Data: &js_ast.STry{
+			BodyLoc: loc,
+			Body:    []js_ast.Stmt{{Loc: loc, Data: &js_ast.SExpr{Value: closeIter}}},
+			Finally: &js_ast.Finally{Loc: loc, Stmts: []js_ast.Stmt{rethrow}},
+		}}}},
+	}}
+}
+
+// This lowers a "for-of" loop into an indexed "for" loop when iterables are
+// assumed to be arrays. For example, "for (let x of y) z()" becomes "for (var
+// i = 0, array = y; i < array.length; i++) { let x = array[i]; z() }".
+func (p *parser) lowerForOfLoopAssumingArrays(loc logger.Loc, loop *js_ast.SForOf) js_ast.Stmt {
+	indexRef := p.newLoweredLoopVar("i")
+	arrayRef := p.newLoweredLoopVar("array")
+	ref := func(ref js_ast.Ref) js_ast.Expr {
+		p.recordUsage(ref)
+		return js_ast.Expr{Loc: loc, Data: &js_ast.EIdentifier{Ref: ref}}
+	}
+
+	return js_ast.Stmt{Loc: loc, Data: &js_ast.SFor{
+		InitOrNil: js_ast.Stmt{Loc: loc, Data: &js_ast.SLocal{Kind: js_ast.LocalVar, Decls: []js_ast.Decl{
+			{Binding: js_ast.Binding{Loc: loc, Data: &js_ast.BIdentifier{Ref: indexRef}}, ValueOrNil: js_ast.Expr{Loc: loc, Data: &js_ast.ENumber{Value: 0}}},
+			{Binding: js_ast.Binding{Loc: loc, Data: &js_ast.BIdentifier{Ref: arrayRef}}, ValueOrNil: loop.Value},
+		}}},
+		TestOrNil: js_ast.Expr{Loc: loc, Data: &js_ast.EBinary{
+			Op:    js_ast.BinOpLt,
+			Left:  ref(indexRef),
+			Right: js_ast.Expr{Loc: loc, Data: &js_ast.EDot{Target: ref(arrayRef), Name: "length", NameLoc: loc}},
+		}},
+		UpdateOrNil: js_ast.Expr{Loc: loc, Data: &js_ast.EUnary{Op: js_ast.UnOpPostInc, Value: ref(indexRef)}},
+		Body: forOfLoopBody(loop, js_ast.Expr{Loc: loc, Data: &js_ast.EIndex{
+			Target: ref(arrayRef),
+			Index:  ref(indexRef),
+		}}),
+	}}
+}
+
+// This moves the loop variable of a lowered "for-of" loop into the loop body:
+// "let x = value" or "x = value"
+func forOfLoopBody(loop *js_ast.SForOf, value js_ast.Expr) js_ast.Stmt {
+	var firstStmt js_ast.Stmt
+	switch init := loop.Init.Data.(type) {
+	case *js_ast.SLocal:
+		firstStmt = js_ast.Stmt{Loc: loop.Init.Loc, Data: &js_ast.SLocal{Kind: init.Kind, Decls: []js_ast.Decl{
+			{Binding: init.Decls[0].Binding, ValueOrNil: value},
+		}}}
+	case *js_ast.SExpr:
+		firstStmt = js_ast.Stmt{Loc: loop.Init.Loc, Data: &js_ast.SExpr{Value: js_ast.Assign(init.Value, value)}}
+	}
+	var bodyStmts []js_ast.Stmt
+	if block, ok := loop.Body.Data.(*js_ast.SBlock); ok {
+		bodyStmts = append(append(make([]js_ast.Stmt, 0, 1+len(block.Stmts)), firstStmt), block.Stmts...)
+	} else {
+		bodyStmts = []js_ast.Stmt{firstStmt, loop.Body}
+	}
+	return js_ast.Stmt{Loc: loop.Body.Loc, Data: &js_ast.SBlock{Stmts: bodyStmts}}
+}
+
+// These helper functions forward "super" property accesses from a nested
+// function that was generated by lowering, since the nested function no
+// longer has direct access to "super"
+func (p *parser) superHelperStmts(bodyLoc logger.Loc, superHelpers *superHelpers) (stmts []js_ast.Stmt) {
+	if superHelpers.getRef != js_ast.InvalidRef {
+		keyRef := p.newSymbol(js_ast.SymbolOther, "key")
+		p.currentScope.Generated = append(p.currentScope.Generated, superHelpers.getRef, keyRef)
+		superGetStmt := js_ast.Stmt{Loc: bodyLoc, Data: &js_ast.SLocal{
+			Decls: []js_ast.Decl{{
+				Binding: js_ast.Binding{Loc: bodyLoc, Data: &js_ast.BIdentifier{Ref: superHelpers.getRef}},
+				ValueOrNil: js_ast.Expr{Loc: bodyLoc, Data: &js_ast.EArrow{
+					Args: []js_ast.Arg{
+						{Binding: js_ast.Binding{Loc: bodyLoc, Data: &js_ast.BIdentifier{Ref: keyRef}}},
+					},
+					Body: js_ast.FnBody{
+						Loc: bodyLoc,
+						Stmts: []js_ast.Stmt{{Loc: bodyLoc, Data: &js_ast.SReturn{
+							ValueOrNil: js_ast.Expr{Loc: bodyLoc, Data: &js_ast.EIndex{
+								Target: js_ast.Expr{Loc: bodyLoc, Data: js_ast.ESuperShared},
+								Index:  js_ast.Expr{Loc: bodyLoc, Data: &js_ast.EIdentifier{Ref: keyRef}},
+							}},
+						}}},
+					},
+					PreferExpr: true,
+				}},
+			}},
+		}}
+		p.recordUsage(keyRef)
+		stmts = append(stmts, superGetStmt)
+	}
+	if superHelpers.setRef != js_ast.InvalidRef {
+		keyRef := p.newSymbol(js_ast.SymbolOther, "key")
+		valueRef := p.newSymbol(js_ast.SymbolOther, "value")
+		p.currentScope.Generated = append(p.currentScope.Generated, superHelpers.setRef, keyRef)
+		p.currentScope.Generated = append(p.currentScope.Generated, superHelpers.setRef, valueRef)
+		superSetStmt := js_ast.Stmt{Loc: bodyLoc, Data: &js_ast.SLocal{
+			Decls: []js_ast.Decl{{
+				Binding: js_ast.Binding{Loc: bodyLoc, Data: &js_ast.BIdentifier{Ref: superHelpers.setRef}},
+				ValueOrNil: js_ast.Expr{Loc: bodyLoc, Data: &js_ast.EArrow{
+					Args: []js_ast.Arg{
+						{Binding: js_ast.Binding{Loc: bodyLoc, Data: &js_ast.BIdentifier{Ref: keyRef}}},
+						{Binding: js_ast.Binding{Loc: bodyLoc, Data: &js_ast.BIdentifier{Ref: valueRef}}},
+					},
+					Body: js_ast.FnBody{
+						Loc: bodyLoc,
+						Stmts: []js_ast.Stmt{{Loc: bodyLoc, Data: &js_ast.SReturn{
+							ValueOrNil: js_ast.Expr{Loc: bodyLoc, Data: &js_ast.EBinary{
+								Op: js_ast.BinOpAssign,
+								Left: js_ast.Expr{Loc: bodyLoc, Data: &js_ast.EIndex{
+									Target: js_ast.Expr{Loc: bodyLoc, Data: js_ast.ESuperShared},
+									Index:  js_ast.Expr{Loc: bodyLoc, Data: &js_ast.EIdentifier{Ref: keyRef}},
+								}},
+								Right: js_ast.Expr{Loc: bodyLoc, Data: &js_ast.EIdentifier{Ref: valueRef}},
+							}},
+						}}},
+					},
+					PreferExpr: true,
+				}},
+			}},
+		}}
+		p.recordUsage(keyRef)
+		p.recordUsage(valueRef)
+		stmts = append(stmts, superSetStmt)
+	}
+	return
 }
 
 func (p *parser) lowerOptionalChain(expr js_ast.Expr, in exprIn, childOut exprOut) (js_ast.Expr, exprOut) {
@@ -635,7 +845,7 @@ flatten:
 					//
 					//   (_a = super.foo) == null ? void 0 : _a.call(this)
 					//
-					thisArg = js_ast.Expr{Loc: loc, Data: js_ast.EThisShared}
+					thisArg = p.superPropertyThis(loc)
 				} else {
 					targetFunc, wrapFunc := p.captureValueWithPossibleSideEffects(loc, 2, e.Target, valueDefinitelyNotMutated)
 					expr = js_ast.Expr{Loc: loc, Data: &js_ast.EDot{
@@ -655,7 +865,7 @@ flatten:
 					}
 
 					// See the comment above about a similar special case for EDot
-					thisArg = js_ast.Expr{Loc: loc, Data: js_ast.EThisShared}
+					thisArg = p.superPropertyThis(loc)
 				} else {
 					targetFunc, wrapFunc := p.captureValueWithPossibleSideEffects(loc, 2, e.Target, valueDefinitelyNotMutated)
 					targetWrapFunc = wrapFunc
@@ -1250,15 +1460,39 @@ func exprHasObjectRest(expr js_ast.Expr) bool {
 	return false
 }
 
+// Object rest patterns can be lowered by themselves, but every binding pattern
+// must be lowered if the target environment doesn't support destructuring
+func (p *parser) bindingPatternNeedsLowering(binding js_ast.Binding) bool {
+	switch binding.Data.(type) {
+	case *js_ast.BArray, *js_ast.BObject:
+		if p.options.unsupportedJSFeatures.Has(compat.Destructuring) {
+			return true
+		}
+		return p.options.unsupportedJSFeatures.Has(compat.ObjectRestSpread) && bindingHasObjectRest(binding)
+	}
+	return false
+}
+
+func (p *parser) exprPatternNeedsLowering(expr js_ast.Expr) bool {
+	switch expr.Data.(type) {
+	case *js_ast.EArray, *js_ast.EObject:
+		if p.options.unsupportedJSFeatures.Has(compat.Destructuring) {
+			return true
+		}
+		return p.options.unsupportedJSFeatures.Has(compat.ObjectRestSpread) && exprHasObjectRest(expr)
+	}
+	return false
+}
+
 func (p *parser) lowerObjectRestInDecls(decls []js_ast.Decl) []js_ast.Decl {
-	if !p.options.unsupportedJSFeatures.Has(compat.ObjectRestSpread) {
+	if !p.options.unsupportedJSFeatures.Has(compat.ObjectRestSpread | compat.Destructuring) {
 		return decls
 	}
 
-	// Don't do any allocations if there are no object rest patterns. We want as
+	// Don't do any allocations if there are no patterns to lower. We want as
 	// little overhead as possible in the common case.
 	for i, decl := range decls {
-		if decl.ValueOrNil.Data != nil && bindingHasObjectRest(decl.Binding) {
+		if decl.ValueOrNil.Data != nil && p.bindingPatternNeedsLowering(decl.Binding) {
 			clone := append([]js_ast.Decl{}, decls[:i]...)
 			for _, decl := range decls[i:] {
 				if decl.ValueOrNil.Data != nil {
@@ -1279,7 +1513,7 @@ func (p *parser) lowerObjectRestInDecls(decls []js_ast.Decl) []js_ast.Decl {
 }
 
 func (p *parser) lowerObjectRestInForLoopInit(init js_ast.Stmt, body *js_ast.Stmt) {
-	if !p.options.unsupportedJSFeatures.Has(compat.ObjectRestSpread) {
+	if !p.options.unsupportedJSFeatures.Has(compat.ObjectRestSpread | compat.Destructuring) {
 		return
 	}
 
@@ -1289,7 +1523,7 @@ func (p *parser) lowerObjectRestInForLoopInit(init js_ast.Stmt, body *js_ast.Stm
 	case *js_ast.SExpr:
 		// "for ({...x} in y) {}"
 		// "for ({...x} of y) {}"
-		if exprHasObjectRest(s.Value) {
+		if p.exprPatternNeedsLowering(s.Value) {
 			ref := p.generateTempRef(tempRefNeedsDeclare, "")
 			if expr, ok := p.lowerAssign(s.Value, js_ast.Expr{Loc: init.Loc, Data: &js_ast.EIdentifier{Ref: ref}}, objRestReturnValueIsUnused); ok {
 				s.Value.Data = &js_ast.EIdentifier{Ref: ref}
@@ -1300,7 +1534,7 @@ func (p *parser) lowerObjectRestInForLoopInit(init js_ast.Stmt, body *js_ast.Stm
 	case *js_ast.SLocal:
 		// "for (let {...x} in y) {}"
 		// "for (let {...x} of y) {}"
-		if len(s.Decls) == 1 && bindingHasObjectRest(s.Decls[0].Binding) {
+		if len(s.Decls) == 1 && p.bindingPatternNeedsLowering(s.Decls[0].Binding) {
 			ref := p.generateTempRef(tempRefNoDeclare, "")
 			decl := js_ast.Decl{Binding: s.Decls[0].Binding, ValueOrNil: js_ast.Expr{Loc: init.Loc, Data: &js_ast.EIdentifier{Ref: ref}}}
 			p.recordUsage(ref)
@@ -1323,18 +1557,18 @@ func (p *parser) lowerObjectRestInForLoopInit(init js_ast.Stmt, body *js_ast.Stm
 }
 
 func (p *parser) lowerObjectRestInCatchBinding(catch *js_ast.Catch) {
-	if !p.options.unsupportedJSFeatures.Has(compat.ObjectRestSpread) {
+	if !p.options.unsupportedJSFeatures.Has(compat.ObjectRestSpread | compat.Destructuring) {
 		return
 	}
 
-	if catch.BindingOrNil.Data != nil && bindingHasObjectRest(catch.BindingOrNil) {
+	if catch.BindingOrNil.Data != nil && p.bindingPatternNeedsLowering(catch.BindingOrNil) {
 		ref := p.generateTempRef(tempRefNoDeclare, "")
 		decl := js_ast.Decl{Binding: catch.BindingOrNil, ValueOrNil: js_ast.Expr{Loc: catch.BindingOrNil.Loc, Data: &js_ast.EIdentifier{Ref: ref}}}
 		p.recordUsage(ref)
 		decls := p.lowerObjectRestInDecls([]js_ast.Decl{decl})
 		catch.BindingOrNil.Data = &js_ast.BIdentifier{Ref: ref}
 		stmts := make([]js_ast.Stmt, 0, 1+len(catch.Body))
-		stmts = append(stmts, js_ast.Stmt{Loc: catch.BindingOrNil.Loc, Data: &js_ast.SLocal{Kind: js_ast.LocalLet, Decls: decls}})
+		stmts = append(stmts, js_ast.Stmt{Loc: catch.BindingOrNil.Loc, Data: &js_ast.SLocal{Kind: p.selectLocalKind(js_ast.LocalLet), Decls: decls}})
 		catch.Body = append(stmts, catch.Body...)
 	}
 }
@@ -1468,6 +1702,12 @@ func (p *parser) lowerObjectRestHelper(
 	declare generateTempRefArg,
 	mode objRestMode,
 ) (wrapFunc func(js_ast.Expr) js_ast.Expr, ok bool) {
+	// If destructuring isn't supported at all, the whole pattern must be broken
+	// apart instead of just splitting it around the object rest patterns
+	if p.options.unsupportedJSFeatures.Has(compat.Destructuring) {
+		return p.lowerDestructuringHelper(rootExpr, rootInit, assign, declare, mode)
+	}
+
 	if !p.options.unsupportedJSFeatures.Has(compat.ObjectRestSpread) {
 		return nil, false
 	}
@@ -1774,6 +2014,228 @@ func (p *parser) captureKeyForObjectRest(originalKey js_ast.Expr) (finalKey js_a
 	return
 }
 
+// This breaks a binding pattern apart into a sequence of simple assignments for
+// target environments that don't support destructuring at all:
+//
+//   // Input:
+//   var {a, b: [c, d = 1], ...e} = f;
+//
+//   // Output:
+//   var a = f.a, _a = __readArray(f.b, 2), c = _a[0], _b = _a[1],
+//     d = _b === void 0 ? 1 : _b, e = __objRest(f, ["a", "b"]);
+//
+// Array patterns use the iterator protocol via "__readArray" unless values are
+// assumed to be arrays, in which case they are indexed into directly. Like the
+// TypeScript compiler, all values for an array pattern are read from the
+// iterator before any of them are assigned.
+func (p *parser) lowerDestructuringHelper(
+	rootExpr js_ast.Expr,
+	rootInit js_ast.Expr,
+	assign func(js_ast.Expr, js_ast.Expr),
+	declare generateTempRefArg,
+	mode objRestMode,
+) (wrapFunc func(js_ast.Expr) js_ast.Expr, ok bool) {
+	switch rootExpr.Data.(type) {
+	case *js_ast.EArray, *js_ast.EObject:
+	default:
+		return nil, false
+	}
+
+	temps := make(map[js_ast.Ref]bool)
+
+	useRef := func(loc logger.Loc, ref js_ast.Ref) js_ast.Expr {
+		p.recordUsage(ref)
+		return js_ast.Expr{Loc: loc, Data: &js_ast.EIdentifier{Ref: ref}}
+	}
+
+	captureIntoRef := func(expr js_ast.Expr) js_ast.Ref {
+		ref := p.generateTempRef(declare, "")
+		temps[ref] = true
+		assign(useRef(expr.Loc, ref), expr)
+		return ref
+	}
+
+	// Values that are referenced more than once must not be evaluated more than
+	// once, and must not be changed by the assignments in between. Identifiers
+	// can be referenced again as long as the pattern doesn't assign to them.
+	captureUnlessReusable := func(init js_ast.Expr, pattern js_ast.Expr) js_ast.Ref {
+		if id, ok := init.Data.(*js_ast.EIdentifier); ok && (temps[id.Ref] || !patternAssignsToRef(pattern, id.Ref)) {
+			return id.Ref
+		}
+		return captureIntoRef(init)
+	}
+
+	// "[a = b] = c" => "_a = c, a = _a === void 0 ? b : _a"
+	withDefaultValue := func(init js_ast.Expr, defaultValue js_ast.Expr) js_ast.Expr {
+		ref := captureUnlessReusable(init, js_ast.Expr{})
+		return js_ast.Expr{Loc: init.Loc, Data: &js_ast.EIf{
+			Test: js_ast.Expr{Loc: init.Loc, Data: &js_ast.EBinary{
+				Op:    js_ast.BinOpStrictEq,
+				Left:  useRef(init.Loc, ref),
+				Right: js_ast.Expr{Loc: init.Loc, Data: js_ast.EUndefinedShared},
+			}},
+			Yes: defaultValue,
+			No:  useRef(init.Loc, ref),
+		}}
+	}
+
+	var visit func(js_ast.Expr, js_ast.Expr)
+	visit = func(expr js_ast.Expr, init js_ast.Expr) {
+		switch e := expr.Data.(type) {
+		case *js_ast.EBinary:
+			if e.Op == js_ast.BinOpAssign {
+				visit(e.Left, withDefaultValue(init, e.Right))
+				return
+			}
+
+		case *js_ast.EArray:
+			hasRest := false
+			if len(e.Items) > 0 {
+				_, hasRest = e.Items[len(e.Items)-1].Data.(*js_ast.ESpread)
+			}
+
+			// "[a, b] = c" => "_a = __readArray(c, 2), a = _a[0], b = _a[1]"
+			if !p.options.assumeIterablesAreArrays {
+				args := []js_ast.Expr{init}
+				if !hasRest {
+					args = append(args, js_ast.Expr{Loc: expr.Loc, Data: &js_ast.ENumber{Value: float64(len(e.Items))}})
+				}
+				init = p.callRuntime(init.Loc, "__readArray", args)
+			}
+
+			// "[] = a" must still evaluate "a" and throw if it's not iterable
+			if len(e.Items) == 0 {
+				if p.options.assumeIterablesAreArrays {
+					init = p.callRuntime(init.Loc, "__requireObjectCoercible", []js_ast.Expr{init})
+				}
+				captureIntoRef(init)
+				return
+			}
+
+			source := func() js_ast.Expr { return init }
+			if len(e.Items) > 1 {
+				ref := captureUnlessReusable(init, expr)
+				source = func() js_ast.Expr { return useRef(init.Loc, ref) }
+			}
+
+			for i, item := range e.Items {
+				index := js_ast.Expr{Loc: item.Loc, Data: &js_ast.ENumber{Value: float64(i)}}
+				switch item2 := item.Data.(type) {
+				case *js_ast.EMissing:
+					continue
+
+				case *js_ast.ESpread:
+					// "[...a] = b" => "a = __readArray(b)" since that's already a new array
+					if i == 0 && !p.options.assumeIterablesAreArrays {
+						visit(item2.Value, source())
+						continue
+					}
+
+					// "[a, ...b] = c" => "a = c[0], b = c.slice(1)"
+					visit(item2.Value, js_ast.Expr{Loc: item.Loc, Data: &js_ast.ECall{
+						Target: js_ast.Expr{Loc: item.Loc, Data: &js_ast.EDot{Target: source(), Name: "slice", NameLoc: item.Loc}},
+						Args:   []js_ast.Expr{index},
+					}})
+					continue
+				}
+				visit(item, js_ast.Expr{Loc: item.Loc, Data: &js_ast.EIndex{Target: source(), Index: index}})
+			}
+			return
+
+		case *js_ast.EObject:
+			// "({} = a)" must still evaluate "a" and throw if it's null or undefined
+			if len(e.Properties) == 0 {
+				captureIntoRef(p.callRuntime(init.Loc, "__requireObjectCoercible", []js_ast.Expr{init}))
+				return
+			}
+
+			source := func() js_ast.Expr { return init }
+			if len(e.Properties) > 1 {
+				ref := captureUnlessReusable(init, expr)
+				source = func() js_ast.Expr { return useRef(init.Loc, ref) }
+			}
+
+			last := len(e.Properties) - 1
+			endsWithRestBinding := e.Properties[last].Kind == js_ast.PropertySpread
+			var capturedKeys []func() js_ast.Expr
+
+			for i := range e.Properties {
+				property := &e.Properties[i]
+
+				// "({a, ...b} = c)" => "a = c.a, b = __objRest(c, ['a'])"
+				if property.Kind == js_ast.PropertySpread {
+					keysToExclude := make([]js_ast.Expr, len(capturedKeys))
+					for i, capturedKey := range capturedKeys {
+						keysToExclude[i] = capturedKey()
+					}
+					visit(property.ValueOrNil, p.callRuntime(property.ValueOrNil.Loc, "__objRest", []js_ast.Expr{source(),
+						{Loc: property.ValueOrNil.Loc, Data: &js_ast.EArray{Items: keysToExclude, IsSingleLine: e.IsSingleLine}}}))
+					continue
+				}
+
+				// Save a copy of this key so the rest binding can exclude it
+				key := property.Key
+				if endsWithRestBinding {
+					var capturedKey func() js_ast.Expr
+					key, capturedKey = p.captureKeyForObjectRest(key)
+					capturedKeys = append(capturedKeys, capturedKey)
+				}
+
+				// "({a: b} = c)" => "b = c.a"
+				var value js_ast.Expr
+				if str, ok := key.Data.(*js_ast.EString); ok && !property.IsComputed && js_lexer.IsIdentifierUTF16(str.Value) {
+					value = js_ast.Expr{Loc: key.Loc, Data: &js_ast.EDot{Target: source(), Name: js_lexer.UTF16ToString(str.Value), NameLoc: key.Loc}}
+				} else {
+					value = js_ast.Expr{Loc: key.Loc, Data: &js_ast.EIndex{Target: source(), Index: key}}
+				}
+				if property.InitializerOrNil.Data != nil {
+					value = withDefaultValue(value, property.InitializerOrNil)
+				}
+				visit(property.ValueOrNil, value)
+			}
+			return
+		}
+
+		assign(expr, init)
+	}
+
+	// "console.log([a] = b)" => "console.log((_a = b, a = _a[0], _a))"
+	if mode == objRestMustReturnInitExpr {
+		ref := captureIntoRef(rootInit)
+		rootInit = useRef(rootInit.Loc, ref)
+		wrapFunc = func(expr js_ast.Expr) js_ast.Expr {
+			return js_ast.JoinWithComma(expr, useRef(rootInit.Loc, ref))
+		}
+	}
+
+	visit(rootExpr, rootInit)
+	return wrapFunc, true
+}
+
+func patternAssignsToRef(expr js_ast.Expr, ref js_ast.Ref) bool {
+	switch e := expr.Data.(type) {
+	case *js_ast.EIdentifier:
+		return e.Ref == ref
+	case *js_ast.EBinary:
+		return e.Op == js_ast.BinOpAssign && patternAssignsToRef(e.Left, ref)
+	case *js_ast.ESpread:
+		return patternAssignsToRef(e.Value, ref)
+	case *js_ast.EArray:
+		for _, item := range e.Items {
+			if patternAssignsToRef(item, ref) {
+				return true
+			}
+		}
+	case *js_ast.EObject:
+		for _, property := range e.Properties {
+			if patternAssignsToRef(property.ValueOrNil, ref) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
 type classLoweringInfo struct {
 	useDefineForClassFields bool
 	avoidTDZ                bool
@@ -1781,6 +2243,42 @@ type classLoweringInfo struct {
 	lowerAllStaticFields    bool
 }
 
+// Decorated properties with computed keys store the key in a temporary
+// variable, so the key can always be cloned without side effects
+func (p *parser) cloneDecoratedKey(key js_ast.Expr) js_ast.Expr {
+	switch k := key.Data.(type) {
+	case *js_ast.ENumber:
+		return js_ast.Expr{Loc: key.Loc, Data: &js_ast.ENumber{Value: k.Value}}
+	case *js_ast.EString:
+		return js_ast.Expr{Loc: key.Loc, Data: &js_ast.EString{Value: k.Value}}
+	case *js_ast.EIdentifier:
+		return js_ast.Expr{Loc: key.Loc, Data: &js_ast.EIdentifier{Ref: k.Ref}}
+	default:
+		panic("Internal error")
+	}
+}
+
+// Standard decorators and the "accessor" keyword come from the same proposal
+// and are lowered together. TypeScript's legacy decorators are handled
+// separately and are always lowered.
+func (p *parser) shouldLowerStandardDecorators(class *js_ast.Class) (lowerAutoAccessors bool, lowerDecorators bool) {
+	if !p.options.unsupportedJSFeatures.Has(compat.Decorators) {
+		return
+	}
+	isLegacy := p.isUsingLegacyDecorators()
+	lowerDecorators = !isLegacy && len(class.Decorators) > 0
+	for _, prop := range class.Properties {
+		if prop.Kind == js_ast.PropertyAutoAccessor {
+			lowerAutoAccessors = true
+		}
+		if !isLegacy && len(prop.Decorators) > 0 {
+			lowerDecorators = true
+		}
+	}
+	lowerAutoAccessors = lowerAutoAccessors || lowerDecorators
+	return
+}
+
 func (p *parser) computeClassLoweringInfo(class *js_ast.Class) (result classLoweringInfo) {
 	// TypeScript has legacy behavior that uses assignment semantics instead of
 	// define semantics for class fields by default. This happened before class
@@ -1793,6 +2291,14 @@ func (p *parser) computeClassLoweringInfo(class *js_ast.Class) (result classLowe
 	// Safari workaround: Automatically avoid TDZ issues when bundling
 	result.avoidTDZ = p.options.mode == config.ModeBundle && p.currentScope.Parent == nil
 
+	// Lowering standard decorators and auto-accessors moves the initializers of
+	// decorated fields and of auto-accessor storage out of the class body. All
+	// fields are lowered in that case to preserve their evaluation order.
+	if lowerAutoAccessors, _ := p.shouldLowerStandardDecorators(class); lowerAutoAccessors {
+		result.lowerAllInstanceFields = true
+		result.lowerAllStaticFields = true
+	}
+
 	// Conservatively lower fields of a given type (instance or static) when any
 	// member of that type needs to be lowered. This must be done to preserve
 	// evaluation order. For example:
@@ -1980,7 +2486,7 @@ func (p *parser) computeClassLoweringInfo(class *js_ast.Class) (result classLowe
 
 // Lower class fields for environments that don't support them. This either
 // takes a statement or an expression.
-func (p *parser) lowerClass(stmt js_ast.Stmt, expr js_ast.Expr, shadowRef js_ast.Ref) ([]js_ast.Stmt, js_ast.Expr) {
+func (p *parser) lowerClass(stmt js_ast.Stmt, expr js_ast.Expr, result visitClassResult) ([]js_ast.Stmt, js_ast.Expr) {
 	type classKind uint8
 	const (
 		classKindExpr classKind = iota
@@ -1989,6 +2495,9 @@ func (p *parser) lowerClass(stmt js_ast.Stmt, expr js_ast.Expr, shadowRef js_ast
 		classKindExportDefaultStmt
 	)
 
+	shadowRef := result.shadowRef
+	lowerToES5 := result.loweredClass != nil
+
 	// Unpack the class from the statement or expression
 	var kind classKind
 	var class *js_ast.Class
@@ -2010,8 +2519,9 @@ func (p *parser) lowerClass(stmt js_ast.Stmt, expr js_ast.Expr, shadowRef js_ast
 			}
 
 			// Remove unused class names when minifying. Check this after we merge in
-			// the shadowing name above since that will adjust the use count.
-			if p.options.mangleSyntax && symbol.UseCountEstimate == 0 {
+			// the shadowing name above since that will adjust the use count. Classes
+			// lowered to ES5 still need the name for the constructor function.
+			if p.options.mangleSyntax && symbol.UseCountEstimate == 0 && !lowerToES5 {
 				class.Name = nil
 			}
 		}
@@ -2041,6 +2551,43 @@ func (p *parser) lowerClass(stmt js_ast.Stmt, expr js_ast.Expr, shadowRef js_ast
 		classLoc = stmt.Loc
 	}
 
+	// Classes lowered to ES5 become a constructor function that is declared
+	// inside a wrapper function along with all code generated for the class
+	// body. This is the name of that constructor function.
+	es5ClassRef := js_ast.InvalidRef
+	isES5ClassPure := false
+	if lowerToES5 {
+		if kind == classKindExportDefaultStmt {
+			es5ClassRef = defaultName.Ref
+		} else if class.Name != nil {
+			es5ClassRef = class.Name.Ref
+		} else {
+			es5ClassRef = p.newSymbol(js_ast.SymbolOther, "_class")
+			p.currentScope.Generated = append(p.currentScope.Generated, es5ClassRef)
+		}
+		if kind != classKindExpr && shadowRef != js_ast.InvalidRef {
+			p.mergeSymbols(shadowRef, es5ClassRef)
+		}
+
+		// The wrapper function call can be removed if the class could have been
+		isES5ClassPure = len(class.Decorators) == 0 && p.classCanBeRemovedIfUnused(*class)
+		for _, prop := range class.Properties {
+			if len(prop.Decorators) > 0 {
+				isES5ClassPure = false
+			}
+		}
+	}
+
+	// Instance fields are initialized on the object returned by the lowered
+	// "super()" call inside constructors of derived classes lowered to ES5
+	thisFunc := func(loc logger.Loc) js_ast.Expr {
+		if lowerToES5 && result.loweredClass.ctorThisRef != js_ast.InvalidRef {
+			p.recordUsage(result.loweredClass.ctorThisRef)
+			return js_ast.Expr{Loc: loc, Data: &js_ast.EIdentifier{Ref: result.loweredClass.ctorThisRef}}
+		}
+		return js_ast.Expr{Loc: loc, Data: js_ast.EThisShared}
+	}
+
 	var ctor *js_ast.EFunction
 	var parameterFields []js_ast.Stmt
 	var instanceMembers []js_ast.Stmt
@@ -2068,7 +2615,12 @@ func (p *parser) lowerClass(stmt js_ast.Stmt, expr js_ast.Expr, shadowRef js_ast
 	//   }
 	//
 	nameFunc = func() js_ast.Expr {
-		if kind == classKindExpr {
+		if lowerToES5 {
+			// Everything generated for the class body references the constructor
+			// function inside the wrapper function
+			p.recordUsage(es5ClassRef)
+			return js_ast.Expr{Loc: classLoc, Data: &js_ast.EIdentifier{Ref: es5ClassRef}}
+		} else if kind == classKindExpr {
 			// If this is a class expression, capture and store it. We have to
 			// do this even if it has a name since the name isn't exposed
 			// outside the class body.
@@ -2117,14 +2669,273 @@ func (p *parser) lowerClass(stmt js_ast.Stmt, expr js_ast.Expr, shadowRef js_ast
 
 	classLoweringInfo := p.computeClassLoweringInfo(class)
 
-	for _, prop := range class.Properties {
+	// Standard decorators are only lowered if the target doesn't support them,
+	// but legacy TypeScript decorators are always lowered. Either way, lowered
+	// decorators are removed from the class.
+	lowerAutoAccessors, lowerStandardDecorators := p.shouldLowerStandardDecorators(class)
+	var classDecorators []js_ast.Expr
+	if p.isUsingLegacyDecorators() || lowerStandardDecorators {
+		classDecorators = class.Decorators
+		class.Decorators = nil
+	}
+
+	// Standard decorators are evaluated before the class is defined and are
+	// applied after the class is defined. Their results are stored in an array
+	// that is created by "__decoratorStart()":
+	//
+	//   [0] is the shared metadata object
+	//   [1] holds the extra initializers of the class itself
+	//   [3] holds the extra initializers of static methods and accessors
+	//   [5] holds the extra initializers of instance methods and accessors
+	//   [6+] hold the initializers of each decorated field or accessor, with
+	//        the extra initializers of fields in the following odd slot
+	//
+	var decoratorPrelude []js_ast.Expr
+	var staticMethodDecorations []js_ast.Expr
+	var instanceMethodDecorations []js_ast.Expr
+	var staticFieldDecorations []js_ast.Expr
+	var instanceFieldDecorations []js_ast.Expr
+	var classDecorations []js_ast.Expr
+	decoratorArrayRef := js_ast.InvalidRef
+	hasStaticExtraInitializers := false
+	hasInstanceExtraInitializers := false
+	nextDecoratorSlot := 6
+	if lowerStandardDecorators {
+		decoratorArrayRef = p.generateTempRef(tempRefNeedsDeclare, "_init")
+	}
+	decoratorArray := func(loc logger.Loc) js_ast.Expr {
+		p.recordUsage(decoratorArrayRef)
+		return js_ast.Expr{Loc: loc, Data: &js_ast.EIdentifier{Ref: decoratorArrayRef}}
+	}
+	hoistDecorators := func(loc logger.Loc, name string, decorators []js_ast.Expr) js_ast.Expr {
+		tempName := "_dec"
+		if name != "" {
+			tempName = "_" + js_ast.EnsureValidIdentifier(name) + "_dec"
+		}
+		ref := p.generateTempRef(tempRefNeedsDeclare, tempName)
+		decoratorPrelude = append(decoratorPrelude, js_ast.Assign(
+			js_ast.Expr{Loc: loc, Data: &js_ast.EIdentifier{Ref: ref}},
+			js_ast.Expr{Loc: loc, Data: &js_ast.EArray{Items: decorators}},
+		))
+		p.recordUsage(ref)
+		return js_ast.Expr{Loc: loc, Data: &js_ast.EIdentifier{Ref: ref}}
+	}
+	decorateElement := func(loc logger.Loc, flags int, name js_ast.Expr, decorators js_ast.Expr, target js_ast.Expr, extra js_ast.Expr) js_ast.Expr {
+		args := []js_ast.Expr{decoratorArray(loc), {Loc: loc, Data: &js_ast.ENumber{Value: float64(flags)}}, name, decorators, target}
+		if extra.Data != nil {
+			args = append(args, extra)
+		}
+		return p.callRuntime(loc, "__decorateElement", args)
+	}
+	runInitializers := func(loc logger.Loc, slot int, target js_ast.Expr, value js_ast.Expr) js_ast.Expr {
+		args := []js_ast.Expr{decoratorArray(loc), {Loc: loc, Data: &js_ast.ENumber{Value: float64(slot)}}, target}
+		if value.Data != nil {
+			args = append(args, value)
+		}
+		return p.callRuntime(loc, "__runInitializers", args)
+	}
+
+	// Class decorators are evaluated before member decorators
+	var classDecoratorsExpr js_ast.Expr
+	if lowerStandardDecorators && len(classDecorators) > 0 {
+		classDecoratorsExpr = hoistDecorators(classLoc, nameToKeep, classDecorators)
+	}
+
+	// Auto-accessors are lowered to a getter and setter pair that stores the
+	// value in a private field:
+	//
+	//   class Foo {
+	//     accessor x = 1
+	//   }
+	//
+	// becomes:
+	//
+	//   var _x;
+	//   class Foo {
+	//     constructor() {
+	//       __privateAdd(this, _x, 1);
+	//     }
+	//     get x() {
+	//       return __privateGet(this, _x);
+	//     }
+	//     set x(_) {
+	//       __privateSet(this, _x, _);
+	//     }
+	//   }
+	//   _x = new WeakMap();
+	//
+	// Decorating a lowered auto-accessor is deferred until the main loop below
+	// reaches it so that decorators are still evaluated in source order.
+	var autoAccessorDecorations map[int]func()
+	if lowerAutoAccessors {
+		properties := make([]js_ast.Property, 0, len(class.Properties))
+		for _, prop := range class.Properties {
+			if prop.Kind != js_ast.PropertyAutoAccessor {
+				properties = append(properties, prop)
+				continue
+			}
+			loc := prop.Key.Loc
+			private, _ := prop.Key.Data.(*js_ast.EPrivateIdentifier)
+
+			// Generate a private field to hold the value of the accessor
+			name := "accessor"
+			if private != nil {
+				name = p.symbols[private.Ref.InnerIndex].OriginalName[1:]
+			} else if str, ok := prop.Key.Data.(*js_ast.EString); ok && !prop.IsComputed {
+				name = js_ast.EnsureValidIdentifier(js_lexer.UTF16ToString(str.Value))
+			}
+			storageKind := js_ast.SymbolPrivateField
+			if prop.IsStatic {
+				storageKind = js_ast.SymbolPrivateStaticField
+			}
+			storageRef := p.newSymbol(storageKind, "#"+name)
+			p.symbols[storageRef.InnerIndex].PrivateSymbolMustBeLowered = true
+			storage := func() js_ast.Expr {
+				return js_ast.Expr{Loc: loc, Data: &js_ast.EIdentifier{Ref: storageRef}}
+			}
+
+			// Computed keys are shared by the getter and the setter
+			getterKey := prop.Key
+			setterKey := prop.Key
+			if private != nil {
+				setterKey = js_ast.Expr{Loc: loc, Data: &js_ast.EPrivateIdentifier{Ref: private.Ref}}
+			} else if prop.IsComputed {
+				ref := p.generateTempRef(tempRefNeedsDeclare, "")
+				getterKey = js_ast.Assign(js_ast.Expr{Loc: loc, Data: &js_ast.EIdentifier{Ref: ref}}, prop.Key)
+				setterKey = js_ast.Expr{Loc: loc, Data: &js_ast.EIdentifier{Ref: ref}}
+			}
+
+			// Standard decorators on auto-accessors are applied to the getter and
+			// setter pair at once and may also replace the initial value
+			init := prop.InitializerOrNil
+			var getterDecorators []js_ast.Expr
+			if len(prop.Decorators) > 0 && lowerStandardDecorators {
+				slot := nextDecoratorSlot
+				nextDecoratorSlot += 2
+				flags := 4 | (slot << 5)
+				var target js_ast.Expr
+				if prop.IsStatic {
+					flags |= 8
+					target = nameFunc()
+				} else {
+					target = thisFunc(loc)
+				}
+				init = runInitializers(loc, slot, target, init)
+
+				if autoAccessorDecorations == nil {
+					autoAccessorDecorations = make(map[int]func())
+				}
+				propDecorators := prop.Decorators
+				isStatic := prop.IsStatic
+				autoAccessorDecorations[len(properties)] = func() {
+					var decoration js_ast.Expr
+					decorators := hoistDecorators(loc, name, propDecorators)
+					if private != nil {
+						// Private accessors are replaced by the returned getter and setter
+						getterRef := p.privateGetters[private.Ref]
+						setterRef := p.privateSetters[private.Ref]
+						descRef := p.generateTempRef(tempRefNeedsDeclare, "")
+						decoration = js_ast.JoinWithComma(
+							js_ast.JoinWithComma(
+								js_ast.Assign(js_ast.Expr{Loc: loc, Data: &js_ast.EIdentifier{Ref: descRef}}, decorateElement(loc, flags|16,
+									js_ast.Expr{Loc: loc, Data: &js_ast.EString{Value: js_lexer.StringToUTF16("#" + name)}},
+									decorators, js_ast.Expr{Loc: loc, Data: &js_ast.EIdentifier{Ref: private.Ref}},
+									js_ast.Expr{Loc: loc, Data: &js_ast.EObject{Properties: []js_ast.Property{
+										{Key: js_ast.Expr{Loc: loc, Data: &js_ast.EString{Value: js_lexer.StringToUTF16("get")}},
+											ValueOrNil: js_ast.Expr{Loc: loc, Data: &js_ast.EIdentifier{Ref: getterRef}}},
+										{Key: js_ast.Expr{Loc: loc, Data: &js_ast.EString{Value: js_lexer.StringToUTF16("set")}},
+											ValueOrNil: js_ast.Expr{Loc: loc, Data: &js_ast.EIdentifier{Ref: setterRef}}},
+									}}})),
+								js_ast.Assign(js_ast.Expr{Loc: loc, Data: &js_ast.EIdentifier{Ref: getterRef}}, js_ast.Expr{Loc: loc, Data: &js_ast.EDot{
+									Target: js_ast.Expr{Loc: loc, Data: &js_ast.EIdentifier{Ref: descRef}}, Name: "get", NameLoc: loc}}),
+							),
+							js_ast.Assign(js_ast.Expr{Loc: loc, Data: &js_ast.EIdentifier{Ref: setterRef}}, js_ast.Expr{Loc: loc, Data: &js_ast.EDot{
+								Target: js_ast.Expr{Loc: loc, Data: &js_ast.EIdentifier{Ref: descRef}}, Name: "set", NameLoc: loc}}),
+						)
+					} else {
+						decoration = decorateElement(loc, flags, p.cloneDecoratedKey(setterKey), decorators, nameFunc(), js_ast.Expr{})
+					}
+					if isStatic {
+						staticMethodDecorations = append(staticMethodDecorations, decoration)
+						hasStaticExtraInitializers = true
+					} else {
+						instanceMethodDecorations = append(instanceMethodDecorations, decoration)
+						hasInstanceExtraInitializers = true
+					}
+				}
+			} else {
+				// Legacy decorators treat the accessor like a getter
+				getterDecorators = prop.Decorators
+			}
+
+			// "accessor x = 1" => "#x = 1"
+			properties = append(properties, js_ast.Property{
+				IsStatic:         prop.IsStatic,
+				Key:              js_ast.Expr{Loc: loc, Data: &js_ast.EPrivateIdentifier{Ref: storageRef}},
+				InitializerOrNil: init,
+			})
+
+			// "get x() { return __privateGet(this, _x) }"
+			properties = append(properties, js_ast.Property{
+				Decorators: getterDecorators,
+				Kind:       js_ast.PropertyGet,
+				IsComputed: prop.IsComputed,
+				IsMethod:   true,
+				IsStatic:   prop.IsStatic,
+				Key:        getterKey,
+				ValueOrNil: js_ast.Expr{Loc: loc, Data: &js_ast.EFunction{Fn: js_ast.Fn{Body: js_ast.FnBody{Loc: loc, Stmts: []js_ast.Stmt{
+					{Loc: loc, Data: &js_ast.SReturn{ValueOrNil: p.callRuntime(loc, "__privateGet", []js_ast.Expr{
+						{Loc: loc, Data: js_ast.EThisShared},
+						storage(),
+					})}},
+				}}}}},
+			})
+
+			// "set x(_) { __privateSet(this, _x, _) }"
+			valueRef := p.newSymbol(js_ast.SymbolHoisted, "_")
+			p.currentScope.Generated = append(p.currentScope.Generated, valueRef)
+			properties = append(properties, js_ast.Property{
+				Kind:       js_ast.PropertySet,
+				IsComputed: prop.IsComputed,
+				IsMethod:   true,
+				IsStatic:   prop.IsStatic,
+				Key:        setterKey,
+				ValueOrNil: js_ast.Expr{Loc: loc, Data: &js_ast.EFunction{Fn: js_ast.Fn{
+					Args: []js_ast.Arg{{Binding: js_ast.Binding{Loc: loc, Data: &js_ast.BIdentifier{Ref: valueRef}}}},
+					Body: js_ast.FnBody{Loc: loc, Stmts: []js_ast.Stmt{
+						{Loc: loc, Data: &js_ast.SExpr{Value: p.callRuntime(loc, "__privateSet", []js_ast.Expr{
+							{Loc: loc, Data: js_ast.EThisShared},
+							storage(),
+							{Loc: loc, Data: &js_ast.EIdentifier{Ref: valueRef}},
+						})}},
+					}},
+				}}},
+			})
+		}
+		class.Properties = properties
+	}
+
+	for i, prop := range class.Properties {
+		if decorate := autoAccessorDecorations[i]; decorate != nil {
+			decorate()
+		}
+
+		// Auto-accessors are kept as-is if they aren't lowered
+		if prop.Kind == js_ast.PropertyAutoAccessor {
+			class.Properties[end] = prop
+			end++
+			continue
+		}
+
 		if prop.Kind == js_ast.PropertyClassStaticBlock {
 			if p.options.unsupportedJSFeatures.Has(compat.ClassStaticBlocks) {
 				if block := *prop.ClassStaticBlock; len(block.Stmts) > 0 {
+					var fn js_ast.E = &js_ast.EArrow{Body: js_ast.FnBody{Stmts: block.Stmts}}
+					if p.options.unsupportedJSFeatures.Has(compat.Arrow) {
+						fn = &js_ast.EFunction{Fn: js_ast.Fn{Body: js_ast.FnBody{Stmts: block.Stmts}}}
+					}
 					staticMembers = append(staticMembers, js_ast.Expr{Loc: block.Loc, Data: &js_ast.ECall{
-						Target: js_ast.Expr{Loc: block.Loc, Data: &js_ast.EArrow{Body: js_ast.FnBody{
-							Stmts: block.Stmts,
-						}}},
+						Target: js_ast.Expr{Loc: block.Loc, Data: fn},
 					}})
 				}
 				continue
@@ -2136,31 +2947,6 @@ func (p *parser) lowerClass(stmt js_ast.Stmt, expr js_ast.Expr, shadowRef js_ast
 			continue
 		}
 
-		// Merge parameter decorators with method decorators
-		if p.options.ts.Parse && prop.IsMethod {
-			if fn, ok := prop.ValueOrNil.Data.(*js_ast.EFunction); ok {
-				isConstructor := false
-				if key, ok := prop.Key.Data.(*js_ast.EString); ok {
-					isConstructor = js_lexer.UTF16EqualsString(key.Value, "constructor")
-				}
-				for i, arg := range fn.Fn.Args {
-					for _, decorator := range arg.TSDecorators {
-						// Generate a call to "__decorateParam()" for this parameter decorator
-						var decorators *[]js_ast.Expr = &prop.TSDecorators
-						if isConstructor {
-							decorators = &class.TSDecorators
-						}
-						*decorators = append(*decorators,
-							p.callRuntime(decorator.Loc, "__decorateParam", []js_ast.Expr{
-								{Loc: decorator.Loc, Data: &js_ast.ENumber{Value: float64(i)}},
-								decorator,
-							}),
-						)
-					}
-				}
-			}
-		}
-
 		// The TypeScript class field transform requires removing fields without
 		// initializers. If the field is removed, then we only need the key for
 		// its side effects and we don't need a temporary reference for the key.
@@ -2192,10 +2978,10 @@ func (p *parser) lowerClass(stmt js_ast.Stmt, expr js_ast.Expr, shadowRef js_ast
 		// Make sure the order of computed property keys doesn't change. These
 		// expressions have side effects and must be evaluated in order.
 		keyExprNoSideEffects := prop.Key
-		if prop.IsComputed && (len(prop.TSDecorators) > 0 ||
+		if prop.IsComputed && (len(prop.Decorators) > 0 ||
 			mustLowerField || computedPropertyCache.Data != nil) {
 			needsKey := true
-			if len(prop.TSDecorators) == 0 && (prop.IsMethod || shouldOmitFieldInitializer || !mustLowerField) {
+			if len(prop.Decorators) == 0 && (prop.IsMethod || shouldOmitFieldInitializer || !mustLowerField) {
 				needsKey = false
 			}
 
@@ -2221,23 +3007,14 @@ func (p *parser) lowerClass(stmt js_ast.Stmt, expr js_ast.Expr, shadowRef js_ast
 		}
 
 		// Handle decorators
-		if p.options.ts.Parse {
+		decoratorSlot := 0
+		if p.isUsingLegacyDecorators() {
 			// Generate a single call to "__decorateClass()" for this property
-			if len(prop.TSDecorators) > 0 {
+			if len(prop.Decorators) > 0 {
 				loc := prop.Key.Loc
 
 				// Clone the key for the property descriptor
-				var descriptorKey js_ast.Expr
-				switch k := keyExprNoSideEffects.Data.(type) {
-				case *js_ast.ENumber:
-					descriptorKey = js_ast.Expr{Loc: loc, Data: &js_ast.ENumber{Value: k.Value}}
-				case *js_ast.EString:
-					descriptorKey = js_ast.Expr{Loc: loc, Data: &js_ast.EString{Value: k.Value}}
-				case *js_ast.EIdentifier:
-					descriptorKey = js_ast.Expr{Loc: loc, Data: &js_ast.EIdentifier{Ref: k.Ref}}
-				default:
-					panic("Internal error")
-				}
+				descriptorKey := p.cloneDecoratedKey(keyExprNoSideEffects)
 
 				// This code tells "__decorateClass()" if the descriptor should be undefined
 				descriptorKind := float64(1)
@@ -2254,7 +3031,7 @@ func (p *parser) lowerClass(stmt js_ast.Stmt, expr js_ast.Expr, shadowRef js_ast
 				}
 
 				decorator := p.callRuntime(loc, "__decorateClass", []js_ast.Expr{
-					{Loc: loc, Data: &js_ast.EArray{Items: prop.TSDecorators}},
+					{Loc: loc, Data: &js_ast.EArray{Items: prop.Decorators}},
 					target,
 					descriptorKey,
 					{Loc: loc, Data: &js_ast.ENumber{Value: descriptorKind}},
@@ -2266,7 +3043,79 @@ func (p *parser) lowerClass(stmt js_ast.Stmt, expr js_ast.Expr, shadowRef js_ast
 				} else {
 					instanceDecorators = append(instanceDecorators, decorator)
 				}
+				prop.Decorators = nil
+			}
+		} else if len(prop.Decorators) > 0 && lowerStandardDecorators {
+			// Generate a single call to "__decorateElement()" for this property
+			loc := prop.Key.Loc
+			flags := 5
+			switch {
+			case prop.Kind == js_ast.PropertyGet:
+				flags = 2
+			case prop.Kind == js_ast.PropertySet:
+				flags = 3
+			case prop.IsMethod:
+				flags = 1
+			default:
+				decoratorSlot = nextDecoratorSlot
+				nextDecoratorSlot += 2
+				flags |= decoratorSlot << 5
+			}
+			if prop.IsStatic {
+				flags |= 8
+			}
+
+			// Private members are decorated through their lowered representation
+			var name string
+			var nameExpr js_ast.Expr
+			var target js_ast.Expr
+			var extra js_ast.Expr
+			fnRef := js_ast.InvalidRef
+			if private != nil {
+				originalName := p.symbols[private.Ref.InnerIndex].OriginalName
+				flags |= 16
+				name = originalName[1:]
+				nameExpr = js_ast.Expr{Loc: loc, Data: &js_ast.EString{Value: js_lexer.StringToUTF16(originalName)}}
+				target = js_ast.Expr{Loc: loc, Data: &js_ast.EIdentifier{Ref: private.Ref}}
+				if prop.IsMethod {
+					if prop.Kind == js_ast.PropertySet {
+						fnRef = p.privateSetters[private.Ref]
+					} else {
+						fnRef = p.privateGetters[private.Ref]
+					}
+					extra = js_ast.Expr{Loc: loc, Data: &js_ast.EIdentifier{Ref: fnRef}}
+				}
+			} else {
+				nameExpr = p.cloneDecoratedKey(keyExprNoSideEffects)
+				if str, ok := nameExpr.Data.(*js_ast.EString); ok {
+					name = js_lexer.UTF16ToString(str.Value)
+				}
+				target = nameFunc()
+			}
+			decoration := decorateElement(loc, flags, nameExpr, hoistDecorators(loc, name, prop.Decorators), target, extra)
+
+			// Decorators on private methods and accessors may replace the function
+			if fnRef != js_ast.InvalidRef {
+				decoration = js_ast.Assign(js_ast.Expr{Loc: loc, Data: &js_ast.EIdentifier{Ref: fnRef}}, decoration)
 			}
+
+			// Methods and accessors are decorated before fields
+			switch {
+			case prop.IsMethod && prop.IsStatic:
+				staticMethodDecorations = append(staticMethodDecorations, decoration)
+				hasStaticExtraInitializers = true
+			case prop.IsMethod:
+				instanceMethodDecorations = append(instanceMethodDecorations, decoration)
+				hasInstanceExtraInitializers = true
+			case prop.IsStatic:
+				staticFieldDecorations = append(staticFieldDecorations, decoration)
+			default:
+				instanceFieldDecorations = append(instanceFieldDecorations, decoration)
+			}
+			prop.Decorators = nil
+
+			// Decorated fields must always be initialized
+			shouldOmitFieldInitializer = false
 		}
 
 		// Handle lowering of instance and static fields. Move their initializers
@@ -2284,7 +3133,7 @@ func (p *parser) lowerClass(stmt js_ast.Stmt, expr js_ast.Expr, shadowRef js_ast
 				if prop.IsStatic {
 					target = nameFunc()
 				} else {
-					target = js_ast.Expr{Loc: loc, Data: js_ast.EThisShared}
+					target = thisFunc(loc)
 				}
 
 				// Generate the assignment initializer
@@ -2295,6 +3144,19 @@ func (p *parser) lowerClass(stmt js_ast.Stmt, expr js_ast.Expr, shadowRef js_ast
 					init = js_ast.Expr{Loc: loc, Data: js_ast.EUndefinedShared}
 				}
 
+				// Let field decorators replace the initial value
+				var extraInitializers js_ast.Expr
+				if decoratorSlot != 0 {
+					self := func() js_ast.Expr {
+						if prop.IsStatic {
+							return nameFunc()
+						}
+						return thisFunc(loc)
+					}
+					init = runInitializers(loc, decoratorSlot, self(), init)
+					extraInitializers = runInitializers(loc, decoratorSlot+1, self(), js_ast.Expr{})
+				}
+
 				// Generate the assignment target
 				var memberExpr js_ast.Expr
 				if mustLowerPrivate {
@@ -2346,9 +3208,15 @@ func (p *parser) lowerClass(stmt js_ast.Stmt, expr js_ast.Expr, shadowRef js_ast
 				if prop.IsStatic {
 					// Move this property to an assignment after the class ends
 					staticMembers = append(staticMembers, memberExpr)
+					if extraInitializers.Data != nil {
+						staticMembers = append(staticMembers, extraInitializers)
+					}
 				} else {
 					// Move this property to an assignment inside the class constructor
 					instanceMembers = append(instanceMembers, js_ast.Stmt{Loc: loc, Data: &js_ast.SExpr{Value: memberExpr}})
+					if extraInitializers.Data != nil {
+						instanceMembers = append(instanceMembers, js_ast.Stmt{Loc: loc, Data: &js_ast.SExpr{Value: extraInitializers}})
+					}
 				}
 			}
 
@@ -2387,7 +3255,7 @@ func (p *parser) lowerClass(stmt js_ast.Stmt, expr js_ast.Expr, shadowRef js_ast
 					if prop.IsStatic {
 						target = nameFunc()
 					} else {
-						target = js_ast.Expr{Loc: loc, Data: js_ast.EThisShared}
+						target = thisFunc(loc)
 					}
 
 					// Add every newly-constructed instance into this map
@@ -2438,7 +3306,7 @@ func (p *parser) lowerClass(stmt js_ast.Stmt, expr js_ast.Expr, shadowRef js_ast
 								if id, ok := arg.Binding.Data.(*js_ast.BIdentifier); ok {
 									parameterFields = append(parameterFields, js_ast.AssignStmt(
 										js_ast.Expr{Loc: arg.Binding.Loc, Data: &js_ast.EDot{
-											Target:  js_ast.Expr{Loc: arg.Binding.Loc, Data: js_ast.EThisShared},
+											Target:  thisFunc(arg.Binding.Loc),
 											Name:    p.symbols[id.Ref.InnerIndex].OriginalName,
 											NameLoc: arg.Binding.Loc,
 										}},
@@ -2460,6 +3328,38 @@ func (p *parser) lowerClass(stmt js_ast.Stmt, expr js_ast.Expr, shadowRef js_ast
 	// Finish the filtering operation
 	class.Properties = class.Properties[:end]
 
+	// Standard decorators are applied after the class body has been defined.
+	// Methods and accessors are decorated before fields, and the class itself
+	// is decorated after its static fields have been initialized.
+	if lowerStandardDecorators {
+		privateMembers = append(privateMembers, js_ast.Assign(decoratorArray(classLoc),
+			p.callRuntime(classLoc, "__decoratorStart", []js_ast.Expr{nameFunc()})))
+		privateMembers = append(privateMembers, staticMethodDecorations...)
+		privateMembers = append(privateMembers, instanceMethodDecorations...)
+		privateMembers = append(privateMembers, staticFieldDecorations...)
+		privateMembers = append(privateMembers, instanceFieldDecorations...)
+		if hasStaticExtraInitializers {
+			staticMembers = append([]js_ast.Expr{runInitializers(classLoc, 3, nameFunc(), js_ast.Expr{})}, staticMembers...)
+		}
+		if hasInstanceExtraInitializers {
+			instanceMembers = append([]js_ast.Stmt{{Loc: classLoc, Data: &js_ast.SExpr{
+				Value: runInitializers(classLoc, 5, thisFunc(classLoc), js_ast.Expr{}),
+			}}}, instanceMembers...)
+		}
+		if classDecoratorsExpr.Data != nil {
+			classDecorations = append(classDecorations, js_ast.Assign(nameFunc(), decorateElement(classLoc, 0,
+				js_ast.Expr{Loc: classLoc, Data: &js_ast.EString{Value: js_lexer.StringToUTF16(nameToKeep)}},
+				classDecoratorsExpr, nameFunc(), js_ast.Expr{})))
+		}
+		classDecorations = append(classDecorations, p.callRuntime(classLoc, "__decoratorMetadata", []js_ast.Expr{
+			decoratorArray(classLoc),
+			nameFunc(),
+		}))
+		if classDecoratorsExpr.Data != nil {
+			classDecorations = append(classDecorations, runInitializers(classLoc, 1, nameFunc(), js_ast.Expr{}))
+		}
+	}
+
 	// Insert instance field initializers into the constructor
 	if len(parameterFields) > 0 || len(instancePrivateMethods) > 0 || len(instanceMembers) > 0 {
 		// Create a constructor if one doesn't already exist
@@ -2477,10 +3377,17 @@ func (p *parser) lowerClass(stmt js_ast.Stmt, expr js_ast.Expr, shadowRef js_ast
 			if class.ExtendsOrNil.Data != nil {
 				argumentsRef := p.newSymbol(js_ast.SymbolUnbound, "arguments")
 				p.currentScope.Generated = append(p.currentScope.Generated, argumentsRef)
-				ctor.Fn.Body.Stmts = append(ctor.Fn.Body.Stmts, js_ast.Stmt{Loc: classLoc, Data: &js_ast.SExpr{Value: js_ast.Expr{Loc: classLoc, Data: &js_ast.ECall{
-					Target: js_ast.Expr{Loc: classLoc, Data: js_ast.ESuperShared},
-					Args:   []js_ast.Expr{{Loc: classLoc, Data: &js_ast.ESpread{Value: js_ast.Expr{Loc: classLoc, Data: &js_ast.EIdentifier{Ref: argumentsRef}}}}},
-				}}}})
+				arguments := js_ast.Expr{Loc: classLoc, Data: &js_ast.EIdentifier{Ref: argumentsRef}}
+				var superCall js_ast.Expr
+				if lowerToES5 {
+					superCall = p.lowerSuperCall(classLoc, result.loweredClass, js_ast.Expr{Loc: classLoc, Data: js_ast.EThisShared}, arguments)
+				} else {
+					superCall = js_ast.Expr{Loc: classLoc, Data: &js_ast.ECall{
+						Target: js_ast.Expr{Loc: classLoc, Data: js_ast.ESuperShared},
+						Args:   []js_ast.Expr{{Loc: classLoc, Data: &js_ast.ESpread{Value: arguments}}},
+					}}
+				}
+				ctor.Fn.Body.Stmts = append(ctor.Fn.Body.Stmts, js_ast.Stmt{Loc: classLoc, Data: &js_ast.SExpr{Value: superCall}})
 			}
 		}
 
@@ -2488,7 +3395,7 @@ func (p *parser) lowerClass(stmt js_ast.Stmt, expr js_ast.Expr, shadowRef js_ast
 		stmtsFrom := ctor.Fn.Body.Stmts
 		stmtsTo := []js_ast.Stmt{}
 		for i, stmt := range stmtsFrom {
-			if js_ast.IsSuperCall(stmt) {
+			if js_ast.IsSuperCall(stmt) || (lowerToES5 && p.isLoweredSuperCall(stmt, result.loweredClass)) {
 				stmtsTo = append(stmtsTo, stmtsFrom[0:i+1]...)
 				stmtsFrom = stmtsFrom[i+1:]
 				break
@@ -2512,6 +3419,94 @@ func (p *parser) lowerClass(stmt js_ast.Stmt, expr js_ast.Expr, shadowRef js_ast
 		}
 	}
 
+	// Pack the class into a wrapper function when lowering to ES5. All code
+	// generated for the class body goes inside the wrapper function:
+	//
+	//   var Foo = /* @__PURE__ */ (function(_super) {
+	//     __inherit(Foo, _super);
+	//     function Foo() {
+	//       var _this;
+	//       _this = __callSuper(this, _super, []);
+	//       _this.foo = 1;
+	//       return _this;
+	//     }
+	//     Foo.prototype.bar = function() {
+	//     };
+	//     return Foo;
+	//   })(Bar);
+	//
+	if lowerToES5 {
+		body := p.lowerClassBodyToES5(class, classLoc, es5ClassRef, result.loweredClass, ctor, result.instanceThisCaptureRef)
+		if computedPropertyCache.Data != nil {
+			body = append(body, js_ast.Stmt{Loc: computedPropertyCache.Loc, Data: &js_ast.SExpr{Value: computedPropertyCache}})
+		}
+		for _, list := range [][]js_ast.Expr{privateMembers, staticPrivateMethods, staticMembers, instanceDecorators, staticDecorators} {
+			for _, expr := range list {
+				body = append(body, js_ast.Stmt{Loc: expr.Loc, Data: &js_ast.SExpr{Value: expr}})
+			}
+		}
+		if len(classDecorators) > 0 && !lowerStandardDecorators {
+			body = append(body, js_ast.AssignStmt(nameFunc(), p.callRuntime(classLoc, "__decorateClass", []js_ast.Expr{
+				{Loc: classLoc, Data: &js_ast.EArray{Items: classDecorators}},
+				nameFunc(),
+			})))
+		}
+		for _, expr := range classDecorations {
+			body = append(body, js_ast.Stmt{Loc: expr.Loc, Data: &js_ast.SExpr{Value: expr}})
+		}
+		body = append(body, js_ast.Stmt{Loc: classLoc, Data: &js_ast.SReturn{ValueOrNil: nameFunc()}})
+
+		// The base class is passed to the wrapper function
+		var args []js_ast.Arg
+		var callArgs []js_ast.Expr
+		if class.ExtendsOrNil.Data != nil {
+			args = []js_ast.Arg{{Binding: js_ast.Binding{Loc: class.ExtendsOrNil.Loc, Data: &js_ast.BIdentifier{Ref: result.loweredClass.superCtorRef}}}}
+			callArgs = []js_ast.Expr{class.ExtendsOrNil}
+		}
+		value := js_ast.Expr{Loc: classLoc, Data: &js_ast.ECall{
+			Target: js_ast.Expr{Loc: classLoc, Data: &js_ast.EFunction{Fn: js_ast.Fn{
+				Args: args,
+				Body: js_ast.FnBody{Loc: class.BodyLoc, Stmts: body},
+			}}},
+			Args:                   callArgs,
+			CanBeUnwrappedIfUnused: isES5ClassPure,
+		}}
+
+		if kind == classKindExpr {
+			if p.options.keepNames && nameToKeep != "" {
+				value = p.keepExprSymbolName(value, nameToKeep)
+			}
+			for i := len(decoratorPrelude) - 1; i >= 0; i-- {
+				value = js_ast.JoinWithComma(decoratorPrelude[i], value)
+			}
+			return nil, value
+		}
+
+		var stmts []js_ast.Stmt
+		for _, expr := range decoratorPrelude {
+			stmts = append(stmts, js_ast.Stmt{Loc: expr.Loc, Data: &js_ast.SExpr{Value: expr}})
+		}
+		stmts = append(stmts, js_ast.Stmt{Loc: classLoc, Data: &js_ast.SLocal{
+			Kind:     p.selectLocalKind(js_ast.LocalLet),
+			IsExport: kind == classKindExportStmt,
+			Decls: []js_ast.Decl{{
+				Binding:    js_ast.Binding{Loc: classLoc, Data: &js_ast.BIdentifier{Ref: es5ClassRef}},
+				ValueOrNil: value,
+			}},
+		}})
+		if p.options.keepNames && nameToKeep != "" {
+			stmts = append(stmts, p.keepStmtSymbolName(classLoc, es5ClassRef, nameToKeep))
+		}
+
+		// "export default class x {}" => "var x = ...; export {x as default}"
+		if kind == classKindExportDefaultStmt {
+			stmts = append(stmts, js_ast.Stmt{Loc: classLoc, Data: &js_ast.SExportClause{
+				Items: []js_ast.ClauseItem{{Alias: "default", Name: defaultName}},
+			}})
+		}
+		return stmts, js_ast.Expr{}
+	}
+
 	// Pack the class back into an expression. We don't need to handle TypeScript
 	// decorators for class expressions because TypeScript doesn't support them.
 	if kind == classKindExpr {
@@ -2519,7 +3514,8 @@ func (p *parser) lowerClass(stmt js_ast.Stmt, expr js_ast.Expr, shadowRef js_ast
 		// before joining "expr" with any other expressions
 		var nameToJoin js_ast.Expr
 		if didCaptureClassExpr || computedPropertyCache.Data != nil ||
-			len(privateMembers) > 0 || len(staticPrivateMethods) > 0 || len(staticMembers) > 0 {
+			len(privateMembers) > 0 || len(staticPrivateMethods) > 0 || len(staticMembers) > 0 ||
+			len(classDecorations) > 0 {
 			nameToJoin = nameFunc()
 		}
 
@@ -2541,11 +3537,19 @@ func (p *parser) lowerClass(stmt js_ast.Stmt, expr js_ast.Expr, shadowRef js_ast
 		for _, value := range staticMembers {
 			expr = js_ast.JoinWithComma(expr, value)
 		}
+		for _, value := range classDecorations {
+			expr = js_ast.JoinWithComma(expr, value)
+		}
 
 		// Finally join "expr" with the variable that holds the class object
 		if nameToJoin.Data != nil {
 			expr = js_ast.JoinWithComma(expr, nameToJoin)
 		}
+
+		// Decorators must be evaluated before the class is defined
+		for i := len(decoratorPrelude) - 1; i >= 0; i-- {
+			expr = js_ast.JoinWithComma(decoratorPrelude[i], expr)
+		}
 		if wrapFunc != nil {
 			expr = wrapFunc(expr)
 		}
@@ -2563,7 +3567,7 @@ func (p *parser) lowerClass(stmt js_ast.Stmt, expr js_ast.Expr, shadowRef js_ast
 			len(staticMembers) > 0 ||
 			len(instanceDecorators) > 0 ||
 			len(staticDecorators) > 0 ||
-			len(class.TSDecorators) > 0)
+			len(classDecorators) > 0)
 
 	// Optionally preserve the name
 	var keepNameStmt js_ast.Stmt
@@ -2575,9 +3579,12 @@ func (p *parser) lowerClass(stmt js_ast.Stmt, expr js_ast.Expr, shadowRef js_ast
 	// Pack the class back into a statement, with potentially some extra
 	// statements afterwards
 	var stmts []js_ast.Stmt
+	for _, expr := range decoratorPrelude {
+		stmts = append(stmts, js_ast.Stmt{Loc: expr.Loc, Data: &js_ast.SExpr{Value: expr}})
+	}
 	var nameForClassDecorators js_ast.LocRef
 	generatedLocalStmt := false
-	if len(class.TSDecorators) > 0 || hasPotentialShadowCaptureEscape || classLoweringInfo.avoidTDZ {
+	if len(classDecorators) > 0 || hasPotentialShadowCaptureEscape || classLoweringInfo.avoidTDZ {
 		generatedLocalStmt = true
 		name := nameFunc()
 		nameRef := name.Data.(*js_ast.EIdentifier).Ref
@@ -2586,7 +3593,7 @@ func (p *parser) lowerClass(stmt js_ast.Stmt, expr js_ast.Expr, shadowRef js_ast
 		class = &classExpr.Class
 		init := js_ast.Expr{Loc: classLoc, Data: &classExpr}
 
-		if hasPotentialShadowCaptureEscape && len(class.TSDecorators) == 0 {
+		if hasPotentialShadowCaptureEscape && len(classDecorators) == 0 {
 			// If something captures the shadowing name and escapes the class body,
 			// make a new constant to store the class and forward that value to a
 			// mutable alias. That way if the alias is mutated, everything bound to
@@ -2695,17 +3702,20 @@ func (p *parser) lowerClass(stmt js_ast.Stmt, expr js_ast.Expr, shadowRef js_ast
 	for _, expr := range staticDecorators {
 		stmts = append(stmts, js_ast.Stmt{Loc: expr.Loc, Data: &js_ast.SExpr{Value: expr}})
 	}
-	if len(class.TSDecorators) > 0 {
+	if len(classDecorators) > 0 && !lowerStandardDecorators {
 		stmts = append(stmts, js_ast.AssignStmt(
 			js_ast.Expr{Loc: nameForClassDecorators.Loc, Data: &js_ast.EIdentifier{Ref: nameForClassDecorators.Ref}},
 			p.callRuntime(classLoc, "__decorateClass", []js_ast.Expr{
-				{Loc: classLoc, Data: &js_ast.EArray{Items: class.TSDecorators}},
+				{Loc: classLoc, Data: &js_ast.EArray{Items: classDecorators}},
 				{Loc: nameForClassDecorators.Loc, Data: &js_ast.EIdentifier{Ref: nameForClassDecorators.Ref}},
 			}),
 		))
 		p.recordUsage(nameForClassDecorators.Ref)
 		p.recordUsage(nameForClassDecorators.Ref)
 	}
+	for _, expr := range classDecorations {
+		stmts = append(stmts, js_ast.Stmt{Loc: expr.Loc, Data: &js_ast.SExpr{Value: expr}})
+	}
 	if generatedLocalStmt {
 		// "export default class x {}" => "class x {} export {x as default}"
 		if kind == classKindExportDefaultStmt {
@@ -2723,6 +3733,144 @@ func (p *parser) lowerClass(stmt js_ast.Stmt, expr js_ast.Expr, shadowRef js_ast
 	return stmts, js_ast.Expr{}
 }
 
+// This generates the constructor function and the class members for a class
+// that is lowered to ES5. Methods and accessors are defined on the prototype
+// (or on the class for static members) using "__defProp" so that they aren't
+// enumerable, and the constructor throws if it's called without "new".
+func (p *parser) lowerClassBodyToES5(
+	class *js_ast.Class,
+	classLoc logger.Loc,
+	classRef js_ast.Ref,
+	member *loweredClassMember,
+	ctor *js_ast.EFunction,
+	instanceThisCaptureRef js_ast.Ref,
+) (stmts []js_ast.Stmt) {
+	classExpr := func(loc logger.Loc) js_ast.Expr {
+		p.recordUsage(classRef)
+		return js_ast.Expr{Loc: loc, Data: &js_ast.EIdentifier{Ref: classRef}}
+	}
+
+	// "class Foo extends Bar {}" => "__inherit(Foo, _super)"
+	if member.superCtorRef != js_ast.InvalidRef {
+		p.recordUsage(member.superCtorRef)
+		stmts = append(stmts, js_ast.Stmt{Loc: classLoc, Data: &js_ast.SExpr{Value: p.callRuntime(classLoc, "__inherit", []js_ast.Expr{
+			classExpr(classLoc),
+			{Loc: classLoc, Data: &js_ast.EIdentifier{Ref: member.superCtorRef}},
+		})}})
+	}
+
+	// Generate the constructor function
+	var fn js_ast.Fn
+	if ctor != nil {
+		fn = ctor.Fn
+	}
+	fn.Name = &js_ast.LocRef{Loc: classLoc, Ref: classRef}
+	if member.ctorThisRef != js_ast.InvalidRef {
+		if ctor == nil {
+			// "constructor(...args) { super(...args) }" => "return __callSuper(this, _super, arguments)"
+			argumentsRef := p.newSymbol(js_ast.SymbolUnbound, "arguments")
+			p.currentScope.Generated = append(p.currentScope.Generated, argumentsRef)
+			p.recordUsage(member.superCtorRef)
+			fn.Body.Stmts = []js_ast.Stmt{{Loc: classLoc, Data: &js_ast.SReturn{ValueOrNil: p.callRuntime(classLoc, "__callSuper", []js_ast.Expr{
+				{Loc: classLoc, Data: js_ast.EThisShared},
+				{Loc: classLoc, Data: &js_ast.EIdentifier{Ref: member.superCtorRef}},
+				{Loc: classLoc, Data: &js_ast.EIdentifier{Ref: argumentsRef}},
+			})}}}
+		} else if p.symbols[member.ctorThisRef.InnerIndex].UseCountEstimate > 0 {
+			// Declare the variable for the lowered "super()" call and return it
+			body := fn.Body.Stmts
+			if _, ok := body[len(body)-1].Data.(*js_ast.SReturn); !ok {
+				p.recordUsage(member.ctorThisRef)
+				body = append(body, js_ast.Stmt{Loc: fn.Body.Loc, Data: &js_ast.SReturn{
+					ValueOrNil: js_ast.Expr{Loc: fn.Body.Loc, Data: &js_ast.EIdentifier{Ref: member.ctorThisRef}},
+				}})
+			}
+			fn.Body.Stmts = append([]js_ast.Stmt{{Loc: fn.Body.Loc, Data: &js_ast.SLocal{Kind: js_ast.LocalVar, Decls: []js_ast.Decl{{
+				Binding: js_ast.Binding{Loc: fn.Body.Loc, Data: &js_ast.BIdentifier{Ref: member.ctorThisRef}},
+			}}}}}, body...)
+		}
+	}
+
+	// Instance field initializers that capture "this" share a variable
+	if instanceThisCaptureRef != js_ast.InvalidRef {
+		p.currentScope.Generated = append(p.currentScope.Generated, instanceThisCaptureRef)
+		fn.Body.Stmts = append([]js_ast.Stmt{{Loc: fn.Body.Loc, Data: &js_ast.SLocal{Kind: js_ast.LocalVar, Decls: []js_ast.Decl{{
+			Binding:    js_ast.Binding{Loc: fn.Body.Loc, Data: &js_ast.BIdentifier{Ref: instanceThisCaptureRef}},
+			ValueOrNil: js_ast.Expr{Loc: fn.Body.Loc, Data: js_ast.EThisShared},
+		}}}}}, fn.Body.Stmts...)
+	}
+
+	// "Foo()" must throw like it does for a real class
+	fn.Body.Stmts = append([]js_ast.Stmt{{Loc: fn.Body.Loc, Data: &js_ast.SExpr{Value: p.callRuntime(fn.Body.Loc, "__classCallCheck", []js_ast.Expr{
+		{Loc: fn.Body.Loc, Data: js_ast.EThisShared},
+		classExpr(fn.Body.Loc),
+	})}}}, fn.Body.Stmts...)
+	stmts = append(stmts, js_ast.Stmt{Loc: classLoc, Data: &js_ast.SFunction{Fn: fn}})
+
+	// Generate the methods and accessors
+	for i := 0; i < len(class.Properties); i++ {
+		prop := class.Properties[i]
+		if !prop.IsMethod || (ctor != nil && prop.ValueOrNil.Data == ctor) {
+			continue
+		}
+		loc := prop.Key.Loc
+		target := classExpr(loc)
+		if !prop.IsStatic {
+			target = js_ast.Expr{Loc: loc, Data: &js_ast.EDot{Target: target, Name: "prototype", NameLoc: loc}}
+		}
+
+		// "get foo() {} set foo(x) {}" => "__defProp(Foo.prototype, 'foo', { get: ..., set: ..., configurable: true })"
+		if prop.Kind == js_ast.PropertyGet || prop.Kind == js_ast.PropertySet {
+			descriptor := []js_ast.Property{accessorDescriptorProperty(prop)}
+			if i+1 < len(class.Properties) && isAccessorPair(prop, class.Properties[i+1]) {
+				i++
+				descriptor = append(descriptor, accessorDescriptorProperty(class.Properties[i]))
+			}
+			descriptor = append(descriptor, js_ast.Property{
+				Key:        js_ast.Expr{Loc: loc, Data: &js_ast.EString{Value: js_lexer.StringToUTF16("configurable")}},
+				ValueOrNil: js_ast.Expr{Loc: loc, Data: &js_ast.EBoolean{Value: true}},
+			})
+			stmts = append(stmts, js_ast.Stmt{Loc: loc, Data: &js_ast.SExpr{Value: p.callRuntime(loc, "__defProp", []js_ast.Expr{
+				target,
+				prop.Key,
+				{Loc: loc, Data: &js_ast.EObject{Properties: descriptor}},
+			})}})
+			continue
+		}
+
+		// "foo() {}" => "__defMethod(Foo.prototype, 'foo', function() {})"
+		stmts = append(stmts, js_ast.Stmt{Loc: loc, Data: &js_ast.SExpr{Value: p.callRuntime(loc, "__defMethod", []js_ast.Expr{
+			target,
+			prop.Key,
+			prop.ValueOrNil,
+		})}})
+	}
+	return
+}
+
+func accessorDescriptorProperty(prop js_ast.Property) js_ast.Property {
+	name := "get"
+	if prop.Kind == js_ast.PropertySet {
+		name = "set"
+	}
+	return js_ast.Property{
+		Key:        js_ast.Expr{Loc: prop.Key.Loc, Data: &js_ast.EString{Value: js_lexer.StringToUTF16(name)}},
+		ValueOrNil: prop.ValueOrNil,
+	}
+}
+
+// Returns true if a getter is followed by a setter for the same property (or
+// the other way around) so that they can be defined together
+func isAccessorPair(a js_ast.Property, b js_ast.Property) bool {
+	if b.IsStatic != a.IsStatic || b.IsComputed || a.IsComputed || !b.IsMethod ||
+		(b.Kind != js_ast.PropertyGet && b.Kind != js_ast.PropertySet) || b.Kind == a.Kind {
+		return false
+	}
+	keyA, okA := a.Key.Data.(*js_ast.EString)
+	keyB, okB := b.Key.Data.(*js_ast.EString)
+	return okA && okB && js_lexer.UTF16EqualsUTF16(keyA.Value, keyB.Value)
+}
+
 func (p *parser) lowerTemplateLiteral(loc logger.Loc, e *js_ast.ETemplate) js_ast.Expr {
 	// If there is no tag, turn this into normal string concatenation
 	if e.TagOrNil.Data == nil {
@@ -2824,63 +3972,562 @@ func (p *parser) lowerTemplateLiteral(loc logger.Loc, e *js_ast.ETemplate) js_as
 	}}
 }
 
-func (p *parser) shouldLowerSuperPropertyAccess(expr js_ast.Expr) bool {
-	if p.fnOrArrowDataVisit.shouldLowerSuper {
-		_, isSuper := expr.Data.(*js_ast.ESuper)
-		return isSuper
-	}
-	return false
+// These are all regular expression features that are a syntax error when the
+// target environment doesn't support them
+const regExpSyntaxFeatures = compat.RegexpDotAllFlag |
+	compat.RegexpLookbehindAssertions |
+	compat.RegexpMatchIndices |
+	compat.RegexpNamedCaptureGroups |
+	compat.RegexpSetNotation |
+	compat.RegexpStickyAndUnicodeFlags |
+	compat.RegexpUnicodePropertyEscapes
+
+type regExpEdit struct {
+	start int
+	end   int
+	text  string
+	name  string // For backreferences, which are resolved after scanning
 }
 
-func (p *parser) ensureSuperGet() {
-	ref := &p.fnOnlyDataVisit.superHelpers.getRef
-	if *ref == js_ast.InvalidRef {
-		*ref = p.newSymbol(js_ast.SymbolOther, "__superGet")
+// Regular expression literals can't be passed through unmodified if they use
+// syntax that the target environment doesn't support because the code would
+// then fail to load. The "s" flag is lowered by replacing "." with "[^]", and
+// named capture groups are lowered by converting them to numbered capture
+// groups. Everything else is moved into a call to the "RegExp" constructor so
+// that the syntax error is deferred to run-time, where a polyfill can fix it.
+func (p *parser) lowerRegExp(loc logger.Loc, e *js_ast.ERegExp) js_ast.Expr {
+	if !p.options.unsupportedJSFeatures.Has(regExpSyntaxFeatures) {
+		return js_ast.Expr{Loc: loc, Data: e}
 	}
-	p.recordUsage(*ref)
-}
 
-func (p *parser) ensureSuperSet() {
-	ref := &p.fnOnlyDataVisit.superHelpers.setRef
-	if *ref == js_ast.InvalidRef {
-		*ref = p.newSymbol(js_ast.SymbolOther, "__superSet")
+	slash := strings.LastIndexByte(e.Value, '/')
+	pattern := e.Value[1:slash]
+	flags := e.Value[slash+1:]
+	hasUnicodeFlag := strings.ContainsAny(flags, "uv")
+	hasSetNotation := strings.IndexByte(flags, 'v') >= 0
+
+	var dots []regExpEdit
+	var groupNames []regExpEdit
+	var backReferences []regExpEdit
+	groupIndices := make(map[string]int)
+	lookbehind := logger.Range{}
+	propertyEscape := logger.Range{}
+	patternRange := func(start int, end int) logger.Range {
+		return logger.Range{Loc: logger.Loc{Start: loc.Start + 1 + int32(start)}, Len: int32(end - start)}
 	}
-	p.recordUsage(*ref)
-}
 
-func (p *parser) callSuperPropertyWrapper(loc logger.Loc, property js_ast.Expr, includeGet bool) js_ast.Expr {
-	var result js_ast.Expr
+	// Scan over the pattern to find the features that need to be lowered
+	groupCount := 0
+	classDepth := 0
+	for i := 0; i < len(pattern); i++ {
+		switch pattern[i] {
+		case '\\':
+			if i+1 == len(pattern) {
+				break
+			}
+			switch pattern[i+1] {
+			case 'p', 'P':
+				// "\p{Letter}"
+				if hasUnicodeFlag && propertyEscape.Len == 0 && strings.HasPrefix(pattern[i+2:], "{") {
+					if end := strings.IndexByte(pattern[i:], '}'); end != -1 {
+						propertyEscape = patternRange(i, i+end+1)
+					}
+				}
 
-	if thisRef := p.fnOnlyDataVisit.thisClassStaticRef; thisRef != nil {
-		p.recordUsage(*thisRef)
-		result = p.callRuntime(loc, "__superStaticWrapper", []js_ast.Expr{
-			{Loc: loc, Data: &js_ast.EIdentifier{Ref: *thisRef}},
-			property,
-		})
-	} else {
-		// Only some uses of the wrapper need to read
-		superGet := js_ast.Expr{Loc: loc, Data: js_ast.ENullShared}
-		if includeGet {
-			p.ensureSuperGet()
-			superGet.Data = &js_ast.EIdentifier{Ref: p.fnOnlyDataVisit.superHelpers.getRef}
-		}
+			case 'k':
+				// "\k<name>"
+				if classDepth == 0 && strings.HasPrefix(pattern[i+2:], "<") {
+					if end := strings.IndexByte(pattern[i+3:], '>'); end != -1 {
+						end += i + 3
+						backReferences = append(backReferences, regExpEdit{start: i, end: end + 1, name: pattern[i+3 : end]})
+						i = end
+						continue
+					}
+				}
+			}
+			i++
 
-		// All uses of the wrapper need to write
-		p.ensureSuperSet()
-		superSet := js_ast.Expr{Loc: loc, Data: &js_ast.EIdentifier{Ref: p.fnOnlyDataVisit.superHelpers.setRef}}
+		case '[':
+			// Character classes can only be nested when the "v" flag is present
+			if classDepth == 0 || hasSetNotation {
+				classDepth++
+			}
 
-		result = p.callRuntime(loc, "__superWrapper", []js_ast.Expr{
-			superGet,
-			superSet,
-			property,
-		})
-	}
+		case ']':
+			if classDepth > 0 {
+				classDepth--
+			}
 
-	return js_ast.Expr{Loc: loc, Data: &js_ast.EDot{Target: result, Name: "_", NameLoc: loc}}
-}
+		case '.':
+			if classDepth == 0 {
+				dots = append(dots, regExpEdit{start: i, end: i + 1, text: "[^]"})
+			}
 
-func (p *parser) lowerSuperPropertyGet(loc logger.Loc, key js_ast.Expr) js_ast.Expr {
-	if thisRef := p.fnOnlyDataVisit.thisClassStaticRef; thisRef != nil {
+		case '(':
+			if classDepth > 0 {
+				break
+			}
+			if !strings.HasPrefix(pattern[i+1:], "?") {
+				groupCount++
+				break
+			}
+			if !strings.HasPrefix(pattern[i+2:], "<") {
+				break
+			}
+
+			// "(?<=a)" and "(?<!a)"
+			if strings.HasPrefix(pattern[i+3:], "=") || strings.HasPrefix(pattern[i+3:], "!") {
+				if lookbehind.Len == 0 {
+					lookbehind = patternRange(i, i+4)
+				}
+				break
+			}
+
+			// "(?<name>a)"
+			if end := strings.IndexByte(pattern[i+3:], '>'); end != -1 {
+				end += i + 3
+				name := pattern[i+3 : end]
+				groupCount++
+				if _, ok := groupIndices[name]; !ok {
+					groupIndices[name] = groupCount
+				}
+				groupNames = append(groupNames, regExpEdit{start: i + 1, end: end + 1})
+				i = end
+			}
+		}
+	}
+
+	// Lower the "s" flag by matching any character explicitly
+	var edits []regExpEdit
+	didChangeFlags := false
+	if strings.IndexByte(flags, 's') != -1 && p.options.unsupportedJSFeatures.Has(compat.RegexpDotAllFlag) {
+		flags = strings.Replace(flags, "s", "", 1)
+		didChangeFlags = true
+		edits = append(edits, dots...)
+	}
+
+	// Lower named capture groups by removing the names and referring to the
+	// groups by number instead. The number is wrapped in a non-capturing group
+	// to avoid merging with a digit that comes after it.
+	if len(groupNames) > 0 && p.options.unsupportedJSFeatures.Has(compat.RegexpNamedCaptureGroups) {
+		where, notes := p.prettyPrintTargetEnvironment(compat.RegexpNamedCaptureGroups)
+		p.log.AddWithNotes(logger.Warning, &p.tracker, patternRange(groupNames[0].start, groupNames[0].end), fmt.Sprintf(
+			"Named capture groups in regular expressions are not available in %s. "+
+				"They have been converted to numbered capture groups, so the \"groups\" property of the match result will be missing "+
+				"and \"$<name>\" in replacement strings will no longer refer to the group.", where), notes)
+		edits = append(edits, groupNames...)
+		for _, ref := range backReferences {
+			if index, ok := groupIndices[ref.name]; ok {
+				ref.text = fmt.Sprintf("(?:\\%d)", index)
+				edits = append(edits, ref)
+			}
+		}
+	}
+
+	if len(edits) > 0 {
+		sort.SliceStable(edits, func(i int, j int) bool {
+			return edits[i].start < edits[j].start
+		})
+		sb := strings.Builder{}
+		end := 0
+		for _, edit := range edits {
+			sb.WriteString(pattern[end:edit.start])
+			sb.WriteString(edit.text)
+			end = edit.end
+		}
+		sb.WriteString(pattern[end:])
+		pattern = sb.String()
+	}
+
+	// Everything else can only be handled by a polyfill at run-time
+	wrap := false
+	warn := func(feature compat.JSFeature, r logger.Range, what string) {
+		if !p.options.unsupportedJSFeatures.Has(feature) {
+			return
+		}
+		where, notes := p.prettyPrintTargetEnvironment(feature)
+		p.log.AddWithNotes(logger.Warning, &p.tracker, r, fmt.Sprintf(
+			"%s not available in %s. This regular expression literal has been converted to a \"new RegExp()\" constructor "+
+				"to avoid generating code with a syntax error. However, you will need to include a polyfill for \"RegExp\" "+
+				"for your code to have the correct behavior at run-time.", what, where), notes)
+		wrap = true
+	}
+	if lookbehind.Len != 0 {
+		warn(compat.RegexpLookbehindAssertions, lookbehind, "Lookbehind assertions in regular expressions are")
+	}
+	if propertyEscape.Len != 0 {
+		warn(compat.RegexpUnicodePropertyEscapes, propertyEscape, "Unicode property escapes in regular expressions are")
+	}
+	for i, c := range e.Value[slash+1:] {
+		r := logger.Range{Loc: logger.Loc{Start: loc.Start + int32(slash+1+i)}, Len: 1}
+		what := fmt.Sprintf("The regular expression flag \"%c\" is", c)
+		switch c {
+		case 'd':
+			warn(compat.RegexpMatchIndices, r, what)
+		case 'v':
+			warn(compat.RegexpSetNotation, r, what)
+		case 'u', 'y':
+			warn(compat.RegexpStickyAndUnicodeFlags, r, what)
+		}
+	}
+
+	if wrap {
+		args := []js_ast.Expr{{Loc: loc, Data: &js_ast.EString{Value: js_lexer.StringToUTF16(pattern)}}}
+		if flags != "" {
+			args = append(args, js_ast.Expr{Loc: loc, Data: &js_ast.EString{Value: js_lexer.StringToUTF16(flags)}})
+		}
+		return p.callRuntime(loc, "__regExp", args)
+	}
+
+	if len(edits) > 0 || didChangeFlags {
+		return js_ast.Expr{Loc: loc, Data: &js_ast.ERegExp{Value: "/" + pattern + "/" + flags}}
+	}
+	return js_ast.Expr{Loc: loc, Data: e}
+}
+
+// Returns true if these statements contain "using" declarations that must be
+// lowered because the target environment doesn't support them
+func (p *parser) shouldLowerUsingDeclarations(stmts []js_ast.Stmt) bool {
+	if !p.options.unsupportedJSFeatures.Has(compat.Using) {
+		return false
+	}
+	for _, stmt := range stmts {
+		if local, ok := stmt.Data.(*js_ast.SLocal); ok && local.Kind.IsUsing() {
+			return true
+		}
+	}
+	return false
+}
+
+// This holds the state for lowering "using" declarations in a single block.
+// All resources in the block are pushed onto the same stack, which is then
+// disposed of when the block is exited:
+//
+//   var _stack = [];
+//   try {
+//     const x = __using(_stack, foo());
+//     bar(x);
+//   } catch (_) {
+//     var _error = _, _hasError = true;
+//   } finally {
+//     __callDispose(_stack, _error, _hasError);
+//   }
+//
+type lowerUsingDeclarationContext struct {
+	stackRef      js_ast.Ref
+	hasAwaitUsing bool
+}
+
+func (p *parser) lowerUsingDeclarationContext() lowerUsingDeclarationContext {
+	return lowerUsingDeclarationContext{
+		stackRef: p.generateTempRef(tempRefNoDeclare, "_stack"),
+	}
+}
+
+// "using x = y" => "const x = __using(_stack, y)"
+func (ctx *lowerUsingDeclarationContext) scanStmts(p *parser, stmts []js_ast.Stmt) {
+	for _, stmt := range stmts {
+		if local, ok := stmt.Data.(*js_ast.SLocal); ok && local.Kind.IsUsing() {
+			isAwait := local.Kind == js_ast.LocalAwaitUsing
+			for i, decl := range local.Decls {
+				if decl.ValueOrNil.Data != nil {
+					local.Decls[i].ValueOrNil = ctx.callUsing(p, decl.ValueOrNil, isAwait)
+				}
+			}
+			local.Kind = p.selectLocalKind(js_ast.LocalConst)
+		}
+	}
+}
+
+func (ctx *lowerUsingDeclarationContext) callUsing(p *parser, value js_ast.Expr, isAwait bool) js_ast.Expr {
+	p.recordUsage(ctx.stackRef)
+	args := []js_ast.Expr{{Loc: value.Loc, Data: &js_ast.EIdentifier{Ref: ctx.stackRef}}, value}
+	if isAwait {
+		ctx.hasAwaitUsing = true
+		args = append(args, js_ast.Expr{Loc: value.Loc, Data: &js_ast.EBoolean{Value: true}})
+	}
+	return p.callRuntime(value.Loc, "__using", args)
+}
+
+// This wraps the statements in the block in a "try" statement that disposes
+// of all resources that were pushed onto the stack
+func (ctx *lowerUsingDeclarationContext) finalize(p *parser, loc logger.Loc, body []js_ast.Stmt) []js_ast.Stmt {
+	errorRef := p.generateTempRef(tempRefNoDeclare, "_error")
+	hasErrorRef := p.generateTempRef(tempRefNoDeclare, "_hasError")
+	catchRef := p.generateTempRef(tempRefNoDeclare, "_")
+	ident := func(ref js_ast.Ref) js_ast.Expr {
+		p.recordUsage(ref)
+		return js_ast.Expr{Loc: loc, Data: &js_ast.EIdentifier{Ref: ref}}
+	}
+	decl := func(ref js_ast.Ref, value js_ast.Expr) js_ast.Decl {
+		return js_ast.Decl{Binding: js_ast.Binding{Loc: loc, Data: &js_ast.BIdentifier{Ref: ref}}, ValueOrNil: value}
+	}
+
+	// "__callDispose(_stack, _error, _hasError)"
+	callDispose := p.callRuntime(loc, "__callDispose", []js_ast.Expr{ident(ctx.stackRef), ident(errorRef), ident(hasErrorRef)})
+	var finally []js_ast.Stmt
+	if ctx.hasAwaitUsing {
+		// "var _promise = __callDispose(...); _promise && await _promise"
+		promiseRef := p.generateTempRef(tempRefNoDeclare, "_promise")
+		finally = []js_ast.Stmt{
+			{Loc: loc, Data: &js_ast.SLocal{Decls: []js_ast.Decl{decl(promiseRef, callDispose)}}},
+			{Loc: loc, Data: &js_ast.SExpr{Value: js_ast.Expr{Loc: loc, Data: &js_ast.EBinary{
+				Op:    js_ast.BinOpLogicalAnd,
+				Left:  ident(promiseRef),
+				Right: p.awaitExpr(loc, ident(promiseRef)),
+			}}}},
+		}
+	} else {
+		finally = []js_ast.Stmt{{Loc: loc, Data: &js_ast.SExpr{Value: callDispose}}}
+	}
+
+	return []js_ast.Stmt{
+		{Loc: loc, Data: &js_ast.SLocal{Decls: []js_ast.Decl{decl(ctx.stackRef, js_ast.Expr{Loc: loc, Data: &js_ast.EArray{}})}}},
+		{Loc: loc, Data: &js_ast.STry{
+			BodyLoc: loc,
+			Body:    body,
+			Catch: &js_ast.Catch{
+				Loc:          loc,
+				BindingOrNil: js_ast.Binding{Loc: loc, Data: &js_ast.BIdentifier{Ref: catchRef}},
+				BodyLoc:      loc,
+				Body: []js_ast.Stmt{{Loc: loc, Data: &js_ast.SLocal{Decls: []js_ast.Decl{
+					decl(errorRef, ident(catchRef)),
+					decl(hasErrorRef, js_ast.Expr{Loc: loc, Data: &js_ast.EBoolean{Value: true}}),
+				}}}},
+			},
+			Finally: &js_ast.Finally{Loc: loc, Stmts: finally},
+		}},
+	}
+}
+
+// The whole block is wrapped in the "try" statement instead of only the
+// statements after the first "using" declaration so that all declarations in
+// the block are still visible to each other. Declarations at the top level
+// of a module are moved outside of the "try" statement instead so that they
+// can still be imported and exported.
+func (p *parser) lowerUsingDeclarations(stmts []js_ast.Stmt) []js_ast.Stmt {
+	ctx := p.lowerUsingDeclarationContext()
+	ctx.scanStmts(p, stmts)
+
+	// Directives must stay at the start of the block
+	var before []js_ast.Stmt
+	var after []js_ast.Stmt
+	for len(stmts) > 0 {
+		if _, ok := stmts[0].Data.(*js_ast.SDirective); !ok {
+			break
+		}
+		before = append(before, stmts[0])
+		stmts = stmts[1:]
+	}
+	loc := logger.Loc{}
+	if len(stmts) > 0 {
+		loc = stmts[0].Loc
+	}
+
+	body := stmts
+	if p.currentScope == p.moduleScope {
+		body = make([]js_ast.Stmt, 0, len(stmts))
+		for _, stmt := range stmts {
+			switch s := stmt.Data.(type) {
+			case *js_ast.SImport, *js_ast.SExportFrom, *js_ast.SExportStar, *js_ast.SExportClause,
+				*js_ast.SFunction, *js_ast.STypeScript:
+				// These are hoisted anyway, so they can be moved before the "try"
+				before = append(before, stmt)
+				continue
+
+			case *js_ast.SExportEquals:
+				after = append(after, stmt)
+				continue
+
+			case *js_ast.SLocal:
+				// "export const x = y" => "export var x; try { x = y } ..."
+				var decls []js_ast.Decl
+				for _, decl := range s.Decls {
+					decls = findIdentifiers(decl.Binding, decls)
+					if decl.ValueOrNil.Data != nil {
+						target := js_ast.ConvertBindingToExpr(decl.Binding, func(loc logger.Loc, ref js_ast.Ref) js_ast.Expr {
+							p.recordUsage(ref)
+							return js_ast.Expr{Loc: loc, Data: &js_ast.EIdentifier{Ref: ref}}
+						})
+						body = append(body, js_ast.Stmt{Loc: stmt.Loc, Data: &js_ast.SExpr{Value: js_ast.Assign(target, decl.ValueOrNil)}})
+					}
+				}
+				before = append(before, js_ast.Stmt{Loc: stmt.Loc, Data: &js_ast.SLocal{Decls: decls, IsExport: s.IsExport}})
+				continue
+
+			case *js_ast.SClass:
+				// "export class Foo {}" => "export var Foo; try { Foo = class Foo {} } ..."
+				before = append(before, js_ast.Stmt{Loc: stmt.Loc, Data: &js_ast.SLocal{
+					Decls:    []js_ast.Decl{{Binding: js_ast.Binding{Loc: s.Class.Name.Loc, Data: &js_ast.BIdentifier{Ref: s.Class.Name.Ref}}}},
+					IsExport: s.IsExport,
+				}})
+				body = append(body, p.assignToHoistedUsingRef(*s.Class.Name, js_ast.Expr{Loc: stmt.Loc, Data: &js_ast.EClass{Class: s.Class}}))
+				continue
+
+			case *js_ast.SExportDefault:
+				// "export default x" => "var y; export { y as default }; try { y = x } ..."
+				var value js_ast.Expr
+				switch v := s.Value.Data.(type) {
+				case *js_ast.SExpr:
+					value = v.Value
+				case *js_ast.SClass:
+					value = js_ast.Expr{Loc: s.Value.Loc, Data: &js_ast.EClass{Class: v.Class}}
+				default:
+					before = append(before, stmt)
+					continue
+				}
+				before = append(before,
+					js_ast.Stmt{Loc: stmt.Loc, Data: &js_ast.SLocal{
+						Decls: []js_ast.Decl{{Binding: js_ast.Binding{Loc: s.DefaultName.Loc, Data: &js_ast.BIdentifier{Ref: s.DefaultName.Ref}}}},
+					}},
+					js_ast.Stmt{Loc: stmt.Loc, Data: &js_ast.SExportClause{
+						Items: []js_ast.ClauseItem{{Alias: "default", AliasLoc: s.DefaultName.Loc, Name: s.DefaultName}},
+					}},
+				)
+				body = append(body, p.assignToHoistedUsingRef(s.DefaultName, value))
+				continue
+			}
+			body = append(body, stmt)
+		}
+	}
+
+	return append(append(before, ctx.finalize(p, loc, body)...), after...)
+}
+
+func (p *parser) assignToHoistedUsingRef(name js_ast.LocRef, value js_ast.Expr) js_ast.Stmt {
+	p.recordUsage(name.Ref)
+	return js_ast.Stmt{Loc: value.Loc, Data: &js_ast.SExpr{Value: js_ast.Assign(
+		js_ast.Expr{Loc: name.Loc, Data: &js_ast.EIdentifier{Ref: name.Ref}}, value)}}
+}
+
+func (p *parser) shouldLowerSuperPropertyAccess(expr js_ast.Expr) bool {
+	if p.fnOrArrowDataVisit.shouldLowerSuper {
+		_, isSuper := expr.Data.(*js_ast.ESuper)
+		return isSuper
+	}
+	return false
+}
+
+func (p *parser) ensureSuperGet() {
+	ref := &p.fnOnlyDataVisit.superHelpers.getRef
+	if *ref == js_ast.InvalidRef {
+		*ref = p.newSymbol(js_ast.SymbolOther, "__superGet")
+	}
+	p.recordUsage(*ref)
+}
+
+func (p *parser) ensureSuperSet() {
+	ref := &p.fnOnlyDataVisit.superHelpers.setRef
+	if *ref == js_ast.InvalidRef {
+		*ref = p.newSymbol(js_ast.SymbolOther, "__superSet")
+	}
+	p.recordUsage(*ref)
+}
+
+// The lowered "super()" call creates the object for "this" by calling the
+// base class constructor and stores it in a variable for the rest of the
+// constructor to use: "super(a, b)" becomes "_this = __callSuper(this,
+// _super, [a, b])". The real value of "this" is only used to find the
+// constructor that was called with "new".
+func (p *parser) lowerSuperCall(loc logger.Loc, member *loweredClassMember, thisExpr js_ast.Expr, args js_ast.Expr) js_ast.Expr {
+	p.recordUsage(member.superCtorRef)
+	p.recordUsage(member.ctorThisRef)
+	return js_ast.Assign(
+		js_ast.Expr{Loc: loc, Data: &js_ast.EIdentifier{Ref: member.ctorThisRef}},
+		p.callRuntime(loc, "__callSuper", []js_ast.Expr{
+			thisExpr,
+			{Loc: loc, Data: &js_ast.EIdentifier{Ref: member.superCtorRef}},
+			args,
+		}),
+	)
+}
+
+func (p *parser) isLoweredSuperCall(stmt js_ast.Stmt, member *loweredClassMember) bool {
+	if member.ctorThisRef != js_ast.InvalidRef {
+		if expr, ok := stmt.Data.(*js_ast.SExpr); ok {
+			if assign, ok := expr.Value.Data.(*js_ast.EBinary); ok && assign.Op == js_ast.BinOpAssign {
+				if id, ok := assign.Left.Data.(*js_ast.EIdentifier); ok && id.Ref == member.ctorThisRef {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+// This is used when mangling to avoid merging a "super()" call with adjacent
+// statements, since instance fields are inserted directly after it later on
+func (p *parser) isSuperCallStmt(stmt js_ast.Stmt) bool {
+	if js_ast.IsSuperCall(stmt) {
+		return true
+	}
+	member := p.fnOnlyDataVisit.loweredClassMember
+	return member != nil && p.isLoweredSuperCall(stmt, member)
+}
+
+// Lowered "super" property accesses in classes lowered to ES5 pass the object
+// to start the property lookup from (the "home object") and the value of
+// "this" to a runtime helper
+func (p *parser) superPropertyHomeAndThis(loc logger.Loc) (js_ast.Expr, js_ast.Expr) {
+	member := p.fnOnlyDataVisit.loweredClassMember
+	p.recordUsage(member.classRef)
+	home := js_ast.Expr{Loc: loc, Data: &js_ast.EIdentifier{Ref: member.classRef}}
+	if !member.isStatic {
+		home.Data = &js_ast.EDot{Target: home, Name: "prototype", NameLoc: loc}
+	}
+	return home, p.superPropertyThis(loc)
+}
+
+func (p *parser) superPropertyThis(loc logger.Loc) js_ast.Expr {
+	if p.fnOnlyDataVisit.loweredClassMember != nil {
+		// Visit "this" so that it's substituted or captured as necessary
+		return p.visitExpr(js_ast.Expr{Loc: loc, Data: &js_ast.EThis{}})
+	}
+	return js_ast.Expr{Loc: loc, Data: js_ast.EThisShared}
+}
+
+func (p *parser) callSuperPropertyWrapper(loc logger.Loc, property js_ast.Expr, includeGet bool) js_ast.Expr {
+	var result js_ast.Expr
+
+	if p.fnOnlyDataVisit.loweredClassMember != nil {
+		home, this := p.superPropertyHomeAndThis(loc)
+		result = p.callRuntime(loc, "__superPropWrapper", []js_ast.Expr{home, this, property})
+	} else if thisRef := p.fnOnlyDataVisit.thisClassStaticRef; thisRef != nil {
+		p.recordUsage(*thisRef)
+		result = p.callRuntime(loc, "__superStaticWrapper", []js_ast.Expr{
+			{Loc: loc, Data: &js_ast.EIdentifier{Ref: *thisRef}},
+			property,
+		})
+	} else {
+		// Only some uses of the wrapper need to read
+		superGet := js_ast.Expr{Loc: loc, Data: js_ast.ENullShared}
+		if includeGet {
+			p.ensureSuperGet()
+			superGet.Data = &js_ast.EIdentifier{Ref: p.fnOnlyDataVisit.superHelpers.getRef}
+		}
+
+		// All uses of the wrapper need to write
+		p.ensureSuperSet()
+		superSet := js_ast.Expr{Loc: loc, Data: &js_ast.EIdentifier{Ref: p.fnOnlyDataVisit.superHelpers.setRef}}
+
+		result = p.callRuntime(loc, "__superWrapper", []js_ast.Expr{
+			superGet,
+			superSet,
+			property,
+		})
+	}
+
+	return js_ast.Expr{Loc: loc, Data: &js_ast.EDot{Target: result, Name: "_", NameLoc: loc}}
+}
+
+func (p *parser) lowerSuperPropertyGet(loc logger.Loc, key js_ast.Expr) js_ast.Expr {
+	// "super.foo" => "__superPropGet(Foo.prototype, this, 'foo')"
+	if p.fnOnlyDataVisit.loweredClassMember != nil {
+		home, this := p.superPropertyHomeAndThis(loc)
+		return p.callRuntime(loc, "__superPropGet", []js_ast.Expr{home, this, key})
+	}
+
+	if thisRef := p.fnOnlyDataVisit.thisClassStaticRef; thisRef != nil {
 		p.recordUsage(*thisRef)
 		return p.callRuntime(loc, "__superStaticGet", []js_ast.Expr{
 			{Loc: loc, Data: &js_ast.EIdentifier{Ref: *thisRef}},
@@ -2898,6 +4545,12 @@ func (p *parser) lowerSuperPropertyGet(loc logger.Loc, key js_ast.Expr) js_ast.E
 }
 
 func (p *parser) lowerSuperPropertySet(loc logger.Loc, key js_ast.Expr, value js_ast.Expr) js_ast.Expr {
+	// "super.foo = bar" => "__superPropSet(Foo.prototype, this, 'foo', bar)"
+	if p.fnOnlyDataVisit.loweredClassMember != nil {
+		home, this := p.superPropertyHomeAndThis(loc)
+		return p.callRuntime(loc, "__superPropSet", []js_ast.Expr{home, this, key, value})
+	}
+
 	if thisRef := p.fnOnlyDataVisit.thisClassStaticRef; thisRef != nil {
 		p.recordUsage(*thisRef)
 		return p.callRuntime(loc, "__superStaticSet", []js_ast.Expr{
@@ -2956,7 +4609,7 @@ func (p *parser) maybeLowerSuperPropertyGetInsideCall(call *js_ast.ECall) {
 		NameLoc: key.Loc,
 		Name:    "call",
 	}
-	thisExpr := js_ast.Expr{Loc: call.Target.Loc, Data: js_ast.EThisShared}
+	thisExpr := p.superPropertyThis(call.Target.Loc)
 	call.Args = append([]js_ast.Expr{thisExpr}, call.Args...)
 }
 
@@ -2978,3 +4631,852 @@ func (p *parser) maybeLowerSetBinOp(left js_ast.Expr, op js_ast.OpCode, right js
 	}
 	return js_ast.Expr{}
 }
+
+// This tracks a "let" or "const" binding that is being converted to "var"
+// because the target environment doesn't support block scoping. Each binding
+// is renamed to be unique within the enclosing function so that it can't
+// collide with other bindings after becoming a "var".
+type loweredLexicalBinding struct {
+	// This is the block scope that the binding was originally declared in
+	scope *js_ast.Scope
+
+	// This is the innermost loop in the same function that contains the
+	// declaration, if any. Each iteration of this loop gets a new copy of the
+	// binding, which matters if the binding is captured by a closure.
+	loop *loweredLoop
+
+	// If the binding was declared directly inside a switch statement, this is
+	// one more than the index of the case that declared it
+	switchCase int
+
+	isTopLevel           bool
+	isInitializing       bool
+	isInitialized        bool
+	isCaptured           bool
+	isAssignedInLoopBody bool
+	didWarnAboutTDZ      bool
+}
+
+// This tracks a loop that may need to have its body moved into a function.
+// That gives closures created in the loop body a separate copy of each binding
+// for every iteration of the loop, like with real "let" and "const" bindings:
+//
+//   for (let i = 0; i < 3; i++) fns.push(() => i)
+//
+// becomes:
+//
+//   var _loop = function(i) { fns.push(() => i) };
+//   for (var i = 0; i < 3; i++) _loop(i);
+//
+type loweredLoop struct {
+	// The enclosing loop in the same function or arrow function
+	parent *loweredLoop
+
+	// The enclosing loop in the same function, which may be outside of the
+	// arrow function that this loop is in
+	outer *loweredLoop
+
+	labels   []js_ast.Ref
+	bindings []*loweredLexicalBinding
+
+	// The bindings declared in the loop header are passed to the function
+	headRefs []js_ast.Ref
+
+	// Some things can't be moved into a function such as "arguments" or "yield"
+	unsupportedName  string
+	unsupportedRange logger.Range
+
+	// Closures in the initializer of a "for" loop capture a separate copy of
+	// the bindings, while closures in the test and update expressions capture
+	// the copy for the current iteration (which isn't supported)
+	isInsideInit           bool
+	isInsideTestOrUpdate   bool
+	isCapturedInInit       bool
+	didWarnAboutTestUpdate bool
+
+	isInsideBody bool
+	usesThis     bool
+}
+
+// This is called during parsing for "let" and "const" declarations
+func (p *parser) recordLoweredLexicalBindings(kind js_ast.SymbolKind, binding js_ast.Binding) {
+	if (kind != js_ast.SymbolOther || !p.options.unsupportedJSFeatures.Has(compat.Let)) &&
+		(kind != js_ast.SymbolConst || !p.options.unsupportedJSFeatures.Has(compat.Const)) {
+		return
+	}
+	if p.loweredLexicalBindings == nil {
+		p.loweredLexicalBindings = make(map[js_ast.Ref]*loweredLexicalBinding)
+	}
+	for _, decl := range findIdentifiers(binding, nil) {
+		ref := decl.Binding.Data.(*js_ast.BIdentifier).Ref
+		p.loweredLexicalBindings[ref] = &loweredLexicalBinding{scope: p.currentScope}
+	}
+}
+
+// Lowered bindings in nested block scopes are added to the enclosing function
+// scope so that the renamer gives them names that are unique in that function.
+// They stay in the block scope too so that name lookups still work.
+func (p *parser) hoistLoweredLexicalBindings(scope *js_ast.Scope) {
+	if p.loweredLexicalBindings == nil {
+		return
+	}
+	var refs []js_ast.Ref
+	for _, member := range scope.Members {
+		if binding, ok := p.loweredLexicalBindings[member.Ref]; ok && binding.scope == scope {
+			refs = append(refs, member.Ref)
+		}
+	}
+	if refs == nil {
+		return
+	}
+
+	// Sort for determinism since map iteration order is random
+	sort.Slice(refs, func(i int, j int) bool {
+		return refs[i].InnerIndex < refs[j].InnerIndex
+	})
+
+	s := scope.Parent
+	for !s.Kind.StopsHoisting() {
+		s = s.Parent
+	}
+	s.Generated = append(s.Generated, refs...)
+
+	// Bindings in top-level blocks become top-level variables, so they must be
+	// renamed along with all other top-level symbols when bundling
+	if s == p.moduleScope {
+		for _, ref := range refs {
+			p.loweredLexicalBindings[ref].isTopLevel = true
+		}
+	}
+}
+
+// This is called before visiting the initializer of a lowered binding
+func (p *parser) visitLoweredLexicalInitializer(binding js_ast.Binding) {
+	if id, ok := binding.Data.(*js_ast.BIdentifier); ok {
+		if lowered, ok := p.loweredLexicalBindings[id.Ref]; ok {
+			lowered.isInitializing = true
+		}
+	}
+}
+
+// This is called when visiting a declaration of lowered bindings
+func (p *parser) visitLoweredLexicalDecl(binding js_ast.Binding, isLoopHead bool) {
+	if p.loweredLexicalBindings == nil {
+		return
+	}
+	for _, decl := range findIdentifiers(binding, nil) {
+		ref := decl.Binding.Data.(*js_ast.BIdentifier).Ref
+		if lowered, ok := p.loweredLexicalBindings[ref]; ok {
+			lowered.isInitializing = false
+			lowered.isInitialized = true
+			if index, ok := p.loweredSwitchCases[lowered.scope]; ok {
+				lowered.switchCase = index + 1
+			}
+			if loop := p.fnOrArrowDataVisit.loweredLoop; loop != nil && lowered.loop == nil {
+				lowered.loop = loop
+				loop.bindings = append(loop.bindings, lowered)
+				if isLoopHead {
+					loop.headRefs = append(loop.headRefs, ref)
+				}
+			}
+		}
+	}
+}
+
+// References from nested functions may happen after the binding has been
+// initialized. Class bodies are included because field initializers are
+// evaluated later too.
+func (p *parser) isLoweredLexicalReferenceFromClosure(binding *loweredLexicalBinding) bool {
+	for s := p.currentScope; s != binding.scope; s = s.Parent {
+		if s == nil || s.Kind.StopsHoisting() || s.Kind == js_ast.ScopeClassBody {
+			return true
+		}
+	}
+	return false
+}
+
+// This returns true if the reference is definitely evaluated before the
+// binding is initialized, which must throw a ReferenceError
+func (p *parser) visitLoweredLexicalReference(loc logger.Loc, ref js_ast.Ref, binding *loweredLexicalBinding, assignTarget js_ast.AssignTarget) bool {
+	isFromClosure := p.isLoweredLexicalReferenceFromClosure(binding)
+	if isFromClosure {
+		if loop := binding.loop; loop != nil && loop.isInsideInit {
+			loop.isCapturedInInit = true
+		} else if loop != nil && loop.isInsideTestOrUpdate {
+			if !loop.didWarnAboutTestUpdate {
+				loop.didWarnAboutTestUpdate = true
+				where, notes := p.prettyPrintTargetEnvironment(compat.Let)
+				p.log.AddWithNotes(logger.Warning, &p.tracker, js_lexer.RangeOfIdentifier(p.source, loc), fmt.Sprintf(
+					"Closures in the test or update expression of a loop will share %q between iterations because \"let\" and \"const\" are not available in %s",
+					p.symbols[ref.InnerIndex].OriginalName, where), notes)
+			}
+		} else {
+			binding.isCaptured = true
+		}
+	}
+
+	// Some accesses can only be checked at run-time. For example, a function
+	// may be called before the binding it uses is initialized, or a later case
+	// of a switch statement may use a binding from a case that was skipped.
+	// These aren't checked since "var" doesn't have a temporal dead zone.
+	//
+	// Closures in the binding's own initializer are assumed to be called later
+	// since that's much more common (e.g. "const f = () => f()").
+	if !binding.didWarnAboutTDZ && ((isFromClosure && ((!binding.isInitialized && !binding.isInitializing) || p.loweredEarlyCalledBindings[binding])) ||
+		(binding.switchCase != 0 && p.loweredSwitchCases[binding.scope]+1 != binding.switchCase)) {
+		binding.didWarnAboutTDZ = true
+		symbol := &p.symbols[ref.InnerIndex]
+		feature, kind := compat.Let, "let"
+		if symbol.Kind == js_ast.SymbolConst {
+			feature, kind = compat.Const, "const"
+		}
+		where, notes := p.prettyPrintTargetEnvironment(feature)
+		p.log.AddWithNotes(logger.Warning, &p.tracker, js_lexer.RangeOfIdentifier(p.source, loc), fmt.Sprintf(
+			"Using %q here may happen before it's initialized, which won't throw an error because %q is not available in %s",
+			symbol.OriginalName, kind, where), notes)
+	}
+	if assignTarget != js_ast.AssignTargetNone && binding.loop != nil && binding.loop.isInsideBody {
+		binding.isAssignedInLoopBody = true
+	}
+	return !isFromClosure && !binding.isInitialized
+}
+
+// Calling a hoisted function before a binding is initialized means references
+// to that binding from inside the function may happen before initialization
+func (p *parser) recordLoweredLexicalEarlyCall(target js_ast.Expr) {
+	id, ok := target.Data.(*js_ast.EIdentifier)
+	if !ok || !p.symbols[id.Ref.InnerIndex].Kind.IsFunction() {
+		return
+	}
+	for s := p.currentScope; s != nil; s = s.Parent {
+		for _, member := range s.Members {
+			if binding, ok := p.loweredLexicalBindings[member.Ref]; ok && binding.scope == s && !binding.isInitialized {
+				if p.loweredEarlyCalls == nil {
+					p.loweredEarlyCalls = make(map[js_ast.Ref]map[*loweredLexicalBinding]bool)
+				}
+				bindings := p.loweredEarlyCalls[id.Ref]
+				if bindings == nil {
+					bindings = make(map[*loweredLexicalBinding]bool)
+					p.loweredEarlyCalls[id.Ref] = bindings
+				}
+				bindings[binding] = true
+			}
+		}
+	}
+}
+
+func (p *parser) lowerLoweredLexicalTDZ(loc logger.Loc, ref js_ast.Ref) js_ast.Expr {
+	// "x; let x" => "__earlyAccess('x'); var x"
+	name := p.symbols[ref.InnerIndex].OriginalName
+	return p.callRuntime(loc, "__earlyAccess", []js_ast.Expr{{Loc: loc, Data: &js_ast.EString{Value: js_lexer.StringToUTF16(name)}}})
+}
+
+// Assigning to a lowered "const" binding must throw a TypeError, and assigning
+// to a lowered binding before it has been initialized must throw a
+// ReferenceError. This returns an expression that throws the error.
+func (p *parser) throwForLoweredLexicalAssign(target js_ast.Expr) (throwExpr js_ast.Expr, isTDZ bool, ok bool) {
+	id, ok := target.Data.(*js_ast.EIdentifier)
+	if !ok {
+		return
+	}
+	binding, ok := p.loweredLexicalBindings[id.Ref]
+	if !ok {
+		return
+	}
+	symbol := &p.symbols[id.Ref.InnerIndex]
+	var helper string
+	if !binding.isInitialized && !p.isLoweredLexicalReferenceFromClosure(binding) {
+		helper = "__earlyAccess"
+		isTDZ = true
+	} else if symbol.Kind == js_ast.SymbolConst {
+		helper = "__constAssign"
+	} else {
+		ok = false
+		return
+	}
+	throwExpr = p.callRuntime(target.Loc, helper, []js_ast.Expr{{Loc: target.Loc, Data: &js_ast.EString{Value: js_lexer.StringToUTF16(symbol.OriginalName)}}})
+	return
+}
+
+func (p *parser) lowerAssignToLoweredLexical(loc logger.Loc, e *js_ast.EBinary) (js_ast.Expr, bool) {
+	throwExpr, isTDZ, ok := p.throwForLoweredLexicalAssign(e.Left)
+	if !ok {
+		return js_ast.Expr{}, false
+	}
+
+	// The value is evaluated before a plain assignment throws
+	if e.Op == js_ast.BinOpAssign {
+		// "x = y" => "(y, __constAssign('x'))"
+		return js_ast.JoinWithComma(e.Right, throwExpr), true
+	}
+
+	// Otherwise the binding is read first, which throws if it's uninitialized
+	if isTDZ {
+		return throwExpr, true
+	}
+	right := js_ast.JoinWithComma(e.Right, throwExpr)
+
+	switch e.Op {
+	case js_ast.BinOpLogicalOrAssign:
+		// "x ||= y" => "x || (y, __constAssign('x'))"
+		return js_ast.Expr{Loc: loc, Data: &js_ast.EBinary{Op: js_ast.BinOpLogicalOr, Left: e.Left, Right: right}}, true
+
+	case js_ast.BinOpLogicalAndAssign:
+		// "x &&= y" => "x && (y, __constAssign('x'))"
+		return js_ast.Expr{Loc: loc, Data: &js_ast.EBinary{Op: js_ast.BinOpLogicalAnd, Left: e.Left, Right: right}}, true
+
+	case js_ast.BinOpNullishCoalescingAssign:
+		// "x ??= y" => "x ?? (y, __constAssign('x'))"
+		if p.options.unsupportedJSFeatures.Has(compat.NullishCoalescing) {
+			return p.lowerNullishCoalescing(loc, e.Left, right), true
+		}
+		return js_ast.Expr{Loc: loc, Data: &js_ast.EBinary{Op: js_ast.BinOpNullishCoalescing, Left: e.Left, Right: right}}, true
+	}
+
+	// "x += y" => "(y, __constAssign('x'))"
+	return right, true
+}
+
+func (p *parser) pushLoweredLoop() *loweredLoop {
+	if p.loweredLexicalBindings == nil {
+		return nil
+	}
+	loop := &loweredLoop{
+		parent: p.fnOrArrowDataVisit.loweredLoop,
+		outer:  p.fnOnlyDataVisit.loweredLoop,
+	}
+	for s := p.currentScope; s.Kind == js_ast.ScopeLabel; s = s.Parent {
+		loop.labels = append(loop.labels, s.Label.Ref)
+	}
+	p.fnOrArrowDataVisit.loweredLoop = loop
+	p.fnOnlyDataVisit.loweredLoop = loop
+	return loop
+}
+
+func (p *parser) popLoweredLoop(loop *loweredLoop) {
+	if loop != nil {
+		p.fnOrArrowDataVisit.loweredLoop = loop.parent
+		p.fnOnlyDataVisit.loweredLoop = loop.outer
+	}
+}
+
+// Moving a loop body into a function changes the meaning of "arguments",
+// "super", "new.target", "yield", and "await". The loops that are affected are
+// different because arrow functions inherit some of these but not others.
+func (p *parser) markLoweredLoopsAsUnsupported(name string, r logger.Range, isInheritedByArrows bool) {
+	loop := p.fnOrArrowDataVisit.loweredLoop
+	if isInheritedByArrows {
+		loop = p.fnOnlyDataVisit.loweredLoop
+	}
+	for loop != nil {
+		if loop.unsupportedName == "" {
+			loop.unsupportedName = name
+			loop.unsupportedRange = r
+		}
+		if isInheritedByArrows {
+			loop = loop.outer
+		} else {
+			loop = loop.parent
+		}
+	}
+}
+
+func (p *parser) markLoweredLoopsAsUsingThis() {
+	for loop := p.fnOnlyDataVisit.loweredLoop; loop != nil; loop = loop.outer {
+		loop.usesThis = true
+	}
+}
+
+// If a closure in the loop captures one of the loop's bindings, the loop body
+// is moved into a function that's called once per iteration. Control flow that
+// leaves the loop body is forwarded through the function's return value.
+// Closures in the initializer of a "for" loop capture a copy of the bindings
+// that is separate from the copies used by the iterations of the loop. This is
+// emulated by evaluating the initializer inside a function:
+//
+//	"for (let i = 0, f = () => i; ...)" =>
+//	"for (var _a = function() { var i = 0, f = () => i; return [i, f]; }(), i = _a[0], f = _a[1]; ...)"
+func (p *parser) lowerForLoopInitWithCapturedBindings(s *js_ast.SFor, loop *loweredLoop) {
+	local, ok := s.InitOrNil.Data.(*js_ast.SLocal)
+	if !ok || len(loop.headRefs) == 0 {
+		return
+	}
+	loc := s.InitOrNil.Loc
+
+	// "return [i, f]"
+	items := make([]js_ast.Expr, 0, len(loop.headRefs))
+	for _, ref := range loop.headRefs {
+		items = append(items, js_ast.Expr{Loc: loc, Data: &js_ast.EIdentifier{Ref: ref}})
+		p.recordUsage(ref)
+	}
+	value := js_ast.Expr{Loc: loc, Data: &js_ast.EArray{Items: items, IsSingleLine: true}}
+
+	// "function() { var i = 0, f = () => i; return [i, f]; }()"
+	target := js_ast.Expr{Loc: loc, Data: &js_ast.EFunction{Fn: js_ast.Fn{
+		Body: js_ast.FnBody{Loc: loc, Stmts: []js_ast.Stmt{
+			{Loc: loc, Data: &js_ast.SLocal{Kind: js_ast.LocalVar, Decls: local.Decls}},
+			{Loc: loc, Data: &js_ast.SReturn{ValueOrNil: value}},
+		}},
+		ArgumentsRef: js_ast.InvalidRef,
+	}}}
+	var args []js_ast.Expr
+	if loop.usesThis {
+		target = js_ast.Expr{Loc: loc, Data: &js_ast.EDot{Target: target, Name: "call", NameLoc: loc}}
+		args = []js_ast.Expr{{Loc: loc, Data: js_ast.EThisShared}}
+	}
+	call := js_ast.Expr{Loc: loc, Data: &js_ast.ECall{Target: target, Args: args}}
+
+	// "var _a = function() { ... }(), i = _a[0], f = _a[1]"
+	tempRef := p.generateTempRef(tempRefNoDeclare, "")
+	decls := make([]js_ast.Decl, 0, len(loop.headRefs)+1)
+	decls = append(decls, js_ast.Decl{Binding: js_ast.Binding{Loc: loc, Data: &js_ast.BIdentifier{Ref: tempRef}}, ValueOrNil: call})
+	p.recordUsage(tempRef)
+	for i, ref := range loop.headRefs {
+		decls = append(decls, js_ast.Decl{
+			Binding: js_ast.Binding{Loc: loc, Data: &js_ast.BIdentifier{Ref: ref}},
+			ValueOrNil: js_ast.Expr{Loc: loc, Data: &js_ast.EIndex{
+				Target: js_ast.Expr{Loc: loc, Data: &js_ast.EIdentifier{Ref: tempRef}},
+				Index:  js_ast.Expr{Loc: loc, Data: &js_ast.ENumber{Value: float64(i)}},
+			}},
+		})
+		p.recordUsage(tempRef)
+	}
+	local.Decls = decls
+}
+
+// If the binding pattern in a for-in or for-of loop header was lowered, the
+// header now declares a temporary and the bindings themselves are declared in
+// the loop body. In that case the temporary must be passed to each iteration.
+func (p *parser) updateLoopHeadRefsAfterLowering(loop *loweredLoop, init js_ast.Stmt) {
+	if local, ok := init.Data.(*js_ast.SLocal); ok && len(loop.headRefs) > 0 && len(local.Decls) == 1 {
+		if id, ok := local.Decls[0].Binding.Data.(*js_ast.BIdentifier); ok {
+			loop.headRefs = []js_ast.Ref{id.Ref}
+		}
+	}
+}
+
+func (p *parser) lowerLoopWithCapturedBindings(stmts []js_ast.Stmt, loopStmt js_ast.Stmt, loop *loweredLoop) []js_ast.Stmt {
+	if s, ok := loopStmt.Data.(*js_ast.SFor); ok && loop.isCapturedInInit {
+		if loop.unsupportedName != "" {
+			where, notes := p.prettyPrintTargetEnvironment(compat.Let)
+			p.log.AddWithNotes(logger.Error, &p.tracker, loop.unsupportedRange, fmt.Sprintf(
+				"Transforming a loop that uses %q to %s is not supported yet when a closure in the loop captures one of its \"let\" or \"const\" bindings",
+				loop.unsupportedName, where), notes)
+			return append(stmts, loopStmt)
+		}
+		p.lowerForLoopInitWithCapturedBindings(s, loop)
+	}
+
+	isCaptured := false
+	for _, binding := range loop.bindings {
+		if binding.isCaptured {
+			isCaptured = true
+			break
+		}
+	}
+	if !isCaptured {
+		return append(stmts, loopStmt)
+	}
+	if loop.unsupportedName != "" {
+		where, notes := p.prettyPrintTargetEnvironment(compat.Let)
+		p.log.AddWithNotes(logger.Error, &p.tracker, loop.unsupportedRange, fmt.Sprintf(
+			"Transforming a loop that uses %q to %s is not supported yet when a closure in the loop captures one of its \"let\" or \"const\" bindings",
+			loop.unsupportedName, where), notes)
+		return append(stmts, loopStmt)
+	}
+
+	var body *js_ast.Stmt
+	switch s := loopStmt.Data.(type) {
+	case *js_ast.SFor:
+		body = &s.Body
+	case *js_ast.SForIn:
+		body = &s.Body
+		p.updateLoopHeadRefsAfterLowering(loop, s.Init)
+	case *js_ast.SForOf:
+		body = &s.Body
+		p.updateLoopHeadRefsAfterLowering(loop, s.Init)
+	case *js_ast.SWhile:
+		body = &s.Body
+	case *js_ast.SDoWhile:
+		body = &s.Body
+	default:
+		panic("Internal error")
+	}
+	bodyLoc := body.Loc
+	var bodyStmts []js_ast.Stmt
+	if block, ok := body.Data.(*js_ast.SBlock); ok {
+		bodyStmts = block.Stmts
+	} else {
+		bodyStmts = []js_ast.Stmt{*body}
+	}
+
+	// Assignments to bindings in the loop header must be copied back out of the
+	// function before the next iteration of the loop starts
+	r := loopBodyRewriter{p: p, loop: loop}
+	var copyIn []js_ast.Expr
+	for _, ref := range loop.headRefs {
+		if binding := p.loweredLexicalBindings[ref]; binding != nil && binding.isAssignedInLoopBody {
+			tempRef := p.generateTempRef(tempRefNeedsDeclare, "_"+p.symbols[ref.InnerIndex].OriginalName)
+			r.copyOut = js_ast.JoinWithComma(r.copyOut, js_ast.Assign(
+				js_ast.Expr{Loc: bodyLoc, Data: &js_ast.EIdentifier{Ref: tempRef}},
+				js_ast.Expr{Loc: bodyLoc, Data: &js_ast.EIdentifier{Ref: ref}},
+			))
+			copyIn = append(copyIn, js_ast.Assign(
+				js_ast.Expr{Loc: bodyLoc, Data: &js_ast.EIdentifier{Ref: ref}},
+				js_ast.Expr{Loc: bodyLoc, Data: &js_ast.EIdentifier{Ref: tempRef}},
+			))
+			p.recordUsage(ref)
+			p.recordUsage(ref)
+			p.recordUsage(tempRef)
+			p.recordUsage(tempRef)
+		}
+	}
+	bodyStmts = r.visitStmts(bodyStmts)
+	if r.copyOut.Data != nil {
+		bodyStmts = append(bodyStmts, js_ast.Stmt{Loc: bodyLoc, Data: &js_ast.SExpr{Value: r.copyOut}})
+	}
+
+	// "var" declarations in the loop body must stay in the enclosing function
+	if len(r.hoistedVars) > 0 {
+		stmts = append(stmts, js_ast.Stmt{Loc: loopStmt.Loc, Data: &js_ast.SLocal{Kind: js_ast.LocalVar, Decls: r.hoistedVars}})
+	}
+
+	// "_loop = function(i) { ... }"
+	loopRef := p.generateTempRef(tempRefNeedsDeclare, "_loop")
+	args := make([]js_ast.Arg, 0, len(loop.headRefs))
+	callArgs := make([]js_ast.Expr, 0, len(loop.headRefs)+1)
+	if loop.usesThis {
+		callArgs = append(callArgs, js_ast.Expr{Loc: bodyLoc, Data: js_ast.EThisShared})
+	}
+	for _, ref := range loop.headRefs {
+		args = append(args, js_ast.Arg{Binding: js_ast.Binding{Loc: bodyLoc, Data: &js_ast.BIdentifier{Ref: ref}}})
+		callArgs = append(callArgs, js_ast.Expr{Loc: bodyLoc, Data: &js_ast.EIdentifier{Ref: ref}})
+		p.recordUsage(ref)
+	}
+	stmts = append(stmts, js_ast.Stmt{Loc: loopStmt.Loc, Data: &js_ast.SExpr{Value: js_ast.Assign(
+		js_ast.Expr{Loc: loopStmt.Loc, Data: &js_ast.EIdentifier{Ref: loopRef}},
+		js_ast.Expr{Loc: loopStmt.Loc, Data: &js_ast.EFunction{Fn: js_ast.Fn{
+			Args:         args,
+			Body:         js_ast.FnBody{Loc: bodyLoc, Stmts: bodyStmts},
+			ArgumentsRef: js_ast.InvalidRef,
+		}}},
+	)}})
+	p.recordUsage(loopRef)
+
+	// "_loop(i)" or "_loop.call(this, i)"
+	callTarget := js_ast.Expr{Loc: bodyLoc, Data: &js_ast.EIdentifier{Ref: loopRef}}
+	p.recordUsage(loopRef)
+	if loop.usesThis {
+		callTarget = js_ast.Expr{Loc: bodyLoc, Data: &js_ast.EDot{Target: callTarget, Name: "call", NameLoc: bodyLoc}}
+	}
+	call := js_ast.Expr{Loc: bodyLoc, Data: &js_ast.ECall{Target: callTarget, Args: callArgs}}
+	var newBody []js_ast.Stmt
+	if !r.hasBreak && !r.hasReturn && len(r.labelJumps) == 0 {
+		newBody = append(newBody, js_ast.Stmt{Loc: bodyLoc, Data: &js_ast.SExpr{Value: call}})
+		for _, value := range copyIn {
+			newBody = append(newBody, js_ast.Stmt{Loc: bodyLoc, Data: &js_ast.SExpr{Value: value}})
+		}
+	} else {
+		// "_ret = _loop(i)"
+		retRef := p.generateTempRef(tempRefNeedsDeclare, "_ret")
+		retExpr := func() js_ast.Expr {
+			p.recordUsage(retRef)
+			return js_ast.Expr{Loc: bodyLoc, Data: &js_ast.EIdentifier{Ref: retRef}}
+		}
+		newBody = append(newBody, js_ast.Stmt{Loc: bodyLoc, Data: &js_ast.SExpr{Value: js_ast.Assign(retExpr(), call)}})
+		for _, value := range copyIn {
+			newBody = append(newBody, js_ast.Stmt{Loc: bodyLoc, Data: &js_ast.SExpr{Value: value}})
+		}
+		checkSignal := func(signal string, yes js_ast.S) {
+			newBody = append(newBody, js_ast.Stmt{Loc: bodyLoc, Data: &js_ast.SIf{
+				Test: js_ast.Expr{Loc: bodyLoc, Data: &js_ast.EBinary{
+					Op:    js_ast.BinOpStrictEq,
+					Left:  retExpr(),
+					Right: js_ast.Expr{Loc: bodyLoc, Data: &js_ast.EString{Value: js_lexer.StringToUTF16(signal)}},
+				}},
+				Yes: js_ast.Stmt{Loc: bodyLoc, Data: yes},
+			}})
+		}
+
+		// "if (_ret === 'break') break;"
+		if r.hasBreak {
+			checkSignal("break", &js_ast.SBreak{})
+		}
+
+		// "if (_ret === 'continue|outer') continue outer;"
+		for _, jump := range r.labelJumps {
+			label := &js_ast.LocRef{Loc: bodyLoc, Ref: jump.ref}
+			name := p.symbols[jump.ref.InnerIndex].OriginalName
+			if jump.isContinue {
+				checkSignal("continue|"+name, &js_ast.SContinue{Label: label})
+			} else {
+				checkSignal("break|"+name, &js_ast.SBreak{Label: label})
+			}
+		}
+
+		// "if (typeof _ret === 'object') return _ret.v;"
+		if r.hasReturn {
+			newBody = append(newBody, js_ast.Stmt{Loc: bodyLoc, Data: &js_ast.SIf{
+				Test: js_ast.Expr{Loc: bodyLoc, Data: &js_ast.EBinary{
+					Op:    js_ast.BinOpStrictEq,
+					Left:  js_ast.Expr{Loc: bodyLoc, Data: &js_ast.EUnary{Op: js_ast.UnOpTypeof, Value: retExpr()}},
+					Right: js_ast.Expr{Loc: bodyLoc, Data: &js_ast.EString{Value: js_lexer.StringToUTF16("object")}},
+				}},
+				Yes: js_ast.Stmt{Loc: bodyLoc, Data: &js_ast.SReturn{ValueOrNil: js_ast.Expr{Loc: bodyLoc, Data: &js_ast.EDot{
+					Target:  retExpr(),
+					Name:    "v",
+					NameLoc: bodyLoc,
+				}}}},
+			}})
+		}
+	}
+	*body = js_ast.Stmt{Loc: bodyLoc, Data: &js_ast.SBlock{Stmts: newBody}}
+
+	return append(stmts, loopStmt)
+}
+
+type loopBodyLabelJump struct {
+	ref        js_ast.Ref
+	isContinue bool
+}
+
+// This rewrites the statements of a loop body that is being moved into a
+// function. It doesn't traverse into nested functions or expressions since
+// jumps and "var" declarations can't cross a function boundary.
+type loopBodyRewriter struct {
+	p           *parser
+	loop        *loweredLoop
+	copyOut     js_ast.Expr
+	hoistedVars []js_ast.Decl
+
+	// Jumps to labels outside of the loop are forwarded to the caller
+	labelJumps  []loopBodyLabelJump
+	innerLabels map[js_ast.Ref]bool
+
+	loopDepth   int
+	switchDepth int
+	hasBreak    bool
+	hasReturn   bool
+}
+
+func (r *loopBodyRewriter) visitStmts(stmts []js_ast.Stmt) []js_ast.Stmt {
+	result := make([]js_ast.Stmt, 0, len(stmts))
+	for _, stmt := range stmts {
+		result = r.visitAndAppendStmt(result, stmt)
+	}
+	return result
+}
+
+func (r *loopBodyRewriter) visitSingleStmt(stmt js_ast.Stmt) js_ast.Stmt {
+	return stmtsToSingleStmt(stmt.Loc, r.visitAndAppendStmt(nil, stmt))
+}
+
+func (r *loopBodyRewriter) visitAndAppendStmt(stmts []js_ast.Stmt, stmt js_ast.Stmt) []js_ast.Stmt {
+	switch s := stmt.Data.(type) {
+	case *js_ast.SLocal:
+		if value, ok := r.hoistVars(s); ok {
+			if value.Data == nil {
+				return stmts
+			}
+			return append(stmts, js_ast.Stmt{Loc: stmt.Loc, Data: &js_ast.SExpr{Value: value}})
+		}
+
+	case *js_ast.SBlock:
+		s.Stmts = r.visitStmts(s.Stmts)
+
+	case *js_ast.SIf:
+		s.Yes = r.visitSingleStmt(s.Yes)
+		if s.NoOrNil.Data != nil {
+			s.NoOrNil = r.visitSingleStmt(s.NoOrNil)
+		}
+
+	case *js_ast.SWith:
+		s.Body = r.visitSingleStmt(s.Body)
+
+	case *js_ast.SLabel:
+		if r.innerLabels == nil {
+			r.innerLabels = make(map[js_ast.Ref]bool)
+		}
+		r.innerLabels[s.Name.Ref] = true
+		s.Stmt = r.visitSingleStmt(s.Stmt)
+
+	case *js_ast.STry:
+		s.Body = r.visitStmts(s.Body)
+		if s.Catch != nil {
+			s.Catch.Body = r.visitStmts(s.Catch.Body)
+		}
+		if s.Finally != nil {
+			s.Finally.Stmts = r.visitStmts(s.Finally.Stmts)
+		}
+
+	case *js_ast.SSwitch:
+		r.switchDepth++
+		for i := range s.Cases {
+			s.Cases[i].Body = r.visitStmts(s.Cases[i].Body)
+		}
+		r.switchDepth--
+
+	case *js_ast.SFor:
+		if local, ok := s.InitOrNil.Data.(*js_ast.SLocal); ok {
+			if value, ok := r.hoistVars(local); ok {
+				if value.Data == nil {
+					s.InitOrNil = js_ast.Stmt{}
+				} else {
+					s.InitOrNil = js_ast.Stmt{Loc: s.InitOrNil.Loc, Data: &js_ast.SExpr{Value: value}}
+				}
+			}
+		}
+		r.loopDepth++
+		s.Body = r.visitSingleStmt(s.Body)
+		r.loopDepth--
+
+	case *js_ast.SForIn:
+		r.hoistForInOrOfVars(&s.Init)
+		r.loopDepth++
+		s.Body = r.visitSingleStmt(s.Body)
+		r.loopDepth--
+
+	case *js_ast.SForOf:
+		r.hoistForInOrOfVars(&s.Init)
+		r.loopDepth++
+		s.Body = r.visitSingleStmt(s.Body)
+		r.loopDepth--
+
+	case *js_ast.SWhile:
+		r.loopDepth++
+		s.Body = r.visitSingleStmt(s.Body)
+		r.loopDepth--
+
+	case *js_ast.SDoWhile:
+		r.loopDepth++
+		s.Body = r.visitSingleStmt(s.Body)
+		r.loopDepth--
+
+	case *js_ast.SReturn:
+		// "return x" => "return { v: x }"
+		value := s.ValueOrNil
+		if value.Data == nil {
+			value = js_ast.Expr{Loc: stmt.Loc, Data: js_ast.EUndefinedShared}
+		}
+		r.hasReturn = true
+		return append(stmts, js_ast.Stmt{Loc: stmt.Loc, Data: &js_ast.SReturn{ValueOrNil: js_ast.Expr{Loc: stmt.Loc, Data: &js_ast.EObject{
+			Properties: []js_ast.Property{{
+				Key:        js_ast.Expr{Loc: stmt.Loc, Data: &js_ast.EString{Value: js_lexer.StringToUTF16("v")}},
+				ValueOrNil: value,
+			}},
+		}}}})
+
+	case *js_ast.SBreak:
+		if s.Label == nil {
+			if r.loopDepth == 0 && r.switchDepth == 0 {
+				// "break" => "return 'break'"
+				r.hasBreak = true
+				return append(stmts, r.returnSignal(stmt.Loc, "break"))
+			}
+		} else if !r.innerLabels[s.Label.Ref] {
+			if r.isLoopLabel(s.Label.Ref) {
+				r.hasBreak = true
+				return append(stmts, r.returnSignal(stmt.Loc, "break"))
+			}
+
+			// "break outer" => "return 'break|outer'"
+			r.addLabelJump(s.Label.Ref, false)
+			return append(stmts, r.returnSignal(stmt.Loc, "break|"+r.p.symbols[s.Label.Ref.InnerIndex].OriginalName))
+		}
+
+	case *js_ast.SContinue:
+		if s.Label == nil {
+			if r.loopDepth == 0 {
+				// "continue" => "return"
+				return r.appendReturnFromIteration(stmts, stmt.Loc)
+			}
+		} else if !r.innerLabels[s.Label.Ref] {
+			if r.isLoopLabel(s.Label.Ref) {
+				return r.appendReturnFromIteration(stmts, stmt.Loc)
+			}
+
+			// "continue outer" => "return 'continue|outer'"
+			r.addLabelJump(s.Label.Ref, true)
+			return append(stmts, r.returnSignal(stmt.Loc, "continue|"+r.p.symbols[s.Label.Ref.InnerIndex].OriginalName))
+		}
+	}
+
+	return append(stmts, stmt)
+}
+
+func (r *loopBodyRewriter) isLoopLabel(ref js_ast.Ref) bool {
+	for _, label := range r.loop.labels {
+		if label == ref {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *loopBodyRewriter) addLabelJump(ref js_ast.Ref, isContinue bool) {
+	for _, jump := range r.labelJumps {
+		if jump.ref == ref && jump.isContinue == isContinue {
+			return
+		}
+	}
+	r.labelJumps = append(r.labelJumps, loopBodyLabelJump{ref: ref, isContinue: isContinue})
+}
+
+func (r *loopBodyRewriter) returnSignal(loc logger.Loc, signal string) js_ast.Stmt {
+	return js_ast.Stmt{Loc: loc, Data: &js_ast.SReturn{ValueOrNil: js_ast.Expr{Loc: loc, Data: &js_ast.EString{Value: js_lexer.StringToUTF16(signal)}}}}
+}
+
+// Ending the current iteration early must still copy assignments to the loop
+// header's bindings back out of the function
+func (r *loopBodyRewriter) appendReturnFromIteration(stmts []js_ast.Stmt, loc logger.Loc) []js_ast.Stmt {
+	if r.copyOut.Data != nil {
+		stmts = append(stmts, js_ast.Stmt{Loc: loc, Data: &js_ast.SExpr{Value: r.copyOut}})
+	}
+	return append(stmts, js_ast.Stmt{Loc: loc, Data: &js_ast.SReturn{}})
+}
+
+// Declarations that were originally "var" are turned into assignments and are
+// declared outside of the function instead. Lowered "let" and "const"
+// declarations aren't hoisted since they must be unique to each iteration.
+func (r *loopBodyRewriter) hoistVars(local *js_ast.SLocal) (js_ast.Expr, bool) {
+	if local.Kind != js_ast.LocalVar || !r.isHoistedVar(local.Decls) {
+		return js_ast.Expr{}, false
+	}
+	var value js_ast.Expr
+	for _, decl := range local.Decls {
+		binding := js_ast.ConvertBindingToExpr(decl.Binding, r.wrapHoistedVar)
+		if decl.ValueOrNil.Data != nil {
+			value = js_ast.JoinWithComma(value, js_ast.Assign(binding, decl.ValueOrNil))
+		}
+	}
+	return value, true
+}
+
+func (r *loopBodyRewriter) hoistForInOrOfVars(init *js_ast.Stmt) {
+	if local, ok := init.Data.(*js_ast.SLocal); ok && local.Kind == js_ast.LocalVar && r.isHoistedVar(local.Decls) {
+		*init = js_ast.Stmt{Loc: init.Loc, Data: &js_ast.SExpr{Value: js_ast.ConvertBindingToExpr(local.Decls[0].Binding, r.wrapHoistedVar)}}
+	}
+}
+
+func (r *loopBodyRewriter) isHoistedVar(decls []js_ast.Decl) bool {
+	for _, decl := range decls {
+		for _, id := range findIdentifiers(decl.Binding, nil) {
+			ref := id.Binding.Data.(*js_ast.BIdentifier).Ref
+			if _, ok := r.p.loweredLexicalBindings[ref]; ok {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func (r *loopBodyRewriter) wrapHoistedVar(loc logger.Loc, ref js_ast.Ref) js_ast.Expr {
+	r.p.recordUsage(ref)
+	for _, decl := range r.hoistedVars {
+		if decl.Binding.Data.(*js_ast.BIdentifier).Ref == ref {
+			return js_ast.Expr{Loc: loc, Data: &js_ast.EIdentifier{Ref: ref}}
+		}
+	}
+	r.hoistedVars = append(r.hoistedVars, js_ast.Decl{Binding: js_ast.Binding{Loc: loc, Data: &js_ast.BIdentifier{Ref: ref}}})
+	return js_ast.Expr{Loc: loc, Data: &js_ast.EIdentifier{Ref: ref}}
+}
@@ -0,0 +1,1241 @@
+// This file contains code for lowering generator functions to a state machine
+// for environments without generators. Async functions are lowered to
+// generator functions first, so this is also what lets async functions run
+// in those environments.
+//
+// The body of the generator function is split into numbered cases at every
+// "yield" expression and every jump target. Each call into the state machine
+// runs until it reaches the end of a case and then returns an instruction to
+// the "__gen" runtime helper:
+//
+//   function* foo(x) {
+//     try {
+//       x = yield x;
+//     } finally {
+//       bar();
+//     }
+//   }
+//
+// This turns into:
+//
+//   function foo(x) {
+//     return __gen(this, function(_ctx) {
+//       switch (_ctx.label) {
+//         case 0:
+//           _ctx.trys.push([1, , 3, 4]);
+//           _ctx.label = 1;
+//         case 1:
+//           return [2, x, 2];
+//         case 2:
+//           x = _ctx.sent();
+//           return [0, 4];
+//         case 3:
+//           bar();
+//           return [4];
+//         case 4:
+//           return [1];
+//       }
+//     });
+//   }
+//
+// Local variables are hoisted out of the state machine into the enclosing
+// function so that they survive across multiple calls into the state machine.
+
+package js_parser
+
+import (
+	"github.com/evanw/esbuild/internal/js_ast"
+	"github.com/evanw/esbuild/internal/js_lexer"
+	"github.com/evanw/esbuild/internal/logger"
+)
+
+// These must be kept in sync with the "__gen" function in the runtime
+const (
+	generatorOpJump       = 0 // [0, label]
+	generatorOpReturn     = 1 // [1, value]
+	generatorOpYield      = 2 // [2, value, label]
+	generatorOpYieldStar  = 3 // [3, value, label]
+	generatorOpEndFinally = 4 // [4]
+)
+
+type generatorLabel uint32
+
+type generatorJumpTarget struct {
+	labels        []js_ast.Ref
+	breakLabel    generatorLabel
+	continueLabel generatorLabel
+	isLoop        bool
+	isSwitch      bool
+}
+
+type generatorLowering struct {
+	p      *parser
+	ctxRef js_ast.Ref
+
+	// Each case is a list of statements. A case ends when a label is marked.
+	cases      [][]js_ast.Stmt
+	current    []js_ast.Stmt
+	isAbrupt   bool
+	labelCases []int
+	labelUses  [][]*js_ast.ENumber
+
+	jumpTargets   []generatorJumpTarget
+	pendingLabels []js_ast.Ref
+
+	// These are moved out of the state machine into the enclosing function
+	hoistedRefs  []js_ast.Ref
+	isHoisted    map[js_ast.Ref]bool
+	isTemp       map[js_ast.Ref]bool
+	hoistedFuncs []js_ast.Stmt
+
+	// This tracks jumps inside statements that are emitted without splitting
+	// them up. Those jumps don't need to go through the state machine.
+	innerLoopDepth   int
+	innerSwitchDepth int
+	innerLabels      map[js_ast.Ref]bool
+}
+
+// This is called with the visited body of a generator function. The "this"
+// and "arguments" captures are left in the enclosing function since they
+// would have different values inside the state machine.
+func (p *parser) lowerGeneratorBody(bodyLoc logger.Loc, stmts []js_ast.Stmt, superHelpers *superHelpers) []js_ast.Stmt {
+	g := &generatorLowering{
+		p:         p,
+		ctxRef:    p.newSymbol(js_ast.SymbolOther, "_ctx"),
+		isHoisted: make(map[js_ast.Ref]bool),
+		isTemp:    make(map[js_ast.Ref]bool),
+	}
+	p.currentScope.Generated = append(p.currentScope.Generated, g.ctxRef)
+
+	// Keep directives and captured values in the enclosing function
+	var prologue []js_ast.Stmt
+	for len(stmts) > 0 {
+		if _, ok := stmts[0].Data.(*js_ast.SDirective); ok {
+			prologue = append(prologue, stmts[0])
+			stmts = stmts[1:]
+			continue
+		}
+		if local, ok := stmts[0].Data.(*js_ast.SLocal); ok && local.Kind == js_ast.LocalVar {
+			var captures []js_ast.Decl
+			var rest []js_ast.Decl
+			for _, decl := range local.Decls {
+				if id, ok := decl.Binding.Data.(*js_ast.BIdentifier); ok && p.isThisOrArgumentsCapture(id.Ref) {
+					captures = append(captures, decl)
+				} else {
+					rest = append(rest, decl)
+				}
+			}
+			if len(captures) > 0 {
+				prologue = append(prologue, js_ast.Stmt{Loc: stmts[0].Loc, Data: &js_ast.SLocal{Kind: js_ast.LocalVar, Decls: captures}})
+				if len(rest) > 0 {
+					local.Decls = rest
+				} else {
+					stmts = stmts[1:]
+				}
+			}
+		}
+		break
+	}
+	if superHelpers != nil {
+		prologue = append(prologue, p.superHelperStmts(bodyLoc, superHelpers)...)
+	}
+
+	// Generate the state machine
+	g.visitStmts(stmts)
+	if !g.isAbrupt {
+		g.emitInstruction(bodyLoc, generatorOpReturn)
+	}
+	g.cases = append(g.cases, g.current)
+	for label, uses := range g.labelUses {
+		for _, use := range uses {
+			use.Value = float64(g.labelCases[label])
+		}
+	}
+
+	// A state machine with a single case doesn't need a "switch" statement
+	var machine []js_ast.Stmt
+	if len(g.cases) == 1 {
+		machine = g.cases[0]
+	} else {
+		cases := make([]js_ast.Case, len(g.cases))
+		for i, body := range g.cases {
+			cases[i] = js_ast.Case{
+				ValueOrNil: js_ast.Expr{Loc: bodyLoc, Data: &js_ast.ENumber{Value: float64(i)}},
+				Body:       body,
+			}
+		}
+		machine = []js_ast.Stmt{{Loc: bodyLoc, Data: &js_ast.SSwitch{
+			Test:    js_ast.Expr{Loc: bodyLoc, Data: &js_ast.EDot{Target: g.ctxExpr(bodyLoc), Name: "label", NameLoc: bodyLoc}},
+			BodyLoc: bodyLoc,
+			Cases:   cases,
+		}}}
+	}
+
+	// "function* foo() { stmts }" => "function foo() { return __gen(this, function(_ctx) { stmts }) }"
+	result := prologue
+	if len(g.hoistedRefs) > 0 {
+		decls := make([]js_ast.Decl, len(g.hoistedRefs))
+		for i, ref := range g.hoistedRefs {
+			decls[i] = js_ast.Decl{Binding: js_ast.Binding{Loc: bodyLoc, Data: &js_ast.BIdentifier{Ref: ref}}}
+		}
+		result = append(result, js_ast.Stmt{Loc: bodyLoc, Data: &js_ast.SLocal{Kind: js_ast.LocalVar, Decls: decls}})
+	}
+	result = append(result, g.hoistedFuncs...)
+	return append(result, js_ast.Stmt{Loc: bodyLoc, Data: &js_ast.SReturn{ValueOrNil: p.callRuntime(bodyLoc, "__gen", []js_ast.Expr{
+		{Loc: bodyLoc, Data: js_ast.EThisShared},
+		{Loc: bodyLoc, Data: &js_ast.EFunction{Fn: js_ast.Fn{
+			Args: []js_ast.Arg{{Binding: js_ast.Binding{Loc: bodyLoc, Data: &js_ast.BIdentifier{Ref: g.ctxRef}}}},
+			Body: js_ast.FnBody{Loc: bodyLoc, Stmts: machine},
+		}}},
+	})}})
+}
+
+func (p *parser) isThisOrArgumentsCapture(ref js_ast.Ref) bool {
+	return (p.fnOnlyDataVisit.thisCaptureRef != nil && ref == *p.fnOnlyDataVisit.thisCaptureRef) ||
+		(p.fnOnlyDataVisit.argumentsCaptureRef != nil && ref == *p.fnOnlyDataVisit.argumentsCaptureRef)
+}
+
+func (g *generatorLowering) ctxExpr(loc logger.Loc) js_ast.Expr {
+	g.p.recordUsage(g.ctxRef)
+	return js_ast.Expr{Loc: loc, Data: &js_ast.EIdentifier{Ref: g.ctxRef}}
+}
+
+// "_ctx.sent()" evaluates to the value passed to "next()" when resuming
+func (g *generatorLowering) sentExpr(loc logger.Loc) js_ast.Expr {
+	return js_ast.Expr{Loc: loc, Data: &js_ast.ECall{Target: js_ast.Expr{Loc: loc, Data: &js_ast.EDot{
+		Target:  g.ctxExpr(loc),
+		Name:    "sent",
+		NameLoc: loc,
+	}}}}
+}
+
+func (g *generatorLowering) isSentExpr(expr js_ast.Expr) bool {
+	if call, ok := expr.Data.(*js_ast.ECall); ok && len(call.Args) == 0 {
+		if dot, ok := call.Target.Data.(*js_ast.EDot); ok && dot.Name == "sent" {
+			if id, ok := dot.Target.Data.(*js_ast.EIdentifier); ok && id.Ref == g.ctxRef {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (g *generatorLowering) newLabel() generatorLabel {
+	g.labelCases = append(g.labelCases, -1)
+	g.labelUses = append(g.labelUses, nil)
+	return generatorLabel(len(g.labelCases) - 1)
+}
+
+// The label numbers aren't known until the case for the label is created, so
+// references to labels are filled in at the end
+func (g *generatorLowering) labelExpr(loc logger.Loc, label generatorLabel) js_ast.Expr {
+	number := &js_ast.ENumber{}
+	g.labelUses[label] = append(g.labelUses[label], number)
+	return js_ast.Expr{Loc: loc, Data: number}
+}
+
+// Start a new case for this label unless the current case is still empty.
+// Falling through into the next case must update "_ctx.label" because the
+// runtime uses it to determine which "try" block an exception came from.
+func (g *generatorLowering) markLabel(loc logger.Loc, label generatorLabel) {
+	if len(g.current) > 0 || g.isAbrupt {
+		if !g.isAbrupt {
+			g.current = append(g.current, js_ast.AssignStmt(
+				js_ast.Expr{Loc: loc, Data: &js_ast.EDot{Target: g.ctxExpr(loc), Name: "label", NameLoc: loc}},
+				js_ast.Expr{Loc: loc, Data: &js_ast.ENumber{Value: float64(len(g.cases) + 1)}},
+			))
+		}
+		g.cases = append(g.cases, g.current)
+		g.current = nil
+		g.isAbrupt = false
+	}
+	g.labelCases[label] = len(g.cases)
+}
+
+// Code after a "return" or "throw" in the same case is unreachable
+func (g *generatorLowering) emit(stmt js_ast.Stmt) {
+	if !g.isAbrupt {
+		g.current = append(g.current, stmt)
+		switch stmt.Data.(type) {
+		case *js_ast.SReturn, *js_ast.SThrow:
+			g.isAbrupt = true
+		}
+	}
+}
+
+func (g *generatorLowering) emitExpr(expr js_ast.Expr) {
+	switch e := expr.Data.(type) {
+	case nil, *js_ast.ENumber, *js_ast.EString, *js_ast.EBoolean, *js_ast.ENull, *js_ast.EUndefined, *js_ast.EThis:
+		return
+
+	case *js_ast.EIdentifier:
+		if g.isTemp[e.Ref] {
+			return
+		}
+	}
+	if g.isSentExpr(expr) {
+		return
+	}
+	g.emit(js_ast.Stmt{Loc: expr.Loc, Data: &js_ast.SExpr{Value: expr}})
+}
+
+func (g *generatorLowering) instruction(loc logger.Loc, op int, args ...js_ast.Expr) js_ast.Stmt {
+	items := append([]js_ast.Expr{{Loc: loc, Data: &js_ast.ENumber{Value: float64(op)}}}, args...)
+	return js_ast.Stmt{Loc: loc, Data: &js_ast.SReturn{ValueOrNil: js_ast.Expr{Loc: loc, Data: &js_ast.EArray{Items: items, IsSingleLine: true}}}}
+}
+
+func (g *generatorLowering) emitInstruction(loc logger.Loc, op int, args ...js_ast.Expr) {
+	g.emit(g.instruction(loc, op, args...))
+}
+
+func (g *generatorLowering) jumpStmt(loc logger.Loc, label generatorLabel) js_ast.Stmt {
+	return g.instruction(loc, generatorOpJump, g.labelExpr(loc, label))
+}
+
+func (g *generatorLowering) emitJump(loc logger.Loc, label generatorLabel) {
+	g.emit(g.jumpStmt(loc, label))
+}
+
+// "if (test) return [0, label];"
+func (g *generatorLowering) emitJumpIf(test js_ast.Expr, label generatorLabel) {
+	if boolean, ok := test.Data.(*js_ast.EBoolean); ok {
+		if boolean.Value {
+			g.emitJump(test.Loc, label)
+		}
+		return
+	}
+	if !g.isAbrupt {
+		g.current = append(g.current, js_ast.Stmt{Loc: test.Loc, Data: &js_ast.SIf{Test: test, Yes: g.jumpStmt(test.Loc, label)}})
+	}
+}
+
+func (g *generatorLowering) hoistRef(loc logger.Loc, ref js_ast.Ref) js_ast.Expr {
+	if !g.isHoisted[ref] {
+		g.isHoisted[ref] = true
+		g.hoistedRefs = append(g.hoistedRefs, ref)
+
+		// Hoisted block-scoped bindings could otherwise collide with each other
+		// after they have been moved into the same scope
+		if !g.isTemp[ref] {
+			g.p.currentScope.Generated = append(g.p.currentScope.Generated, ref)
+		}
+	}
+	g.p.recordUsage(ref)
+	return js_ast.Expr{Loc: loc, Data: &js_ast.EIdentifier{Ref: ref}}
+}
+
+// Temporary variables are only assigned to once, so they never need to be
+// stored in another temporary variable
+func (g *generatorLowering) newTemp(loc logger.Loc) js_ast.Expr {
+	ref := g.p.generateTempRef(tempRefNoDeclare, "")
+	g.isTemp[ref] = true
+	return g.hoistRef(loc, ref)
+}
+
+// Store the value in a temporary variable if evaluating it later could give
+// a different result
+func (g *generatorLowering) spill(expr js_ast.Expr) js_ast.Expr {
+	switch e := expr.Data.(type) {
+	case *js_ast.ENumber, *js_ast.EString, *js_ast.EBoolean, *js_ast.ENull, *js_ast.EUndefined,
+		*js_ast.EThis, *js_ast.EFunction, *js_ast.EArrow, *js_ast.ERegExp:
+		return expr
+
+	case *js_ast.EIdentifier:
+		if g.isTemp[e.Ref] {
+			return expr
+		}
+	}
+	temp := g.newTemp(expr.Loc)
+	g.emitExpr(js_ast.Assign(temp, expr))
+	return temp
+}
+
+// Declarations are turned into assignments since the variables are hoisted
+func (g *generatorLowering) hoistDecls(decls []js_ast.Decl) (value js_ast.Expr) {
+	for _, decl := range decls {
+		target := js_ast.ConvertBindingToExpr(decl.Binding, g.hoistRef)
+		if decl.ValueOrNil.Data != nil {
+			value = js_ast.JoinWithComma(value, js_ast.Assign(target, decl.ValueOrNil))
+		}
+	}
+	return
+}
+
+func (g *generatorLowering) hoistForInOrOfInit(init js_ast.Stmt) js_ast.Stmt {
+	if local, ok := init.Data.(*js_ast.SLocal); ok {
+		return js_ast.Stmt{Loc: init.Loc, Data: &js_ast.SExpr{Value: js_ast.ConvertBindingToExpr(local.Decls[0].Binding, g.hoistRef)}}
+	}
+	return init
+}
+
+func (g *generatorLowering) pushJumpTarget(target generatorJumpTarget) {
+	target.labels = g.pendingLabels
+	g.pendingLabels = nil
+	g.jumpTargets = append(g.jumpTargets, target)
+}
+
+func (g *generatorLowering) popJumpTarget() {
+	g.jumpTargets = g.jumpTargets[:len(g.jumpTargets)-1]
+}
+
+func (g *generatorLowering) findJumpTarget(label *js_ast.LocRef, isContinue bool) generatorLabel {
+	for i := len(g.jumpTargets) - 1; i >= 0; i-- {
+		target := g.jumpTargets[i]
+		if label != nil {
+			for _, ref := range target.labels {
+				if ref == label.Ref {
+					if isContinue {
+						return target.continueLabel
+					}
+					return target.breakLabel
+				}
+			}
+		} else if isContinue && target.isLoop {
+			return target.continueLabel
+		} else if !isContinue && (target.isLoop || target.isSwitch) {
+			return target.breakLabel
+		}
+	}
+	panic("Internal error")
+}
+
+func (g *generatorLowering) visitStmts(stmts []js_ast.Stmt) {
+	for _, stmt := range stmts {
+		g.visitStmt(stmt)
+	}
+}
+
+func (g *generatorLowering) visitStmt(stmt js_ast.Stmt) {
+	// Declarations must always be hoisted since later cases may use them
+	switch s := stmt.Data.(type) {
+	case *js_ast.SFunction:
+		g.hoistedFuncs = append(g.hoistedFuncs, stmt)
+		return
+
+	case *js_ast.SLocal:
+		for _, decl := range s.Decls {
+			if decl.ValueOrNil.Data != nil {
+				target := js_ast.ConvertBindingToExpr(decl.Binding, g.hoistRef)
+				g.emitExpr(js_ast.Assign(target, g.visitExpr(decl.ValueOrNil)))
+			} else {
+				js_ast.ConvertBindingToExpr(decl.Binding, g.hoistRef)
+			}
+		}
+		return
+
+	case *js_ast.SClass:
+		if !classContainsYield(&s.Class) {
+			name := g.hoistRef(s.Class.Name.Loc, s.Class.Name.Ref)
+			g.emitExpr(js_ast.Assign(name, js_ast.Expr{Loc: stmt.Loc, Data: &js_ast.EClass{Class: s.Class}}))
+			return
+		}
+	}
+
+	// Statements without "yield" don't need to be split up
+	if !stmtContainsYield(stmt) {
+		g.innerLoopDepth = 0
+		g.innerSwitchDepth = 0
+		g.innerLabels = nil
+		for _, s := range g.rewriteAndAppendStmt(nil, stmt) {
+			g.emit(s)
+		}
+		return
+	}
+
+	switch s := stmt.Data.(type) {
+	case *js_ast.SBlock:
+		g.visitStmts(s.Stmts)
+		return
+
+	case *js_ast.SExpr:
+		g.emitExpr(g.visitExpr(s.Value))
+		return
+
+	case *js_ast.SReturn:
+		// "return yield x" => "return [2, x, label]; label: return [1, _ctx.sent()]"
+		g.emitInstruction(stmt.Loc, generatorOpReturn, g.visitExpr(s.ValueOrNil))
+		return
+
+	case *js_ast.SThrow:
+		g.emit(js_ast.Stmt{Loc: stmt.Loc, Data: &js_ast.SThrow{Value: g.visitExpr(s.Value)}})
+		return
+
+	case *js_ast.SLabel:
+		g.pendingLabels = append(g.pendingLabels, s.Name.Ref)
+		switch s.Stmt.Data.(type) {
+		case *js_ast.SLabel, *js_ast.SFor, *js_ast.SForIn, *js_ast.SWhile, *js_ast.SDoWhile:
+			g.visitStmt(s.Stmt)
+		default:
+			end := g.newLabel()
+			g.pushJumpTarget(generatorJumpTarget{breakLabel: end})
+			g.visitStmt(s.Stmt)
+			g.popJumpTarget()
+			g.markLabel(stmt.Loc, end)
+		}
+		return
+
+	case *js_ast.SIf:
+		test := g.visitExpr(s.Test)
+		end := g.newLabel()
+		if s.NoOrNil.Data == nil {
+			g.emitJumpIf(js_ast.Not(test), end)
+			g.visitStmt(s.Yes)
+		} else {
+			no := g.newLabel()
+			g.emitJumpIf(js_ast.Not(test), no)
+			g.visitStmt(s.Yes)
+			g.emitJump(stmt.Loc, end)
+			g.markLabel(stmt.Loc, no)
+			g.visitStmt(s.NoOrNil)
+		}
+		g.markLabel(stmt.Loc, end)
+		return
+
+	case *js_ast.SWhile:
+		loop := g.newLabel()
+		end := g.newLabel()
+		g.markLabel(stmt.Loc, loop)
+		g.emitJumpIf(js_ast.Not(g.visitExpr(s.Test)), end)
+		g.pushJumpTarget(generatorJumpTarget{breakLabel: end, continueLabel: loop, isLoop: true})
+		g.visitStmt(s.Body)
+		g.popJumpTarget()
+		g.emitJump(stmt.Loc, loop)
+		g.markLabel(stmt.Loc, end)
+		return
+
+	case *js_ast.SDoWhile:
+		loop := g.newLabel()
+		test := g.newLabel()
+		end := g.newLabel()
+		g.markLabel(stmt.Loc, loop)
+		g.pushJumpTarget(generatorJumpTarget{breakLabel: end, continueLabel: test, isLoop: true})
+		g.visitStmt(s.Body)
+		g.popJumpTarget()
+		g.markLabel(stmt.Loc, test)
+		g.emitJumpIf(g.visitExpr(s.Test), loop)
+		g.markLabel(stmt.Loc, end)
+		return
+
+	case *js_ast.SFor:
+		if s.InitOrNil.Data != nil {
+			g.visitStmt(s.InitOrNil)
+		}
+		loop := g.newLabel()
+		update := g.newLabel()
+		end := g.newLabel()
+		g.markLabel(stmt.Loc, loop)
+		if s.TestOrNil.Data != nil {
+			g.emitJumpIf(js_ast.Not(g.visitExpr(s.TestOrNil)), end)
+		}
+		g.pushJumpTarget(generatorJumpTarget{breakLabel: end, continueLabel: update, isLoop: true})
+		g.visitStmt(s.Body)
+		g.popJumpTarget()
+		g.markLabel(stmt.Loc, update)
+		g.emitExpr(g.visitExpr(s.UpdateOrNil))
+		g.emitJump(stmt.Loc, loop)
+		g.markLabel(stmt.Loc, end)
+		return
+
+	case *js_ast.SForIn:
+		// The keys are collected up front since the loop can't be suspended:
+		//
+		//   _a = obj;
+		//   _b = [];
+		//   for (_c in _a) _b.push(_c);
+		//   _d = 0;
+		//   ...
+		//   if (!(_d < _b.length)) return [0, end];
+		//   _c = _b[_d];
+		//   if (!(_c in _a)) return [0, next];
+		//   x = _c;
+		//
+		if init, ok := s.Init.Data.(*js_ast.SExpr); ok && exprContainsYield(init.Value) {
+			break
+		}
+		loc := stmt.Loc
+		object := g.spill(g.visitExpr(s.Value))
+		keys := g.newTemp(loc)
+		key := g.newTemp(loc)
+		index := g.newTemp(loc)
+		g.emitExpr(js_ast.Assign(keys, js_ast.Expr{Loc: loc, Data: &js_ast.EArray{}}))
+		g.emit(js_ast.Stmt{Loc: loc, Data: &js_ast.SForIn{
+			Init:  js_ast.Stmt{Loc: loc, Data: &js_ast.SExpr{Value: key}},
+			Value: object,
+			Body: js_ast.Stmt{Loc: loc, Data: &js_ast.SExpr{Value: js_ast.Expr{Loc: loc, Data: &js_ast.ECall{
+				Target: js_ast.Expr{Loc: loc, Data: &js_ast.EDot{Target: keys, Name: "push", NameLoc: loc}},
+				Args:   []js_ast.Expr{key},
+			}}}},
+		}})
+		g.emitExpr(js_ast.Assign(index, js_ast.Expr{Loc: loc, Data: &js_ast.ENumber{Value: 0}}))
+		loop := g.newLabel()
+		next := g.newLabel()
+		end := g.newLabel()
+		g.markLabel(loc, loop)
+		g.emitJumpIf(js_ast.Not(js_ast.Expr{Loc: loc, Data: &js_ast.EBinary{
+			Op:    js_ast.BinOpLt,
+			Left:  index,
+			Right: js_ast.Expr{Loc: loc, Data: &js_ast.EDot{Target: keys, Name: "length", NameLoc: loc}},
+		}}), end)
+		g.emitExpr(js_ast.Assign(key, js_ast.Expr{Loc: loc, Data: &js_ast.EIndex{Target: keys, Index: index}}))
+		g.emitJumpIf(js_ast.Not(js_ast.Expr{Loc: loc, Data: &js_ast.EBinary{Op: js_ast.BinOpIn, Left: key, Right: object}}), next)
+		g.emitExpr(js_ast.Assign(g.hoistForInOrOfInit(s.Init).Data.(*js_ast.SExpr).Value, key))
+		g.pushJumpTarget(generatorJumpTarget{breakLabel: end, continueLabel: next, isLoop: true})
+		g.visitStmt(s.Body)
+		g.popJumpTarget()
+		g.markLabel(loc, next)
+		g.emitExpr(js_ast.Expr{Loc: loc, Data: &js_ast.EUnary{Op: js_ast.UnOpPostInc, Value: index}})
+		g.emitJump(loc, loop)
+		g.markLabel(loc, end)
+		return
+
+	case *js_ast.SSwitch:
+		test := g.spill(g.visitExpr(s.Test))
+		end := g.newLabel()
+		labels := make([]generatorLabel, len(s.Cases))
+		defaultLabel := end
+		for i, c := range s.Cases {
+			labels[i] = g.newLabel()
+			if c.ValueOrNil.Data == nil {
+				defaultLabel = labels[i]
+			} else {
+				g.emitJumpIf(js_ast.Expr{Loc: c.ValueOrNil.Loc, Data: &js_ast.EBinary{
+					Op:    js_ast.BinOpStrictEq,
+					Left:  test,
+					Right: g.visitExpr(c.ValueOrNil),
+				}}, labels[i])
+			}
+		}
+		g.emitJump(stmt.Loc, defaultLabel)
+		g.pushJumpTarget(generatorJumpTarget{breakLabel: end, isSwitch: true})
+		for i, c := range s.Cases {
+			g.markLabel(stmt.Loc, labels[i])
+			g.visitStmts(c.Body)
+		}
+		g.popJumpTarget()
+		g.markLabel(stmt.Loc, end)
+		return
+
+	case *js_ast.STry:
+		// "_ctx.trys.push([start, catch, finally, end]);"
+		start := g.newLabel()
+		end := g.newLabel()
+		var catchLabel, finallyLabel generatorLabel
+		items := []js_ast.Expr{g.labelExpr(stmt.Loc, start), {Loc: stmt.Loc, Data: js_ast.EMissingShared},
+			{Loc: stmt.Loc, Data: js_ast.EMissingShared}, g.labelExpr(stmt.Loc, end)}
+		if s.Catch != nil {
+			catchLabel = g.newLabel()
+			items[1] = g.labelExpr(s.Catch.Loc, catchLabel)
+		}
+		if s.Finally != nil {
+			finallyLabel = g.newLabel()
+			items[2] = g.labelExpr(s.Finally.Loc, finallyLabel)
+		}
+		g.emitExpr(js_ast.Expr{Loc: stmt.Loc, Data: &js_ast.ECall{
+			Target: js_ast.Expr{Loc: stmt.Loc, Data: &js_ast.EDot{
+				Target:  js_ast.Expr{Loc: stmt.Loc, Data: &js_ast.EDot{Target: g.ctxExpr(stmt.Loc), Name: "trys", NameLoc: stmt.Loc}},
+				Name:    "push",
+				NameLoc: stmt.Loc,
+			}},
+			Args: []js_ast.Expr{{Loc: stmt.Loc, Data: &js_ast.EArray{Items: items, IsSingleLine: true}}},
+		}})
+		g.markLabel(stmt.Loc, start)
+		g.visitStmts(s.Body)
+		g.emitJump(stmt.Loc, end)
+		if s.Catch != nil {
+			g.markLabel(s.Catch.Loc, catchLabel)
+			if s.Catch.BindingOrNil.Data != nil {
+				g.emitExpr(js_ast.Assign(js_ast.ConvertBindingToExpr(s.Catch.BindingOrNil, g.hoistRef), g.sentExpr(s.Catch.Loc)))
+			}
+			g.visitStmts(s.Catch.Body)
+			g.emitJump(s.Catch.Loc, end)
+		}
+		if s.Finally != nil {
+			g.markLabel(s.Finally.Loc, finallyLabel)
+			g.visitStmts(s.Finally.Stmts)
+			g.emitInstruction(s.Finally.Loc, generatorOpEndFinally)
+		}
+		g.markLabel(stmt.Loc, end)
+		return
+	}
+
+	// Everything else containing "yield" is not supported
+	g.p.log.Add(logger.Error, &g.p.tracker, js_lexer.RangeOfIdentifier(g.p.source, stmt.Loc),
+		"Transforming this statement containing \"yield\" is not supported yet when generator functions are not available")
+	g.emit(stmt)
+}
+
+// Statements without "yield" stay intact except for jumps that leave the
+// statement and "var" declarations. This doesn't traverse into nested
+// functions since neither can cross a function boundary.
+func (g *generatorLowering) rewriteStmts(stmts []js_ast.Stmt) []js_ast.Stmt {
+	result := make([]js_ast.Stmt, 0, len(stmts))
+	for _, stmt := range stmts {
+		result = g.rewriteAndAppendStmt(result, stmt)
+	}
+	return result
+}
+
+func (g *generatorLowering) rewriteSingleStmt(stmt js_ast.Stmt) js_ast.Stmt {
+	return stmtsToSingleStmt(stmt.Loc, g.rewriteAndAppendStmt(nil, stmt))
+}
+
+func (g *generatorLowering) rewriteAndAppendStmt(stmts []js_ast.Stmt, stmt js_ast.Stmt) []js_ast.Stmt {
+	switch s := stmt.Data.(type) {
+	case *js_ast.SLocal:
+		if s.Kind == js_ast.LocalVar {
+			if value := g.hoistDecls(s.Decls); value.Data != nil {
+				return append(stmts, js_ast.Stmt{Loc: stmt.Loc, Data: &js_ast.SExpr{Value: value}})
+			}
+			return stmts
+		}
+
+	case *js_ast.SBlock:
+		s.Stmts = g.rewriteStmts(s.Stmts)
+
+	case *js_ast.SIf:
+		s.Yes = g.rewriteSingleStmt(s.Yes)
+		if s.NoOrNil.Data != nil {
+			s.NoOrNil = g.rewriteSingleStmt(s.NoOrNil)
+		}
+
+	case *js_ast.SWith:
+		s.Body = g.rewriteSingleStmt(s.Body)
+
+	case *js_ast.SLabel:
+		if g.innerLabels == nil {
+			g.innerLabels = make(map[js_ast.Ref]bool)
+		}
+		g.innerLabels[s.Name.Ref] = true
+		s.Stmt = g.rewriteSingleStmt(s.Stmt)
+
+	case *js_ast.STry:
+		s.Body = g.rewriteStmts(s.Body)
+		if s.Catch != nil {
+			s.Catch.Body = g.rewriteStmts(s.Catch.Body)
+		}
+		if s.Finally != nil {
+			s.Finally.Stmts = g.rewriteStmts(s.Finally.Stmts)
+		}
+
+	case *js_ast.SSwitch:
+		g.innerSwitchDepth++
+		for i := range s.Cases {
+			s.Cases[i].Body = g.rewriteStmts(s.Cases[i].Body)
+		}
+		g.innerSwitchDepth--
+
+	case *js_ast.SFor:
+		if local, ok := s.InitOrNil.Data.(*js_ast.SLocal); ok && local.Kind == js_ast.LocalVar {
+			if value := g.hoistDecls(local.Decls); value.Data != nil {
+				s.InitOrNil = js_ast.Stmt{Loc: s.InitOrNil.Loc, Data: &js_ast.SExpr{Value: value}}
+			} else {
+				s.InitOrNil = js_ast.Stmt{}
+			}
+		}
+		g.innerLoopDepth++
+		s.Body = g.rewriteSingleStmt(s.Body)
+		g.innerLoopDepth--
+
+	case *js_ast.SForIn:
+		if local, ok := s.Init.Data.(*js_ast.SLocal); ok && local.Kind == js_ast.LocalVar {
+			s.Init = g.hoistForInOrOfInit(s.Init)
+		}
+		g.innerLoopDepth++
+		s.Body = g.rewriteSingleStmt(s.Body)
+		g.innerLoopDepth--
+
+	case *js_ast.SForOf:
+		if local, ok := s.Init.Data.(*js_ast.SLocal); ok && local.Kind == js_ast.LocalVar {
+			s.Init = g.hoistForInOrOfInit(s.Init)
+		}
+		g.innerLoopDepth++
+		s.Body = g.rewriteSingleStmt(s.Body)
+		g.innerLoopDepth--
+
+	case *js_ast.SWhile:
+		g.innerLoopDepth++
+		s.Body = g.rewriteSingleStmt(s.Body)
+		g.innerLoopDepth--
+
+	case *js_ast.SDoWhile:
+		g.innerLoopDepth++
+		s.Body = g.rewriteSingleStmt(s.Body)
+		g.innerLoopDepth--
+
+	case *js_ast.SReturn:
+		// "return x" => "return [1, x]"
+		if s.ValueOrNil.Data == nil {
+			return append(stmts, g.instruction(stmt.Loc, generatorOpReturn))
+		}
+		return append(stmts, g.instruction(stmt.Loc, generatorOpReturn, s.ValueOrNil))
+
+	case *js_ast.SBreak:
+		if (s.Label == nil && g.innerLoopDepth == 0 && g.innerSwitchDepth == 0) || (s.Label != nil && !g.innerLabels[s.Label.Ref]) {
+			// "break" => "return [0, label]"
+			return append(stmts, g.jumpStmt(stmt.Loc, g.findJumpTarget(s.Label, false)))
+		}
+
+	case *js_ast.SContinue:
+		if (s.Label == nil && g.innerLoopDepth == 0) || (s.Label != nil && !g.innerLabels[s.Label.Ref]) {
+			// "continue" => "return [0, label]"
+			return append(stmts, g.jumpStmt(stmt.Loc, g.findJumpTarget(s.Label, true)))
+		}
+	}
+
+	return append(stmts, stmt)
+}
+
+// This returns an expression with the same value as the original expression
+// that is evaluated after all statements that have been emitted so far.
+// Sub-expressions that are evaluated before a "yield" are stored in temporary
+// variables to preserve the order of evaluation.
+func (g *generatorLowering) visitExpr(expr js_ast.Expr) js_ast.Expr {
+	if !exprContainsYield(expr) {
+		return expr
+	}
+
+	switch e := expr.Data.(type) {
+	case *js_ast.EYield:
+		// "yield x" => "return [2, x, label]"
+		value := g.visitExpr(e.ValueOrNil)
+		if value.Data == nil {
+			value = js_ast.Expr{Loc: expr.Loc, Data: js_ast.EUndefinedShared}
+		}
+		op := generatorOpYield
+		if e.IsStar {
+			op = generatorOpYieldStar
+		}
+		resume := g.newLabel()
+		g.emitInstruction(expr.Loc, op, value, g.labelExpr(expr.Loc, resume))
+		g.markLabel(expr.Loc, resume)
+		return g.sentExpr(expr.Loc)
+
+	case *js_ast.EBinary:
+		switch e.Op {
+		case js_ast.BinOpComma:
+			g.emitExpr(g.visitExpr(e.Left))
+			return g.visitExpr(e.Right)
+
+		case js_ast.BinOpLogicalOr, js_ast.BinOpLogicalAnd, js_ast.BinOpNullishCoalescing:
+			// "a && yield b" => "_a = a; if (!_a) return [0, end]; ...; _a = _ctx.sent(); end:"
+			temp := g.newTemp(expr.Loc)
+			end := g.newLabel()
+			g.emitExpr(js_ast.Assign(temp, g.visitExpr(e.Left)))
+			switch e.Op {
+			case js_ast.BinOpLogicalOr:
+				g.emitJumpIf(temp, end)
+			case js_ast.BinOpLogicalAnd:
+				g.emitJumpIf(js_ast.Not(temp), end)
+			default:
+				g.emitJumpIf(js_ast.Expr{Loc: expr.Loc, Data: &js_ast.EBinary{
+					Op:    js_ast.BinOpLooseNe,
+					Left:  temp,
+					Right: js_ast.Expr{Loc: expr.Loc, Data: js_ast.ENullShared},
+				}}, end)
+			}
+			g.emitExpr(js_ast.Assign(temp, g.visitExpr(e.Right)))
+			g.markLabel(expr.Loc, end)
+			return temp
+		}
+
+		if e.Op.BinaryAssignTarget() == js_ast.AssignTargetNone {
+			values := g.visitExprs([]js_ast.Expr{e.Left, e.Right})
+			return js_ast.Expr{Loc: expr.Loc, Data: &js_ast.EBinary{Op: e.Op, Left: values[0], Right: values[1]}}
+		}
+
+		// The assignment target is evaluated before the value
+		rightHasYield := exprContainsYield(e.Right)
+		left, ok := g.visitAssignTarget(e.Left, rightHasYield)
+		if !ok {
+			break
+		}
+		if e.Op != js_ast.BinOpAssign && rightHasYield {
+			// "a += yield b" => "_a = a; ...; a = _a + _ctx.sent()"
+			op, ok := generatorCompoundAssignOps[e.Op]
+			if !ok {
+				break
+			}
+			old := g.spill(left)
+			right := g.visitExpr(e.Right)
+			return js_ast.Assign(left, js_ast.Expr{Loc: expr.Loc, Data: &js_ast.EBinary{Op: op, Left: old, Right: right}})
+		}
+		return js_ast.Expr{Loc: expr.Loc, Data: &js_ast.EBinary{Op: e.Op, Left: left, Right: g.visitExpr(e.Right)}}
+
+	case *js_ast.EUnary:
+		if e.Op.UnaryAssignTarget() != js_ast.AssignTargetNone || e.Op == js_ast.UnOpDelete {
+			value, ok := g.visitAssignTarget(e.Value, false)
+			if !ok {
+				break
+			}
+			return js_ast.Expr{Loc: expr.Loc, Data: &js_ast.EUnary{Op: e.Op, Value: value}}
+		}
+		return js_ast.Expr{Loc: expr.Loc, Data: &js_ast.EUnary{Op: e.Op, Value: g.visitExpr(e.Value)}}
+
+	case *js_ast.EIf:
+		// "a ? yield b : c" => "if (!a) return [0, no]; ...; _a = _ctx.sent(); return [0, end]; no: _a = c; end:"
+		temp := g.newTemp(expr.Loc)
+		no := g.newLabel()
+		end := g.newLabel()
+		g.emitJumpIf(js_ast.Not(g.visitExpr(e.Test)), no)
+		g.emitExpr(js_ast.Assign(temp, g.visitExpr(e.Yes)))
+		g.emitJump(expr.Loc, end)
+		g.markLabel(expr.Loc, no)
+		g.emitExpr(js_ast.Assign(temp, g.visitExpr(e.No)))
+		g.markLabel(expr.Loc, end)
+		return temp
+
+	case *js_ast.EDot:
+		return js_ast.Expr{Loc: expr.Loc, Data: &js_ast.EDot{Target: g.visitExpr(e.Target), Name: e.Name, NameLoc: e.NameLoc}}
+
+	case *js_ast.EIndex:
+		values := g.visitExprs([]js_ast.Expr{e.Target, e.Index})
+		return js_ast.Expr{Loc: expr.Loc, Data: &js_ast.EIndex{Target: values[0], Index: values[1]}}
+
+	case *js_ast.ECall:
+		if !exprsContainYield(e.Args) {
+			return js_ast.Expr{Loc: expr.Loc, Data: &js_ast.ECall{Target: g.visitExpr(e.Target), Args: e.Args}}
+		}
+
+		// The function and the value for "this" must be evaluated before the
+		// arguments: "a.b(yield c)" => "_a = a; _b = _a.b; ...; _b.call(_a, _ctx.sent())"
+		var thisValue js_ast.Expr
+		target := e.Target
+		switch t := target.Data.(type) {
+		case *js_ast.EDot:
+			thisValue = g.spill(g.visitExpr(t.Target))
+			target = g.spill(js_ast.Expr{Loc: target.Loc, Data: &js_ast.EDot{Target: thisValue, Name: t.Name, NameLoc: t.NameLoc}})
+
+		case *js_ast.EIndex:
+			values := g.visitExprs([]js_ast.Expr{t.Target, t.Index})
+			thisValue = g.spill(values[0])
+			target = g.spill(js_ast.Expr{Loc: target.Loc, Data: &js_ast.EIndex{Target: thisValue, Index: g.spill(values[1])}})
+
+		default:
+			target = g.spill(g.visitExpr(target))
+		}
+		args := g.visitExprs(e.Args)
+		if thisValue.Data != nil {
+			args = append([]js_ast.Expr{thisValue}, args...)
+			target = js_ast.Expr{Loc: target.Loc, Data: &js_ast.EDot{Target: target, Name: "call", NameLoc: target.Loc}}
+		}
+		return js_ast.Expr{Loc: expr.Loc, Data: &js_ast.ECall{Target: target, Args: args}}
+
+	case *js_ast.ENew:
+		values := g.visitExprs(append([]js_ast.Expr{e.Target}, e.Args...))
+		return js_ast.Expr{Loc: expr.Loc, Data: &js_ast.ENew{Target: values[0], Args: values[1:]}}
+
+	case *js_ast.EArray:
+		return js_ast.Expr{Loc: expr.Loc, Data: &js_ast.EArray{Items: g.visitExprs(e.Items), IsSingleLine: e.IsSingleLine}}
+
+	case *js_ast.ESpread:
+		return js_ast.Expr{Loc: expr.Loc, Data: &js_ast.ESpread{Value: g.visitExpr(e.Value)}}
+
+	case *js_ast.EObject:
+		// Computed keys and values are evaluated in order
+		var values []js_ast.Expr
+		for _, property := range e.Properties {
+			if property.IsComputed {
+				values = append(values, property.Key)
+			}
+			values = append(values, property.ValueOrNil)
+		}
+		values = g.visitExprs(values)
+		properties := make([]js_ast.Property, len(e.Properties))
+		for i, property := range e.Properties {
+			if property.IsComputed {
+				property.Key = values[0]
+				values = values[1:]
+			}
+			property.ValueOrNil = values[0]
+			values = values[1:]
+			properties[i] = property
+		}
+		return js_ast.Expr{Loc: expr.Loc, Data: &js_ast.EObject{Properties: properties, IsSingleLine: e.IsSingleLine}}
+
+	case *js_ast.ETemplate:
+		if e.TagOrNil.Data != nil {
+			break
+		}
+		values := make([]js_ast.Expr, len(e.Parts))
+		for i, part := range e.Parts {
+			values[i] = part.Value
+		}
+		values = g.visitExprs(values)
+		parts := make([]js_ast.TemplatePart, len(e.Parts))
+		for i, part := range e.Parts {
+			part.Value = values[i]
+			parts[i] = part
+		}
+		return js_ast.Expr{Loc: expr.Loc, Data: &js_ast.ETemplate{HeadLoc: e.HeadLoc, HeadCooked: e.HeadCooked, Parts: parts}}
+	}
+
+	g.p.log.Add(logger.Error, &g.p.tracker, logger.Range{Loc: expr.Loc}, "Transforming this expression containing \"yield\" "+
+		"is not supported yet when generator functions are not available")
+	return expr
+}
+
+// Everything before the last "yield" is stored in temporary variables since
+// evaluating it later could give a different result
+func (g *generatorLowering) visitExprs(exprs []js_ast.Expr) []js_ast.Expr {
+	last := -1
+	for i, expr := range exprs {
+		if exprContainsYield(expr) {
+			last = i
+		}
+	}
+	if last == -1 {
+		return exprs
+	}
+	result := make([]js_ast.Expr, len(exprs))
+	copy(result, exprs)
+	for i := 0; i <= last; i++ {
+		if result[i].Data == nil {
+			continue
+		}
+		value := g.visitExpr(result[i])
+		if i < last {
+			if spread, ok := value.Data.(*js_ast.ESpread); ok {
+				value = js_ast.Expr{Loc: value.Loc, Data: &js_ast.ESpread{Value: g.spill(spread.Value)}}
+			} else if _, ok := value.Data.(*js_ast.EMissing); !ok {
+				value = g.spill(value)
+			}
+		}
+		result[i] = value
+	}
+	return result
+}
+
+// The object and the property key of an assignment target are evaluated
+// before the assigned value
+func (g *generatorLowering) visitAssignTarget(target js_ast.Expr, mustSpill bool) (js_ast.Expr, bool) {
+	switch t := target.Data.(type) {
+	case *js_ast.EIdentifier:
+		return target, true
+
+	case *js_ast.EDot:
+		object := g.visitExpr(t.Target)
+		if mustSpill {
+			object = g.spill(object)
+		}
+		return js_ast.Expr{Loc: target.Loc, Data: &js_ast.EDot{Target: object, Name: t.Name, NameLoc: t.NameLoc}}, true
+
+	case *js_ast.EIndex:
+		values := g.visitExprs([]js_ast.Expr{t.Target, t.Index})
+		if mustSpill {
+			values[0] = g.spill(values[0])
+			values[1] = g.spill(values[1])
+		}
+		return js_ast.Expr{Loc: target.Loc, Data: &js_ast.EIndex{Target: values[0], Index: values[1]}}, true
+	}
+
+	// Destructuring patterns don't evaluate anything before the assigned value
+	return target, !exprContainsYield(target)
+}
+
+var generatorCompoundAssignOps = map[js_ast.OpCode]js_ast.OpCode{
+	js_ast.BinOpAddAssign:        js_ast.BinOpAdd,
+	js_ast.BinOpSubAssign:        js_ast.BinOpSub,
+	js_ast.BinOpMulAssign:        js_ast.BinOpMul,
+	js_ast.BinOpDivAssign:        js_ast.BinOpDiv,
+	js_ast.BinOpRemAssign:        js_ast.BinOpRem,
+	js_ast.BinOpPowAssign:        js_ast.BinOpPow,
+	js_ast.BinOpShlAssign:        js_ast.BinOpShl,
+	js_ast.BinOpShrAssign:        js_ast.BinOpShr,
+	js_ast.BinOpUShrAssign:       js_ast.BinOpUShr,
+	js_ast.BinOpBitwiseOrAssign:  js_ast.BinOpBitwiseOr,
+	js_ast.BinOpBitwiseAndAssign: js_ast.BinOpBitwiseAnd,
+	js_ast.BinOpBitwiseXorAssign: js_ast.BinOpBitwiseXor,
+}
+
+func stmtsContainYield(stmts []js_ast.Stmt) bool {
+	for _, stmt := range stmts {
+		if stmtContainsYield(stmt) {
+			return true
+		}
+	}
+	return false
+}
+
+// This doesn't traverse into nested functions since "yield" expressions in
+// nested functions belong to those functions
+func stmtContainsYield(stmt js_ast.Stmt) bool {
+	switch s := stmt.Data.(type) {
+	case *js_ast.SBlock:
+		return stmtsContainYield(s.Stmts)
+
+	case *js_ast.SExpr:
+		return exprContainsYield(s.Value)
+
+	case *js_ast.SLocal:
+		for _, decl := range s.Decls {
+			if bindingContainsYield(decl.Binding) || exprContainsYield(decl.ValueOrNil) {
+				return true
+			}
+		}
+
+	case *js_ast.SReturn:
+		return exprContainsYield(s.ValueOrNil)
+
+	case *js_ast.SThrow:
+		return exprContainsYield(s.Value)
+
+	case *js_ast.SLabel:
+		return stmtContainsYield(s.Stmt)
+
+	case *js_ast.SIf:
+		return exprContainsYield(s.Test) || stmtContainsYield(s.Yes) || (s.NoOrNil.Data != nil && stmtContainsYield(s.NoOrNil))
+
+	case *js_ast.SFor:
+		return (s.InitOrNil.Data != nil && stmtContainsYield(s.InitOrNil)) || exprContainsYield(s.TestOrNil) ||
+			exprContainsYield(s.UpdateOrNil) || stmtContainsYield(s.Body)
+
+	case *js_ast.SForIn:
+		return stmtContainsYield(s.Init) || exprContainsYield(s.Value) || stmtContainsYield(s.Body)
+
+	case *js_ast.SForOf:
+		return stmtContainsYield(s.Init) || exprContainsYield(s.Value) || stmtContainsYield(s.Body)
+
+	case *js_ast.SWhile:
+		return exprContainsYield(s.Test) || stmtContainsYield(s.Body)
+
+	case *js_ast.SDoWhile:
+		return stmtContainsYield(s.Body) || exprContainsYield(s.Test)
+
+	case *js_ast.SWith:
+		return exprContainsYield(s.Value) || stmtContainsYield(s.Body)
+
+	case *js_ast.STry:
+		return stmtsContainYield(s.Body) ||
+			(s.Catch != nil && ((s.Catch.BindingOrNil.Data != nil && bindingContainsYield(s.Catch.BindingOrNil)) || stmtsContainYield(s.Catch.Body))) ||
+			(s.Finally != nil && stmtsContainYield(s.Finally.Stmts))
+
+	case *js_ast.SSwitch:
+		if exprContainsYield(s.Test) {
+			return true
+		}
+		for _, c := range s.Cases {
+			if exprContainsYield(c.ValueOrNil) || stmtsContainYield(c.Body) {
+				return true
+			}
+		}
+
+	case *js_ast.SClass:
+		return classContainsYield(&s.Class)
+	}
+	return false
+}
+
+func bindingContainsYield(binding js_ast.Binding) bool {
+	switch b := binding.Data.(type) {
+	case *js_ast.BArray:
+		for _, item := range b.Items {
+			if bindingContainsYield(item.Binding) || exprContainsYield(item.DefaultValueOrNil) {
+				return true
+			}
+		}
+
+	case *js_ast.BObject:
+		for _, property := range b.Properties {
+			if exprContainsYield(property.Key) || bindingContainsYield(property.Value) || exprContainsYield(property.DefaultValueOrNil) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func classContainsYield(class *js_ast.Class) bool {
+	if exprContainsYield(class.ExtendsOrNil) {
+		return true
+	}
+	for _, property := range class.Properties {
+		if property.IsComputed && exprContainsYield(property.Key) {
+			return true
+		}
+	}
+	return false
+}
+
+func exprsContainYield(exprs []js_ast.Expr) bool {
+	for _, expr := range exprs {
+		if exprContainsYield(expr) {
+			return true
+		}
+	}
+	return false
+}
+
+func exprContainsYield(expr js_ast.Expr) bool {
+	switch e := expr.Data.(type) {
+	case *js_ast.EYield:
+		return true
+
+	case *js_ast.EAwait:
+		return exprContainsYield(e.Value)
+
+	case *js_ast.EUnary:
+		return exprContainsYield(e.Value)
+
+	case *js_ast.EBinary:
+		return exprContainsYield(e.Left) || exprContainsYield(e.Right)
+
+	case *js_ast.EIf:
+		return exprContainsYield(e.Test) || exprContainsYield(e.Yes) || exprContainsYield(e.No)
+
+	case *js_ast.EDot:
+		return exprContainsYield(e.Target)
+
+	case *js_ast.EIndex:
+		return exprContainsYield(e.Target) || exprContainsYield(e.Index)
+
+	case *js_ast.ECall:
+		return exprContainsYield(e.Target) || exprsContainYield(e.Args)
+
+	case *js_ast.ENew:
+		return exprContainsYield(e.Target) || exprsContainYield(e.Args)
+
+	case *js_ast.EArray:
+		return exprsContainYield(e.Items)
+
+	case *js_ast.ESpread:
+		return exprContainsYield(e.Value)
+
+	case *js_ast.EObject:
+		for _, property := range e.Properties {
+			if (property.IsComputed && exprContainsYield(property.Key)) ||
+				exprContainsYield(property.ValueOrNil) || exprContainsYield(property.InitializerOrNil) {
+				return true
+			}
+		}
+
+	case *js_ast.ETemplate:
+		if exprContainsYield(e.TagOrNil) {
+			return true
+		}
+		for _, part := range e.Parts {
+			if exprContainsYield(part.Value) {
+				return true
+			}
+		}
+
+	case *js_ast.EClass:
+		return classContainsYield(&e.Class)
+
+	case *js_ast.EInlinedEnum:
+		return exprContainsYield(e.Value)
+
+	case *js_ast.EImportCall:
+		return exprContainsYield(e.Expr) || exprContainsYield(e.OptionsOrNil)
+
+	case *js_ast.EJSXElement:
+		if exprContainsYield(e.TagOrNil) || exprsContainYield(e.Children) {
+			return true
+		}
+		for _, property := range e.Properties {
+			if exprContainsYield(property.Key) || exprContainsYield(property.ValueOrNil) {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -644,3 +644,606 @@ func TestLowerExportStarAs(t *testing.T) {
 	expectPrintedTarget(t, 2020, "export * as ns from 'path'", "export * as ns from \"path\";\n")
 	expectPrintedTarget(t, 2019, "export * as ns from 'path'", "import * as ns from \"path\";\nexport { ns };\n")
 }
+
+func TestLowerLetConst(t *testing.T) {
+	expectPrintedTarget(t, 2015, "let x = 1; const y = 2", "let x = 1;\nconst y = 2;\n")
+	expectPrintedTarget(t, 5, "let x = 1; const y = 2", "var x = 1;\nvar y = 2;\n")
+	expectPrintedTarget(t, 5, "while (a) { let u }", "while (a) {\n  var u = void 0;\n}\n")
+
+	// Reading or assigning a binding before it's initialized must throw
+	expectPrintedTarget(t, 5, "x; let x = 1", "__earlyAccess(\"x\");\nvar x = 1;\n")
+	expectPrintedTarget(t, 5, "function f() { x = 1; let x }", "function f() {\n  1, __earlyAccess(\"x\");\n  var x;\n}\n")
+	expectPrintedTarget(t, 5, "const c = 1; c = 2; c += 3; c++",
+		"var c = 1;\n2, __constAssign(\"c\");\n3, __constAssign(\"c\");\n__constAssign(\"c\");\n")
+
+	// Loops with captured bindings need a new binding for each iteration
+	expectPrintedTarget(t, 5, "for (let i = 0; i < 3; i++) fns.push(() => i)",
+		"var _loop;\n_loop = function(i) {\n  fns.push(function() {\n    return i;\n  });\n};\nfor (var i = 0; i < 3; i++) {\n  _loop(i);\n}\n")
+	expectPrintedTarget(t, 5, "while (a) { let u; fns.push(() => u) }",
+		"var _loop;\n_loop = function() {\n  var u = void 0;\n  fns.push(function() {\n    return u;\n  });\n};\nwhile (a) {\n  _loop();\n}\n")
+	expectPrintedTarget(t, 5, "for (let i = 0; i < 3; i++) { if (i) continue; fns.push(() => i); i++ }",
+		"var _i, _loop;\n_loop = function(i) {\n  if (i) {\n    _i = i;\n    return;\n  }\n  fns.push(function() {\n    return i;\n  });\n  i++;\n  _i = i;\n};\n"+
+			"for (var i = 0; i < 3; i++) {\n  _loop(i);\n  i = _i;\n}\n")
+	expectPrintedTarget(t, 5, "for (const x in y) { if (x) break; if (!x) return x; fns.push(() => x) }",
+		"var _loop, _ret;\n_loop = function(x) {\n  if (x)\n    return \"break\";\n  if (!x)\n    return {\n      v: x\n    };\n  fns.push(function() {\n    return x;\n  });\n};\n"+
+			"for (var x in y) {\n  _ret = _loop(x);\n  if (_ret === \"break\")\n    break;\n  if (typeof _ret === \"object\")\n    return _ret.v;\n}\n")
+	expectPrintedTarget(t, 5, "outer: for (let i in a) for (let j in b) { fns.push(() => i + j); if (j) continue outer; var v = j }",
+		"var _loop, _ret, _loop;\nouter: {\n  var v;\n  _loop = function(i) {\n    _loop = function(j) {\n      fns.push(function() {\n        return i + j;\n      });\n"+
+			"      if (j)\n        return \"continue|outer\";\n      v = j;\n    };\n    for (var j in b) {\n      _ret = _loop(j);\n      if (_ret === \"continue|outer\")\n        return;\n    }\n  };\n"+
+			"  for (var i in a) {\n    _loop(i);\n  }\n}\n")
+	expectPrintedTarget(t, 5, "function f() { for (let i = 0; i < 3; i++) fns.push(() => this[i]) }",
+		"function f() {\n  var _loop, _this = this;\n  _loop = function(i) {\n    fns.push(function() {\n      return _this[i];\n    });\n  };\n  for (var i = 0; i < 3; i++) {\n    _loop(i);\n  }\n}\n")
+	expectParseErrorTarget(t, 5, "function f() { for (let i in a) fns.push(function() { return i }), arguments }",
+		"<stdin>: ERROR: Transforming a loop that uses \"arguments\" to the configured target environment is not supported yet "+
+			"when a closure in the loop captures one of its \"let\" or \"const\" bindings\n")
+
+	// Closures in the loop initializer capture a copy that isn't shared with any iteration
+	expectPrintedTarget(t, 5, "for (let i = 0, f = () => i; i < 2; i++) r.push(f())",
+		"for (var _a = function() {\n  var i = 0, f = function() {\n    return i;\n  };\n  return [i, f];\n}(), i = _a[0], f = _a[1]; i < 2; i++)\n  r.push(f());\n")
+	expectPrintedTarget(t, 5, "for (let i = 0, f = () => i; i < 2; i++) r.push(f(), () => i)",
+		"var _loop;\n_loop = function(i, f) {\n  r.push(f(), function() {\n    return i;\n  });\n};\n"+
+			"for (var _a = function() {\n  var i = 0, f = function() {\n    return i;\n  };\n  return [i, f];\n}(), i = _a[0], f = _a[1]; i < 2; i++) {\n  _loop(i, f);\n}\n")
+	expectPrintedTarget(t, 5, "function f() { for (let i = 0, g = () => this[i]; i < 2; i++) ; }",
+		"function f() {\n  var _this = this;\n  for (var _a = function() {\n    var i = 0, g = function() {\n      return _this[i];\n    };\n    return [i, g];\n  }(), i = _a[0], g = _a[1]; i < 2; i++)\n    ;\n}\n")
+	expectParseErrorTarget(t, 5, "function f() { for (let i = 0, g = () => i; i < arguments.length; i++) ; }",
+		"<stdin>: ERROR: Transforming a loop that uses \"arguments\" to the configured target environment is not supported yet "+
+			"when a closure in the loop captures one of its \"let\" or \"const\" bindings\n")
+
+	// Closures in the loop test and update expressions can't be given a copy for each iteration
+	expectParseErrorTarget(t, 5, "for (let i = 0; i < 2; i++, fns.push(() => i)) ;",
+		"<stdin>: WARNING: Closures in the test or update expression of a loop will share \"i\" between iterations "+
+			"because \"let\" and \"const\" are not available in the configured target environment\n")
+	expectParseErrorTarget(t, 5, "for (let i = 0; fns.push(() => i) < 2; i++) ;",
+		"<stdin>: WARNING: Closures in the test or update expression of a loop will share \"i\" between iterations "+
+			"because \"let\" and \"const\" are not available in the configured target environment\n")
+
+	// Accesses before initialization that can only be detected at run-time aren't checked
+	expectParseErrorTarget(t, 5, "f(); let x = 1; function f() { return x }",
+		"<stdin>: WARNING: Using \"x\" here may happen before it's initialized, which won't throw an error "+
+			"because \"let\" is not available in the configured target environment\n")
+	expectParseErrorTarget(t, 5, "function f() { return x } const x = 1",
+		"<stdin>: WARNING: Using \"x\" here may happen before it's initialized, which won't throw an error "+
+			"because \"const\" is not available in the configured target environment\n")
+	expectParseErrorTarget(t, 5, "switch (a) { case 0: let x = 1; break; case 1: x }",
+		"<stdin>: WARNING: Using \"x\" here may happen before it's initialized, which won't throw an error "+
+			"because \"let\" is not available in the configured target environment\n")
+	expectParseErrorTarget(t, 5, "let x = 1; function f() { return x } f()", "")
+	expectParseErrorTarget(t, 5, "const f = () => f()", "")
+	expectParseErrorTarget(t, 5, "switch (a) { case 0: let x = 1; x }", "")
+}
+
+func TestLowerDestructuring(t *testing.T) {
+	expectPrintedTarget(t, 2015, "var {a, b: [c]} = d", "var { a, b: [c] } = d;\n")
+
+	// Declarations
+	expectPrintedTarget(t, 5, "var {a, b} = c", "var a = c.a, b = c.b;\n")
+	expectPrintedTarget(t, 5, "var {a, b} = c()", "var _a = c(), a = _a.a, b = _a.b;\n")
+	expectPrintedTarget(t, 5, "var {a, b: c} = c", "var _a = c, a = _a.a, c = _a.b;\n")
+	expectPrintedTarget(t, 5, "var {'a-b': a, 0: b, [c]: d} = e", "var a = e[\"a-b\"], b = e[0], d = e[c];\n")
+	expectPrintedTarget(t, 5, "var {a = 1, b: {c} = 2} = d",
+		"var _a = d.a, a = _a === void 0 ? 1 : _a, _b = d.b, c = (_b === void 0 ? 2 : _b).c;\n")
+	expectPrintedTarget(t, 5, "var {a, [b()]: c, ...d} = e",
+		"var _a;\nvar a = e.a, c = e[_a = b()], d = __objRest(e, [\"a\", __restKey(_a)]);\n")
+	expectPrintedTarget(t, 5, "var [a] = b", "var a = __readArray(b, 1)[0];\n")
+	expectPrintedTarget(t, 5, "var [a, , b = 1] = c",
+		"var _a = __readArray(c, 3), a = _a[0], _b = _a[2], b = _b === void 0 ? 1 : _b;\n")
+	expectPrintedTarget(t, 5, "var [a, ...b] = c", "var _a = __readArray(c), a = _a[0], b = _a.slice(1);\n")
+	expectPrintedTarget(t, 5, "var [...[a, b]] = c", "var _a = __readArray(__readArray(c), 2), a = _a[0], b = _a[1];\n")
+	expectPrintedTargetAssumeArrays(t, 5, "var [a] = b", "var a = b[0];\n")
+	expectPrintedTargetAssumeArrays(t, 5, "var [a, , b = 1] = c", "var a = c[0], _a = c[2], b = _a === void 0 ? 1 : _a;\n")
+	expectPrintedTargetAssumeArrays(t, 5, "var [a, ...b] = c", "var a = c[0], b = c.slice(1);\n")
+	expectPrintedTargetAssumeArrays(t, 5, "var [a, b] = [b, a]", "var _a = [b, a], a = _a[0], b = _a[1];\n")
+
+	// Empty patterns must still throw for null and undefined
+	expectPrintedTarget(t, 5, "const {} = null", "var _a = __requireObjectCoercible(null);\n")
+	expectPrintedTarget(t, 5, "var {a: {}} = b", "var _a = __requireObjectCoercible(b.a);\n")
+	expectPrintedTarget(t, 5, "function f({}) {}", "function f(_a) {\n  var _b = __requireObjectCoercible(_a);\n}\n")
+	expectPrintedTargetAssumeArrays(t, 5, "var [] = a", "var _a = __requireObjectCoercible(a);\n")
+
+	// Assignments
+	expectPrintedTarget(t, 5, "({a: x.y, b: x[z]} = c)", "x.y = c.a, x[z] = c.b;\n")
+	expectPrintedTarget(t, 5, "[a, b] = [b, a]", "var _a;\n_a = __readArray([b, a], 2), a = _a[0], b = _a[1];\n")
+	expectPrintedTargetAssumeArrays(t, 5, "[a, b] = [b, a]", "var _a;\n_a = [b, a], a = _a[0], b = _a[1];\n")
+	expectPrintedTarget(t, 5, "x = [a] = b", "var _a;\nx = (_a = b, a = __readArray(_a, 1)[0], _a);\n")
+	expectPrintedTarget(t, 5, "x = {a, ...b} = c", "var _a;\nx = (_a = c, a = _a.a, b = __objRest(_a, [\"a\"]), _a);\n")
+
+	// Function arguments, loop headers, and catch bindings
+	expectPrintedTarget(t, 5, "function f({a}, [b]) {}",
+		"function f(_a, _b) {\n  var a = _a.a;\n  var b = __readArray(_b, 1)[0];\n}\n")
+	expectPrintedTarget(t, 5, "for (var [a, b] in c) ;",
+		"for (var _a in c) {\n  var _b = __readArray(_a, 2), a = _b[0], b = _b[1];\n  ;\n}\n")
+	expectPrintedTarget(t, 5, "for ({a} in b) ;", "var _a;\nfor (_a in b) {\n  a = _a.a;\n  ;\n}\n")
+	expectPrintedTarget(t, 5, "for (let [a] in b) fns.push(() => a)",
+		"var _loop;\n_loop = function(_a) {\n  var a = __readArray(_a, 1)[0];\n  fns.push(function() {\n    return a;\n  });\n};\nfor (var _a in b) {\n  _loop(_a);\n}\n")
+	expectPrintedTarget(t, 5, "try {} catch ({message}) {}", "try {\n} catch (_a) {\n  var message = _a.message;\n}\n")
+}
+
+func TestLowerGenerator(t *testing.T) {
+	expectPrintedTarget(t, 5, "function* f() { yield 1; yield 2 }", `function f() {
+  return __gen(this, function(_ctx) {
+    switch (_ctx.label) {
+      case 0:
+        return [2, 1, 1];
+      case 1:
+        return [2, 2, 2];
+      case 2:
+        return [1];
+    }
+  });
+}
+`)
+	expectPrintedTarget(t, 5, "function* f() { while (a) { if (b) break; yield c } }", `function f() {
+  return __gen(this, function(_ctx) {
+    switch (_ctx.label) {
+      case 0:
+        if (!a)
+          return [0, 2];
+        if (b)
+          return [0, 2];
+        return [2, c, 1];
+      case 1:
+        return [0, 0];
+      case 2:
+        return [1];
+    }
+  });
+}
+`)
+	expectPrintedTarget(t, 5, "function* f() { try { yield a } catch (e) { b(e) } finally { c() } }", `function f() {
+  var e;
+  return __gen(this, function(_ctx) {
+    switch (_ctx.label) {
+      case 0:
+        _ctx.trys.push([1, 3, 4, 5]);
+        _ctx.label = 1;
+      case 1:
+        return [2, a, 2];
+      case 2:
+        return [0, 5];
+      case 3:
+        e = _ctx.sent();
+        b(e);
+        return [0, 5];
+      case 4:
+        c();
+        return [4];
+      case 5:
+        return [1];
+    }
+  });
+}
+`)
+	expectPrintedTarget(t, 5, "function* f() { yield* a }", `function f() {
+  return __gen(this, function(_ctx) {
+    switch (_ctx.label) {
+      case 0:
+        return [3, a, 1];
+      case 1:
+        return [1];
+    }
+  });
+}
+`)
+
+	// Values computed before a "yield" must be saved, and "arguments" must
+	// refer to the arguments of the original function
+	expectPrintedTarget(t, 5, "function* f() { return arguments[0] + (yield) }", `function f() {
+  var _arguments = arguments;
+  var _a;
+  return __gen(this, function(_ctx) {
+    switch (_ctx.label) {
+      case 0:
+        _a = _arguments[0];
+        return [2, void 0, 1];
+      case 1:
+        return [1, _a + _ctx.sent()];
+    }
+  });
+}
+`)
+
+	// Async functions are first lowered to generators and then to state machines
+	expectPrintedTarget(t, 5, "async function f() { await a; return b }", `function f() {
+  return __async(this, null, function() {
+    return __gen(this, function(_ctx) {
+      switch (_ctx.label) {
+        case 0:
+          return [2, a, 1];
+        case 1:
+          return [1, b];
+      }
+    });
+  });
+}
+`)
+}
+
+func TestLowerAsyncGenerator(t *testing.T) {
+	expectPrintedTarget(t, 2017, "async function* f() { await x; yield y; yield* z; return w }", `function f() {
+  return __asyncGen(this, null, function* () {
+    yield new __genAwait(x);
+    yield y;
+    yield* __yieldStar(z);
+    return yield new __genAwait(w);
+  });
+}
+`)
+	expectPrintedTarget(t, 2017, "({ async *f() { return } })", `({ f() {
+  return __asyncGen(this, null, function* () {
+    return;
+  });
+} });
+`)
+	expectPrintedTarget(t, 2018, "async function* f() { await x }", "async function* f() {\n  await x;\n}\n")
+
+	// Async generators are lowered through generators when those are unsupported too
+	expectPrintedTarget(t, 5, "async function* f() { yield await x }", `function f() {
+  return __asyncGen(this, null, function() {
+    return __gen(this, function(_ctx) {
+      switch (_ctx.label) {
+        case 0:
+          return [2, new __genAwait(x), 1];
+        case 1:
+          return [2, _ctx.sent(), 2];
+        case 2:
+          return [1];
+      }
+    });
+  });
+}
+`)
+}
+
+func TestLowerForAwait(t *testing.T) {
+	expectPrintedTarget(t, 2017, "async function f() { for await (x of y) z(x) }", `async function f() {
+  try {
+    for (var iter = __forAwait(y), more, temp, error; more = !(temp = await iter.next()).done; more = false) {
+      x = temp.value;
+      z(x);
+    }
+  } catch (temp) {
+    error = [temp];
+  } finally {
+    try {
+      more && (temp = iter.return) && await temp.call(iter);
+    } finally {
+      if (error)
+        throw error[0];
+    }
+  }
+}
+`)
+
+	// The label must be moved onto the loop so that "continue" still works
+	expectPrintedTarget(t, 2017, "async function f() { label: for await (let x of y) continue label }", `async function f() {
+  try {
+    label:
+      for (var iter = __forAwait(y), more, temp, error; more = !(temp = await iter.next()).done; more = false) {
+        let x = temp.value;
+        continue label;
+      }
+  } catch (temp) {
+    error = [temp];
+  } finally {
+    try {
+      more && (temp = iter.return) && await temp.call(iter);
+    } finally {
+      if (error)
+        throw error[0];
+    }
+  }
+}
+`)
+
+	// Inside a lowered async generator, "await" becomes "yield"
+	expectPrintedTarget(t, 2017, "async function* f() { for await (const x of y) yield x }", `function f() {
+  return __asyncGen(this, null, function* () {
+    try {
+      for (var iter = __forAwait(y), more, temp, error; more = !(temp = yield new __genAwait(iter.next())).done; more = false) {
+        const x = temp.value;
+        yield x;
+      }
+    } catch (temp) {
+      error = [temp];
+    } finally {
+      try {
+        more && (temp = iter.return) && (yield new __genAwait(temp.call(iter)));
+      } finally {
+        if (error)
+          throw error[0];
+      }
+    }
+  });
+}
+`)
+}
+
+func TestLowerClassES5(t *testing.T) {
+	expectPrintedTarget(t, 5, "class Foo {}", `var Foo = /* @__PURE__ */ function() {
+  function Foo() {
+    __classCallCheck(this, Foo);
+  }
+  return Foo;
+}();
+`)
+	expectPrintedTarget(t, 5, "class Foo { constructor(x) { this.x = x } foo() { return this.x } static bar() {} }", `var Foo = /* @__PURE__ */ function() {
+  function Foo(x) {
+    __classCallCheck(this, Foo);
+    this.x = x;
+  }
+  __defMethod(Foo.prototype, "foo", function() {
+    return this.x;
+  });
+  __defMethod(Foo, "bar", function() {
+  });
+  return Foo;
+}();
+`)
+	expectPrintedTarget(t, 5, "class Foo { get x() { return 1 } set x(v) {} static get y() { return 2 } ['z']() {} }", `var Foo = /* @__PURE__ */ function() {
+  function Foo() {
+    __classCallCheck(this, Foo);
+  }
+  __defProp(Foo.prototype, "x", {
+    get: function() {
+      return 1;
+    },
+    set: function(v) {
+    },
+    configurable: true
+  });
+  __defProp(Foo, "y", {
+    get: function() {
+      return 2;
+    },
+    configurable: true
+  });
+  __defMethod(Foo.prototype, "z", function() {
+  });
+  return Foo;
+}();
+`)
+	expectPrintedTarget(t, 5, "x = class {}", `x = /* @__PURE__ */ function() {
+  function _class() {
+    __classCallCheck(this, _class);
+  }
+  return _class;
+}();
+`)
+
+	// Derived classes forward "super()" calls to a runtime helper
+	expectPrintedTarget(t, 5, "class Foo extends Bar {}", `var Foo = function(_super) {
+  __inherit(Foo, _super);
+  function Foo() {
+    __classCallCheck(this, Foo);
+    return __callSuper(this, _super, arguments);
+  }
+  return Foo;
+}(Bar);
+`)
+	expectPrintedTarget(t, 5, "class Foo extends Bar { constructor() { super(1); this.x = 2 } }", `var Foo = function(_super) {
+  __inherit(Foo, _super);
+  function Foo() {
+    __classCallCheck(this, Foo);
+    var _this;
+    _this = __callSuper(this, _super, [1]);
+    _this.x = 2;
+    return _this;
+  }
+  return Foo;
+}(Bar);
+`)
+	expectPrintedTarget(t, 5, "class Foo extends Bar { constructor() { if (a) return; super() } }", `var Foo = function(_super) {
+  __inherit(Foo, _super);
+  function Foo() {
+    __classCallCheck(this, Foo);
+    var _this;
+    if (a)
+      return _this;
+    _this = __callSuper(this, _super, []);
+    return _this;
+  }
+  return Foo;
+}(Bar);
+`)
+	expectPrintedTarget(t, 5, "class Foo extends Bar { x = 1; constructor() { super() } }", `var Foo = function(_super) {
+  __inherit(Foo, _super);
+  function Foo() {
+    __classCallCheck(this, Foo);
+    var _this;
+    _this = __callSuper(this, _super, []);
+    __publicField(_this, "x", 1);
+    return _this;
+  }
+  return Foo;
+}(Bar);
+`)
+
+	// Instance fields must stay after the "super()" call when minifying
+	expectPrintedMangleTarget(t, 5, "class Foo extends Bar { x = 1; constructor() { super(); this.y = 2 } }", `var Foo = function(_super) {
+  __inherit(Foo, _super);
+  function Foo() {
+    __classCallCheck(this, Foo);
+    var _this;
+    _this = __callSuper(this, _super, []);
+    __publicField(_this, "x", 1);
+    _this.y = 2;
+    return _this;
+  }
+  return Foo;
+}(Bar);
+`)
+
+	// Accesses to "super" properties start the lookup from the home object
+	expectPrintedTarget(t, 5, "class Foo extends Bar { foo() { return super.foo() } static bar() { super.x = 1 } }", `var Foo = function(_super) {
+  __inherit(Foo, _super);
+  function Foo() {
+    __classCallCheck(this, Foo);
+    return __callSuper(this, _super, arguments);
+  }
+  __defMethod(Foo.prototype, "foo", function() {
+    return __superPropGet(Foo.prototype, this, "foo").call(this);
+  });
+  __defMethod(Foo, "bar", function() {
+    __superPropSet(Foo, this, "x", 1);
+  });
+  return Foo;
+}(Bar);
+`)
+	expectPrintedTarget(t, 5, "class Foo extends Bar { foo() { return () => super.foo() } }", `var Foo = function(_super) {
+  __inherit(Foo, _super);
+  function Foo() {
+    __classCallCheck(this, Foo);
+    return __callSuper(this, _super, arguments);
+  }
+  __defMethod(Foo.prototype, "foo", function() {
+    var _this = this;
+    return function() {
+      return __superPropGet(Foo.prototype, _this, "foo").call(_this);
+    };
+  });
+  return Foo;
+}(Bar);
+`)
+}
+
+func TestLowerForOf(t *testing.T) {
+	expectPrintedTarget(t, 5, "for (const x of y) z(x)", `try {
+  for (var iter = __getIterator(y), more, temp, error; more = !(temp = iter.next()).done; more = false) {
+    var x = temp.value;
+    z(x);
+  }
+} catch (temp) {
+  error = [temp];
+} finally {
+  try {
+    more && (temp = iter.return) && temp.call(iter);
+  } finally {
+    if (error)
+      throw error[0];
+  }
+}
+`)
+	expectPrintedTarget(t, 2015, "for (x.y of z) ;", "for (x.y of z)\n  ;\n")
+
+	// Iterables can be assumed to be arrays for a simpler indexed loop
+	expectPrintedTargetAssumeArrays(t, 5, "for (const x of y) z(x)", `for (var i = 0, array = y; i < array.length; i++) {
+  var x = array[i];
+  z(x);
+}
+`)
+	expectPrintedTargetAssumeArrays(t, 5, "label: for (x of y) continue label", `label:
+  for (var i = 0, array = y; i < array.length; i++) {
+    x = array[i];
+    continue label;
+  }
+`)
+	expectPrintedTargetAssumeArrays(t, 2015, "for (const x of y) ;", "for (const x of y)\n  ;\n")
+}
+
+func TestLowerAutoAccessors(t *testing.T) {
+	expectPrintedTarget(t, 2021, "class Foo { accessor x = 1 }", `var _x;
+class Foo {
+  constructor() {
+    __privateAdd(this, _x, 1);
+  }
+  get x() {
+    return __privateGet(this, _x);
+  }
+  set x(_) {
+    __privateSet(this, _x, _);
+  }
+}
+_x = new WeakMap();
+`)
+	expectPrintedTarget(t, 2021, "class Foo { accessor [x] = 1 }", `var _a, _accessor;
+class Foo {
+  constructor() {
+    __privateAdd(this, _accessor, 1);
+  }
+  get [_a = x]() {
+    return __privateGet(this, _accessor);
+  }
+  set [_a](_) {
+    __privateSet(this, _accessor, _);
+  }
+}
+_accessor = new WeakMap();
+`)
+	expectPrinted(t, "class Foo { accessor x = 1 }", "class Foo {\n  accessor x = 1;\n}\n")
+}
+
+func TestLowerDecorators(t *testing.T) {
+	expectPrintedTarget(t, 2021, "@dec class Foo {}", `var _init, _Foo_dec;
+_Foo_dec = [
+  dec
+];
+let Foo = class {
+};
+_init = __decoratorStart(Foo);
+Foo = __decorateElement(_init, 0, "Foo", _Foo_dec, Foo);
+__decoratorMetadata(_init, Foo);
+__runInitializers(_init, 1, Foo);
+`)
+	expectPrintedTarget(t, 2021, "class Foo { @dec m() {} }", `var _init, _m_dec;
+_m_dec = [
+  dec
+];
+class Foo {
+  constructor() {
+    __runInitializers(_init, 5, this);
+  }
+  m() {
+  }
+}
+_init = __decoratorStart(Foo);
+__decorateElement(_init, 1, "m", _m_dec, Foo);
+__decoratorMetadata(_init, Foo);
+`)
+	expectPrintedTarget(t, 2021, "class Foo { @dec x = 1 }", `var _init, _x_dec;
+_x_dec = [
+  dec
+];
+class Foo {
+  constructor() {
+    __publicField(this, "x", __runInitializers(_init, 6, this, 1));
+    __runInitializers(_init, 7, this);
+  }
+}
+_init = __decoratorStart(Foo);
+__decorateElement(_init, 197, "x", _x_dec, Foo);
+__decoratorMetadata(_init, Foo);
+`)
+	expectPrintedTarget(t, 2021, "class Foo { @dec #m() {} }", `var _init, _m_dec, _m, m_fn;
+_m_dec = [
+  dec
+];
+class Foo {
+  constructor() {
+    __privateAdd(this, _m);
+    __runInitializers(_init, 5, this);
+  }
+}
+_m = new WeakSet();
+m_fn = function() {
+};
+_init = __decoratorStart(Foo);
+m_fn = __decorateElement(_init, 17, "#m", _m_dec, _m, m_fn);
+__decoratorMetadata(_init, Foo);
+`)
+	expectPrintedTarget(t, 2021, "(@dec class {})", `var _init, _dec, _a;
+_dec = [
+  dec
+], _a = class {
+}, _init = __decoratorStart(_a), _a = __decorateElement(_init, 0, "", _dec, _a), __decoratorMetadata(_init, _a), __runInitializers(_init, 1, _a), _a;
+`)
+}
+
+func TestLowerRegExpDotAllFlag(t *testing.T) {
+	// The flag must be removed even if there's no "." to replace
+	expectPrintedTarget(t, 2017, "/\\./s", "/\\./;\n")
+	expectPrintedTarget(t, 2017, "/[.]/s", "/[.]/;\n")
+	expectPrintedTarget(t, 2017, "/[^.]/s", "/[^.]/;\n")
+	expectPrintedTarget(t, 2015, "/[^.]./gs", "/[^.][^]/g;\n")
+	expectPrintedTarget(t, 5, "/a/s", "/a/;\n")
+	expectPrintedTarget(t, 2018, "/[^.]/s", "/[^.]/s;\n")
+}
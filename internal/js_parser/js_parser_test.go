@@ -94,6 +94,16 @@ func expectPrintedTarget(t *testing.T, esVersion int, contents string, expected
 	})
 }
 
+func expectPrintedTargetAssumeArrays(t *testing.T, esVersion int, contents string, expected string) {
+	t.Helper()
+	expectPrintedCommon(t, contents, expected, config.Options{
+		UnsupportedJSFeatures: compat.UnsupportedJSFeatures(map[compat.Engine][]int{
+			compat.ES: {esVersion},
+		}),
+		AssumeIterablesAreArrays: true,
+	})
+}
+
 func expectPrintedMangleTarget(t *testing.T, esVersion int, contents string, expected string) {
 	t.Helper()
 	expectPrintedCommon(t, contents, expected, config.Options{
@@ -581,6 +591,8 @@ func TestRegExp(t *testing.T) {
 	expectPrinted(t, "/x/s", "/x/s;\n")
 	expectPrinted(t, "/x/u", "/x/u;\n")
 	expectPrinted(t, "/x/y", "/x/y;\n")
+	expectPrinted(t, "/x/d", "/x/d;\n")
+	expectPrinted(t, "/x/v", "/x/v;\n")
 
 	expectParseError(t, "/x/msuygig",
 		`<stdin>: ERROR: Duplicate flag "g" in regular expression
@@ -588,6 +600,108 @@ func TestRegExp(t *testing.T) {
 `)
 }
 
+func TestLowerRegExp(t *testing.T) {
+	polyfill := " This regular expression literal has been converted to a \"new RegExp()\" constructor " +
+		"to avoid generating code with a syntax error. However, you will need to include a polyfill for \"RegExp\" " +
+		"for your code to have the correct behavior at run-time.\n"
+	namedGroups := "<stdin>: WARNING: Named capture groups in regular expressions are not available in the configured target environment. " +
+		"They have been converted to numbered capture groups, so the \"groups\" property of the match result will be missing " +
+		"and \"$<name>\" in replacement strings will no longer refer to the group.\n"
+
+	// The "s" flag
+	expectPrintedTarget(t, 2018, "/a.b/s", "/a.b/s;\n")
+	expectPrintedTarget(t, 2017, "/a.b/s", "/a[^]b/;\n")
+	expectPrintedTarget(t, 2017, "/a.b/gsi", "/a[^]b/gi;\n")
+	expectPrintedTarget(t, 2017, "/\\.[.]\\[.]/s", "/\\.[.]\\[[^]]/;\n")
+	expectPrintedTarget(t, 2017, "/a.b/", "/a.b/;\n")
+
+	// Named capture groups
+	expectPrintedTarget(t, 2018, "/(?<x>a)\\k<x>/", "/(?<x>a)\\k<x>/;\n")
+	expectPrintedTarget(t, 2017, "/(?<x>a)(b)(?<y>c)\\k<y>\\k<x>/", "/(a)(b)(c)(?:\\3)(?:\\1)/;\n")
+	expectPrintedTarget(t, 2017, "/\\k<x>(?<x>a)\\k<x>0/", "/(?:\\1)(a)(?:\\1)0/;\n")
+	expectPrintedTarget(t, 2017, "/[(](?:a)(?<x>b)\\(\\k<x>/", "/[(](?:a)(b)\\((?:\\1)/;\n")
+	expectPrintedTarget(t, 2017, "/\\k<x>/", "/\\k<x>/;\n")
+	expectParseErrorTarget(t, 2017, "/(?<x>a)/", namedGroups)
+	expectParseErrorTarget(t, 2017, "/(?<x>.)\\k<x>/s", namedGroups)
+
+	// Features that must be constructed at run-time
+	expectPrintedTarget(t, 2018, "/(?<=a)b(?<!c)/", "/(?<=a)b(?<!c)/;\n")
+	expectPrintedTarget(t, 2017, "/(?<=a)b(?<!c)/", "__regExp(\"(?<=a)b(?<!c)\");\n")
+	expectPrintedTarget(t, 2017, "/\\p{L}/u", "__regExp(\"\\\\p{L}\", \"u\");\n")
+	expectPrintedTarget(t, 2017, "/\\p{L}/", "/\\p{L}/;\n")
+	expectPrintedTarget(t, 2017, "/[\\p{L}]/u", "__regExp(\"[\\\\p{L}]\", \"u\");\n")
+	expectPrintedTarget(t, 2021, "/a/dg", "__regExp(\"a\", \"dg\");\n")
+	expectPrintedTarget(t, 2022, "/a/dg", "/a/dg;\n")
+	expectPrintedTarget(t, 2022, "/[[a-z]--[aeiou]]/v", "__regExp(\"[[a-z]--[aeiou]]\", \"v\");\n")
+	expectPrintedTarget(t, 5, "/a/y", "__regExp(\"a\", \"y\");\n")
+	expectPrintedTarget(t, 5, "/\\//u", "__regExp(\"\\\\/\", \"u\");\n")
+	expectPrintedTarget(t, 2017, "/.(?<=(?<x>a))\\k<x>/su", "__regExp(\"[^](?<=(a))(?:\\\\1)\", \"u\");\n")
+
+	expectParseErrorTarget(t, 2017, "/(?<=a)/",
+		"<stdin>: WARNING: Lookbehind assertions in regular expressions are not available in the configured target environment."+polyfill)
+	expectParseErrorTarget(t, 2017, "/\\p{L}/u",
+		"<stdin>: WARNING: Unicode property escapes in regular expressions are not available in the configured target environment."+polyfill)
+	expectParseErrorTarget(t, 2021, "/a/d",
+		"<stdin>: WARNING: The regular expression flag \"d\" is not available in the configured target environment."+polyfill)
+	expectParseErrorTarget(t, 2022, "/a/v",
+		"<stdin>: WARNING: The regular expression flag \"v\" is not available in the configured target environment."+polyfill)
+	expectParseErrorTarget(t, 5, "/a/uy",
+		"<stdin>: WARNING: The regular expression flag \"u\" is not available in the configured target environment."+polyfill+
+			"<stdin>: WARNING: The regular expression flag \"y\" is not available in the configured target environment."+polyfill)
+}
+
+func TestUsing(t *testing.T) {
+	expectPrinted(t, "using x = y", "using x = y;\n")
+	expectPrinted(t, "using x = y, z = w", "using x = y, z = w;\n")
+	expectPrinted(t, "using x = null", "using x = null;\n")
+	expectPrinted(t, "{ using x = y }", "{\n  using x = y;\n}\n")
+	expectPrinted(t, "for (using x of y) ;", "for (using x of y)\n  ;\n")
+	expectPrinted(t, "for (using x = y; ; ) ;", "for (using x = y; ; )\n  ;\n")
+	expectPrinted(t, "async function f() { await using x = y }", "async function f() {\n  await using x = y;\n}\n")
+	expectPrinted(t, "async function f() { for (await using x of y) ; }", "async function f() {\n  for (await using x of y)\n    ;\n}\n")
+	expectPrinted(t, "await using x = y", "await using x = y;\n")
+
+	// These are not "using" declarations
+	expectPrinted(t, "using", "using;\n")
+	expectPrinted(t, "using\nx = y", "using;\nx = y;\n")
+	expectPrinted(t, "using[x] = y", "using[x] = y;\n")
+	expectPrinted(t, "using.x = y", "using.x = y;\n")
+	expectPrinted(t, "for (using of x) ;", "for (using of x)\n  ;\n")
+	expectPrinted(t, "for (using in x) ;", "for (using in x)\n  ;\n")
+
+	expectParseError(t, "using x", "<stdin>: ERROR: The declaration \"x\" must be initialized\n")
+	expectParseError(t, "for (using x in y) ;", "<stdin>: ERROR: \"using\" declarations are not allowed in for-in loops\n")
+	expectParseError(t, "switch (x) { case 0: using y = z }",
+		"<stdin>: ERROR: \"using\" declarations are not allowed directly inside a switch case\n")
+	expectParseError(t, "using [x] = y", "")
+	expectParseError(t, "if (x) using y = z", "<stdin>: ERROR: Cannot use a declaration in a single-statement context\n")
+}
+
+func TestLowerUsing(t *testing.T) {
+	expectPrintedTarget(t, 2020, "{ using x = y; z(x) }",
+		"{\n  var _stack = [];\n  try {\n    const x = __using(_stack, y);\n    z(x);\n"+
+			"  } catch (_) {\n    var _error = _, _hasError = true;\n  } finally {\n    __callDispose(_stack, _error, _hasError);\n  }\n}\n")
+	expectPrintedTarget(t, 2020, "function f() { 'use strict'; using x = y }",
+		"function f() {\n  \"use strict\";\n  var _stack = [];\n  try {\n    const x = __using(_stack, y);\n"+
+			"  } catch (_) {\n    var _error = _, _hasError = true;\n  } finally {\n    __callDispose(_stack, _error, _hasError);\n  }\n}\n")
+	expectPrintedTarget(t, 2020, "async function f() { await using x = y }",
+		"async function f() {\n  var _stack = [];\n  try {\n    const x = __using(_stack, y, true);\n"+
+			"  } catch (_) {\n    var _error = _, _hasError = true;\n  } finally {\n"+
+			"    var _promise = __callDispose(_stack, _error, _hasError);\n    _promise && await _promise;\n  }\n}\n")
+	expectPrintedTarget(t, 2020, "for (using x of y) z(x)",
+		"for (const x of y) {\n  var _stack = [];\n  try {\n    __using(_stack, x);\n    z(x);\n"+
+			"  } catch (_) {\n    var _error = _, _hasError = true;\n  } finally {\n    __callDispose(_stack, _error, _hasError);\n  }\n}\n")
+	expectPrintedTarget(t, 2020, "using x = y; export let z = x",
+		"var x;\nexport var z;\nvar _stack = [];\ntry {\n  x = __using(_stack, y);\n  z = x;\n"+
+			"} catch (_) {\n  var _error = _, _hasError = true;\n} finally {\n  __callDispose(_stack, _error, _hasError);\n}\n")
+	expectPrintedTarget(t, 5, "{ using x = y }",
+		"{\n  var _stack = [];\n  try {\n    var x = __using(_stack, y);\n"+
+			"  } catch (_) {\n    var _error = _, _hasError = true;\n  } finally {\n    __callDispose(_stack, _error, _hasError);\n  }\n}\n")
+
+	// Nothing to lower
+	expectPrintedTarget(t, 2020, "{ using\nx = y }", "{\n  using;\n  x = y;\n}\n")
+}
+
 func TestUnicodeIdentifierNames(t *testing.T) {
 	// There are two code points that are valid in identifiers in ES5 but not in ES6+:
 	//
@@ -1714,6 +1828,46 @@ func TestClassStaticBlocks(t *testing.T) {
 	expectParseError(t, "x: { class Foo { static { continue x } } }", "<stdin>: ERROR: There is no containing label named \"x\"\n")
 }
 
+func TestDecorators(t *testing.T) {
+	expectPrinted(t, "@dec class Foo {}", "@dec class Foo {\n}\n")
+	expectPrinted(t, "@a @b class Foo {}", "@a @b class Foo {\n}\n")
+	expectPrinted(t, "@dec.a.b class Foo {}", "@dec.a.b class Foo {\n}\n")
+	expectPrinted(t, "@dec.a() class Foo {}", "@dec.a() class Foo {\n}\n")
+	expectPrinted(t, "@(a, b) class Foo {}", "@(a, b) class Foo {\n}\n")
+	expectPrinted(t, "@(a[b]) class Foo {}", "@(a[b]) class Foo {\n}\n")
+	expectPrinted(t, "(@dec class {})", "(@dec class {\n});\n")
+	expectPrinted(t, "export @dec class Foo {}", "export @dec class Foo {\n}\n")
+	expectPrinted(t, "@dec export class Foo {}", "export @dec class Foo {\n}\n")
+	expectPrinted(t, "export default @dec class {}", "export default @dec class {\n}\n")
+	expectPrinted(t, "@dec export default class {}", "export default @dec class {\n}\n")
+	expectPrinted(t, "class Foo { @dec x; @dec y() {} @dec get z() {} @dec static #w = 1 }",
+		"class Foo {\n  @dec x;\n  @dec y() {\n  }\n  @dec get z() {\n  }\n  @dec static #w = 1;\n}\n")
+	expectPrinted(t, "class A { static #a; static m() { @A.#a class B {} } }",
+		"class A {\n  static #a;\n  static m() {\n    @A.#a class B {\n    }\n  }\n}\n")
+
+	expectParseError(t, "@dec function foo() {}", "<stdin>: ERROR: Expected \"class\" but found \"function\"\n")
+	expectParseError(t, "@dec let x", "<stdin>: ERROR: Expected \"class\" but found \"let\"\n")
+	expectParseError(t, "@dec[x] class Foo {}", "<stdin>: ERROR: Expected \"class\" but found \"[\"\n")
+	expectParseError(t, "@new Foo class Bar {}", "<stdin>: ERROR: Expected identifier but found \"new\"\n")
+	expectParseError(t, "@await class Foo {}", "<stdin>: ERROR: Cannot use \"await\" as an identifier here:\n")
+	expectParseError(t, "class Foo { @dec static {} }", "<stdin>: ERROR: Decorators are not allowed on class static blocks\n")
+	expectParseError(t, "class Foo { @dec constructor() {} }", "<stdin>: ERROR: Decorators are not allowed on class constructors\n")
+	expectParseError(t, "({ @dec foo() {} })", "<stdin>: ERROR: Expected identifier but found \"@\"\n")
+}
+
+func TestAutoAccessors(t *testing.T) {
+	expectPrinted(t, "class Foo { accessor x = 1 }", "class Foo {\n  accessor x = 1;\n}\n")
+	expectPrinted(t, "class Foo { static accessor #x }", "class Foo {\n  static accessor #x;\n}\n")
+	expectPrinted(t, "class Foo { accessor [x] }", "class Foo {\n  accessor [x];\n}\n")
+	expectPrinted(t, "class Foo { @dec accessor x }", "class Foo {\n  @dec accessor x;\n}\n")
+	expectPrinted(t, "class Foo { accessor }", "class Foo {\n  accessor;\n}\n")
+	expectPrinted(t, "class Foo { accessor() {} }", "class Foo {\n  accessor() {\n  }\n}\n")
+	expectPrinted(t, "class Foo { accessor\n x }", "class Foo {\n  accessor;\n  x;\n}\n")
+
+	expectParseError(t, "class Foo { async accessor x }", "<stdin>: ERROR: Expected \"(\" but found \"x\"\n")
+	expectParseError(t, "({ accessor x: 1 })", "<stdin>: ERROR: Expected \"}\" but found \"x\"\n")
+}
+
 func TestGenerator(t *testing.T) {
 	expectParseError(t, "(class { * foo })", "<stdin>: ERROR: Expected \"(\" but found \"}\"\n")
 	expectParseError(t, "(class { * *foo() {} })", "<stdin>: ERROR: Unexpected \"*\"\n")
@@ -4600,6 +4754,41 @@ func TestImportAssertions(t *testing.T) {
 		"<stdin>: ERROR: Using an arbitrary value as the second argument to \"import()\" is not possible in the configured target environment\n")
 }
 
+func TestImportAttributes(t *testing.T) {
+	expectPrinted(t, "import 'x' with {}", "import \"x\" with {};\n")
+	expectPrinted(t, "import 'x' with {\n}", "import \"x\" with {};\n")
+	expectPrinted(t, "import 'x'\nwith\n{}", "import \"x\" with {};\n")
+	expectPrinted(t, "import 'x' with {type: 'json'}", "import \"x\" with { type: \"json\" };\n")
+	expectPrinted(t, "import 'x' with {type: 'json',}", "import \"x\" with { type: \"json\" };\n")
+	expectPrinted(t, "import 'x' with {'type': 'json'}", "import \"x\" with { \"type\": \"json\" };\n")
+	expectPrinted(t, "import 'x' with {a: 'b', c: 'd'}", "import \"x\" with { a: \"b\", c: \"d\" };\n")
+	expectPrintedMangle(t, "import 'x' with {'type': 'json'}", "import \"x\" with { type: \"json\" };\n")
+
+	expectParseError(t, "import 'x' with {x: y}", "<stdin>: ERROR: Expected string but found \"y\"\n")
+	expectParseError(t, "import 'x' with: {x: 'y'}", "<stdin>: ERROR: Expected \"{\" but found \":\"\n")
+	expectParseError(t, "import 'x' with {x: 'y', x: 'y'}",
+		"<stdin>: ERROR: Duplicate import attribute \"x\"\n<stdin>: NOTE: The first \"x\" was here:\n")
+
+	expectPrinted(t, "import x from 'x' with {x: 'y'}", "import x from \"x\" with { x: \"y\" };\n")
+	expectPrinted(t, "import * as x from 'x' with {x: 'y'}", "import * as x from \"x\" with { x: \"y\" };\n")
+	expectPrinted(t, "import {} from 'x' with {x: 'y'}", "import {} from \"x\" with { x: \"y\" };\n")
+	expectPrinted(t, "export {} from 'x' with {x: 'y'}", "export {} from \"x\" with { x: \"y\" };\n")
+	expectPrinted(t, "export * from 'x' with {x: 'y'}", "export * from \"x\" with { x: \"y\" };\n")
+
+	expectPrinted(t, "import(x ? 'y' : 'z', {with: {}})",
+		"x ? import(\"y\", { with: {} }) : import(\"z\", { with: {} });\n")
+	expectPrinted(t, "import(x ? 'y' : 'z', {with: {a: 'b'}})",
+		"x ? import(\"y\", { with: { a: \"b\" } }) : import(\"z\", { with: { a: \"b\" } });\n")
+	expectPrinted(t, "import(x ? 'y' : 'z', {with: []})", "import(x ? \"y\" : \"z\", { with: [] });\n")
+
+	// The "with" keyword is only used if the target supports it
+	expectPrintedTarget(t, 2025, "import 'x' with {x: 'y'}", "import \"x\" with { x: \"y\" };\n")
+	expectPrintedTarget(t, 2015, "import 'x' with {x: 'y'}", "import \"x\";\n")
+	expectPrintedTarget(t, 2015, "import(x ? 'y' : 'z', {with: {x: 1}})", "import(x ? \"y\" : \"z\");\n")
+	expectParseErrorTarget(t, 2015, "import(x ? 'y' : 'z', {with: {x: foo()}})",
+		"<stdin>: ERROR: Using an arbitrary value as the second argument to \"import()\" is not possible in the configured target environment\n")
+}
+
 func TestES5(t *testing.T) {
 	// Do not generate "let" when emulating block-level function declarations and targeting ES5
 	expectPrintedTarget(t, 2015, "if (1) function f() {}", "if (1) {\n  let f = function() {\n  };\n  var f = f;\n}\n")
@@ -4621,8 +4810,9 @@ func TestES5(t *testing.T) {
 		"<stdin>: ERROR: Transforming rest arguments to the configured target environment is not supported yet\n")
 	expectParseErrorTarget(t, 5, "[...x]",
 		"<stdin>: ERROR: Transforming array spread to the configured target environment is not supported yet\n")
-	expectParseErrorTarget(t, 5, "for (var x of y) ;",
-		"<stdin>: ERROR: Transforming for-of loops to the configured target environment is not supported yet\n")
+	expectPrintedTarget(t, 5, "for (var x of y) ;",
+		"try {\n  for (var iter = __getIterator(y), more, temp, error; more = !(temp = iter.next()).done; more = false) {\n    var x = temp.value;\n    ;\n  }\n"+
+			"} catch (temp) {\n  error = [temp];\n} finally {\n  try {\n    more && (temp = iter.return) && temp.call(iter);\n  } finally {\n    if (error)\n      throw error[0];\n  }\n}\n")
 	expectPrintedTarget(t, 5, "({ x })", "({ x: x });\n")
 	expectParseErrorTarget(t, 5, "({ [x]: y })",
 		"<stdin>: ERROR: Transforming object literal extensions to the configured target environment is not supported yet\n")
@@ -4634,51 +4824,24 @@ func TestES5(t *testing.T) {
 		"<stdin>: ERROR: Transforming object literal extensions to the configured target environment is not supported yet\n")
 	expectParseErrorTarget(t, 5, "({ set [x](x) {} });",
 		"<stdin>: ERROR: Transforming object literal extensions to the configured target environment is not supported yet\n")
-	expectParseErrorTarget(t, 5, "function foo([]) {}",
-		"<stdin>: ERROR: Transforming destructuring to the configured target environment is not supported yet\n")
-	expectParseErrorTarget(t, 5, "function foo({}) {}",
-		"<stdin>: ERROR: Transforming destructuring to the configured target environment is not supported yet\n")
-	expectParseErrorTarget(t, 5, "(function([]) {})",
-		"<stdin>: ERROR: Transforming destructuring to the configured target environment is not supported yet\n")
-	expectParseErrorTarget(t, 5, "(function({}) {})",
-		"<stdin>: ERROR: Transforming destructuring to the configured target environment is not supported yet\n")
-	expectParseErrorTarget(t, 5, "([]) => {}",
-		"<stdin>: ERROR: Transforming destructuring to the configured target environment is not supported yet\n")
-	expectParseErrorTarget(t, 5, "({}) => {}",
-		"<stdin>: ERROR: Transforming destructuring to the configured target environment is not supported yet\n")
-	expectParseErrorTarget(t, 5, "var [] = [];",
-		"<stdin>: ERROR: Transforming destructuring to the configured target environment is not supported yet\n")
-	expectParseErrorTarget(t, 5, "var {} = {};",
-		"<stdin>: ERROR: Transforming destructuring to the configured target environment is not supported yet\n")
-	expectParseErrorTarget(t, 5, "([] = []);",
-		"<stdin>: ERROR: Transforming destructuring to the configured target environment is not supported yet\n")
-	expectParseErrorTarget(t, 5, "({} = {});",
-		"<stdin>: ERROR: Transforming destructuring to the configured target environment is not supported yet\n")
-	expectParseErrorTarget(t, 5, "for ([] in []);",
-		"<stdin>: ERROR: Transforming destructuring to the configured target environment is not supported yet\n")
-	expectParseErrorTarget(t, 5, "for ({} in []);",
-		"<stdin>: ERROR: Transforming destructuring to the configured target environment is not supported yet\n")
-	expectParseErrorTarget(t, 5, "function foo([...x]) {}",
-		"<stdin>: ERROR: Transforming destructuring to the configured target environment is not supported yet\n")
-	expectParseErrorTarget(t, 5, "(function([...x]) {})",
-		"<stdin>: ERROR: Transforming destructuring to the configured target environment is not supported yet\n")
-	expectParseErrorTarget(t, 5, "([...x]) => {}",
-		"<stdin>: ERROR: Transforming destructuring to the configured target environment is not supported yet\n")
-	expectParseErrorTarget(t, 5, "function foo([...[x]]) {}",
-		`<stdin>: ERROR: Transforming destructuring to the configured target environment is not supported yet
-<stdin>: ERROR: Transforming destructuring to the configured target environment is not supported yet
-<stdin>: ERROR: Transforming non-identifier array rest patterns to the configured target environment is not supported yet
-`)
-	expectParseErrorTarget(t, 5, "(function([...[x]]) {})",
-		`<stdin>: ERROR: Transforming destructuring to the configured target environment is not supported yet
-<stdin>: ERROR: Transforming destructuring to the configured target environment is not supported yet
-<stdin>: ERROR: Transforming non-identifier array rest patterns to the configured target environment is not supported yet
-`)
-	expectParseErrorTarget(t, 5, "([...[x]]) => {}",
-		`<stdin>: ERROR: Transforming destructuring to the configured target environment is not supported yet
-<stdin>: ERROR: Transforming destructuring to the configured target environment is not supported yet
-<stdin>: ERROR: Transforming non-identifier array rest patterns to the configured target environment is not supported yet
-`)
+	expectPrintedTarget(t, 5, "function foo([]) {}", "function foo(_a) {\n  var _b = __readArray(_a, 0);\n}\n")
+	expectPrintedTarget(t, 5, "function foo({}) {}", "function foo(_a) {\n  var _b = __requireObjectCoercible(_a);\n}\n")
+	expectPrintedTarget(t, 5, "(function([]) {})", "(function(_a) {\n  var _b = __readArray(_a, 0);\n});\n")
+	expectPrintedTarget(t, 5, "(function({}) {})", "(function(_a) {\n  var _b = __requireObjectCoercible(_a);\n});\n")
+	expectPrintedTarget(t, 5, "([]) => {}", "(function(_a) {\n  var _b = __readArray(_a, 0);\n});\n")
+	expectPrintedTarget(t, 5, "({}) => {}", "(function(_a) {\n  var _b = __requireObjectCoercible(_a);\n});\n")
+	expectPrintedTarget(t, 5, "var [] = [];", "var _a = __readArray([], 0);\n")
+	expectPrintedTarget(t, 5, "var {} = {};", "var _a = __requireObjectCoercible({});\n")
+	expectPrintedTarget(t, 5, "([] = []);", "var _a;\n_a = __readArray([], 0);\n")
+	expectPrintedTarget(t, 5, "({} = {});", "var _a;\n_a = __requireObjectCoercible({});\n")
+	expectPrintedTarget(t, 5, "for ([] in []);", "var _a, _b;\nfor (_a in []) {\n  _b = __readArray(_a, 0);\n  ;\n}\n")
+	expectPrintedTarget(t, 5, "for ({} in []);", "var _a, _b;\nfor (_a in []) {\n  _b = __requireObjectCoercible(_a);\n  ;\n}\n")
+	expectPrintedTarget(t, 5, "function foo([...x]) {}", "function foo(_a) {\n  var x = __readArray(_a);\n}\n")
+	expectPrintedTarget(t, 5, "(function([...x]) {})", "(function(_a) {\n  var x = __readArray(_a);\n});\n")
+	expectPrintedTarget(t, 5, "([...x]) => {}", "(function(_a) {\n  var x = __readArray(_a);\n});\n")
+	expectPrintedTarget(t, 5, "function foo([...[x]]) {}", "function foo(_a) {\n  var x = __readArray(__readArray(_a), 1)[0];\n}\n")
+	expectPrintedTarget(t, 5, "(function([...[x]]) {})", "(function(_a) {\n  var x = __readArray(__readArray(_a), 1)[0];\n});\n")
+	expectPrintedTarget(t, 5, "([...[x]]) => {}", "(function(_a) {\n  var x = __readArray(__readArray(_a), 1)[0];\n});\n")
 	expectParseErrorTarget(t, 5, "([...[x]])",
 		"<stdin>: ERROR: Transforming array spread to the configured target environment is not supported yet\n")
 	expectPrintedTarget(t, 5, "`abc`;", "\"abc\";\n")
@@ -4699,25 +4862,21 @@ func TestES5(t *testing.T) {
 	expectPrintedTarget(t, 5, "tag`a${b}\\u`;", "var _a;\ntag(_a || (_a = __template([\"a\", void 0], [\"a\", \"\\\\u\"])), b);\n")
 	expectPrintedTarget(t, 5, "tag`\\u${b}c`;", "var _a;\ntag(_a || (_a = __template([void 0, \"c\"], [\"\\\\u\", \"c\"])), b);\n")
 	expectParseErrorTarget(t, 5, "class Foo { constructor() { new.target } }",
-		"<stdin>: ERROR: Transforming class syntax to the configured target environment is not supported yet\n"+
-			"<stdin>: ERROR: Transforming object literal extensions to the configured target environment is not supported yet\n"+
-			"<stdin>: ERROR: Transforming new.target to the configured target environment is not supported yet\n")
-	expectParseErrorTarget(t, 5, "const x = 1;",
-		"<stdin>: ERROR: Transforming const to the configured target environment is not supported yet\n")
-	expectParseErrorTarget(t, 5, "let x = 2;",
-		"<stdin>: ERROR: Transforming let to the configured target environment is not supported yet\n")
+		"<stdin>: ERROR: Transforming new.target to the configured target environment is not supported yet\n")
+	expectPrintedTarget(t, 5, "const x = 1;", "var x = 1;\n")
+	expectPrintedTarget(t, 5, "let x = 2;", "var x = 2;\n")
 	expectPrintedTarget(t, 5, "async => foo;", "(function(async) {\n  return foo;\n});\n")
 	expectPrintedTarget(t, 5, "x => x;", "(function(x) {\n  return x;\n});\n")
-	expectParseErrorTarget(t, 5, "async () => foo;",
-		"<stdin>: ERROR: Transforming async functions to the configured target environment is not supported yet\n")
-	expectParseErrorTarget(t, 5, "class Foo {}",
-		"<stdin>: ERROR: Transforming class syntax to the configured target environment is not supported yet\n")
-	expectParseErrorTarget(t, 5, "(class {});",
-		"<stdin>: ERROR: Transforming class syntax to the configured target environment is not supported yet\n")
-	expectParseErrorTarget(t, 5, "function* gen() {}",
-		"<stdin>: ERROR: Transforming generator functions to the configured target environment is not supported yet\n")
-	expectParseErrorTarget(t, 5, "(function* () {});",
-		"<stdin>: ERROR: Transforming generator functions to the configured target environment is not supported yet\n")
+	expectPrintedTarget(t, 5, "async () => foo;",
+		"(function() {\n  return __async(this, null, function() {\n    return __gen(this, function(_ctx) {\n      return [1, foo];\n    });\n  });\n});\n")
+	expectPrintedTarget(t, 5, "class Foo {}",
+		"var Foo = /* @__PURE__ */ function() {\n  function Foo() {\n    __classCallCheck(this, Foo);\n  }\n  return Foo;\n}();\n")
+	expectPrintedTarget(t, 5, "(class {});",
+		"/* @__PURE__ */ (function() {\n  function _class() {\n    __classCallCheck(this, _class);\n  }\n  return _class;\n})();\n")
+	expectPrintedTarget(t, 5, "function* gen() {}",
+		"function gen() {\n  return __gen(this, function(_ctx) {\n    return [1];\n  });\n}\n")
+	expectPrintedTarget(t, 5, "(function* () {});",
+		"(function() {\n  return __gen(this, function(_ctx) {\n    return [1];\n  });\n});\n")
 }
 
 func TestASCIIOnly(t *testing.T) {
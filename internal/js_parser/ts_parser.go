@@ -1,6 +1,8 @@
 // This file contains code for parsing TypeScript syntax. The parser just skips
 // over type expressions as if they are whitespace and doesn't bother generating
-// an AST because nothing uses type information.
+// an AST because nothing uses type information. The only exception is a small
+// summary of each type that is needed for TypeScript's "emitDecoratorMetadata"
+// setting.
 
 package js_parser
 
@@ -141,22 +143,34 @@ func (p *parser) skipTypeScriptFnArgs() {
 //     let x = (y: any): (y) => {return 0};
 //     let x = (y: any): asserts y is (y) => {};
 //
-func (p *parser) skipTypeScriptParenOrFnType() {
+func (p *parser) skipTypeScriptParenOrFnType(opts skipTypeOpts) js_ast.TSMetadata {
 	if p.trySkipTypeScriptArrowArgsWithBacktracking() {
 		p.skipTypeScriptReturnType()
-	} else {
-		p.lexer.Expect(js_lexer.TOpenParen)
-		p.skipTypeScriptType(js_ast.LLowest)
-		p.lexer.Expect(js_lexer.TCloseParen)
+		return js_ast.TSMetadata{Kind: js_ast.TSMetadataFunction}
 	}
+	if p.options.ts.Flow {
+		return p.skipFlowParenOrFnType(opts)
+	}
+	p.lexer.Expect(js_lexer.TOpenParen)
+	metadata := p.skipTypeScriptType(js_ast.LLowest)
+	p.lexer.Expect(js_lexer.TCloseParen)
+	return metadata
 }
 
-func (p *parser) skipTypeScriptReturnType() {
-	p.skipTypeScriptTypeWithOpts(js_ast.LLowest, skipTypeOpts{isReturnType: true})
+func (p *parser) skipTypeScriptReturnType() js_ast.TSMetadata {
+	// "function isString(x: mixed): %checks { ... }"
+	if p.trySkipFlowPredicate() {
+		return js_ast.TSMetadata{Kind: js_ast.TSMetadataBoolean}
+	}
+	metadata := p.skipTypeScriptTypeWithOpts(js_ast.LLowest, skipTypeOpts{isReturnType: true})
+
+	// "function isString(x: mixed): boolean %checks { ... }"
+	p.trySkipFlowPredicate()
+	return metadata
 }
 
-func (p *parser) skipTypeScriptType(level js_ast.L) {
-	p.skipTypeScriptTypeWithOpts(level, skipTypeOpts{})
+func (p *parser) skipTypeScriptType(level js_ast.L) js_ast.TSMetadata {
+	return p.skipTypeScriptTypeWithOpts(level, skipTypeOpts{})
 }
 
 type skipTypeOpts struct {
@@ -197,13 +211,92 @@ var tsTypeIdentifierMap = map[string]tsTypeIdentifierKind{
 	"symbol":    tsTypeIdentifierPrimitive,
 }
 
-func (p *parser) skipTypeScriptTypeWithOpts(level js_ast.L, opts skipTypeOpts) {
+var tsPrimitiveMetadataMap = map[string]js_ast.TSMetadataKind{
+	"any":       js_ast.TSMetadataAny,
+	"never":     js_ast.TSMetadataNever,
+	"unknown":   js_ast.TSMetadataUnknown,
+	"undefined": js_ast.TSMetadataUndefined,
+	"object":    js_ast.TSMetadataObject,
+	"number":    js_ast.TSMetadataNumber,
+	"string":    js_ast.TSMetadataString,
+	"boolean":   js_ast.TSMetadataBoolean,
+	"bigint":    js_ast.TSMetadataBigInt,
+	"symbol":    js_ast.TSMetadataSymbol,
+}
+
+// This mirrors how the TypeScript compiler serializes union and intersection
+// types for decorator metadata. All members must serialize to the same value
+// or the result is "Object". Note that "null" and "undefined" are always
+// ignored, which matches what TypeScript does when "strictNullChecks" is off.
+func mergeTSMetadata(a js_ast.TSMetadata, b js_ast.TSMetadata, isIntersection bool) js_ast.TSMetadata {
+	for _, m := range [2]js_ast.TSMetadata{a, b} {
+		switch m.Kind {
+		case js_ast.TSMetadataAny:
+			return js_ast.TSMetadata{Kind: js_ast.TSMetadataObject}
+
+		case js_ast.TSMetadataNever:
+			if isIntersection {
+				return js_ast.TSMetadata{Kind: js_ast.TSMetadataVoid}
+			}
+
+		case js_ast.TSMetadataUnknown:
+			if !isIntersection {
+				return js_ast.TSMetadata{Kind: js_ast.TSMetadataObject}
+			}
+		}
+	}
+	if isIgnoredInTSUnion(a.Kind, isIntersection) {
+		return b
+	}
+	if isIgnoredInTSUnion(b.Kind, isIntersection) {
+		return a
+	}
+	if a != b {
+		return js_ast.TSMetadata{Kind: js_ast.TSMetadataObject}
+	}
+	return a
+}
+
+func isIgnoredInTSUnion(kind js_ast.TSMetadataKind, isIntersection bool) bool {
+	switch kind {
+	case js_ast.TSMetadataNull, js_ast.TSMetadataUndefined:
+		return true
+	case js_ast.TSMetadataNever:
+		return !isIntersection
+	case js_ast.TSMetadataUnknown:
+		return isIntersection
+	}
+	return false
+}
+
+func (p *parser) skipTypeScriptTypeWithOpts(level js_ast.L, opts skipTypeOpts) (metadata js_ast.TSMetadata) {
+	metadata.Kind = js_ast.TSMetadataObject
+
 	for {
 		switch p.lexer.Token {
-		case js_lexer.TNumericLiteral, js_lexer.TBigIntegerLiteral, js_lexer.TStringLiteral,
-			js_lexer.TNoSubstitutionTemplateLiteral, js_lexer.TTrue, js_lexer.TFalse,
-			js_lexer.TNull, js_lexer.TVoid:
+		case js_lexer.TNumericLiteral:
+			p.lexer.Next()
+			metadata.Kind = js_ast.TSMetadataNumber
+
+		case js_lexer.TBigIntegerLiteral:
 			p.lexer.Next()
+			metadata.Kind = js_ast.TSMetadataBigInt
+
+		case js_lexer.TStringLiteral, js_lexer.TNoSubstitutionTemplateLiteral:
+			p.lexer.Next()
+			metadata.Kind = js_ast.TSMetadataString
+
+		case js_lexer.TTrue, js_lexer.TFalse:
+			p.lexer.Next()
+			metadata.Kind = js_ast.TSMetadataBoolean
+
+		case js_lexer.TNull:
+			p.lexer.Next()
+			metadata.Kind = js_ast.TSMetadataNull
+
+		case js_lexer.TVoid:
+			p.lexer.Next()
+			metadata.Kind = js_ast.TSMetadataVoid
 
 		case js_lexer.TConst:
 			r := p.lexer.Range()
@@ -221,7 +314,7 @@ func (p *parser) skipTypeScriptTypeWithOpts(level js_ast.L, opts skipTypeOpts) {
 			if p.lexer.IsContextualKeyword("is") && !p.lexer.HasNewlineBefore {
 				p.lexer.Next()
 				p.skipTypeScriptType(js_ast.LLowest)
-				return
+				return js_ast.TSMetadata{Kind: js_ast.TSMetadataBoolean}
 			}
 
 		case js_lexer.TMinus:
@@ -230,8 +323,10 @@ func (p *parser) skipTypeScriptTypeWithOpts(level js_ast.L, opts skipTypeOpts) {
 			p.lexer.Next()
 			if p.lexer.Token == js_lexer.TBigIntegerLiteral {
 				p.lexer.Next()
+				metadata.Kind = js_ast.TSMetadataBigInt
 			} else {
 				p.lexer.Expect(js_lexer.TNumericLiteral)
+				metadata.Kind = js_ast.TSMetadataNumber
 			}
 
 		case js_lexer.TAmpersand:
@@ -240,6 +335,21 @@ func (p *parser) skipTypeScriptTypeWithOpts(level js_ast.L, opts skipTypeOpts) {
 			p.lexer.Next()
 			continue
 
+		case js_lexer.TQuestion:
+			// "let foo: ?string" is a Flow maybe type
+			if !p.options.ts.Flow {
+				p.lexer.Unexpected()
+			}
+			p.lexer.Next()
+			continue
+
+		case js_lexer.TAsterisk:
+			// "let foo: Array<*>" is a Flow existential type
+			if !p.options.ts.Flow {
+				p.lexer.Unexpected()
+			}
+			p.lexer.Next()
+
 		case js_lexer.TImport:
 			// "import('fs')"
 			p.lexer.Next()
@@ -263,24 +373,42 @@ func (p *parser) skipTypeScriptTypeWithOpts(level js_ast.L, opts skipTypeOpts) {
 				return
 			}
 
-			p.skipTypeScriptTypeParameters()
-			p.skipTypeScriptParenOrFnType()
+			p.skipTypeScriptTypeParameters(allowConstModifier)
+			p.skipTypeScriptParenOrFnType(skipTypeOpts{})
+			metadata.Kind = js_ast.TSMetadataFunction
 
 		case js_lexer.TLessThan:
 			// "<T>() => Foo<T>"
-			p.skipTypeScriptTypeParameters()
-			p.skipTypeScriptParenOrFnType()
+			p.skipTypeScriptTypeParameters(allowConstModifier)
+			p.skipTypeScriptParenOrFnType(skipTypeOpts{})
+			metadata.Kind = js_ast.TSMetadataFunction
 
 		case js_lexer.TOpenParen:
 			// "(number | string)"
-			p.skipTypeScriptParenOrFnType()
+			metadata = p.skipTypeScriptParenOrFnType(opts)
 
 		case js_lexer.TIdentifier:
 			kind := tsTypeIdentifierMap[p.lexer.Identifier]
+			name := p.lexer.Identifier
 
 			if kind == tsTypeIdentifierPrefix {
 				p.lexer.Next()
-				p.skipTypeScriptType(js_ast.LPrefix)
+
+				// "type Foo<T> = T extends [infer U extends string] ? U : never"
+				if name == "infer" {
+					p.lexer.Expect(js_lexer.TIdentifier)
+					if p.lexer.Token == js_lexer.TExtends {
+						p.trySkipTypeScriptConstraintOfInferTypeWithBacktracking(level)
+					}
+					break
+				}
+
+				operand := p.skipTypeScriptType(js_ast.LPrefix)
+
+				// "readonly string[]" has the same metadata as "string[]"
+				if name == "readonly" {
+					metadata = operand
+				}
 				break
 			}
 
@@ -292,8 +420,10 @@ func (p *parser) skipTypeScriptTypeWithOpts(level js_ast.L, opts skipTypeOpts) {
 				// "let foo: unique symbol"
 				if p.lexer.IsContextualKeyword("symbol") {
 					p.lexer.Next()
+					metadata.Kind = js_ast.TSMetadataSymbol
 					break
 				}
+				metadata = js_ast.TSMetadata{Kind: js_ast.TSMetadataReference, Name: name}
 			} else if kind == tsTypeIdentifierAbstract {
 				p.lexer.Next()
 
@@ -301,26 +431,31 @@ func (p *parser) skipTypeScriptTypeWithOpts(level js_ast.L, opts skipTypeOpts) {
 				if p.lexer.Token == js_lexer.TNew {
 					continue
 				}
+				metadata = js_ast.TSMetadata{Kind: js_ast.TSMetadataReference, Name: name}
 			} else if kind == tsTypeIdentifierAsserts {
 				p.lexer.Next()
+				metadata = js_ast.TSMetadata{Kind: js_ast.TSMetadataReference, Name: name}
 
 				// "function assert(x: boolean): asserts x"
 				// "function assert(x: boolean): asserts x is boolean"
 				if opts.isReturnType && !p.lexer.HasNewlineBefore && (p.lexer.Token == js_lexer.TIdentifier || p.lexer.Token == js_lexer.TThis) {
 					p.lexer.Next()
+					metadata = js_ast.TSMetadata{Kind: js_ast.TSMetadataBoolean}
 				}
 			} else if kind == tsTypeIdentifierPrimitive {
 				p.lexer.Next()
 				checkTypeParameters = false
+				metadata.Kind = tsPrimitiveMetadataMap[name]
 			} else {
 				p.lexer.Next()
+				metadata = js_ast.TSMetadata{Kind: js_ast.TSMetadataReference, Name: name}
 			}
 
 			// "function assert(x: any): x is boolean"
 			if p.lexer.IsContextualKeyword("is") && !p.lexer.HasNewlineBefore {
 				p.lexer.Next()
 				p.skipTypeScriptType(js_ast.LLowest)
-				return
+				return js_ast.TSMetadata{Kind: js_ast.TSMetadataBoolean}
 			}
 
 			// "let foo: any \n <number>foo" must not become a single type
@@ -376,6 +511,7 @@ func (p *parser) skipTypeScriptTypeWithOpts(level js_ast.L, opts skipTypeOpts) {
 				p.lexer.Next()
 			}
 			p.lexer.Expect(js_lexer.TCloseBracket)
+			metadata.Kind = js_ast.TSMetadataArray
 
 		case js_lexer.TOpenBrace:
 			p.skipTypeScriptObjectType()
@@ -391,6 +527,7 @@ func (p *parser) skipTypeScriptTypeWithOpts(level js_ast.L, opts skipTypeOpts) {
 					break
 				}
 			}
+			metadata.Kind = js_ast.TSMetadataString
 
 		default:
 			// "[function: number]"
@@ -413,18 +550,18 @@ func (p *parser) skipTypeScriptTypeWithOpts(level js_ast.L, opts skipTypeOpts) {
 	for {
 		switch p.lexer.Token {
 		case js_lexer.TBar:
-			if level >= js_ast.LBitwiseOr {
+			if level >= js_ast.LBitwiseOr || p.isFlowExactObjectTypeEnd() {
 				return
 			}
 			p.lexer.Next()
-			p.skipTypeScriptType(js_ast.LBitwiseOr)
+			metadata = mergeTSMetadata(metadata, p.skipTypeScriptType(js_ast.LBitwiseOr), false /* isIntersection */)
 
 		case js_lexer.TAmpersand:
 			if level >= js_ast.LBitwiseAnd {
 				return
 			}
 			p.lexer.Next()
-			p.skipTypeScriptType(js_ast.LBitwiseAnd)
+			metadata = mergeTSMetadata(metadata, p.skipTypeScriptType(js_ast.LBitwiseAnd), true /* isIntersection */)
 
 		case js_lexer.TExclamation:
 			// A postfix "!" is allowed in JSDoc types in TypeScript, which are only
@@ -442,6 +579,13 @@ func (p *parser) skipTypeScriptTypeWithOpts(level js_ast.L, opts skipTypeOpts) {
 			if !p.lexer.IsIdentifierOrKeyword() {
 				p.lexer.Expect(js_lexer.TIdentifier)
 			}
+
+			// "ns.Foo" refers to a value but "import('fs').Foo" doesn't
+			if metadata.Kind == js_ast.TSMetadataReference {
+				metadata.Name += "." + p.lexer.Identifier
+			} else {
+				metadata = js_ast.TSMetadata{Kind: js_ast.TSMetadataObject}
+			}
 			p.lexer.Next()
 
 			// "{ <A extends B>(): c.d \n <E extends F>(): g.h }" must not become a single type
@@ -455,8 +599,13 @@ func (p *parser) skipTypeScriptTypeWithOpts(level js_ast.L, opts skipTypeOpts) {
 				return
 			}
 			p.lexer.Next()
+
+			// "T[]" is an array but "T[K]" is an indexed access type
 			if p.lexer.Token != js_lexer.TCloseBracket {
 				p.skipTypeScriptType(js_ast.LLowest)
+				metadata = js_ast.TSMetadata{Kind: js_ast.TSMetadataObject}
+			} else {
+				metadata = js_ast.TSMetadata{Kind: js_ast.TSMetadataArray}
 			}
 			p.lexer.Expect(js_lexer.TCloseBracket)
 
@@ -468,11 +617,24 @@ func (p *parser) skipTypeScriptTypeWithOpts(level js_ast.L, opts skipTypeOpts) {
 			p.lexer.Next()
 
 			// The type following "extends" is not permitted to be another conditional type
+			// The metadata for a conditional type is the union of both branches
 			p.skipTypeScriptType(js_ast.LConditional)
 			p.lexer.Expect(js_lexer.TQuestion)
-			p.skipTypeScriptType(js_ast.LLowest)
+			yes := p.skipTypeScriptType(js_ast.LLowest)
 			p.lexer.Expect(js_lexer.TColon)
-			p.skipTypeScriptType(js_ast.LLowest)
+			no := p.skipTypeScriptType(js_ast.LLowest)
+			metadata = mergeTSMetadata(yes, no, false /* isIntersection */)
+
+		case js_lexer.TEqualsGreaterThan:
+			// "let foo: string => void" is a Flow function type with a single
+			// unnamed parameter. This isn't allowed in a return type position
+			// because it's ambiguous with the "=>" of an arrow function.
+			if !p.options.ts.Flow || opts.isReturnType || level != js_ast.LLowest {
+				return
+			}
+			p.lexer.Next()
+			p.skipTypeScriptReturnType()
+			metadata = js_ast.TSMetadata{Kind: js_ast.TSMetadataFunction}
 
 		default:
 			return
@@ -483,7 +645,37 @@ func (p *parser) skipTypeScriptTypeWithOpts(level js_ast.L, opts skipTypeOpts) {
 func (p *parser) skipTypeScriptObjectType() {
 	p.lexer.Expect(js_lexer.TOpenBrace)
 
-	for p.lexer.Token != js_lexer.TCloseBrace {
+	// "let foo: {| x: number |}" is a Flow exact object type
+	isExact := false
+	if p.options.ts.Flow {
+		switch p.lexer.Token {
+		case js_lexer.TBar:
+			p.lexer.Next()
+			isExact = true
+
+		case js_lexer.TBarBar:
+			// "let foo: {||}"
+			p.lexer.Next()
+			p.lexer.Expect(js_lexer.TCloseBrace)
+			return
+		}
+	}
+
+	for p.lexer.Token != js_lexer.TCloseBrace && (!isExact || p.lexer.Token != js_lexer.TBar) {
+		// "let foo: { ...Bar, x: number }"
+		// "let foo: { x: number, ... }"
+		if p.options.ts.Flow && p.lexer.Token == js_lexer.TDotDotDot {
+			p.lexer.Next()
+			if p.lexer.Token != js_lexer.TComma && p.lexer.Token != js_lexer.TSemicolon &&
+				p.lexer.Token != js_lexer.TCloseBrace && p.lexer.Token != js_lexer.TBar {
+				p.skipTypeScriptType(js_ast.LLowest)
+			}
+			if p.lexer.Token == js_lexer.TComma || p.lexer.Token == js_lexer.TSemicolon {
+				p.lexer.Next()
+			}
+			continue
+		}
+
 		// "{ -readonly [K in keyof T]: T[K] }"
 		// "{ +readonly [K in keyof T]: T[K] }"
 		if p.lexer.Token == js_lexer.TPlus || p.lexer.Token == js_lexer.TMinus {
@@ -537,7 +729,7 @@ func (p *parser) skipTypeScriptObjectType() {
 		}
 
 		// Type parameters come right after the optional mark
-		p.skipTypeScriptTypeParameters()
+		p.skipTypeScriptTypeParameters(allowConstModifier)
 
 		switch p.lexer.Token {
 		case js_lexer.TColon:
@@ -569,23 +761,81 @@ func (p *parser) skipTypeScriptObjectType() {
 			p.lexer.Next()
 
 		default:
-			if !p.lexer.HasNewlineBefore {
+			if !p.lexer.HasNewlineBefore && (!isExact || p.lexer.Token != js_lexer.TBar) {
 				p.lexer.Unexpected()
 			}
 		}
 	}
 
+	if isExact {
+		p.lexer.Expect(js_lexer.TBar)
+	}
 	p.lexer.Expect(js_lexer.TCloseBrace)
 }
 
+type typeParameterFlags uint8
+
+const (
+	// TypeScript 4.7
+	allowInOutVarianceAnnotations typeParameterFlags = 1 << iota
+
+	// TypeScript 5.0
+	allowConstModifier
+)
+
 // This is the type parameter declarations that go with other symbol
 // declarations (class, function, type, etc.)
-func (p *parser) skipTypeScriptTypeParameters() {
+func (p *parser) skipTypeScriptTypeParameters(flags typeParameterFlags) {
 	if p.lexer.Token == js_lexer.TLessThan {
 		p.lexer.Next()
 
 		for {
-			p.lexer.Expect(js_lexer.TIdentifier)
+			// "class Foo<in T> {}"
+			// "class Foo<out T> {}"
+			// "class Foo<in out T> {}"
+			// "function foo<const T>() {}"
+			invalidModifierRange := logger.Range{}
+			hasName := false
+
+			// "class Foo<+T, -U> {}" is Flow's variance syntax
+			if p.options.ts.Flow && (p.lexer.Token == js_lexer.TPlus || p.lexer.Token == js_lexer.TMinus) {
+				p.lexer.Next()
+			}
+
+			for {
+				r := p.lexer.Range()
+				isAllowed := false
+				if p.lexer.Token == js_lexer.TIn || p.lexer.IsContextualKeyword("out") {
+					isAllowed = (flags & allowInOutVarianceAnnotations) != 0
+				} else if p.lexer.Token == js_lexer.TConst {
+					isAllowed = (flags & allowConstModifier) != 0
+				} else {
+					break
+				}
+				isOut := p.lexer.Token == js_lexer.TIdentifier
+				p.lexer.Next()
+
+				// "class Foo<out> {}" declares a type parameter named "out"
+				if isOut && p.lexer.Token != js_lexer.TIdentifier && p.lexer.Token != js_lexer.TIn && p.lexer.Token != js_lexer.TConst {
+					hasName = true
+					break
+				}
+
+				if !isAllowed && invalidModifierRange.Len == 0 {
+					invalidModifierRange = r
+				}
+			}
+			if invalidModifierRange.Len > 0 {
+				// Fail instead of reporting an error when backtracking
+				if p.lexer.IsLogDisabled {
+					p.lexer.Unexpected()
+				}
+				p.log.Add(logger.Error, &p.tracker, invalidModifierRange,
+					fmt.Sprintf("The modifier %q is not valid here:", p.source.TextForRange(invalidModifierRange)))
+			}
+			if !hasName {
+				p.lexer.Expect(js_lexer.TIdentifier)
+			}
 
 			// "class Foo<T extends number> {}"
 			if p.lexer.Token == js_lexer.TExtends {
@@ -593,6 +843,12 @@ func (p *parser) skipTypeScriptTypeParameters() {
 				p.skipTypeScriptType(js_ast.LLowest)
 			}
 
+			// "class Foo<T: number> {}" is Flow's syntax for a bound
+			if p.options.ts.Flow && p.lexer.Token == js_lexer.TColon {
+				p.lexer.Next()
+				p.skipTypeScriptType(js_ast.LLowest)
+			}
+
 			// "class Foo<T = void> {}"
 			if p.lexer.Token == js_lexer.TEquals {
 				p.lexer.Next()
@@ -651,6 +907,13 @@ func (p *parser) trySkipTypeScriptTypeArgumentsWithBacktracking() bool {
 
 	p.skipTypeScriptTypeArguments(false /* isInsideJSXElement */)
 
+	// TypeScript doesn't split a ">" off of a token such as ">=" here, so
+	// "x < y >= z" is a comparison instead of "x<y> = z"
+	if start := int(p.lexer.Loc().Start); start > 0 && start < len(p.source.Contents) &&
+		p.source.Contents[start-1] == '>' && p.source.Contents[start] == '=' {
+		p.lexer.Unexpected()
+	}
+
 	// Check the token after this and backtrack if it's the wrong one
 	if !p.canFollowTypeArgumentsInExpression() {
 		p.lexer.Unexpected()
@@ -662,6 +925,38 @@ func (p *parser) trySkipTypeScriptTypeArgumentsWithBacktracking() bool {
 	return true
 }
 
+// The "extends" after "infer T" is ambiguous with the "extends" of a
+// conditional type. It's only a constraint if conditional types aren't allowed
+// here (i.e. this is already the check type of a conditional type) or if no
+// "?" follows it.
+func (p *parser) trySkipTypeScriptConstraintOfInferTypeWithBacktracking(level js_ast.L) bool {
+	oldLexer := p.lexer
+	p.lexer.IsLogDisabled = true
+
+	// Implement backtracking by restoring the lexer's memory to its original state
+	defer func() {
+		r := recover()
+		if _, isLexerPanic := r.(js_lexer.LexerPanic); isLexerPanic {
+			p.lexer = oldLexer
+		} else if r != nil {
+			panic(r)
+		}
+	}()
+
+	p.lexer.Expect(js_lexer.TExtends)
+	p.skipTypeScriptType(js_ast.LConditional)
+
+	// Check the token after this and backtrack if it's the wrong one
+	if level < js_ast.LConditional && p.lexer.Token == js_lexer.TQuestion {
+		p.lexer.Unexpected()
+	}
+
+	// Restore the log disabled flag. Note that we can't just set it back to false
+	// because it may have been true to start with.
+	p.lexer.IsLogDisabled = oldLexer.IsLogDisabled
+	return true
+}
+
 func (p *parser) trySkipTypeScriptTypeParametersThenOpenParenWithBacktracking() bool {
 	oldLexer := p.lexer
 	p.lexer.IsLogDisabled = true
@@ -676,7 +971,7 @@ func (p *parser) trySkipTypeScriptTypeParametersThenOpenParenWithBacktracking()
 		}
 	}()
 
-	p.skipTypeScriptTypeParameters()
+	p.skipTypeScriptTypeParameters(allowConstModifier)
 	if p.lexer.Token != js_lexer.TOpenParen {
 		p.lexer.Unexpected()
 	}
@@ -715,6 +1010,48 @@ func (p *parser) trySkipTypeScriptArrowReturnTypeWithBacktracking() bool {
 	return true
 }
 
+// This is used for an arrow function with a return type at the start of the
+// "yes" branch of a conditional expression such as "a ? (b) : c => d : e".
+// It parses the arrow function ahead of time to check whether it's followed by
+// the ":" for the conditional expression, then undoes everything it did.
+func (p *parser) isTypeScriptArrowFnFollowedByColonWithBacktracking(isAsync bool) (result bool) {
+	oldLexer := p.lexer
+	oldLog := p.log
+	oldAllowIn := p.allowIn
+	oldFnOrArrowData := p.fnOrArrowDataParse
+	oldScope := p.currentScope
+	oldConditionalYesLoc := p.conditionalYesLoc
+	scopeIndex := len(p.scopesInOrder)
+	p.lexer.IsLogDisabled = true
+	p.log = logger.NewDeferLog(logger.DeferLogNoVerboseOrDebug)
+
+	// Implement backtracking by restoring the parser's state no matter what.
+	// Any syntax error means this isn't an arrow function followed by ":".
+	defer func() {
+		r := recover()
+		if _, isLexerPanic := r.(js_lexer.LexerPanic); !isLexerPanic && r != nil {
+			panic(r)
+		}
+		p.lexer = oldLexer
+		p.log = oldLog
+		p.allowIn = oldAllowIn
+		p.fnOrArrowDataParse = oldFnOrArrowData
+		p.currentScope = oldScope
+		p.conditionalYesLoc = oldConditionalYesLoc
+		p.discardScopesUpTo(scopeIndex)
+	}()
+
+	p.lexer.Expect(js_lexer.TColon)
+	p.skipTypeScriptReturnType()
+
+	await := allowIdent
+	if isAsync {
+		await = allowExpr
+	}
+	p.parseArrowBody(nil, fnOrArrowDataParse{await: await})
+	return p.lexer.Token == js_lexer.TColon
+}
+
 func (p *parser) trySkipTypeScriptArrowArgsWithBacktracking() bool {
 	oldLexer := p.lexer
 	p.lexer.IsLogDisabled = true
@@ -744,6 +1081,11 @@ func (p *parser) isTSArrowFnJSX() (isTSArrowFn bool) {
 	oldLexer := p.lexer
 	p.lexer.Next()
 
+	// "<const T,>() => {}"
+	if p.lexer.Token == js_lexer.TConst {
+		p.lexer.Next()
+	}
+
 	// Look ahead to see if this should be an arrow function instead
 	if p.lexer.Token == js_lexer.TIdentifier {
 		p.lexer.Next()
@@ -765,49 +1107,151 @@ func (p *parser) isTSArrowFnJSX() (isTSArrowFn bool) {
 func (p *parser) canFollowTypeArgumentsInExpression() bool {
 	switch p.lexer.Token {
 	case
-		// These are the only tokens can legally follow a type argument list. So we
-		// definitely want to treat them as type arg lists.
+		// These tokens can follow a type argument list in a call expression.
 		js_lexer.TOpenParen,                     // foo<x>(
 		js_lexer.TNoSubstitutionTemplateLiteral, // foo<T> `...`
 		js_lexer.TTemplateHead:                  // foo<T> `...${100}...`
 		return true
 
 	case
-		// These cases can't legally follow a type arg list. However, they're not
-		// legal expressions either. The user is probably in the middle of a
-		// generic type. So treat it as such.
-		js_lexer.TDot,                     // foo<x>.
-		js_lexer.TCloseParen,              // foo<x>)
-		js_lexer.TCloseBracket,            // foo<x>]
-		js_lexer.TColon,                   // foo<x>:
-		js_lexer.TSemicolon,               // foo<x>;
-		js_lexer.TQuestion,                // foo<x>?
-		js_lexer.TEqualsEquals,            // foo<x> ==
-		js_lexer.TEqualsEqualsEquals,      // foo<x> ===
-		js_lexer.TExclamationEquals,       // foo<x> !=
-		js_lexer.TExclamationEqualsEquals, // foo<x> !==
-		js_lexer.TAmpersandAmpersand,      // foo<x> &&
-		js_lexer.TBarBar,                  // foo<x> ||
-		js_lexer.TQuestionQuestion,        // foo<x> ??
-		js_lexer.TCaret,                   // foo<x> ^
-		js_lexer.TAmpersand,               // foo<x> &
-		js_lexer.TBar,                     // foo<x> |
-		js_lexer.TCloseBrace,              // foo<x> }
-		js_lexer.TEndOfFile:               // foo<x>
+		// A type argument list followed by "<" never makes sense, and a type
+		// argument list followed by ">" is ambiguous with a (re-scanned) ">>"
+		// operator, so we disqualify both. Also, in this context, "+" and "-"
+		// are unary operators, not binary operators.
+		js_lexer.TLessThan,
+		js_lexer.TGreaterThan,
+		js_lexer.TPlus,
+		js_lexer.TMinus,
+
+		// TypeScript always sees "x<y>>=z" as "x<y> >= z" but our lexer has
+		// already combined the trailing ">" characters into a single token.
+		js_lexer.TGreaterThanEquals,
+		js_lexer.TGreaterThanGreaterThan,
+		js_lexer.TGreaterThanGreaterThanEquals,
+		js_lexer.TGreaterThanGreaterThanGreaterThan,
+		js_lexer.TGreaterThanGreaterThanGreaterThanEquals:
+		return false
+	}
+
+	// We favor the type argument list interpretation when it is immediately
+	// followed by a line break, a binary operator, or something that can't
+	// start an expression.
+	return p.lexer.HasNewlineBefore || p.isBinaryOperator() || !p.isStartOfExpression()
+}
+
+// This function is taken from the official TypeScript compiler source code:
+// https://github.com/microsoft/TypeScript/blob/master/src/compiler/parser.ts
+func (p *parser) isBinaryOperator() bool {
+	switch p.lexer.Token {
+	case js_lexer.TIn:
+		return p.allowIn
+
+	case
+		js_lexer.TQuestionQuestion,
+		js_lexer.TBarBar,
+		js_lexer.TAmpersandAmpersand,
+		js_lexer.TBar,
+		js_lexer.TCaret,
+		js_lexer.TAmpersand,
+		js_lexer.TEqualsEquals,
+		js_lexer.TExclamationEquals,
+		js_lexer.TEqualsEqualsEquals,
+		js_lexer.TExclamationEqualsEquals,
+		js_lexer.TLessThan,
+		js_lexer.TGreaterThan,
+		js_lexer.TLessThanEquals,
+		js_lexer.TGreaterThanEquals,
+		js_lexer.TInstanceof,
+		js_lexer.TLessThanLessThan,
+		js_lexer.TGreaterThanGreaterThan,
+		js_lexer.TGreaterThanGreaterThanGreaterThan,
+		js_lexer.TPlus,
+		js_lexer.TMinus,
+		js_lexer.TAsterisk,
+		js_lexer.TSlash,
+		js_lexer.TPercent,
+		js_lexer.TAsteriskAsterisk:
 		return true
 
+	case js_lexer.TIdentifier:
+		return p.lexer.IsContextualKeyword("as") || p.lexer.IsContextualKeyword("satisfies")
+	}
+
+	return false
+}
+
+// This function is taken from the official TypeScript compiler source code:
+// https://github.com/microsoft/TypeScript/blob/master/src/compiler/parser.ts
+func (p *parser) isStartOfExpression() bool {
+	if p.isStartOfLeftHandSideExpression() {
+		return true
+	}
+
+	switch p.lexer.Token {
 	case
-		// We don't want to treat these as type arguments. Otherwise we'll parse
-		// this as an invocation expression. Instead, we want to parse out the
-		// expression in isolation from the type arguments.
-		js_lexer.TComma,     // foo<x>,
-		js_lexer.TOpenBrace: // foo<x> {
-		return false
+		js_lexer.TPlus,
+		js_lexer.TMinus,
+		js_lexer.TTilde,
+		js_lexer.TExclamation,
+		js_lexer.TDelete,
+		js_lexer.TTypeof,
+		js_lexer.TVoid,
+		js_lexer.TPlusPlus,
+		js_lexer.TMinusMinus,
+		js_lexer.TLessThan,
+		js_lexer.TPrivateIdentifier,
+		js_lexer.TAt:
+		return true
 
 	default:
-		// Anything else treat as an expression.
-		return false
+		// Error tolerance. If we see the start of some binary operator, we consider
+		// that the start of an expression. That way we'll parse out a missing identifier,
+		// give a good message about an identifier being missing, and then consume the
+		// rest of the binary expression.
+		if p.isBinaryOperator() {
+			return true
+		}
+
+		return p.lexer.Token == js_lexer.TIdentifier
+	}
+}
+
+// This function is taken from the official TypeScript compiler source code:
+// https://github.com/microsoft/TypeScript/blob/master/src/compiler/parser.ts
+func (p *parser) isStartOfLeftHandSideExpression() bool {
+	switch p.lexer.Token {
+	case
+		js_lexer.TThis,
+		js_lexer.TSuper,
+		js_lexer.TNull,
+		js_lexer.TTrue,
+		js_lexer.TFalse,
+		js_lexer.TNumericLiteral,
+		js_lexer.TBigIntegerLiteral,
+		js_lexer.TStringLiteral,
+		js_lexer.TNoSubstitutionTemplateLiteral,
+		js_lexer.TTemplateHead,
+		js_lexer.TOpenParen,
+		js_lexer.TOpenBracket,
+		js_lexer.TOpenBrace,
+		js_lexer.TFunction,
+		js_lexer.TClass,
+		js_lexer.TNew,
+		js_lexer.TSlash,
+		js_lexer.TSlashEquals,
+		js_lexer.TIdentifier:
+		return true
+
+	case js_lexer.TImport:
+		// "import(...)" and "import.meta" are expressions but "import x" isn't
+		oldLexer := p.lexer
+		p.lexer.Next()
+		isStart := p.lexer.Token == js_lexer.TOpenParen || p.lexer.Token == js_lexer.TLessThan || p.lexer.Token == js_lexer.TDot
+		p.lexer = oldLexer
+		return isStart
 	}
+
+	return false
 }
 
 func (p *parser) skipTypeScriptInterfaceStmt(opts parseStmtOpts) {
@@ -818,7 +1262,7 @@ func (p *parser) skipTypeScriptInterfaceStmt(opts parseStmtOpts) {
 		p.localTypeNames[name] = true
 	}
 
-	p.skipTypeScriptTypeParameters()
+	p.skipTypeScriptTypeParameters(allowInOutVarianceAnnotations)
 
 	if p.lexer.Token == js_lexer.TExtends {
 		p.lexer.Next()
@@ -858,6 +1302,21 @@ func (p *parser) skipTypeScriptTypeStmt(opts parseStmtOpts) {
 		return
 	}
 
+	if opts.isExport && p.lexer.Token == js_lexer.TAsterisk {
+		// "export type * from 'bar'"
+		// "export type * as foo from 'bar'"
+		p.lexer.Next()
+		if p.lexer.IsContextualKeyword("as") {
+			p.lexer.Next()
+			p.parseClauseAlias("export")
+			p.lexer.Next()
+		}
+		p.lexer.ExpectContextualKeyword("from")
+		p.parsePath()
+		p.lexer.ExpectOrInsertSemicolon()
+		return
+	}
+
 	name := p.lexer.Identifier
 	p.lexer.Expect(js_lexer.TIdentifier)
 
@@ -865,33 +1324,13 @@ func (p *parser) skipTypeScriptTypeStmt(opts parseStmtOpts) {
 		p.localTypeNames[name] = true
 	}
 
-	p.skipTypeScriptTypeParameters()
+	p.skipTypeScriptTypeParameters(allowInOutVarianceAnnotations)
 	p.lexer.Expect(js_lexer.TEquals)
 	p.skipTypeScriptType(js_ast.LLowest)
 	p.lexer.ExpectOrInsertSemicolon()
 }
 
-func (p *parser) parseTypeScriptDecorators() []js_ast.Expr {
-	var tsDecorators []js_ast.Expr
-	if p.options.ts.Parse {
-		for p.lexer.Token == js_lexer.TAt {
-			p.lexer.Next()
-
-			// Parse a new/call expression with "exprFlagTSDecorator" so we ignore
-			// EIndex expressions, since they may be part of a computed property:
-			//
-			//   class Foo {
-			//     @foo ['computed']() {}
-			//   }
-			//
-			// This matches the behavior of the TypeScript compiler.
-			tsDecorators = append(tsDecorators, p.parseExprWithFlags(js_ast.LNew, exprFlagTSDecorator))
-		}
-	}
-	return tsDecorators
-}
-
-func (p *parser) parseTypeScriptEnumStmt(loc logger.Loc, opts parseStmtOpts) js_ast.Stmt {
+func (p *parser) parseTypeScriptEnumStmt(loc logger.Loc, opts parseStmtOpts, isConst bool) js_ast.Stmt {
 	p.lexer.Expect(js_lexer.TEnum)
 	nameLoc := p.lexer.Loc()
 	nameText := p.lexer.Identifier
@@ -910,6 +1349,10 @@ func (p *parser) parseTypeScriptEnumStmt(loc logger.Loc, opts parseStmtOpts) js_
 	}
 
 	// Declare the enum and create the scope
+	existingRef := js_ast.InvalidRef
+	if existing, ok := p.currentScope.Members[nameText]; ok {
+		existingRef = existing.Ref
+	}
 	if !opts.isTypeScriptDeclare {
 		name.Ref = p.declareSymbol(js_ast.SymbolTSEnum, nameLoc, nameText)
 		p.pushScopeForParsePass(js_ast.ScopeEntry, loc)
@@ -1044,11 +1487,23 @@ func (p *parser) parseTypeScriptEnumStmt(loc logger.Loc, opts parseStmtOpts) js_
 
 	p.lexer.Expect(js_lexer.TCloseBrace)
 
+	if !opts.isTypeScriptDeclare {
+		p.recordTSEnumMetadataKind(name.Ref, existingRef, values)
+	}
+
 	if opts.isTypeScriptDeclare {
 		if opts.isNamespaceScope && opts.isExport {
 			p.hasNonLocalExportDeclareInsideNamespace = true
 		}
 
+		// The values of ambient const enums only exist in the type system
+		if isConst && opts.isModuleScope {
+			if p.localAmbientConstEnumNames == nil {
+				p.localAmbientConstEnumNames = make(map[string]bool)
+			}
+			p.localAmbientConstEnumNames[nameText] = true
+		}
+
 		return js_ast.Stmt{Loc: loc, Data: &js_ast.STypeScript{}}
 	}
 
@@ -1057,9 +1512,84 @@ func (p *parser) parseTypeScriptEnumStmt(loc logger.Loc, opts parseStmtOpts) js_
 		Arg:      tsNamespace.ArgRef,
 		Values:   values,
 		IsExport: opts.isExport,
+		IsConst:  isConst,
 	}}
 }
 
+// TypeScript's "emitDecoratorMetadata" setting emits "Number" for enum types
+// with numeric values, "String" for enum types with string values, and
+// "Object" for enum types that mix both. Merged enum declarations are combined.
+func (p *parser) recordTSEnumMetadataKind(ref js_ast.Ref, existingRef js_ast.Ref, values []js_ast.EnumValue) {
+	hasNumber := false
+	hasString := false
+	switch p.tsEnumMetadataKinds[existingRef] {
+	case js_ast.TSMetadataNumber:
+		hasNumber = true
+	case js_ast.TSMetadataString:
+		hasString = true
+	case js_ast.TSMetadataObject:
+		hasNumber = true
+		hasString = true
+	}
+
+	// Identifiers haven't been bound yet, so earlier members are matched by name
+	stringNames := make(map[string]bool)
+	for _, value := range values {
+		if value.ValueOrNil.Data != nil && p.isStringEnumInitializer(value.ValueOrNil, stringNames) {
+			stringNames[js_lexer.UTF16ToString(value.Name)] = true
+			hasString = true
+		} else {
+			hasNumber = true
+		}
+	}
+
+	kind := js_ast.TSMetadataNumber
+	if hasString {
+		kind = js_ast.TSMetadataString
+		if hasNumber {
+			kind = js_ast.TSMetadataObject
+		}
+	}
+	if p.tsEnumMetadataKinds == nil {
+		p.tsEnumMetadataKinds = make(map[js_ast.Ref]js_ast.TSMetadataKind)
+	}
+	p.tsEnumMetadataKinds[ref] = kind
+}
+
+func (p *parser) isStringEnumInitializer(value js_ast.Expr, stringNames map[string]bool) bool {
+	switch e := value.Data.(type) {
+	case *js_ast.EString:
+		return true
+
+	case *js_ast.ETemplate:
+		return e.TagOrNil.Data == nil
+
+	case *js_ast.EIdentifier:
+		return stringNames[p.loadNameFromRef(e.Ref)]
+
+	case *js_ast.EBinary:
+		return e.Op == js_ast.BinOpAdd && (p.isStringEnumInitializer(e.Left, stringNames) || p.isStringEnumInitializer(e.Right, stringNames))
+	}
+	return false
+}
+
+// TypeScript's "isolatedModules" setting forbids accessing ambient const enums
+// because their values can't be known when compiling a single file. esbuild
+// compiles each file independently too, so the generated code references an
+// enum object that doesn't exist at run time.
+func (p *parser) warnAboutAmbientConstEnumAccess(target js_ast.Expr) {
+	if id, ok := target.Data.(*js_ast.EIdentifier); ok {
+		symbol := &p.symbols[id.Ref.InnerIndex]
+		if symbol.Kind == js_ast.SymbolUnbound && p.localAmbientConstEnumNames[symbol.OriginalName] {
+			r := js_lexer.RangeOfIdentifier(p.source, target.Loc)
+			p.log.AddWithNotes(logger.Warning, &p.tracker, r,
+				fmt.Sprintf("Cannot access the ambient const enum %q when \"isolatedModules\" is enabled", symbol.OriginalName),
+				[]logger.MsgData{{Text: fmt.Sprintf("The enum %q was declared using \"declare\", so no code is generated for it "+
+					"and this reference will fail at run time. Remove \"declare\" to generate the enum instead.", symbol.OriginalName)}})
+		}
+	}
+}
+
 // This assumes the caller has already parsed the "import" token
 func (p *parser) parseTypeScriptImportEqualsStmt(loc logger.Loc, opts parseStmtOpts, defaultNameLoc logger.Loc, defaultName string) js_ast.Stmt {
 	p.lexer.Expect(js_lexer.TEquals)
@@ -1394,7 +1924,7 @@ func (p *parser) generateClosureForTypeScriptNamespaceOrEnum(
 		} else {
 			// Nested namespace: "let"
 			stmts = append(stmts, js_ast.Stmt{Loc: stmtLoc, Data: &js_ast.SLocal{
-				Kind:  js_ast.LocalLet,
+				Kind:  p.selectLocalKind(js_ast.LocalLet),
 				Decls: decls,
 			}})
 		}
@@ -1599,3 +2129,145 @@ func (p *parser) wrapInlinedEnum(value js_ast.Expr, comment string) js_ast.Expr
 		Comment: comment,
 	}}
 }
+
+// This generates the runtime value for a type annotation that TypeScript's
+// "emitDecoratorMetadata" setting passes to "Reflect.metadata()". Type names
+// are checked with "typeof" before they are used because they may refer to
+// something that only exists at compile time, such as an interface or a
+// type-only import. The result must be visited before it's used.
+func (p *parser) tsMetadataValue(loc logger.Loc, metadata js_ast.TSMetadata) js_ast.Expr {
+	ident := func(name string) js_ast.Expr {
+		return js_ast.Expr{Loc: loc, Data: &js_ast.EIdentifier{Ref: p.storeNameInRef(name)}}
+	}
+	isUndefined := func(value js_ast.Expr) js_ast.Expr {
+		return js_ast.Expr{Loc: loc, Data: &js_ast.EBinary{
+			Op:    js_ast.BinOpStrictEq,
+			Left:  js_ast.Expr{Loc: loc, Data: &js_ast.EUnary{Op: js_ast.UnOpTypeof, Value: value}},
+			Right: js_ast.Expr{Loc: loc, Data: &js_ast.EString{Value: js_lexer.StringToUTF16("undefined")}},
+		}}
+	}
+
+	switch metadata.Kind {
+	case js_ast.TSMetadataNever, js_ast.TSMetadataVoid, js_ast.TSMetadataUndefined, js_ast.TSMetadataNull:
+		return js_ast.Expr{Loc: loc, Data: &js_ast.EUndefined{}}
+
+	case js_ast.TSMetadataFunction:
+		return ident("Function")
+
+	case js_ast.TSMetadataArray:
+		return ident("Array")
+
+	case js_ast.TSMetadataBoolean:
+		return ident("Boolean")
+
+	case js_ast.TSMetadataString:
+		return ident("String")
+
+	case js_ast.TSMetadataNumber:
+		return ident("Number")
+
+	case js_ast.TSMetadataSymbol:
+		return ident("Symbol")
+
+	case js_ast.TSMetadataBigInt:
+		// "BigInt" may not exist in older environments
+		if !p.options.unsupportedJSFeatures.Has(compat.BigInt) {
+			return ident("BigInt")
+		}
+		return js_ast.Expr{Loc: loc, Data: &js_ast.EIf{
+			Test: isUndefined(ident("BigInt")),
+			Yes:  ident("Object"),
+			No:   ident("BigInt"),
+		}}
+
+	case js_ast.TSMetadataReference:
+		// "ns.Foo" => "typeof ns === 'undefined' || typeof ns.Foo === 'undefined' ? Object : ns.Foo"
+		parts := strings.Split(metadata.Name, ".")
+		path := func(count int) js_ast.Expr {
+			value := ident(parts[0])
+			for _, name := range parts[1:count] {
+				value = js_ast.Expr{Loc: loc, Data: &js_ast.EDot{Target: value, Name: name, NameLoc: loc}}
+			}
+			return value
+		}
+		// Enum types become "Number" or "String" depending on their values
+		if len(parts) == 1 {
+			for s := p.currentScope; s != nil; s = s.Parent {
+				if member, ok := s.Members[parts[0]]; ok {
+					if kind, ok := p.tsEnumMetadataKinds[member.Ref]; ok {
+						return p.tsMetadataValue(loc, js_ast.TSMetadata{Kind: kind})
+					}
+					break
+				}
+			}
+		}
+
+		test := isUndefined(path(1))
+		for i := 2; i <= len(parts); i++ {
+			test = js_ast.Expr{Loc: loc, Data: &js_ast.EBinary{Op: js_ast.BinOpLogicalOr, Left: test, Right: isUndefined(path(i))}}
+		}
+		return js_ast.Expr{Loc: loc, Data: &js_ast.EIf{Test: test, Yes: ident("Object"), No: path(len(parts))}}
+	}
+
+	// Everything else (including a missing type annotation) becomes "Object"
+	return ident("Object")
+}
+
+func (p *parser) tsMetadataCall(loc logger.Loc, key string, value js_ast.Expr) js_ast.Expr {
+	return p.callRuntime(loc, "__metadata", []js_ast.Expr{
+		{Loc: loc, Data: &js_ast.EString{Value: js_lexer.StringToUTF16(key)}},
+		value,
+	})
+}
+
+func (p *parser) tsMetadataParamTypes(loc logger.Loc, args []js_ast.Arg) js_ast.Expr {
+	items := make([]js_ast.Expr, 0, len(args))
+	for _, arg := range args {
+		items = append(items, p.visitExpr(p.tsMetadataValue(arg.Binding.Loc, arg.TSMetadata)))
+	}
+	return p.tsMetadataCall(loc, "design:paramtypes", js_ast.Expr{Loc: loc, Data: &js_ast.EArray{Items: items}})
+}
+
+// These are the decorators that TypeScript's "emitDecoratorMetadata" setting
+// adds to a decorated class member. They must be generated before the member
+// is visited because lowering may change the function.
+func (p *parser) tsDecoratorMetadataForProperty(property *js_ast.Property) (decorators []js_ast.Expr) {
+	loc := property.Key.Loc
+	fn, ok := property.ValueOrNil.Data.(*js_ast.EFunction)
+	if !ok || !property.IsMethod {
+		// "@dec x: Foo" => "__metadata('design:type', Foo)"
+		return []js_ast.Expr{p.tsMetadataCall(loc, "design:type", p.visitExpr(p.tsMetadataValue(loc, property.TSMetadata)))}
+	}
+
+	switch property.Kind {
+	case js_ast.PropertyGet:
+		decorators = append(decorators,
+			p.tsMetadataCall(loc, "design:type", p.visitExpr(p.tsMetadataValue(loc, fn.Fn.ReturnTSMetadata))),
+			p.tsMetadataParamTypes(loc, nil))
+
+	case js_ast.PropertySet:
+		var setterType js_ast.TSMetadata
+		if len(fn.Fn.Args) > 0 {
+			setterType = fn.Fn.Args[0].TSMetadata
+		}
+		decorators = append(decorators,
+			p.tsMetadataCall(loc, "design:type", p.visitExpr(p.tsMetadataValue(loc, setterType))),
+			p.tsMetadataParamTypes(loc, fn.Fn.Args))
+
+	default:
+		// A missing return type is "void 0" unless the method is async
+		returnType := p.tsMetadataValue(loc, fn.Fn.ReturnTSMetadata)
+		if fn.Fn.ReturnTSMetadata.Kind == js_ast.TSMetadataNone {
+			if fn.Fn.IsAsync {
+				returnType = js_ast.Expr{Loc: loc, Data: &js_ast.EIdentifier{Ref: p.storeNameInRef("Promise")}}
+			} else {
+				returnType = js_ast.Expr{Loc: loc, Data: &js_ast.EUndefined{}}
+			}
+		}
+		decorators = append(decorators,
+			p.tsMetadataCall(loc, "design:type", p.visitExpr(js_ast.Expr{Loc: loc, Data: &js_ast.EIdentifier{Ref: p.storeNameInRef("Function")}})),
+			p.tsMetadataParamTypes(loc, fn.Fn.Args),
+			p.tsMetadataCall(loc, "design:returntype", p.visitExpr(returnType)))
+	}
+	return
+}
@@ -69,6 +69,26 @@ func expectPrintedTSNoAmbiguousLessThan(t *testing.T, contents string, expected
 	})
 }
 
+func expectPrintedDecoratorMetadataTS(t *testing.T, contents string, expected string) {
+	t.Helper()
+	expectPrintedCommon(t, contents, expected, config.Options{
+		TS: config.TSOptions{
+			Parse: true,
+		},
+		EmitDecoratorMetadata: config.True,
+	})
+}
+
+func expectParseErrorIsolatedModulesTS(t *testing.T, contents string, expected string) {
+	t.Helper()
+	expectParseErrorCommon(t, contents, expected, config.Options{
+		TS: config.TSOptions{
+			Parse: true,
+		},
+		IsolatedModules: true,
+	})
+}
+
 func expectParseErrorTSX(t *testing.T, contents string, expected string) {
 	t.Helper()
 	expectParseErrorCommon(t, contents, expected, config.Options{
@@ -263,6 +283,15 @@ func TestTSTypes(t *testing.T) {
 	expectParseErrorTS(t, "let x: abstract () => void = Foo", "<stdin>: ERROR: Expected \";\" but found \"(\"\n")
 	expectParseErrorTS(t, "let x: abstract <T>() => Foo<T>", "<stdin>: ERROR: Expected \";\" but found \"(\"\n")
 	expectParseErrorTS(t, "let x: abstract <T extends object>() => Foo<T>", "<stdin>: ERROR: Expected \"?\" but found \">\"\n")
+
+	// TypeScript 4.7
+	expectPrintedTS(t, "type Foo<T> = T extends [infer U extends string] ? U : never", "")
+	expectPrintedTS(t, "type Foo<T> = T extends { a: infer U extends number } ? U : never", "")
+	expectPrintedTS(t, "type Foo<T> = T extends infer U extends string ? U : never", "")
+	expectPrintedTS(t, "type Foo<T> = T extends [infer U extends string ? 1 : 0] ? U : never", "")
+	expectPrintedTS(t, "type Foo<T> = T extends (infer U extends string ? 1 : 0) ? U : never", "")
+	expectPrintedTS(t, "let x: T extends [infer U extends string] ? U : never = y", "let x = y;\n")
+	expectParseErrorTS(t, "type Foo<T> = T extends [infer U extends] ? U : never", "<stdin>: ERROR: Unexpected \"]\"\n")
 }
 
 func TestTSAsCast(t *testing.T) {
@@ -295,6 +324,27 @@ func TestTSAsCast(t *testing.T) {
 	expectParseErrorTS(t, "(x = y as any(z));", "<stdin>: ERROR: Expected \")\" but found \"(\"\n")
 }
 
+func TestTSSatisfies(t *testing.T) {
+	expectPrintedTS(t, "const t1 = { a: 1 } satisfies I1;", "const t1 = { a: 1 };\n")
+	expectPrintedTS(t, "const t2 = { a: 1, b: 1 } satisfies I1;", "const t2 = { a: 1, b: 1 };\n")
+	expectPrintedTS(t, "const t3 = { } satisfies I1;", "const t3 = {};\n")
+	expectPrintedTS(t, "const t4: T1 = { a: 'a' } satisfies T1;", "const t4 = { a: \"a\" };\n")
+	expectPrintedTS(t, "const t5 = (m => m.substring(0)) satisfies T2;", "const t5 = (m) => m.substring(0);\n")
+	expectPrintedTS(t, "const t6 = [1, 2] satisfies [number, number];", "const t6 = [1, 2];\n")
+	expectPrintedTS(t, "let t7 = { a: 'test' } satisfies A;", "let t7 = { a: \"test\" };\n")
+	expectPrintedTS(t, "let t8 = { a: 'test', b: 'test' } satisfies A;", "let t8 = { a: \"test\", b: \"test\" };\n")
+	expectPrintedTS(t, "x = y satisfies Z as W", "x = y;\n")
+	expectPrintedTS(t, "x = y as W satisfies Z", "x = y;\n")
+	expectPrintedTS(t, "x + y satisfies Z", "x + y;\n")
+	expectPrintedTS(t, "x satisfies any\n(y);", "x;\ny;\n")
+	expectPrintedTS(t, "x satisfies any\n`y`;", "x;\n`y`;\n")
+	expectPrintedTS(t, "let satisfies = 1; satisfies satisfies any", "let satisfies = 1;\nsatisfies;\n")
+	expectParseErrorTS(t, "x = y satisfies any `z`;", "<stdin>: ERROR: Expected \";\" but found \"`z`\"\n")
+	expectParseErrorTS(t, "x = y satisfies any(z);", "<stdin>: ERROR: Expected \";\" but found \"(\"\n")
+	expectParseErrorTS(t, "x satisfies any = y;", "<stdin>: ERROR: Expected \";\" but found \"=\"\n")
+	expectParseError(t, "x satisfies y", "<stdin>: ERROR: Expected \";\" but found \"satisfies\"\n")
+}
+
 func TestTSClass(t *testing.T) {
 	expectPrintedTS(t, "export default class Foo {}", "export default class Foo {\n}\n")
 	expectPrintedTS(t, "export default class Foo extends Bar<T> {}", "export default class Foo extends Bar {\n}\n")
@@ -437,6 +487,55 @@ func TestTSClass(t *testing.T) {
 	expectParseErrorTS(t, "class Foo<> {}", "<stdin>: ERROR: Expected identifier but found \">\"\n")
 	expectParseErrorTS(t, "class Foo<,> {}", "<stdin>: ERROR: Expected identifier but found \",\"\n")
 	expectParseErrorTS(t, "class Foo<T><T> {}", "<stdin>: ERROR: Expected \"{\" but found \"<\"\n")
+
+	// Auto-accessors can have types and TypeScript modifiers
+	expectPrintedTS(t, "class Foo { accessor x: number = 1 }", "class Foo {\n  accessor x = 1;\n}\n")
+	expectPrintedTS(t, "class Foo { accessor x?: number }", "class Foo {\n  accessor x;\n}\n")
+	expectPrintedTS(t, "class Foo { accessor x!: number }", "class Foo {\n  accessor x;\n}\n")
+	expectPrintedTS(t, "class Foo { private static accessor x: number }", "class Foo {\n  static accessor x;\n}\n")
+	expectPrintedTS(t, "class Foo { public override accessor x = 1 }", "class Foo {\n  accessor x = 1;\n}\n")
+	expectPrintedTS(t, "class Foo { declare accessor x: number }", "class Foo {\n}\n")
+	expectPrintedTS(t, "abstract class Foo { abstract accessor x: number }", "class Foo {\n}\n")
+}
+
+func TestTSTypeParameterModifiers(t *testing.T) {
+	// TypeScript 4.7
+	expectPrintedTS(t, "class Foo<in T> {}", "class Foo {\n}\n")
+	expectPrintedTS(t, "class Foo<out T> {}", "class Foo {\n}\n")
+	expectPrintedTS(t, "class Foo<in out T> {}", "class Foo {\n}\n")
+	expectPrintedTS(t, "class Foo<in T, out U> {}", "class Foo {\n}\n")
+	expectPrintedTS(t, "let Foo = class <in T, out U> {}", "let Foo = class {\n};\n")
+	expectPrintedTS(t, "interface Foo<in T, out U> {}", "")
+	expectPrintedTS(t, "type Foo<in T> = T", "")
+	expectPrintedTS(t, "type Foo<out T> = T", "")
+	expectPrintedTS(t, "type Foo<in out T extends object = {}> = T", "")
+	expectPrintedTS(t, "type Foo<out> = out", "")
+	expectPrintedTS(t, "type Foo<out, in out> = out", "")
+	expectPrintedTS(t, "type Foo<out extends object> = out", "")
+	expectPrintedTS(t, "type Foo<out = {}> = out", "")
+	expectParseErrorTS(t, "function foo<in T>() {}", "<stdin>: ERROR: The modifier \"in\" is not valid here:\n")
+	expectParseErrorTS(t, "function foo<out T>() {}", "<stdin>: ERROR: The modifier \"out\" is not valid here:\n")
+	expectParseErrorTS(t, "class Foo { foo<in T>() {} }", "<stdin>: ERROR: The modifier \"in\" is not valid here:\n")
+	expectParseErrorTS(t, "type Foo = <out T>() => T", "<stdin>: ERROR: The modifier \"out\" is not valid here:\n")
+	expectParseErrorTS(t, "type Foo = { foo<in T>(): T }", "<stdin>: ERROR: The modifier \"in\" is not valid here:\n")
+	expectParseErrorTS(t, "let foo = <in T>(x: T) => x", "<stdin>: ERROR: Unexpected \"in\"\n")
+
+	// TypeScript 5.0
+	expectPrintedTS(t, "class Foo<const T> {}", "class Foo {\n}\n")
+	expectPrintedTS(t, "class Foo<const in out T> {}", "class Foo {\n}\n")
+	expectPrintedTS(t, "function foo<const T>(x: T) {}", "function foo(x) {\n}\n")
+	expectPrintedTS(t, "let foo = function <const T>(x: T) {}", "let foo = function(x) {\n};\n")
+	expectPrintedTS(t, "let foo = <const T>(x: T) => x", "let foo = (x) => x;\n")
+	expectPrintedTS(t, "let foo = async <const T>(x: T) => x", "let foo = async (x) => x;\n")
+	expectPrintedTS(t, "let foo = { bar<const T>(x: T) {} }", "let foo = { bar(x) {\n} };\n")
+	expectPrintedTS(t, "class Foo { bar<const T>(x: T) {} }", "class Foo {\n  bar(x) {\n  }\n}\n")
+	expectPrintedTS(t, "type Foo = <const T>(x: T) => T", "")
+	expectPrintedTS(t, "type Foo = new <const T>(x: T) => T", "")
+	expectPrintedTS(t, "type Foo = { bar<const T>(x: T): T }", "")
+	expectPrintedTSX(t, "let foo = <const T,>(x: T) => x", "let foo = (x) => x;\n")
+	expectPrintedTSX(t, "let foo = <const T extends object>(x: T) => x", "let foo = (x) => x;\n")
+	expectParseErrorTS(t, "type Foo<const T> = T", "<stdin>: ERROR: The modifier \"const\" is not valid here:\n")
+	expectParseErrorTS(t, "interface Foo<const T> {}", "<stdin>: ERROR: The modifier \"const\" is not valid here:\n")
 }
 
 func TestTSPrivateIdentifiers(t *testing.T) {
@@ -1163,6 +1262,25 @@ y = [0 /* A */, Foo?.["A"], Foo?.["A"]()];
 	expectParseErrorTS(t, "export enum x { yield = 1, y = yield }",
 		"<stdin>: ERROR: \"yield\" is a reserved word and cannot be used in strict mode\n"+
 			"<stdin>: NOTE: This file is implicitly in strict mode because of the \"export\" keyword here:\n")
+
+	// Check that const enums are erased when it's safe to do so
+	expectPrintedTS(t, "const enum a { b, c } x(a.c)", "x(1 /* c */);\n")
+	expectPrintedTS(t, "const enum a { b = 'x' } x(a.b)", "x(\"x\" /* b */);\n")
+	expectPrintedTS(t, "{ const enum a { b = 1 } x(a.b) }", "{\n  x(1 /* b */);\n}\n")
+	expectPrintedTS(t, "namespace ns { const enum a { b = 1 } x(a.b) }", "var ns;\n((ns) => {\n  x(1 /* b */);\n})(ns || (ns = {}));\n")
+	expectPrintedTS(t, "const enum a { b = x }", "var a = ((a) => {\n  a[a[\"b\"] = x] = \"b\";\n  return a;\n})(a || {});\n")
+	expectPrintedTS(t, "export const enum a { b }", "export var a = /* @__PURE__ */ ((a) => {\n  a[a[\"b\"] = 0] = \"b\";\n  return a;\n})(a || {});\n")
+	expectPrintedTS(t, "const enum a { b } export { a }", "var a = /* @__PURE__ */ ((a) => {\n  a[a[\"b\"] = 0] = \"b\";\n  return a;\n})(a || {});\nexport { a };\n")
+	expectPrintedTS(t, "export { a }; const enum a { b }", "export { a };\nvar a = /* @__PURE__ */ ((a) => {\n  a[a[\"b\"] = 0] = \"b\";\n  return a;\n})(a || {});\n")
+	expectPrintedTS(t, "function f() { return a.b } const enum a { b }", "function f() {\n  return a.b;\n}\nvar a = /* @__PURE__ */ ((a) => {\n  a[a[\"b\"] = 0] = \"b\";\n  return a;\n})(a || {});\n")
+
+	// Uses of the enum object after the declaration must also keep the enum
+	expectPrintedTS(t, "const enum a { b = 1 } x(a.b, a)", "var a = /* @__PURE__ */ ((a) => {\n  a[a[\"b\"] = 1] = \"b\";\n  return a;\n})(a || {});\nx(1 /* b */, a);\n")
+	expectPrintedTS(t, "const enum a { b = 1 } function f() { return a }", "var a = /* @__PURE__ */ ((a) => {\n  a[a[\"b\"] = 1] = \"b\";\n  return a;\n})(a || {});\nfunction f() {\n  return a;\n}\n")
+	expectPrintedTS(t, "{ const enum a { b = 1 } x(a.b); y(a) }", "{\n  let a;\n  ((a) => {\n    a[a[\"b\"] = 1] = \"b\";\n  })(a || (a = {}));\n  x(1 /* b */);\n  y(a);\n}\n")
+	expectPrintedTS(t, "const enum a { b = 1 } const enum c { d = 2 } x(a.b, c.d)", "x(1 /* b */, 2 /* d */);\n")
+	expectPrintedTS(t, "switch (x) { case 0: const enum a { b = 1 } break; case 1: y(a) }",
+		"switch (x) {\n  case 0:\n    let a;\n    ((a) => {\n      a[a[\"b\"] = 1] = \"b\";\n    })(a || (a = {}));\n    break;\n  case 1:\n    y(a);\n}\n")
 }
 
 func TestTSEnumConstantFolding(t *testing.T) {
@@ -1415,6 +1533,33 @@ func TestTSDecorator(t *testing.T) {
 	expectParseErrorTS(t, "class Foo { @dec public constructor() {} }", "<stdin>: ERROR: TypeScript does not allow decorators on class constructors\n")
 }
 
+func TestTSDecoratorMetadata(t *testing.T) {
+	expectPrintedDecoratorMetadataTS(t, "@dec class Foo { constructor(x: number, y: Bar) {} }",
+		"let Foo = class {\n  constructor(x, y) {\n  }\n};\nFoo = __decorateClass([\n  dec,\n  __metadata(\"design:paramtypes\", [\n    Number,\n    typeof Bar === \"undefined\" ? Object : Bar\n  ])\n], Foo);\n")
+	expectPrintedDecoratorMetadataTS(t, "class Foo { constructor(@dec x: number) {} }",
+		"let Foo = class {\n  constructor(x) {\n  }\n};\nFoo = __decorateClass([\n  __decorateParam(0, dec),\n  __metadata(\"design:paramtypes\", [\n    Number\n  ])\n], Foo);\n")
+	expectPrintedDecoratorMetadataTS(t, "@dec class Foo {}",
+		"let Foo = class {\n};\nFoo = __decorateClass([\n  dec\n], Foo);\n")
+	expectPrintedDecoratorMetadataTS(t, "class Foo { @dec x: string; @dec y: a.b.C; @dec z: 1 | 2; @dec w: string | null; @dec v: string | number; @dec u }",
+		"class Foo {\n}\n__decorateClass([\n  dec,\n  __metadata(\"design:type\", String)\n], Foo.prototype, \"x\", 2);\n__decorateClass([\n  dec,\n  __metadata(\"design:type\", typeof a === \"undefined\" || typeof a.b === \"undefined\" || typeof a.b.C === \"undefined\" ? Object : a.b.C)\n], Foo.prototype, \"y\", 2);\n__decorateClass([\n  dec,\n  __metadata(\"design:type\", Number)\n], Foo.prototype, \"z\", 2);\n__decorateClass([\n  dec,\n  __metadata(\"design:type\", String)\n], Foo.prototype, \"w\", 2);\n__decorateClass([\n  dec,\n  __metadata(\"design:type\", Object)\n], Foo.prototype, \"v\", 2);\n__decorateClass([\n  dec,\n  __metadata(\"design:type\", Object)\n], Foo.prototype, \"u\", 2);\n")
+	expectPrintedDecoratorMetadataTS(t, "class Foo { @dec x: void; @dec y: never; @dec z: unknown; @dec w: symbol; @dec u: T[]; @dec t: [T]; @dec s: `x`; @dec r: () => void; @dec q: bigint }",
+		"class Foo {\n}\n__decorateClass([\n  dec,\n  __metadata(\"design:type\", void 0)\n], Foo.prototype, \"x\", 2);\n__decorateClass([\n  dec,\n  __metadata(\"design:type\", void 0)\n], Foo.prototype, \"y\", 2);\n__decorateClass([\n  dec,\n  __metadata(\"design:type\", Object)\n], Foo.prototype, \"z\", 2);\n__decorateClass([\n  dec,\n  __metadata(\"design:type\", Symbol)\n], Foo.prototype, \"w\", 2);\n__decorateClass([\n  dec,\n  __metadata(\"design:type\", Array)\n], Foo.prototype, \"u\", 2);\n__decorateClass([\n  dec,\n  __metadata(\"design:type\", Array)\n], Foo.prototype, \"t\", 2);\n__decorateClass([\n  dec,\n  __metadata(\"design:type\", String)\n], Foo.prototype, \"s\", 2);\n__decorateClass([\n  dec,\n  __metadata(\"design:type\", Function)\n], Foo.prototype, \"r\", 2);\n__decorateClass([\n  dec,\n  __metadata(\"design:type\", BigInt)\n], Foo.prototype, \"q\", 2);\n")
+	expectPrintedDecoratorMetadataTS(t, "class Foo { @dec foo(x: string): number {} @dec async bar() {} @dec baz() {} }",
+		"class Foo {\n  foo(x) {\n  }\n  async bar() {\n  }\n  baz() {\n  }\n}\n__decorateClass([\n  dec,\n  __metadata(\"design:type\", Function),\n  __metadata(\"design:paramtypes\", [\n    String\n  ]),\n  __metadata(\"design:returntype\", Number)\n], Foo.prototype, \"foo\", 1);\n__decorateClass([\n  dec,\n  __metadata(\"design:type\", Function),\n  __metadata(\"design:paramtypes\", []),\n  __metadata(\"design:returntype\", Promise)\n], Foo.prototype, \"bar\", 1);\n__decorateClass([\n  dec,\n  __metadata(\"design:type\", Function),\n  __metadata(\"design:paramtypes\", []),\n  __metadata(\"design:returntype\", void 0)\n], Foo.prototype, \"baz\", 1);\n")
+	expectPrintedDecoratorMetadataTS(t, "class Foo { @dec get foo(): string {} @dec set bar(x: number) {} }",
+		"class Foo {\n  get foo() {\n  }\n  set bar(x) {\n  }\n}\n__decorateClass([\n  dec,\n  __metadata(\"design:type\", String),\n  __metadata(\"design:paramtypes\", [])\n], Foo.prototype, \"foo\", 1);\n__decorateClass([\n  dec,\n  __metadata(\"design:type\", Number),\n  __metadata(\"design:paramtypes\", [\n    Number\n  ])\n], Foo.prototype, \"bar\", 1);\n")
+	expectPrintedDecoratorMetadataTS(t, "class Foo { foo(@dec x: string, y) {} }",
+		"class Foo {\n  foo(x, y) {\n  }\n}\n__decorateClass([\n  __decorateParam(0, dec),\n  __metadata(\"design:type\", Function),\n  __metadata(\"design:paramtypes\", [\n    String,\n    Object\n  ]),\n  __metadata(\"design:returntype\", void 0)\n], Foo.prototype, \"foo\", 1);\n")
+	expectPrintedDecoratorMetadataTS(t, "class Foo { foo(x: string) {} }",
+		"class Foo {\n  foo(x) {\n  }\n}\n")
+
+	// Enum types depend on the values of the enum members
+	expectPrintedDecoratorMetadataTS(t, "class Foo { foo(@dec a: N, b: S, c: M, d: E) {} } enum N { A, B = 2 } enum S { A = 'a', B = A + 'b' } enum M { A, B = 'b' } enum E {}",
+		"class Foo {\n  foo(a, b, c, d) {\n  }\n}\n__decorateClass([\n  __decorateParam(0, dec),\n  __metadata(\"design:type\", Function),\n  __metadata(\"design:paramtypes\", [\n    Number,\n    String,\n    Object,\n    Number\n  ]),\n  __metadata(\"design:returntype\", void 0)\n], Foo.prototype, \"foo\", 1);\nvar N = /* @__PURE__ */ ((N) => {\n  N[N[\"A\"] = 0] = \"A\";\n  N[N[\"B\"] = 2] = \"B\";\n  return N;\n})(N || {});\nvar S = /* @__PURE__ */ ((S) => {\n  S[\"A\"] = \"a\";\n  S[\"B\"] = \"ab\";\n  return S;\n})(S || {});\nvar M = /* @__PURE__ */ ((M) => {\n  M[M[\"A\"] = 0] = \"A\";\n  M[\"B\"] = \"b\";\n  return M;\n})(M || {});\nvar E = /* @__PURE__ */ ((E) => {\n})(E || {});\n")
+	expectPrintedDecoratorMetadataTS(t, "enum S { A = 'a' } enum S { B = 'b' } class Foo { @dec x: S; @dec y: ns.S }",
+		"var S = /* @__PURE__ */ ((S) => {\n  S[\"A\"] = \"a\";\n  return S;\n})(S || {});\nvar S = /* @__PURE__ */ ((S) => {\n  S[\"B\"] = \"b\";\n  return S;\n})(S || {});\nclass Foo {\n}\n__decorateClass([\n  dec,\n  __metadata(\"design:type\", String)\n], Foo.prototype, \"x\", 2);\n__decorateClass([\n  dec,\n  __metadata(\"design:type\", typeof ns === \"undefined\" || typeof ns.S === \"undefined\" ? Object : ns.S)\n], Foo.prototype, \"y\", 2);\n")
+}
+
 func TestTSTry(t *testing.T) {
 	expectPrintedTS(t, "try {} catch (x: any) {}", "try {\n} catch (x) {\n}\n")
 	expectPrintedTS(t, "try {} catch (x: unknown) {}", "try {\n} catch (x) {\n}\n")
@@ -1455,6 +1600,12 @@ func TestTSArrow(t *testing.T) {
 	expectPrintedTS(t, "(foo) ? (foo as Bar) : null;", "foo ? foo : null;\n")
 	expectPrintedTS(t, "((foo) ? (foo as Bar) : null)", "foo ? foo : null;\n")
 	expectPrintedTS(t, "let x = a ? (b, c) : (d, e)", "let x = a ? (b, c) : (d, e);\n")
+	expectPrintedTS(t, "let x = a ? (b) : c => d", "let x = a ? b : (c) => d;\n")
+	expectPrintedTS(t, "let x = a ? (b) : c => d ? e : f", "let x = a ? b : (c) => d ? e : f;\n")
+	expectPrintedTS(t, "let x = a ? (b): c => d : e", "let x = a ? (b) => d : e;\n")
+	expectPrintedTS(t, "let x = a ? async (b): c => d : e", "let x = a ? async (b) => d : e;\n")
+	expectPrintedTS(t, "let x = a ? (b): c => { return d } : e", "let x = a ? (b) => {\n  return d;\n} : e;\n")
+	expectPrintedTS(t, "let x = a ? ((b): c => d) : e", "let x = a ? (b) => d : e;\n")
 
 	expectPrintedTS(t, "let x: () => void = () => {}", "let x = () => {\n};\n")
 	expectPrintedTS(t, "let x: (y) => void = () => {}", "let x = () => {\n};\n")
@@ -1533,6 +1684,42 @@ func TestTSNew(t *testing.T) {
 	expectParseError(t, "new Foo!()", "<stdin>: ERROR: Unexpected \"!\"\n")
 }
 
+func TestTSInstantiationExpression(t *testing.T) {
+	expectPrintedTS(t, "f<number>", "f;\n")
+	expectPrintedTS(t, "f<number, boolean>", "f;\n")
+	expectPrintedTS(t, "f<number>;", "f;\n")
+	expectPrintedTS(t, "const ErrorMap = Map<string, Error>", "const ErrorMap = Map;\n")
+	expectPrintedTS(t, "const a = f<number>, b = g<string>", "const a = f, b = g;\n")
+	expectPrintedTS(t, "f<number>\ng()", "f;\ng();\n")
+	expectPrintedTS(t, "f<number>.g", "f.g;\n")
+	expectPrintedTS(t, "f<number>?.()", "f?.();\n")
+	expectPrintedTS(t, "f<number>?.[x]", "f?.[x];\n")
+	expectPrintedTS(t, "new A<number>", "new A();\n")
+	expectPrintedTS(t, "(f<number>)", "f;\n")
+	expectPrintedTS(t, "[f<number>]", "[f];\n")
+	expectPrintedTS(t, "f<number> == g", "f == g;\n")
+	expectPrintedTS(t, "f<number> && g", "f && g;\n")
+	expectPrintedTS(t, "f<number> as any", "f;\n")
+	expectPrintedTS(t, "f<number> satisfies any", "f;\n")
+	expectPrintedTS(t, "f<number> instanceof g", "f instanceof g;\n")
+	expectPrintedTS(t, "f<number> in g", "f in g;\n")
+	expectPrintedTS(t, "for (f<number> in g) ;", "for (f in g)\n  ;\n")
+
+	// These are still relational expressions
+	expectPrintedTS(t, "a<b>c", "a < b > c;\n")
+	expectPrintedTS(t, "a<b>(c)", "a(c);\n")
+	expectPrintedTS(t, "a<b> + c", "a < b > +c;\n")
+	expectPrintedTS(t, "a<b> - c", "a < b > -c;\n")
+	expectPrintedTS(t, "a<b>>c", "a < b >> c;\n")
+	expectPrintedTS(t, "a<b>!c", "a < b > !c;\n")
+	expectPrintedTS(t, "a<b>[c]", "a < b > [c];\n")
+	expectPrintedTS(t, "a<b>{}", "a < b > {};\n")
+	expectPrintedTS(t, "a<b>function(){}", "a < b > function() {\n};\n")
+	expectPrintedTS(t, "let r = x < y >= z", "let r = x < y >= z;\n")
+	expectPrintedTS(t, "a<b>=c", "a < b >= c;\n")
+	expectPrintedTS(t, "a<b> = c", "a = c;\n")
+}
+
 func TestTSExponentiation(t *testing.T) {
 	// More info: https://github.com/microsoft/TypeScript/issues/41755
 	expectParseErrorTS(t, "await x! ** 2", "<stdin>: ERROR: Unexpected \"**\"\n")
@@ -1704,6 +1891,11 @@ func TestTSTypeOnlyExport(t *testing.T) {
 	expectPrintedTS(t, "export type {foo} from 'bar'; x", "x;\n")
 	expectPrintedTS(t, "export type {foo} from 'bar'\nx", "x;\n")
 	expectPrintedTS(t, "export type {default} from 'bar'", "")
+	expectPrintedTS(t, "export type * from 'bar'", "")
+	expectPrintedTS(t, "export type * as foo from 'bar'; foo", "foo;\n")
+	expectPrintedTS(t, "export type * as 'foo' from 'bar'", "")
+	expectParseErrorTS(t, "export type * as foo", "<stdin>: ERROR: Expected \"from\" but found end of file\n")
+	expectParseErrorTS(t, "export type *", "<stdin>: ERROR: Expected \"from\" but found end of file\n")
 	expectParseErrorTS(t, "export type {default}", "<stdin>: ERROR: Expected identifier but found \"default\"\n")
 
 	expectPrintedTS(t, "export { type } from 'mod'; type", "export { type } from \"mod\";\ntype;\n")
@@ -1864,6 +2056,30 @@ func TestTSNoAmbiguousLessThan(t *testing.T) {
 			"<stdin>: ERROR: Unexpected \"=\"\n")
 }
 
+func TestTSIsolatedModules(t *testing.T) {
+	// Exporting a type declared in the same file is allowed, since the compiler
+	// can see that it's a type. Re-exported imports are checked by the linker.
+	expectParseErrorIsolatedModulesTS(t, "type Foo = {}; export {Foo}", "")
+	expectParseErrorIsolatedModulesTS(t, "interface Foo {} export {Foo as Bar}", "")
+	expectParseErrorIsolatedModulesTS(t, "type Foo = {}; export type {Foo}", "")
+	expectParseErrorIsolatedModulesTS(t, "type Foo = {}; export {type Foo}", "")
+	expectParseErrorIsolatedModulesTS(t, "class Foo {} export {Foo}", "")
+	expectParseErrorIsolatedModulesTS(t, "export {Foo}", "")
+	expectParseErrorTS(t, "type Foo = {}; export {Foo}", "")
+
+	enumAccessWarning := "<stdin>: WARNING: Cannot access the ambient const enum \"Foo\" when \"isolatedModules\" is enabled\n" +
+		"NOTE: The enum \"Foo\" was declared using \"declare\", so no code is generated for it and this reference will fail at run time. " +
+		"Remove \"declare\" to generate the enum instead.\n"
+	expectParseErrorIsolatedModulesTS(t, "declare const enum Foo { A } Foo.A", enumAccessWarning)
+	expectParseErrorIsolatedModulesTS(t, "declare const enum Foo { A } Foo['A']", enumAccessWarning)
+	expectParseErrorIsolatedModulesTS(t, "export declare const enum Foo { A } Foo.A", enumAccessWarning)
+	expectParseErrorIsolatedModulesTS(t, "declare const enum Foo { A } let x: Foo.A", "")
+	expectParseErrorIsolatedModulesTS(t, "declare const enum Foo { A } function f(Foo) { Foo.A }", "")
+	expectParseErrorIsolatedModulesTS(t, "declare enum Foo { A } Foo.A", "")
+	expectParseErrorIsolatedModulesTS(t, "const enum Foo { A } Foo.A", "")
+	expectParseErrorTS(t, "declare const enum Foo { A } Foo.A", "")
+}
+
 func TestClassSideEffectOrder(t *testing.T) {
 	// The order of computed property side effects must not change
 	expectPrintedTS(t, `class Foo {
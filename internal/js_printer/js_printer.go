@@ -844,6 +844,43 @@ func (p *printer) printClass(class js_ast.Class) {
 	p.print("}")
 }
 
+// Decorators that don't match the restricted decorator grammar must be wrapped
+// in parentheses. Only a chain of property accesses that is optionally followed
+// by a single call can be printed without them.
+func isDecoratorMemberExpression(expr js_ast.Expr) bool {
+	switch e := expr.Data.(type) {
+	case *js_ast.EIdentifier:
+		return true
+
+	case *js_ast.EDot:
+		return e.OptionalChain == js_ast.OptionalChainNone && isDecoratorMemberExpression(e.Target)
+
+	case *js_ast.EIndex:
+		if _, ok := e.Index.Data.(*js_ast.EPrivateIdentifier); ok && e.OptionalChain == js_ast.OptionalChainNone {
+			return isDecoratorMemberExpression(e.Target)
+		}
+	}
+	return false
+}
+
+func (p *printer) printDecorators(decorators []js_ast.Expr) {
+	for _, decorator := range decorators {
+		p.print("@")
+		wrap := !isDecoratorMemberExpression(decorator)
+		if call, ok := decorator.Data.(*js_ast.ECall); ok && call.OptionalChain == js_ast.OptionalChainNone && isDecoratorMemberExpression(call.Target) {
+			wrap = false
+		}
+		if wrap {
+			p.print("(")
+			p.printExpr(decorator, js_ast.LLowest, 0)
+			p.print(")")
+		} else {
+			p.printExpr(decorator, js_ast.LPostfix, 0)
+		}
+		p.print(" ")
+	}
+}
+
 func (p *printer) printProperty(item js_ast.Property) {
 	if item.Kind == js_ast.PropertySpread {
 		p.print("...")
@@ -851,12 +888,19 @@ func (p *printer) printProperty(item js_ast.Property) {
 		return
 	}
 
+	p.printDecorators(item.Decorators)
+
 	if item.IsStatic {
 		p.print("static")
 		p.printSpace()
 	}
 
 	switch item.Kind {
+	case js_ast.PropertyAutoAccessor:
+		p.printSpaceBeforeIdentifier()
+		p.print("accessor")
+		p.printSpace()
+
 	case js_ast.PropertyGet:
 		p.printSpaceBeforeIdentifier()
 		p.print("get")
@@ -1102,7 +1146,7 @@ func (p *printer) printRequireOrImportExpr(
 		p.addSourceMapping(record.Range.Loc)
 		p.printQuotedUTF8(record.Path.Text, true /* allowBacktick */)
 		if !p.options.UnsupportedFeatures.Has(compat.DynamicImport) {
-			p.printImportCallAssertions(record.Assertions)
+			p.printImportCallAssertOrWith(record.AssertOrWith)
 		}
 		if len(leadingInteriorComments) > 0 {
 			p.printNewline()
@@ -1209,6 +1253,31 @@ const (
 	isInsideForAwait
 )
 
+func (p *printer) tryToGetImportedEnumValue(target js_ast.Expr, name string) (js_ast.TSEnumValue, bool) {
+	if id, ok := target.Data.(*js_ast.EImportIdentifier); ok {
+		if enum, ok := p.options.TSEnums[js_ast.FollowSymbols(p.symbols, id.Ref)]; ok {
+			value, ok := enum[name]
+			return value, ok
+		}
+	}
+	return js_ast.TSEnumValue{}, false
+}
+
+func (p *printer) printInlinedEnum(value js_ast.TSEnumValue, name string, level js_ast.L, flags printExprFlags) {
+	var expr js_ast.Expr
+	if value.String != nil {
+		expr.Data = &js_ast.EString{Value: value.String}
+	} else {
+		expr.Data = &js_ast.ENumber{Value: value.Number}
+	}
+
+	// Match the parser, which omits the comment when minifying
+	if !p.options.MangleSyntax && !strings.Contains(name, "*/") {
+		expr.Data = &js_ast.EInlinedEnum{Value: expr, Comment: name}
+	}
+	p.printExpr(expr, level, flags)
+}
+
 func (p *printer) printUndefined(level js_ast.L) {
 	if level >= js_ast.LPrefix {
 		p.print("(void 0)")
@@ -1502,6 +1571,14 @@ func (p *printer) printExpr(expr js_ast.Expr, level js_ast.L, flags printExprFla
 		}
 
 	case *js_ast.EDot:
+		// Inline cross-module TypeScript enum references here
+		if e.CanBeInlinedIfEnum {
+			if value, ok := p.tryToGetImportedEnumValue(e.Target, e.Name); ok {
+				p.printInlinedEnum(value, e.Name, level, flags)
+				return
+			}
+		}
+
 		wrap := false
 		if e.OptionalChain == js_ast.OptionalChainNone {
 			flags |= hasNonOptionalChainParent
@@ -1539,6 +1616,17 @@ func (p *printer) printExpr(expr js_ast.Expr, level js_ast.L, flags printExprFla
 		}
 
 	case *js_ast.EIndex:
+		// Inline cross-module TypeScript enum references here
+		if e.CanBeInlinedIfEnum {
+			if index, ok := e.Index.Data.(*js_ast.EString); ok {
+				name := js_lexer.UTF16ToString(index.Value)
+				if value, ok := p.tryToGetImportedEnumValue(e.Target, name); ok {
+					p.printInlinedEnum(value, name, level, flags)
+					return
+				}
+			}
+		}
+
 		wrap := false
 		if e.OptionalChain == js_ast.OptionalChainNone {
 			flags |= hasNonOptionalChainParent
@@ -1647,6 +1735,7 @@ func (p *printer) printExpr(expr js_ast.Expr, level js_ast.L, flags printExprFla
 		if wrap {
 			p.print("(")
 		}
+		p.printDecorators(e.Class.Decorators)
 		p.printSpaceBeforeIdentifier()
 		p.print("class")
 		if e.Class.Name != nil {
@@ -2287,6 +2376,10 @@ func (p *printer) printForLoopInit(init js_ast.Stmt, flags printExprFlags) {
 			p.printDecls("let", s.Decls, flags)
 		case js_ast.LocalConst:
 			p.printDecls("const", s.Decls, flags)
+		case js_ast.LocalUsing:
+			p.printDecls("using", s.Decls, flags)
+		case js_ast.LocalAwaitUsing:
+			p.printDecls("await using", s.Decls, flags)
 		}
 	default:
 		panic("Internal error")
@@ -2469,42 +2562,51 @@ func (p *printer) printPath(importRecordIndex uint32) {
 	record := p.importRecords[importRecordIndex]
 	p.printQuotedUTF8(record.Path.Text, false /* allowBacktick */)
 
-	// Just omit import assertions if they aren't supported
-	if p.options.UnsupportedFeatures.Has(compat.ImportAssertions) {
-		return
-	}
-
-	if record.Assertions != nil {
+	if keyword, ok := p.importAssertOrWithKeyword(record.AssertOrWith); ok {
 		p.printSpace()
-		p.print("assert")
+		p.print(keyword)
 		p.printSpace()
-		p.printImportAssertionsClause(*record.Assertions)
+		p.printImportAssertOrWithClause(record.AssertOrWith.Entries)
 	}
 }
 
-func (p *printer) printImportCallAssertions(assertions *[]ast.AssertEntry) {
-	// Just omit import assertions if they aren't supported
-	if p.options.UnsupportedFeatures.Has(compat.ImportAssertions) {
-		return
-	}
-
-	if assertions != nil {
+func (p *printer) printImportCallAssertOrWith(assertOrWith *ast.ImportAssertOrWith) {
+	if keyword, ok := p.importAssertOrWithKeyword(assertOrWith); ok {
 		p.print(",")
 		p.printSpace()
 		p.print("{")
 		p.printSpace()
-		p.print("assert:")
+		p.print(keyword)
+		p.print(":")
 		p.printSpace()
-		p.printImportAssertionsClause(*assertions)
+		p.printImportAssertOrWithClause(assertOrWith.Entries)
 		p.printSpace()
 		p.print("}")
 	}
 }
 
-func (p *printer) printImportAssertionsClause(assertions []ast.AssertEntry) {
+// The keyword from the original code is kept if the target supports it.
+// Otherwise the other keyword is used if the target supports that one instead,
+// and the clause is just omitted if neither syntax is supported.
+func (p *printer) importAssertOrWithKeyword(assertOrWith *ast.ImportAssertOrWith) (string, bool) {
+	if assertOrWith == nil {
+		return "", false
+	}
+	hasWith := !p.options.UnsupportedFeatures.Has(compat.ImportAttributes)
+	hasAssert := !p.options.UnsupportedFeatures.Has(compat.ImportAssertions)
+	if hasWith && (assertOrWith.Keyword == ast.WithKeyword || !hasAssert) {
+		return "with", true
+	}
+	if hasAssert {
+		return "assert", true
+	}
+	return "", false
+}
+
+func (p *printer) printImportAssertOrWithClause(entries []ast.AssertOrWithEntry) {
 	p.print("{")
 
-	for i, entry := range assertions {
+	for i, entry := range entries {
 		if i > 0 {
 			p.print(",")
 		}
@@ -2525,7 +2627,7 @@ func (p *printer) printImportAssertionsClause(assertions []ast.AssertEntry) {
 		p.printQuotedUTF16(entry.Value, false /* allowBacktick */)
 	}
 
-	if len(assertions) > 0 {
+	if len(entries) > 0 {
 		p.printSpace()
 	}
 	p.print("}")
@@ -2580,6 +2682,7 @@ func (p *printer) printStmt(stmt js_ast.Stmt) {
 		if s.IsExport {
 			p.print("export ")
 		}
+		p.printDecorators(s.Class.Decorators)
 		p.print("class")
 		p.printSymbol(s.Class.Name.Ref)
 		p.printClass(s.Class)
@@ -2622,6 +2725,7 @@ func (p *printer) printStmt(stmt js_ast.Stmt) {
 			p.printNewline()
 
 		case *js_ast.SClass:
+			p.printDecorators(s2.Class.Decorators)
 			p.printSpaceBeforeIdentifier()
 			p.print("class")
 			if s2.Class.Name != nil {
@@ -2752,6 +2856,10 @@ func (p *printer) printStmt(stmt js_ast.Stmt) {
 			p.printDeclStmt(s.IsExport, "let", s.Decls)
 		case js_ast.LocalVar:
 			p.printDeclStmt(s.IsExport, "var", s.Decls)
+		case js_ast.LocalUsing:
+			p.printDeclStmt(s.IsExport, "using", s.Decls)
+		case js_ast.LocalAwaitUsing:
+			p.printDeclStmt(s.IsExport, "await using", s.Decls)
 		}
 
 	case *js_ast.SIf:
@@ -3106,6 +3214,10 @@ type Options struct {
 	UnsupportedFeatures          compat.JSFeature
 	RequireOrImportMetaForSource func(uint32) RequireOrImportMeta
 
+	// Property accesses off of imported TypeScript enums are replaced with the
+	// constant value of the enum member if it's present in this map
+	TSEnums map[js_ast.Ref]map[string]js_ast.TSEnumValue
+
 	// If we're writing out a source map, this table of line start indices lets
 	// us do binary search on to figure out what line a given AST node came from
 	LineOffsetTables []sourcemap.LineOffsetTable
@@ -231,9 +231,61 @@ type Path struct {
 	// the output. This is supported by other bundlers, so we also support this.
 	IgnoredSuffix string
 
+	// Files imported with different import attributes are different modules.
+	// For example, the same file can be imported both as JavaScript and as JSON.
+	ImportAttributes ImportAttributes
+
 	Flags PathFlags
 }
 
+// This is stored as a single string instead of a map so that paths remain
+// comparable and can still be used as map keys
+type ImportAttributes struct {
+	packedData string
+}
+
+type ImportAttribute struct {
+	Key   string
+	Value string
+}
+
+// The attributes are sorted by key so that the order they were written in
+// doesn't matter
+func EncodeImportAttributes(value map[string]string) ImportAttributes {
+	if len(value) == 0 {
+		return ImportAttributes{}
+	}
+	keys := make([]string, 0, len(value))
+	for key := range value {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	var sb strings.Builder
+	for _, key := range keys {
+		sb.WriteString(key)
+		sb.WriteByte(0)
+		sb.WriteString(value[key])
+		sb.WriteByte(0)
+	}
+	return ImportAttributes{packedData: sb.String()}
+}
+
+func (attrs ImportAttributes) DecodeIntoArray() (result []ImportAttribute) {
+	parts := strings.Split(attrs.packedData, "\x00")
+	for i := 0; i+1 < len(parts); i += 2 {
+		result = append(result, ImportAttribute{Key: parts[i], Value: parts[i+1]})
+	}
+	return
+}
+
+func (attrs ImportAttributes) DecodeIntoMap() map[string]string {
+	result := make(map[string]string)
+	for _, attr := range attrs.DecodeIntoArray() {
+		result[attr.Key] = attr.Value
+	}
+	return result
+}
+
 type PathFlags uint8
 
 const (
@@ -249,7 +301,8 @@ func (a Path) ComesBeforeInSortedOrder(b Path) bool {
 	return a.Namespace > b.Namespace ||
 		(a.Namespace == b.Namespace && (a.Text < b.Text ||
 			(a.Text == b.Text && (a.Flags < b.Flags ||
-				(a.Flags == b.Flags && a.IgnoredSuffix < b.IgnoredSuffix)))))
+				(a.Flags == b.Flags && (a.IgnoredSuffix < b.IgnoredSuffix ||
+					(a.IgnoredSuffix == b.IgnoredSuffix && a.ImportAttributes.packedData < b.ImportAttributes.packedData)))))))
 }
 
 var noColorResult bool
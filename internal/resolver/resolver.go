@@ -118,9 +118,21 @@ type ResolveResult struct {
 	// If true, the class field transform should use Object.defineProperty().
 	UseDefineForClassFieldsTS config.MaybeBool
 
-	// This is the "importsNotUsedAsValues" and "preserveValueImports" fields from "package.json"
+	// If true, decorators use TypeScript's legacy "experimentalDecorators"
+	// semantics instead of the standard JavaScript semantics.
+	ExperimentalDecoratorsTS config.MaybeBool
+
+	// If true, legacy TypeScript decorators also receive type metadata
+	EmitDecoratorMetadataTS config.MaybeBool
+
+	// This is the "importsNotUsedAsValues", "preserveValueImports", and
+	// "verbatimModuleSyntax" fields from "tsconfig.json"
 	UnusedImportsTS config.UnusedImportsTS
 
+	// This is true if "isolatedModules" or "verbatimModuleSyntax" is enabled
+	// in "tsconfig.json"
+	IsolatedModulesTS bool
+
 	// This is the "type" field from "package.json"
 	ModuleType config.ModuleType
 }
@@ -221,7 +233,7 @@ func NewResolver(fs fs.FS, log logger.Log, caches *cache.CacheSet, options confi
 	// Filter out non-CSS extensions for CSS "@import" imports
 	atImportExtensionOrder := make([]string, 0, len(options.ExtensionOrder))
 	for _, ext := range options.ExtensionOrder {
-		if loader, ok := options.ExtensionToLoader[ext]; ok && loader != config.LoaderCSS {
+		if loader, ok := options.ExtensionToLoader[ext]; ok && !loader.IsCSS() {
 			continue
 		}
 		atImportExtensionOrder = append(atImportExtensionOrder, ext)
@@ -560,10 +572,15 @@ func (r resolverQuery) finalizeResolve(result *ResolveResult) {
 						result.JSXFactory = dirInfo.enclosingTSConfigJSON.JSXFactory
 						result.JSXFragment = dirInfo.enclosingTSConfigJSON.JSXFragmentFactory
 						result.UseDefineForClassFieldsTS = dirInfo.enclosingTSConfigJSON.UseDefineForClassFields
+						result.ExperimentalDecoratorsTS = dirInfo.enclosingTSConfigJSON.ExperimentalDecorators
+						result.EmitDecoratorMetadataTS = dirInfo.enclosingTSConfigJSON.EmitDecoratorMetadata
 						result.UnusedImportsTS = config.UnusedImportsFromTsconfigValues(
 							dirInfo.enclosingTSConfigJSON.PreserveImportsNotUsedAsValues,
 							dirInfo.enclosingTSConfigJSON.PreserveValueImports,
+							dirInfo.enclosingTSConfigJSON.VerbatimModuleSyntax,
 						)
+						result.IsolatedModulesTS = dirInfo.enclosingTSConfigJSON.IsolatedModules ||
+							dirInfo.enclosingTSConfigJSON.VerbatimModuleSyntax
 						result.TSTarget = dirInfo.enclosingTSConfigJSON.TSTarget
 
 						if r.debugLogs != nil {
@@ -658,10 +675,11 @@ func (r resolverQuery) resolveWithoutSymlinks(sourceDir string, importPath strin
 		}
 	}
 
-	// Check both relative and package paths for CSS URL tokens, with relative
-	// paths taking precedence over package paths to match Webpack behavior.
+	// Check both relative and package paths for CSS URL tokens and HTML attributes,
+	// with relative paths taking precedence over package paths to match Webpack
+	// behavior.
 	isPackagePath := IsPackagePath(importPath)
-	checkRelative := !isPackagePath || r.kind == ast.ImportURL || r.kind == ast.ImportAt
+	checkRelative := !isPackagePath || r.kind == ast.ImportURL || r.kind == ast.ImportAt || r.kind.IsFromHTML()
 	checkPackage := isPackagePath
 
 	if checkRelative {
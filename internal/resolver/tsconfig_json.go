@@ -39,8 +39,12 @@ type TSConfigJSON struct {
 	JSXFragmentFactory             []string
 	TSTarget                       *config.TSTarget
 	UseDefineForClassFields        config.MaybeBool
+	ExperimentalDecorators         config.MaybeBool
+	EmitDecoratorMetadata          config.MaybeBool
 	PreserveImportsNotUsedAsValues bool
 	PreserveValueImports           bool
+	VerbatimModuleSyntax           bool
+	IsolatedModules                bool
 }
 
 func ParseTSConfigJSON(
@@ -114,6 +118,28 @@ func ParseTSConfigJSON(
 			}
 		}
 
+		// Parse "experimentalDecorators"
+		if valueJSON, _, ok := getProperty(compilerOptionsJSON, "experimentalDecorators"); ok {
+			if value, ok := getBool(valueJSON); ok {
+				if value {
+					result.ExperimentalDecorators = config.True
+				} else {
+					result.ExperimentalDecorators = config.False
+				}
+			}
+		}
+
+		// Parse "emitDecoratorMetadata"
+		if valueJSON, _, ok := getProperty(compilerOptionsJSON, "emitDecoratorMetadata"); ok {
+			if value, ok := getBool(valueJSON); ok {
+				if value {
+					result.EmitDecoratorMetadata = config.True
+				} else {
+					result.EmitDecoratorMetadata = config.False
+				}
+			}
+		}
+
 		// Parse "target"
 		if valueJSON, _, ok := getProperty(compilerOptionsJSON, "target"); ok {
 			if value, ok := getString(valueJSON); ok {
@@ -182,6 +208,20 @@ func ParseTSConfigJSON(
 			}
 		}
 
+		// Parse "verbatimModuleSyntax"
+		if valueJSON, _, ok := getProperty(compilerOptionsJSON, "verbatimModuleSyntax"); ok {
+			if value, ok := getBool(valueJSON); ok {
+				result.VerbatimModuleSyntax = value
+			}
+		}
+
+		// Parse "isolatedModules"
+		if valueJSON, _, ok := getProperty(compilerOptionsJSON, "isolatedModules"); ok {
+			if value, ok := getBool(valueJSON); ok {
+				result.IsolatedModules = value
+			}
+		}
+
 		// Parse "paths"
 		if valueJSON, _, ok := getProperty(compilerOptionsJSON, "paths"); ok {
 			if paths, ok := valueJSON.Data.(*js_ast.EObject); ok {
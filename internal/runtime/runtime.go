@@ -61,13 +61,10 @@ func code(isES6 bool) string {
 	//   __spreadArray
 	//   __spreadArrays
 	//   __values
-	//
-	// Note: The "__objRest" function has a for-of loop which requires ES6, but
-	// transforming destructuring to ES5 isn't even supported so it's ok.
 	text := `
 		var __create = Object.create
 		var __freeze = Object.freeze
-		var __defProp = Object.defineProperty
+		export var __defProp = Object.defineProperty
 		var __defProps = Object.defineProperties
 		var __getOwnPropDesc = Object.getOwnPropertyDescriptor // Note: can return "undefined" due to a Safari bug
 		var __getOwnPropDescs = Object.getOwnPropertyDescriptors
@@ -78,9 +75,15 @@ func code(isES6 bool) string {
 		var __propIsEnum = Object.prototype.propertyIsEnumerable
 		var __reflectGet = Reflect.get
 		var __reflectSet = Reflect.set
+		var __setProtoOf = Object.setPrototypeOf || ((obj, proto) => (obj.__proto__ = proto, obj))
 
 		export var __pow = Math.pow
 
+		// Regular expression literals that use syntax the target environment
+		// doesn't support would be a syntax error, so they are constructed at
+		// run-time instead where a polyfill for "RegExp" can handle them
+		export var __regExp = (pattern, flags) => new RegExp(pattern, flags)
+
 		var __defNormalProp = (obj, key, value) => key in obj
 			? __defProp(obj, key, {enumerable: true, configurable: true, writable: true, value})
 			: obj[key] = value
@@ -164,6 +167,148 @@ func code(isES6 bool) string {
 			return target
 		}
 
+		// For lowering array destructuring patterns. This reads up to "n" values
+		// (or all values if "n" is undefined) from an iterable into an array. It
+		// falls back to array-like objects if iterators don't exist.
+		export var __readArray = (value, n) => {
+			var array = [], iter, step
+			if (value == null || typeof Symbol === 'function' && typeof (iter = value[Symbol.iterator]) !== 'function')
+				throw TypeError(value + ' is not iterable')
+			if (!iter)
+				return array.slice.call(value, 0, n)
+			for (iter = iter.call(value); (n === void 0 || array.length < n) && !(step = iter.next()).done;)
+				array.push(step.value)
+			if ((!step || !step.done) && iter.return)
+				iter.return()
+			return array
+		}
+
+		// For lowering empty destructuring patterns, which must still throw for
+		// null and undefined even though no properties are read
+		export var __requireObjectCoercible = value => {
+			if (value == null)
+				throw TypeError('Cannot destructure ' + value)
+			return value
+		}
+
+		// For lowering "yield*" and for-of loops. This falls back to array-like objects and to
+		// objects with a "next" method if iterators don't exist.
+		export var __getIterator = (value) => {
+			var iter, i = 0
+			if (value == null || typeof Symbol === 'function' && typeof (iter = value[Symbol.iterator]) !== 'function')
+				throw TypeError(value + ' is not iterable')
+			return iter ? iter.call(value) : typeof value.next === 'function' ? value : {
+				next: () => ({ value: value[i], done: i++ >= value.length }),
+			}
+		}
+
+		// For lowering generator functions. The body is a state machine that
+		// returns one of these instructions each time it's called:
+		//
+		//   [0, label]         Jump to a label, running "finally" blocks on the way
+		//   [1, value]         Return from the generator function
+		//   [2, value, label]  Yield a value and resume at a label
+		//   [3, value, label]  Yield each value from an iterable and resume at a label
+		//   [4]                Continue with the instruction that ran a "finally" block
+		//
+		// The instructions [5, value] and [6, error] are also used internally to
+		// resume the state machine and to throw an error. Each entry in "trys" is
+		// "[start, catch, finally, end, pending]" for an active "try" statement.
+		export var __gen = (self, body) => {
+			var state = 0 // 0 = suspended, 1 = running, 2 = done
+			var delegate, sent, t, method, result
+			var ctx = { label: 0, trys: [], sent: () => sent }
+			var step = (op) => {
+				if (state === 1)
+					throw TypeError('Generator is already running')
+				if (state === 2) {
+					if (op[0] === 6) throw op[1]
+					return { value: op[0] === 1 ? op[1] : void 0, done: true }
+				}
+				state = 1
+				try {
+					for (var trys = ctx.trys; ;) {
+						// Forward to the iterable from "yield*" until it's done
+						if (delegate) {
+							try {
+								method = delegate[op[0] === 5 ? 'next' : op[0] === 6 ? 'throw' : 'return']
+								if (method) {
+									result = method.call(delegate, op[1])
+									if (Object(result) !== result) throw TypeError('Iterator result ' + result + ' is not an object')
+									if (!result.done) return result
+									op = [op[0] === 1 ? 1 : 5, result.value]
+								} else if (op[0] === 6) {
+									if (method = delegate.return) method.call(delegate)
+									op = [6, TypeError('The iterator does not provide a "throw" method')]
+								}
+							} catch (e) {
+								op = [6, e]
+							}
+							delegate = 0
+						}
+
+						t = trys[trys.length - 1]
+						if (op[0] === 5) {
+							sent = op[1]
+							try {
+								op = body.call(self, ctx)
+							} catch (e) {
+								op = [6, e]
+							}
+						} else if (op[0] === 2) {
+							ctx.label = op[2]
+							return { value: op[1], done: false }
+						} else if (op[0] === 3) {
+							ctx.label = op[2]
+							try {
+								delegate = __getIterator(op[1])
+								op = [5]
+							} catch (e) {
+								op = [6, e]
+							}
+						} else if (op[0] === 4) {
+							op = t[4]
+							trys.pop()
+						}
+
+						// Jumps, returns, and errors may leave the innermost "try" statement
+						else if (!t) {
+							if (op[0] === 0) {
+								ctx.label = op[1]
+								op = [5]
+							} else {
+								state = 2
+								if (op[0] === 6) throw op[1]
+								return { value: op[1], done: true }
+							}
+						} else if (op[0] === 0 && op[1] >= t[0] && op[1] < t[3]) {
+							ctx.label = op[1]
+							op = [5]
+						} else if (op[0] === 6 && t[1] !== void 0 && ctx.label < t[1]) {
+							ctx.label = t[1]
+							op = [5, op[1]]
+						} else if (t[2] !== void 0 && ctx.label < t[2]) {
+							t[4] = op
+							ctx.label = t[2]
+							op = [5]
+						} else {
+							trys.pop()
+						}
+					}
+				} finally {
+					if (state === 1) state = 0
+				}
+			}
+			var generator = {
+				next: (value) => step([5, value]),
+				throw: (value) => step([6, value]),
+				return: (value) => step([1, value]),
+			}
+			if (typeof Symbol === 'function' && Symbol.iterator)
+				generator[Symbol.iterator] = () => generator
+			return generator
+		}
+
 		// This is for lazily-initialized ESM code. This has two implementations, a
 		// compact one for minified code and a verbose one that generates friendly
 		// names in V8's profiler and in stack traces.
@@ -243,6 +388,53 @@ func code(isES6 bool) string {
 			return result
 		}
 		export var __decorateParam = (index, decorator) => (target, key) => decorator(target, key, index)
+		export var __metadata = (key, value) => {
+			if (typeof Reflect === 'object' && typeof Reflect.metadata === 'function') return Reflect.metadata(key, value)
+		}
+
+		// For standard decorators
+		// - flags & 7: 0 = class, 1 = method, 2 = getter, 3 = setter, 4 = accessor, 5 = field
+		// - flags & 8: static
+		// - flags & 16: private
+		// - flags >> 5: index of the initializers for fields and accessors
+		var __typeError = msg => { throw TypeError(msg) }
+		var __decoratorStrings = ['class', 'method', 'getter', 'setter', 'accessor', 'field', 'value', 'get', 'set']
+		var __expectFn = fn => fn !== void 0 && typeof fn !== 'function' ? __typeError('Function expected') : fn
+		var __decoratorContext = (kind, name, done, metadata, fns) => ({
+			kind: __decoratorStrings[kind],
+			name,
+			metadata,
+			addInitializer: fn => done._ ? __typeError('Already initialized') : fns.push(__expectFn(fn || null)),
+		})
+		export var __decoratorStart = target => [__create(__getProtoOf(target)[__knownSymbol('metadata')] || null)]
+		export var __decoratorMetadata = (array, target) => __defNormalProp(target, __knownSymbol('metadata'), array[0])
+		export var __runInitializers = (array, index, self, value) => {
+			for (var i = 0, fns = array[index] || [], n = fns.length; i < n; i++)
+				index & 1 ? fns[i].call(self) : value = fns[i].call(self, value)
+			return value
+		}
+		export var __decorateElement = (array, flags, name, decorators, target, extra) => {
+			var fn, it, done, ctx, access, k = flags & 7, s = !!(flags & 8), p = !!(flags & 16)
+			var j = k > 4 ? (flags >> 5) + 1 : k ? s ? 3 : 5 : 1, key = __decoratorStrings[(k || 1) + 5]
+			var initializers = k > 3 && (array[flags >> 5] || (array[flags >> 5] = []))
+			var extraInitializers = array[j] || (array[j] = [])
+			var desc = k > 4 ? {} : !k ? { value: target } : !p ? __getOwnPropDesc(s ? target : target.prototype, name) :
+				k > 3 ? extra : (desc = {}, desc[key] = fn = extra, desc)
+			for (var i = decorators.length - 1; i >= 0; i--) {
+				ctx = __decoratorContext(k, name, done = {}, array[0], extraInitializers)
+				if (k) {
+					ctx.static = s, ctx.private = p, access = ctx.access = { has: p ? x => __privateIn(target, x) : x => name in x }
+					if (k ^ 3) access.get = p ? x => (k ^ 1 ? __privateGet : __privateMethod)(x, target, k ^ 4 ? fn : desc.get) : x => x[name]
+					if (k > 2) access.set = p ? (x, y) => __privateSet(x, target, y, k ^ 4 ? fn : desc.set) : (x, y) => x[name] = y
+				}
+				it = (0, decorators[i])(k > 3 ? k > 4 ? void 0 : { get: desc.get, set: desc.set } : desc[key], ctx), done._ = 1
+				if (k ^ 4 || it === void 0) __expectFn(it) && (k > 4 ? initializers.unshift(it) : desc[key] = it)
+				else if (typeof it !== 'object' || it === null) __typeError('Object expected')
+				else __expectFn(it.get) && (desc.get = it.get), __expectFn(it.set) && (desc.set = it.set), __expectFn(it.init) && initializers.unshift(it.init)
+			}
+			if (k && k < 5 && !p) __defProp(s ? target : target.prototype, name, desc)
+			return k ^ 4 ? desc[key] : desc
+		}
 
 		// For class members
 		export var __publicField = (obj, key, value) => {
@@ -296,6 +488,54 @@ func code(isES6 bool) string {
 			}
 		}
 
+		// For lowering classes to ES5 constructor functions. Constructors of
+		// derived classes call "__callSuper" instead of "super()" and then use
+		// the returned object as "this". This uses "Reflect.construct" when it's
+		// available so that subclassing built-in classes such as "Error" and
+		// "Array" works. Methods are defined with "__defMethod" so that they
+		// aren't enumerable.
+		export var __inherit = (child, parent) => {
+			if (typeof parent !== 'function' && parent !== null)
+				throw TypeError('Class extends value ' + parent + ' is not a constructor or null')
+			child.prototype = __create(parent && parent.prototype, {
+				constructor: { value: child, writable: true, configurable: true },
+			})
+			if (parent) __setProtoOf(child, parent)
+		}
+		export var __defMethod = (obj, key, value) => __defProp(obj, key, { value, writable: true, configurable: true })
+		export var __classCallCheck = (obj, ctor) => {
+			if (!(obj instanceof ctor))
+				throw TypeError('Cannot call a class as a function')
+		}
+		export var __callSuper = (obj, parent, args) => {
+			if (typeof Reflect === 'object' && Reflect.construct)
+				return Reflect.construct(parent, args, __getProtoOf(obj).constructor)
+			var result = parent.apply(obj, args)
+			return result !== null && (typeof result === 'object' || typeof result === 'function') ? result : obj
+		}
+		var __superLookup = (home, member) => {
+			for (var obj = __getProtoOf(home), desc; obj; obj = __getProtoOf(obj))
+				if (desc = __getOwnPropDesc(obj, member)) return desc
+		}
+		export var __superPropGet = (home, obj, member, desc) =>
+			(desc = __superLookup(home, member)) ? desc.get ? desc.get.call(obj) : desc.value : void 0
+		export var __superPropSet = (home, obj, member, value, desc) =>
+			((desc = __superLookup(home, member)) && desc.set ? desc.set.call(obj, value) : obj[member] = value, value)
+		export var __superPropWrapper = (home, obj, member) => {
+			return {
+				set _(value) { __superPropSet(home, obj, member, value) },
+				get _() { return __superPropGet(home, obj, member) },
+			}
+		}
+
+		// For lowering "let" and "const" to "var"
+		export var __earlyAccess = (name) => {
+			throw ReferenceError('Cannot access "' + name + '" before initialization')
+		}
+		export var __constAssign = (name) => {
+			throw TypeError('Assignment to constant variable "' + name + '"')
+		}
+
 		// For lowering tagged template literals
 		export var __template = (cooked, raw) => __freeze(__defProp(cooked, 'raw', { value: __freeze(raw || cooked.slice()) }))
 
@@ -321,6 +561,144 @@ func code(isES6 bool) string {
 			})
 		}
 
+		// These help for lowering async generator functions and "for await" loops.
+		// An async generator becomes a generator where "await x" is represented
+		// by "yield new __genAwait(x)" and "yield* x" by "yield* __yieldStar(x)".
+		// Symbols that are missing in older engines (e.g. "Symbol.asyncIterator"
+		// in node 8) are looked up in the global symbol registry instead.
+		var __knownSymbol = (name, symbol) => typeof Symbol !== 'function' ? '@@' + name :
+			(symbol = Symbol[name]) ? symbol : Symbol.for('Symbol.' + name)
+		export var __genAwait = function (value, isYieldStar) {
+			this[0] = value
+			this[1] = isYieldStar
+		}
+		export var __asyncGen = (__this, __arguments, generator) => {
+			var queue = [], it = {}
+			var settle = (ok, value) => {
+				queue.shift()[ok ? 2 : 3](value)
+				if (queue.length) start(queue[0][0], queue[0][1])
+			}
+			var start = (k, value) => {
+				// The value passed to "return()" is awaited before resuming
+				if (k === 'return') Promise.resolve(value).then(x => resume(k, k, x), e => resume(k, 'throw', e))
+				else resume(k, k, value)
+			}
+			var resume = (k, method, value) => {
+				var result, isAwait
+				try {
+					result = generator[method](value)
+					value = result.value
+					isAwait = value instanceof __genAwait
+				} catch (e) {
+					return settle(0, e)
+				}
+				if (isAwait) Promise.resolve(value[0]).then(
+					x => resume(k, value[1] && k === 'return' ? k : 'next', x),
+					e => resume(k, 'throw', e))
+				else if (result.done) Promise.resolve(value).then(x => settle(1, { value: x, done: true }), e => settle(0, e))
+				else Promise.resolve(value).then(x => settle(1, { value: x, done: false }), e => resume(k, 'throw', e))
+			}
+			var method = k => it[k] = value => new Promise((resolve, reject) => {
+				if (queue.push([k, value, resolve, reject]) < 2) start(k, value)
+			})
+			generator = generator.apply(__this, __arguments)
+			method('next')
+			method('throw')
+			method('return')
+			it[__knownSymbol('asyncIterator')] = () => it
+			return it
+		}
+		export var __yieldStar = value => {
+			var obj = value[__knownSymbol('asyncIterator')], isAwait = false, it = {}, method
+			if (obj == null) {
+				obj = __getIterator(value)
+				method = k => it[k] = x => obj[k](x)
+			} else {
+				// Alternate between awaiting the result of the async iterator and
+				// passing that result through to the enclosing "yield*"
+				obj = obj.call(value)
+				method = k => it[k] = x => {
+					if (isAwait) {
+						isAwait = false
+						if (k === 'throw') throw x
+						return x
+					}
+					isAwait = true
+					return {
+						done: false,
+						value: new __genAwait(new Promise(resolve => {
+							var result = obj[k](x)
+							if (Object(result) !== result) throw TypeError('Iterator result ' + result + ' is not an object')
+							resolve(result)
+						}), 1),
+					}
+				}
+			}
+			method('next')
+			if ('throw' in obj) method('throw')
+			else it.throw = x => { throw x }
+			if ('return' in obj) method('return')
+			it[__knownSymbol('iterator')] = () => it
+			return it
+		}
+		export var __forAwait = obj => {
+			var iter = obj[__knownSymbol('asyncIterator')], it = {}
+			if (iter) return iter.call(obj)
+			var method = k => it[k] = x => new Promise((resolve, reject) => {
+				var result = iter[k](x), done = result.done
+				Promise.resolve(result.value).then(value => resolve({ value, done }), reject)
+			})
+			iter = __getIterator(obj)
+			method('next')
+			if (iter.return) method('return')
+			return it
+		}
+
+		// These help for lowering "using" and "await using" declarations. Each
+		// resource is pushed onto a stack along with its disposal method, and the
+		// stack is unwound in reverse order when the enclosing block is exited.
+		// Errors thrown during disposal are combined using "SuppressedError".
+		export var __using = (stack, value, async) => {
+			if (value != null) {
+				if (typeof value !== 'object' && typeof value !== 'function') __typeError('Object expected')
+				var dispose, inner
+				if (async) dispose = value[__knownSymbol('asyncDispose')]
+				if (dispose === void 0) {
+					dispose = value[__knownSymbol('dispose')]
+					if (async) inner = dispose
+				}
+				if (typeof dispose !== 'function') __typeError('Object not disposable')
+				if (inner) dispose = function () {
+					try {
+						inner.call(this)
+					} catch (e) {
+						return Promise.reject(e)
+					}
+				}
+				stack.push([async, dispose, value])
+			} else if (async) {
+				stack.push([async])
+			}
+			return value
+		}
+		export var __callDispose = (stack, error, hasError) => {
+			var E = typeof SuppressedError === 'function' ? SuppressedError :
+				function (e, s, m, _) { return _ = Error(m), _.name = 'SuppressedError', _.error = e, _.suppressed = s, _ }
+			var fail = e => error = hasError ? new E(e, error, 'An error was suppressed during disposal') : (hasError = true, e)
+			var next = it => {
+				while (it = stack.pop()) {
+					try {
+						var result = it[1] && it[1].call(it[2])
+						if (it[0]) return Promise.resolve(result).then(next, e => (fail(e), next()))
+					} catch (e) {
+						fail(e)
+					}
+				}
+				if (hasError) throw error
+			}
+			return next()
+		}
+
 		// This is for the "binary" loader (custom code is ~2x faster than "atob")
 		export var __toBinaryNode = base64 => new Uint8Array(Buffer.from(base64, 'base64'))
 		export var __toBinary = /* @__PURE__ */ (() => {
@@ -110,6 +110,9 @@ function pushCommonFlags(flags: string[], options: CommonOptions, keys: OptionKe
   let charset = getFlag(options, keys, 'charset', mustBeString);
   let treeShaking = getFlag(options, keys, 'treeShaking', mustBeBoolean);
   let ignoreAnnotations = getFlag(options, keys, 'ignoreAnnotations', mustBeBoolean);
+  let cssDirection = getFlag(options, keys, 'cssDirection', mustBeString);
+  let cssRebaseURLs = getFlag(options, keys, 'cssRebaseURLs', mustBeBoolean);
+  let assumeIterablesAreArrays = getFlag(options, keys, 'assumeIterablesAreArrays', mustBeBoolean);
   let jsx = getFlag(options, keys, 'jsx', mustBeString);
   let jsxFactory = getFlag(options, keys, 'jsxFactory', mustBeString);
   let jsxFragment = getFlag(options, keys, 'jsxFragment', mustBeString);
@@ -134,6 +137,9 @@ function pushCommonFlags(flags: string[], options: CommonOptions, keys: OptionKe
   if (charset) flags.push(`--charset=${charset}`);
   if (treeShaking !== void 0) flags.push(`--tree-shaking=${treeShaking}`);
   if (ignoreAnnotations) flags.push(`--ignore-annotations`);
+  if (cssDirection) flags.push(`--css-direction=${cssDirection}`);
+  if (cssRebaseURLs) flags.push(`--css-rebase-urls`);
+  if (assumeIterablesAreArrays) flags.push(`--assume-iterables-are-arrays`);
 
   if (jsx) flags.push(`--jsx=${jsx}`);
   if (jsxFactory) flags.push(`--jsx-factory=${jsxFactory}`);
@@ -784,6 +790,7 @@ export function createChannel(streamIn: StreamIn): StreamOut {
                 resolveDir: request.resolveDir,
                 kind: request.kind,
                 pluginData: stash.load(request.pluginData),
+                with: request.with,
               });
 
               if (result != null) {
@@ -830,6 +837,7 @@ export function createChannel(streamIn: StreamIn): StreamOut {
                 path: request.path,
                 namespace: request.namespace,
                 pluginData: stash.load(request.pluginData),
+                with: request.with,
               });
 
               if (result != null) {
@@ -157,6 +157,7 @@ export interface OnResolveRequest {
   resolveDir: string;
   kind: types.ImportKind;
   pluginData: number;
+  with: Record<string, string>;
 }
 
 export interface OnResolveResponse {
@@ -183,6 +184,7 @@ export interface OnLoadRequest {
   path: string;
   namespace: string;
   pluginData: number;
+  with: Record<string, string>;
 }
 
 export interface OnLoadResponse {
@@ -1,6 +1,6 @@
 export type Platform = 'browser' | 'node' | 'neutral';
 export type Format = 'iife' | 'cjs' | 'esm';
-export type Loader = 'js' | 'jsx' | 'ts' | 'tsx' | 'css' | 'json' | 'text' | 'base64' | 'file' | 'dataurl' | 'binary' | 'default';
+export type Loader = 'js' | 'jsx' | 'ts' | 'tsx' | 'flow' | 'css' | 'local-css' | 'html' | 'json' | 'text' | 'base64' | 'file' | 'dataurl' | 'binary' | 'default';
 export type LogLevel = 'verbose' | 'debug' | 'info' | 'warning' | 'error' | 'silent';
 export type Charset = 'ascii' | 'utf8';
 
@@ -35,6 +35,12 @@ interface CommonOptions {
   treeShaking?: boolean;
   /** Documentation: https://esbuild.github.io/api/#ignore-annotations */
   ignoreAnnotations?: boolean;
+  /** Documentation: https://esbuild.github.io/api/#css-direction */
+  cssDirection?: 'ltr' | 'rtl';
+  /** Documentation: https://esbuild.github.io/api/#css-rebase-urls */
+  cssRebaseURLs?: boolean;
+  /** Documentation: https://esbuild.github.io/api/#assume-iterables-are-arrays */
+  assumeIterablesAreArrays?: boolean;
 
   /** Documentation: https://esbuild.github.io/api/#jsx */
   jsx?: 'transform' | 'preserve';
@@ -302,6 +308,7 @@ export interface OnResolveArgs {
   resolveDir: string;
   kind: ImportKind;
   pluginData: any;
+  with: Record<string, string>;
 }
 
 export type ImportKind =
@@ -316,6 +323,12 @@ export type ImportKind =
   // CSS
   | 'import-rule'
   | 'url-token'
+  | 'composes-from'
+
+  // HTML
+  | 'html-script'
+  | 'html-stylesheet'
+  | 'html-url'
 
 export interface OnResolveResult {
   pluginName?: string;
@@ -342,6 +355,7 @@ export interface OnLoadArgs {
   path: string;
   namespace: string;
   pluginData: any;
+  with: Record<string, string>;
 }
 
 export interface OnLoadResult {
@@ -143,6 +143,9 @@ const (
 	LoaderBinary
 	LoaderCSS
 	LoaderDefault
+	LoaderHTML
+	LoaderLocalCSS
+	LoaderFlow
 )
 
 type Platform uint8
@@ -231,6 +234,13 @@ const (
 	CharsetUTF8
 )
 
+type CSSDirection uint8
+
+const (
+	CSSDirectionLTR CSSDirection = iota
+	CSSDirectionRTL
+)
+
 type TreeShaking uint8
 
 const (
@@ -254,6 +264,8 @@ type BuildOptions struct {
 	Target  Target   // Documentation: https://esbuild.github.io/api/#target
 	Engines []Engine // Documentation: https://esbuild.github.io/api/#target
 
+	AssumeIterablesAreArrays bool // Documentation: https://esbuild.github.io/api/#assume-iterables-are-arrays
+
 	MinifyWhitespace  bool          // Documentation: https://esbuild.github.io/api/#minify
 	MinifyIdentifiers bool          // Documentation: https://esbuild.github.io/api/#minify
 	MinifySyntax      bool          // Documentation: https://esbuild.github.io/api/#minify
@@ -261,6 +273,8 @@ type BuildOptions struct {
 	TreeShaking       TreeShaking   // Documentation: https://esbuild.github.io/api/#tree-shaking
 	IgnoreAnnotations bool          // Documentation: https://esbuild.github.io/api/#ignore-annotations
 	LegalComments     LegalComments // Documentation: https://esbuild.github.io/api/#legal-comments
+	CSSDirection      CSSDirection  // Documentation: https://esbuild.github.io/api/#css-direction
+	CSSRebaseURLs     bool          // Documentation: https://esbuild.github.io/api/#css-rebase-urls
 
 	JSXMode     JSXMode // Documentation: https://esbuild.github.io/api/#jsx-mode
 	JSXFactory  string  // Documentation: https://esbuild.github.io/api/#jsx-factory
@@ -362,6 +376,8 @@ type TransformOptions struct {
 	Target  Target   // Documentation: https://esbuild.github.io/api/#target
 	Engines []Engine // Documentation: https://esbuild.github.io/api/#target
 
+	AssumeIterablesAreArrays bool // Documentation: https://esbuild.github.io/api/#assume-iterables-are-arrays
+
 	Format     Format // Documentation: https://esbuild.github.io/api/#format
 	GlobalName string // Documentation: https://esbuild.github.io/api/#global-name
 
@@ -372,6 +388,8 @@ type TransformOptions struct {
 	TreeShaking       TreeShaking   // Documentation: https://esbuild.github.io/api/#tree-shaking
 	IgnoreAnnotations bool          // Documentation: https://esbuild.github.io/api/#ignore-annotations
 	LegalComments     LegalComments // Documentation: https://esbuild.github.io/api/#legal-comments
+	CSSDirection      CSSDirection  // Documentation: https://esbuild.github.io/api/#css-direction
+	CSSRebaseURLs     bool          // Documentation: https://esbuild.github.io/api/#css-rebase-urls
 
 	JSXMode     JSXMode // Documentation: https://esbuild.github.io/api/#jsx
 	JSXFactory  string  // Documentation: https://esbuild.github.io/api/#jsx-factory
@@ -474,6 +492,7 @@ type OnResolveArgs struct {
 	ResolveDir string
 	Kind       ResolveKind
 	PluginData interface{}
+	With       map[string]string
 }
 
 type OnResolveResult struct {
@@ -501,6 +520,7 @@ type OnLoadArgs struct {
 	Path       string
 	Namespace  string
 	PluginData interface{}
+	With       map[string]string
 }
 
 type OnLoadResult struct {
@@ -528,6 +548,10 @@ const (
 	ResolveJSRequireResolve
 	ResolveCSSImportRule
 	ResolveCSSURLToken
+	ResolveCSSComposesFrom
+	ResolveHTMLScript
+	ResolveHTMLStylesheet
+	ResolveHTMLURL
 )
 
 ////////////////////////////////////////////////////////////////////////////////
@@ -239,6 +239,8 @@ func validateLoader(value Loader) config.Loader {
 		return config.LoaderTS
 	case LoaderTSX:
 		return config.LoaderTSX
+	case LoaderFlow:
+		return config.LoaderFlow
 	case LoaderJSON:
 		return config.LoaderJSON
 	case LoaderText:
@@ -253,6 +255,10 @@ func validateLoader(value Loader) config.Loader {
 		return config.LoaderBinary
 	case LoaderCSS:
 		return config.LoaderCSS
+	case LoaderLocalCSS:
+		return config.LoaderLocalCSS
+	case LoaderHTML:
+		return config.LoaderHTML
 	case LoaderDefault:
 		return config.LoaderDefault
 	default:
@@ -281,9 +287,9 @@ func validateEngine(value EngineName) compat.Engine {
 
 var versionRegex = regexp.MustCompile(`^([0-9]+)(?:\.([0-9]+))?(?:\.([0-9]+))?$`)
 
-func validateFeatures(log logger.Log, target Target, engines []Engine) (config.TargetFromAPI, compat.JSFeature, compat.CSSFeature, string) {
+func validateFeatures(log logger.Log, target Target, engines []Engine) (config.TargetFromAPI, compat.JSFeature, compat.CSSFeature, *compat.CSSPrefixData, string) {
 	if target == DefaultTarget && len(engines) == 0 {
-		return config.TargetWasUnconfigured, 0, 0, ""
+		return config.TargetWasUnconfigured, 0, 0, nil, ""
 	}
 
 	constraints := make(map[compat.Engine][]int)
@@ -363,7 +369,8 @@ func validateFeatures(log logger.Log, target Target, engines []Engine) (config.T
 	sort.Strings(targets)
 	targetEnv := strings.Join(targets, ", ")
 
-	return targetFromAPI, compat.UnsupportedJSFeatures(constraints), compat.UnsupportedCSSFeatures(constraints), targetEnv
+	return targetFromAPI, compat.UnsupportedJSFeatures(constraints), compat.UnsupportedCSSFeatures(constraints),
+		compat.CSSPrefixDataForConstraints(constraints), targetEnv
 }
 
 func validateGlobalName(log logger.Log, text string) []string {
@@ -815,7 +822,7 @@ func rebuildImpl(
 		// This should already have been checked above
 		panic(err.Error())
 	}
-	targetFromAPI, jsFeatures, cssFeatures, targetEnv := validateFeatures(log, buildOpts.Target, buildOpts.Engines)
+	targetFromAPI, jsFeatures, cssFeatures, cssPrefixData, targetEnv := validateFeatures(log, buildOpts.Target, buildOpts.Engines)
 	outJS, outCSS := validateOutputExtensions(log, buildOpts.OutExtensions)
 	bannerJS, bannerCSS := validateBannerOrFooter(log, "banner", buildOpts.Banner)
 	footerJS, footerCSS := validateBannerOrFooter(log, "footer", buildOpts.Footer)
@@ -825,55 +832,59 @@ func rebuildImpl(
 		TargetFromAPI:          targetFromAPI,
 		UnsupportedJSFeatures:  jsFeatures,
 		UnsupportedCSSFeatures: cssFeatures,
+		CSSPrefixData:          cssPrefixData,
 		OriginalTargetEnv:      targetEnv,
 		JSX: config.JSXOptions{
 			Preserve: buildOpts.JSXMode == JSXModePreserve,
 			Factory:  validateJSXExpr(log, buildOpts.JSXFactory, "factory", js_parser.JSXFactory),
 			Fragment: validateJSXExpr(log, buildOpts.JSXFragment, "fragment", js_parser.JSXFragment),
 		},
-		Defines:               defines,
-		InjectedDefines:       injectedDefines,
-		Platform:              validatePlatform(buildOpts.Platform),
-		SourceMap:             validateSourceMap(buildOpts.Sourcemap),
-		LegalComments:         validateLegalComments(buildOpts.LegalComments, buildOpts.Bundle),
-		SourceRoot:            buildOpts.SourceRoot,
-		ExcludeSourcesContent: buildOpts.SourcesContent == SourcesContentExclude,
-		MangleSyntax:          buildOpts.MinifySyntax,
-		RemoveWhitespace:      buildOpts.MinifyWhitespace,
-		MinifyIdentifiers:     buildOpts.MinifyIdentifiers,
-		AllowOverwrite:        buildOpts.AllowOverwrite,
-		ASCIIOnly:             validateASCIIOnly(buildOpts.Charset),
-		IgnoreDCEAnnotations:  buildOpts.IgnoreAnnotations,
-		TreeShaking:           validateTreeShaking(buildOpts.TreeShaking, buildOpts.Bundle, buildOpts.Format),
-		GlobalName:            validateGlobalName(log, buildOpts.GlobalName),
-		CodeSplitting:         buildOpts.Splitting,
-		OutputFormat:          validateFormat(buildOpts.Format),
-		AbsOutputFile:         validatePath(log, realFS, buildOpts.Outfile, "outfile path"),
-		AbsOutputDir:          validatePath(log, realFS, buildOpts.Outdir, "outdir path"),
-		AbsOutputBase:         validatePath(log, realFS, buildOpts.Outbase, "outbase path"),
-		NeedsMetafile:         buildOpts.Metafile,
-		EntryPathTemplate:     validatePathTemplate(buildOpts.EntryNames),
-		ChunkPathTemplate:     validatePathTemplate(buildOpts.ChunkNames),
-		AssetPathTemplate:     validatePathTemplate(buildOpts.AssetNames),
-		OutputExtensionJS:     outJS,
-		OutputExtensionCSS:    outCSS,
-		ExtensionToLoader:     validateLoaders(log, buildOpts.Loader),
-		ExtensionOrder:        validateResolveExtensions(log, buildOpts.ResolveExtensions),
-		ExternalModules:       validateExternals(log, realFS, buildOpts.External),
-		TsConfigOverride:      validatePath(log, realFS, buildOpts.Tsconfig, "tsconfig path"),
-		MainFields:            buildOpts.MainFields,
-		Conditions:            append([]string{}, buildOpts.Conditions...),
-		PublicPath:            buildOpts.PublicPath,
-		KeepNames:             buildOpts.KeepNames,
-		InjectAbsPaths:        make([]string, len(buildOpts.Inject)),
-		AbsNodePaths:          make([]string, len(buildOpts.NodePaths)),
-		JSBanner:              bannerJS,
-		JSFooter:              footerJS,
-		CSSBanner:             bannerCSS,
-		CSSFooter:             footerCSS,
-		PreserveSymlinks:      buildOpts.PreserveSymlinks,
-		WatchMode:             buildOpts.Watch != nil,
-		Plugins:               plugins,
+		Defines:                  defines,
+		InjectedDefines:          injectedDefines,
+		Platform:                 validatePlatform(buildOpts.Platform),
+		SourceMap:                validateSourceMap(buildOpts.Sourcemap),
+		LegalComments:            validateLegalComments(buildOpts.LegalComments, buildOpts.Bundle),
+		CSSRightToLeft:           buildOpts.CSSDirection == CSSDirectionRTL,
+		CSSRebaseURLs:            buildOpts.CSSRebaseURLs,
+		AssumeIterablesAreArrays: buildOpts.AssumeIterablesAreArrays,
+		SourceRoot:               buildOpts.SourceRoot,
+		ExcludeSourcesContent:    buildOpts.SourcesContent == SourcesContentExclude,
+		MangleSyntax:             buildOpts.MinifySyntax,
+		RemoveWhitespace:         buildOpts.MinifyWhitespace,
+		MinifyIdentifiers:        buildOpts.MinifyIdentifiers,
+		AllowOverwrite:           buildOpts.AllowOverwrite,
+		ASCIIOnly:                validateASCIIOnly(buildOpts.Charset),
+		IgnoreDCEAnnotations:     buildOpts.IgnoreAnnotations,
+		TreeShaking:              validateTreeShaking(buildOpts.TreeShaking, buildOpts.Bundle, buildOpts.Format),
+		GlobalName:               validateGlobalName(log, buildOpts.GlobalName),
+		CodeSplitting:            buildOpts.Splitting,
+		OutputFormat:             validateFormat(buildOpts.Format),
+		AbsOutputFile:            validatePath(log, realFS, buildOpts.Outfile, "outfile path"),
+		AbsOutputDir:             validatePath(log, realFS, buildOpts.Outdir, "outdir path"),
+		AbsOutputBase:            validatePath(log, realFS, buildOpts.Outbase, "outbase path"),
+		NeedsMetafile:            buildOpts.Metafile,
+		EntryPathTemplate:        validatePathTemplate(buildOpts.EntryNames),
+		ChunkPathTemplate:        validatePathTemplate(buildOpts.ChunkNames),
+		AssetPathTemplate:        validatePathTemplate(buildOpts.AssetNames),
+		OutputExtensionJS:        outJS,
+		OutputExtensionCSS:       outCSS,
+		ExtensionToLoader:        validateLoaders(log, buildOpts.Loader),
+		ExtensionOrder:           validateResolveExtensions(log, buildOpts.ResolveExtensions),
+		ExternalModules:          validateExternals(log, realFS, buildOpts.External),
+		TsConfigOverride:         validatePath(log, realFS, buildOpts.Tsconfig, "tsconfig path"),
+		MainFields:               buildOpts.MainFields,
+		Conditions:               append([]string{}, buildOpts.Conditions...),
+		PublicPath:               buildOpts.PublicPath,
+		KeepNames:                buildOpts.KeepNames,
+		InjectAbsPaths:           make([]string, len(buildOpts.Inject)),
+		AbsNodePaths:             make([]string, len(buildOpts.NodePaths)),
+		JSBanner:                 bannerJS,
+		JSFooter:                 footerJS,
+		CSSBanner:                bannerCSS,
+		CSSFooter:                footerCSS,
+		PreserveSymlinks:         buildOpts.PreserveSymlinks,
+		WatchMode:                buildOpts.Watch != nil,
+		Plugins:                  plugins,
 	}
 	if options.MainFields != nil {
 		options.MainFields = append([]string{}, options.MainFields...)
@@ -1264,7 +1275,10 @@ func transformImpl(input string, transformOpts TransformOptions) TransformResult
 
 	// Settings from the user come first
 	unusedImportsTS := config.UnusedImportsRemoveStmt
+	isolatedModules := false
 	useDefineForClassFieldsTS := config.Unspecified
+	experimentalDecoratorsTS := config.Unspecified
+	emitDecoratorMetadataTS := config.Unspecified
 	jsx := config.JSXOptions{
 		Preserve: transformOpts.JSXMode == JSXModePreserve,
 		Factory:  validateJSXExpr(log, transformOpts.JSXFactory, "factory", js_parser.JSXFactory),
@@ -1290,14 +1304,28 @@ func transformImpl(input string, transformOpts TransformOptions) TransformResult
 			if result.UseDefineForClassFields != config.Unspecified {
 				useDefineForClassFieldsTS = result.UseDefineForClassFields
 			}
+			if result.ExperimentalDecorators != config.Unspecified {
+				experimentalDecoratorsTS = result.ExperimentalDecorators
+			}
+			if result.EmitDecoratorMetadata != config.Unspecified {
+				emitDecoratorMetadataTS = result.EmitDecoratorMetadata
+			}
 			unusedImportsTS = config.UnusedImportsFromTsconfigValues(
 				result.PreserveImportsNotUsedAsValues,
 				result.PreserveValueImports,
+				result.VerbatimModuleSyntax,
 			)
+			isolatedModules = result.IsolatedModules || result.VerbatimModuleSyntax
 			tsTarget = result.TSTarget
 		}
 	}
 
+	// Relative URLs can only be rebased if the original file path is known
+	if transformOpts.CSSRebaseURLs && transformOpts.Sourcefile == "" {
+		log.Add(logger.Error, nil, logger.Range{},
+			"Must use \"sourcefile\" with \"css-rebase-urls\" to set the original file path")
+	}
+
 	// Apply default values
 	if transformOpts.Sourcefile == "" {
 		transformOpts.Sourcefile = "<stdin>"
@@ -1307,40 +1335,47 @@ func transformImpl(input string, transformOpts TransformOptions) TransformResult
 	}
 
 	// Convert and validate the transformOpts
-	targetFromAPI, jsFeatures, cssFeatures, targetEnv := validateFeatures(log, transformOpts.Target, transformOpts.Engines)
+	targetFromAPI, jsFeatures, cssFeatures, cssPrefixData, targetEnv := validateFeatures(log, transformOpts.Target, transformOpts.Engines)
 	defines, injectedDefines := validateDefines(log, transformOpts.Define, transformOpts.Pure, PlatformNeutral, false /* minify */)
 	options := config.Options{
-		TargetFromAPI:           targetFromAPI,
-		UnsupportedJSFeatures:   jsFeatures,
-		UnsupportedCSSFeatures:  cssFeatures,
-		OriginalTargetEnv:       targetEnv,
-		TSTarget:                tsTarget,
-		JSX:                     jsx,
-		Defines:                 defines,
-		InjectedDefines:         injectedDefines,
-		SourceMap:               validateSourceMap(transformOpts.Sourcemap),
-		LegalComments:           validateLegalComments(transformOpts.LegalComments, false /* bundle */),
-		SourceRoot:              transformOpts.SourceRoot,
-		ExcludeSourcesContent:   transformOpts.SourcesContent == SourcesContentExclude,
-		OutputFormat:            validateFormat(transformOpts.Format),
-		GlobalName:              validateGlobalName(log, transformOpts.GlobalName),
-		MangleSyntax:            transformOpts.MinifySyntax,
-		RemoveWhitespace:        transformOpts.MinifyWhitespace,
-		MinifyIdentifiers:       transformOpts.MinifyIdentifiers,
-		ASCIIOnly:               validateASCIIOnly(transformOpts.Charset),
-		IgnoreDCEAnnotations:    transformOpts.IgnoreAnnotations,
-		TreeShaking:             validateTreeShaking(transformOpts.TreeShaking, false /* bundle */, transformOpts.Format),
-		AbsOutputFile:           transformOpts.Sourcefile + "-out",
-		KeepNames:               transformOpts.KeepNames,
-		UseDefineForClassFields: useDefineForClassFieldsTS,
-		UnusedImportsTS:         unusedImportsTS,
+		TargetFromAPI:            targetFromAPI,
+		UnsupportedJSFeatures:    jsFeatures,
+		UnsupportedCSSFeatures:   cssFeatures,
+		CSSPrefixData:            cssPrefixData,
+		OriginalTargetEnv:        targetEnv,
+		TSTarget:                 tsTarget,
+		JSX:                      jsx,
+		Defines:                  defines,
+		InjectedDefines:          injectedDefines,
+		SourceMap:                validateSourceMap(transformOpts.Sourcemap),
+		LegalComments:            validateLegalComments(transformOpts.LegalComments, false /* bundle */),
+		CSSRightToLeft:           transformOpts.CSSDirection == CSSDirectionRTL,
+		CSSRebaseURLs:            transformOpts.CSSRebaseURLs,
+		AssumeIterablesAreArrays: transformOpts.AssumeIterablesAreArrays,
+		SourceRoot:               transformOpts.SourceRoot,
+		ExcludeSourcesContent:    transformOpts.SourcesContent == SourcesContentExclude,
+		OutputFormat:             validateFormat(transformOpts.Format),
+		GlobalName:               validateGlobalName(log, transformOpts.GlobalName),
+		MangleSyntax:             transformOpts.MinifySyntax,
+		RemoveWhitespace:         transformOpts.MinifyWhitespace,
+		MinifyIdentifiers:        transformOpts.MinifyIdentifiers,
+		ASCIIOnly:                validateASCIIOnly(transformOpts.Charset),
+		IgnoreDCEAnnotations:     transformOpts.IgnoreAnnotations,
+		TreeShaking:              validateTreeShaking(transformOpts.TreeShaking, false /* bundle */, transformOpts.Format),
+		AbsOutputFile:            transformOpts.Sourcefile + "-out",
+		KeepNames:                transformOpts.KeepNames,
+		UseDefineForClassFields:  useDefineForClassFieldsTS,
+		ExperimentalDecorators:   experimentalDecoratorsTS,
+		EmitDecoratorMetadata:    emitDecoratorMetadataTS,
+		UnusedImportsTS:          unusedImportsTS,
+		IsolatedModules:          isolatedModules,
 		Stdin: &config.StdinInfo{
 			Loader:     validateLoader(transformOpts.Loader),
 			Contents:   input,
 			SourceFile: transformOpts.Sourcefile,
 		},
 	}
-	if options.Stdin.Loader == config.LoaderCSS {
+	if options.Stdin.Loader.IsCSS() {
 		options.CSSBanner = transformOpts.Banner
 		options.CSSFooter = transformOpts.Footer
 	} else {
@@ -1473,6 +1508,14 @@ func (impl *pluginImpl) OnResolve(options OnResolveOptions, callback func(OnReso
 				kind = ResolveCSSImportRule
 			case ast.ImportURL:
 				kind = ResolveCSSURLToken
+			case ast.ImportComposesFrom:
+				kind = ResolveCSSComposesFrom
+			case ast.ImportHTMLScript:
+				kind = ResolveHTMLScript
+			case ast.ImportHTMLStylesheet:
+				kind = ResolveHTMLStylesheet
+			case ast.ImportHTMLURL:
+				kind = ResolveHTMLURL
 			default:
 				panic("Internal error")
 			}
@@ -1484,6 +1527,7 @@ func (impl *pluginImpl) OnResolve(options OnResolveOptions, callback func(OnReso
 				ResolveDir: args.ResolveDir,
 				Kind:       kind,
 				PluginData: args.PluginData,
+				With:       args.With.DecodeIntoMap(),
 			})
 			result.PluginName = response.PluginName
 			result.AbsWatchFiles = impl.validatePathsArray(response.WatchFiles, "watch file")
@@ -1527,6 +1571,7 @@ func (impl *pluginImpl) OnLoad(options OnLoadOptions, callback func(OnLoadArgs)
 				Path:       args.Path.Text,
 				Namespace:  args.Path.Namespace,
 				PluginData: args.PluginData,
+				With:       args.Path.ImportAttributes.DecodeIntoMap(),
 			})
 			result.PluginName = response.PluginName
 			result.AbsWatchFiles = impl.validatePathsArray(response.WatchFiles, "watch file")
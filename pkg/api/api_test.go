@@ -0,0 +1,29 @@
+package api
+
+import (
+	"testing"
+
+	"github.com/evanw/esbuild/internal/test"
+)
+
+// The numeric values of these constants are part of the public API since
+// they can be persisted by users. New loaders must be added after the last
+// existing one instead of being inserted in the middle.
+func TestLoaderValues(t *testing.T) {
+	test.AssertEqual(t, LoaderNone, Loader(0))
+	test.AssertEqual(t, LoaderJS, Loader(1))
+	test.AssertEqual(t, LoaderJSX, Loader(2))
+	test.AssertEqual(t, LoaderTS, Loader(3))
+	test.AssertEqual(t, LoaderTSX, Loader(4))
+	test.AssertEqual(t, LoaderJSON, Loader(5))
+	test.AssertEqual(t, LoaderText, Loader(6))
+	test.AssertEqual(t, LoaderBase64, Loader(7))
+	test.AssertEqual(t, LoaderDataURL, Loader(8))
+	test.AssertEqual(t, LoaderFile, Loader(9))
+	test.AssertEqual(t, LoaderBinary, Loader(10))
+	test.AssertEqual(t, LoaderCSS, Loader(11))
+	test.AssertEqual(t, LoaderDefault, Loader(12))
+	test.AssertEqual(t, LoaderHTML, Loader(13))
+	test.AssertEqual(t, LoaderLocalCSS, Loader(14))
+	test.AssertEqual(t, LoaderFlow, Loader(15))
+}
@@ -122,6 +122,40 @@ func parseOptionsImpl(
 				transformOpts.LegalComments = legalComments
 			}
 
+		case strings.HasPrefix(arg, "--css-direction="):
+			value := arg[len("--css-direction="):]
+			var cssDirection api.CSSDirection
+			switch value {
+			case "ltr":
+				cssDirection = api.CSSDirectionLTR
+			case "rtl":
+				cssDirection = api.CSSDirectionRTL
+			default:
+				return cli_helpers.MakeErrorWithNote(
+					fmt.Sprintf("Invalid value %q in %q", value, arg),
+					"Valid values are \"ltr\" or \"rtl\".",
+				), nil
+			}
+			if buildOpts != nil {
+				buildOpts.CSSDirection = cssDirection
+			} else {
+				transformOpts.CSSDirection = cssDirection
+			}
+
+		case arg == "--assume-iterables-are-arrays":
+			if buildOpts != nil {
+				buildOpts.AssumeIterablesAreArrays = true
+			} else {
+				transformOpts.AssumeIterablesAreArrays = true
+			}
+
+		case arg == "--css-rebase-urls":
+			if buildOpts != nil {
+				buildOpts.CSSRebaseURLs = true
+			} else {
+				transformOpts.CSSRebaseURLs = true
+			}
+
 		case strings.HasPrefix(arg, "--charset="):
 			var value *api.Charset
 			if buildOpts != nil {
@@ -585,24 +619,27 @@ func parseOptionsImpl(
 
 		default:
 			bare := map[string]bool{
-				"allow-overwrite":    true,
-				"bundle":             true,
-				"ignore-annotations": true,
-				"keep-names":         true,
-				"metafile":           true,
-				"minify-identifiers": true,
-				"minify-syntax":      true,
-				"minify-whitespace":  true,
-				"minify":             true,
-				"preserve-symlinks":  true,
-				"sourcemap":          true,
-				"splitting":          true,
-				"watch":              true,
+				"allow-overwrite":             true,
+				"assume-iterables-are-arrays": true,
+				"bundle":                      true,
+				"css-rebase-urls":             true,
+				"ignore-annotations":          true,
+				"keep-names":                  true,
+				"metafile":                    true,
+				"minify-identifiers":          true,
+				"minify-syntax":               true,
+				"minify-whitespace":           true,
+				"minify":                      true,
+				"preserve-symlinks":           true,
+				"sourcemap":                   true,
+				"splitting":                   true,
+				"watch":                       true,
 			}
 
 			equals := map[string]bool{
 				"legal-comments":     true,
 				"charset":            true,
+				"css-direction":      true,
 				"tree-shaking":       true,
 				"sourcemap":          true,
 				"source-root":        true,
@@ -139,6 +139,7 @@ mergeVersions('NewTarget', { es2015: true })
 mergeVersions('ObjectExtensions', { es2015: true })
 mergeVersions('RestArgument', { es2015: true })
 mergeVersions('TemplateLiteral', { es2015: true })
+mergeVersions('RegexpStickyAndUnicodeFlags', { es2015: true })
 mergeVersions('UnicodeEscapes', { es2015: true })
 
 // >ES6 features
@@ -153,11 +154,20 @@ mergeVersions('BigInt', { es2020: true })
 mergeVersions('ImportMeta', { es2020: true })
 mergeVersions('NullishCoalescing', { es2020: true })
 mergeVersions('OptionalChain', { es2020: true })
+mergeVersions('RegexpDotAllFlag', { es2018: true })
+mergeVersions('RegexpLookbehindAssertions', { es2018: true })
+mergeVersions('RegexpNamedCaptureGroups', { es2018: true })
+mergeVersions('RegexpUnicodePropertyEscapes', { es2018: true })
 mergeVersions('LogicalAssignment', { es2021: true })
+mergeVersions('RegexpMatchIndices', { es2022: true })
+mergeVersions('RegexpSetNotation', { es2024: true })
 mergeVersions('TopLevelAwait', {})
 mergeVersions('ArbitraryModuleNamespaceNames', {})
 mergeVersions('ImportAssertions', {})
+mergeVersions('ImportAttributes', { es2025: true })
 mergeVersions('ClassStaticBlocks', {})
+mergeVersions('Decorators', {})
+mergeVersions('Using', {})
 
 // Manually copied from https://caniuse.com/?search=export%20*%20as
 mergeVersions('ExportStarAs', {
@@ -244,6 +254,21 @@ mergeVersions('ImportAssertions', {
   // Not yet in Firefox: https://bugzilla.mozilla.org/show_bug.cgi?id=1668330
 })
 
+// Manually copied from https://developer.mozilla.org/en-US/docs/Web/JavaScript/Reference/Statements/import/with
+mergeVersions('ImportAttributes', {
+  chrome123: true,
+  edge123: true,
+  firefox138: true,
+  ios17_2: true,
+  safari17_2: true,
+})
+
+// From https://nodejs.org/api/esm.html#import-attributes
+versions.ImportAttributes.node = [
+  { start: [18, 20], end: [19] },
+  { start: [20, 10] },
+]
+
 mergeVersions('ClassStaticBlocks', {
   // From https://www.chromestatus.com/feature/6482797915013120
   chrome91: true,
@@ -255,6 +280,72 @@ mergeVersions('ClassStaticBlocks', {
   // Not yet in Firefox: https://bugzilla.mozilla.org/show_bug.cgi?id=1670018
 })
 
+// Manually copied from https://developer.mozilla.org/en-US/docs/Web/JavaScript/Reference/Global_Objects/RegExp#browser_compatibility
+mergeVersions('RegexpStickyAndUnicodeFlags', {
+  chrome50: true,
+  edge13: true,
+  firefox46: true,
+  ios12: true,
+  node6: true,
+  safari12: true,
+})
+mergeVersions('RegexpDotAllFlag', {
+  chrome62: true,
+  edge79: true,
+  firefox78: true,
+  ios11_3: true,
+  node8_10: true,
+  safari11_1: true,
+})
+mergeVersions('RegexpLookbehindAssertions', {
+  chrome62: true,
+  edge79: true,
+  firefox78: true,
+  ios16_4: true,
+  node8_10: true,
+  safari16_4: true,
+})
+mergeVersions('RegexpNamedCaptureGroups', {
+  chrome64: true,
+  edge79: true,
+  firefox78: true,
+  ios11_3: true,
+  node10: true,
+  safari11_1: true,
+})
+mergeVersions('RegexpUnicodePropertyEscapes', {
+  chrome64: true,
+  edge79: true,
+  firefox78: true,
+  ios11_3: true,
+  node10: true,
+  safari11_1: true,
+})
+mergeVersions('RegexpMatchIndices', {
+  chrome90: true,
+  edge90: true,
+  firefox88: true,
+  ios15: true,
+  node16: true,
+  safari15: true,
+})
+mergeVersions('RegexpSetNotation', {
+  chrome112: true,
+  edge112: true,
+  firefox116: true,
+  ios17: true,
+  node20: true,
+  safari17: true,
+})
+
+// Manually copied from https://developer.mozilla.org/en-US/docs/Web/JavaScript/Reference/Statements/using
+mergeVersions('Using', {
+  chrome134: true,
+  edge134: true,
+  firefox141: true,
+  node24: true,
+})
+
 for (const test of [...es5.tests, ...es6.tests, ...stage4.tests, ...stage1to3.tests]) {
   const feature = features[test.name]
   if (feature) {
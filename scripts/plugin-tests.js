@@ -1726,6 +1726,37 @@ let pluginTests = {
     assert.strictEqual(result.outputFiles[0].text, '// xyz:nested\nfoo();\n')
   },
 
+  async importAttributesResolveAndLoad({ esbuild }) {
+    const result = await esbuild.build({
+      entryPoints: ['entry'],
+      write: false,
+      bundle: true,
+      format: 'esm',
+      plugins: [{
+        name: 'plugin',
+        setup(build) {
+          build.onResolve({ filter: /.*/ }, args => {
+            if (args.path === 'entry') {
+              assert.deepStrictEqual(args.with, {})
+              return { path: 'entry', namespace: 'xyz' }
+            }
+            assert.deepStrictEqual(args.with, { type: 'json' })
+            return { path: 'nested', namespace: 'xyz' }
+          })
+          build.onLoad({ filter: /.*/ }, args => {
+            if (args.path === 'entry') {
+              assert.deepStrictEqual(args.with, {})
+              return { contents: 'import x from "nested" with { type: "json" }; foo(x)' };
+            }
+            assert.deepStrictEqual(args.with, { type: 'json' })
+            return { contents: '123', loader: 'json' };
+          })
+        },
+      }],
+    })
+    assert.strictEqual(result.outputFiles[0].text, '// xyz:nested with { type: "json" }\nvar nested_default = 123;\n\n// xyz:entry\nfoo(nested_default);\n')
+  },
+
   async pluginDataLoadToResolve({ esbuild }) {
     const theObject = {}
     const result = await esbuild.build({